@@ -137,7 +137,8 @@ class Block(object):
   def _resolve_global(self, writer, name):
     result = self.alloc_temp()
     writer.write_checked_call2(
-        result, 'πg.ResolveGlobal(πF, {})', self.root.intern(name))
+        result, '{}.ResolveGlobal(πF, {})', self.root.alloc_global_cache(),
+        self.root.intern(name))
     return result
 
 
@@ -152,8 +153,14 @@ class ModuleBlock(Block):
     self.filename = filename
     self.buffer = source.Buffer(src)
     self.strings = set()
+    self.global_cache_count = 0
     self.future_features = future_features
 
+  def alloc_global_cache(self):
+    """Return a new πg.GlobalCache for a single global name reference."""
+    self.global_cache_count += 1
+    return 'πCache[{}]'.format(self.global_cache_count - 1)
+
   def bind_var(self, writer, name, value):
     writer.write_checked_call1(
         'πF.Globals().SetItem(πF, {}.ToObject(), {})',
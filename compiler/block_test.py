@@ -86,6 +86,15 @@ class BlockTest(unittest.TestCase):
     self.assertRegexpMatches(self._ResolveName(keyword_block, 'case'),
                              r'CheckLocal\b.*µcase, "case"')
 
+  def testResolveNameGlobalCachePerSite(self):
+    module_block = _MakeModuleBlock()
+    func_block = block.FunctionBlock(module_block, 'func', {}, False)
+    self.assertRegexpMatches(self._ResolveName(module_block, 'foo'),
+                             r'πCache\[0\]\.ResolveGlobal\b.*foo')
+    self.assertRegexpMatches(self._ResolveName(func_block, 'foo'),
+                             r'πCache\[1\]\.ResolveGlobal\b.*foo')
+    self.assertEqual(module_block.global_cache_count, 2)
+
   def _ResolveName(self, b, name):
     writer = util.Writer()
     b.resolve_name(writer, name)
@@ -299,9 +299,10 @@ class ExprVisitor(algorithm.Visitor):
       if node.n < 0:
         expr_str = expr_str + '.Neg()'
     elif isinstance(node.n, float):
-      expr_str = 'NewFloat({})'.format(node.n)
+      expr_str = 'NewFloat({!r})'.format(node.n)
     elif isinstance(node.n, complex):
-      expr_str = 'NewComplex(complex({}, {}))'.format(node.n.real, node.n.imag)
+      expr_str = 'NewComplex(complex({!r}, {!r}))'.format(
+          node.n.real, node.n.imag)
     else:
       msg = 'number type not yet implemented: ' + type(node.n).__name__
       raise util.ParseError(node, msg)
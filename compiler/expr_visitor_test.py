@@ -193,6 +193,7 @@ class ExprVisitorTest(unittest.TestCase):
   testNumFloatSciCap = _MakeLiteralTest('1E6', '1000000.0')
   testNumFloatSciCapPlus = _MakeLiteralTest('1E+6', '1000000.0')
   testNumFloatSciMinus = _MakeLiteralTest('1e-06')
+  testNumFloatPrecision = _MakeLiteralTest('0.2485427422412062')
   testNumComplex = _MakeLiteralTest('3j')
 
   testSubscriptDictStr = _MakeExprTest('{"foo": 42}["foo"]')
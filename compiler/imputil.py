@@ -32,6 +32,14 @@ from grumpy.pythonparser import ast
 
 _NATIVE_MODULE_PREFIX = '__go__/'
 
+# Modules implemented in Go and registered directly by the Grumpy runtime. They
+# have no Python source and are linked into every binary so they are never
+# dependencies.
+_BUILTIN_MODULES = frozenset(
+    ['_bisect', '_cPickle', '_datetime', '_functools', '_heapq', '_io',
+     '_operator', '_random', '_select', '_socket', '_subprocess', 'array',
+     'cStringIO', 'itertools', 'marshal', 'posix', 'time'])
+
 
 class Import(object):
   """Represents a single module import and all its associated bindings.
@@ -46,10 +54,11 @@ class Import(object):
   MODULE = "<BindType 'module'>"
   MEMBER = "<BindType 'member'>"
 
-  def __init__(self, name, script=None, is_native=False):
+  def __init__(self, name, script=None, is_native=False, is_builtin=False):
     self.name = name
     self.script = script
     self.is_native = is_native
+    self.is_builtin = is_builtin
     self.bindings = []
 
   def add_binding(self, bind_type, alias, value):
@@ -151,6 +160,8 @@ class Importer(algorithm.Visitor):
       script = find_script(self.package_dir, modname)
       if script:
         return Import('{}.{}'.format(self.package_name, modname), script)
+    if modname in _BUILTIN_MODULES:
+      return Import(modname, is_builtin=True)
     for dirname in self.pathdirs:
       script = find_script(dirname, modname)
       if script:
@@ -215,6 +226,8 @@ def calculate_transitive_deps(modname, script, gopath):
       if imp.is_native:
         deps.add(imp.name)
         continue
+      if imp.is_builtin:
+        continue
       parts = imp.name.split('.')
       calc(imp.name, imp.script)
       if len(parts) == 1:
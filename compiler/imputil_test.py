@@ -197,6 +197,16 @@ class ImportVisitorTest(unittest.TestCase):
     imp.add_binding(imputil.Import.MEMBER, 'foo', 'Printf')
     self._check_imports('from "__go__/fmt" import Printf as foo', [imp])
 
+  def testImportBuiltin(self):
+    imp = imputil.Import('posix', is_builtin=True)
+    imp.add_binding(imputil.Import.MODULE, 'posix', 0)
+    self._check_imports('import posix', [imp])
+
+  def testImportFromBuiltinMember(self):
+    imp = imputil.Import('posix', is_builtin=True)
+    imp.add_binding(imputil.Import.MEMBER, 'stat', 'stat')
+    self._check_imports('from posix import stat', [imp])
+
   def testRelativeImportNonPackage(self):
     self.assertRaises(util.ImportError, self.importer.visit,
                       pythonparser.parse('from . import bar').body[0])
@@ -75,12 +75,19 @@ class StatementVisitor(algorithm.Visitor):
       fmt = 'augmented assignment op not implemented: {}'
       raise util.ParseError(node, fmt.format(op_type.__name__))
     self._write_py_context(node.lineno)
+    tmpl = StatementVisitor._AUG_ASSIGN_TEMPLATES[op_type]
+    if (op_type in StatementVisitor._AUG_ASSIGN_INT_TEMPLATES and
+        isinstance(node.value, ast.Num) and isinstance(node.value.n, int)):
+      # Statements like "i += 1" pass the operand as a Go int.
+      tmpl = StatementVisitor._AUG_ASSIGN_INT_TEMPLATES[op_type]
+      value_expr = expr.GeneratedLiteral(str(node.value.n))
+    else:
+      value_expr = self.visit_expr(node.value)
     with self.visit_expr(node.target) as target,\
-        self.visit_expr(node.value) as value,\
+        value_expr as value,\
         self.block.alloc_temp() as temp:
       self.writer.write_checked_call2(
-          temp, StatementVisitor._AUG_ASSIGN_TEMPLATES[op_type],
-          lhs=target.expr, rhs=value.expr)
+          temp, tmpl, lhs=target.expr, rhs=value.expr)
       self._assign_target(node.target, temp.expr)
 
   def visit_Assign(self, node):
@@ -242,19 +249,16 @@ class StatementVisitor(algorithm.Visitor):
     orelse = [node]
     while len(orelse) == 1 and isinstance(orelse[0], ast.If):
       ifnode = orelse[0]
-      with self.visit_expr(ifnode.test) as cond:
+      with self.block.alloc_temp('bool') as is_true:
+        self._write_truth_test(ifnode.test, is_true)
         label = self.block.genlabel()
         # We goto the body of the if statement instead of executing it inline
         # because the body itself may be a goto target and Go does not support
         # jumping to targets inside a block.
-        with self.block.alloc_temp('bool') as is_true:
-          self.writer.write_tmpl(textwrap.dedent("""\
-              if $is_true, πE = πg.IsTrue(πF, $cond); πE != nil {
-              \tcontinue
-              }
-              if $is_true {
-              \tgoto Label$label
-              }"""), is_true=is_true.name, cond=cond.expr, label=label)
+        self.writer.write_tmpl(textwrap.dedent("""\
+            if $is_true {
+            \tgoto Label$label
+            }"""), is_true=is_true.name, label=label)
       bodies.append((label, ifnode.body, ifnode.lineno))
       orelse = ifnode.orelse
     default_label = end_label = self.block.genlabel()
@@ -296,14 +300,20 @@ class StatementVisitor(algorithm.Visitor):
     if self.block.root.future_features.print_function:
       raise util.ParseError(node, 'syntax error (print is not a keyword)')
     self._write_py_context(node.lineno)
-    with self.block.alloc_temp('[]*πg.Object') as args:
+    # The destination is evaluated before the values, as in CPython.
+    with self.visit_expr(node.dest) if node.dest else _nil_expr as dest, \
+        self.block.alloc_temp('[]*πg.Object') as args:
       self.writer.write('{} = make([]*πg.Object, {})'.format(
           args.expr, len(node.values)))
       for i, v in enumerate(node.values):
         with self.visit_expr(v) as arg:
           self.writer.write('{}[{}] = {}'.format(args.expr, i, arg.expr))
-      self.writer.write_checked_call1('πg.Print(πF, {}, {})', args.expr,
-                                      'true' if node.nl else 'false')
+      nl = 'true' if node.nl else 'false'
+      if node.dest:
+        self.writer.write_checked_call1('πg.PrintTo(πF, {}, {}, {})',
+                                        dest.expr, args.expr, nl)
+      else:
+        self.writer.write_checked_call1('πg.Print(πF, {}, {})', args.expr, nl)
 
   def visit_Raise(self, node):
     with self.visit_expr(node.exc) if node.exc else _nil_expr as t,\
@@ -412,9 +422,7 @@ class StatementVisitor(algorithm.Visitor):
   def visit_While(self, node):
     self._write_py_context(node.lineno)
     def testfunc(testvar):
-      with self.visit_expr(node.test) as cond:
-        self.writer.write_checked_call2(
-            testvar, 'πg.IsTrue(πF, {})', cond.expr)
+      self._write_truth_test(node.test, testvar)
     self._visit_loop(testfunc, node)
 
   def visit_With(self, node):
@@ -580,6 +588,20 @@ class StatementVisitor(algorithm.Visitor):
       ast.BitXor: 'πg.IXor(πF, {lhs}, {rhs})',
   }
 
+  _AUG_ASSIGN_INT_TEMPLATES = {
+      ast.Add: 'πg.IAddInt(πF, {lhs}, {rhs})',
+      ast.Sub: 'πg.ISubInt(πF, {lhs}, {rhs})',
+  }
+
+  _CMP_BOOL_TEMPLATES = {
+      ast.Eq: 'πg.EqBool(πF, {lhs}, {rhs})',
+      ast.Gt: 'πg.GTBool(πF, {lhs}, {rhs})',
+      ast.GtE: 'πg.GEBool(πF, {lhs}, {rhs})',
+      ast.Lt: 'πg.LTBool(πF, {lhs}, {rhs})',
+      ast.LtE: 'πg.LEBool(πF, {lhs}, {rhs})',
+      ast.NotEq: 'πg.NEBool(πF, {lhs}, {rhs})',
+  }
+
   def _assign_target(self, target, value):
     if isinstance(target, ast.Name):
       self.block.bind_var(self.writer, target.id, value)
@@ -742,6 +764,22 @@ class StatementVisitor(algorithm.Visitor):
       self.writer.write('continue')
     return handler_labels
 
+  def _write_truth_test(self, node, result):
+    """Writes code storing the truthiness of node in the Go bool result."""
+    if (isinstance(node, ast.Compare) and len(node.ops) == 1 and
+        type(node.ops[0]) in StatementVisitor._CMP_BOOL_TEMPLATES):
+      # Simple comparisons like "i < n" produce a Go bool directly rather
+      # than a bool object that then needs to be tested.
+      with self.visit_expr(node.left) as lhs,\
+          self.visit_expr(node.comparators[0]) as rhs:
+        self.writer.write_checked_call2(
+            result, StatementVisitor._CMP_BOOL_TEMPLATES[type(node.ops[0])],
+            lhs=lhs.expr, rhs=rhs.expr)
+    else:
+      with self.visit_expr(node) as cond:
+        self.writer.write_checked_call2(
+            result, 'πg.IsTrue(πF, {})', cond.expr)
+
   def _write_py_context(self, lineno):
     if lineno:
       line = self.block.root.buffer.source_line(lineno).strip()
@@ -103,6 +103,20 @@ class StatementVisitorTest(unittest.TestCase):
         foo &= 3
         print foo""")))
 
+  def testAugAssignIntOverflow(self):
+    self.assertEqual((0, "9223372036854775808 <type 'long'>\n"
+                         "-9223372036854775809 <type 'long'>\n"
+                         "foobar\n"), _GrumpRun(textwrap.dedent("""\
+        foo = 9223372036854775807
+        foo += 1
+        print foo, type(foo)
+        bar = -9223372036854775808
+        bar -= 1
+        print bar, type(bar)
+        baz = ['foo']
+        baz += 'bar'
+        print ''.join(baz)""")))
+
   def testAugAssignPow(self):
     self.assertEqual((0, '64\n'), _GrumpRun(textwrap.dedent("""\
         foo = 8
@@ -281,6 +295,15 @@ class StatementVisitorTest(unittest.TestCase):
         elif True:
           print 'bar'""")))
 
+  def testIfCompare(self):
+    self.assertEqual((0, 'foo\nbar\n'), _GrumpRun(textwrap.dedent("""\
+        if 1 < 2.5:
+          print 'foo'
+        if 'a' != 'a':
+          print 'baz'
+        elif 2 ** 64 >= 2:
+          print 'bar'""")))
+
   def testIfElse(self):
     self.assertEqual((0, 'foo\nbar\n'), _GrumpRun(textwrap.dedent("""\
         if True:
@@ -357,6 +380,43 @@ class StatementVisitorTest(unittest.TestCase):
         print('abc', 123, sep='x')
         print('abc', 123, end=' ')""")))
 
+  def testPrintRedirect(self):
+    self.assertEqual((0, 'abc 123\nfoo\n'), _GrumpRun(textwrap.dedent("""\
+        import sys
+        class Writer(object):
+          def __init__(self):
+            self.parts = []
+          def write(self, s):
+            self.parts.append(s)
+        w = Writer()
+        print >>w, 'abc', 123
+        sys.stdout.write(''.join(w.parts))
+        print >>None, 'foo'""")))
+
+  def testPrintRedirectEvaluationOrder(self):
+    self.assertEqual((0, 'dest\nval\nx\n'), _GrumpRun(textwrap.dedent("""\
+        import sys
+        def d():
+          print 'dest'
+          return sys.stdout
+        def v():
+          print 'val'
+          return 'x'
+        print >>d(), v()""")))
+
+  def testPrintFunctionFile(self):
+    self.assertEqual((0, 'a-b!'), _GrumpRun(textwrap.dedent("""\
+        from __future__ import print_function
+        import sys
+        class Writer(object):
+          def __init__(self):
+            self.parts = []
+          def write(self, s):
+            self.parts.append(s)
+        w = Writer()
+        print('a', 'b', sep='-', end='!', file=w)
+        print(''.join(w.parts), end='', file=sys.stdout)""")))
+
   def testRaiseExitStatus(self):
     self.assertEqual(1, _GrumpRun('raise Exception')[0])
 
@@ -455,6 +515,21 @@ class StatementVisitorTest(unittest.TestCase):
           print i
           i -= 1""")))
 
+  def testWhileCompare(self):
+    self.assertEqual((0, '3\n'), _GrumpRun(textwrap.dedent("""\
+        i = 0
+        while i < 3:
+          i += 1
+        print i""")))
+
+  def testWhileCompareWritesGoBool(self):
+    visitor = _ParseAndVisit(textwrap.dedent("""\
+        i = 0
+        while i < 3:
+          i += 1"""))
+    expected = re.compile(r'LTBool\(πF, .*\bIAddInt\(πF, .*, 1\)', re.DOTALL)
+    self.assertRegexpMatches(visitor.writer.getvalue(), expected)
+
   def testWhileElse(self):
     self.assertEqual((0, 'bar\n'), _GrumpRun(textwrap.dedent("""\
         while False:
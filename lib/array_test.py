@@ -0,0 +1,108 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import array
+import os
+import tempfile
+
+import weetest
+
+
+def TestSequence():
+  a = array.array('i', [1, 2, 3])
+  assert len(a) == 3 and a[0] == 1 and a[-1] == 3
+  assert list(a) == [1, 2, 3]
+  assert 2 in a and 5 not in a
+  a.append(4)
+  a.extend([5, 6])
+  a.extend(array.array('i', [7]))
+  a += array.array('i', [8])
+  assert a.tolist() == [1, 2, 3, 4, 5, 6, 7, 8]
+  a[1:3] = array.array('i', [9])
+  del a[::2]
+  assert a == array.array('i', [9, 5, 7]), a
+  assert a * 2 == array.array('i', [9, 5, 7, 9, 5, 7])
+  assert a.pop() == 7 and a.index(5) == 1 and a.count(9) == 1
+  a.insert(0, 3)
+  a.reverse()
+  assert a.tolist() == [5, 9, 3]
+  assert a < array.array('i', [6]) and a != array.array('i', [5, 9])
+  assert type(a[:1]) is array.array and array.ArrayType is array.array
+
+
+def TestTypecodes():
+  assert [array.array(c).itemsize for c in 'cbBuhHiIlLfd'] == [
+      1, 1, 1, 4, 2, 2, 4, 4, 8, 8, 4, 8]
+  assert array.array('I', [1])[0] == 1L
+  assert array.array('f', [0.5])[0] == 0.5
+  assert array.array('c', 'ab')[1] == 'b'
+  assert array.array('u', u'ab').tounicode() == u'ab'
+  for c, bad in [('b', 128), ('B', -1), ('h', 2**15), ('I', 2**32),
+                 ('L', 2**64)]:
+    try:
+      array.array(c, [bad])
+    except OverflowError:
+      pass
+    else:
+      raise AssertionError('%s accepted %d' % (c, bad))
+  try:
+    array.array('q')
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestBytes():
+  a = array.array('H', [1, 0x0203])
+  s = a.tostring()
+  assert len(s) == 4
+  b = array.array('H')
+  b.fromstring(s)
+  assert b == a
+  b.byteswap()
+  assert b.tolist() == [0x0100, 0x0302]
+  assert a.buffer_info()[1] == 2
+  try:
+    b.fromstring('abc')
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestFile():
+  fd, path = tempfile.mkstemp()
+  os.close(fd)
+  try:
+    with open(path, 'wb') as f:
+      array.array('i', range(5)).tofile(f)
+      f.write(array.array('c', 'xy'))
+    a = array.array('i')
+    with open(path, 'rb') as f:
+      a.fromfile(f, 3)
+      try:
+        a.fromfile(f, 3)
+      except EOFError:
+        pass
+      else:
+        raise AssertionError
+      assert f.read() == ''
+    assert a.tolist() == [0, 1, 2, 3, 4], a
+  finally:
+    os.remove(path)
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
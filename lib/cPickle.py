@@ -0,0 +1,32 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Serialize and deserialize Python objects.
+
+The Pickler and Unpickler implementations live in the _cPickle module. Both
+support protocols 0, 1 and 2 and produce the same output as CPython's cPickle.
+"""
+
+# copy_reg must be imported before _cPickle, which reads its dispatch and
+# extension tables on import. Globals from __builtin__ are pickled by
+# reference so it must be importable too.
+import __builtin__  # pylint: disable=unused-import
+import copy_reg  # pylint: disable=unused-import
+
+# pylint: disable=g-multiple-import
+from _cPickle import (BadPickleGet, HIGHEST_PROTOCOL, PickleError, Pickler,
+                      PicklerType, PicklingError, UnpickleableError, Unpickler,
+                      UnpicklerType, UnpicklingError, __version__,
+                      compatible_formats, dump, dumps, format_version, load,
+                      loads)
@@ -0,0 +1,234 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# pylint: disable=g-multiple-import
+
+import copy_reg
+import cPickle
+import cStringIO
+
+import weetest
+
+
+class Foo(object):
+
+  def __init__(self, x):
+    self.x = x
+
+
+class GetState(object):
+
+  def __getstate__(self):
+    return {'z': 1}
+
+  def __setstate__(self, state):
+    self.__dict__.update(state)
+    self.restored = True
+
+
+class NewArgs(object):
+
+  def __new__(cls, a, b):
+    o = object.__new__(cls)
+    o.ab = (a, b)
+    return o
+
+  def __getnewargs__(self):
+    return self.ab
+
+
+class IntSub(int):
+  pass
+
+
+class ListSub(list):
+  pass
+
+
+class Point(object):
+
+  def __init__(self, x, y):
+    self.x, self.y = x, y
+
+
+def _ReducePoint(p):
+  return _MakePoint, (p.x, p.y)
+
+
+def _MakePoint(x, y):
+  return ('point', x, y)
+
+
+copy_reg.pickle(Point, _ReducePoint)
+
+
+def TestBasicTypesProtocol2():
+  # These match the output of CPython's cPickle.
+  assert cPickle.dumps(None, 2) == '\x80\x02N.'
+  assert cPickle.dumps(70000, 2) == '\x80\x02Jp\x11\x01\x00.'
+  assert cPickle.dumps(2 ** 64, 2) == '\x80\x02\x8a\t\x00\x00\x00\x00\x00\x00\x00\x00\x01.'
+  assert cPickle.dumps(1.5, 2) == '\x80\x02G?\xf8\x00\x00\x00\x00\x00\x00.'
+  assert cPickle.dumps(u'\xe9', 2) == '\x80\x02X\x02\x00\x00\x00\xc3\xa9.'
+  assert cPickle.dumps([1, 'ab', 'ab'], 2) == '\x80\x02]q\x01(K\x01U\x02abq\x02h\x02e.'
+  assert cPickle.dumps({'a': (1, 2)}, 2) == '\x80\x02}q\x01U\x01aK\x01K\x02\x86q\x02s.'
+  assert cPickle.dumps(len, 2) == '\x80\x02c__builtin__\nlen\nq\x01.'
+
+
+def TestRoundTrip():
+  x = [1, 2]
+  values = [None, True, 0, -1, 2 ** 31, -2 ** 100, 0.1, float('inf'), '',
+            "it's", 'a"b', '\x00\xff', u'', u'a\\b\n\u20ac', (), (1,),
+            (1, 2, 3, 4), [x, x], {'a': x, 'b': [x]}, range(1200), Foo, len,
+            complex(1, 2)]
+  for proto in range(cPickle.HIGHEST_PROTOCOL + 1):
+    for v in values:
+      assert cPickle.loads(cPickle.dumps(v, proto)) == v, (v, proto)
+    y = cPickle.loads(cPickle.dumps([x, x], proto))
+    assert y[0] is y[1]
+
+
+def TestRecursive():
+  for proto in range(3):
+    l = []
+    l.append(l)
+    got = cPickle.loads(cPickle.dumps(l, proto))
+    assert got[0] is got
+    d = {}
+    d['self'] = d
+    got = cPickle.loads(cPickle.dumps(d, proto))
+    assert got['self'] is got
+    t = ([],)
+    t[0].append(t)
+    got = cPickle.loads(cPickle.dumps(t, proto))
+    assert got[0][0] is got
+
+
+def TestInstances():
+  for proto in range(3):
+    foo = cPickle.loads(cPickle.dumps(Foo([1, 'ab']), proto))
+    assert type(foo) is Foo and foo.x == [1, 'ab']
+    gs = cPickle.loads(cPickle.dumps(GetState(), proto))
+    assert gs.z == 1 and gs.restored
+    i = cPickle.loads(cPickle.dumps(IntSub(5), proto))
+    assert type(i) is IntSub and i == 5
+    l = ListSub([1, 2])
+    l.attr = 'v'
+    l = cPickle.loads(cPickle.dumps(l, proto))
+    assert type(l) is ListSub and l == [1, 2] and l.attr == 'v'
+  na = cPickle.loads(cPickle.dumps(NewArgs(1, 'q'), 2))
+  assert type(na) is NewArgs and na.ab == (1, 'q')
+
+
+def TestCopyRegDispatch():
+  for proto in range(3):
+    assert cPickle.loads(cPickle.dumps(Point(1, 2), proto)) == ('point', 1, 2)
+
+
+def TestExtensionRegistry():
+  copy_reg.add_extension(__name__, 'Foo', 240)
+  try:
+    assert cPickle.dumps(Foo, 2) == '\x80\x02\x82\xf0.'
+    assert cPickle.loads('\x80\x02\x82\xf0.') is Foo
+    assert 'Foo' in cPickle.dumps(Foo, 1)
+  finally:
+    copy_reg.remove_extension(__name__, 'Foo', 240)
+
+
+def TestPersistent():
+  f = cStringIO.StringIO()
+  p = cPickle.Pickler(f, 2)
+  p.persistent_id = lambda o: 'ext' if o is Foo else None
+  p.dump([Foo, 1])
+  u = cPickle.Unpickler(cStringIO.StringIO(f.getvalue()))
+  u.persistent_load = lambda pid: ('loaded', pid)
+  assert u.load() == [('loaded', 'ext'), 1]
+  u = cPickle.Unpickler(cStringIO.StringIO(f.getvalue()))
+  try:
+    u.load()
+  except cPickle.UnpicklingError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestPicklerMemo():
+  p = cPickle.Pickler(1)
+  x = ['ab']
+  p.dump(x)
+  p.dump(x)
+  assert p.getvalue() == ']q\x01U\x02aba.h\x01.'
+  p.clear_memo()
+  p.dump(x)
+  assert len(p.memo) == 2
+  assert cPickle.Pickler(1).getvalue() == ''
+
+
+def TestFileDumpLoad():
+  f = cStringIO.StringIO()
+  cPickle.dump({'a': 1}, f, 2)
+  cPickle.dump('second', f)
+  f.seek(0)
+  assert cPickle.load(f) == {'a': 1}
+  assert cPickle.load(f) == 'second'
+  try:
+    cPickle.load(f)
+  except EOFError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestFindGlobal():
+  u = cPickle.Unpickler(cStringIO.StringIO('c__builtin__\nlen\n.'))
+  u.find_global = lambda module, name: (module, name)
+  assert u.load() == ('__builtin__', 'len')
+  u = cPickle.Unpickler(cStringIO.StringIO('c__builtin__\nlen\n.'))
+  u.find_global = None
+  try:
+    u.load()
+  except cPickle.UnpicklingError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestNoload():
+  for proto in range(3):
+    u = cPickle.Unpickler(cStringIO.StringIO(cPickle.dumps([Foo(1), 2], proto)))
+    assert u.noload() == []
+
+
+def TestErrors():
+  for args, exc in [((1, 3), ValueError), ((lambda: None,), TypeError)]:
+    try:
+      cPickle.dumps(*args)
+    except exc:
+      pass
+    else:
+      raise AssertionError(args)
+  for s, exc in [('', EOFError), ('z', cPickle.UnpicklingError),
+                 ('g1\n.', cPickle.BadPickleGet), ("S'ab\n.", ValueError)]:
+    try:
+      cPickle.loads(s)
+    except exc:
+      pass
+    else:
+      raise AssertionError(s)
+  assert issubclass(cPickle.BadPickleGet, cPickle.UnpicklingError)
+  assert issubclass(cPickle.UnpickleableError, cPickle.PicklingError)
+  assert issubclass(cPickle.PicklingError, cPickle.PickleError)
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
@@ -1 +0,0 @@
-../third_party/stdlib/StringIO.py
\ No newline at end of file
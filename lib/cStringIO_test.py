@@ -0,0 +1,87 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import cStringIO
+
+import weetest
+
+
+def TestInput():
+  f = cStringIO.StringIO('ab\ncd\nef')
+  assert type(f) is cStringIO.InputType
+  assert f.readline() == 'ab\n' and f.read(1) == 'c' and f.tell() == 4
+  assert f.readlines() == ['d\n', 'ef']
+  f.seek(-100)
+  assert f.tell() == 0
+  f.seek(-2, 2)
+  assert f.read() == 'ef'
+  f.reset()
+  assert list(f) == ['ab\n', 'cd\n', 'ef']
+  f.seek(2)
+  assert f.getvalue(True) == 'ab' and f.getvalue() == 'ab\ncd\nef'
+  assert not hasattr(f, 'write') and not hasattr(f, 'softspace')
+
+
+def TestOutput():
+  f = cStringIO.StringIO()
+  assert type(f) is cStringIO.OutputType
+  f.write('abc')
+  f.seek(1)
+  f.write('X')
+  assert f.getvalue() == 'aXc' and f.tell() == 2
+  f.seek(5)
+  f.writelines(['1', u'2'])
+  assert f.getvalue() == 'aXc\0\x0012'
+  f.truncate(2)
+  assert f.getvalue() == 'aX' and f.tell() == 2
+  f.write(bytearray(2))
+  assert f.getvalue() == 'aX\0\0'
+  f.softspace = 1
+  assert f.softspace == 1
+  try:
+    f.write(1)
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+  try:
+    f.truncate(-1)
+  except IOError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestClose():
+  f = cStringIO.StringIO()
+  assert not f.closed
+  f.close()
+  assert f.closed
+  try:
+    f.write('a')
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestPrintRedirect():
+  f = cStringIO.StringIO()
+  print >>f, 'hello', 1
+  print >>f, 'a'
+  assert f.getvalue() == 'hello 1\na\n'
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
@@ -1 +1,37 @@
-EINVAL = 22
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Standard errno system symbols."""
+
+# pylint: disable=g-multiple-import
+from '__go__/syscall' import (
+    E2BIG, EACCES, EADDRINUSE, EADDRNOTAVAIL, EAFNOSUPPORT, EAGAIN, EALREADY,
+    EBADF, EBUSY, ECHILD, ECONNABORTED, ECONNREFUSED, ECONNRESET, EDEADLK,
+    EDESTADDRREQ, EDOM, EEXIST, EFAULT, EFBIG, EHOSTUNREACH, EINPROGRESS, EINTR,
+    EINVAL, EIO, EISCONN, EISDIR, ELOOP, EMFILE, EMLINK, EMSGSIZE, ENAMETOOLONG,
+    ENETDOWN, ENETUNREACH, ENFILE, ENOBUFS, ENODEV, ENOENT, ENOEXEC, ENOLCK,
+    ENOMEM, ENOPROTOOPT, ENOSPC, ENOSYS, ENOTCONN, ENOTDIR, ENOTEMPTY, ENOTSOCK,
+    ENOTTY, ENXIO, EOPNOTSUPP, EPERM, EPIPE, EPROTONOSUPPORT, EPROTOTYPE,
+    ERANGE, EROFS, ESPIPE, ESRCH, ETIMEDOUT, EWOULDBLOCK, EXDEV)
+
+
+# Map errno values to their names. The values imported above are Go
+# syscall.Errno objects so replace them with plain ints while we're at it.
+errorcode = {}
+for _name, _value in sorted(globals().items()):
+  if _name.startswith('E'):
+    _value = int(_value)
+    globals()[_name] = _value
+    errorcode.setdefault(_value, _name)
+del _name, _value
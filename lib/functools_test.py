@@ -0,0 +1,150 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import cPickle
+import functools
+
+import weetest
+
+
+def _Capture(*args, **kwargs):
+  return args, kwargs
+
+
+class PartialSub(functools.partial):
+  pass
+
+
+def TestPartial():
+  p = functools.partial(_Capture, 1, a=2)
+  assert p.func is _Capture
+  assert p.args == (1,)
+  assert p.keywords == {'a': 2}
+  assert p(3, b=4) == ((1, 3), {'a': 2, 'b': 4})
+  assert p(a=5) == ((1,), {'a': 5})
+  assert functools.partial(_Capture)() == ((), {})
+  assert functools.partial(_Capture).keywords == {}
+  p.keywords['c'] = 6
+  assert p() == ((1,), {'a': 2, 'c': 6})
+
+
+def TestPartialErrors():
+  for args, msg in [((), "type 'partial' takes at least one argument"),
+                    ((1,), 'the first argument must be callable')]:
+    try:
+      functools.partial(*args)
+    except TypeError as e:
+      assert str(e) == msg, str(e)
+    else:
+      raise AssertionError(args)
+  try:
+    functools.partial(_Capture).func = len
+  except (AttributeError, TypeError):
+    pass
+  else:
+    raise AssertionError
+
+
+def TestPartialFlatten():
+  inner = functools.partial(_Capture, 1, a=2)
+  p = functools.partial(inner, 3, a=4, b=5)
+  assert p.func is _Capture
+  assert p.args == (1, 3)
+  assert p.keywords == {'a': 4, 'b': 5}
+  inner.attr = 'x'
+  assert functools.partial(inner, 3).func is inner
+  assert PartialSub(functools.partial(_Capture, 1), 2).args == (2,)
+
+
+def TestPartialDict():
+  p = functools.partial(_Capture)
+  p.attr = 1
+  assert p.__dict__ == {'attr': 1}
+  p.__dict__ = {'other': 2}
+  assert p.other == 2
+  for stmt, msg in [('del', "a partial object's dictionary may not be deleted"),
+                    ('set', "setting partial object's dictionary to a non-dict")]:
+    try:
+      if stmt == 'del':
+        del p.__dict__
+      else:
+        p.__dict__ = 1
+    except TypeError as e:
+      assert str(e) == msg, str(e)
+    else:
+      raise AssertionError(stmt)
+
+
+def TestPartialPickle():
+  p = functools.partial(_Capture, 1, a=[2])
+  p.attr = 'x'
+  assert p.__reduce__() == (functools.partial, (_Capture,),
+                            (_Capture, (1,), {'a': [2]}, {'attr': 'x'}))
+  for proto in range(cPickle.HIGHEST_PROTOCOL + 1):
+    q = cPickle.loads(cPickle.dumps(p, proto))
+    assert type(q) is functools.partial
+    assert q.args == (1,) and q.keywords == {'a': [2]} and q.attr == 'x'
+    assert q(3) == ((1, 3), {'a': [2]})
+  q = PartialSub(_Capture)
+  q.__setstate__((len, (1,), None, None))
+  assert q.func is len and q.args == (1,) and q.keywords == {}
+  try:
+    q.__setstate__((1, (), None, None))
+  except TypeError as e:
+    assert str(e) == 'invalid partial state'
+  else:
+    raise AssertionError
+
+
+def TestReduce():
+  assert functools.reduce(lambda a, b: a + b, [1, 2, 3]) == 6
+  assert functools.reduce(lambda a, b: a + b, [1, 2, 3], 10) == 16
+  assert functools.reduce(lambda a, b: a + b, [], 'x') == 'x'
+  assert functools.reduce(lambda a, b: a + b, iter('ab')) == 'ab'
+  for args, msg in [(([],), 'reduce() of empty sequence with no initial value'),
+                    ((1,), 'reduce() arg 2 must support iteration')]:
+    try:
+      functools.reduce(_Capture, *args)
+    except TypeError as e:
+      assert str(e) == msg, str(e)
+    else:
+      raise AssertionError(args)
+
+
+def TestCmpToKey():
+  key = functools.cmp_to_key(lambda a, b: cmp(b, a))
+  assert key(1) < key(0)
+  assert key(1) <= key(1)
+  assert key(0) > key(1)
+  assert key(0) >= key(0)
+  assert key(1) == key(1)
+  assert key(1) != key(2)
+  assert key(3).obj == 3
+  assert sorted([key(2), key(3), key(1)])[0].obj == 3
+  try:
+    hash(key(1))
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+  try:
+    key(1) < 1
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
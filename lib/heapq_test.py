@@ -0,0 +1,111 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import _heapq
+import heapq
+import random
+
+import weetest
+
+
+def _CheckInvariant(heap):
+  for pos in range(1, len(heap)):
+    assert heap[(pos - 1) >> 1] <= heap[pos], heap
+
+
+def TestAccelerated():
+  for name in ('heapify', 'heappop', 'heappush', 'heappushpop', 'heapreplace'):
+    assert getattr(heapq, name) is getattr(_heapq, name), name
+
+
+def TestPushPop():
+  data = [random.randrange(100) for _ in range(200)]
+  heap = []
+  for item in data:
+    heapq.heappush(heap, item)
+    _CheckInvariant(heap)
+  got = [heapq.heappop(heap) for _ in range(len(data))]
+  assert got == sorted(data), got
+  try:
+    heapq.heappop(heap)
+  except IndexError as e:
+    assert str(e) == 'index out of range', str(e)
+  else:
+    raise AssertionError
+
+
+def TestHeapify():
+  for size in range(30):
+    heap = [random.random() for _ in range(size)]
+    heapq.heapify(heap)
+    _CheckInvariant(heap)
+  try:
+    heapq.heapify((1, 2))
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestReplaceAndPushPop():
+  heap = [1, 3, 2]
+  assert heapq.heapreplace(heap, 5) == 1
+  assert heap == [2, 3, 5], heap
+  assert heapq.heappushpop(heap, 0) == 0
+  assert heapq.heappushpop(heap, 4) == 2
+  assert heap == [3, 4, 5], heap
+  assert heapq.heappushpop([], 'x') == 'x'
+
+
+def TestTuplePriorities():
+  heap = []
+  for item in [(2, 'b'), (1, 'z'), (2, 'a'), (0, 'q')]:
+    heapq.heappush(heap, item)
+  got = [heapq.heappop(heap) for _ in range(4)]
+  assert got == [(0, 'q'), (1, 'z'), (2, 'a'), (2, 'b')], got
+
+
+def TestCmpError():
+  class CmpErr(object):
+    def __lt__(self, other):
+      raise ZeroDivisionError
+  heap = [CmpErr(), CmpErr()]
+  try:
+    heapq.heappush(heap, CmpErr())
+  except ZeroDivisionError:
+    pass
+  else:
+    raise AssertionError
+  assert len(heap) == 3
+
+
+def TestNLargestNSmallest():
+  data = [random.randrange(1000) for _ in range(100)]
+  ascending = sorted(data)
+  descending = ascending[::-1]
+  for n in (0, 1, 2, 10, 99):
+    assert heapq.nsmallest(n, data) == ascending[:n]
+    assert heapq.nlargest(n, data) == descending[:n]
+    assert heapq.nsmallest(n, iter(data)) == ascending[:n]
+    assert heapq.nlargest(n, iter(data)) == descending[:n]
+    assert heapq.nsmallest(n, data, key=lambda x: -x) == descending[:n]
+    assert heapq.nlargest(n, data, key=lambda x: -x) == ascending[:n]
+  assert _heapq.nsmallest(150, iter(data)) == ascending
+  assert _heapq.nlargest(150, iter(data)) == descending
+  assert _heapq.nlargest(-1, data) == []
+  assert _heapq.nsmallest(3, 'hello') == ['e', 'h', 'l']
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
@@ -0,0 +1,64 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""The io module provides the Python interfaces to stream handling.
+
+The builtin open function is defined in this module. At the top of the I/O
+hierarchy is the abstract base class IOBase. RawIOBase extends it with
+unbuffered access to raw binary streams, BufferedIOBase with buffering and
+TextIOBase with access to text streams. The concrete classes are implemented
+in the _io module.
+"""
+
+import abc
+
+# pylint: disable=g-multiple-import
+from _io import (DEFAULT_BUFFER_SIZE, BlockingIOError, UnsupportedOperation,
+                 open, FileIO, BytesIO, StringIO, BufferedReader,
+                 BufferedWriter, BufferedRandom, TextIOWrapper, SEEK_SET,
+                 SEEK_CUR, SEEK_END)
+import _io
+
+
+__all__ = ['BlockingIOError', 'open', 'IOBase', 'RawIOBase', 'FileIO',
+           'BytesIO', 'StringIO', 'BufferedIOBase', 'BufferedReader',
+           'BufferedWriter', 'BufferedRandom', 'TextIOBase', 'TextIOWrapper',
+           'UnsupportedOperation', 'SEEK_SET', 'SEEK_CUR', 'SEEK_END',
+           'DEFAULT_BUFFER_SIZE']
+
+
+class IOBase(_io._IOBase):  # pylint: disable=protected-access
+  __metaclass__ = abc.ABCMeta
+
+
+class RawIOBase(_io._RawIOBase, IOBase):  # pylint: disable=protected-access
+  pass
+
+
+class BufferedIOBase(_io._BufferedIOBase, IOBase):  # pylint: disable=protected-access
+  pass
+
+
+class TextIOBase(_io._TextIOBase, IOBase):  # pylint: disable=protected-access
+  pass
+
+
+RawIOBase.register(FileIO)
+
+for klass in (BytesIO, BufferedReader, BufferedWriter, BufferedRandom):
+  BufferedIOBase.register(klass)
+
+for klass in (StringIO, TextIOWrapper):
+  TextIOBase.register(klass)
+del klass
@@ -0,0 +1,246 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import io
+import os
+import tempfile
+
+import weetest
+
+
+def _TempPath(contents):
+  fd, path = tempfile.mkstemp()
+  os.write(fd, contents)
+  os.close(fd)
+  return path
+
+
+def TestBytesIO():
+  b = io.BytesIO('ab\ncd')
+  assert b.read(1) == 'a' and b.readline() == 'b\n' and b.tell() == 3
+  assert b.seek(0, 2) == 5 and b.write('xy') == 2
+  assert b.getvalue() == 'ab\ncdxy'
+  assert b.seek(10) == 10 and b.write('z') == 1
+  assert b.getvalue() == 'ab\ncdxy\0\0\0z'
+  assert b.truncate(3) == 3 and b.tell() == 11 and b.getvalue() == 'ab\n'
+  b.seek(0)
+  assert b.readlines() == ['ab\n']
+  ba = bytearray(2)
+  b.seek(0)
+  assert b.readinto(ba) == 2 and list(ba) == [ord('a'), ord('b')]
+  for args, exc in [((-1,), ValueError), ((0, 3), ValueError)]:
+    try:
+      b.seek(*args)
+    except exc:
+      pass
+    else:
+      raise AssertionError
+  try:
+    b.fileno()
+  except io.UnsupportedOperation:
+    pass
+  else:
+    raise AssertionError
+  with io.BytesIO() as c:
+    c.write('a')
+  assert c.closed
+
+
+def TestStringIO():
+  s = io.StringIO(u'ab\ncd')
+  assert s.read(1) == u'a' and s.readline() == u'b\n' and s.tell() == 3
+  assert s.write(u'xy') == 2 and s.getvalue() == u'ab\nxy'
+  assert s.seek(0, 2) == 5
+  try:
+    s.write('x')
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+  try:
+    s.seek(1, 1)
+  except IOError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestStringIONewline():
+  s = io.StringIO(u'a\r\nb\rc\n', newline=None)
+  assert s.getvalue() == u'a\nb\nc\n'
+  assert s.newlines == ('\r', '\n', '\r\n')
+  assert io.StringIO(u'a\r\nb\rc\n', newline='').readlines() == [
+      u'a\r\n', u'b\r', u'c\n']
+  assert io.StringIO(u'a\nb', newline='\r\n').getvalue() == u'a\r\nb'
+  assert io.StringIO(u'a\rb\n', newline='\r').readlines() == [
+      u'a\r', u'b\r']
+  try:
+    io.StringIO(newline='x')
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestOpenText():
+  path = _TempPath('ab\r\ncd\n')
+  try:
+    with io.open(path) as f:
+      assert isinstance(f, io.TextIOWrapper) and f.mode == 'r'
+      assert isinstance(f.buffer, io.BufferedReader)
+      assert f.readable() and not f.writable() and f.seekable()
+      assert list(f) == [u'ab\n', u'cd\n']
+    assert f.closed
+    with io.open(path, newline='') as f:
+      assert f.readline() == u'ab\r\n'
+    with io.open(path, 'w', encoding='utf8') as f:
+      assert f.write(u'\xe9t\xe9\n') == 4
+    with io.open(path, 'rb') as f:
+      assert f.read() == '\xc3\xa9t\xc3\xa9\n'
+    with io.open(path, 'a') as f:
+      f.write(u'x')
+    with io.open(path) as f:
+      assert f.read() == u'\xe9t\xe9\nx'
+  finally:
+    os.remove(path)
+
+
+def TestOpenBinary():
+  path = _TempPath('abcdef')
+  try:
+    with io.open(path, 'rb') as f:
+      assert isinstance(f, io.BufferedReader)
+      assert f.peek(1).startswith('a') and f.read(2) == 'ab'
+      assert f.read1(2) == 'cd' and f.tell() == 4
+      assert f.seek(-1, io.SEEK_END) == 5 and f.read() == 'f'
+    with io.open(path, 'r+b') as f:
+      assert isinstance(f, io.BufferedRandom)
+      f.seek(2)
+      f.write('XY')
+      f.seek(0)
+      assert f.read() == 'abXYef'
+    with io.open(path, 'wb', buffering=0) as f:
+      assert isinstance(f, io.FileIO) and f.mode == 'wb'
+      assert f.write('z') == 1
+    with io.open(path, 'rb') as f:
+      assert f.read() == 'z'
+  finally:
+    os.remove(path)
+
+
+def TestOpenErrors():
+  path = _TempPath('')
+  try:
+    for args, exc in [((path, 'rw'), ValueError), ((path, 'rz'), ValueError),
+                      ((path, 'wU'), ValueError), ((path, 'rbt'), ValueError),
+                      ((path, 'r', 0), ValueError), ((None,), TypeError),
+                      ((path, 'rb', -1, 'utf8'), ValueError),
+                      ((os.path.dirname(path),), IOError)]:
+      try:
+        io.open(*args)
+      except exc:
+        pass
+      else:
+        raise AssertionError(args)
+  finally:
+    os.remove(path)
+
+
+def TestFileIO():
+  path = _TempPath('abc')
+  try:
+    f = io.FileIO(path, 'r+')
+    assert f.mode == 'rb+' and f.name == path
+    assert f.read(1) == 'a' and f.readall() == 'bc'
+    assert f.seek(0) == 0 and f.write('x') == 1 and f.tell() == 1
+    assert f.truncate(2) == 2
+    f.close()
+    assert f.closed
+    try:
+      f.read()
+    except ValueError:
+      pass
+    else:
+      raise AssertionError
+    fd = os.open(path, os.O_RDONLY)
+    g = io.FileIO(fd, closefd=False)
+    assert g.read() == 'xb' and g.fileno() == fd
+    g.close()
+    os.close(fd)
+  finally:
+    os.remove(path)
+
+
+def TestTextIOWrapper():
+  b = io.BytesIO('a\r\nb\rc')
+  t = io.TextIOWrapper(b)
+  assert t.encoding == 'UTF-8' and t.buffer is b
+  assert t.read() == u'a\nb\nc'
+  assert t.newlines == ('\r', '\r\n')
+  b = io.BytesIO()
+  t = io.TextIOWrapper(b, newline='\r\n')
+  t.write(u'x\ny')
+  t.flush()
+  assert b.getvalue() == 'x\r\ny'
+  try:
+    t.write('x')
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+  assert t.detach() is b
+  try:
+    t.read()
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestABCs():
+  assert isinstance(io.BytesIO(), io.BufferedIOBase)
+  assert isinstance(io.StringIO(), io.TextIOBase)
+  assert issubclass(io.FileIO, io.RawIOBase)
+  assert issubclass(io.TextIOWrapper, io.IOBase)
+
+  class Raw(io.RawIOBase):
+
+    def __init__(self, data):
+      self.data = data
+
+    def readable(self):
+      return True
+
+    def readinto(self, b):
+      n = min(len(b), len(self.data))
+      b[:n] = self.data[:n]
+      self.data = self.data[n:]
+      return n
+
+  r = Raw('ab\ncd')
+  assert r.read(1) == 'a' and r.readline() == 'b\n' and r.read() == 'cd'
+  assert io.BufferedReader(Raw('xyz')).read() == 'xyz'
+  with Raw('') as r:
+    pass
+  assert r.closed
+  try:
+    r.seek(0)
+  except io.UnsupportedOperation:
+    pass
+  else:
+    raise AssertionError
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
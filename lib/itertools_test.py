@@ -13,6 +13,7 @@
 # limitations under the License.
 
 import itertools
+import sys
 
 import weetest
 
@@ -189,5 +190,84 @@ def TestTakewhile():
     assert got == want, 'tuple(takewhile%s) == %s, want %s' % (args, got, want)
 
 
+def TestCount():
+  c = itertools.count()
+  assert [next(c) for _ in range(3)] == [0, 1, 2]
+  assert repr(c) == 'count(3)', repr(c)
+  c = itertools.count(10, -2.5)
+  assert [next(c) for _ in range(3)] == [10, 7.5, 5.0]
+  assert repr(c) == 'count(2.5, -2.5)', repr(c)
+  c = itertools.count(sys.maxint)
+  next(c)
+  assert next(c) == sys.maxint + 1
+  try:
+    itertools.count('a')
+  except TypeError as e:
+    assert str(e) == 'a number is required', str(e)
+  else:
+    raise AssertionError
+
+
+def TestRepeat():
+  assert list(itertools.repeat('a', 3)) == ['a', 'a', 'a']
+  assert list(itertools.repeat('a', -1)) == []
+  r = itertools.repeat(1, 2)
+  assert repr(r) == 'repeat(1, 2)', repr(r)
+  assert r.__length_hint__() == 2
+  assert repr(itertools.repeat('a')) == "repeat('a')"
+  assert list(itertools.islice(itertools.repeat(None), 4)) == [None] * 4
+
+
+def TestCompress():
+  got = list(itertools.compress('ABCDEF', [1, 0, 1, 0, 1, 1]))
+  assert got == ['A', 'C', 'E', 'F'], got
+
+
+def TestIMap():
+  got = list(itertools.imap(lambda a, b: a * b, [1, 2, 3], [4, 5]))
+  assert got == [4, 10], got
+  got = list(itertools.imap(None, 'ab', 'xyz'))
+  assert got == [('a', 'x'), ('b', 'y')], got
+
+
+def TestIZip():
+  assert list(itertools.izip('ab', [1, 2, 3])) == [('a', 1), ('b', 2)]
+  assert list(itertools.izip()) == []
+  try:
+    itertools.izip('ab', 1)
+  except TypeError as e:
+    assert str(e) == 'izip argument #2 must support iteration', str(e)
+  else:
+    raise AssertionError
+
+
+def TestStarmap():
+  got = list(itertools.starmap(lambda a, b: a - b, [(5, 2), [7, 3]]))
+  assert got == [3, 4], got
+
+
+def TestTee():
+  a, b = itertools.tee(range(200))
+  assert list(itertools.islice(a, 100)) == range(100)
+  c = a.__copy__()
+  assert list(b) == range(200)
+  assert list(a) == range(100, 200)
+  assert list(c) == range(100, 200)
+  assert itertools.tee('ab', 0) == ()
+  x, y, z = itertools.tee(iter('abc'), 3)
+  assert (next(z), next(y), next(x)) == ('a', 'a', 'a')
+  assert list(x) + list(y) + list(z) == list('bcbcbc')
+
+
+def TestSubclass():
+  class Evens(itertools.count):
+    def __new__(cls, start=0):
+      return super(Evens, cls).__new__(cls, start, 2)
+  e = Evens()
+  e.name = 'evens'
+  assert isinstance(e, Evens) and e.name == 'evens'
+  assert (next(e), next(e), next(e)) == (0, 2, 4)
+
+
 if __name__ == '__main__':
   weetest.RunTests()
@@ -0,0 +1,99 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import marshal
+import os
+import tempfile
+
+import weetest
+
+
+def TestDumps():
+  # These match the output of CPython's marshal.
+  assert marshal.dumps(None) == 'N'
+  assert marshal.dumps(StopIteration) == 'S'
+  assert marshal.dumps(Ellipsis) == '.'
+  assert marshal.dumps(-1) == 'i\xff\xff\xff\xff'
+  assert marshal.dumps(1099511627776) == 'I\x00\x00\x00\x00\x00\x01\x00\x00'
+  assert marshal.dumps(2 ** 64) == 'l\x05\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x10\x00'
+  assert marshal.dumps(0.5) == 'g\x00\x00\x00\x00\x00\x00\xe0?'
+  assert marshal.dumps(0.5, 1) == 'f\x030.5'
+  assert marshal.dumps(1.5+2.5j, 1) == 'x\x031.5\x032.5'
+  assert marshal.dumps(u'\xe9') == 'u\x02\x00\x00\x00\xc3\xa9'
+  assert marshal.dumps(['abc', 'abc']) == '[\x02\x00\x00\x00t\x03\x00\x00\x00abcR\x00\x00\x00\x00'
+  assert marshal.dumps(['abc', 'abc'], 0) == '[\x02\x00\x00\x00s\x03\x00\x00\x00abcs\x03\x00\x00\x00abc'
+  assert marshal.dumps({'a': None}) == '{t\x01\x00\x00\x00aN0'
+  assert marshal.dumps(frozenset()) == '>\x00\x00\x00\x00'
+
+
+def TestRoundTrip():
+  values = [None, True, False, StopIteration, Ellipsis, 0, -2 ** 31,
+            2 ** 62, -2 ** 100, 0.0, -2.5e-300, float('inf'), 1j, '',
+            'a b', u'', u'\u20ac', (), (1, 'a'), [[2]], {'a': [1]},
+            set([1, 2]), frozenset(['a'])]
+  for version in range(marshal.version + 1):
+    for v in values:
+      assert marshal.loads(marshal.dumps(v, version)) == v, (v, version)
+
+
+def TestLoadsErrors():
+  for s, exc in [('', EOFError), ('z', ValueError), ('s\x05\x00\x00\x00ab', EOFError),
+                 ('R\x00\x00\x00\x00', ValueError), (1, TypeError)]:
+    try:
+      marshal.loads(s)
+    except exc:
+      pass
+    else:
+      raise AssertionError(s)
+  assert marshal.loads('Nextra') is None
+
+
+def TestUnmarshallable():
+  for v in [object(), [len], marshal]:
+    try:
+      marshal.dumps(v)
+    except ValueError:
+      pass
+    else:
+      raise AssertionError(v)
+
+
+def TestDumpLoadFile():
+  fd, path = tempfile.mkstemp()
+  os.close(fd)
+  try:
+    with open(path, 'wb') as f:
+      marshal.dump({'a': (1, 2.5)}, f)
+      marshal.dump('second', f, 1)
+    with open(path, 'rb') as f:
+      assert marshal.load(f) == {'a': (1, 2.5)}
+      assert marshal.load(f) == 'second'
+      try:
+        marshal.load(f)
+      except EOFError:
+        pass
+      else:
+        raise AssertionError
+  finally:
+    os.remove(path)
+  try:
+    marshal.dump(1, 'not a file')
+  except TypeError:
+    pass
+  else:
+    raise AssertionError
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
@@ -15,193 +15,191 @@
 """Miscellaneous operating system interfaces."""
 
 # pylint: disable=g-multiple-import
-from '__go__/io/ioutil' import ReadDir
-from '__go__/os' import (Chdir, Chmod, Environ, Getpid as getpid, Getwd, Pipe,
-    ProcAttr, Remove, StartProcess, Stat, Stdout, Stdin,
-    Stderr, Mkdir)
 from '__go__/path/filepath' import Separator
-from '__go__/grumpy' import (NewFileFromFD, StartThread, ToNative)
-from '__go__/reflect' import MakeSlice
-from '__go__/runtime' import GOOS
-from '__go__/syscall' import (Close, SYS_FCNTL, Syscall, F_GETFD, Wait4,
-    WaitStatus, WNOHANG)
-from '__go__/sync' import WaitGroup
-from '__go__/time' import Second
-import _syscall
+from '__go__/grumpy' import NewFileFromFD
+import errno
 from os import path
-import stat as stat_module
-import sys
+import posix
+from posix import (
+    F_OK, O_APPEND, O_CREAT, O_EXCL, O_NOCTTY, O_NONBLOCK, O_RDONLY, O_RDWR,
+    O_SYNC, O_TRUNC, O_WRONLY, R_OK, W_OK, WEXITSTATUS, WIFEXITED, WIFSIGNALED,
+    WIFSTOPPED, WNOHANG, WSTOPSIG, WTERMSIG, WUNTRACED, X_OK, _exit, access,
+    chdir, chmod, chown, close, dup, dup2, error, fstat, getcwd, getegid,
+    geteuid, getgid, getpid, getppid, getuid, kill, link, listdir, lseek, lstat,
+    mkdir, open, pipe, putenv, read, readlink, remove, rename, rmdir, stat,
+    stat_result, strerror, symlink, umask, unlink, unsetenv, urandom, utime,
+    waitpid, write)
+import UserDict
 
 
+name = 'posix'
 sep = chr(Separator)
-error = OSError  # pylint: disable=invalid-name
+altsep = None
 curdir = '.'
-name = 'posix'
+pardir = '..'
+extsep = '.'
+pathsep = ':'
+linesep = '\n'
+defpath = ':/bin:/usr/bin'
+devnull = '/dev/null'
+
+SEEK_SET = 0
+SEEK_CUR = 1
+SEEK_END = 2
+
+
+class _Environ(UserDict.IterableUserDict):
+  """Mapping of environment variables that writes changes through to the
+  process environment so that they're visible to child processes."""
+
+  def __init__(self, environ):
+    UserDict.UserDict.__init__(self)
+    # The variables are already in the process environment so there's no
+    # need to putenv() them again.
+    self.data = environ
+
+  def __setitem__(self, key, item):
+    putenv(key, item)
+    self.data[key] = item
 
+  def __delitem__(self, key):
+    unsetenv(key)
+    del self.data[key]
 
-environ = {}
-for var in Environ():
-  k, v = var.split('=', 1)
-  environ[k] = v
+  def clear(self):
+    for key in self.data.keys():
+      unsetenv(key)
+    self.data.clear()
 
+  def pop(self, key, *args):
+    unsetenv(key)
+    return self.data.pop(key, *args)
 
-def mkdir(path, mode=0o777):
-  err = Mkdir(path, mode)
-  if err:
-    raise OSError(err.Error())
+  def update(self, other=None, **kwargs):
+    if other is not None:
+      if hasattr(other, 'keys'):
+        for key in other.keys():
+          self[key] = other[key]
+      else:
+        for key, item in other:
+          self[key] = item
+    for key, item in kwargs.iteritems():
+      self[key] = item
 
+  def setdefault(self, key, failobj=None):
+    if key not in self:
+      self[key] = failobj
+    return self[key]
 
-def chdir(path):
-  err = Chdir(path)
-  if err:
-    raise OSError(err.Error())
+  def copy(self):
+    return dict(self)
 
 
-def chmod(filepath, mode):
-  # TODO: Support mode flags other than perms.
-  err = Chmod(filepath, stat(filepath).st_mode & ~0o777 | mode & 0o777)
-  if err:
-    raise OSError(err.Error())
+environ = _Environ(posix.environ)
 
 
-def close(fd):
-  err = Close(fd)
-  if err:
-    raise OSError(err.Error())
+def getenv(key, default=None):
+  return environ.get(key, default)
 
 
 def fdopen(fd, mode='r'):  # pylint: disable=unused-argument
   # Ensure this is a valid file descriptor to match CPython behavior.
-  _, _, err = Syscall(SYS_FCNTL, fd, F_GETFD, 0)
-  if err:
-    raise OSError(err.Error())
+  fstat(fd)
   return NewFileFromFD(fd, None)
 
 
-def listdir(p):
-  files, err = ReadDir(p)
-  if err:
-    raise OSError(err.Error())
-  return [x.Name() for x in files]
-
-
-def getcwd():
-  dir, err = Getwd()
-  if err:
-    raise OSError(err.Error())
-  return dir
-
-
-class _Popen(object):
-
-  def __init__(self, command, mode):
-    self.mode = mode
-    self.result = None
-    self.r, self.w, err = Pipe()
-    if err:
-      raise OSError(err.Error())
-    attr = ProcAttr.new()
-    # Create a slice using a reflect.Type returned by ToNative.
-    # TODO: There should be a cleaner way to create slices in Python.
-    files_type = ToNative(__frame__(), attr.Files).Type()
-    files = MakeSlice(files_type, 3, 3).Interface()
-    if self.mode == 'r':
-      fd = self.r.Fd()
-      files[0], files[1], files[2] = Stdin, self.w, Stderr
-    elif self.mode == 'w':
-      fd = self.w.Fd()
-      files[0], files[1], files[2] = self.r, Stdout, Stderr
+def makedirs(name, mode=0o777):  # pylint: disable=redefined-outer-name
+  """Super-mkdir; create a leaf directory and all intermediate ones."""
+  head, tail = path.split(name)
+  if not tail:
+    head, tail = path.split(head)
+  if head and tail and not path.exists(head):
+    try:
+      makedirs(head, mode)
+    except OSError as e:
+      # Be happy if someone already created the path.
+      if e.errno != errno.EEXIST:
+        raise
+    if tail == curdir:
+      return
+  mkdir(name, mode)
+
+
+def removedirs(name):  # pylint: disable=redefined-outer-name
+  """Remove a leaf directory and all empty intermediate ones."""
+  rmdir(name)
+  head, tail = path.split(name)
+  if not tail:
+    head, tail = path.split(head)
+  while head and tail:
+    try:
+      rmdir(head)
+    except OSError:
+      break
+    head, tail = path.split(head)
+
+
+def walk(top, topdown=True, onerror=None, followlinks=False):
+  """Directory tree generator yielding (dirpath, dirnames, filenames)."""
+  try:
+    names = listdir(top)
+  except OSError as err:
+    if onerror is not None:
+      onerror(err)
+    return
+  dirs, nondirs = [], []
+  for name in names:  # pylint: disable=redefined-outer-name
+    if path.isdir(path.join(top, name)):
+      dirs.append(name)
     else:
-      raise ValueError('invalid popen mode: %r', self.mode)
-    attr.Files = files
-    # TODO: There should be a cleaner way to create slices in Python.
-    args_type = ToNative(__frame__(), StartProcess).Type().In(1)
-    args = MakeSlice(args_type, 3, 3).Interface()
-    shell = environ['SHELL']
-    args[0] = shell
-    args[1] = '-c'
-    args[2] = command
-    self.proc, err = StartProcess(shell, args, attr)
-    if err:
-      raise OSError(err.Error())
-    self.wg = WaitGroup.new()
-    self.wg.Add(1)
-    StartThread(self._thread_func)
-    self.file = NewFileFromFD(fd, self.close)
-
-  def _thread_func(self):
-    self.result = self.proc.Wait()
-    if self.mode == 'r':
-      self.w.Close()
-    self.wg.Done()
-
-  def close(self, _):
-    if self.mode == 'w':
-      self.w.Close()
-    self.wg.Wait()
-    state, err = self.result
-    if err:
-      raise OSError(err.Error())
-    return state.Sys() 
-
-
-def popen(command, mode='r'):
-  return _Popen(command, mode).file
-
-
-def remove(filepath):
-  if stat_module.S_ISDIR(stat(filepath).st_mode):
-    raise OSError('Operation not permitted: ' + filepath)
-  err = Remove(filepath)
-  if err:
-    raise OSError(err.Error())
-
-
-def rmdir(filepath):
-  if not stat_module.S_ISDIR(stat(filepath).st_mode):
-    raise OSError('Operation not permitted: ' + filepath)
-  err = Remove(filepath)
-  if err:
-    raise OSError(err.Error())
-
-
-class StatResult(object):
-
-  def __init__(self, info):
-    self._info = info
-
-  def st_mode(self):
-    # TODO: This is an incomplete mode flag. It should include S_IFDIR, etc.
-    return self._info.Mode()
-  # TODO: Make this a decorator once they're implemented.
-  st_mode = property(st_mode)
-
-  def st_mtime(self):
-    return float(self._info.ModTime().UnixNano()) / Second
-  # TODO: Make this a decorator once they're implemented.
-  st_mtime = property(st_mtime)
-
-  def st_size(self):
-    return self._info.Size()
-  # TODO: Make this a decorator once they're implemented.
-  st_size = property(st_size)
-
-
-def stat(filepath):
-  info, err = Stat(filepath)
-  if err:
-    raise OSError(err.Error())
-  return StatResult(info)
-
-
-unlink = remove
-
-
-def waitpid(pid, options):
-  status = WaitStatus.new()
-  _syscall.invoke(Wait4, pid, status, options, None)
-  return pid, _encode_wait_result(status)
-
-
-def _encode_wait_result(status):
-  return status.Signal() | (status.ExitStatus() << 8)
+      nondirs.append(name)
+  if topdown:
+    yield top, dirs, nondirs
+  for name in dirs:
+    new_path = path.join(top, name)
+    if followlinks or not path.islink(new_path):
+      for x in walk(new_path, topdown, onerror, followlinks):
+        yield x
+  if not topdown:
+    yield top, dirs, nondirs
+
+
+class _wrap_close(object):
+  """The file object returned by popen(). Closing it waits for the command to
+  exit and returns its exit status encoded like waitpid() does."""
+
+  def __init__(self, stream, proc):
+    self._stream = stream
+    self._proc = proc
+
+  def close(self):
+    self._stream.close()
+    returncode = self._proc.wait()
+    if returncode < 0:
+      # The command was killed by signal -returncode.
+      return -returncode
+    return returncode << 8
+
+  def __enter__(self):
+    return self
+
+  def __exit__(self, *args):
+    self.close()
+
+  def __getattr__(self, attr):
+    return getattr(self._stream, attr)
+
+  def __iter__(self):
+    return iter(self._stream)
+
+
+def popen(command, mode='r', bufsize=-1):  # pylint: disable=unused-argument
+  """Open a pipe to or from command, which is run by /bin/sh."""
+  import subprocess  # pylint: disable=g-import-not-at-top
+  if mode[:1] == 'r':
+    proc = subprocess.Popen(command, shell=True, stdout=subprocess.PIPE)
+    return _wrap_close(proc.stdout, proc)
+  if mode[:1] == 'w':
+    proc = subprocess.Popen(command, shell=True, stdin=subprocess.PIPE)
+    return _wrap_close(proc.stdin, proc)
+  raise ValueError('invalid popen mode: %r' % mode)
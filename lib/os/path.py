@@ -14,7 +14,7 @@
 
 """"Utilities for manipulating and inspecting OS paths."""
 
-from '__go__/os' import Stat
+from '__go__/os' import Lstat, ModeSymlink, Stat  # pylint: disable=g-multiple-import
 from '__go__/path/filepath' import Abs, Base, Clean, Dir as dirname, IsAbs as isabs, Join, Split  # pylint: disable=g-multiple-import,unused-import
 
 
@@ -52,6 +52,13 @@ def isfile(path):
   return False
 
 
+def islink(path):
+  info, err = Lstat(path)
+  if info and err is None:
+    return info.Mode() & ModeSymlink != 0
+  return False
+
+
 # NOTE(compatibility): This method uses Go's filepath.Join() method which
 # implicitly normalizes the resulting path (pruning extra /, .., etc.) The usual
 # CPython behavior is to leave all the cruft. This deviation is reasonable
@@ -12,6 +12,7 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
+import errno
 import os
 import stat
 import time
@@ -243,6 +244,102 @@ def TestStatNoExist():
     os.rmdir(path)
 
 
+def TestFileDescriptors():
+  tempdir = tempfile.mkdtemp()
+  path = os.path.join(tempdir, 'foo')
+  try:
+    fd = os.open(path, os.O_CREAT | os.O_WRONLY, 0o600)
+    assert os.write(fd, 'foobar') == 6
+    os.close(fd)
+    fd = os.open(path, os.O_RDONLY)
+    try:
+      assert os.lseek(fd, 3, os.SEEK_SET) == 3
+      assert os.read(fd, 10) == 'bar'
+    finally:
+      os.close(fd)
+  finally:
+    os.remove(path)
+    os.rmdir(tempdir)
+
+
+def TestMakedirsAndWalk():
+  tempdir = tempfile.mkdtemp()
+  try:
+    os.makedirs(os.path.join(tempdir, 'a', 'b'))
+    os.close(os.open(os.path.join(tempdir, 'a', 'foo'), os.O_CREAT, 0o600))
+    got = sorted((p[len(tempdir):], sorted(d), sorted(f))
+                 for p, d, f in os.walk(tempdir))
+    assert got == [('', ['a'], []), ('/a', ['b'], ['foo']), ('/a/b', [], [])]
+    try:
+      os.makedirs(os.path.join(tempdir, 'a'))
+    except OSError as e:
+      assert e.errno == errno.EEXIST
+    else:
+      raise AssertionError
+  finally:
+    for p, _, files in os.walk(tempdir, topdown=False):
+      for name in files:
+        os.remove(os.path.join(p, name))
+      os.rmdir(p)
+
+
+def TestOSErrorErrno():
+  try:
+    os.stat('/nonexistent')
+  except OSError as e:
+    assert e.errno == errno.ENOENT
+    assert e.filename == '/nonexistent'
+    assert str(e) == "[Errno 2] No such file or directory: '/nonexistent'"
+  else:
+    raise AssertionError
+
+
+def TestPutenv():
+  os.environ['GRUMPY_OS_TEST'] = 'foo'
+  assert os.popen('echo $GRUMPY_OS_TEST').read() == 'foo\n'
+  del os.environ['GRUMPY_OS_TEST']
+  assert os.popen('echo $GRUMPY_OS_TEST').read() == '\n'
+  assert os.getenv('GRUMPY_OS_TEST') is None
+
+
+def TestRename():
+  tempdir = tempfile.mkdtemp()
+  src, dst = os.path.join(tempdir, 'foo'), os.path.join(tempdir, 'bar')
+  try:
+    os.close(os.open(src, os.O_CREAT, 0o600))
+    os.rename(src, dst)
+    assert os.listdir(tempdir) == ['bar']
+  finally:
+    os.remove(dst)
+    os.rmdir(tempdir)
+
+
+def TestSymlink():
+  tempdir = tempfile.mkdtemp()
+  link = os.path.join(tempdir, 'link')
+  try:
+    os.symlink(tempdir, link)
+    assert os.readlink(link) == tempdir
+    assert stat.S_ISLNK(os.lstat(link).st_mode)
+    assert stat.S_ISDIR(os.stat(link).st_mode)
+  finally:
+    os.remove(link)
+    os.rmdir(tempdir)
+
+
+def TestUtime():
+  fd, path = tempfile.mkstemp()
+  os.close(fd)
+  try:
+    os.utime(path, (100, 200.5))
+    st = os.stat(path)
+    assert st.st_atime == 100
+    assert st.st_mtime == 200.5
+    assert st[stat.ST_MTIME] == 200
+  finally:
+    os.remove(path)
+
+
 def TestWaitPid():
   try:
     pid, status = os.waitpid(-1, os.WNOHANG)
@@ -18,39 +18,70 @@ import random
 import weetest
 
 
-def TestGrumpyRandom():
-  assert len(_random._gorandom(5)) == 5
+def TestRandomGenerator():
+  r = _random.Random(42)
+  assert [r.random() for _ in range(3)] == [
+      0.6394267984578837, 0.025010755222666936, 0.27502931836911926]
 
-  assert _random._int_bit_length(0) == 0
-  assert _random._int_bit_length(1) == 1
-  assert _random._int_bit_length(8) == 4
-  assert _random._int_bit_length(256) == 9
+  r.seed(12345678901234567890123L)
+  assert r.getrandbits(32) == 678764485
+  assert r.getrandbits(70) == 682277483887105743172L
+  assert r.getrandbits(5) == 4
 
-  assert _random._int_from_bytes([1, 0, 0, 0]) == 1
-  assert _random._int_from_bytes([0, 0, 0, 0]) == 0
-  assert _random._int_from_bytes([255, 255, 0, 0]) == 65535
-  assert _random._int_from_bytes([0, 0, 0, 1]) == 16777216
+  r.seed(-42)
+  assert r.random() == 0.6394267984578837
+  r.seed("hello")
+  assert r.random() == 0.8180391270568783
 
-  r = _random.GrumpyRandom()
-  assert 0.0 <= r.random() < 1.0
-
-  assert 0 <= r.getrandbits(1) <= 1
-  assert 0 <= r.getrandbits(2) <= 3
-  assert 0 <= r.getrandbits(8) <= 255
+  try:
+    r.getrandbits(0)
+  except ValueError:
+    pass
+  else:
+    raise AssertionError("ValueError not raised")
 
-  assert 0 <= r._randbelow(1) < 1
-  assert 0 <= r._randbelow(3) < 3
-  assert 0 <= r._randbelow(1000) < 1000
 
+def TestRandomState():
+  r = random.Random(0)
+  state = r.getstate()
+  a = [r.random() for _ in range(700)]
+  r.setstate(state)
+  assert [r.random() for _ in range(700)] == a
+  r.setstate(state)
+  r.jumpahead(100)
+  b = r.random()
+  r.setstate(state)
+  r.jumpahead(100)
+  assert r.random() == b != a[0]
+
+  r.seed(0)
+  r.jumpahead(100)
+  assert r.random() == 0.2485427422412062
+  r.seed(0)
+  r.jumpahead(10**30)
+  assert r.random() == 0.0071307681691135105
 
-def TestSeed():
-  random.seed()
   try:
-    random.seed("wrongtype")
-  except TypeError:
+    r.setstate((3, (1, 2, 3), None))
+  except ValueError:
     pass
   else:
-    raise AssertionError("TypeError not raised")
+    raise AssertionError("ValueError not raised")
+
+
+def TestRandomRange():
+  r = random.Random(7)
+  assert r.randrange(10**20) == 35931773795037525048L
+  assert r.randint(1, 6) == 3
+  assert r.choice('abcdef') == 'a'
+
+
+def TestSeed():
+  random.seed()
+  random.seed("hello")
+  a = random.random()
+  random.seed("hello")
+  assert random.random() == a
 
 
 def TestRandom():
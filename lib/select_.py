@@ -18,12 +18,20 @@ from '__go__/syscall' import (
     FdSet as _FdSet,
     Timeval as _Timeval
 )
+import _select
+# pylint: disable=g-multiple-import
+from _select import (
+    POLLERR, POLLHUP, POLLIN, POLLNVAL, POLLOUT, POLLPRI, POLLRDBAND,
+    POLLRDNORM, POLLWRBAND, POLLWRNORM, error, poll, wait_readable,
+    wait_writable)
 import _syscall
 import math
 
-
-class error(Exception):
-  pass
+if hasattr(_select, 'epoll'):
+  epoll = _select.epoll
+  for _name in dir(_select):
+    if _name.startswith('EPOLL'):
+      globals()[_name] = getattr(_select, _name)
 
 
 def select(rlist, wlist, xlist, timeout=None):
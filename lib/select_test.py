@@ -0,0 +1,207 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import errno
+import os
+import select_ as select
+import socket
+import tempfile
+import threading
+import time
+
+import weetest
+
+
+def _SocketPair():
+  server = socket.socket()
+  server.bind(('127.0.0.1', 0))
+  server.listen(1)
+  client = socket.create_connection(server.getsockname())
+  conn, _ = server.accept()
+  server.close()
+  return client, conn
+
+
+def TestPoll():
+  r, w = os.pipe()
+  try:
+    p = select.poll()
+    p.register(r, select.POLLIN)
+    p.register(w)
+    assert p.poll(0) == [(w, select.POLLOUT)]
+    os.write(w, 'foo')
+    assert p.poll() == [(r, select.POLLIN), (w, select.POLLOUT)]
+    p.modify(w, select.POLLIN)
+    assert p.poll(1000) == [(r, select.POLLIN)]
+    p.unregister(r)
+    assert p.poll(10) == []
+  finally:
+    os.close(r)
+    os.close(w)
+
+
+def TestPollErrors():
+  p = select.poll()
+  try:
+    p.unregister(123)
+  except KeyError:
+    pass
+  else:
+    raise AssertionError
+  try:
+    p.modify(123, select.POLLIN)
+  except IOError as e:
+    assert e.errno == errno.ENOENT
+  else:
+    raise AssertionError
+  for fd, exc in [(-1, ValueError), ('foo', TypeError)]:
+    try:
+      p.register(fd)
+    except exc:
+      pass
+    else:
+      raise AssertionError(fd)
+
+
+def TestPollFileno():
+  client, conn = _SocketPair()
+  p = select.poll()
+  p.register(conn, select.POLLIN)
+  assert p.poll(0) == []
+  client.sendall('foo')
+  assert p.poll(1000) == [(conn.fileno(), select.POLLIN)]
+  client.close()
+  conn.close()
+
+
+def TestPollTimeout():
+  r, w = os.pipe()
+  try:
+    p = select.poll()
+    p.register(r, select.POLLIN)
+    start = time.time()
+    assert p.poll(50) == []
+    assert time.time() - start >= 0.04
+  finally:
+    os.close(r)
+    os.close(w)
+
+
+def TestEpoll():
+  if not hasattr(select, 'epoll'):
+    return
+  ep = select.epoll()
+  r, w = os.pipe()
+  try:
+    assert not ep.closed
+    ep.register(r, select.EPOLLIN)
+    assert ep.poll(0) == []
+    os.write(w, 'foo')
+    assert ep.poll(1) == [(r, select.EPOLLIN)]
+    ep.modify(r, select.EPOLLIN | select.EPOLLET)
+    assert ep.poll(1) == [(r, select.EPOLLIN)]
+    assert ep.poll(0.01) == []
+    try:
+      ep.register(r)
+    except IOError as e:
+      assert e.errno == errno.EEXIST
+    else:
+      raise AssertionError
+    ep.unregister(r)
+    try:
+      ep.poll(0, 0)
+    except ValueError:
+      pass
+    else:
+      raise AssertionError
+  finally:
+    ep.close()
+    os.close(r)
+    os.close(w)
+  assert ep.closed
+  try:
+    ep.fileno()
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestWaitSocket():
+  client, conn = _SocketPair()
+  assert not select.wait_readable(conn, 0)
+  assert not select.wait_readable(conn, 0.01)
+  assert select.wait_writable(client, 0)
+  client.sendall('foo')
+  assert select.wait_readable(conn)
+  assert select.wait_readable(conn, 0)
+  assert conn.recv(3) == 'foo'
+  client.close()
+  conn.close()
+  try:
+    select.wait_readable(conn)
+  except socket.error as e:
+    assert e.errno == errno.EBADF
+  else:
+    raise AssertionError
+
+
+def TestWaitDoesNotBlockOtherThreads():
+  client, conn = _SocketPair()
+  result = []
+  t = threading.Thread(target=lambda: result.append(select.wait_readable(conn)))
+  t.start()
+  # The thread above is parked waiting for conn. This thread must still be
+  # able to run and wake it.
+  time.sleep(0.01)
+  client.sendall('foo')
+  t.join()
+  assert result == [True]
+  client.close()
+  conn.close()
+
+
+def TestWaitFile():
+  r, w = os.pipe()
+  f = os.fdopen(r, 'r')
+  try:
+    assert not select.wait_readable(f, 0.01)
+    assert select.wait_writable(w, 0)
+    os.write(w, 'foo\nbar\n')
+    assert select.wait_readable(f, 1)
+    assert f.readline() == 'foo\n'
+    # The rest of the data is buffered in f so it is ready even though the
+    # pipe is now empty.
+    assert select.wait_readable(f, 0)
+  finally:
+    f.close()
+    os.close(w)
+  try:
+    select.wait_readable(f)
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+  fd, path = tempfile.mkstemp()
+  try:
+    with open(path) as f:
+      assert select.wait_readable(f)
+    assert select.wait_writable(fd)
+  finally:
+    os.close(fd)
+    os.remove(path)
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
@@ -0,0 +1,63 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Low-level networking interface."""
+
+import _socket
+# pylint: disable=g-multiple-import
+from _socket import (
+    AF_INET, AF_INET6, AF_UNIX, AF_UNSPEC, AI_CANONNAME, AI_NUMERICHOST,
+    AI_PASSIVE, EAI_AGAIN, EAI_FAMILY, EAI_NONAME, EAI_SERVICE, IPPROTO_IP,
+    IPPROTO_IPV6, IPPROTO_TCP, IPPROTO_UDP, IPV6_V6ONLY, MSG_DONTWAIT, MSG_OOB,
+    MSG_PEEK, MSG_WAITALL, SHUT_RD, SHUT_RDWR, SHUT_WR, SOCK_DGRAM, SOCK_RAW,
+    SOCK_STREAM, SOL_SOCKET, SOMAXCONN, SO_BROADCAST, SO_ERROR, SO_KEEPALIVE,
+    SO_LINGER, SO_RCVBUF, SO_REUSEADDR, SO_SNDBUF, SO_TYPE, TCP_NODELAY, error,
+    gaierror, getaddrinfo, getdefaulttimeout, gethostbyname, gethostname,
+    herror, setdefaulttimeout, socket, timeout)
+
+
+SocketType = socket
+
+_GLOBAL_DEFAULT_TIMEOUT = object()
+
+
+def create_connection(address, timeout=_GLOBAL_DEFAULT_TIMEOUT,
+                      source_address=None):
+  """Connect to address, a (host, port) tuple, and return the socket.
+
+  Each address host resolves to is tried in turn until one succeeds. If
+  timeout is given it is set on the socket before connecting, otherwise the
+  global default timeout is used. If source_address is given the socket is
+  bound to it before connecting.
+  """
+  host, port = address
+  err = None
+  for family, socktype, proto, _, sa in getaddrinfo(host, port, 0,
+                                                    SOCK_STREAM):
+    sock = None
+    try:
+      sock = socket(family, socktype, proto)
+      if timeout is not _GLOBAL_DEFAULT_TIMEOUT:
+        sock.settimeout(timeout)
+      if source_address:
+        sock.bind(source_address)
+      sock.connect(sa)
+      return sock
+    except error as e:
+      err = e
+      if sock is not None:
+        sock.close()
+  if err is not None:
+    raise err  # pylint: disable=raising-bad-type
+  raise error('getaddrinfo returns an empty list')
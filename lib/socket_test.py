@@ -0,0 +1,283 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import errno
+import os
+import socket
+import tempfile
+import threading
+
+import weetest
+
+
+def _Listener(family=socket.AF_INET, addr=('127.0.0.1', 0)):
+  sock = socket.socket(family, socket.SOCK_STREAM)
+  sock.bind(addr)
+  sock.listen(5)
+  return sock
+
+
+def _Echo(server):
+  conn, _ = server.accept()
+  while True:
+    data = conn.recv(1024)
+    if not data:
+      break
+    conn.sendall(data)
+  conn.close()
+
+
+def _StartThread(target, *args):
+  t = threading.Thread(target=target, args=args)
+  t.start()
+  return t
+
+
+def TestTCPEcho():
+  server = _Listener()
+  host, port = server.getsockname()
+  assert host == '127.0.0.1' and port > 0
+  t = _StartThread(_Echo, server)
+  client = socket.create_connection(('127.0.0.1', port))
+  assert client.getpeername() == ('127.0.0.1', port)
+  client.sendall('foo' * 10000)
+  data = ''
+  while len(data) < 30000:
+    data += client.recv(65536)
+  assert data == 'foo' * 10000
+  client.close()
+  t.join()
+  server.close()
+
+
+def TestBlockingCallsDoNotBlockOtherThreads():
+  server = _Listener()
+  port = server.getsockname()[1]
+  result = []
+  t = _StartThread(lambda: result.append(server.accept()))
+  # The thread above is blocked in accept. Connecting from this thread must
+  # still make progress.
+  client = socket.socket()
+  client.connect(('127.0.0.1', port))
+  t.join()
+  conn, addr = result[0]
+  assert addr == client.getsockname()
+  conn.close()
+  client.close()
+  server.close()
+
+
+def TestClose():
+  server = _Listener()
+  errors = []
+  def Accept():
+    try:
+      server.accept()
+    except socket.error as e:
+      errors.append(e.errno)
+  t = _StartThread(Accept)
+  server.close()
+  t.join()
+  assert errors == [errno.EBADF]
+  server.close()
+  try:
+    server.fileno()
+  except socket.error as e:
+    assert e.errno == errno.EBADF
+  else:
+    raise AssertionError
+
+
+def TestConnectRefused():
+  server = _Listener()
+  addr = server.getsockname()
+  server.close()
+  sock = socket.socket()
+  assert sock.connect_ex(addr) == errno.ECONNREFUSED
+  sock.close()
+  sock = socket.socket()
+  try:
+    sock.connect(addr)
+  except socket.error as e:
+    assert e.errno == errno.ECONNREFUSED
+    assert isinstance(e, IOError)
+  else:
+    raise AssertionError
+  sock.close()
+
+
+def TestTimeout():
+  server = _Listener()
+  assert server.gettimeout() is None
+  server.settimeout(0.05)
+  assert server.gettimeout() == 0.05
+  try:
+    server.accept()
+  except socket.timeout as e:
+    assert str(e) == 'timed out'
+    assert isinstance(e, socket.error)
+  else:
+    raise AssertionError
+  server.close()
+
+
+def TestDefaultTimeout():
+  assert socket.getdefaulttimeout() is None
+  socket.setdefaulttimeout(1.5)
+  try:
+    sock = socket.socket()
+    assert sock.gettimeout() == 1.5
+    sock.close()
+  finally:
+    socket.setdefaulttimeout(None)
+  try:
+    socket.setdefaulttimeout(-1)
+  except ValueError:
+    pass
+  else:
+    raise AssertionError
+
+
+def TestNonBlocking():
+  server = _Listener()
+  server.setblocking(False)
+  assert server.gettimeout() == 0.0
+  try:
+    server.accept()
+  except socket.error as e:
+    assert e.errno == errno.EAGAIN
+  else:
+    raise AssertionError
+  client = socket.socket()
+  client.setblocking(False)
+  err = client.connect_ex(server.getsockname())
+  assert err in (0, errno.EINPROGRESS)
+  client.close()
+  server.close()
+
+
+def TestMakefile():
+  server = _Listener()
+  t = _StartThread(_Echo, server)
+  client = socket.create_connection(server.getsockname())
+  w = client.makefile('wb')
+  r = client.makefile('rb')
+  w.write('foo\nbar\n')
+  w.close()
+  assert r.readline() == 'foo\n'
+  assert r.readline() == 'bar\n'
+  client.shutdown(socket.SHUT_WR)
+  assert r.read() == ''
+  r.close()
+  client.close()
+  t.join()
+  server.close()
+
+
+def TestRecvInto():
+  server = _Listener()
+  t = _StartThread(_Echo, server)
+  client = socket.create_connection(server.getsockname())
+  client.sendall('foobar')
+  buf = bytearray(3)
+  assert client.recv_into(buf) == 3
+  assert str(buf) == 'foo'
+  assert client.recv_into(buf, 2) == 2
+  assert str(buf) == 'bao'
+  assert client.recv(1) == 'r'
+  client.close()
+  t.join()
+  server.close()
+
+
+def TestUDP():
+  a = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+  a.bind(('127.0.0.1', 0))
+  b = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+  assert b.sendto('foo', a.getsockname()) == 3
+  data, addr = a.recvfrom(1024)
+  assert data == 'foo'
+  assert addr == ('127.0.0.1', b.getsockname()[1])
+  a.sendto('bar', 0, addr)
+  assert b.recv(1024) == 'bar'
+  a.close()
+  b.close()
+
+
+def TestUnix():
+  tmpdir = tempfile.mkdtemp()
+  path = os.path.join(tmpdir, 'sock')
+  try:
+    server = _Listener(socket.AF_UNIX, path)
+    assert server.getsockname() == path
+    t = _StartThread(_Echo, server)
+    client = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
+    client.connect(path)
+    client.sendall('foo')
+    assert client.recv(1024) == 'foo'
+    client.close()
+    t.join()
+    server.close()
+  finally:
+    os.remove(path)
+    os.rmdir(tmpdir)
+
+
+def TestSockopt():
+  sock = socket.socket()
+  sock.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+  assert sock.getsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR) != 0
+  assert sock.getsockopt(socket.SOL_SOCKET, socket.SO_TYPE) == socket.SOCK_STREAM
+  sock.setsockopt(socket.IPPROTO_TCP, socket.TCP_NODELAY, 1)
+  assert sock.getsockopt(socket.IPPROTO_TCP, socket.TCP_NODELAY) != 0
+  sock.close()
+
+
+def TestSockaddrErrors():
+  sock = socket.socket()
+  for addr, exc in [('foo', TypeError), (('127.0.0.1',), TypeError),
+                    (('127.0.0.1', 70000), OverflowError)]:
+    try:
+      sock.bind(addr)
+    except exc:
+      pass
+    else:
+      raise AssertionError(addr)
+  sock.close()
+
+
+def TestGetaddrinfo():
+  want = [(socket.AF_INET, socket.SOCK_STREAM, socket.IPPROTO_TCP, '',
+           ('127.0.0.1', 80))]
+  assert socket.getaddrinfo('127.0.0.1', 80, 0, socket.SOCK_STREAM) == want
+  assert socket.getaddrinfo('127.0.0.1', '80', socket.AF_INET,
+                            socket.SOCK_STREAM) == want
+  infos = socket.getaddrinfo(None, 0, socket.AF_INET6, 0, 0, socket.AI_PASSIVE)
+  assert [info[4] for info in infos] == [('::', 0, 0, 0)] * 2
+  try:
+    socket.getaddrinfo('foo.invalid', 80, 0, 0, 0, socket.AI_NUMERICHOST)
+  except socket.gaierror as e:
+    assert e.errno == socket.EAI_NONAME
+  else:
+    raise AssertionError
+
+
+def TestGethostbyname():
+  assert socket.gethostbyname('127.0.0.1') == '127.0.0.1'
+  assert socket.gethostbyname('localhost') == '127.0.0.1'
+  assert socket.gethostname()
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
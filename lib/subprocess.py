@@ -0,0 +1,188 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Subprocesses with accessible I/O streams."""
+
+import _subprocess
+from _subprocess import DEVNULL, PIPE, STDOUT  # pylint: disable=g-multiple-import
+
+
+__all__ = ['Popen', 'PIPE', 'STDOUT', 'DEVNULL', 'call', 'check_call',
+           'check_output', 'CalledProcessError', 'TimeoutExpired']
+
+
+class CalledProcessError(Exception):
+  """Raised when a process run by check_call() or check_output() returns a
+  non-zero exit status."""
+
+  def __init__(self, returncode, cmd, output=None):
+    super(CalledProcessError, self).__init__(returncode, cmd, output)
+    self.returncode = returncode
+    self.cmd = cmd
+    self.output = output
+
+  def __str__(self):
+    return "Command '%s' returned non-zero exit status %d" % (
+        self.cmd, self.returncode)
+
+
+class TimeoutExpired(Exception):
+  """Raised when the timeout expires while waiting for a child process."""
+
+  def __init__(self, cmd, timeout, output=None):
+    super(TimeoutExpired, self).__init__(cmd, timeout, output)
+    self.cmd = cmd
+    self.timeout = timeout
+    self.output = output
+
+  def __str__(self):
+    return "Command '%s' timed out after %s seconds" % (self.cmd, self.timeout)
+
+
+def _stream_arg(stream):
+  if stream is None or isinstance(stream, int):
+    return stream
+  return stream.fileno()
+
+
+class Popen(object):
+  """Execute a child program in a new process.
+
+  NOTE(compatibility): Descriptors opened by Grumpy are never inherited by the
+  child (see os.open) so close_fds is effectively always true. preexec_fn,
+  universal_newlines and the Windows only arguments are not supported.
+  """
+
+  def __init__(self, args, bufsize=0, executable=None, stdin=None,
+               stdout=None, stderr=None, preexec_fn=None, close_fds=False,  # pylint: disable=unused-argument
+               shell=False, cwd=None, env=None, universal_newlines=False,
+               startupinfo=None, creationflags=0):
+    if preexec_fn is not None:
+      raise NotImplementedError('preexec_fn is not supported')
+    if universal_newlines:
+      raise NotImplementedError('universal_newlines is not supported')
+    if startupinfo is not None or creationflags:
+      raise ValueError('startupinfo and creationflags are only supported on '
+                       'Windows platforms')
+    self.args = args
+    self.returncode = None
+    if isinstance(args, basestring):
+      args = [args]
+    else:
+      args = list(args)
+    if shell:
+      args = ['/bin/sh', '-c'] + args
+      if executable:
+        args[0] = executable
+    if env is not None:
+      env = dict(env)
+    self._proc = _subprocess.spawn(
+        args, executable, cwd, env, _stream_arg(stdin), _stream_arg(stdout),
+        _stream_arg(stderr))
+    self.pid = self._proc.pid
+    self.stdin = self._proc.stdin
+    self.stdout = self._proc.stdout
+    self.stderr = self._proc.stderr
+
+  def __enter__(self):
+    return self
+
+  def __exit__(self, *args):
+    for f in (self.stdin, self.stdout, self.stderr):
+      if f:
+        f.close()
+    self.wait()
+
+  def poll(self):
+    """Check if the child process has terminated and return its returncode,
+    or None if it is still running."""
+    if self.returncode is None:
+      self.returncode = self._proc.poll()
+    return self.returncode
+
+  def wait(self, timeout=None):
+    """Wait for the child process to terminate and return its returncode."""
+    if self.returncode is None:
+      returncode = self._proc.wait(timeout)
+      if returncode is None:
+        raise TimeoutExpired(self.args, timeout)
+      self.returncode = returncode
+    return self.returncode
+
+  def communicate(self, input=None, timeout=None):  # pylint: disable=redefined-builtin
+    """Send input to stdin and read stdout and stderr until EOF, then wait
+    for the process to terminate. Returns a tuple (stdout, stderr).
+
+    If the timeout expires TimeoutExpired is raised but the child is not
+    killed. A subsequent call continues the same exchange, ignoring input.
+    """
+    result = self._proc.communicate(input, timeout)
+    if result is None:
+      raise TimeoutExpired(self.args, timeout)
+    self.wait()
+    return result
+
+  def send_signal(self, sig):
+    self._proc.send_signal(sig)
+
+  def terminate(self):
+    self._proc.terminate()
+
+  def kill(self):
+    self._proc.kill()
+
+
+def call(*popenargs, **kwargs):
+  """Run command with arguments and return its returncode."""
+  timeout = kwargs.pop('timeout', None)
+  p = Popen(*popenargs, **kwargs)
+  try:
+    return p.wait(timeout)
+  except TimeoutExpired:
+    p.kill()
+    p.wait()
+    raise
+
+
+def check_call(*popenargs, **kwargs):
+  """Run command with arguments, raising CalledProcessError if its returncode
+  is non-zero."""
+  retcode = call(*popenargs, **kwargs)
+  if retcode:
+    cmd = kwargs.get('args')
+    if cmd is None:
+      cmd = popenargs[0]
+    raise CalledProcessError(retcode, cmd)
+  return 0
+
+
+def check_output(*popenargs, **kwargs):
+  """Run command with arguments and return its output, raising
+  CalledProcessError if its returncode is non-zero."""
+  if 'stdout' in kwargs:
+    raise ValueError('stdout argument not allowed, it will be overridden.')
+  timeout = kwargs.pop('timeout', None)
+  input = kwargs.pop('input', None)  # pylint: disable=redefined-builtin
+  if input is not None:
+    kwargs['stdin'] = PIPE
+  p = Popen(stdout=PIPE, *popenargs, **kwargs)
+  try:
+    output, _ = p.communicate(input, timeout)
+  except TimeoutExpired:
+    p.kill()
+    output, _ = p.communicate()
+    raise TimeoutExpired(p.args, timeout, output)
+  if p.returncode:
+    raise CalledProcessError(p.returncode, p.args, output)
+  return output
@@ -0,0 +1,157 @@
+# Copyright 2016 Google Inc. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+import errno
+import os
+import subprocess
+import tempfile
+
+import weetest
+
+
+def TestCall():
+  assert subprocess.call(['true']) == 0
+  assert subprocess.call('exit 3', shell=True) == 3
+
+
+def TestCallTimeout():
+  try:
+    subprocess.call(['sleep', '10'], timeout=0.1)
+  except subprocess.TimeoutExpired as e:
+    assert e.timeout == 0.1
+  else:
+    raise AssertionError
+
+
+def TestCheckCall():
+  assert subprocess.check_call(['true']) == 0
+  try:
+    subprocess.check_call(['false'])
+  except subprocess.CalledProcessError as e:
+    assert e.returncode == 1
+    assert e.cmd == ['false']
+  else:
+    raise AssertionError
+
+
+def TestCheckOutput():
+  assert subprocess.check_output(['echo', 'foo']) == 'foo\n'
+  assert subprocess.check_output('echo bar 1>&2', shell=True,
+                                 stderr=subprocess.STDOUT) == 'bar\n'
+  assert subprocess.check_output(['cat'], input='baz') == 'baz'
+  try:
+    subprocess.check_output('echo qux; exit 2', shell=True)
+  except subprocess.CalledProcessError as e:
+    assert e.returncode == 2
+    assert e.output == 'qux\n'
+  else:
+    raise AssertionError
+
+
+def TestCommunicateLargeOutput():
+  # Both pipes exceed the pipe buffer size so reading them one after the other
+  # would deadlock.
+  data = 'x' * (1 << 20)
+  p = subprocess.Popen(['sh', '-c', 'tee /dev/stderr'], stdin=subprocess.PIPE,
+                       stdout=subprocess.PIPE, stderr=subprocess.PIPE)
+  out, err = p.communicate(data)
+  assert out == data
+  assert err == data
+  assert p.returncode == 0
+
+
+def TestCommunicateTimeout():
+  p = subprocess.Popen(['sh', '-c', 'echo foo; sleep 10'],
+                       stdout=subprocess.PIPE)
+  try:
+    p.communicate(timeout=0.1)
+  except subprocess.TimeoutExpired:
+    pass
+  else:
+    raise AssertionError
+  p.kill()
+  out, err = p.communicate()
+  assert out == 'foo\n'
+  assert err is None
+  assert p.returncode == -9
+
+
+def TestCwdAndEnv():
+  tempdir = tempfile.mkdtemp()
+  path = os.path.join(tempdir, 'foo')
+  os.close(os.open(path, os.O_CREAT, 0o600))
+  try:
+    out = subprocess.check_output('echo *; echo $FOO', shell=True, cwd=tempdir,
+                                  env={'FOO': 'bar'})
+    assert out == 'foo\nbar\n', out
+  finally:
+    os.remove(path)
+    os.rmdir(tempdir)
+
+
+def TestDevNull():
+  p = subprocess.Popen(['cat'], stdin=subprocess.DEVNULL,
+                       stdout=subprocess.PIPE)
+  assert p.communicate() == ('', None)
+
+
+def TestFileStreams():
+  fd, path = tempfile.mkstemp()
+  try:
+    f = os.fdopen(fd, 'w')
+    assert subprocess.call(['echo', 'foo'], stdout=f) == 0
+    f.close()
+    with open(path) as f:
+      assert f.read() == 'foo\n'
+  finally:
+    os.remove(path)
+
+
+def TestPollAndTerminate():
+  p = subprocess.Popen(['sleep', '10'])
+  assert p.poll() is None
+  p.terminate()
+  assert p.wait() == -15
+  assert p.poll() == -15
+
+
+def TestPopenNoSuchFile():
+  try:
+    subprocess.Popen(['/nonexistent'])
+  except OSError as e:
+    assert e.errno == errno.ENOENT
+  else:
+    raise AssertionError
+
+
+def TestPopenStdoutRead():
+  p = subprocess.Popen(['echo', 'foo'], stdout=subprocess.PIPE)
+  assert p.stdout.read() == 'foo\n'
+  assert p.wait() == 0
+
+
+def TestWaitTimeout():
+  p = subprocess.Popen(['sleep', '10'])
+  try:
+    p.wait(timeout=0.1)
+  except subprocess.TimeoutExpired as e:
+    assert e.cmd == ['sleep', '10']
+  else:
+    raise AssertionError
+  p.kill()
+  assert p.wait() == -9
+
+
+if __name__ == '__main__':
+  weetest.RunTests()
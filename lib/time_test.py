@@ -12,6 +12,7 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
+import os
 import time
 
 assert time.time() > 1000000000
@@ -20,3 +21,47 @@ assert time.time() < 3000000000
 time_struct = (1999, 9, 19, 0, 0, 0, 6, 262, 0)
 got = time.localtime(time.mktime(time_struct))
 assert got == time_struct, got
+
+t = time.struct_time((2009, 2, 3, 16, 5, 6, 1, 34, 0))
+assert t.tm_year == 2009 and t.tm_yday == 34 and t.tm_isdst == 0
+assert repr(t).startswith('time.struct_time(tm_year=2009, tm_mon=2,'), repr(t)
+try:
+  time.struct_time((1, 2, 3))
+except TypeError:
+  pass
+else:
+  raise AssertionError
+
+assert time.strftime('%Y-%m-%d %H:%M:%S', t) == '2009-02-03 16:05:06'
+assert time.strftime('%a %A %b %B %j %U %W %w', t) == \
+    'Tue Tuesday Feb February 034 05 05 2'
+assert time.strftime('%c|%x|%X', t) == \
+    'Tue Feb  3 16:05:06 2009|02/03/09|16:05:06'
+assert time.asctime(t) == 'Tue Feb  3 16:05:06 2009'
+assert time.strptime('2009-02-03 16:05:06', '%Y-%m-%d %H:%M:%S') == \
+    (2009, 2, 3, 16, 5, 6, 1, 34, -1)
+assert time.strptime(time.asctime(t))[:8] == t[:8]
+try:
+  time.strptime('2009', '%m')
+except ValueError:
+  pass
+else:
+  raise AssertionError
+
+m = time.monotonic()
+assert time.monotonic() >= m
+assert time.perf_counter() >= 0
+assert time.clock() >= 0
+
+os.environ['TZ'] = 'EST+05EDT,M3.2.0,M11.1.0'
+time.tzset()
+assert time.timezone == 18000 and time.altzone == 14400, time.timezone
+assert time.daylight == 1
+assert time.tzname == ('EST', 'EDT'), time.tzname
+assert time.localtime(1246406400)[3:] == (20, 0, 0, 1, 181, 1)
+assert time.mktime((2009, 7, 1, 0, 0, 0, 0, 0, -1)) == 1246420800
+assert time.strftime('%Z %z', time.localtime(1230768000)) == 'EST -0500'
+os.environ['TZ'] = 'UTC'
+time.tzset()
+assert time.timezone == 0 and time.daylight == 0
+assert time.ctime(0) == 'Thu Jan  1 00:00:00 1970'
@@ -0,0 +1,1021 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+var (
+	// arrayType corresponds to the Python type 'array.array'.
+	arrayType = newBasisType("array", reflect.TypeOf(array{}), toArrayUnsafe, ObjectType)
+	// arrayDescrs maps each typecode to the descriptor of its elements.
+	arrayDescrs = map[byte]*arrayDescr{
+		'c': {typecode: 'c', elemType: reflect.TypeOf(byte(0)), get: arrayCharGet, set: arrayCharSet},
+		'b': newArrayIntDescr('b', int8(0), math.MinInt8, math.MaxInt8, "signed char is less than minimum", "signed char is greater than maximum"),
+		'B': newArrayIntDescr('B', uint8(0), 0, math.MaxUint8, "unsigned byte integer is less than minimum", "unsigned byte integer is greater than maximum"),
+		'u': {typecode: 'u', elemType: reflect.TypeOf(rune(0)), get: arrayUnicodeGet, set: arrayUnicodeSet},
+		'h': newArrayIntDescr('h', int16(0), math.MinInt16, math.MaxInt16, "signed short integer is less than minimum", "signed short integer is greater than maximum"),
+		'H': newArrayIntDescr('H', uint16(0), 0, math.MaxUint16, "unsigned short is less than minimum", "unsigned short is greater than maximum"),
+		'i': newArrayIntDescr('i', int32(0), math.MinInt32, math.MaxInt32, "signed integer is less than minimum", "signed integer is greater than maximum"),
+		'I': newArrayIntDescr('I', uint32(0), 0, math.MaxUint32, "unsigned int is less than minimum", "unsigned int is greater than maximum"),
+		'l': newArrayIntDescr('l', int64(0), math.MinInt64, math.MaxInt64, "Python int too large to convert to C long", "Python int too large to convert to C long"),
+		'L': newArrayIntDescr('L', uint64(0), 0, math.MaxUint64, "unsigned long is less than minimum", "long int too large to convert"),
+		'f': {typecode: 'f', elemType: reflect.TypeOf(float32(0)), get: arrayFloatGet, set: arrayFloatSet},
+		'd': {typecode: 'd', elemType: reflect.TypeOf(float64(0)), get: arrayFloatGet, set: arrayFloatSet},
+	}
+)
+
+// arrayDescr describes the elements stored in arrays of a particular
+// typecode.
+type arrayDescr struct {
+	typecode byte
+	elemType reflect.Type
+	// get returns the Python object for the element v.
+	get func(v reflect.Value) *Object
+	// set stores the Python object o in the addressable element v.
+	set func(f *Frame, v reflect.Value, o *Object) *BaseException
+}
+
+func newArrayIntDescr(typecode byte, zero interface{}, min int64, max uint64, lessMsg, greaterMsg string) *arrayDescr {
+	elemType := reflect.TypeOf(zero)
+	signed := elemType.Kind() >= reflect.Int && elemType.Kind() <= reflect.Int64
+	minBig, maxBig := big.NewInt(min), new(big.Int).SetUint64(max)
+	d := &arrayDescr{typecode: typecode, elemType: elemType}
+	switch {
+	case signed:
+		d.get = func(v reflect.Value) *Object {
+			return NewInt(int(v.Int())).ToObject()
+		}
+	case elemType.Size() < 4:
+		d.get = func(v reflect.Value) *Object {
+			return NewInt(int(v.Uint())).ToObject()
+		}
+	default:
+		// Like CPython, 'I' and 'L' elements are always longs.
+		d.get = func(v reflect.Value) *Object {
+			return NewLong(new(big.Int).SetUint64(v.Uint())).ToObject()
+		}
+	}
+	d.set = func(f *Frame, v reflect.Value, o *Object) *BaseException {
+		var n *big.Int
+		switch {
+		case o.isInstance(IntType):
+			i := toIntUnsafe(o).Value()
+			if int64(i) < min {
+				return f.RaiseType(OverflowErrorType, lessMsg)
+			}
+			if i > 0 && uint64(i) > max {
+				return f.RaiseType(OverflowErrorType, greaterMsg)
+			}
+			if signed {
+				v.SetInt(int64(i))
+			} else {
+				v.SetUint(uint64(i))
+			}
+			return nil
+		case o.isInstance(LongType):
+			n = toLongUnsafe(o).Value()
+		case o.isInstance(FloatType):
+			return f.RaiseType(TypeErrorType, "integer argument expected, got float")
+		default:
+			return f.RaiseType(TypeErrorType, "an integer is required")
+		}
+		if n.Cmp(minBig) < 0 {
+			return f.RaiseType(OverflowErrorType, lessMsg)
+		}
+		if n.Cmp(maxBig) > 0 {
+			return f.RaiseType(OverflowErrorType, greaterMsg)
+		}
+		if signed {
+			v.SetInt(n.Int64())
+		} else {
+			v.SetUint(n.Uint64())
+		}
+		return nil
+	}
+	return d
+}
+
+func arrayCharGet(v reflect.Value) *Object {
+	return NewStr(string([]byte{byte(v.Uint())})).ToObject()
+}
+
+func arrayCharSet(f *Frame, v reflect.Value, o *Object) *BaseException {
+	if !o.isInstance(StrType) || len(toStrUnsafe(o).Value()) != 1 {
+		return f.RaiseType(TypeErrorType, "array item must be char")
+	}
+	v.SetUint(uint64(toStrUnsafe(o).Value()[0]))
+	return nil
+}
+
+func arrayFloatGet(v reflect.Value) *Object {
+	return NewFloat(v.Float()).ToObject()
+}
+
+func arrayFloatSet(f *Frame, v reflect.Value, o *Object) *BaseException {
+	x, ok := floatCoerce(o)
+	if !ok {
+		if o.isInstance(LongType) {
+			return f.RaiseType(OverflowErrorType, "long int too large to convert to float")
+		}
+		return f.RaiseType(TypeErrorType, "a float is required")
+	}
+	v.SetFloat(x)
+	return nil
+}
+
+func arrayUnicodeGet(v reflect.Value) *Object {
+	return NewUnicodeFromRunes([]rune{rune(v.Int())}).ToObject()
+}
+
+func arrayUnicodeSet(f *Frame, v reflect.Value, o *Object) *BaseException {
+	if !o.isInstance(UnicodeType) || len(toUnicodeUnsafe(o).Value()) != 1 {
+		return f.RaiseType(TypeErrorType, "array item must be unicode character")
+	}
+	v.SetInt(int64(toUnicodeUnsafe(o).Value()[0]))
+	return nil
+}
+
+// array represents Python 'array.array' objects. Elements are stored
+// unboxed in a Go slice whose element type is determined by the typecode.
+type array struct {
+	Object
+	mutex sync.RWMutex
+	descr *arrayDescr
+	// value is a slice of descr.elemType.
+	value reflect.Value
+}
+
+func newArray(t *Type, descr *arrayDescr, value reflect.Value) *array {
+	a := toArrayUnsafe(newObject(t))
+	a.descr = descr
+	a.value = value
+	return a
+}
+
+func toArrayUnsafe(o *Object) *array {
+	return (*array)(o.toPointer())
+}
+
+// ToObject upcasts a to an Object.
+func (a *array) ToObject() *Object {
+	return &a.Object
+}
+
+// bytes returns the memory backing a's elements. The caller must hold
+// a.mutex.
+func (a *array) bytes() []byte {
+	return arrayBytes(a.value)
+}
+
+// elems returns the elements of a as Python objects.
+func (a *array) elems() []*Object {
+	a.mutex.RLock()
+	n := a.value.Len()
+	elems := make([]*Object, n)
+	for i := 0; i < n; i++ {
+		elems[i] = a.descr.get(a.value.Index(i))
+	}
+	a.mutex.RUnlock()
+	return elems
+}
+
+// snapshot returns a copy of the slice backing a.
+func (a *array) snapshot() reflect.Value {
+	a.mutex.RLock()
+	n := a.value.Len()
+	value := arrayCopy(a.value, 0, 1, n)
+	a.mutex.RUnlock()
+	return value
+}
+
+// appendObjects converts each of objs to a's element type and appends them
+// to a. Nothing is appended if any conversion fails.
+func (a *array) appendObjects(f *Frame, objs []*Object) *BaseException {
+	value := reflect.MakeSlice(a.value.Type(), len(objs), len(objs))
+	for i, o := range objs {
+		if raised := a.descr.set(f, value.Index(i), o); raised != nil {
+			return raised
+		}
+	}
+	a.mutex.Lock()
+	a.value = reflect.AppendSlice(a.value, value)
+	a.mutex.Unlock()
+	return nil
+}
+
+// extend appends the elements of iterable to a. If iterable is an array it
+// must have the same typecode as a.
+func (a *array) extend(f *Frame, iterable *Object) *BaseException {
+	if iterable.isInstance(arrayType) {
+		other := toArrayUnsafe(iterable)
+		if other.descr != a.descr {
+			return f.RaiseType(TypeErrorType, "can only extend with array of same kind")
+		}
+		value := other.snapshot()
+		a.mutex.Lock()
+		a.value = reflect.AppendSlice(a.value, value)
+		a.mutex.Unlock()
+		return nil
+	}
+	elem := reflect.New(a.descr.elemType).Elem()
+	return seqForEach(f, iterable, func(o *Object) *BaseException {
+		if raised := a.descr.set(f, elem, o); raised != nil {
+			return raised
+		}
+		a.mutex.Lock()
+		a.value = reflect.Append(a.value, elem)
+		a.mutex.Unlock()
+		return nil
+	})
+}
+
+// fromBytes appends the elements encoded in b, whose length must be a
+// multiple of the item size, to a.
+func (a *array) fromBytes(b []byte) {
+	n := len(b) / int(a.descr.elemType.Size())
+	value := reflect.MakeSlice(a.value.Type(), n, n)
+	copy(arrayBytes(value), b)
+	a.mutex.Lock()
+	a.value = reflect.AppendSlice(a.value, value)
+	a.mutex.Unlock()
+}
+
+func arrayAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	a := toArrayUnsafe(v)
+	if !w.isInstance(arrayType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("can only append array (not \"%s\") to array", w.typ.Name()))
+	}
+	other := toArrayUnsafe(w)
+	if other.descr != a.descr {
+		return nil, f.RaiseType(TypeErrorType, "bad argument type for built-in operation")
+	}
+	tail := other.snapshot()
+	value := a.snapshot()
+	return newArray(arrayType, a.descr, reflect.AppendSlice(value, tail)).ToObject(), nil
+}
+
+func arrayAppend(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "append", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := toArrayUnsafe(args[0]).appendObjects(f, args[1:]); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func arrayBufferInfo(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "buffer_info", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	a.mutex.RLock()
+	address, n := 0, a.value.Len()
+	if n > 0 {
+		address = int(a.value.Pointer())
+	}
+	a.mutex.RUnlock()
+	return NewTuple2(NewInt(address).ToObject(), NewInt(n).ToObject()).ToObject(), nil
+}
+
+func arrayByteswap(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "byteswap", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	a.mutex.Lock()
+	b, itemSize := a.bytes(), int(a.descr.elemType.Size())
+	for i := 0; i < len(b); i += itemSize {
+		for j, k := i, i+itemSize-1; j < k; j, k = j+1, k-1 {
+			b[j], b[k] = b[k], b[j]
+		}
+	}
+	a.mutex.Unlock()
+	return None, nil
+}
+
+func arrayContains(f *Frame, v, w *Object) (*Object, *BaseException) {
+	i, raised := seqFindElem(f, toArrayUnsafe(v).elems(), w)
+	if raised != nil {
+		return nil, raised
+	}
+	return GetBool(i != -1).ToObject(), nil
+}
+
+func arrayCopyMethod(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "__copy__", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	return newArray(arrayType, a.descr, a.snapshot()).ToObject(), nil
+}
+
+func arrayCount(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "count", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return seqCount(f, NewTuple(toArrayUnsafe(args[0]).elems()...).ToObject(), args[1])
+}
+
+func arrayDelItem(f *Frame, o, key *Object) *BaseException {
+	a := toArrayUnsafe(o)
+	if key.typ.slots.Index != nil {
+		index, raised := IndexInt(f, key)
+		if raised != nil {
+			return raised
+		}
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		n := a.value.Len()
+		if index < 0 {
+			index += n
+		}
+		if index < 0 || index >= n {
+			return f.RaiseType(IndexErrorType, "array assignment index out of range")
+		}
+		reflect.Copy(a.value.Slice(index, n), a.value.Slice(index+1, n))
+		a.value = a.value.Slice(0, n-1)
+		return nil
+	}
+	if key.isInstance(SliceType) {
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		n := a.value.Len()
+		start, _, step, sliceLen, raised := toSliceUnsafe(key).calcSlice(f, n)
+		if raised != nil {
+			return raised
+		}
+		if sliceLen == 0 {
+			return nil
+		}
+		if step < 0 {
+			start, step = start+step*(sliceLen-1), -step
+		}
+		j := start
+		for i := start; i < n; i++ {
+			if d := i - start; d%step != 0 || d/step >= sliceLen {
+				a.value.Index(j).Set(a.value.Index(i))
+				j++
+			}
+		}
+		a.value = a.value.Slice(0, j)
+		return nil
+	}
+	return f.RaiseType(TypeErrorType, "array indices must be integers")
+}
+
+func arrayEq(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return arrayCompare(f, v, w, Eq)
+}
+
+func arrayExtend(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "extend", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := toArrayUnsafe(args[0]).extend(f, args[1]); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func arrayFromFile(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fromfile", args, arrayType, ObjectType, IntType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(FileType) {
+		return nil, f.RaiseType(TypeErrorType, "arg1 must be open file")
+	}
+	a, file, n := toArrayUnsafe(args[0]), toFileUnsafe(args[1]), toIntUnsafe(args[2]).Value()
+	if n < 0 {
+		return nil, f.RaiseType(ValueErrorType, "negative count")
+	}
+	itemSize := int(a.descr.elemType.Size())
+	if n > MaxInt/itemSize {
+		return nil, f.RaiseType(MemoryErrorType, "")
+	}
+	file.mutex.Lock()
+	if !file.open {
+		file.mutex.Unlock()
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	data := make([]byte, n*itemSize)
+	numRead, err := io.ReadFull(file.reader, data)
+	file.mutex.Unlock()
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, f.RaiseType(IOErrorType, err.Error())
+	}
+	a.fromBytes(data[:numRead-numRead%itemSize])
+	if numRead < len(data) {
+		return nil, f.RaiseType(EOFErrorType, "not enough items in file")
+	}
+	return None, nil
+}
+
+func arrayFromList(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fromlist", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(ListType) {
+		return nil, f.RaiseType(TypeErrorType, "arg must be list")
+	}
+	l := toListUnsafe(args[1])
+	l.mutex.RLock()
+	elems := make([]*Object, len(l.elems))
+	copy(elems, l.elems)
+	l.mutex.RUnlock()
+	if raised := toArrayUnsafe(args[0]).appendObjects(f, elems); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func arrayFromString(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fromstring", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	if args[1] == args[0] {
+		return nil, f.RaiseType(ValueErrorType, "array.fromstring(x): x cannot be self")
+	}
+	raised := bufferApply(f, args[1], func(b []byte) *BaseException {
+		if len(b)%int(a.descr.elemType.Size()) != 0 {
+			return f.RaiseType(ValueErrorType, "string length not a multiple of item size")
+		}
+		a.fromBytes(b)
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func arrayFromUnicode(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fromunicode", args, arrayType, UnicodeType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	if a.descr.typecode != 'u' {
+		return nil, f.RaiseType(ValueErrorType, "fromunicode() may only be called on type 'u' arrays")
+	}
+	a.mutex.Lock()
+	a.value = reflect.AppendSlice(a.value, reflect.ValueOf(toUnicodeUnsafe(args[1]).Value()))
+	a.mutex.Unlock()
+	return None, nil
+}
+
+func arrayGE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return arrayCompare(f, v, w, GE)
+}
+
+func arrayGetBuffer(f *Frame, o *Object, fun func([]byte) *BaseException) *BaseException {
+	a := toArrayUnsafe(o)
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return fun(a.bytes())
+}
+
+func arrayGetItem(f *Frame, o, key *Object) (*Object, *BaseException) {
+	a := toArrayUnsafe(o)
+	if key.typ.slots.Index != nil {
+		index, raised := IndexInt(f, key)
+		if raised != nil {
+			return nil, raised
+		}
+		a.mutex.RLock()
+		defer a.mutex.RUnlock()
+		n := a.value.Len()
+		if index < 0 {
+			index += n
+		}
+		if index < 0 || index >= n {
+			return nil, f.RaiseType(IndexErrorType, "array index out of range")
+		}
+		return a.descr.get(a.value.Index(index)), nil
+	}
+	if key.isInstance(SliceType) {
+		a.mutex.RLock()
+		defer a.mutex.RUnlock()
+		start, _, step, sliceLen, raised := toSliceUnsafe(key).calcSlice(f, a.value.Len())
+		if raised != nil {
+			return nil, raised
+		}
+		return newArray(arrayType, a.descr, arrayCopy(a.value, start, step, sliceLen)).ToObject(), nil
+	}
+	return nil, f.RaiseType(TypeErrorType, "array indices must be integers")
+}
+
+func arrayGetItemSize(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_itemsize", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	return NewInt(int(toArrayUnsafe(args[0]).descr.elemType.Size())).ToObject(), nil
+}
+
+func arrayGetTypecode(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_typecode", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	return NewStr(string([]byte{toArrayUnsafe(args[0]).descr.typecode})).ToObject(), nil
+}
+
+func arrayGT(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return arrayCompare(f, v, w, GT)
+}
+
+func arrayIAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(arrayType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("can only extend array with array (not \"%s\")", w.typ.Name()))
+	}
+	if raised := toArrayUnsafe(v).extend(f, w); raised != nil {
+		return nil, raised
+	}
+	return v, nil
+}
+
+func arrayIMul(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(IntType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("can't multiply sequence by non-int of type '%s'", w.typ.Name()))
+	}
+	a := toArrayUnsafe(v)
+	a.mutex.Lock()
+	value, raised := arrayRepeat(f, a.value, toIntUnsafe(w).Value())
+	if raised == nil {
+		a.value = value
+	}
+	a.mutex.Unlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return v, nil
+}
+
+func arrayIndex(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "index", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	i, raised := seqFindElem(f, toArrayUnsafe(args[0]).elems(), args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if i == -1 {
+		return nil, f.RaiseType(ValueErrorType, "array.index(x): x not in list")
+	}
+	return NewInt(i).ToObject(), nil
+}
+
+func arrayInsert(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "insert", args, arrayType, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(IntType) {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	a := toArrayUnsafe(args[0])
+	elem := reflect.New(a.descr.elemType).Elem()
+	if raised := a.descr.set(f, elem, args[2]); raised != nil {
+		return nil, raised
+	}
+	a.mutex.Lock()
+	n := a.value.Len()
+	i := seqClampIndex(toIntUnsafe(args[1]).Value(), n)
+	a.value = reflect.Append(a.value, elem)
+	reflect.Copy(a.value.Slice(i+1, n+1), a.value.Slice(i, n))
+	a.value.Index(i).Set(elem)
+	a.mutex.Unlock()
+	return None, nil
+}
+
+func arrayIter(f *Frame, o *Object) (*Object, *BaseException) {
+	return newSeqIterator(o), nil
+}
+
+func arrayLE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return arrayCompare(f, v, w, LE)
+}
+
+func arrayLen(f *Frame, o *Object) (*Object, *BaseException) {
+	a := toArrayUnsafe(o)
+	a.mutex.RLock()
+	n := a.value.Len()
+	a.mutex.RUnlock()
+	return NewInt(n).ToObject(), nil
+}
+
+func arrayLT(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return arrayCompare(f, v, w, LT)
+}
+
+func arrayMul(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(IntType) {
+		return NotImplemented, nil
+	}
+	a := toArrayUnsafe(v)
+	a.mutex.RLock()
+	value, raised := arrayRepeat(f, a.value, toIntUnsafe(w).Value())
+	a.mutex.RUnlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return newArray(arrayType, a.descr, value).ToObject(), nil
+}
+
+func arrayNative(f *Frame, o *Object) (reflect.Value, *BaseException) {
+	a := toArrayUnsafe(o)
+	a.mutex.RLock()
+	result := a.value
+	a.mutex.RUnlock()
+	return result, nil
+}
+
+func arrayNE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return arrayCompare(f, v, w, NE)
+}
+
+func arrayNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if t == arrayType && len(kwargs) > 0 {
+		return nil, f.RaiseType(TypeErrorType, "array.array() does not take keyword arguments")
+	}
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	argc := len(args)
+	if argc == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkFunctionArgs(f, "array", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	var typecode string
+	if args[0].isInstance(StrType) {
+		typecode = toStrUnsafe(args[0]).Value()
+	} else if args[0].isInstance(UnicodeType) {
+		if runes := toUnicodeUnsafe(args[0]).Value(); len(runes) == 1 && runes[0] < 128 {
+			typecode = string(runes)
+		}
+	}
+	if len(typecode) != 1 {
+		format := "array() argument 1 or typecode must be char (string or ascii-unicode with length 1), not %s"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, args[0].typ.Name()))
+	}
+	descr := arrayDescrs[typecode[0]]
+	if descr == nil {
+		return nil, f.RaiseType(ValueErrorType, "bad typecode (must be c, b, B, u, h, H, i, I, l, L, f or d)")
+	}
+	a := newArray(t, descr, reflect.MakeSlice(reflect.SliceOf(descr.elemType), 0, 0))
+	if argc > 1 {
+		init := args[1]
+		var raised *BaseException
+		switch {
+		case init.isInstance(StrType):
+			_, raised = arrayFromString(f, Args{a.ToObject(), init}, nil)
+		case init.isInstance(UnicodeType) && descr.typecode == 'u':
+			_, raised = arrayFromUnicode(f, Args{a.ToObject(), init}, nil)
+		case init.isInstance(arrayType):
+			// An array initializer is iterated even if the
+			// typecodes match.
+			raised = a.appendObjects(f, toArrayUnsafe(init).elems())
+		default:
+			raised = a.extend(f, init)
+		}
+		if raised != nil {
+			return nil, raised
+		}
+	}
+	return a.ToObject(), nil
+}
+
+func arrayPop(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{arrayType, ObjectType}
+	if argc == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "pop", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	i := -1
+	if argc == 2 {
+		if args[1].isInstance(FloatType) {
+			return nil, f.RaiseType(TypeErrorType, "integer argument expected, got float")
+		}
+		var raised *BaseException
+		if i, raised = ToIntValue(f, args[1]); raised != nil {
+			return nil, raised
+		}
+	}
+	a := toArrayUnsafe(args[0])
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	n := a.value.Len()
+	if n == 0 {
+		return nil, f.RaiseType(IndexErrorType, "pop from empty array")
+	}
+	if i < 0 {
+		i += n
+	}
+	if i < 0 || i >= n {
+		return nil, f.RaiseType(IndexErrorType, "pop index out of range")
+	}
+	item := a.descr.get(a.value.Index(i))
+	reflect.Copy(a.value.Slice(i, n), a.value.Slice(i+1, n))
+	a.value = a.value.Slice(0, n-1)
+	return item, nil
+}
+
+func arrayReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	typecode := NewStr(string([]byte{a.descr.typecode})).ToObject()
+	state := None
+	if d := a.Dict(); d != nil && d.Len() > 0 {
+		state = d.ToObject()
+	}
+	ctorArgs := NewTuple2(typecode, NewList(a.elems()...).ToObject()).ToObject()
+	return NewTuple(a.typ.ToObject(), ctorArgs, state).ToObject(), nil
+}
+
+func arrayRemove(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "remove", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	i, raised := seqFindElem(f, toArrayUnsafe(args[0]).elems(), args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if i == -1 {
+		return nil, f.RaiseType(ValueErrorType, "array.remove(x): x not in list")
+	}
+	if raised := arrayDelItem(f, args[0], NewInt(i).ToObject()); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func arrayRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	a := toArrayUnsafe(o)
+	typecode := a.descr.typecode
+	value := a.snapshot()
+	if value.Len() == 0 {
+		return NewStr(fmt.Sprintf("array('%c')", typecode)).ToObject(), nil
+	}
+	var initializer *Object
+	switch typecode {
+	case 'c':
+		initializer = NewStr(string(value.Bytes())).ToObject()
+	case 'u':
+		initializer = NewUnicodeFromRunes(value.Interface().([]rune)).ToObject()
+	default:
+		initializer = NewList(a.elems()...).ToObject()
+	}
+	s, raised := Repr(f, initializer)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(fmt.Sprintf("array('%c', %s)", typecode, s.Value())).ToObject(), nil
+}
+
+func arrayReverse(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "reverse", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	a.mutex.Lock()
+	swap := reflect.Swapper(a.value.Interface())
+	for i, j := 0, a.value.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+	a.mutex.Unlock()
+	return None, nil
+}
+
+func arraySetItem(f *Frame, o, key, value *Object) *BaseException {
+	a := toArrayUnsafe(o)
+	if key.typ.slots.Index != nil {
+		index, raised := IndexInt(f, key)
+		if raised != nil {
+			return raised
+		}
+		elem := reflect.New(a.descr.elemType).Elem()
+		if raised := a.descr.set(f, elem, value); raised != nil {
+			return raised
+		}
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		n := a.value.Len()
+		if index < 0 {
+			index += n
+		}
+		if index < 0 || index >= n {
+			return f.RaiseType(IndexErrorType, "array assignment index out of range")
+		}
+		a.value.Index(index).Set(elem)
+		return nil
+	}
+	if key.isInstance(SliceType) {
+		if !value.isInstance(arrayType) {
+			return f.RaiseType(TypeErrorType, fmt.Sprintf("can only assign array (not \"%s\") to array slice", value.typ.Name()))
+		}
+		other := toArrayUnsafe(value)
+		if other.descr != a.descr {
+			return f.RaiseType(TypeErrorType, "bad argument type for built-in operation")
+		}
+		elems := other.snapshot()
+		numElems := elems.Len()
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		n := a.value.Len()
+		start, _, step, sliceLen, raised := toSliceUnsafe(key).calcSlice(f, n)
+		if raised != nil {
+			return raised
+		}
+		if step == 1 {
+			result := reflect.MakeSlice(a.value.Type(), 0, n-sliceLen+numElems)
+			result = reflect.AppendSlice(result, a.value.Slice(0, start))
+			result = reflect.AppendSlice(result, elems)
+			a.value = reflect.AppendSlice(result, a.value.Slice(start+sliceLen, n))
+			return nil
+		}
+		if sliceLen != numElems {
+			format := "attempt to assign array of size %d to extended slice of size %d"
+			return f.RaiseType(ValueErrorType, fmt.Sprintf(format, numElems, sliceLen))
+		}
+		for i, j := 0, start; i < numElems; i, j = i+1, j+step {
+			a.value.Index(j).Set(elems.Index(i))
+		}
+		return nil
+	}
+	return f.RaiseType(TypeErrorType, "array indices must be integers")
+}
+
+func arrayToFile(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tofile", args, arrayType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(FileType) {
+		return nil, f.RaiseType(TypeErrorType, "arg must be open file")
+	}
+	a, file := toArrayUnsafe(args[0]), toFileUnsafe(args[1])
+	data := arrayBytes(a.snapshot())
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	if _, err := file.file.Write(data); err != nil {
+		return nil, f.RaiseType(IOErrorType, err.Error())
+	}
+	return None, nil
+}
+
+func arrayToList(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tolist", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	return NewList(toArrayUnsafe(args[0]).elems()...).ToObject(), nil
+}
+
+func arrayToString(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tostring", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	a.mutex.RLock()
+	s := string(a.bytes())
+	a.mutex.RUnlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func arrayToUnicode(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tounicode", args, arrayType); raised != nil {
+		return nil, raised
+	}
+	a := toArrayUnsafe(args[0])
+	if a.descr.typecode != 'u' {
+		return nil, f.RaiseType(ValueErrorType, "tounicode() may only be called on type 'u' arrays")
+	}
+	return NewUnicodeFromRunes(a.snapshot().Interface().([]rune)).ToObject(), nil
+}
+
+func initArrayType(dict map[string]*Object) {
+	dict["__copy__"] = newBuiltinFunction("__copy__", arrayCopyMethod).ToObject()
+	dict["__deepcopy__"] = newBuiltinFunction("__deepcopy__", arrayCopyMethod).ToObject()
+	dict["__module__"] = NewStr("array").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", arrayReduce).ToObject()
+	dict["append"] = newBuiltinFunction("append", arrayAppend).ToObject()
+	dict["buffer_info"] = newBuiltinFunction("buffer_info", arrayBufferInfo).ToObject()
+	dict["byteswap"] = newBuiltinFunction("byteswap", arrayByteswap).ToObject()
+	dict["count"] = newBuiltinFunction("count", arrayCount).ToObject()
+	dict["extend"] = newBuiltinFunction("extend", arrayExtend).ToObject()
+	dict["fromfile"] = newBuiltinFunction("fromfile", arrayFromFile).ToObject()
+	dict["fromlist"] = newBuiltinFunction("fromlist", arrayFromList).ToObject()
+	dict["fromstring"] = newBuiltinFunction("fromstring", arrayFromString).ToObject()
+	dict["fromunicode"] = newBuiltinFunction("fromunicode", arrayFromUnicode).ToObject()
+	dict["index"] = newBuiltinFunction("index", arrayIndex).ToObject()
+	dict["insert"] = newBuiltinFunction("insert", arrayInsert).ToObject()
+	dict["itemsize"] = newProperty(newBuiltinFunction("_get_itemsize", arrayGetItemSize).ToObject(), nil, nil).ToObject()
+	dict["pop"] = newBuiltinFunction("pop", arrayPop).ToObject()
+	dict["remove"] = newBuiltinFunction("remove", arrayRemove).ToObject()
+	dict["reverse"] = newBuiltinFunction("reverse", arrayReverse).ToObject()
+	dict["tofile"] = newBuiltinFunction("tofile", arrayToFile).ToObject()
+	dict["tolist"] = newBuiltinFunction("tolist", arrayToList).ToObject()
+	dict["tostring"] = newBuiltinFunction("tostring", arrayToString).ToObject()
+	dict["tounicode"] = newBuiltinFunction("tounicode", arrayToUnicode).ToObject()
+	dict["typecode"] = newProperty(newBuiltinFunction("_get_typecode", arrayGetTypecode).ToObject(), nil, nil).ToObject()
+	arrayType.slots.Add = &binaryOpSlot{arrayAdd}
+	arrayType.slots.Buffer = &bufferSlot{arrayGetBuffer}
+	arrayType.slots.Contains = &binaryOpSlot{arrayContains}
+	arrayType.slots.DelItem = &delItemSlot{arrayDelItem}
+	arrayType.slots.Eq = &binaryOpSlot{arrayEq}
+	arrayType.slots.GE = &binaryOpSlot{arrayGE}
+	arrayType.slots.GetItem = &binaryOpSlot{arrayGetItem}
+	arrayType.slots.GT = &binaryOpSlot{arrayGT}
+	arrayType.slots.Hash = &unaryOpSlot{hashNotImplemented}
+	arrayType.slots.IAdd = &binaryOpSlot{arrayIAdd}
+	arrayType.slots.IMul = &binaryOpSlot{arrayIMul}
+	arrayType.slots.Iter = &unaryOpSlot{arrayIter}
+	arrayType.slots.LE = &binaryOpSlot{arrayLE}
+	arrayType.slots.Len = &unaryOpSlot{arrayLen}
+	arrayType.slots.LT = &binaryOpSlot{arrayLT}
+	arrayType.slots.Mul = &binaryOpSlot{arrayMul}
+	arrayType.slots.Native = &nativeSlot{arrayNative}
+	arrayType.slots.NE = &binaryOpSlot{arrayNE}
+	arrayType.slots.New = &newSlot{arrayNew}
+	arrayType.slots.Repr = &unaryOpSlot{arrayRepr}
+	arrayType.slots.RMul = &binaryOpSlot{arrayMul}
+	arrayType.slots.SetItem = &setItemSlot{arraySetItem}
+}
+
+// arrayBytes returns the memory backing the elements of the slice value.
+func arrayBytes(value reflect.Value) []byte {
+	n := value.Len()
+	if n == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(value.UnsafePointer()), n*int(value.Type().Elem().Size()))
+}
+
+func arrayCompare(f *Frame, v, w *Object, cmp binaryOpFunc) (*Object, *BaseException) {
+	if !w.isInstance(arrayType) {
+		return NotImplemented, nil
+	}
+	return seqCompare(f, toArrayUnsafe(v).elems(), toArrayUnsafe(w).elems(), cmp)
+}
+
+// arrayCopy returns a new slice holding the sliceLen elements of value
+// starting at start and advancing by step.
+func arrayCopy(value reflect.Value, start, step, sliceLen int) reflect.Value {
+	result := reflect.MakeSlice(value.Type(), sliceLen, sliceLen)
+	if step == 1 {
+		reflect.Copy(result, value.Slice(start, start+sliceLen))
+		return result
+	}
+	for i, j := 0, start; i < sliceLen; i, j = i+1, j+step {
+		result.Index(i).Set(value.Index(j))
+	}
+	return result
+}
+
+func arrayRepeat(f *Frame, value reflect.Value, n int) (reflect.Value, *BaseException) {
+	numElems := value.Len()
+	if n <= 0 || numElems == 0 {
+		return reflect.MakeSlice(value.Type(), 0, 0), nil
+	}
+	if numElems > MaxInt/n {
+		return reflect.Value{}, f.RaiseType(OverflowErrorType, errResultTooLarge)
+	}
+	result := reflect.MakeSlice(value.Type(), 0, numElems*n)
+	for i := 0; i < n; i++ {
+		result = reflect.AppendSlice(result, value)
+	}
+	return result, nil
+}
+
+func init() {
+	RegisterModule("array", NewCode("<module>", "array", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		if raised := f.Globals().SetItemString(f, "array", arrayType.ToObject()); raised != nil {
+			return nil, raised
+		}
+		return nil, f.Globals().SetItemString(f, "ArrayType", arrayType.ToObject())
+	}))
+}
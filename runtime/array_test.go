@@ -0,0 +1,185 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestArrayNew(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		a, raised := arrayType.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewList(toArrayUnsafe(a).elems()...).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("i"), want: NewList().ToObject()},
+		{args: wrapArgs("b", newTestList(-128, 127)), want: newTestList(-128, 127).ToObject()},
+		{args: wrapArgs("I", newTestTuple(1, 4294967295)), want: newTestList(big.NewInt(1), big.NewInt(4294967295)).ToObject()},
+		{args: wrapArgs("d", newTestList(1, 2.5)), want: newTestList(1.0, 2.5).ToObject()},
+		{args: wrapArgs("c", "ab"), want: newTestList("a", "b").ToObject()},
+		{args: wrapArgs("u", NewUnicode("ab")), want: newTestList(NewUnicode("a"), NewUnicode("b")).ToObject()},
+		{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "array() argument 1 or typecode must be char (string or ascii-unicode with length 1), not int")},
+		{args: wrapArgs("x"), wantExc: mustCreateException(ValueErrorType, "bad typecode (must be c, b, B, u, h, H, i, I, l, L, f or d)")},
+		{args: wrapArgs("b", newTestList(128)), wantExc: mustCreateException(OverflowErrorType, "signed char is greater than maximum")},
+		{args: wrapArgs("H", newTestList(-1)), wantExc: mustCreateException(OverflowErrorType, "unsigned short is less than minimum")},
+		{args: wrapArgs("L", newTestList(new(big.Int).Lsh(big.NewInt(1), 64))), wantExc: mustCreateException(OverflowErrorType, "long int too large to convert")},
+		{args: wrapArgs("i", newTestList(1.5)), wantExc: mustCreateException(TypeErrorType, "integer argument expected, got float")},
+		{args: wrapArgs("d", newTestList("a")), wantExc: mustCreateException(TypeErrorType, "a float is required")},
+		{args: wrapArgs("c", newTestList("ab")), wantExc: mustCreateException(TypeErrorType, "array item must be char")},
+		{args: wrapArgs("i", "abc"), wantExc: mustCreateException(ValueErrorType, "string length not a multiple of item size")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestArrayGetItem(t *testing.T) {
+	a := newTestArray("h", 1, 2, 3, 4)
+	cases := []invokeTestCase{
+		{args: wrapArgs(a, 0), want: NewInt(1).ToObject()},
+		{args: wrapArgs(a, -1), want: NewInt(4).ToObject()},
+		{args: wrapArgs(a, newTestSlice(1, None)), want: newTestArray("h", 2, 3, 4)},
+		{args: wrapArgs(a, newTestSlice(None, None, -2)), want: newTestArray("h", 4, 2)},
+		{args: wrapArgs(a, 4), wantExc: mustCreateException(IndexErrorType, "array index out of range")},
+		{args: wrapArgs(a, "a"), wantExc: mustCreateException(TypeErrorType, "array indices must be integers")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(GetItem), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestArraySetItem(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, a, key, value *Object) (*Object, *BaseException) {
+		if raised := SetItem(f, a, key, value); raised != nil {
+			return nil, raised
+		}
+		return a, nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), 1, 5), want: newTestArray("i", 1, 5, 3)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), newTestSlice(1, 2), newTestArray("i", 7, 8)), want: newTestArray("i", 1, 7, 8, 3)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), newTestSlice(None, None, 2), newTestArray("i", 7, 8)), want: newTestArray("i", 7, 2, 8)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), newTestSlice(None, None, 2), newTestArray("i", 7)), wantExc: mustCreateException(ValueErrorType, "attempt to assign array of size 1 to extended slice of size 2")},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), newTestSlice(1, 2), newTestList(7)), wantExc: mustCreateException(TypeErrorType, `can only assign array (not "list") to array slice`)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), newTestSlice(1, 2), newTestArray("d", 7)), wantExc: mustCreateException(TypeErrorType, "bad argument type for built-in operation")},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), 3, 5), wantExc: mustCreateException(IndexErrorType, "array assignment index out of range")},
+		{args: wrapArgs(newTestArray("B", 1), 0, 256), wantExc: mustCreateException(OverflowErrorType, "unsigned byte integer is greater than maximum")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestArrayDelItem(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, a, key *Object) (*Object, *BaseException) {
+		if raised := DelItem(f, a, key); raised != nil {
+			return nil, raised
+		}
+		return a, nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), 0), want: newTestArray("i", 2, 3)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3, 4, 5), newTestSlice(None, None, 2)), want: newTestArray("i", 2, 4)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3, 4, 5), newTestSlice(None, None, -2)), want: newTestArray("i", 2, 4)},
+		{args: wrapArgs(newTestArray("i", 1, 2, 3), newTestSlice(1, None)), want: newTestArray("i", 1)},
+		{args: wrapArgs(newTestArray("i"), 0), wantExc: mustCreateException(IndexErrorType, "array assignment index out of range")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestArrayRepr(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestArray("i")), want: NewStr("array('i')").ToObject()},
+		{args: wrapArgs(newTestArray("l", 1, -2)), want: NewStr("array('l', [1, -2])").ToObject()},
+		{args: wrapArgs(newTestArray("d", 0.5)), want: NewStr("array('d', [0.5])").ToObject()},
+		{args: wrapArgs(newTestArray("c", "a", "b")), want: NewStr("array('c', 'ab')").ToObject()},
+		{args: wrapArgs(newTestArray("u", NewUnicode("a"))), want: NewStr("array('u', u'a')").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(Repr), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestArrayNative(t *testing.T) {
+	f := NewRootFrame()
+	cases := []struct {
+		a    *Object
+		want interface{}
+	}{
+		{newTestArray("b", -1, 2), []int8{-1, 2}},
+		{newTestArray("H", 1), []uint16{1}},
+		{newTestArray("i", 3), []int32{3}},
+		{newTestArray("L", 4), []uint64{4}},
+		{newTestArray("f", 0.5), []float32{0.5}},
+		{newTestArray("d", 0.25), []float64{0.25}},
+		{newTestArray("c", "x"), []byte("x")},
+		{newTestArray("u", NewUnicode("y")), []rune("y")},
+	}
+	for _, cas := range cases {
+		got, raised := ToNative(f, cas.a)
+		if raised != nil {
+			t.Errorf("ToNative(%v) raised %v", cas.a, raised)
+		} else if !reflect.DeepEqual(got.Interface(), cas.want) {
+			t.Errorf("ToNative(%v) = %#v, want %#v", cas.a, got.Interface(), cas.want)
+		}
+	}
+}
+
+func TestArrayBuffer(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+		var s string
+		raised := bufferApply(f, o, func(b []byte) *BaseException {
+			s = string(b)
+			return nil
+		})
+		if raised != nil {
+			return nil, raised
+		}
+		return NewStr(s).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestArray("B", 97, 98)), want: NewStr("ab").ToObject()},
+		{args: wrapArgs(newTestArray("h", 0x6261)), want: NewStr("ab").ToObject()},
+		{args: wrapArgs("cd"), want: NewStr("cd").ToObject()},
+		{args: wrapArgs(newTestByteArray("ef")), want: NewStr("ef").ToObject()},
+		{args: wrapArgs(123), wantExc: mustCreateException(TypeErrorType, "'int' does not have the buffer interface")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func newTestArray(typecode string, elems ...interface{}) *Object {
+	return mustNotRaise(arrayType.Call(NewRootFrame(), wrapArgs(typecode, newTestList(elems...)), nil))
+}
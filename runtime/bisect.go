@@ -0,0 +1,175 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+var (
+	bisectLeftParams  = newBisectParamSpec("bisect_left")
+	bisectRightParams = newBisectParamSpec("bisect_right")
+	insortLeftParams  = newBisectParamSpec("insort_left")
+	insortRightParams = newBisectParamSpec("insort_right")
+)
+
+func newBisectParamSpec(name string) *ParamSpec {
+	return NewParamSpec(name, []Param{{"a", nil}, {"x", nil}, {"lo", NewInt(0).ToObject()}, {"hi", None}}, false, false)
+}
+
+func bisectIndex(f *Frame, o *Object) (int, *BaseException) {
+	if o.typ.slots.Index == nil {
+		return 0, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	return IndexInt(f, o)
+}
+
+// bisectSearch validates the (a, x, lo=0, hi=None) arguments of the bisect
+// functions and returns a, x and the insertion point of x in a[lo:hi]. When
+// right is true the insertion point follows any existing entries equal to x,
+// otherwise it precedes them.
+func bisectSearch(f *Frame, s *ParamSpec, args Args, kwargs KWArgs, right bool) (*Object, *Object, int, *BaseException) {
+	var validated [4]*Object
+	if raised := s.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, nil, 0, raised
+	}
+	a, x := validated[0], validated[1]
+	lo, raised := bisectIndex(f, validated[2])
+	if raised != nil {
+		return nil, nil, 0, raised
+	}
+	if lo < 0 {
+		return nil, nil, 0, f.RaiseType(ValueErrorType, "lo must be non-negative")
+	}
+	var hi int
+	if validated[3] == None {
+		n, raised := Len(f, a)
+		if raised != nil {
+			return nil, nil, 0, raised
+		}
+		hi = n.Value()
+	} else if hi, raised = bisectIndex(f, validated[3]); raised != nil {
+		return nil, nil, 0, raised
+	}
+	var l *List
+	if a.typ == ListType {
+		l = toListUnsafe(a)
+		l.mutex.RLock()
+	}
+	for lo < hi {
+		// Written this way rather than (lo + hi) / 2 so that it doesn't
+		// overflow for sequences close to MaxInt in length.
+		mid := lo + (hi-lo)/2
+		var item *Object
+		if l == nil {
+			item, raised = GetItem(f, a, NewInt(mid).ToObject())
+		} else if mid < len(l.elems) {
+			item = l.elems[mid]
+		} else {
+			raised = f.RaiseType(IndexErrorType, "list index out of range")
+		}
+		if raised != nil {
+			break
+		}
+		var lt bool
+		if right {
+			lt, raised = LTBool(f, x, item)
+		} else {
+			lt, raised = LTBool(f, item, x)
+		}
+		if raised != nil {
+			break
+		}
+		if lt == right {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	if l != nil {
+		l.mutex.RUnlock()
+	}
+	if raised != nil {
+		return nil, nil, 0, raised
+	}
+	return a, x, lo, nil
+}
+
+// bisectInsort inserts x into a at index i. Exact lists are updated in place
+// while other sequences have their insert() method called, so that
+// subclasses overriding insert() are honored.
+func bisectInsort(f *Frame, a, x *Object, i int) (*Object, *BaseException) {
+	index := NewInt(i).ToObject()
+	if a.typ == ListType {
+		return listInsert(f, Args{a, index, x}, nil)
+	}
+	insert, raised := GetAttr(f, a, NewStr("insert"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	if _, raised := insert.Call(f, Args{index, x}, nil); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func bisectBisectLeft(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	_, _, i, raised := bisectSearch(f, bisectLeftParams, args, kwargs, false)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(i).ToObject(), nil
+}
+
+func bisectBisectRight(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	_, _, i, raised := bisectSearch(f, bisectRightParams, args, kwargs, true)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(i).ToObject(), nil
+}
+
+func bisectInsortLeft(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	a, x, i, raised := bisectSearch(f, insortLeftParams, args, kwargs, false)
+	if raised != nil {
+		return nil, raised
+	}
+	return bisectInsort(f, a, x, i)
+}
+
+func bisectInsortRight(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	a, x, i, raised := bisectSearch(f, insortRightParams, args, kwargs, true)
+	if raised != nil {
+		return nil, raised
+	}
+	return bisectInsort(f, a, x, i)
+}
+
+func init() {
+	RegisterModule("_bisect", NewCode("<module>", "_bisect", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		bisectRight := newBuiltinFunction("bisect_right", bisectBisectRight).ToObject()
+		insortRight := newBuiltinFunction("insort_right", bisectInsortRight).ToObject()
+		attrs := map[string]*Object{
+			"bisect":       bisectRight,
+			"bisect_left":  newBuiltinFunction("bisect_left", bisectBisectLeft).ToObject(),
+			"bisect_right": bisectRight,
+			"insort":       insortRight,
+			"insort_left":  newBuiltinFunction("insort_left", bisectInsortLeft).ToObject(),
+			"insort_right": insortRight,
+		}
+		for name, o := range attrs {
+			if raised := f.Globals().SetItemString(f, name, o); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestBisectLeftRight(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		left, raised := bisectBisectLeft(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		right, raised := bisectBisectRight(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(left, right).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewList(), 1), want: newTestTuple(0, 0).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 2, 3), 2), want: newTestTuple(1, 3).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 2, 3), 0), want: newTestTuple(0, 0).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 2, 3), 4), want: newTestTuple(4, 4).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 2, 3), 2, 2), want: newTestTuple(2, 3).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 2, 3), 2, 0, 2), want: newTestTuple(1, 2).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 2, 3), 2, 0, None), want: newTestTuple(1, 3).ToObject()},
+		{args: wrapArgs(newTestTuple("a", "c", "e"), "d"), want: newTestTuple(2, 2).ToObject()},
+		{args: wrapArgs(newTestList(1, 2), 5, 0, 4), wantExc: mustCreateException(IndexErrorType, "list index out of range")},
+		{args: wrapArgs(newTestList(1, 2), 1, -1), wantExc: mustCreateException(ValueErrorType, "lo must be non-negative")},
+		{args: wrapArgs(newTestList(1, 2), 1, 0, "foo"), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+		{args: wrapArgs(10, 10), wantExc: mustCreateException(TypeErrorType, "object of type 'int' has no len()")},
+		{args: wrapArgs(10), wantExc: mustCreateException(TypeErrorType, "bisect_left() takes at least 2 arguments (1 given)")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBisectKeywords(t *testing.T) {
+	fun := newBuiltinFunction("bisect_right", bisectBisectRight).ToObject()
+	cas := invokeTestCase{
+		kwargs: wrapKWArgs("a", newTestList(10, 20, 30, 40, 50), "x", 25, "lo", 1, "hi", 3),
+		want:   NewInt(2).ToObject(),
+	}
+	if err := runInvokeTestCase(fun, &cas); err != "" {
+		t.Error(err)
+	}
+}
+
+func TestBisectInsort(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, a, x *Object) (*Object, *BaseException) {
+		if _, raised := bisectInsortLeft(f, Args{a, x}, nil); raised != nil {
+			return nil, raised
+		}
+		if _, raised := bisectInsortRight(f, Args{a, x}, nil); raised != nil {
+			return nil, raised
+		}
+		return a, nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewList(), 1), want: newTestList(1, 1).ToObject()},
+		{args: wrapArgs(newTestList(0, 2), 1), want: newTestList(0, 1, 1, 2).ToObject()},
+		{args: wrapArgs(newTestTuple(0, 2), 1), wantExc: mustCreateException(AttributeErrorType, "'tuple' object has no attribute 'insert'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,643 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// bufferedRandomType corresponds to the Python type
+	// '_io.BufferedRandom'.
+	bufferedRandomType = newBasisType("BufferedRandom", reflect.TypeOf(bufferedRandom{}), toBufferedRandomUnsafe, bufferedIOBaseType)
+	// bufferedReaderType corresponds to the Python type
+	// '_io.BufferedReader'.
+	bufferedReaderType = newBasisType("BufferedReader", reflect.TypeOf(bufferedReader{}), toBufferedReaderUnsafe, bufferedIOBaseType)
+	// bufferedWriterType corresponds to the Python type
+	// '_io.BufferedWriter'.
+	bufferedWriterType = newBasisType("BufferedWriter", reflect.TypeOf(bufferedWriter{}), toBufferedWriterUnsafe, bufferedIOBaseType)
+)
+
+// buffered holds the state shared by BufferedReader, BufferedWriter and
+// BufferedRandom objects. rbuf holds data read from raw but not yet consumed
+// and wbuf holds data written but not yet flushed to raw. At most one of them
+// is non-empty at any time.
+type buffered struct {
+	mutex      sync.Mutex
+	raw        *Object
+	bufferSize int
+	rbuf       []byte
+	wbuf       []byte
+	readable   bool
+	writable   bool
+	detached   bool
+}
+
+// bufferedReader represents Python '_io.BufferedReader' objects.
+type bufferedReader struct {
+	ioBase
+	buffered
+}
+
+func toBufferedReaderUnsafe(o *Object) *bufferedReader {
+	return (*bufferedReader)(o.toPointer())
+}
+
+// bufferedWriter represents Python '_io.BufferedWriter' objects.
+type bufferedWriter struct {
+	ioBase
+	buffered
+}
+
+func toBufferedWriterUnsafe(o *Object) *bufferedWriter {
+	return (*bufferedWriter)(o.toPointer())
+}
+
+// bufferedRandom represents Python '_io.BufferedRandom' objects.
+type bufferedRandom struct {
+	ioBase
+	buffered
+}
+
+func toBufferedRandomUnsafe(o *Object) *bufferedRandom {
+	return (*bufferedRandom)(o.toPointer())
+}
+
+// toBufferedUnsafe returns the buffered state of o which must be an instance
+// of one of the buffered types.
+func toBufferedUnsafe(o *Object) *buffered {
+	switch o.typ.basis {
+	case bufferedRandomType.basis:
+		return &toBufferedRandomUnsafe(o).buffered
+	case bufferedWriterType.basis:
+		return &toBufferedWriterUnsafe(o).buffered
+	}
+	return &toBufferedReaderUnsafe(o).buffered
+}
+
+// checkClosed raises ValueError with the given message if the raw stream is
+// closed.
+func (b *buffered) checkClosed(f *Frame, msg string) *BaseException {
+	closed, raised := GetAttr(f, b.raw, NewStr("closed"), nil)
+	if raised != nil {
+		return raised
+	}
+	isClosed, raised := IsTrue(f, closed)
+	if raised != nil {
+		return raised
+	}
+	if isClosed {
+		return f.RaiseType(ValueErrorType, msg)
+	}
+	return nil
+}
+
+// fill reads up to n bytes from the raw stream and appends them to rbuf. It
+// returns false when the raw stream is at EOF or has no data available.
+func (b *buffered) fill(f *Frame, n int) (bool, *BaseException) {
+	data, raised := ioCall(f, b.raw, "read", NewInt(n).ToObject())
+	if raised != nil {
+		return false, raised
+	}
+	if data == None {
+		return false, nil
+	}
+	if !data.isInstance(StrType) {
+		return false, f.RaiseType(TypeErrorType, fmt.Sprintf("read() should return bytes, not '%s'", data.typ.Name()))
+	}
+	s := toStrUnsafe(data).Value()
+	b.rbuf = append(b.rbuf, s...)
+	return s != "", nil
+}
+
+// flushWrites writes any pending data to the raw stream.
+func (b *buffered) flushWrites(f *Frame) *BaseException {
+	for len(b.wbuf) > 0 {
+		result, raised := ioCall(f, b.raw, "write", NewStr(string(b.wbuf)).ToObject())
+		if raised != nil {
+			return raised
+		}
+		if result == None {
+			return f.RaiseType(blockingIOErrorType, "write could not complete without blocking")
+		}
+		n, raised := IndexInt(f, result)
+		if raised != nil {
+			return raised
+		}
+		if n < 0 || n > len(b.wbuf) {
+			return f.RaiseType(IOErrorType, fmt.Sprintf("raw write() returned invalid length %d (should have been between 0 and %d)", n, len(b.wbuf)))
+		}
+		b.wbuf = b.wbuf[n:]
+	}
+	b.wbuf = nil
+	return nil
+}
+
+// rewind discards unconsumed read data, moving the raw stream's position
+// back so that it agrees with the logical position.
+func (b *buffered) rewind(f *Frame) *BaseException {
+	if len(b.rbuf) == 0 {
+		return nil
+	}
+	n := len(b.rbuf)
+	b.rbuf = nil
+	_, raised := ioCall(f, b.raw, "seek", NewInt(-n).ToObject(), NewInt(1).ToObject())
+	return raised
+}
+
+// prepareRead flushes pending writes ahead of a read.
+func (b *buffered) prepareRead(f *Frame) *BaseException {
+	if raised := b.checkClosed(f, "read of closed file"); raised != nil {
+		return raised
+	}
+	return b.flushWrites(f)
+}
+
+func (b *buffered) tell(f *Frame) (int, *BaseException) {
+	result, raised := ioCall(f, b.raw, "tell")
+	if raised != nil {
+		return 0, raised
+	}
+	pos, raised := IndexInt(f, result)
+	if raised != nil {
+		return 0, raised
+	}
+	if pos < 0 {
+		return 0, f.RaiseType(IOErrorType, fmt.Sprintf("Raw stream returned invalid position %d", pos))
+	}
+	return pos - len(b.rbuf) + len(b.wbuf), nil
+}
+
+// bufferedMethod is the signature of the methods shared by the buffered
+// types. They are called with b's mutex held.
+type bufferedMethod func(f *Frame, b *buffered, args Args) (*Object, *BaseException)
+
+// newBufferedMethod returns a builtin function named name that checks its
+// receiver is an instance of typ and is not detached before calling fn.
+func newBufferedMethod(typ *Type, name string, fn bufferedMethod) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodVarArgs(f, name, args, typ); raised != nil {
+			return nil, raised
+		}
+		if len(kwargs) > 0 {
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("%s() takes no keyword arguments", name))
+		}
+		b := toBufferedUnsafe(args[0])
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if b.raw == nil {
+			return nil, f.RaiseType(ValueErrorType, "I/O operation on uninitialized object")
+		}
+		if b.detached {
+			return nil, f.RaiseType(ValueErrorType, "raw stream has been detached")
+		}
+		return fn(f, b, args)
+	}).ToObject()
+}
+
+func bufferedClose(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	closed, raised := GetAttr(f, b.raw, NewStr("closed"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	isClosed, raised := IsTrue(f, closed)
+	if raised != nil {
+		return nil, raised
+	}
+	if isClosed {
+		return None, nil
+	}
+	// Like CPython, the raw stream is closed even if the flush fails and
+	// the flush error takes precedence.
+	flushRaised := b.flushWrites(f)
+	_, raised = ioCall(f, b.raw, "close")
+	if flushRaised != nil {
+		return nil, flushRaised
+	}
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func bufferedDelegate(name string) bufferedMethod {
+	return func(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, name, args, ObjectType); raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, b.raw, name)
+	}
+}
+
+func bufferedDetach(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "detach", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := b.flushWrites(f); raised != nil {
+		return nil, raised
+	}
+	raw := b.raw
+	b.detached = true
+	return raw, nil
+}
+
+func bufferedFlush(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := b.checkClosed(f, "flush of closed file"); raised != nil {
+		return nil, raised
+	}
+	if raised := b.flushWrites(f); raised != nil {
+		return nil, raised
+	}
+	if b.readable {
+		if raised := b.rewind(f); raised != nil {
+			return nil, raised
+		}
+	}
+	return None, nil
+}
+
+func bufferedGetAttr(name string) bufferedMethod {
+	return func(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_get_"+name, args, ObjectType); raised != nil {
+			return nil, raised
+		}
+		return GetAttr(f, b.raw, NewStr(name), nil)
+	}
+}
+
+func bufferedGetRaw(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_raw", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return b.raw, nil
+}
+
+// bufferedInit returns the __init__ slot for typ. The raw stream must be
+// readable and/or writable as the type requires.
+func bufferedInit(typ *Type, readable, writable bool) *initSlot {
+	spec := NewParamSpec(typ.Name(), []Param{{"raw", nil}, {"buffer_size", NewInt(ioDefaultBufferSize).ToObject()}}, false, false)
+	return &initSlot{func(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		var validated [2]*Object
+		if raised := spec.Validate(f, validated[:], args, kwargs); raised != nil {
+			return nil, raised
+		}
+		raw := validated[0]
+		if readable && writable {
+			if raised := ioCheckMode(f, raw, "seekable", "File or stream is not seekable."); raised != nil {
+				return nil, raised
+			}
+		}
+		if readable {
+			if raised := ioCheckMode(f, raw, "readable", "File or stream is not readable."); raised != nil {
+				return nil, raised
+			}
+		}
+		if writable {
+			if raised := ioCheckMode(f, raw, "writable", "File or stream is not writable."); raised != nil {
+				return nil, raised
+			}
+		}
+		if validated[1].typ.slots.Index == nil {
+			return nil, f.RaiseType(TypeErrorType, "an integer is required")
+		}
+		size, raised := IndexInt(f, validated[1])
+		if raised != nil {
+			return nil, raised
+		}
+		if size <= 0 {
+			return nil, f.RaiseType(ValueErrorType, "buffer size must be strictly positive")
+		}
+		b := toBufferedUnsafe(o)
+		b.mutex.Lock()
+		b.raw, b.bufferSize = raw, size
+		b.rbuf, b.wbuf = nil, nil
+		b.readable, b.writable, b.detached = readable, writable, false
+		b.mutex.Unlock()
+		return None, nil
+	}}
+}
+
+func bufferedNext(f *Frame, o *Object) (*Object, *BaseException) {
+	line, raised := ioCall(f, o, "readline")
+	if raised != nil {
+		return nil, raised
+	}
+	if toStrUnsafe(line).Value() == "" {
+		return nil, f.Raise(StopIterationType.ToObject(), nil, nil)
+	}
+	return line, nil
+}
+
+func bufferedPeek(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, IntType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "peek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	if raised := b.checkClosed(f, "peek of closed file"); raised != nil {
+		return nil, raised
+	}
+	if raised := b.flushWrites(f); raised != nil {
+		return nil, raised
+	}
+	if len(b.rbuf) == 0 {
+		if _, raised := b.fill(f, b.bufferSize); raised != nil {
+			return nil, raised
+		}
+	}
+	return NewStr(string(b.rbuf)).ToObject(), nil
+}
+
+func bufferedRead(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "read", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	n, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	if n < -1 {
+		return nil, f.RaiseType(ValueErrorType, "read length must be positive or -1")
+	}
+	if raised := b.prepareRead(f); raised != nil {
+		return nil, raised
+	}
+	for n < 0 || len(b.rbuf) < n {
+		size := b.bufferSize
+		if n > 0 && n-len(b.rbuf) > size {
+			size = n - len(b.rbuf)
+		}
+		ok, raised := b.fill(f, size)
+		if raised != nil {
+			return nil, raised
+		}
+		if !ok {
+			break
+		}
+	}
+	if n < 0 || n > len(b.rbuf) {
+		n = len(b.rbuf)
+	}
+	s := string(b.rbuf[:n])
+	b.rbuf = b.rbuf[n:]
+	return NewStr(s).ToObject(), nil
+}
+
+func bufferedRead1(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "read1", args, ObjectType, IntType); raised != nil {
+		return nil, raised
+	}
+	n := toIntUnsafe(args[1]).Value()
+	if n < 0 {
+		return nil, f.RaiseType(ValueErrorType, "read length must be positive")
+	}
+	if raised := b.prepareRead(f); raised != nil {
+		return nil, raised
+	}
+	if n == 0 {
+		return NewStr("").ToObject(), nil
+	}
+	if len(b.rbuf) == 0 {
+		if _, raised := b.fill(f, b.bufferSize); raised != nil {
+			return nil, raised
+		}
+	}
+	if n > len(b.rbuf) {
+		n = len(b.rbuf)
+	}
+	s := string(b.rbuf[:n])
+	b.rbuf = b.rbuf[n:]
+	return NewStr(s).ToObject(), nil
+}
+
+func bufferedReadLine(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "readline", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	limit, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := b.prepareRead(f); raised != nil {
+		return nil, raised
+	}
+	n := 0
+	for {
+		if i := bytes.IndexByte(b.rbuf[n:], '\n'); i >= 0 {
+			n += i + 1
+			break
+		}
+		n = len(b.rbuf)
+		if limit >= 0 && n >= limit {
+			break
+		}
+		ok, raised := b.fill(f, b.bufferSize)
+		if raised != nil {
+			return nil, raised
+		}
+		if !ok {
+			break
+		}
+	}
+	if limit >= 0 && n > limit {
+		n = limit
+	}
+	s := string(b.rbuf[:n])
+	b.rbuf = b.rbuf[n:]
+	return NewStr(s).ToObject(), nil
+}
+
+func bufferedRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	name, raised := GetAttr(f, o, NewStr("name"), nil)
+	if raised != nil {
+		if !raised.isInstance(AttributeErrorType) && !raised.isInstance(ValueErrorType) {
+			return nil, raised
+		}
+		f.RestoreExc(nil, nil)
+		return NewStr(fmt.Sprintf("<_io.%s>", o.typ.Name())).ToObject(), nil
+	}
+	s, raised := Repr(f, name)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(fmt.Sprintf("<_io.%s name=%s>", o.typ.Name(), s.Value())).ToObject(), nil
+}
+
+func bufferedSeek(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType, IntType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	whence := 0
+	if argc > 2 {
+		whence = toIntUnsafe(args[2]).Value()
+	}
+	if whence < 0 || whence > 2 {
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("invalid whence (%d, should be 0, 1 or 2)", whence))
+	}
+	if raised := b.checkClosed(f, "seek of closed file"); raised != nil {
+		return nil, raised
+	}
+	if args[1].typ.slots.Index == nil {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	pos, raised := IndexInt(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := b.flushWrites(f); raised != nil {
+		return nil, raised
+	}
+	if whence == 1 {
+		pos -= len(b.rbuf)
+	}
+	b.rbuf = nil
+	return ioCall(f, b.raw, "seek", NewInt(pos).ToObject(), NewInt(whence).ToObject())
+}
+
+func bufferedTell(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	pos, raised := b.tell(f)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(pos).ToObject(), nil
+}
+
+func bufferedTruncate(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "truncate", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	if raised := b.checkClosed(f, "truncate of closed file"); raised != nil {
+		return nil, raised
+	}
+	if raised := b.flushWrites(f); raised != nil {
+		return nil, raised
+	}
+	if raised := b.rewind(f); raised != nil {
+		return nil, raised
+	}
+	if len(args) > 1 && args[1] != None {
+		return ioCall(f, b.raw, "truncate", args[1])
+	}
+	return ioCall(f, b.raw, "truncate")
+}
+
+func bufferedWrite(f *Frame, b *buffered, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "write", args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if args[1].isInstance(UnicodeType) || args[1].typ.slots.Buffer == nil {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("'%s' does not have the buffer interface", args[1].typ.Name()))
+	}
+	if raised := b.checkClosed(f, "write to closed file"); raised != nil {
+		return nil, raised
+	}
+	if raised := b.rewind(f); raised != nil {
+		return nil, raised
+	}
+	n := 0
+	raised := bufferApply(f, args[1], func(data []byte) *BaseException {
+		n = len(data)
+		b.wbuf = append(b.wbuf, data...)
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	if len(b.wbuf) >= b.bufferSize {
+		if raised := b.flushWrites(f); raised != nil {
+			return nil, raised
+		}
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+func initBufferedType(typ *Type, dict map[string]*Object, readable, writable bool) {
+	methods := map[string]bufferedMethod{
+		"close":    bufferedClose,
+		"detach":   bufferedDetach,
+		"fileno":   bufferedDelegate("fileno"),
+		"flush":    bufferedFlush,
+		"isatty":   bufferedDelegate("isatty"),
+		"readable": bufferedDelegate("readable"),
+		"seek":     bufferedSeek,
+		"seekable": bufferedDelegate("seekable"),
+		"tell":     bufferedTell,
+		"truncate": bufferedTruncate,
+		"writable": bufferedDelegate("writable"),
+	}
+	if readable {
+		methods["peek"] = bufferedPeek
+		methods["read"] = bufferedRead
+		methods["read1"] = bufferedRead1
+		methods["readline"] = bufferedReadLine
+	}
+	if writable {
+		methods["write"] = bufferedWrite
+	}
+	for name, fn := range methods {
+		dict[name] = newBufferedMethod(typ, name, fn)
+	}
+	for _, name := range []string{"closed", "mode", "name"} {
+		dict[name] = newProperty(newBufferedMethod(typ, "_get_"+name, bufferedGetAttr(name)), nil, nil).ToObject()
+	}
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["raw"] = newProperty(newBufferedMethod(typ, "_get_raw", bufferedGetRaw), nil, nil).ToObject()
+	typ.slots.Init = bufferedInit(typ, readable, writable)
+	typ.slots.Next = &unaryOpSlot{bufferedNext}
+	typ.slots.Repr = &unaryOpSlot{bufferedRepr}
+}
+
+func initBufferedRandomType(dict map[string]*Object) {
+	initBufferedType(bufferedRandomType, dict, true, true)
+}
+
+func initBufferedReaderType(dict map[string]*Object) {
+	initBufferedType(bufferedReaderType, dict, true, false)
+}
+
+func initBufferedWriterType(dict map[string]*Object) {
+	initBufferedType(bufferedWriterType, dict, false, true)
+}
@@ -0,0 +1,176 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestBufferedInit(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, t *Type, args ...*Object) (*Object, *BaseException) {
+		b, raised := t.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return GetAttr(f, b, NewStr("raw"), nil)
+	})
+	raw := mustNotRaise(bytesIOType.Call(NewRootFrame(), nil, nil))
+	cases := []invokeTestCase{
+		{args: wrapArgs(bufferedReaderType, raw), want: raw},
+		{args: wrapArgs(bufferedWriterType, raw, 16), want: raw},
+		{args: wrapArgs(bufferedRandomType, raw), want: raw},
+		{args: wrapArgs(bufferedReaderType, raw, 0), wantExc: mustCreateException(ValueErrorType, "buffer size must be strictly positive")},
+		{args: wrapArgs(bufferedReaderType, raw, 1.5), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBufferedReaderMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, method string, args ...*Object) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, wrapArgs("ab\ncd\nef"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		b, raised := bufferedReaderType.Call(f, wrapArgs(raw, 4), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, b, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("read"), want: NewStr("ab\ncd\nef").ToObject()},
+		{args: wrapArgs("read", 5), want: NewStr("ab\ncd").ToObject()},
+		{args: wrapArgs("read", -1), want: NewStr("ab\ncd\nef").ToObject()},
+		{args: wrapArgs("read1", 10), want: NewStr("ab\nc").ToObject()},
+		{args: wrapArgs("peek"), want: NewStr("ab\nc").ToObject()},
+		{args: wrapArgs("readline"), want: NewStr("ab\n").ToObject()},
+		{args: wrapArgs("readlines"), want: newTestList("ab\n", "cd\n", "ef").ToObject()},
+		{args: wrapArgs("seek", 2, 0), want: NewInt(2).ToObject()},
+		{args: wrapArgs("tell"), want: NewInt(0).ToObject()},
+		{args: wrapArgs("readable"), want: True.ToObject()},
+		{args: wrapArgs("read", -2), wantExc: mustCreateException(ValueErrorType, "read length must be positive or -1")},
+		{args: wrapArgs("read1", -1), wantExc: mustCreateException(ValueErrorType, "read length must be positive")},
+		{args: wrapArgs("seek", 0, 3), wantExc: mustCreateException(ValueErrorType, "invalid whence (3, should be 0, 1 or 2)")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBufferedWriterMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, bufSize int, data *Object) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		b, raised := bufferedWriterType.Call(f, wrapArgs(raw, bufSize), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(f, b, "write", data); raised != nil {
+			return nil, raised
+		}
+		before, raised := ioCall(f, raw, "getvalue")
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(f, b, "flush"); raised != nil {
+			return nil, raised
+		}
+		after, raised := ioCall(f, raw, "getvalue")
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(before, after).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(8, "abc"), want: newTestTuple("", "abc").ToObject()},
+		{args: wrapArgs(2, "abc"), want: newTestTuple("abc", "abc").ToObject()},
+		{args: wrapArgs(8, newTestByteArray("xyz")), want: newTestTuple("", "xyz").ToObject()},
+		{args: wrapArgs(8, 123), wantExc: mustCreateException(TypeErrorType, "'int' does not have the buffer interface")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBufferedRandomSeekDiscardsWrites(t *testing.T) {
+	f := NewRootFrame()
+	raw := mustNotRaise(bytesIOType.Call(f, wrapArgs("abcdef"), nil))
+	b := mustNotRaise(bufferedRandomType.Call(f, wrapArgs(raw), nil))
+	mustNotRaise(ioCall(f, b, "read", NewInt(2).ToObject()))
+	mustNotRaise(ioCall(f, b, "write", NewStr("XY").ToObject()))
+	if got := mustNotRaise(ioCall(f, b, "tell")); toIntUnsafe(got).Value() != 4 {
+		t.Errorf("tell() = %v, want 4", got)
+	}
+	mustNotRaise(ioCall(f, b, "seek", NewInt(0).ToObject()))
+	got := mustNotRaise(ioCall(f, b, "read"))
+	if want := NewStr("abXYef").ToObject(); !got.isInstance(StrType) || toStrUnsafe(got).Value() != "abXYef" {
+		t.Errorf("read() = %v, want %v", got, want)
+	}
+}
+
+func TestBufferedClosed(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, method string, args ...*Object) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		b, raised := bufferedRandomType.Call(f, wrapArgs(raw), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(f, b, "close"); raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, b, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("close"), want: None},
+		{args: wrapArgs("write", "x"), wantExc: mustCreateException(ValueErrorType, "write to closed file")},
+		{args: wrapArgs("flush"), wantExc: mustCreateException(ValueErrorType, "flush of closed file")},
+		{args: wrapArgs("seek", 0), wantExc: mustCreateException(ValueErrorType, "seek of closed file")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBufferedDetach(t *testing.T) {
+	f := NewRootFrame()
+	raw := mustNotRaise(bytesIOType.Call(f, nil, nil))
+	b := mustNotRaise(bufferedWriterType.Call(f, wrapArgs(raw), nil))
+	mustNotRaise(ioCall(f, b, "write", NewStr("abc").ToObject()))
+	if got := mustNotRaise(ioCall(f, b, "detach")); got != raw {
+		t.Errorf("detach() = %v, want %v", got, raw)
+	}
+	if got := mustNotRaise(ioCall(f, raw, "getvalue")); toStrUnsafe(got).Value() != "abc" {
+		t.Errorf("detach() did not flush, raw contains %v", got)
+	}
+	_, raised := ioCall(f, b, "flush")
+	if raised == nil || !raised.isInstance(ValueErrorType) {
+		t.Errorf("flush() after detach() raised %v, want ValueError", raised)
+	}
+}
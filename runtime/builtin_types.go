@@ -99,26 +99,47 @@ type builtinTypeInfo struct {
 
 var builtinTypes = map[*Type]*builtinTypeInfo{
 	ArithmeticErrorType:           {global: true},
+	arrayType:                     {init: initArrayType},
 	AssertionErrorType:            {global: true},
+	attrGetterType:                {init: initAttrGetterType},
 	AttributeErrorType:            {global: true},
+	badPickleGetType:              {init: initPickleErrorType},
 	BaseExceptionType:             {init: initBaseExceptionType, global: true},
 	BaseStringType:                {init: initBaseStringType, global: true},
+	blockingIOErrorType:           {},
 	BoolType:                      {init: initBoolType, global: true},
+	bufferedIOBaseType:            {init: initBufferedIOBaseType},
+	bufferedRandomType:            {init: initBufferedRandomType},
+	bufferedReaderType:            {init: initBufferedReaderType},
+	bufferedWriterType:            {init: initBufferedWriterType},
+	bytesIOType:                   {init: initBytesIOType},
 	ByteArrayType:                 {init: initByteArrayType, global: true},
 	BytesWarningType:              {global: true},
 	CodeType:                      {},
 	ComplexType:                   {init: initComplexType, global: true},
+	chainType:                     {init: initChainType},
+	combinationsType:              {init: initCombinationsType},
+	compressType:                  {init: initCompressType},
+	countType:                     {init: initCountType},
+	cwrType:                       {init: initCWRType},
+	cycleType:                     {init: initCycleType},
 	ClassMethodType:               {init: initClassMethodType, global: true},
+	dateType:                      {init: initDateType},
+	datetimeType:                  {init: initDatetimeType},
 	DeprecationWarningType:        {global: true},
 	dictItemIteratorType:          {init: initDictItemIteratorType},
 	dictKeyIteratorType:           {init: initDictKeyIteratorType},
+	dictProxyType:                 {init: initDictProxyType},
 	dictValueIteratorType:         {init: initDictValueIteratorType},
 	DictType:                      {init: initDictType, global: true},
+	dropWhileType:                 {init: initDropWhileType},
 	EllipsisType:                  {init: initEllipsisType, global: true},
 	enumerateType:                 {init: initEnumerateType, global: true},
-	EnvironmentErrorType:          {global: true},
+	EnvironmentErrorType:          {init: initEnvironmentErrorType, global: true},
+	epollType:                     {init: initEpollType},
 	EOFErrorType:                  {global: true},
 	ExceptionType:                 {global: true},
+	fileIOType:                    {init: initFileIOType},
 	FileType:                      {init: initFileType, global: true},
 	FloatType:                     {init: initFloatType, global: true},
 	FrameType:                     {init: initFrameType},
@@ -126,11 +147,22 @@ var builtinTypes = map[*Type]*builtinTypeInfo{
 	FunctionType:                  {init: initFunctionType},
 	FutureWarningType:             {global: true},
 	GeneratorType:                 {init: initGeneratorType},
+	groupByType:                   {init: initGroupByType},
+	grouperType:                   {init: initGrouperType},
 	ImportErrorType:               {global: true},
+	iFilterType:                   {init: initIFilterType},
+	iFilterFalseType:              {init: initIFilterFalseType},
+	iMapType:                      {init: initIMapType},
 	ImportWarningType:             {global: true},
 	IndexErrorType:                {global: true},
 	IntType:                       {init: initIntType, global: true},
+	itemGetterType:                {init: initItemGetterType},
+	iSliceType:                    {init: initISliceType},
+	iZipType:                      {init: initIZipType},
+	iZipLongestType:               {init: initIZipLongestType},
+	ioBaseType:                    {init: initIOBaseType},
 	IOErrorType:                   {global: true},
+	keyWrapperType:                {init: initKeyWrapperType},
 	KeyboardInterruptType:         {global: true},
 	KeyErrorType:                  {global: true},
 	listIteratorType:              {init: initListIteratorType},
@@ -138,6 +170,8 @@ var builtinTypes = map[*Type]*builtinTypeInfo{
 	LongType:                      {init: initLongType, global: true},
 	LookupErrorType:               {global: true},
 	MemoryErrorType:               {global: true},
+	memberDescriptorType:          {init: initMemberDescriptorType},
+	methodCallerType:              {init: initMethodCallerType},
 	MethodType:                    {init: initMethodType},
 	ModuleType:                    {init: initModuleType},
 	NameErrorType:                 {global: true},
@@ -146,6 +180,8 @@ var builtinTypes = map[*Type]*builtinTypeInfo{
 	nativeMetaclassType:           {init: initNativeMetaclassType},
 	nativeSliceType:               {init: initNativeSliceType},
 	nativeType:                    {init: initNativeType},
+	pollType:                      {init: initPollType},
+	processType:                   {init: initProcessType},
 	NoneType:                      {init: initNoneType, global: true},
 	NotImplementedErrorType:       {global: true},
 	NotImplementedType:            {init: initNotImplementedType, global: true},
@@ -153,28 +189,56 @@ var builtinTypes = map[*Type]*builtinTypeInfo{
 	OSErrorType:                   {global: true},
 	OverflowErrorType:             {global: true},
 	PendingDeprecationWarningType: {global: true},
+	partialType:                   {init: initPartialType},
+	permutationsType:              {init: initPermutationsType},
+	pickleErrorType:               {init: initPickleErrorType},
+	picklerType:                   {init: initPicklerType},
+	picklingErrorType:             {init: initPickleErrorType},
+	productType:                   {init: initProductType},
 	PropertyType:                  {init: initPropertyType, global: true},
+	randomType:                    {init: initRandomType},
+	rawIOBaseType:                 {init: initRawIOBaseType},
 	rangeIteratorType:             {init: initRangeIteratorType, global: true},
+	repeatType:                    {init: initRepeatType},
 	ReferenceErrorType:            {global: true},
 	RuntimeErrorType:              {global: true},
 	RuntimeWarningType:            {global: true},
 	seqIteratorType:               {init: initSeqIteratorType},
 	SetType:                       {init: initSetType, global: true},
 	sliceIteratorType:             {init: initSliceIteratorType},
+	selectErrorType:               {init: initSelectErrorType},
+	socketErrorType:               {init: initSocketErrorType},
+	socketGaierrorType:            {init: initSocketErrorType},
+	socketHerrorType:              {init: initSocketErrorType},
+	socketTimeoutType:             {init: initSocketErrorType},
+	socketType:                    {init: initSocketType},
+	statResultType:                {init: initStatResultType},
+	structTimeType:                {init: initStructTimeType},
 	SliceType:                     {init: initSliceType, global: true},
+	starMapType:                   {init: initStarMapType},
 	StandardErrorType:             {global: true},
 	StaticMethodType:              {init: initStaticMethodType, global: true},
 	StopIterationType:             {global: true},
+	stringIOType:                  {init: initStringIOType},
+	stringIType:                   {init: initStringIType},
+	stringOType:                   {init: initStringOType},
 	StrType:                       {init: initStrType, global: true},
 	superType:                     {init: initSuperType, global: true},
 	SyntaxErrorType:               {global: true},
 	SyntaxWarningType:             {global: true},
 	SystemErrorType:               {global: true},
 	SystemExitType:                {global: true, init: initSystemExitType},
+	takeWhileType:                 {init: initTakeWhileType},
+	teeType:                       {init: initTeeType},
+	textIOBaseType:                {init: initTextIOBaseType},
+	textIOWrapperType:             {init: initTextIOWrapperType},
+	timedeltaType:                 {init: initTimedeltaType},
+	timeOfDayType:                 {init: initTimeOfDayType},
 	TracebackType:                 {init: initTracebackType},
 	TupleType:                     {init: initTupleType, global: true},
 	TypeErrorType:                 {global: true},
 	TypeType:                      {init: initTypeType, global: true},
+	tzinfoType:                    {init: initTzinfoType},
 	UnboundLocalErrorType:         {global: true},
 	unboundLocalType:              {init: initUnboundLocalType},
 	UnicodeDecodeErrorType:        {global: true},
@@ -182,6 +246,10 @@ var builtinTypes = map[*Type]*builtinTypeInfo{
 	UnicodeErrorType:              {global: true},
 	UnicodeType:                   {init: initUnicodeType, global: true},
 	UnicodeWarningType:            {global: true},
+	unpickleableErrorType:         {init: initPickleErrorType},
+	unpicklerType:                 {init: initUnpicklerType},
+	unpicklingErrorType:           {init: initPickleErrorType},
+	unsupportedOperationType:      {init: initUnsupportedOperationType},
 	UserWarningType:               {global: true},
 	ValueErrorType:                {global: true},
 	WarningType:                   {global: true},
@@ -311,10 +379,17 @@ func builtinCallable(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 }
 
 func builtinChr(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
-	if raised := checkFunctionArgs(f, "chr", args, IntType); raised != nil {
+	expectedTypes := []*Type{IntType}
+	if len(args) == 1 && args[0].isInstance(LongType) {
+		expectedTypes = []*Type{LongType}
+	}
+	if raised := checkFunctionArgs(f, "chr", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	i, raised := ToIntValue(f, args[0])
+	if raised != nil {
 		return nil, raised
 	}
-	i := toIntUnsafe(args[0]).Value()
 	if i < 0 || i > 255 {
 		return nil, f.RaiseType(ValueErrorType, "chr() arg not in range(256)")
 	}
@@ -549,7 +624,7 @@ func builtinOrd(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 func builtinPrint(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
 	sep := " "
 	end := "\n"
-	file := Stdout
+	file := Stdout.ToObject()
 	for _, kwarg := range kwargs {
 		switch kwarg.Name {
 		case "sep":
@@ -565,9 +640,9 @@ func builtinPrint(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException)
 			}
 			end = kwend.Value()
 		case "file":
-			// TODO: need to map Python sys.stdout, sys.stderr etc. to os.Stdout,
-			// os.Stderr, but for other file-like objects would need to recover
-			// to the file descriptor probably
+			if kwarg.Value != None {
+				file = kwarg.Value
+			}
 		}
 	}
 	return nil, pyPrint(f, args, sep, end, file)
@@ -598,7 +673,7 @@ func builtinRawInput(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseExceptio
 	}
 
 	if len(args) == 1 {
-		err := pyPrint(f, args, "", "", Stdout)
+		err := pyPrint(f, args, "", "", Stdout.ToObject())
 		if err != nil {
 			return nil, err
 		}
@@ -57,6 +57,7 @@ func TestBuiltinFuncs(t *testing.T) {
 	objectDir.Sort(f)
 	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{"bar": None}))
 	fooTypeDir := NewList(objectDir.elems...)
+	fooTypeDir.Append(NewStr("__doc__").ToObject())
 	fooTypeDir.Append(NewStr("bar").ToObject())
 	fooTypeDir.Sort(f)
 	foo := newObject(fooType)
@@ -160,6 +161,8 @@ func TestBuiltinFuncs(t *testing.T) {
 		{f: "callable", args: wrapArgs(1, 2), wantExc: mustCreateException(TypeErrorType, "'callable' requires 1 arguments")},
 		{f: "chr", args: wrapArgs(0), want: NewStr("\x00").ToObject()},
 		{f: "chr", args: wrapArgs(65), want: NewStr("A").ToObject()},
+		{f: "chr", args: wrapArgs(big.NewInt(66)), want: NewStr("B").ToObject()},
+		{f: "chr", args: wrapArgs(1.5), wantExc: mustCreateException(TypeErrorType, "'chr' requires a 'int' object but received a \"float\"")},
 		{f: "chr", args: wrapArgs(300), wantExc: mustCreateException(ValueErrorType, "chr() arg not in range(256)")},
 		{f: "chr", args: wrapArgs(-1), wantExc: mustCreateException(ValueErrorType, "chr() arg not in range(256)")},
 		{f: "chr", args: wrapArgs(), wantExc: mustCreateException(TypeErrorType, "'chr' requires 1 arguments")},
@@ -55,6 +55,13 @@ func byteArrayGE(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return byteArrayCompare(v, w, False, True, True), nil
 }
 
+func byteArrayGetBuffer(f *Frame, o *Object, fun func([]byte) *BaseException) *BaseException {
+	a := toByteArrayUnsafe(o)
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return fun(a.Value())
+}
+
 func byteArrayGetItem(f *Frame, o, key *Object) (result *Object, raised *BaseException) {
 	a := toByteArrayUnsafe(o)
 	if key.typ.slots.Index != nil {
@@ -108,6 +115,14 @@ func byteArrayInit(f *Frame, o *Object, args Args, _ KWArgs) (*Object, *BaseExce
 	return None, nil
 }
 
+func byteArrayLen(f *Frame, o *Object) (*Object, *BaseException) {
+	a := toByteArrayUnsafe(o)
+	a.mutex.RLock()
+	ret := NewInt(len(a.value)).ToObject()
+	a.mutex.RUnlock()
+	return ret, nil
+}
+
 func byteArrayLE(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return byteArrayCompare(v, w, True, True, False), nil
 }
@@ -139,6 +154,75 @@ func byteArrayRepr(f *Frame, o *Object) (*Object, *BaseException) {
 	return NewStr(fmt.Sprintf("bytearray(b%s)", s.Value())).ToObject(), nil
 }
 
+func byteArraySetItem(f *Frame, o, key, value *Object) *BaseException {
+	a := toByteArrayUnsafe(o)
+	if key.typ.slots.Index != nil {
+		index, raised := IndexInt(f, key)
+		if raised != nil {
+			return raised
+		}
+		var b int
+		switch {
+		case value.isInstance(StrType):
+			s := toStrUnsafe(value).Value()
+			if len(s) != 1 {
+				return f.RaiseType(ValueErrorType, "string must be of size 1")
+			}
+			b = int(s[0])
+		case value.typ.slots.Index != nil:
+			if b, raised = IndexInt(f, value); raised != nil {
+				return raised
+			}
+		default:
+			return f.RaiseType(TypeErrorType, "an integer or string of size 1 is required")
+		}
+		if b < 0 || b > 255 {
+			return f.RaiseType(ValueErrorType, "byte must be in range(0, 256)")
+		}
+		a.mutex.Lock()
+		if index, raised = seqCheckedIndex(f, len(a.value), index); raised == nil {
+			a.value[index] = byte(b)
+		}
+		a.mutex.Unlock()
+		return raised
+	}
+	if !key.isInstance(SliceType) {
+		return f.RaiseType(TypeErrorType, fmt.Sprintf("bytearray indices must be integers or slice, not %s", key.typ.Name()))
+	}
+	if value.typ.slots.Buffer == nil || value.isInstance(UnicodeType) {
+		return f.RaiseType(TypeErrorType, "can assign only bytes, buffers, or iterables of ints in range(0, 256)")
+	}
+	var data []byte
+	raised := bufferApply(f, value, func(b []byte) *BaseException {
+		// Copy since value may alias a.
+		data = append([]byte(nil), b...)
+		return nil
+	})
+	if raised != nil {
+		return raised
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	numElems := len(a.value)
+	start, stop, step, sliceLen, raised := toSliceUnsafe(key).calcSlice(f, numElems)
+	if raised != nil {
+		return raised
+	}
+	if step == 1 {
+		a.value = append(a.value[:start:start], append(data, a.value[stop:]...)...)
+	} else if sliceLen == len(data) {
+		i := 0
+		for j := start; j != stop; j += step {
+			a.value[j] = data[i]
+			i++
+		}
+	} else {
+		format := "attempt to assign bytes of size %d to extended slice of size %d"
+		return f.RaiseType(ValueErrorType, fmt.Sprintf(format, len(data), sliceLen))
+	}
+	return nil
+}
+
 func byteArrayStr(f *Frame, o *Object) (*Object, *BaseException) {
 	a := toByteArrayUnsafe(o)
 	a.mutex.RLock()
@@ -148,16 +232,19 @@ func byteArrayStr(f *Frame, o *Object) (*Object, *BaseException) {
 }
 
 func initByteArrayType(dict map[string]*Object) {
+	ByteArrayType.slots.Buffer = &bufferSlot{byteArrayGetBuffer}
 	ByteArrayType.slots.Eq = &binaryOpSlot{byteArrayEq}
 	ByteArrayType.slots.GE = &binaryOpSlot{byteArrayGE}
 	ByteArrayType.slots.GetItem = &binaryOpSlot{byteArrayGetItem}
 	ByteArrayType.slots.GT = &binaryOpSlot{byteArrayGT}
 	ByteArrayType.slots.Init = &initSlot{byteArrayInit}
 	ByteArrayType.slots.LE = &binaryOpSlot{byteArrayLE}
+	ByteArrayType.slots.Len = &unaryOpSlot{byteArrayLen}
 	ByteArrayType.slots.LT = &binaryOpSlot{byteArrayLT}
 	ByteArrayType.slots.Native = &nativeSlot{byteArrayNative}
 	ByteArrayType.slots.NE = &binaryOpSlot{byteArrayNE}
 	ByteArrayType.slots.Repr = &unaryOpSlot{byteArrayRepr}
+	ByteArrayType.slots.SetItem = &setItemSlot{byteArraySetItem}
 	ByteArrayType.slots.Str = &unaryOpSlot{byteArrayStr}
 }
 
@@ -78,6 +78,18 @@ func TestByteArrayInit(t *testing.T) {
 	}
 }
 
+func TestByteArrayLen(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestByteArray("")), want: NewInt(0).ToObject()},
+		{args: wrapArgs(newTestByteArray("foo")), want: NewInt(3).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(Len), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestByteArrayNative(t *testing.T) {
 	val, raised := ToNative(NewRootFrame(), newTestByteArray("foo").ToObject())
 	if raised != nil {
@@ -101,6 +113,33 @@ func TestByteArrayRepr(t *testing.T) {
 	}
 }
 
+func TestByteArraySetItem(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, a *ByteArray, key, value *Object) (*Object, *BaseException) {
+		if raised := SetItem(f, a.ToObject(), key, value); raised != nil {
+			return nil, raised
+		}
+		return a.ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestByteArray("foo"), 0, 98), want: newTestByteArray("boo").ToObject()},
+		{args: wrapArgs(newTestByteArray("foo"), -1, "x"), want: newTestByteArray("fox").ToObject()},
+		{args: wrapArgs(newTestByteArray("foo"), newTestSlice(1, 2), "abc"), want: newTestByteArray("fabco").ToObject()},
+		{args: wrapArgs(newTestByteArray("foo"), newTestSlice(None, None), newTestByteArray("")), want: newTestByteArray("").ToObject()},
+		{args: wrapArgs(newTestByteArray("abcd"), newTestSlice(None, None, 2), "xy"), want: newTestByteArray("xbyd").ToObject()},
+		{args: wrapArgs(newTestByteArray("foo"), 3, 98), wantExc: mustCreateException(IndexErrorType, "index out of range")},
+		{args: wrapArgs(newTestByteArray("foo"), 0, 256), wantExc: mustCreateException(ValueErrorType, "byte must be in range(0, 256)")},
+		{args: wrapArgs(newTestByteArray("foo"), 0, "ab"), wantExc: mustCreateException(ValueErrorType, "string must be of size 1")},
+		{args: wrapArgs(newTestByteArray("foo"), 0, NewUnicode("a")), wantExc: mustCreateException(TypeErrorType, "an integer or string of size 1 is required")},
+		{args: wrapArgs(newTestByteArray("foo"), newTestSlice(None, None), NewUnicode("x")), wantExc: mustCreateException(TypeErrorType, "can assign only bytes, buffers, or iterables of ints in range(0, 256)")},
+		{args: wrapArgs(newTestByteArray("abcd"), newTestSlice(None, None, 2), "x"), wantExc: mustCreateException(ValueErrorType, "attempt to assign bytes of size 1 to extended slice of size 2")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestByteArrayStr(t *testing.T) {
 	cases := []invokeTestCase{
 		{args: wrapArgs(newTestByteArray("")), want: NewStr("").ToObject()},
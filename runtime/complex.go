@@ -110,6 +110,20 @@ func complexFloorDiv(f *Frame, v, w *Object) (*Object, *BaseException) {
 	})
 }
 
+func complexGetImag(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_imag", args, ComplexType); raised != nil {
+		return nil, raised
+	}
+	return NewFloat(imag(toComplexUnsafe(args[0]).Value())).ToObject(), nil
+}
+
+func complexGetReal(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_real", args, ComplexType); raised != nil {
+		return nil, raised
+	}
+	return NewFloat(real(toComplexUnsafe(args[0]).Value())).ToObject(), nil
+}
+
 func complexHash(f *Frame, o *Object) (*Object, *BaseException) {
 	v := toComplexUnsafe(o).Value()
 	hashCombined := hashFloat(real(v)) + 1000003*hashFloat(imag(v))
@@ -311,6 +325,8 @@ func complexSub(f *Frame, v, w *Object) (*Object, *BaseException) {
 }
 
 func initComplexType(dict map[string]*Object) {
+	dict["imag"] = newProperty(newBuiltinFunction("_get_imag", complexGetImag).ToObject(), nil, nil).ToObject()
+	dict["real"] = newProperty(newBuiltinFunction("_get_real", complexGetReal).ToObject(), nil, nil).ToObject()
 	ComplexType.slots.Abs = &unaryOpSlot{complexAbs}
 	ComplexType.slots.Add = &binaryOpSlot{complexAdd}
 	ComplexType.slots.Complex = &unaryOpSlot{complexComplex}
@@ -427,6 +427,30 @@ func TestComplexPos(t *testing.T) {
 	}
 }
 
+func TestComplexRealImag(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+		r, raised := GetAttr(f, o, NewStr("real"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		i, raised := GetAttr(f, o, NewStr("imag"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(r, i).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(complex(0, 0)), want: newTestTuple(0.0, 0.0).ToObject()},
+		{args: wrapArgs(complex(1.5, -2)), want: newTestTuple(1.5, -2.0).ToObject()},
+		{args: wrapArgs(complex(math.Inf(1), 3)), want: newTestTuple(math.Inf(1), 3.0).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestComplexRepr(t *testing.T) {
 	cases := []invokeTestCase{
 		{args: wrapArgs(complex(0.0, 0.0)), want: NewStr("0j").ToObject()},
@@ -17,6 +17,7 @@ package grumpy
 import (
 	"fmt"
 	"log"
+	"math/big"
 	"reflect"
 	"sync/atomic"
 )
@@ -26,8 +27,18 @@ var (
 	// ThreadCount is the number of goroutines started with StartThread that
 	// have not yet joined.
 	ThreadCount int64
+
+	// The hook names used by checkClassInfo. They're interned so that
+	// mroLookup can cache the lookups.
+	internedInstanceCheck = InternStr("__instancecheck__")
+	internedSubclassCheck = InternStr("__subclasscheck__")
 )
 
+// maxClassInfoDepth bounds the nesting of isinstance() and issubclass()
+// checks, which can recurse through classinfo tuples and user defined
+// __instancecheck__ and __subclasscheck__ methods.
+const maxClassInfoDepth = 1000
+
 // Abs returns the result of o.__abs__ and is equivalent to the Python
 // expression "abs(o)".
 func Abs(f *Frame, o *Object) (*Object, *BaseException) {
@@ -41,6 +52,9 @@ func Abs(f *Frame, o *Object) (*Object, *BaseException) {
 // Add returns the result of adding v and w together according to the
 // __add/radd__ operator.
 func Add(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r := fastArithmeticOp(v, w, intCheckedAdd, longAdd, floatAddFunc); r != nil {
+		return r, nil
+	}
 	return binaryOp(f, v, w, v.typ.slots.Add, v.typ.slots.RAdd, w.typ.slots.RAdd, "+")
 }
 
@@ -173,6 +187,9 @@ func DivMod(f *Frame, v, w *Object) (*Object, *BaseException) {
 
 // Eq returns the equality of v and w according to the __eq__ operator.
 func Eq(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r, ok := fastCompare(compareOpEq, v, w); ok {
+		return GetBool(r).ToObject(), nil
+	}
 	r, raised := compareRich(f, compareOpEq, v, w)
 	if raised != nil {
 		return nil, raised
@@ -183,6 +200,12 @@ func Eq(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return GetBool(compareDefault(f, v, w) == 0).ToObject(), nil
 }
 
+// EqBool returns the truthiness of v == w. It is equivalent to calling IsTrue on
+// the result of Eq but doesn't allocate when v and w are numbers.
+func EqBool(f *Frame, v, w *Object) (bool, *BaseException) {
+	return compareBool(f, compareOpEq, v, w, Eq)
+}
+
 // FloorDiv returns the equality of v and w according to the __floordiv/rfloordiv__ operator.
 func FloorDiv(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return binaryOp(f, v, w, v.typ.slots.FloorDiv, v.typ.slots.RFloorDiv, w.typ.slots.RFloorDiv, "//")
@@ -220,6 +243,9 @@ func FormatExc(f *Frame) (s string) {
 
 // GE returns the result of operation v >= w.
 func GE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r, ok := fastCompare(compareOpGE, v, w); ok {
+		return GetBool(r).ToObject(), nil
+	}
 	r, raised := compareRich(f, compareOpGE, v, w)
 	if raised != nil {
 		return nil, raised
@@ -230,6 +256,12 @@ func GE(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return GetBool(compareDefault(f, v, w) >= 0).ToObject(), nil
 }
 
+// GEBool returns the truthiness of v >= w. It is equivalent to calling IsTrue on
+// the result of GE but doesn't allocate when v and w are numbers.
+func GEBool(f *Frame, v, w *Object) (bool, *BaseException) {
+	return compareBool(f, compareOpGE, v, w, GE)
+}
+
 // GetItem returns the result of operation o[key].
 func GetItem(f *Frame, o, key *Object) (*Object, *BaseException) {
 	getItem := o.typ.slots.GetItem
@@ -258,6 +290,9 @@ func GetAttr(f *Frame, o *Object, name *Str, def *Object) (*Object, *BaseExcepti
 
 // GT returns the result of operation v > w.
 func GT(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r, ok := fastCompare(compareOpGT, v, w); ok {
+		return GetBool(r).ToObject(), nil
+	}
 	r, raised := compareRich(f, compareOpGT, v, w)
 	if raised != nil {
 		return nil, raised
@@ -268,6 +303,12 @@ func GT(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return GetBool(compareDefault(f, v, w) > 0).ToObject(), nil
 }
 
+// GTBool returns the truthiness of v > w. It is equivalent to calling IsTrue on
+// the result of GT but doesn't allocate when v and w are numbers.
+func GTBool(f *Frame, v, w *Object) (bool, *BaseException) {
+	return compareBool(f, compareOpGT, v, w, GT)
+}
+
 // Hash returns the hash of o according to its __hash__ operator.
 func Hash(f *Frame, o *Object) (*Int, *BaseException) {
 	hash := o.typ.slots.Hash
@@ -305,9 +346,24 @@ func Hex(f *Frame, o *Object) (*Object, *BaseException) {
 // IAdd returns the result of v.__iadd__ if defined, otherwise falls back to
 // Add.
 func IAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if v.typ == StrType && w.typ == StrType {
+		return strIAdd(f, v, w)
+	}
 	return inplaceOp(f, v, w, v.typ.slots.IAdd, Add)
 }
 
+// IAddInt is equivalent to IAdd(f, v, NewInt(n).ToObject()) but avoids
+// dispatching through v's slots when v is an int. The compiler uses it for
+// statements like "i += 1".
+func IAddInt(f *Frame, v *Object, n int) (*Object, *BaseException) {
+	if v.typ == IntType {
+		if r, ok := intCheckedAdd(toIntUnsafe(v).Value(), n); ok {
+			return NewInt(r).ToObject(), nil
+		}
+	}
+	return IAdd(f, v, NewInt(n).ToObject())
+}
+
 // IAnd returns the result of v.__iand__ if defined, otherwise falls back to
 // And.
 func IAnd(f *Frame, v, w *Object) (*Object, *BaseException) {
@@ -373,36 +429,41 @@ func IRShift(f *Frame, v, w *Object) (*Object, *BaseException) {
 // IsInstance returns true if the type o is an instance of classinfo, or an
 // instance of an element in classinfo (if classinfo is a tuple). It returns
 // false otherwise. The argument classinfo must be a type or a tuple whose
-// elements are types like the isinstance() Python builtin.
+// elements are types like the isinstance() Python builtin. Like CPython, the
+// __instancecheck__ method of classinfo's metaclass is consulted if present.
 func IsInstance(f *Frame, o *Object, classinfo *Object) (bool, *BaseException) {
-	return IsSubclass(f, o.typ.ToObject(), classinfo)
+	// Fast path: plain types can't override __instancecheck__.
+	if o.typ.ToObject() == classinfo || classinfo.typ == TypeType {
+		return o.typ.isSubclass(toTypeUnsafe(classinfo)), nil
+	}
+	return checkClassInfo(f, internedInstanceCheck, o, classinfo, IsInstance, func() (bool, *BaseException) {
+		if !classinfo.isInstance(TypeType) {
+			return false, f.RaiseType(TypeErrorType, "classinfo must be a type or tuple of types")
+		}
+		return o.typ.isSubclass(toTypeUnsafe(classinfo)), nil
+	})
 }
 
 // IsSubclass returns true if the type o is a subtype of classinfo or a subtype
 // of an element in classinfo (if classinfo is a tuple). It returns false
 // otherwise. The argument o must be a type and classinfo must be a type or a
-// tuple whose elements are types like the issubclass() Python builtin.
+// tuple whose elements are types like the issubclass() Python builtin. Like
+// CPython, the __subclasscheck__ method of classinfo's metaclass is consulted
+// if present.
 func IsSubclass(f *Frame, o *Object, classinfo *Object) (bool, *BaseException) {
-	if !o.isInstance(TypeType) {
-		return false, f.RaiseType(TypeErrorType, "issubclass() arg 1 must be a class")
-	}
-	t := toTypeUnsafe(o)
-	errorMsg := "classinfo must be a type or tuple of types"
-	if classinfo.isInstance(TypeType) {
-		return t.isSubclass(toTypeUnsafe(classinfo)), nil
+	// Fast path: plain types can't override __subclasscheck__.
+	if classinfo.typ == TypeType && o.isInstance(TypeType) {
+		return toTypeUnsafe(o).isSubclass(toTypeUnsafe(classinfo)), nil
 	}
-	if !classinfo.isInstance(TupleType) {
-		return false, f.RaiseType(TypeErrorType, errorMsg)
-	}
-	for _, elem := range toTupleUnsafe(classinfo).elems {
-		if !elem.isInstance(TypeType) {
-			return false, f.RaiseType(TypeErrorType, errorMsg)
+	return checkClassInfo(f, internedSubclassCheck, o, classinfo, IsSubclass, func() (bool, *BaseException) {
+		if !o.isInstance(TypeType) {
+			return false, f.RaiseType(TypeErrorType, "issubclass() arg 1 must be a class")
 		}
-		if t.isSubclass(toTypeUnsafe(elem)) {
-			return true, nil
+		if !classinfo.isInstance(TypeType) {
+			return false, f.RaiseType(TypeErrorType, "classinfo must be a type or tuple of types")
 		}
-	}
-	return false, nil
+		return toTypeUnsafe(o).isSubclass(toTypeUnsafe(classinfo)), nil
+	})
 }
 
 // IsTrue returns the truthiness of o according to the __nonzero__ operator.
@@ -444,6 +505,18 @@ func ISub(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return Sub(f, v, w)
 }
 
+// ISubInt is equivalent to ISub(f, v, NewInt(n).ToObject()) but avoids
+// dispatching through v's slots when v is an int. The compiler uses it for
+// statements like "i -= 1".
+func ISubInt(f *Frame, v *Object, n int) (*Object, *BaseException) {
+	if v.typ == IntType {
+		if r, ok := intCheckedSub(toIntUnsafe(v).Value(), n); ok {
+			return NewInt(r).ToObject(), nil
+		}
+	}
+	return ISub(f, v, NewInt(n).ToObject())
+}
+
 // Iter implements the Python iter() builtin. It returns an iterator for o if
 // o is iterable. Otherwise it raises TypeError.
 // Note that the iter(f, sentinel) form is not yet supported.
@@ -467,6 +540,9 @@ func IXor(f *Frame, v, w *Object) (*Object, *BaseException) {
 
 // LE returns the result of operation v <= w.
 func LE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r, ok := fastCompare(compareOpLE, v, w); ok {
+		return GetBool(r).ToObject(), nil
+	}
 	r, raised := compareRich(f, compareOpLE, v, w)
 	if raised != nil {
 		return nil, raised
@@ -477,6 +553,12 @@ func LE(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return GetBool(compareDefault(f, v, w) <= 0).ToObject(), nil
 }
 
+// LEBool returns the truthiness of v <= w. It is equivalent to calling IsTrue on
+// the result of LE but doesn't allocate when v and w are numbers.
+func LEBool(f *Frame, v, w *Object) (bool, *BaseException) {
+	return compareBool(f, compareOpLE, v, w, LE)
+}
+
 // Len returns the length of the given sequence object.
 func Len(f *Frame, o *Object) (*Int, *BaseException) {
 	lenSlot := o.typ.slots.Len
@@ -501,6 +583,9 @@ func LShift(f *Frame, v, w *Object) (*Object, *BaseException) {
 
 // LT returns the result of operation v < w.
 func LT(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r, ok := fastCompare(compareOpLT, v, w); ok {
+		return GetBool(r).ToObject(), nil
+	}
 	r, raised := compareRich(f, compareOpLT, v, w)
 	if raised != nil {
 		return nil, raised
@@ -511,6 +596,12 @@ func LT(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return GetBool(compareDefault(f, v, w) < 0).ToObject(), nil
 }
 
+// LTBool returns the truthiness of v < w. It is equivalent to calling IsTrue on
+// the result of LT but doesn't allocate when v and w are numbers.
+func LTBool(f *Frame, v, w *Object) (bool, *BaseException) {
+	return compareBool(f, compareOpLT, v, w, LT)
+}
+
 // Mod returns the remainder from the division of v by w according to the
 // __mod/rmod__ operator.
 func Mod(f *Frame, v, w *Object) (*Object, *BaseException) {
@@ -520,6 +611,9 @@ func Mod(f *Frame, v, w *Object) (*Object, *BaseException) {
 // Mul returns the result of multiplying v and w together according to the
 // __mul/rmul__ operator.
 func Mul(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r := fastArithmeticOp(v, w, intCheckedMul, longMul, floatMulFunc); r != nil {
+		return r, nil
+	}
 	return binaryOp(f, v, w, v.typ.slots.Mul, v.typ.slots.RMul, w.typ.slots.RMul, "*")
 }
 
@@ -646,6 +740,9 @@ func Invoke(f *Frame, callable *Object, args Args, varargs *Object, keywords KWA
 
 // NE returns the non-equality of v and w according to the __ne__ operator.
 func NE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r, ok := fastCompare(compareOpNE, v, w); ok {
+		return GetBool(r).ToObject(), nil
+	}
 	r, raised := compareRich(f, compareOpNE, v, w)
 	if raised != nil {
 		return nil, raised
@@ -656,6 +753,12 @@ func NE(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return GetBool(compareDefault(f, v, w) != 0).ToObject(), nil
 }
 
+// NEBool returns the truthiness of v != w. It is equivalent to calling IsTrue on
+// the result of NE but doesn't allocate when v and w are numbers.
+func NEBool(f *Frame, v, w *Object) (bool, *BaseException) {
+	return compareBool(f, compareOpNE, v, w, NE)
+}
+
 // Next implements the Python next() builtin. It calls next on the provided
 // iterator. It raises TypeError if iter is not an iterator object.
 // Note that the next(it, default) form is not yet supported.
@@ -699,14 +802,24 @@ func Pos(f *Frame, o *Object) (*Object, *BaseException) {
 // and outputs the results to stdout separated by spaces. Similar to the Python
 // print statement.
 func Print(f *Frame, args Args, nl bool) *BaseException {
-	// TODO: Support outputting to files other than stdout and softspace.
+	return PrintTo(f, Stdout.ToObject(), args, nl)
+}
+
+// PrintTo implements the Python "print >>dest" statement. It behaves like
+// Print but writes to dest, which is either None (meaning stdout) or a file
+// like object with a write method.
+func PrintTo(f *Frame, dest *Object, args Args, nl bool) *BaseException {
+	// TODO: Support softspace.
 	var end string
 	if nl {
 		end = "\n"
 	} else if len(args) > 0 {
 		end = " "
 	}
-	return pyPrint(f, args, " ", end, Stdout)
+	if dest == None {
+		dest = Stdout.ToObject()
+	}
+	return pyPrint(f, args, " ", end, dest)
 }
 
 // Repr returns a string containing a printable representation of o. This is
@@ -755,7 +868,7 @@ func ResolveGlobal(f *Frame, name *Str) (*Object, *BaseException) {
 	if value, raised := f.Globals().GetItem(f, name.ToObject()); raised != nil || value != nil {
 		return value, raised
 	}
-	value, raised := Builtins.GetItem(f, name.ToObject())
+	value, raised := lookupBuiltin(f, name)
 	if raised != nil {
 		return nil, raised
 	}
@@ -816,6 +929,9 @@ func StartThread(callable *Object) {
 // Sub returns the result of subtracting v from w according to the
 // __sub/rsub__ operator.
 func Sub(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if r := fastArithmeticOp(v, w, intCheckedSub, longSub, floatSubFunc); r != nil {
+		return r, nil
+	}
 	return binaryOp(f, v, w, v.typ.slots.Sub, v.typ.slots.RSub, w.typ.slots.RSub, "-")
 }
 
@@ -987,6 +1103,48 @@ func binaryOp(f *Frame, v, w *Object, op, vrop, wrop *binaryOpSlot, opName strin
 	return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(errUnsupportedOperand, opName, v.typ.Name(), w.typ.Name()))
 }
 
+// fastArithmeticOp computes the result of an arithmetic operation directly
+// when v and w are exact ints, longs or floats, promoting to long when an int
+// operation overflows. It returns nil when the operands are of any other type
+// (including subclasses), in which case the operation must be dispatched via
+// binaryOp.
+func fastArithmeticOp(v, w *Object, intFun func(v, w int) (int, bool), bigFun func(z, x, y *big.Int), floatFun func(v, w float64) float64) *Object {
+	switch v.typ {
+	case IntType:
+		switch w.typ {
+		case IntType:
+			x, y := toIntUnsafe(v), toIntUnsafe(w)
+			if r, ok := intFun(x.Value(), y.Value()); ok {
+				return NewInt(r).ToObject()
+			}
+			return longCallBinary(bigFun, intToLong(x), intToLong(y))
+		case LongType:
+			return longCallBinary(bigFun, intToLong(toIntUnsafe(v)), toLongUnsafe(w))
+		case FloatType:
+			return NewFloat(floatFun(float64(toIntUnsafe(v).Value()), toFloatUnsafe(w).Value())).ToObject()
+		}
+	case LongType:
+		switch w.typ {
+		case IntType:
+			return longCallBinary(bigFun, toLongUnsafe(v), intToLong(toIntUnsafe(w)))
+		case LongType:
+			return longCallBinary(bigFun, toLongUnsafe(v), toLongUnsafe(w))
+		}
+	case FloatType:
+		switch w.typ {
+		case IntType:
+			return NewFloat(floatFun(toFloatUnsafe(v).Value(), float64(toIntUnsafe(w).Value()))).ToObject()
+		case FloatType:
+			return NewFloat(floatFun(toFloatUnsafe(v).Value(), toFloatUnsafe(w).Value())).ToObject()
+		}
+	}
+	return nil
+}
+
+func floatAddFunc(v, w float64) float64 { return v + w }
+func floatMulFunc(v, w float64) float64 { return v * w }
+func floatSubFunc(v, w float64) float64 { return v - w }
+
 func inplaceOp(f *Frame, v, w *Object, slot *binaryOpSlot, fallback binaryOpFunc) (*Object, *BaseException) {
 	if slot != nil {
 		return slot.Fn(f, v, w)
@@ -1047,10 +1205,80 @@ func compareRich(f *Frame, op compareOp, v, w *Object) (*Object, *BaseException)
 	return try3wayToRichCompare(f, op, v, w)
 }
 
+// compareBool returns the truthiness of the comparison op between v and w,
+// using fallback to compute the result when a fast comparison isn't possible.
+func compareBool(f *Frame, op compareOp, v, w *Object, fallback binaryOpFunc) (bool, *BaseException) {
+	if r, ok := fastCompare(op, v, w); ok {
+		return r, nil
+	}
+	r, raised := fallback(f, v, w)
+	if raised != nil {
+		return false, raised
+	}
+	return IsTrue(f, r)
+}
+
+// fastCompare compares v and w directly when they are exact ints, longs or
+// floats. ok is false when the operands are of any other type (including
+// subclasses), in which case the comparison must be done via compareRich.
+func fastCompare(op compareOp, v, w *Object) (result, ok bool) {
+	var c int
+	switch {
+	case v.typ == IntType && w.typ == IntType:
+		x, y := toIntUnsafe(v).Value(), toIntUnsafe(w).Value()
+		if x < y {
+			c = -1
+		} else if x > y {
+			c = 1
+		}
+	case (v.typ == IntType || v.typ == LongType) && (w.typ == IntType || w.typ == LongType):
+		c = fastBigInt(v).Cmp(fastBigInt(w))
+	case (v.typ == FloatType || v.typ == IntType) && (w.typ == FloatType || w.typ == IntType):
+		x, y := fastFloat(v), fastFloat(w)
+		switch {
+		case x < y:
+			c = -1
+		case x > y:
+			c = 1
+		case x != y:
+			// A NaN is involved, which compares false like
+			// floatCompare.
+			return false, true
+		}
+	default:
+		return false, false
+	}
+	return convert3wayToBool(op, c), true
+}
+
+// fastBigInt returns the value of o, which must be an exact int or long, as
+// a big.Int.
+func fastBigInt(o *Object) *big.Int {
+	if o.typ == IntType {
+		return big.NewInt(int64(toIntUnsafe(o).Value()))
+	}
+	return &toLongUnsafe(o).value
+}
+
+// fastFloat returns the value of o, which must be an exact int or float, as
+// a float64.
+func fastFloat(o *Object) float64 {
+	if o.typ == IntType {
+		return float64(toIntUnsafe(o).Value())
+	}
+	return toFloatUnsafe(o).Value()
+}
+
 // convert3wayToObject converts the integer results from a 3-way
 // comparison to a suitable boolean value for the given rich
 // comparison op.
 func convert3wayToObject(op compareOp, c int) *Object {
+	return GetBool(convert3wayToBool(op, c)).ToObject()
+}
+
+// convert3wayToBool converts the integer results from a 3-way comparison to
+// the result of the given rich comparison op.
+func convert3wayToBool(op compareOp, c int) bool {
 	b := false
 	switch op {
 	case compareOpLT:
@@ -1066,7 +1294,7 @@ func convert3wayToObject(op compareOp, c int) *Object {
 	case compareOpGT:
 		b = c > 0
 	}
-	return GetBool(b).ToObject()
+	return b
 }
 
 // try3wayToRichCompare tries to perform a rich comparison operation on the given objects
@@ -1232,6 +1460,54 @@ func tryRichTo3wayCompare(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return NotImplemented, nil
 }
 
+// bufferApply calls fun with the bytes exported by o's __buffer__ slot. The
+// slice is borrowed and must not be retained or modified. It raises TypeError
+// if o does not support the buffer interface.
+func bufferApply(f *Frame, o *Object, fun func([]byte) *BaseException) *BaseException {
+	buffer := o.typ.slots.Buffer
+	if buffer == nil {
+		return f.RaiseType(TypeErrorType, fmt.Sprintf("'%s' does not have the buffer interface", o.typ.Name()))
+	}
+	return buffer.Fn(f, o, fun)
+}
+
+// checkClassInfo performs the parts of isinstance() and issubclass() that go
+// beyond a simple MRO walk. Tuples are checked element-wise using check and
+// otherwise the method named by hook is looked up on classinfo's metaclass and
+// called if present. If neither applies then fallback is used.
+func checkClassInfo(f *Frame, hook *Str, o, classinfo *Object, check func(*Frame, *Object, *Object) (bool, *BaseException), fallback func() (bool, *BaseException)) (bool, *BaseException) {
+	if f.threadState.classInfoDepth >= maxClassInfoDepth {
+		return false, f.RaiseType(RuntimeErrorType, "maximum recursion depth exceeded in "+hook.Value())
+	}
+	f.threadState.classInfoDepth++
+	defer func() { f.threadState.classInfoDepth-- }()
+	if classinfo.isInstance(TupleType) {
+		for _, elem := range toTupleUnsafe(classinfo).elems {
+			if ret, raised := check(f, o, elem); raised != nil || ret {
+				return ret, raised
+			}
+		}
+		return false, nil
+	}
+	method, raised := classinfo.typ.mroLookup(f, hook)
+	if raised != nil {
+		return false, raised
+	}
+	if method == nil {
+		return fallback()
+	}
+	if get := method.typ.slots.Get; get != nil {
+		if method, raised = get.Fn(f, method, classinfo, classinfo.typ); raised != nil {
+			return false, raised
+		}
+	}
+	ret, raised := method.Call(f, Args{o}, nil)
+	if raised != nil {
+		return false, raised
+	}
+	return IsTrue(f, ret)
+}
+
 func checkFunctionArgs(f *Frame, function string, args Args, types ...*Type) *BaseException {
 	if len(args) != len(types) {
 		msg := fmt.Sprintf("'%s' requires %d arguments", function, len(types))
@@ -1279,12 +1555,11 @@ func hashNotImplemented(f *Frame, o *Object) (*Object, *BaseException) {
 }
 
 // pyPrint encapsulates the logic of the Python print function.
-func pyPrint(f *Frame, args Args, sep, end string, file *File) *BaseException {
+func pyPrint(f *Frame, args Args, sep, end string, file *Object) *BaseException {
 	for i, arg := range args {
 		if i > 0 {
-			err := file.writeString(sep)
-			if err != nil {
-				return f.RaiseType(IOErrorType, err.Error())
+			if raised := printWrite(f, file, sep); raised != nil {
+				return raised
 			}
 		}
 
@@ -1293,16 +1568,52 @@ func pyPrint(f *Frame, args Args, sep, end string, file *File) *BaseException {
 			return raised
 		}
 
-		err := file.writeString(s.Value())
-		if err != nil {
-			return f.RaiseType(IOErrorType, err.Error())
+		if raised := printWrite(f, file, s.Value()); raised != nil {
+			return raised
 		}
 	}
 
-	err := file.writeString(end)
-	if err != nil {
-		return f.RaiseType(IOErrorType, err.Error())
-	}
+	return printWrite(f, file, end)
+}
 
+// printWrite writes s to file on behalf of pyPrint. Builtin files and
+// in-memory buffers are written to directly, other objects via their write
+// method.
+func printWrite(f *Frame, file *Object, s string) *BaseException {
+	if s == "" {
+		return nil
+	}
+	var b *memBuffer
+	switch file.typ {
+	case FileType:
+		if err := toFileUnsafe(file).writeString(s); err != nil {
+			return f.RaiseType(IOErrorType, err.Error())
+		}
+		return nil
+	case stringOType:
+		b = &toStringOUnsafe(file).memBuffer
+	case bytesIOType:
+		b = &toBytesIOUnsafe(file).memBuffer
+	default:
+		write, raised := GetAttr(f, file, NewStr("write"), nil)
+		if raised != nil {
+			return raised
+		}
+		_, raised = write.Call(f, Args{NewStr(s).ToObject()}, nil)
+		return raised
+	}
+	b.mutex.Lock()
+	closed := b.closed
+	if !closed {
+		b.write([]byte(s))
+	}
+	b.mutex.Unlock()
+	if closed {
+		msg := errIOClosed
+		if file.typ == bytesIOType {
+			msg += "."
+		}
+		return f.RaiseType(ValueErrorType, msg)
+	}
 	return nil
 }
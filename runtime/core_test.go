@@ -16,6 +16,7 @@ package grumpy
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"regexp"
@@ -117,6 +118,10 @@ func TestBinaryOps(t *testing.T) {
 		{And, NewInt(-42).ToObject(), NewInt(244).ToObject(), NewInt(212).ToObject(), nil},
 		{And, NewInt(42).ToObject(), NewStr("foo").ToObject(), nil, mustCreateException(TypeErrorType, "unsupported operand type(s) for &: 'int' and 'str'")},
 		{Add, newObject(fooType), newObject(barType), NewStr("foo add").ToObject(), nil},
+		{Add, NewInt(MaxInt).ToObject(), NewInt(1).ToObject(), NewLong(new(big.Int).Add(maxIntBig, big.NewInt(1))).ToObject(), nil},
+		{Add, NewInt(2).ToObject(), NewLong(big.NewInt(3)).ToObject(), NewLong(big.NewInt(5)).ToObject(), nil},
+		{Add, NewFloat(1.5).ToObject(), NewInt(2).ToObject(), NewFloat(3.5).ToObject(), nil},
+		{Add, True.ToObject(), True.ToObject(), NewInt(2).ToObject(), nil},
 		{Div, NewInt(123).ToObject(), newObject(bazType), NewStr("123").ToObject(), nil},
 		{IAdd, NewStr("foo").ToObject(), NewStr("bar").ToObject(), NewStr("foobar").ToObject(), nil},
 		{IAdd, NewStr("foo").ToObject(), NewStr("bar").ToObject(), NewStr("foobar").ToObject(), nil},
@@ -144,12 +149,17 @@ func TestBinaryOps(t *testing.T) {
 		{IXor, newObject(ObjectType), newObject(fooType), nil, mustCreateException(TypeErrorType, "unsupported operand type(s) for ^: 'object' and 'Foo'")},
 		{Mod, NewInt(24).ToObject(), NewInt(6).ToObject(), NewInt(0).ToObject(), nil},
 		{Mul, NewStr("foo").ToObject(), NewInt(3).ToObject(), NewStr("foofoofoo").ToObject(), nil},
+		{Mul, NewInt(MaxInt).ToObject(), NewInt(2).ToObject(), NewLong(new(big.Int).Mul(maxIntBig, big.NewInt(2))).ToObject(), nil},
+		{Mul, NewInt(3).ToObject(), NewFloat(0.5).ToObject(), NewFloat(1.5).ToObject(), nil},
 		{Mul, newObject(ObjectType), newObject(fooType), nil, mustCreateException(TypeErrorType, "unsupported operand type(s) for *: 'object' and 'Foo'")},
 		{Or, NewInt(-42).ToObject(), NewInt(244).ToObject(), NewInt(-10).ToObject(), nil},
 		{Or, NewInt(42).ToObject(), NewStr("foo").ToObject(), nil, mustCreateException(TypeErrorType, "unsupported operand type(s) for |: 'int' and 'str'")},
 		{Pow, NewInt(2).ToObject(), NewInt(-2).ToObject(), NewFloat(0.25).ToObject(), nil},
 		{Pow, NewInt(2).ToObject(), newObject(fooType), nil, mustCreateException(TypeErrorType, "unsupported operand type(s) for **: 'int' and 'Foo'")},
 		{Sub, NewInt(3).ToObject(), NewInt(-3).ToObject(), NewInt(6).ToObject(), nil},
+		{Sub, NewInt(MinInt).ToObject(), NewInt(1).ToObject(), NewLong(new(big.Int).Sub(minIntBig, big.NewInt(1))).ToObject(), nil},
+		{Sub, NewLong(big.NewInt(7)).ToObject(), NewInt(3).ToObject(), NewLong(big.NewInt(4)).ToObject(), nil},
+		{Sub, NewInt(7).ToObject(), NewFloat(0.5).ToObject(), NewFloat(6.5).ToObject(), nil},
 		{Xor, NewInt(-42).ToObject(), NewInt(244).ToObject(), NewInt(-222).ToObject(), nil},
 		{Xor, NewInt(42).ToObject(), NewStr("foo").ToObject(), nil, mustCreateException(TypeErrorType, "unsupported operand type(s) for ^: 'int' and 'str'")},
 	}
@@ -240,6 +250,46 @@ func TestCompare(t *testing.T) {
 	}
 }
 
+func TestCompareBool(t *testing.T) {
+	intLTType := newTestClass("IntLT", []*Type{IntType}, newStringDict(map[string]*Object{
+		"__lt__": newBuiltinFunction("__lt__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return True.ToObject(), nil
+		}).ToObject(),
+	}))
+	fun := wrapFuncForTest(func(f *Frame, v, w *Object) (*Tuple, *BaseException) {
+		var results []*Object
+		for _, cmp := range []func(*Frame, *Object, *Object) (bool, *BaseException){LTBool, LEBool, EqBool, NEBool, GEBool, GTBool} {
+			r, raised := cmp(f, v, w)
+			if raised != nil {
+				return nil, raised
+			}
+			results = append(results, GetBool(r).ToObject())
+		}
+		return NewTuple(results...), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(1, 2), want: compareAllResultLT},
+		{args: wrapArgs(MaxInt, MaxInt), want: compareAllResultEq},
+		{args: wrapArgs(NewLong(new(big.Int).Add(maxIntBig, big.NewInt(1))), MaxInt), want: compareAllResultGT},
+		{args: wrapArgs(-1, NewLong(big.NewInt(-1))), want: compareAllResultEq},
+		{args: wrapArgs(2, 1.5), want: compareAllResultGT},
+		{args: wrapArgs(1.5, 1.5), want: compareAllResultEq},
+		{args: wrapArgs(math.NaN(), 1), want: newTestTuple(false, false, false, false, false, false).ToObject()},
+		{args: wrapArgs(false, true), want: compareAllResultLT},
+		{args: wrapArgs(mustNotRaise(intLTType.Call(NewRootFrame(), wrapArgs(5), nil)), 1), want: newTestTuple(true, false, false, true, true, true).ToObject()},
+		{args: wrapArgs("foo", "bar"), want: compareAllResultGT},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+		// The results must agree with the Object returning functions.
+		if err := runInvokeTestCase(compareAll, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestCompareDefault(t *testing.T) {
 	o1, o2 := newObject(ObjectType), newObject(ObjectType)
 	// Make sure uintptr(o1) < uintptr(o2).
@@ -457,6 +507,42 @@ func TestHex(t *testing.T) {
 	}
 }
 
+func TestIAddISubInt(t *testing.T) {
+	inplaceType := newTestClass("Inplace", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"__iadd__": newBuiltinFunction("__iadd__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return NewStr("iadd").ToObject(), nil
+		}).ToObject(),
+		"__isub__": newBuiltinFunction("__isub__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return NewStr("isub").ToObject(), nil
+		}).ToObject(),
+	}))
+	fun := wrapFuncForTest(func(f *Frame, v *Object, n int) (*Tuple, *BaseException) {
+		sum, raised := IAddInt(f, v, n)
+		if raised != nil {
+			return nil, raised
+		}
+		diff, raised := ISubInt(f, v, n)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(sum, diff), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(41, 1), want: newTestTuple(42, 40).ToObject()},
+		{args: wrapArgs(MaxInt, 1), want: newTestTuple(NewLong(new(big.Int).Add(maxIntBig, big.NewInt(1))), MaxInt-1).ToObject()},
+		{args: wrapArgs(MinInt, 1), want: newTestTuple(MinInt+1, NewLong(new(big.Int).Sub(minIntBig, big.NewInt(1)))).ToObject()},
+		{args: wrapArgs(NewLong(big.NewInt(3)), 2), want: newTestTuple(NewLong(big.NewInt(5)), NewLong(big.NewInt(1))).ToObject()},
+		{args: wrapArgs(1.5, 1), want: newTestTuple(2.5, 0.5).ToObject()},
+		{args: wrapArgs(newObject(inplaceType), 1), want: newTestTuple("iadd", "isub").ToObject()},
+		{args: wrapArgs("foo", 1), wantExc: mustCreateException(TypeErrorType, "unsupported operand type(s) for +: 'str' and 'int'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestIndex(t *testing.T) {
 	goodType := newTestClass("GoodIndex", []*Type{ObjectType}, newStringDict(map[string]*Object{
 		"__index__": newBuiltinFunction("__index__", func(f *Frame, _ Args, _ KWArgs) (*Object, *BaseException) {
@@ -557,6 +643,61 @@ func TestIsInstanceIsSubclass(t *testing.T) {
 	}
 }
 
+func TestIsInstanceIsSubclassHooks(t *testing.T) {
+	// class Meta(type):
+	//   def __instancecheck__(cls, o):
+	//     return isinstance(o, int)
+	//   def __subclasscheck__(cls, o):
+	//     return issubclass(o, int)
+	metaType := newTestClass("Meta", []*Type{TypeType}, newStringDict(map[string]*Object{
+		"__instancecheck__": wrapFuncForTest(func(f *Frame, cls, o *Object) (bool, *BaseException) {
+			return IsInstance(f, o, IntType.ToObject())
+		}),
+		"__subclasscheck__": wrapFuncForTest(func(f *Frame, cls, o *Object) (bool, *BaseException) {
+			return IsSubclass(f, o, IntType.ToObject())
+		}),
+	}))
+	fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
+	// class Virtual(object):
+	//   __metaclass__ = Meta
+	virtualType := &Type{Object: Object{typ: metaType}, name: "Virtual", basis: fooType.basis, bases: []*Type{ObjectType}}
+	virtualType.setDict(NewDict())
+	prepareType(virtualType)
+	// class Recursive(object):
+	//   __metaclass__ = RecursiveMeta
+	recursiveMetaType := newTestClass("RecursiveMeta", []*Type{TypeType}, newStringDict(map[string]*Object{
+		"__instancecheck__": wrapFuncForTest(func(f *Frame, cls, o *Object) (bool, *BaseException) {
+			return IsInstance(f, o, cls)
+		}),
+	}))
+	recursiveType := &Type{Object: Object{typ: recursiveMetaType}, name: "Recursive", basis: fooType.basis, bases: []*Type{ObjectType}}
+	recursiveType.setDict(NewDict())
+	prepareType(recursiveType)
+	cases := []struct {
+		fun  func(*Frame, *Object, *Object) (bool, *BaseException)
+		args Args
+		want *Object
+		exc  *BaseException
+	}{
+		{IsInstance, wrapArgs(42, virtualType), True.ToObject(), nil},
+		{IsInstance, wrapArgs(newObject(virtualType), virtualType), True.ToObject(), nil},
+		{IsInstance, wrapArgs("foo", virtualType), False.ToObject(), nil},
+		{IsInstance, wrapArgs(True, newTestTuple(StrType, newTestTuple(virtualType))), True.ToObject(), nil},
+		{IsInstance, wrapArgs(None, recursiveType), nil, mustCreateException(RuntimeErrorType, "maximum recursion depth exceeded in __instancecheck__")},
+		{IsInstance, wrapArgs(newObject(recursiveType), recursiveType), True.ToObject(), nil},
+		{IsSubclass, wrapArgs(BoolType, virtualType), True.ToObject(), nil},
+		{IsSubclass, wrapArgs(fooType, virtualType), False.ToObject(), nil},
+		{IsSubclass, wrapArgs(fooType, newTestTuple(virtualType, fooType)), True.ToObject(), nil},
+		{IsSubclass, wrapArgs(None, virtualType), nil, mustCreateException(TypeErrorType, "issubclass() arg 1 must be a class")},
+	}
+	for _, cas := range cases {
+		testCase := invokeTestCase{args: cas.args, want: cas.want, wantExc: cas.exc}
+		if err := runInvokeTestCase(wrapFuncForTest(cas.fun), &testCase); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestIsTrue(t *testing.T) {
 	badNonZeroType := newTestClass("BadNonZeroType", []*Type{ObjectType}, newStringDict(map[string]*Object{
 		"__nonzero__": newBuiltinFunction("__nonzero__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
@@ -820,7 +961,7 @@ func TestPos(t *testing.T) {
 func TestPyPrint(t *testing.T) {
 	fun := wrapFuncForTest(func(f *Frame, args *Tuple, sep, end string) (string, *BaseException) {
 		return captureStdout(f, func() *BaseException {
-			return pyPrint(NewRootFrame(), args.elems, sep, end, Stdout)
+			return pyPrint(NewRootFrame(), args.elems, sep, end, Stdout.ToObject())
 		})
 	})
 	cases := []invokeTestCase{
@@ -0,0 +1,2522 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// pickleHighestProtocol is the newest pickle protocol supported. It
+	// is exposed as cPickle.HIGHEST_PROTOCOL.
+	pickleHighestProtocol = 2
+	// pickleBatchSize is the maximum number of list or dict items written
+	// between a MARK and an APPENDS or SETITEMS.
+	pickleBatchSize = 1000
+	// pickleMaxDepth bounds the nesting of objects being pickled.
+	pickleMaxDepth = 1000
+
+	pickleOpMark           = '('
+	pickleOpStop           = '.'
+	pickleOpPop            = '0'
+	pickleOpPopMark        = '1'
+	pickleOpDup            = '2'
+	pickleOpFloat          = 'F'
+	pickleOpInt            = 'I'
+	pickleOpBinInt         = 'J'
+	pickleOpBinInt1        = 'K'
+	pickleOpLong           = 'L'
+	pickleOpBinInt2        = 'M'
+	pickleOpNone           = 'N'
+	pickleOpPersID         = 'P'
+	pickleOpBinPersID      = 'Q'
+	pickleOpReduce         = 'R'
+	pickleOpString         = 'S'
+	pickleOpBinString      = 'T'
+	pickleOpShortBinString = 'U'
+	pickleOpUnicode        = 'V'
+	pickleOpBinUnicode     = 'X'
+	pickleOpAppend         = 'a'
+	pickleOpBuild          = 'b'
+	pickleOpGlobal         = 'c'
+	pickleOpDict           = 'd'
+	pickleOpEmptyDict      = '}'
+	pickleOpAppends        = 'e'
+	pickleOpGet            = 'g'
+	pickleOpBinGet         = 'h'
+	pickleOpInst           = 'i'
+	pickleOpLongBinGet     = 'j'
+	pickleOpList           = 'l'
+	pickleOpEmptyList      = ']'
+	pickleOpObj            = 'o'
+	pickleOpPut            = 'p'
+	pickleOpBinPut         = 'q'
+	pickleOpLongBinPut     = 'r'
+	pickleOpSetItem        = 's'
+	pickleOpTuple          = 't'
+	pickleOpEmptyTuple     = ')'
+	pickleOpSetItems       = 'u'
+	pickleOpBinFloat       = 'G'
+	pickleOpProto          = '\x80'
+	pickleOpNewObj         = '\x81'
+	pickleOpExt1           = '\x82'
+	pickleOpExt2           = '\x83'
+	pickleOpExt4           = '\x84'
+	pickleOpTuple1         = '\x85'
+	pickleOpTuple2         = '\x86'
+	pickleOpTuple3         = '\x87'
+	pickleOpNewTrue        = '\x88'
+	pickleOpNewFalse       = '\x89'
+	pickleOpLong1          = '\x8a'
+	pickleOpLong4          = '\x8b'
+)
+
+var (
+	// pickleErrorType corresponds to the Python type
+	// 'cPickle.PickleError'.
+	pickleErrorType = newSimpleType("PickleError", ExceptionType)
+	// picklingErrorType corresponds to the Python type
+	// 'cPickle.PicklingError'.
+	picklingErrorType = newSimpleType("PicklingError", pickleErrorType)
+	// unpicklingErrorType corresponds to the Python type
+	// 'cPickle.UnpicklingError'.
+	unpicklingErrorType = newSimpleType("UnpicklingError", pickleErrorType)
+	// unpickleableErrorType corresponds to the Python type
+	// 'cPickle.UnpickleableError'.
+	unpickleableErrorType = newSimpleType("UnpickleableError", picklingErrorType)
+	// badPickleGetType corresponds to the Python type
+	// 'cPickle.BadPickleGet'.
+	badPickleGetType = newSimpleType("BadPickleGet", unpicklingErrorType)
+	// picklerType corresponds to the Python type 'cPickle.Pickler'.
+	picklerType = newBasisType("Pickler", reflect.TypeOf(pickler{}), toPicklerUnsafe, ObjectType)
+	// unpicklerType corresponds to the Python type 'cPickle.Unpickler'.
+	unpicklerType = newBasisType("Unpickler", reflect.TypeOf(unpickler{}), toUnpicklerUnsafe, ObjectType)
+	// The copy_reg tables consulted when pickling and unpickling. They
+	// are populated when the cPickle module is imported and remain nil
+	// otherwise.
+	pickleDispatchTable    *Dict
+	pickleExtRegistry      *Dict
+	pickleInvertedRegistry *Dict
+	pickleExtCache         *Dict
+)
+
+// pickleMemoRef records a memo operation in the output of a list-based
+// binary pickler. Puts are only emitted by getvalue() when a later get refers
+// to them.
+type pickleMemoRef struct {
+	pos   int
+	index int
+	get   bool
+}
+
+// pickler represents Python 'cPickle.Pickler' objects.
+//
+// CPython's cPickle only memoizes an object when its reference count shows it
+// may be referenced elsewhere. Grumpy has no reference counts so objects are
+// assumed to be shared, except for the temporaries produced while reducing an
+// object (e.g. argument tuples) which are recorded in unshared. For
+// the common types this yields the same output as CPython.
+type pickler struct {
+	Object
+	mutex recursiveMutex
+	// write is the write method of the output file. It is nil for a
+	// list-based pickler whose output is retrieved with getvalue() and
+	// None when the output is discarded.
+	write            *Object
+	proto            int
+	Binary           int `attr:"binary" attr_mode:"rw"`
+	Fast             int `attr:"fast" attr_mode:"rw"`
+	memo             *Dict
+	persistentID     *Object
+	instPersistentID *Object
+	buf              []byte
+	refs             []pickleMemoRef
+	depth            int
+	fastObjects      map[*Object]bool
+	unshared         map[*Object]bool
+}
+
+func toPicklerUnsafe(o *Object) *pickler {
+	return (*pickler)(o.toPointer())
+}
+
+// ToObject upcasts p to an Object.
+func (p *pickler) ToObject() *Object {
+	return &p.Object
+}
+
+func newPickler(write *Object, proto int) *pickler {
+	if proto < 0 {
+		proto = pickleHighestProtocol
+	}
+	p := &pickler{Object: Object{typ: picklerType}, write: write, proto: proto, memo: NewDict()}
+	if proto > 0 {
+		p.Binary = 1
+	}
+	return p
+}
+
+// pickleMemoKey returns the memo key for o, which is id(o).
+func pickleMemoKey(o *Object) *Object {
+	return NewInt(int(uintptr(o.toPointer()))).ToObject()
+}
+
+// pickleFloatString returns the text representation of x used by text mode
+// pickles and marshal versions prior to 2.
+func pickleFloatString(x float64) string {
+	return unsignPositiveInf(strings.ToLower(strconv.FormatFloat(x, 'g', 17, 64)))
+}
+
+func (p *pickler) writeOp(op byte) {
+	p.buf = append(p.buf, op)
+}
+
+func (p *pickler) writeString(s string) {
+	p.buf = append(p.buf, s...)
+}
+
+func (p *pickler) writeUint32(n uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], n)
+	p.buf = append(p.buf, b[:]...)
+}
+
+// writeMemoOp emits a PUT or GET of the given memo index.
+func (p *pickler) writeMemoOp(index int, get bool) {
+	if p.Binary == 0 {
+		op := byte(pickleOpPut)
+		if get {
+			op = pickleOpGet
+		}
+		p.writeOp(op)
+		p.writeString(fmt.Sprintf("%d\n", index))
+		return
+	}
+	if p.write == nil {
+		p.refs = append(p.refs, pickleMemoRef{len(p.buf), index, get})
+		return
+	}
+	p.buf = pickleAppendMemoOp(p.buf, index, get)
+}
+
+func pickleAppendMemoOp(buf []byte, index int, get bool) []byte {
+	if index < 256 {
+		op := byte(pickleOpBinPut)
+		if get {
+			op = pickleOpBinGet
+		}
+		return append(buf, op, byte(index))
+	}
+	op := byte(pickleOpLongBinPut)
+	if get {
+		op = pickleOpLongBinGet
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(index))
+	return append(append(buf, op), b[:]...)
+}
+
+// put memoizes o unless it is an unshared temporary.
+func (p *pickler) put(f *Frame, o *Object) *BaseException {
+	if p.unshared[o] {
+		return nil
+	}
+	return p.put2(f, o)
+}
+
+// put2 memoizes o and emits a PUT for it.
+func (p *pickler) put2(f *Frame, o *Object) *BaseException {
+	if p.Fast != 0 {
+		return nil
+	}
+	index := p.memo.Len() + 1
+	entry := NewTuple2(NewInt(index).ToObject(), o).ToObject()
+	if raised := p.memo.SetItem(f, pickleMemoKey(o), entry); raised != nil {
+		return raised
+	}
+	p.writeMemoOp(index, false)
+	return nil
+}
+
+// get emits a GET for the memo entry entry, an (index, obj) tuple.
+func (p *pickler) get(f *Frame, entry *Object) *BaseException {
+	var index *Object
+	if entry.isInstance(TupleType) && len(toTupleUnsafe(entry).elems) > 0 {
+		index = toTupleUnsafe(entry).elems[0]
+	}
+	if index == nil || !index.isInstance(IntType) {
+		return f.RaiseType(picklingErrorType, "no int where int expected in memo")
+	}
+	p.writeMemoOp(toIntUnsafe(index).Value(), true)
+	return nil
+}
+
+// lookupMemo returns the memo entry for o or nil if o has not been pickled.
+func (p *pickler) lookupMemo(f *Frame, o *Object) (*Object, *BaseException) {
+	return p.memo.GetItem(f, pickleMemoKey(o))
+}
+
+// saveUnshared pickles o, a temporary that is not referenced elsewhere and
+// so is not memoized.
+func (p *pickler) saveUnshared(f *Frame, o *Object) *BaseException {
+	if p.unshared == nil {
+		p.unshared = map[*Object]bool{}
+	}
+	if p.unshared[o] {
+		return p.save(f, o, false)
+	}
+	p.unshared[o] = true
+	raised := p.save(f, o, false)
+	delete(p.unshared, o)
+	return raised
+}
+
+func (p *pickler) save(f *Frame, o *Object, pers bool) *BaseException {
+	if p.depth >= pickleMaxDepth {
+		return f.RaiseType(RuntimeErrorType, "maximum recursion depth exceeded while pickling an object")
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	if !pers && p.persistentID != nil {
+		if saved, raised := p.savePers(f, o, p.persistentID); raised != nil || saved {
+			return raised
+		}
+	}
+	switch {
+	case o == None:
+		p.writeOp(pickleOpNone)
+		return nil
+	case o.typ == BoolType:
+		p.saveBool(o == True.ToObject())
+		return nil
+	case o.typ == IntType:
+		p.saveInt(toIntUnsafe(o).Value())
+		return nil
+	case o.typ == LongType:
+		p.saveLong(toLongUnsafe(o).Value())
+		return nil
+	case o.typ == FloatType:
+		p.saveFloat(toFloatUnsafe(o).Value())
+		return nil
+	case o.typ == TupleType && len(toTupleUnsafe(o).elems) == 0:
+		if p.proto > 0 {
+			p.writeOp(pickleOpEmptyTuple)
+		} else {
+			p.writeString("(t")
+		}
+		return nil
+	case o.typ == StrType && len(toStrUnsafe(o).Value()) < 2:
+		return p.saveStr(f, o)
+	case o.typ == UnicodeType && len(toUnicodeUnsafe(o).Value()) < 2:
+		return p.saveUnicode(f, o)
+	}
+	if !p.unshared[o] {
+		entry, raised := p.lookupMemo(f, o)
+		if raised != nil {
+			return raised
+		}
+		if entry != nil {
+			return p.get(f, entry)
+		}
+	}
+	switch o.typ {
+	case StrType:
+		return p.saveStr(f, o)
+	case UnicodeType:
+		return p.saveUnicode(f, o)
+	case TupleType:
+		return p.saveTuple(f, o)
+	case TypeType:
+		return p.saveGlobal(f, o, nil)
+	case ListType:
+		return p.saveList(f, o)
+	case DictType:
+		return p.saveDict(f, o)
+	case FunctionType:
+		raised := p.saveGlobal(f, o, nil)
+		if raised == nil || !raised.isInstance(pickleErrorType) {
+			return raised
+		}
+		// Fall back to the reduce protocol.
+		f.RestoreExc(nil, nil)
+	}
+	if !pers && p.instPersistentID != nil {
+		if saved, raised := p.savePers(f, o, p.instPersistentID); raised != nil || saved {
+			return raised
+		}
+	}
+	var reduce, result *Object
+	var raised *BaseException
+	if pickleDispatchTable != nil {
+		if reduce, raised = pickleDispatchTable.GetItem(f, o.typ.ToObject()); raised != nil {
+			return raised
+		}
+	}
+	if reduce != nil {
+		result, raised = reduce.Call(f, Args{o}, nil)
+	} else if o.isInstance(TypeType) {
+		return p.saveGlobal(f, o, nil)
+	} else if reduce, raised = GetAttr(f, o, NewStr("__reduce_ex__"), None); raised != nil {
+		return raised
+	} else if reduce != None {
+		result, raised = reduce.Call(f, Args{NewInt(p.proto).ToObject()}, nil)
+	} else if reduce, raised = GetAttr(f, o, NewStr("__reduce__"), None); raised != nil {
+		return raised
+	} else if reduce != None {
+		result, raised = reduce.Call(f, nil, nil)
+	} else {
+		return f.Raise(unpickleableErrorType.ToObject(), o, nil)
+	}
+	if raised != nil {
+		return raised
+	}
+	if result.isInstance(StrType) {
+		return p.saveGlobal(f, o, result)
+	}
+	if !result.isInstance(TupleType) {
+		return p.raiseWithObject(f, picklingErrorType, "Value returned by %s must be string or tuple", reduce)
+	}
+	return p.saveReduce(f, toTupleUnsafe(result), reduce, o)
+}
+
+// raiseWithObject raises an exception of type t with a message formatted
+// from format and the str() of o.
+func (p *pickler) raiseWithObject(f *Frame, t *Type, format string, o *Object) *BaseException {
+	s, raised := ToStr(f, o)
+	if raised != nil {
+		return raised
+	}
+	return f.RaiseType(t, fmt.Sprintf(format, s.Value()))
+}
+
+// savePers calls the persistent id function fn and pickles the result as a
+// persistent reference if it's not None. It returns true if o was saved.
+func (p *pickler) savePers(f *Frame, o, fn *Object) (bool, *BaseException) {
+	pid, raised := fn.Call(f, Args{o}, nil)
+	if raised != nil {
+		return false, raised
+	}
+	if pid == None {
+		return false, nil
+	}
+	if p.Binary == 0 {
+		if !pid.isInstance(StrType) {
+			return false, f.RaiseType(picklingErrorType, "persistent id must be string")
+		}
+		p.writeOp(pickleOpPersID)
+		p.writeString(toStrUnsafe(pid).Value())
+		p.writeOp('\n')
+		return true, nil
+	}
+	if raised := p.save(f, pid, true); raised != nil {
+		return false, raised
+	}
+	p.writeOp(pickleOpBinPersID)
+	return true, nil
+}
+
+func (p *pickler) saveBool(b bool) {
+	switch {
+	case p.proto >= 2 && b:
+		p.writeOp(pickleOpNewTrue)
+	case p.proto >= 2:
+		p.writeOp(pickleOpNewFalse)
+	case b:
+		p.writeString("I01\n")
+	default:
+		p.writeString("I00\n")
+	}
+}
+
+func (p *pickler) saveInt(i int) {
+	if p.Binary == 0 || int(int32(i)) != i {
+		// Text mode or the value doesn't fit in a BININT.
+		p.writeString(fmt.Sprintf("I%d\n", i))
+		return
+	}
+	switch {
+	case i >= 0 && i <= 0xff:
+		p.buf = append(p.buf, pickleOpBinInt1, byte(i))
+	case i >= 0 && i <= 0xffff:
+		p.buf = append(p.buf, pickleOpBinInt2, byte(i), byte(i>>8))
+	default:
+		p.writeOp(pickleOpBinInt)
+		p.writeUint32(uint32(i))
+	}
+}
+
+func (p *pickler) saveLong(x *big.Int) {
+	if p.proto < 2 {
+		p.writeString(fmt.Sprintf("L%sL\n", x.String()))
+		return
+	}
+	if x.Sign() == 0 {
+		p.buf = append(p.buf, pickleOpLong1, 0)
+		return
+	}
+	n := x.BitLen()/8 + 1
+	// Build the little endian two's complement representation.
+	v := new(big.Int).Set(x)
+	if x.Sign() < 0 {
+		v.Add(v, new(big.Int).Lsh(big.NewInt(1), uint(n*8)))
+	}
+	b := make([]byte, n)
+	be := v.Bytes()
+	for i, c := range be {
+		b[len(be)-1-i] = c
+	}
+	// A negative number may have a redundant sign byte.
+	if x.Sign() < 0 && n > 1 && b[n-1] == 0xff && b[n-2]&0x80 != 0 {
+		n--
+		b = b[:n]
+	}
+	if n < 256 {
+		p.buf = append(p.buf, pickleOpLong1, byte(n))
+	} else {
+		p.writeOp(pickleOpLong4)
+		p.writeUint32(uint32(n))
+	}
+	p.buf = append(p.buf, b...)
+}
+
+func (p *pickler) saveFloat(x float64) {
+	if p.Binary == 0 {
+		p.writeOp(pickleOpFloat)
+		p.writeString(pickleFloatString(x))
+		p.writeOp('\n')
+		return
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(x))
+	p.writeOp(pickleOpBinFloat)
+	p.buf = append(p.buf, b[:]...)
+}
+
+func (p *pickler) saveStr(f *Frame, o *Object) *BaseException {
+	s := toStrUnsafe(o).Value()
+	if p.Binary == 0 {
+		p.writeOp(pickleOpString)
+		p.writeString(pickleStrRepr(s))
+		p.writeOp('\n')
+	} else if len(s) < 256 {
+		p.buf = append(p.buf, pickleOpShortBinString, byte(len(s)))
+		p.writeString(s)
+	} else {
+		p.writeOp(pickleOpBinString)
+		p.writeUint32(uint32(len(s)))
+		p.writeString(s)
+	}
+	if len(s) > 1 {
+		return p.put(f, o)
+	}
+	return nil
+}
+
+// pickleStrRepr returns the repr of s as produced by CPython, which
+// prefers double quotes when s contains single quotes but no double quotes.
+func pickleStrRepr(s string) string {
+	quote := byte('\'')
+	if strings.IndexByte(s, '\'') != -1 && strings.IndexByte(s, '"') == -1 {
+		quote = '"'
+	}
+	buf := bytes.Buffer{}
+	buf.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if escape, ok := escapeMap[rune(c)]; ok && c != '\'' {
+			buf.WriteString(escape)
+		} else if c == quote {
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		} else if c < ' ' || c >= 0x7f {
+			fmt.Fprintf(&buf, `\x%02x`, c)
+		} else {
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte(quote)
+	return buf.String()
+}
+
+func (p *pickler) saveUnicode(f *Frame, o *Object) *BaseException {
+	runes := toUnicodeUnsafe(o).Value()
+	if p.Binary == 0 {
+		p.writeOp(pickleOpUnicode)
+		p.writeString(pickleEncodeRawUnicodeEscape(runes))
+		p.writeOp('\n')
+	} else {
+		s, raised := toUnicodeUnsafe(o).Encode(f, "utf8", EncodeStrict)
+		if raised != nil {
+			return raised
+		}
+		p.writeOp(pickleOpBinUnicode)
+		p.writeUint32(uint32(len(s.Value())))
+		p.writeString(s.Value())
+	}
+	if len(runes) > 1 {
+		return p.put(f, o)
+	}
+	return nil
+}
+
+// pickleEncodeRawUnicodeEscape encodes runes like the raw-unicode-escape
+// codec except that backslashes and newlines are also escaped so the result
+// can be read back by readline().
+func pickleEncodeRawUnicodeEscape(runes []rune) string {
+	var buf bytes.Buffer
+	for _, r := range runes {
+		switch {
+		case r >= 0x10000:
+			fmt.Fprintf(&buf, "\\U%08x", r)
+		case r >= 0x100 || r == '\\' || r == '\n':
+			fmt.Fprintf(&buf, "\\u%04x", r)
+		default:
+			buf.WriteByte(byte(r))
+		}
+	}
+	return buf.String()
+}
+
+func (p *pickler) saveTuple(f *Frame, o *Object) *BaseException {
+	elems := toTupleUnsafe(o).elems
+	n := len(elems)
+	useCounted := n <= 3 && p.proto >= 2
+	if !useCounted {
+		p.writeOp(pickleOpMark)
+	}
+	for _, elem := range elems {
+		if raised := p.save(f, elem, false); raised != nil {
+			return raised
+		}
+	}
+	// If the tuple is in the memo now then it's recursive so discard the
+	// elements pushed and fetch it from the memo.
+	entry, raised := p.lookupMemo(f, o)
+	if raised != nil {
+		return raised
+	}
+	if entry != nil {
+		switch {
+		case useCounted:
+			p.writeString(strings.Repeat(string(pickleOpPop), n))
+		case p.Binary != 0:
+			p.writeOp(pickleOpPopMark)
+		default:
+			p.writeString(strings.Repeat(string(pickleOpPop), n+1))
+		}
+		return p.get(f, entry)
+	}
+	if useCounted {
+		p.writeOp(pickleOpTuple1 + byte(n-1))
+	} else {
+		p.writeOp(pickleOpTuple)
+	}
+	return p.put(f, o)
+}
+
+// fastEnter guards against cycles in fast mode, where objects aren't
+// memoized.
+func (p *pickler) fastEnter(f *Frame, o *Object) *BaseException {
+	if p.Fast == 0 {
+		return nil
+	}
+	if p.fastObjects == nil {
+		p.fastObjects = map[*Object]bool{}
+	}
+	if p.fastObjects[o] {
+		format := "fast mode: can't pickle cyclic objects including object type %s at %p"
+		return f.RaiseType(ValueErrorType, fmt.Sprintf(format, o.typ.Name(), o))
+	}
+	p.fastObjects[o] = true
+	return nil
+}
+
+func (p *pickler) fastLeave(o *Object) {
+	if p.Fast != 0 {
+		delete(p.fastObjects, o)
+	}
+}
+
+func (p *pickler) saveList(f *Frame, o *Object) *BaseException {
+	if raised := p.fastEnter(f, o); raised != nil {
+		return raised
+	}
+	defer p.fastLeave(o)
+	if p.Binary != 0 {
+		p.writeOp(pickleOpEmptyList)
+	} else {
+		p.writeString("(l")
+	}
+	l := toListUnsafe(o)
+	l.mutex.RLock()
+	numElems := len(l.elems)
+	l.mutex.RUnlock()
+	if numElems == 0 {
+		return p.put(f, o)
+	}
+	if raised := p.put2(f, o); raised != nil {
+		return raised
+	}
+	iter, raised := Iter(f, o)
+	if raised != nil {
+		return raised
+	}
+	return p.batchList(f, iter)
+}
+
+func (p *pickler) saveDict(f *Frame, o *Object) *BaseException {
+	if raised := p.fastEnter(f, o); raised != nil {
+		return raised
+	}
+	defer p.fastLeave(o)
+	if p.Binary != 0 {
+		p.writeOp(pickleOpEmptyDict)
+	} else {
+		p.writeString("(d")
+	}
+	if toDictUnsafe(o).Len() == 0 {
+		return p.put(f, o)
+	}
+	if raised := p.put2(f, o); raised != nil {
+		return raised
+	}
+	iterItems, raised := GetAttr(f, o, NewStr("iteritems"), nil)
+	if raised != nil {
+		return raised
+	}
+	iter, raised := iterItems.Call(f, nil, nil)
+	if raised != nil {
+		return raised
+	}
+	return p.batchDict(f, iter)
+}
+
+// pickleNextBatch returns up to pickleBatchSize items from iter.
+func pickleNextBatch(f *Frame, iter *Object) ([]*Object, *BaseException) {
+	var items []*Object
+	for len(items) < pickleBatchSize {
+		item, raised := Next(f, iter)
+		if raised != nil {
+			if !raised.isInstance(StopIterationType) {
+				return nil, raised
+			}
+			f.RestoreExc(nil, nil)
+			break
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// batchList pickles the items produced by iter, appending them to the list
+// on top of the stack.
+func (p *pickler) batchList(f *Frame, iter *Object) *BaseException {
+	if p.proto == 0 {
+		// APPENDS isn't available so append one at a time.
+		return seqForEach(f, iter, func(item *Object) *BaseException {
+			if raised := p.save(f, item, false); raised != nil {
+				return raised
+			}
+			p.writeOp(pickleOpAppend)
+			return nil
+		})
+	}
+	for {
+		items, raised := pickleNextBatch(f, iter)
+		if raised != nil {
+			return raised
+		}
+		if len(items) > 1 {
+			p.writeOp(pickleOpMark)
+		}
+		for _, item := range items {
+			if raised := p.save(f, item, false); raised != nil {
+				return raised
+			}
+		}
+		if len(items) > 1 {
+			p.writeOp(pickleOpAppends)
+		} else if len(items) == 1 {
+			p.writeOp(pickleOpAppend)
+		}
+		if len(items) < pickleBatchSize {
+			return nil
+		}
+	}
+}
+
+// batchDict pickles the (key, value) pairs produced by iter, setting them in
+// the dict on top of the stack.
+func (p *pickler) batchDict(f *Frame, iter *Object) *BaseException {
+	saveItem := func(item *Object) *BaseException {
+		if !item.isInstance(TupleType) || len(toTupleUnsafe(item).elems) != 2 {
+			return f.RaiseType(TypeErrorType, "dict items iterator must return 2-tuples")
+		}
+		elems := toTupleUnsafe(item).elems
+		if raised := p.save(f, elems[0], false); raised != nil {
+			return raised
+		}
+		return p.save(f, elems[1], false)
+	}
+	if p.proto == 0 {
+		// SETITEMS isn't available so set one at a time.
+		return seqForEach(f, iter, func(item *Object) *BaseException {
+			if raised := saveItem(item); raised != nil {
+				return raised
+			}
+			p.writeOp(pickleOpSetItem)
+			return nil
+		})
+	}
+	for {
+		items, raised := pickleNextBatch(f, iter)
+		if raised != nil {
+			return raised
+		}
+		if len(items) > 1 {
+			p.writeOp(pickleOpMark)
+		}
+		for _, item := range items {
+			if raised := saveItem(item); raised != nil {
+				return raised
+			}
+		}
+		if len(items) > 1 {
+			p.writeOp(pickleOpSetItems)
+		} else if len(items) == 1 {
+			p.writeOp(pickleOpSetItem)
+		}
+		if len(items) < pickleBatchSize {
+			return nil
+		}
+	}
+}
+
+// pickleWhichModule returns the name of the module that o, named name, can
+// be imported from.
+func pickleWhichModule(f *Frame, o *Object, name string) (string, *BaseException) {
+	if o.isInstance(FunctionType) {
+		// Functions don't have their own __module__ attribute in Grumpy
+		// so use the name of the module they were defined in.
+		if globals := toFunctionUnsafe(o).globals; globals != nil {
+			if modName, raised := globals.GetItemString(f, "__name__"); raised != nil {
+				return "", raised
+			} else if modName != nil && modName.isInstance(StrType) {
+				return toStrUnsafe(modName).Value(), nil
+			}
+		} else if builtin, raised := Builtins.GetItemString(f, name); raised != nil {
+			return "", raised
+		} else if builtin == o {
+			return "__builtin__", nil
+		}
+	} else if module, raised := GetAttr(f, o, NewStr("__module__"), nil); raised == nil {
+		s, raised := ToStr(f, module)
+		if raised != nil {
+			return "", raised
+		}
+		return s.Value(), nil
+	} else if !raised.isInstance(AttributeErrorType) {
+		return "", raised
+	} else {
+		f.RestoreExc(nil, nil)
+	}
+	SysModules.mutex.Lock(f)
+	iter := newDictEntryIterator(SysModules)
+	SysModules.mutex.Unlock(f)
+	for entry := iter.next(); entry != nil; entry = iter.next() {
+		if !entry.key.isInstance(StrType) || toStrUnsafe(entry.key).Value() == "__main__" {
+			continue
+		}
+		attr, raised := GetAttr(f, entry.value, NewStr(name), nil)
+		if raised != nil {
+			if !raised.isInstance(AttributeErrorType) {
+				return "", raised
+			}
+			f.RestoreExc(nil, nil)
+			continue
+		}
+		if attr == o {
+			return toStrUnsafe(entry.key).Value(), nil
+		}
+	}
+	return "__main__", nil
+}
+
+// saveGlobal pickles o as a reference to a module global. If name is nil
+// then o's __name__ is used.
+func (p *pickler) saveGlobal(f *Frame, o, name *Object) *BaseException {
+	var module string
+	switch {
+	case name != nil:
+	case o == objectReconstructorFunc || o == objectNewObjFunc:
+		// These correspond to functions in CPython's copy_reg module.
+		name, module = NewStr(toFunctionUnsafe(o).name).ToObject(), "copy_reg"
+	default:
+		var raised *BaseException
+		if name, raised = GetAttr(f, o, NewStr("__name__"), nil); raised != nil {
+			return raised
+		}
+	}
+	nameStr, raised := ToStr(f, name)
+	if raised != nil {
+		return raised
+	}
+	if module == "" {
+		if module, raised = pickleWhichModule(f, o, nameStr.Value()); raised != nil {
+			return raised
+		}
+		modules, raised := ImportModule(f, module)
+		if raised != nil {
+			f.RestoreExc(nil, nil)
+			return p.raiseWithObject(f, picklingErrorType, "Can't pickle %s: import of module "+module+" failed", o)
+		}
+		attr, raised := GetAttr(f, modules[len(modules)-1], nameStr, nil)
+		if raised != nil {
+			f.RestoreExc(nil, nil)
+			format := "Can't pickle %s: attribute lookup " + module + "." + nameStr.Value() + " failed"
+			return p.raiseWithObject(f, picklingErrorType, format, o)
+		}
+		if attr != o {
+			format := "Can't pickle %s: it's not the same object as " + module + "." + nameStr.Value()
+			return p.raiseWithObject(f, picklingErrorType, format, o)
+		}
+	}
+	if p.proto >= 2 && pickleExtRegistry != nil {
+		key := NewTuple2(NewStr(module).ToObject(), nameStr.ToObject()).ToObject()
+		code, raised := pickleExtRegistry.GetItem(f, key)
+		if raised != nil {
+			return raised
+		}
+		if code != nil {
+			return p.saveExt(f, o, code)
+		}
+	}
+	p.writeOp(pickleOpGlobal)
+	p.writeString(module + "\n" + nameStr.Value() + "\n")
+	return p.put(f, o)
+}
+
+// saveExt emits an EXT opcode referring to o's extension code.
+func (p *pickler) saveExt(f *Frame, o, code *Object) *BaseException {
+	if !code.isInstance(IntType) {
+		codeStr, raised := ToStr(f, code)
+		if raised != nil {
+			return raised
+		}
+		format := "Can't pickle %s: extension code " + codeStr.Value() + " isn't an integer"
+		return p.raiseWithObject(f, picklingErrorType, format, o)
+	}
+	c := toIntUnsafe(code).Value()
+	switch {
+	case c <= 0 || c > 0x7fffffff:
+		format := "Can't pickle %s: extension code " + strconv.Itoa(c) + " is out of range"
+		return p.raiseWithObject(f, picklingErrorType, format, o)
+	case c <= 0xff:
+		p.buf = append(p.buf, pickleOpExt1, byte(c))
+	case c <= 0xffff:
+		p.buf = append(p.buf, pickleOpExt2, byte(c), byte(c>>8))
+	default:
+		p.writeOp(pickleOpExt4)
+		p.writeUint32(uint32(c))
+	}
+	return nil
+}
+
+// saveReduce pickles ob using the tuple t returned by its reduce method fn.
+func (p *pickler) saveReduce(f *Frame, t *Tuple, fn, ob *Object) *BaseException {
+	n := len(t.elems)
+	if n < 2 || n > 5 {
+		return p.raiseWithObject(f, picklingErrorType, "tuple returned by %s must contain 2 through 5 elements", fn)
+	}
+	callable, argTuple := t.elems[0], t.elems[1]
+	state, listItems, dictItems := None, None, None
+	if n > 2 {
+		state = t.elems[2]
+	}
+	if n > 3 {
+		listItems = t.elems[3]
+	}
+	if n > 4 {
+		dictItems = t.elems[4]
+	}
+	if !argTuple.isInstance(TupleType) {
+		return p.raiseWithObject(f, picklingErrorType, "Second element of tuple returned by %s must be a tuple", fn)
+	}
+	if listItems != None && listItems.typ.slots.Next == nil {
+		format := "Fourth element of tuple returned by %s must be an iterator, not " + listItems.typ.Name()
+		return p.raiseWithObject(f, picklingErrorType, format, fn)
+	}
+	if dictItems != None && dictItems.typ.slots.Next == nil {
+		format := "Fifth element of tuple returned by %s must be an iterator, not " + dictItems.typ.Name()
+		return p.raiseWithObject(f, picklingErrorType, format, fn)
+	}
+	useNewObj := false
+	if p.proto >= 2 {
+		name, raised := GetAttr(f, callable, NewStr("__name__"), None)
+		if raised != nil {
+			return raised
+		}
+		useNewObj = name.isInstance(StrType) && toStrUnsafe(name).Value() == "__newobj__"
+	}
+	args := toTupleUnsafe(argTuple).elems
+	if useNewObj {
+		if len(args) == 0 {
+			return f.RaiseType(picklingErrorType, "__newobj__ arglist is empty")
+		}
+		cls := args[0]
+		if newMethod, raised := GetAttr(f, cls, NewStr("__new__"), None); raised != nil {
+			return raised
+		} else if newMethod == None {
+			return f.RaiseType(picklingErrorType, "args[0] from __newobj__ args has no __new__")
+		}
+		if ob.Type().ToObject() != cls {
+			return f.RaiseType(picklingErrorType, "args[0] from __newobj__ args has the wrong class")
+		}
+		if raised := p.save(f, cls, false); raised != nil {
+			return raised
+		}
+		if raised := p.saveUnshared(f, NewTuple(args[1:]...).ToObject()); raised != nil {
+			return raised
+		}
+		p.writeOp(pickleOpNewObj)
+	} else {
+		if raised := p.save(f, callable, false); raised != nil {
+			return raised
+		}
+		if callable == objectReconstructorFunc && len(args) == 3 {
+			// The state passed to copy_reg._reconstructor is a new
+			// instance of the base type.
+			p.unshared[args[2]] = true
+			defer delete(p.unshared, args[2])
+		}
+		if callable == ob.typ.ToObject() && len(args) == 1 && (ob.isInstance(SetType) || ob.isInstance(FrozenSetType)) {
+			// The element list passed to set and frozenset is
+			// built by the reduce method so it's not shared.
+			p.unshared[args[0]] = true
+			defer delete(p.unshared, args[0])
+		}
+		if raised := p.saveUnshared(f, argTuple); raised != nil {
+			return raised
+		}
+		p.writeOp(pickleOpReduce)
+	}
+	// If ob is already in the memo then it's recursive so discard the
+	// result and fetch it from the memo.
+	if p.Fast == 0 {
+		entry, raised := p.lookupMemo(f, ob)
+		if raised != nil {
+			return raised
+		}
+		if entry != nil {
+			p.writeOp(pickleOpPop)
+			return p.get(f, entry)
+		}
+	}
+	if state != None && !state.isInstance(DictType) {
+		if raised := p.put2(f, ob); raised != nil {
+			return raised
+		}
+	} else if raised := p.put(f, ob); raised != nil {
+		return raised
+	}
+	if listItems != None {
+		if raised := p.batchList(f, listItems); raised != nil {
+			return raised
+		}
+	}
+	if dictItems != None {
+		if raised := p.batchDict(f, dictItems); raised != nil {
+			return raised
+		}
+	}
+	if state != None {
+		var raised *BaseException
+		if d := ob.Dict(); d != nil && d.ToObject() == state {
+			raised = p.save(f, state, false)
+		} else {
+			raised = p.saveUnshared(f, state)
+		}
+		if raised != nil {
+			return raised
+		}
+		p.writeOp(pickleOpBuild)
+	}
+	return nil
+}
+
+// pickle appends the pickle of o, terminated by STOP, to p's output.
+func (p *pickler) pickle(f *Frame, o *Object) *BaseException {
+	if p.unshared == nil {
+		p.unshared = map[*Object]bool{}
+	}
+	if p.proto >= 2 {
+		p.buf = append(p.buf, pickleOpProto, byte(p.proto))
+	}
+	if raised := p.save(f, o, false); raised != nil {
+		return raised
+	}
+	p.writeOp(pickleOpStop)
+	return nil
+}
+
+// dump pickles o and, unless p is list-based, writes the output to the file.
+func (p *pickler) dump(f *Frame, o *Object) *BaseException {
+	p.mutex.Lock(f)
+	defer p.mutex.Unlock(f)
+	raised := p.pickle(f, o)
+	if p.write == nil {
+		return raised
+	}
+	buf := p.buf
+	p.buf = nil
+	if raised != nil || p.write == None {
+		return raised
+	}
+	_, raised = p.write.Call(f, Args{NewStr(string(buf)).ToObject()}, nil)
+	return raised
+}
+
+// getValue returns the output of a list-based pickler, only emitting puts
+// that are referenced by gets.
+func (p *pickler) getValue(f *Frame, clear bool) (*Object, *BaseException) {
+	p.mutex.Lock(f)
+	defer p.mutex.Unlock(f)
+	if p.write != nil {
+		return nil, f.RaiseType(picklingErrorType, "Attempt to getvalue() a non-list-based pickler")
+	}
+	haveGet := map[int]bool{}
+	keep := make([]bool, len(p.refs))
+	for i := len(p.refs) - 1; i >= 0; i-- {
+		ref := p.refs[i]
+		if ref.get {
+			haveGet[ref.index] = true
+		}
+		keep[i] = ref.get || haveGet[ref.index]
+	}
+	var buf []byte
+	pos := 0
+	for i, ref := range p.refs {
+		buf = append(buf, p.buf[pos:ref.pos]...)
+		pos = ref.pos
+		if keep[i] {
+			buf = pickleAppendMemoOp(buf, ref.index, ref.get)
+		}
+	}
+	buf = append(buf, p.buf[pos:]...)
+	if clear {
+		dictClear(f, Args{p.memo.ToObject()}, nil)
+		p.buf = nil
+		p.refs = nil
+	}
+	return NewStr(string(buf)).ToObject(), nil
+}
+
+func picklerClearMemo(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "clear_memo", args, picklerType); raised != nil {
+		return nil, raised
+	}
+	return dictClear(f, Args{toPicklerUnsafe(args[0]).memo.ToObject()}, nil)
+}
+
+func picklerDump(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{picklerType, ObjectType, ObjectType}
+	if len(args) == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "dump", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	p := toPicklerUnsafe(args[0])
+	if raised := p.dump(f, args[1]); raised != nil {
+		return nil, raised
+	}
+	if len(args) > 2 {
+		get, raised := IsTrue(f, args[2])
+		if raised != nil {
+			return nil, raised
+		}
+		if get {
+			return p.getValue(f, true)
+		}
+	}
+	return args[0], nil
+}
+
+func picklerGetValue(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{picklerType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "getvalue", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	clear := true
+	if len(args) > 1 {
+		var raised *BaseException
+		if clear, raised = IsTrue(f, args[1]); raised != nil {
+			return nil, raised
+		}
+	}
+	return toPicklerUnsafe(args[0]).getValue(f, clear)
+}
+
+// newPickleAttr returns a read/write property whose value is stored in the
+// field returned by field. Like CPython, reading an unset attribute raises
+// AttributeError and deletion isn't supported.
+func newPickleAttr(typ *Type, name string, field func(*Object) **Object, validate func(*Frame, *Object) *BaseException) *Object {
+	get := newBuiltinFunction("_get_"+name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_get_"+name, args, typ); raised != nil {
+			return nil, raised
+		}
+		value := *field(args[0])
+		if value == nil {
+			return nil, f.RaiseType(AttributeErrorType, name)
+		}
+		return value, nil
+	}).ToObject()
+	set := newBuiltinFunction("_set_"+name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_set_"+name, args, typ, ObjectType); raised != nil {
+			return nil, raised
+		}
+		if validate != nil {
+			if raised := validate(f, args[1]); raised != nil {
+				return nil, raised
+			}
+		}
+		*field(args[0]) = args[1]
+		return None, nil
+	}).ToObject()
+	del := newBuiltinFunction("_del_"+name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return nil, f.RaiseType(TypeErrorType, "attribute deletion is not supported")
+	}).ToObject()
+	return newProperty(get, set, del).ToObject()
+}
+
+func pickleValidateMemo(f *Frame, o *Object) *BaseException {
+	if !o.isInstance(DictType) {
+		return f.RaiseType(TypeErrorType, "memo must be a dictionary")
+	}
+	return nil
+}
+
+func initPicklerType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("cPickle").ToObject()
+	dict["PicklingError"] = picklingErrorType.ToObject()
+	dict["clear_memo"] = newBuiltinFunction("clear_memo", picklerClearMemo).ToObject()
+	dict["dump"] = newBuiltinFunction("dump", picklerDump).ToObject()
+	dict["getvalue"] = newBuiltinFunction("getvalue", picklerGetValue).ToObject()
+	dict["inst_persistent_id"] = newPickleAttr(picklerType, "inst_persistent_id", func(o *Object) **Object {
+		return &toPicklerUnsafe(o).instPersistentID
+	}, nil)
+	dict["memo"] = newPickleMemoAttr(picklerType, func(o *Object) **Dict {
+		return &toPicklerUnsafe(o).memo
+	})
+	dict["persistent_id"] = newPickleAttr(picklerType, "persistent_id", func(o *Object) **Object {
+		return &toPicklerUnsafe(o).persistentID
+	}, nil)
+	picklerType.flags &^= typeFlagBasetype | typeFlagInstantiable
+}
+
+// newPickleMemoAttr returns the memo property of a Pickler or Unpickler.
+func newPickleMemoAttr(typ *Type, field func(*Object) **Dict) *Object {
+	get := newBuiltinFunction("_get_memo", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_get_memo", args, typ); raised != nil {
+			return nil, raised
+		}
+		return (*field(args[0])).ToObject(), nil
+	}).ToObject()
+	set := newBuiltinFunction("_set_memo", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_set_memo", args, typ, ObjectType); raised != nil {
+			return nil, raised
+		}
+		if raised := pickleValidateMemo(f, args[1]); raised != nil {
+			return nil, raised
+		}
+		*field(args[0]) = toDictUnsafe(args[1])
+		return None, nil
+	}).ToObject()
+	del := newBuiltinFunction("_del_memo", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return nil, f.RaiseType(TypeErrorType, "attribute deletion is not supported")
+	}).ToObject()
+	return newProperty(get, set, del).ToObject()
+}
+
+// unpickler represents Python 'cPickle.Unpickler' objects.
+type unpickler struct {
+	Object
+	mutex recursiveMutex
+	// read and readline are the methods of the input file. They are nil
+	// when unpickling from data.
+	read     *Object
+	readline *Object
+	data     []byte
+	pos      int
+	stack    []*Object
+	marks    []int
+	memo     *Dict
+	// persistentLoad is either a callable or a list that persistent ids
+	// are appended to.
+	persistentLoad *Object
+	// findGlobal is the callable used to resolve globals, None to forbid
+	// globals or nil to import them.
+	findGlobal *Object
+}
+
+func toUnpicklerUnsafe(o *Object) *unpickler {
+	return (*unpickler)(o.toPointer())
+}
+
+// ToObject upcasts u to an Object.
+func (u *unpickler) ToObject() *Object {
+	return &u.Object
+}
+
+func newUnpickler(read, readline *Object, data []byte) *unpickler {
+	return &unpickler{Object: Object{typ: unpicklerType}, read: read, readline: readline, data: data, memo: NewDict()}
+}
+
+// readBytes returns the next n bytes of input, raising EOFError if fewer
+// are available.
+func (u *unpickler) readBytes(f *Frame, n int) (string, *BaseException) {
+	if u.read == nil {
+		if n > len(u.data)-u.pos {
+			u.pos = len(u.data)
+			return "", f.Raise(EOFErrorType.ToObject(), nil, nil)
+		}
+		s := string(u.data[u.pos : u.pos+n])
+		u.pos += n
+		return s, nil
+	}
+	o, raised := u.read.Call(f, Args{NewInt(n).ToObject()}, nil)
+	if raised != nil {
+		return "", raised
+	}
+	if !o.isInstance(StrType) {
+		return "", f.RaiseType(TypeErrorType, fmt.Sprintf("expected string or Unicode object, %s found", o.typ.Name()))
+	}
+	s := toStrUnsafe(o).Value()
+	if len(s) != n {
+		return "", f.Raise(EOFErrorType.ToObject(), nil, nil)
+	}
+	return s, nil
+}
+
+// readLine returns the next line of input including the newline, or the
+// remaining input if there is no newline.
+func (u *unpickler) readLine(f *Frame) (string, *BaseException) {
+	if u.readline == nil {
+		end := len(u.data)
+		if i := bytes.IndexByte(u.data[u.pos:], '\n'); i >= 0 {
+			end = u.pos + i + 1
+		}
+		s := string(u.data[u.pos:end])
+		u.pos = end
+		return s, nil
+	}
+	o, raised := u.readline.Call(f, nil, nil)
+	if raised != nil {
+		return "", raised
+	}
+	if !o.isInstance(StrType) {
+		return "", f.RaiseType(TypeErrorType, fmt.Sprintf("expected string or Unicode object, %s found", o.typ.Name()))
+	}
+	return toStrUnsafe(o).Value(), nil
+}
+
+// readArg returns the argument of a text opcode, the next line without its
+// trailing newline.
+func (u *unpickler) readArg(f *Frame) (string, *BaseException) {
+	s, raised := u.readLine(f)
+	if raised != nil {
+		return "", raised
+	}
+	if len(s) < 2 {
+		return "", f.RaiseType(unpicklingErrorType, "pickle data was truncated")
+	}
+	return s[:len(s)-1], nil
+}
+
+// readInt reads an n byte little endian integer. Like CPython, 4 byte values
+// are signed and smaller ones unsigned.
+func (u *unpickler) readInt(f *Frame, n int) (int, *BaseException) {
+	s, raised := u.readBytes(f, n)
+	if raised != nil {
+		return 0, raised
+	}
+	x := 0
+	for i := n - 1; i >= 0; i-- {
+		x = x<<8 | int(s[i])
+	}
+	if n == 4 {
+		x = int(int32(x))
+	}
+	return x, nil
+}
+
+func (u *unpickler) push(o *Object) {
+	u.stack = append(u.stack, o)
+}
+
+func (u *unpickler) pop(f *Frame) (*Object, *BaseException) {
+	n := len(u.stack)
+	if n == 0 {
+		return nil, f.RaiseType(unpicklingErrorType, "bad pickle data")
+	}
+	o := u.stack[n-1]
+	u.stack = u.stack[:n-1]
+	return o, nil
+}
+
+func (u *unpickler) raiseStackUnderflow(f *Frame) *BaseException {
+	return f.RaiseType(unpicklingErrorType, "unpickling stack underflow")
+}
+
+// marker pops the most recent mark, returning the stack position it
+// refers to.
+func (u *unpickler) marker(f *Frame) (int, *BaseException) {
+	n := len(u.marks)
+	if n == 0 {
+		return 0, f.RaiseType(unpicklingErrorType, "could not find MARK")
+	}
+	i := u.marks[n-1]
+	u.marks = u.marks[:n-1]
+	return i, nil
+}
+
+// popFrom removes and returns the elements of the stack from position i.
+func (u *unpickler) popFrom(f *Frame, i int) ([]*Object, *BaseException) {
+	if i < 0 || i > len(u.stack) {
+		return nil, u.raiseStackUnderflow(f)
+	}
+	elems := make([]*Object, len(u.stack)-i)
+	copy(elems, u.stack[i:])
+	u.stack = u.stack[:i]
+	return elems, nil
+}
+
+// popMark removes and returns the elements of the stack above the most
+// recent mark.
+func (u *unpickler) popMark(f *Frame) ([]*Object, *BaseException) {
+	i, raised := u.marker(f)
+	if raised != nil {
+		return nil, raised
+	}
+	return u.popFrom(f, i)
+}
+
+// truncate discards the elements of the stack from position i.
+func (u *unpickler) truncate(f *Frame, i int) *BaseException {
+	if i < 0 {
+		return u.raiseStackUnderflow(f)
+	}
+	if i < len(u.stack) {
+		u.stack = u.stack[:i]
+	}
+	return nil
+}
+
+func (u *unpickler) findClass(f *Frame, module, name string) (*Object, *BaseException) {
+	if u.findGlobal == None {
+		return nil, f.RaiseType(unpicklingErrorType, "Global and instance pickles are not supported.")
+	}
+	if u.findGlobal != nil {
+		return u.findGlobal.Call(f, Args{NewStr(module).ToObject(), NewStr(name).ToObject()}, nil)
+	}
+	if module == "copy_reg" {
+		// Mirror saveGlobal by using the native implementations of
+		// these copy_reg functions.
+		switch name {
+		case "_reconstructor":
+			return objectReconstructorFunc, nil
+		case "__newobj__":
+			return objectNewObjFunc, nil
+		}
+	}
+	mod, raised := SysModules.GetItemString(f, module)
+	if raised != nil {
+		return nil, raised
+	}
+	if mod == nil {
+		modules, raised := ImportModule(f, module)
+		if raised != nil {
+			return nil, raised
+		}
+		mod = modules[len(modules)-1]
+	}
+	return GetAttr(f, mod, NewStr(name), nil)
+}
+
+// callClass calls cls with args, which must be a tuple.
+func (u *unpickler) callClass(f *Frame, cls, args *Object) (*Object, *BaseException) {
+	if !args.isInstance(TupleType) {
+		return nil, f.RaiseType(TypeErrorType, "argument list must be a tuple")
+	}
+	return cls.Call(f, toTupleUnsafe(args).elems, nil)
+}
+
+func (u *unpickler) raiseNoPersistentLoad(f *Frame) *BaseException {
+	return f.RaiseType(unpicklingErrorType, "A load persistent id instruction was encountered,\nbut no persistent_load function was specified.")
+}
+
+// loadPersistent pushes the object referred to by the persistent id pid.
+func (u *unpickler) loadPersistent(f *Frame, pid *Object) *BaseException {
+	switch {
+	case u.persistentLoad.isInstance(ListType):
+		toListUnsafe(u.persistentLoad).Append(pid)
+	default:
+		var raised *BaseException
+		if pid, raised = u.persistentLoad.Call(f, Args{pid}, nil); raised != nil {
+			return raised
+		}
+	}
+	u.push(pid)
+	return nil
+}
+
+func (u *unpickler) loadInt(f *Frame) *BaseException {
+	s, raised := u.readLine(f)
+	if raised != nil {
+		return raised
+	}
+	if len(s) < 2 {
+		return f.RaiseType(unpicklingErrorType, "pickle data was truncated")
+	}
+	if s[len(s)-1] == '\n' {
+		if i, err := strconv.ParseInt(s[:len(s)-1], 0, 64); err == nil {
+			if len(s) == 3 && (i == 0 || i == 1) {
+				u.push(GetBool(i == 1).ToObject())
+			} else {
+				u.push(NewInt(int(i)).ToObject())
+			}
+			return nil
+		}
+	}
+	// The value may be too large for an int.
+	o, raised := LongType.Call(f, Args{NewStr(strings.TrimSpace(s)).ToObject(), NewInt(0).ToObject()}, nil)
+	if raised != nil {
+		f.RestoreExc(nil, nil)
+		return f.RaiseType(ValueErrorType, "could not convert string to int")
+	}
+	u.push(o)
+	return nil
+}
+
+func (u *unpickler) loadLong(f *Frame) *BaseException {
+	s, raised := u.readArg(f)
+	if raised != nil {
+		return raised
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "L") || strings.HasSuffix(s, "l") {
+		s = s[:len(s)-1]
+	}
+	o, raised := LongType.Call(f, Args{NewStr(s).ToObject(), NewInt(0).ToObject()}, nil)
+	if raised != nil {
+		return raised
+	}
+	u.push(o)
+	return nil
+}
+
+func (u *unpickler) loadCountedLong(f *Frame, size int) *BaseException {
+	n, raised := u.readInt(f, size)
+	if raised != nil {
+		return raised
+	}
+	if n < 0 {
+		return f.RaiseType(unpicklingErrorType, "LONG pickle has negative byte count")
+	}
+	s, raised := u.readBytes(f, n)
+	if raised != nil {
+		return raised
+	}
+	// Convert from little endian two's complement.
+	b := make([]byte, n)
+	for i := 0; i < n; i++ {
+		b[n-1-i] = s[i]
+	}
+	x := new(big.Int).SetBytes(b)
+	if n > 0 && b[0]&0x80 != 0 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), uint(n*8)))
+	}
+	u.push(NewLong(x).ToObject())
+	return nil
+}
+
+func (u *unpickler) loadFloat(f *Frame) *BaseException {
+	s, raised := u.readLine(f)
+	if raised != nil {
+		return raised
+	}
+	if len(s) < 2 {
+		return f.RaiseType(unpicklingErrorType, "pickle data was truncated")
+	}
+	x, err := strconv.ParseFloat(strings.TrimSuffix(s, "\n"), 64)
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange && math.IsInf(x, 0) {
+		return f.RaiseType(OverflowErrorType, "could not convert string to float")
+	} else if (err != nil && !ok) || (ok && numErr.Err != strconv.ErrRange) || s[len(s)-1] != '\n' {
+		return f.RaiseType(ValueErrorType, "could not convert string to float")
+	}
+	u.push(NewFloat(x).ToObject())
+	return nil
+}
+
+func (u *unpickler) loadString(f *Frame) *BaseException {
+	s, raised := u.readLine(f)
+	if raised != nil {
+		return raised
+	}
+	if len(s) < 2 {
+		return f.RaiseType(unpicklingErrorType, "pickle data was truncated")
+	}
+	// Strip trailing whitespace and then the outermost quotes.
+	n := len(s)
+	for n > 0 && s[n-1] <= ' ' {
+		n--
+	}
+	if n < 2 || (s[0] != '"' && s[0] != '\'') || s[n-1] != s[0] {
+		return f.RaiseType(ValueErrorType, "insecure string pickle")
+	}
+	decoded, raised := pickleDecodeStringEscape(f, s[1:n-1])
+	if raised != nil {
+		return raised
+	}
+	u.push(NewStr(decoded).ToObject())
+	return nil
+}
+
+// pickleDecodeStringEscape decodes the backslash escapes produced by repr()
+// of a str.
+func pickleDecodeStringEscape(f *Frame, s string) (string, *BaseException) {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", f.RaiseType(ValueErrorType, "Trailing \\ in string")
+		}
+		c = s[i]
+		switch c {
+		case '\n':
+		case '\\', '\'', '"':
+			buf.WriteByte(c)
+		case 'a':
+			buf.WriteByte('\a')
+		case 'b':
+			buf.WriteByte('\b')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'v':
+			buf.WriteByte('\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			x := int(c - '0')
+			for j := 0; j < 2 && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '7'; j++ {
+				i++
+				x = x<<3 | int(s[i]-'0')
+			}
+			buf.WriteByte(byte(x))
+		case 'x':
+			if i+2 < len(s) && pickleHexDigit(s[i+1]) >= 0 && pickleHexDigit(s[i+2]) >= 0 {
+				buf.WriteByte(byte(pickleHexDigit(s[i+1])<<4 | pickleHexDigit(s[i+2])))
+				i += 2
+			} else {
+				return "", f.RaiseType(ValueErrorType, "invalid \\x escape")
+			}
+		default:
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String(), nil
+}
+
+func pickleHexDigit(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// pickleDecodeRawUnicodeEscape decodes s with the raw-unicode-escape codec.
+func pickleDecodeRawUnicodeEscape(f *Frame, s string) ([]rune, *BaseException) {
+	var runes []rune
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			runes = append(runes, rune(s[i]))
+			i++
+			continue
+		}
+		// \u and \U are only escapes when preceded by an odd number
+		// of backslashes.
+		start := i
+		for i < len(s) && s[i] == '\\' {
+			runes = append(runes, '\\')
+			i++
+		}
+		if (i-start)%2 == 0 || i == len(s) || (s[i] != 'u' && s[i] != 'U') {
+			continue
+		}
+		runes = runes[:len(runes)-1]
+		start = i - 1
+		numDigits, msg := 4, "truncated \\uXXXX"
+		if s[i] == 'U' {
+			numDigits, msg = 8, "truncated \\UXXXXXXXX"
+		}
+		i++
+		var r rune
+		j := 0
+		for ; j < numDigits && i < len(s) && pickleHexDigit(s[i]) >= 0; j++ {
+			r = r<<4 | rune(pickleHexDigit(s[i]))
+			i++
+		}
+		if j < numDigits {
+			format := "'rawunicodeescape' codec can't decode bytes in position %d-%d: %s"
+			return nil, f.RaiseType(UnicodeDecodeErrorType, fmt.Sprintf(format, start, i-1, msg))
+		}
+		if r > unicode.MaxRune {
+			format := "'rawunicodeescape' codec can't decode bytes in position %d-%d: \\Uxxxxxxxx out of range"
+			return nil, f.RaiseType(UnicodeDecodeErrorType, fmt.Sprintf(format, start, i-1))
+		}
+		runes = append(runes, r)
+	}
+	return runes, nil
+}
+
+func (u *unpickler) loadBinString(f *Frame, size int, what string) (string, *BaseException) {
+	n, raised := u.readInt(f, size)
+	if raised != nil {
+		return "", raised
+	}
+	if n < 0 {
+		return "", f.RaiseType(unpicklingErrorType, what+" pickle has negative byte count")
+	}
+	return u.readBytes(f, n)
+}
+
+func (u *unpickler) loadGlobal(f *Frame) (*Object, *BaseException) {
+	module, raised := u.readArg(f)
+	if raised != nil {
+		return nil, raised
+	}
+	name, raised := u.readArg(f)
+	if raised != nil {
+		return nil, raised
+	}
+	return u.findClass(f, module, name)
+}
+
+func (u *unpickler) loadExt(f *Frame, size int, noload bool) *BaseException {
+	code, raised := u.readInt(f, size)
+	if raised != nil {
+		return raised
+	}
+	if noload {
+		u.push(None)
+		return nil
+	}
+	if code <= 0 {
+		return f.RaiseType(unpicklingErrorType, "EXT specifies code <= 0")
+	}
+	if pickleExtCache == nil || pickleInvertedRegistry == nil {
+		return f.RaiseType(ValueErrorType, fmt.Sprintf("unregistered extension code %d", code))
+	}
+	key := NewInt(code).ToObject()
+	o, raised := pickleExtCache.GetItem(f, key)
+	if raised != nil {
+		return raised
+	}
+	if o != nil {
+		u.push(o)
+		return nil
+	}
+	pair, raised := pickleInvertedRegistry.GetItem(f, key)
+	if raised != nil {
+		return raised
+	}
+	if pair == nil {
+		return f.RaiseType(ValueErrorType, fmt.Sprintf("unregistered extension code %d", code))
+	}
+	if !pair.isInstance(TupleType) || len(toTupleUnsafe(pair).elems) != 2 || !toTupleUnsafe(pair).elems[0].isInstance(StrType) || !toTupleUnsafe(pair).elems[1].isInstance(StrType) {
+		return f.RaiseType(ValueErrorType, fmt.Sprintf("_inverted_registry[%d] isn't a 2-tuple of strings", code))
+	}
+	elems := toTupleUnsafe(pair).elems
+	if o, raised = u.findClass(f, toStrUnsafe(elems[0]).Value(), toStrUnsafe(elems[1]).Value()); raised != nil {
+		return raised
+	}
+	if raised := pickleExtCache.SetItem(f, key, o); raised != nil {
+		return raised
+	}
+	u.push(o)
+	return nil
+}
+
+func (u *unpickler) memoGet(f *Frame, key *Object) *BaseException {
+	o, raised := u.memo.GetItem(f, key)
+	if raised != nil {
+		return raised
+	}
+	if o == nil {
+		return f.Raise(badPickleGetType.ToObject(), key, nil)
+	}
+	u.push(o)
+	return nil
+}
+
+func (u *unpickler) memoPut(f *Frame, key *Object) *BaseException {
+	n := len(u.stack)
+	if n == 0 {
+		return u.raiseStackUnderflow(f)
+	}
+	return u.memo.SetItem(f, key, u.stack[n-1])
+}
+
+// appendFrom appends the elements of the stack from position i to the list
+// at position i-1.
+func (u *unpickler) appendFrom(f *Frame, i int) *BaseException {
+	n := len(u.stack)
+	if i <= 0 || i > n {
+		return u.raiseStackUnderflow(f)
+	}
+	if i == n {
+		return nil
+	}
+	target := u.stack[i-1]
+	elems, raised := u.popFrom(f, i)
+	if raised != nil {
+		return raised
+	}
+	if target.isInstance(ListType) {
+		l := toListUnsafe(target)
+		l.mutex.Lock()
+		l.elems = append(l.elems, elems...)
+		l.mutex.Unlock()
+		return nil
+	}
+	appendMethod, raised := GetAttr(f, target, NewStr("append"), nil)
+	if raised != nil {
+		return raised
+	}
+	for _, elem := range elems {
+		if _, raised := appendMethod.Call(f, Args{elem}, nil); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+// setItemsFrom sets the key/value pairs on the stack from position i in the
+// dict at position i-1.
+func (u *unpickler) setItemsFrom(f *Frame, i int) *BaseException {
+	n := len(u.stack)
+	if i <= 0 || i > n {
+		return u.raiseStackUnderflow(f)
+	}
+	target := u.stack[i-1]
+	elems, raised := u.popFrom(f, i)
+	if raised != nil {
+		return raised
+	}
+	for j := 0; j+1 < len(elems); j += 2 {
+		if raised := SetItem(f, target, elems[j], elems[j+1]); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+func (u *unpickler) loadBuild(f *Frame) *BaseException {
+	if len(u.stack) < 2 {
+		return u.raiseStackUnderflow(f)
+	}
+	state, raised := u.pop(f)
+	if raised != nil {
+		return raised
+	}
+	inst := u.stack[len(u.stack)-1]
+	setState, raised := GetAttr(f, inst, NewStr("__setstate__"), None)
+	if raised != nil {
+		return raised
+	}
+	if setState != None {
+		_, raised := setState.Call(f, Args{state}, nil)
+		return raised
+	}
+	slotState := None
+	if state.isInstance(TupleType) && len(toTupleUnsafe(state).elems) == 2 {
+		elems := toTupleUnsafe(state).elems
+		state, slotState = elems[0], elems[1]
+	}
+	if state != None {
+		if !state.isInstance(DictType) {
+			return f.RaiseType(unpicklingErrorType, "state is not a dictionary")
+		}
+		dict, raised := GetAttr(f, inst, NewStr("__dict__"), nil)
+		if raised != nil {
+			return raised
+		}
+		raised = pickleForEachItem(f, toDictUnsafe(state), func(key, value *Object) *BaseException {
+			return SetItem(f, dict, key, value)
+		})
+		if raised != nil {
+			return raised
+		}
+	}
+	if slotState != None {
+		if !slotState.isInstance(DictType) {
+			return f.RaiseType(unpicklingErrorType, "slot state is not a dictionary")
+		}
+		return pickleForEachItem(f, toDictUnsafe(slotState), func(key, value *Object) *BaseException {
+			if !key.isInstance(StrType) {
+				return f.RaiseType(TypeErrorType, fmt.Sprintf("attribute name must be string, not '%s'", key.typ.Name()))
+			}
+			return SetAttr(f, inst, toStrUnsafe(key), value)
+		})
+	}
+	return nil
+}
+
+// pickleForEachItem calls fn for each entry of d.
+func pickleForEachItem(f *Frame, d *Dict, fn func(key, value *Object) *BaseException) *BaseException {
+	d.mutex.Lock(f)
+	iter := newDictEntryIterator(d)
+	d.mutex.Unlock(f)
+	for entry := iter.next(); entry != nil; entry = iter.next() {
+		if raised := fn(entry.key, entry.value); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+func (u *unpickler) loadNewObj(f *Frame, noload bool) *BaseException {
+	argTuple, raised := u.pop(f)
+	if raised != nil {
+		return raised
+	}
+	cls, raised := u.pop(f)
+	if raised != nil {
+		return raised
+	}
+	if noload {
+		u.push(None)
+		return nil
+	}
+	if !argTuple.isInstance(TupleType) {
+		return f.RaiseType(unpicklingErrorType, "NEWOBJ expected an arg tuple.")
+	}
+	if !cls.isInstance(TypeType) {
+		return f.RaiseType(unpicklingErrorType, "NEWOBJ class argument isn't a type object")
+	}
+	o, raised := objectNewObj(f, append(Args{cls}, toTupleUnsafe(argTuple).elems...), nil)
+	if raised != nil {
+		return raised
+	}
+	u.push(o)
+	return nil
+}
+
+// dispatch executes the opcode op. When noload is true objects are not
+// constructed, which allows persistent ids to be found without importing
+// anything.
+func (u *unpickler) dispatch(f *Frame, op byte, noload bool) *BaseException {
+	switch op {
+	case pickleOpNone:
+		u.push(None)
+	case pickleOpNewTrue:
+		u.push(True.ToObject())
+	case pickleOpNewFalse:
+		u.push(False.ToObject())
+	case pickleOpInt:
+		return u.loadInt(f)
+	case pickleOpBinInt, pickleOpBinInt1, pickleOpBinInt2:
+		size := map[byte]int{pickleOpBinInt: 4, pickleOpBinInt1: 1, pickleOpBinInt2: 2}[op]
+		i, raised := u.readInt(f, size)
+		if raised != nil {
+			return raised
+		}
+		u.push(NewInt(i).ToObject())
+	case pickleOpLong:
+		return u.loadLong(f)
+	case pickleOpLong1:
+		return u.loadCountedLong(f, 1)
+	case pickleOpLong4:
+		return u.loadCountedLong(f, 4)
+	case pickleOpFloat:
+		return u.loadFloat(f)
+	case pickleOpBinFloat:
+		s, raised := u.readBytes(f, 8)
+		if raised != nil {
+			return raised
+		}
+		u.push(NewFloat(math.Float64frombits(binary.BigEndian.Uint64([]byte(s)))).ToObject())
+	case pickleOpString:
+		return u.loadString(f)
+	case pickleOpBinString, pickleOpShortBinString:
+		size := 4
+		if op == pickleOpShortBinString {
+			size = 1
+		}
+		s, raised := u.loadBinString(f, size, "BINSTRING")
+		if raised != nil {
+			return raised
+		}
+		u.push(NewStr(s).ToObject())
+	case pickleOpUnicode:
+		s, raised := u.readLine(f)
+		if raised != nil {
+			return raised
+		}
+		if len(s) < 1 {
+			return f.RaiseType(unpicklingErrorType, "pickle data was truncated")
+		}
+		runes, raised := pickleDecodeRawUnicodeEscape(f, s[:len(s)-1])
+		if raised != nil {
+			return raised
+		}
+		u.push(NewUnicodeFromRunes(runes).ToObject())
+	case pickleOpBinUnicode:
+		s, raised := u.loadBinString(f, 4, "BINUNICODE")
+		if raised != nil {
+			return raised
+		}
+		decoded, raised := NewStr(s).Decode(f, "utf8", EncodeStrict)
+		if raised != nil {
+			return raised
+		}
+		u.push(decoded.ToObject())
+	case pickleOpEmptyTuple:
+		u.push(NewTuple().ToObject())
+	case pickleOpTuple:
+		elems, raised := u.popMark(f)
+		if raised != nil {
+			return raised
+		}
+		u.push(NewTuple(elems...).ToObject())
+	case pickleOpTuple1, pickleOpTuple2, pickleOpTuple3:
+		n := int(op-pickleOpTuple1) + 1
+		elems := make([]*Object, n)
+		for i := n - 1; i >= 0; i-- {
+			elem, raised := u.pop(f)
+			if raised != nil {
+				return raised
+			}
+			elems[i] = elem
+		}
+		u.push(NewTuple(elems...).ToObject())
+	case pickleOpEmptyList:
+		u.push(NewList().ToObject())
+	case pickleOpList:
+		elems, raised := u.popMark(f)
+		if raised != nil {
+			return raised
+		}
+		u.push(NewList(elems...).ToObject())
+	case pickleOpEmptyDict:
+		u.push(NewDict().ToObject())
+	case pickleOpDict:
+		elems, raised := u.popMark(f)
+		if raised != nil {
+			return raised
+		}
+		d := NewDict()
+		for i := 0; i+1 < len(elems); i += 2 {
+			if raised := d.SetItem(f, elems[i], elems[i+1]); raised != nil {
+				return raised
+			}
+		}
+		u.push(d.ToObject())
+	case pickleOpObj:
+		i, raised := u.marker(f)
+		if raised != nil {
+			return raised
+		}
+		if noload {
+			return u.truncate(f, i+1)
+		}
+		if len(u.stack)-i < 1 {
+			return u.raiseStackUnderflow(f)
+		}
+		elems, raised := u.popFrom(f, i+1)
+		if raised != nil {
+			return raised
+		}
+		cls, raised := u.pop(f)
+		if raised != nil {
+			return raised
+		}
+		o, raised := cls.Call(f, elems, nil)
+		if raised != nil {
+			return raised
+		}
+		u.push(o)
+	case pickleOpInst:
+		i, raised := u.marker(f)
+		if raised != nil {
+			return raised
+		}
+		module, raised := u.readArg(f)
+		if raised != nil {
+			return raised
+		}
+		name, raised := u.readArg(f)
+		if raised != nil {
+			return raised
+		}
+		if noload {
+			if raised := u.truncate(f, i); raised != nil {
+				return raised
+			}
+			u.push(None)
+			return nil
+		}
+		cls, raised := u.findClass(f, module, name)
+		if raised != nil {
+			return raised
+		}
+		elems, raised := u.popFrom(f, i)
+		if raised != nil {
+			return raised
+		}
+		o, raised := cls.Call(f, elems, nil)
+		if raised != nil {
+			return raised
+		}
+		u.push(o)
+	case pickleOpGlobal:
+		if noload {
+			if _, raised := u.readArg(f); raised != nil {
+				return raised
+			}
+			if _, raised := u.readArg(f); raised != nil {
+				return raised
+			}
+			u.push(None)
+			return nil
+		}
+		o, raised := u.loadGlobal(f)
+		if raised != nil {
+			return raised
+		}
+		u.push(o)
+	case pickleOpNewObj:
+		return u.loadNewObj(f, noload)
+	case pickleOpReduce:
+		if noload {
+			if len(u.stack) < 2 {
+				return u.raiseStackUnderflow(f)
+			}
+			u.stack = u.stack[:len(u.stack)-2]
+			u.push(None)
+			return nil
+		}
+		args, raised := u.pop(f)
+		if raised != nil {
+			return raised
+		}
+		callable, raised := u.pop(f)
+		if raised != nil {
+			return raised
+		}
+		o, raised := u.callClass(f, callable, args)
+		if raised != nil {
+			return raised
+		}
+		u.push(o)
+	case pickleOpExt1:
+		return u.loadExt(f, 1, noload)
+	case pickleOpExt2:
+		return u.loadExt(f, 2, noload)
+	case pickleOpExt4:
+		return u.loadExt(f, 4, noload)
+	case pickleOpMark:
+		u.marks = append(u.marks, len(u.stack))
+	case pickleOpPop:
+		n := len(u.stack)
+		if m := len(u.marks); m > 0 && u.marks[m-1] == n {
+			u.marks = u.marks[:m-1]
+		} else if n > 0 {
+			u.stack = u.stack[:n-1]
+		} else {
+			return u.raiseStackUnderflow(f)
+		}
+	case pickleOpPopMark:
+		i, raised := u.marker(f)
+		if raised != nil {
+			return raised
+		}
+		return u.truncate(f, i)
+	case pickleOpDup:
+		n := len(u.stack)
+		if n == 0 {
+			return u.raiseStackUnderflow(f)
+		}
+		u.push(u.stack[n-1])
+	case pickleOpGet:
+		s, raised := u.readArg(f)
+		if raised != nil {
+			return raised
+		}
+		return u.memoGet(f, NewStr(s).ToObject())
+	case pickleOpBinGet, pickleOpLongBinGet:
+		size := 1
+		if op == pickleOpLongBinGet {
+			size = 4
+		}
+		i, raised := u.readInt(f, size)
+		if raised != nil {
+			return raised
+		}
+		return u.memoGet(f, NewInt(i).ToObject())
+	case pickleOpPut:
+		s, raised := u.readArg(f)
+		if raised != nil {
+			return raised
+		}
+		return u.memoPut(f, NewStr(s).ToObject())
+	case pickleOpBinPut, pickleOpLongBinPut:
+		size := 1
+		if op == pickleOpLongBinPut {
+			size = 4
+		}
+		i, raised := u.readInt(f, size)
+		if raised != nil {
+			return raised
+		}
+		return u.memoPut(f, NewInt(i).ToObject())
+	case pickleOpAppend:
+		if noload {
+			return u.truncate(f, len(u.stack)-1)
+		}
+		return u.appendFrom(f, len(u.stack)-1)
+	case pickleOpAppends:
+		i, raised := u.marker(f)
+		if raised != nil {
+			return raised
+		}
+		if noload {
+			return u.truncate(f, i)
+		}
+		return u.appendFrom(f, i)
+	case pickleOpSetItem:
+		if noload {
+			return u.truncate(f, len(u.stack)-2)
+		}
+		return u.setItemsFrom(f, len(u.stack)-2)
+	case pickleOpSetItems:
+		i, raised := u.marker(f)
+		if raised != nil {
+			return raised
+		}
+		if noload {
+			return u.truncate(f, i)
+		}
+		return u.setItemsFrom(f, i)
+	case pickleOpBuild:
+		if noload {
+			if len(u.stack) < 1 {
+				return u.raiseStackUnderflow(f)
+			}
+			return u.truncate(f, len(u.stack)-1)
+		}
+		return u.loadBuild(f)
+	case pickleOpPersID:
+		if u.persistentLoad == nil {
+			return u.raiseNoPersistentLoad(f)
+		}
+		s, raised := u.readArg(f)
+		if raised != nil {
+			return raised
+		}
+		return u.loadPersistent(f, NewStr(s).ToObject())
+	case pickleOpBinPersID:
+		if u.persistentLoad == nil {
+			return u.raiseNoPersistentLoad(f)
+		}
+		pid, raised := u.pop(f)
+		if raised != nil {
+			return raised
+		}
+		return u.loadPersistent(f, pid)
+	case pickleOpProto:
+		s, raised := u.readBytes(f, 1)
+		if raised != nil {
+			return raised
+		}
+		if proto := int(s[0]); proto > pickleHighestProtocol {
+			return f.RaiseType(ValueErrorType, fmt.Sprintf("unsupported pickle protocol: %d", proto))
+		}
+	default:
+		return f.RaiseType(unpicklingErrorType, fmt.Sprintf("invalid load key, '%c'.", op))
+	}
+	return nil
+}
+
+// load unpickles the next object from the input.
+func (u *unpickler) load(f *Frame, noload bool) (*Object, *BaseException) {
+	u.mutex.Lock(f)
+	defer u.mutex.Unlock(f)
+	u.stack = nil
+	u.marks = nil
+	for {
+		s, raised := u.readBytes(f, 1)
+		if raised != nil {
+			return nil, raised
+		}
+		if s[0] == pickleOpStop {
+			break
+		}
+		if raised := u.dispatch(f, s[0], noload); raised != nil {
+			return nil, raised
+		}
+	}
+	return u.pop(f)
+}
+
+func unpicklerLoad(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "load", args, unpicklerType); raised != nil {
+		return nil, raised
+	}
+	return toUnpicklerUnsafe(args[0]).load(f, false)
+}
+
+func unpicklerNoload(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "noload", args, unpicklerType); raised != nil {
+		return nil, raised
+	}
+	return toUnpicklerUnsafe(args[0]).load(f, true)
+}
+
+func initUnpicklerType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("cPickle").ToObject()
+	dict["UnpicklingError"] = unpicklingErrorType.ToObject()
+	dict["find_global"] = newPickleAttr(unpicklerType, "find_global", func(o *Object) **Object {
+		return &toUnpicklerUnsafe(o).findGlobal
+	}, nil)
+	dict["load"] = newBuiltinFunction("load", unpicklerLoad).ToObject()
+	dict["memo"] = newPickleMemoAttr(unpicklerType, func(o *Object) **Dict {
+		return &toUnpicklerUnsafe(o).memo
+	})
+	dict["noload"] = newBuiltinFunction("noload", unpicklerNoload).ToObject()
+	dict["persistent_load"] = newPickleAttr(unpicklerType, "persistent_load", func(o *Object) **Object {
+		return &toUnpicklerUnsafe(o).persistentLoad
+	}, nil)
+	unpicklerType.flags &^= typeFlagBasetype | typeFlagInstantiable
+}
+
+func initPickleErrorType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("cPickle").ToObject()
+}
+
+var (
+	pickleDumpParams  = NewParamSpec("dump", []Param{{"obj", nil}, {"file", nil}, {"protocol", NewInt(0).ToObject()}}, false, false)
+	pickleDumpsParams = NewParamSpec("dumps", []Param{{"obj", nil}, {"protocol", NewInt(0).ToObject()}}, false, false)
+	picklerParams     = NewParamSpec("Pickler", []Param{{"file", nil}, {"protocol", NewInt(0).ToObject()}}, false, false)
+)
+
+// pickleNewPickler returns a Pickler writing to file using the protocol
+// proto. If file is nil then the Pickler is list-based.
+func pickleNewPickler(f *Frame, file, proto *Object) (*pickler, *BaseException) {
+	if !proto.isInstance(IntType) {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	n := toIntUnsafe(proto).Value()
+	if n > pickleHighestProtocol {
+		format := "pickle protocol %d asked for; the highest available protocol is %d"
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf(format, n, pickleHighestProtocol))
+	}
+	write := file
+	if file != nil && file != None {
+		var raised *BaseException
+		if write, raised = GetAttr(f, file, NewStr("write"), None); raised != nil {
+			return nil, raised
+		}
+		if write == None {
+			return nil, f.RaiseType(TypeErrorType, "argument must have 'write' attribute")
+		}
+	}
+	return newPickler(write, n), nil
+}
+
+func cPicklePickler(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	// Like CPython, Pickler() and Pickler(protocol) create a list-based
+	// Pickler.
+	if len(kwargs) == 0 && len(args) <= 1 && (len(args) == 0 || args[0].isInstance(IntType)) {
+		proto := NewInt(0).ToObject()
+		if len(args) == 1 {
+			proto = args[0]
+		}
+		p, raised := pickleNewPickler(f, nil, proto)
+		if raised != nil {
+			return nil, raised
+		}
+		return p.ToObject(), nil
+	}
+	var validated [2]*Object
+	if raised := picklerParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	p, raised := pickleNewPickler(f, validated[0], validated[1])
+	if raised != nil {
+		return nil, raised
+	}
+	return p.ToObject(), nil
+}
+
+func cPickleUnpickler(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "Unpickler", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	readline, raised := GetAttr(f, args[0], NewStr("readline"), None)
+	if raised != nil {
+		return nil, raised
+	}
+	read, raised := GetAttr(f, args[0], NewStr("read"), None)
+	if raised != nil {
+		return nil, raised
+	}
+	if read == None || readline == None {
+		return nil, f.RaiseType(TypeErrorType, "argument must have 'read' and 'readline' attributes")
+	}
+	return newUnpickler(read, readline, nil).ToObject(), nil
+}
+
+func cPickleDump(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [3]*Object
+	if raised := pickleDumpParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	if validated[1] == None {
+		return nil, f.RaiseType(TypeErrorType, "argument must have 'write' attribute")
+	}
+	p, raised := pickleNewPickler(f, validated[1], validated[2])
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := p.dump(f, validated[0]); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func cPickleDumps(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := pickleDumpsParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	p, raised := pickleNewPickler(f, None, validated[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := p.pickle(f, validated[0]); raised != nil {
+		return nil, raised
+	}
+	return NewStr(string(p.buf)).ToObject(), nil
+}
+
+func cPickleLoad(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	u, raised := cPickleUnpickler(f, args, kwargs)
+	if raised != nil {
+		return nil, raised
+	}
+	return toUnpicklerUnsafe(u).load(f, false)
+}
+
+func cPickleLoads(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "loads", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[0].isInstance(StrType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("loads() argument 1 must be string, not %s", args[0].typ.Name()))
+	}
+	return newUnpickler(nil, nil, []byte(toStrUnsafe(args[0]).Value())).load(f, false)
+}
+
+func init() {
+	// The pure Python cPickle module imports copy_reg before this module
+	// so that its tables are available here.
+	RegisterModule("_cPickle", NewCode("<module>", "_cPickle", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		modules, raised := ImportModule(f, "copy_reg")
+		if raised != nil {
+			return nil, raised
+		}
+		copyReg := modules[0]
+		for name, table := range map[string]**Dict{
+			"dispatch_table":      &pickleDispatchTable,
+			"_extension_registry": &pickleExtRegistry,
+			"_inverted_registry":  &pickleInvertedRegistry,
+			"_extension_cache":    &pickleExtCache,
+		} {
+			o, raised := GetAttr(f, copyReg, NewStr(name), nil)
+			if raised != nil {
+				return nil, raised
+			}
+			if !o.isInstance(DictType) {
+				return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("copy_reg.%s must be a dict", name))
+			}
+			*table = toDictUnsafe(o)
+		}
+		compatibleFormats := NewList()
+		for _, s := range []string{"1.0", "1.1", "1.2", "1.3", "2.0"} {
+			compatibleFormats.Append(NewStr(s).ToObject())
+		}
+		for name, value := range map[string]*Object{
+			"BadPickleGet":       badPickleGetType.ToObject(),
+			"HIGHEST_PROTOCOL":   NewInt(pickleHighestProtocol).ToObject(),
+			"PickleError":        pickleErrorType.ToObject(),
+			"Pickler":            newBuiltinFunction("Pickler", cPicklePickler).ToObject(),
+			"PicklerType":        picklerType.ToObject(),
+			"PicklingError":      picklingErrorType.ToObject(),
+			"UnpickleableError":  unpickleableErrorType.ToObject(),
+			"Unpickler":          newBuiltinFunction("Unpickler", cPickleUnpickler).ToObject(),
+			"UnpicklerType":      unpicklerType.ToObject(),
+			"UnpicklingError":    unpicklingErrorType.ToObject(),
+			"__version__":        NewStr("1.71").ToObject(),
+			"compatible_formats": compatibleFormats.ToObject(),
+			"dump":               newBuiltinFunction("dump", cPickleDump).ToObject(),
+			"dumps":              newBuiltinFunction("dumps", cPickleDumps).ToObject(),
+			"format_version":     NewStr("2.0").ToObject(),
+			"load":               newBuiltinFunction("load", cPickleLoad).ToObject(),
+			"loads":              newBuiltinFunction("loads", cPickleLoads).ToObject(),
+		} {
+			if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
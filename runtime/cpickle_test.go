@@ -0,0 +1,192 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCPickleDumps(t *testing.T) {
+	shared := newTestList(1, 2)
+	bigInt, _ := new(big.Int).SetString("100000000000000000000", 10)
+	cases := []invokeTestCase{
+		{args: wrapArgs(None), want: NewStr("N.").ToObject()},
+		{args: wrapArgs(True, 2), want: NewStr("\x80\x02\x88.").ToObject()},
+		{args: wrapArgs(1), want: NewStr("I1\n.").ToObject()},
+		{args: wrapArgs(1, 1), want: NewStr("K\x01.").ToObject()},
+		{args: wrapArgs(-1, 1), want: NewStr("J\xff\xff\xff\xff.").ToObject()},
+		{args: wrapArgs(300, 1), want: NewStr("M,\x01.").ToObject()},
+		{args: wrapArgs(70000, 2), want: NewStr("\x80\x02Jp\x11\x01\x00.").ToObject()},
+		{args: wrapArgs(1<<40, 2), want: NewStr("\x80\x02I1099511627776\n.").ToObject()},
+		{args: wrapArgs(1, -1), want: NewStr("\x80\x02K\x01.").ToObject()},
+		{args: wrapArgs(bigInt), want: NewStr("L100000000000000000000L\n.").ToObject()},
+		{args: wrapArgs(bigInt, 2), want: NewStr("\x80\x02\x8a\t\x00\x00\x10c-^\xc7k\x05.").ToObject()},
+		{args: wrapArgs(1.5), want: NewStr("F1.5\n.").ToObject()},
+		{args: wrapArgs(1.5, 1), want: NewStr("G?\xf8\x00\x00\x00\x00\x00\x00.").ToObject()},
+		{args: wrapArgs("ab"), want: NewStr("S'ab'\np1\n.").ToObject()},
+		{args: wrapArgs("it's"), want: NewStr("S\"it's\"\np1\n.").ToObject()},
+		{args: wrapArgs("ab", 1), want: NewStr("U\x02abq\x01.").ToObject()},
+		{args: wrapArgs(NewUnicode("é")), want: NewStr("V\xe9\n.").ToObject()},
+		{args: wrapArgs(NewUnicode("é"), 2), want: NewStr("\x80\x02X\x02\x00\x00\x00\xc3\xa9.").ToObject()},
+		{args: wrapArgs(NewTuple(), 1), want: NewStr(").").ToObject()},
+		{args: wrapArgs(newTestTuple(1, 2), 2), want: NewStr("\x80\x02K\x01K\x02\x86q\x01.").ToObject()},
+		{args: wrapArgs(NewList(), 1), want: NewStr("]q\x01.").ToObject()},
+		{args: wrapArgs(newTestList(1, "ab")), want: NewStr("(lp1\nI1\naS'ab'\np2\na.").ToObject()},
+		{args: wrapArgs(newTestDict("a", 1), 1), want: NewStr("}q\x01U\x01aK\x01s.").ToObject()},
+		{args: wrapArgs(newTestList(shared, shared), 2), want: NewStr("\x80\x02]q\x01(]q\x02(K\x01K\x02eh\x02e.").ToObject()},
+		{args: wrapArgs(1, 3), wantExc: mustCreateException(ValueErrorType, "pickle protocol 3 asked for; the highest available protocol is 2")},
+		{args: wrapArgs(1, "foo"), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("dumps", cPickleDumps).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCPickleDumpsSet(t *testing.T) {
+	// Pickling a set refers to __builtin__.set, which must be importable.
+	f := NewRootFrame()
+	builtin := newModule("__builtin__", "__builtin__.py")
+	builtin.state = moduleStateReady
+	mustNotRaise(nil, builtin.Dict().SetItemString(f, "set", SetType.ToObject()))
+	mustNotRaise(nil, builtin.Dict().SetItemString(f, "frozenset", FrozenSetType.ToObject()))
+	mustNotRaise(nil, SysModules.SetItemString(f, "__builtin__", builtin.ToObject()))
+	defer SysModules.DelItemString(f, "__builtin__")
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestSet(1), 2), want: NewStr("\x80\x02c__builtin__\nset\nq\x01]q\x02K\x01a\x85Rq\x03.").ToObject()},
+		// The empty element list is a temporary so it isn't memoized.
+		{args: wrapArgs(newTestSet()), want: NewStr("c__builtin__\nset\np1\n((ltRp2\n.").ToObject()},
+		{args: wrapArgs(newTestFrozenSet(), 2), want: NewStr("\x80\x02c__builtin__\nfrozenset\nq\x01]\x85Rq\x02.").ToObject()},
+		{args: wrapArgs(newTestFrozenSet(1), 2), want: NewStr("\x80\x02c__builtin__\nfrozenset\nq\x01]q\x02K\x01a\x85Rq\x03.").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("dumps", cPickleDumps).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCPickleLoads(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs("N."), want: None},
+		{args: wrapArgs("]q\x00(K\x01K\x02e."), want: newTestList(1, 2).ToObject()},
+		{args: wrapArgs("(K\x01K\x02t."), want: newTestTuple(1, 2).ToObject()},
+		{args: wrapArgs("\x80\x02\x8a\x02\x00\x80."), want: NewLong(big.NewInt(-32768)).ToObject()},
+		{args: wrapArgs("S\"it's\"\n."), want: NewStr("it's").ToObject()},
+		{args: wrapArgs("Vab\\u20ac\n."), want: NewUnicode("ab€").ToObject()},
+		{args: wrapArgs("(dp1\nS'a'\nI1\nsS'b'\n(lp2\nI2\nas."), want: newTestDict("a", 1, "b", newTestList(2)).ToObject()},
+		{args: wrapArgs(""), wantExc: mustCreateException(EOFErrorType, "")},
+		{args: wrapArgs("I1\n"), wantExc: mustCreateException(EOFErrorType, "")},
+		{args: wrapArgs("z"), wantExc: mustCreateException(unpicklingErrorType, "invalid load key, 'z'.")},
+		{args: wrapArgs("g1\n."), wantExc: mustCreateException(badPickleGetType, "1")},
+		{args: wrapArgs("0."), wantExc: mustCreateException(unpicklingErrorType, "unpickling stack underflow")},
+		{args: wrapArgs("S'ab\n."), wantExc: mustCreateException(ValueErrorType, "insecure string pickle")},
+		{args: wrapArgs("\x80\x03N."), wantExc: mustCreateException(ValueErrorType, "unsupported pickle protocol: 3")},
+		{args: wrapArgs("Ix\n."), wantExc: mustCreateException(ValueErrorType, "could not convert string to int")},
+		{args: wrapArgs("P1\n."), wantExc: mustCreateException(unpicklingErrorType, "A load persistent id instruction was encountered,\nbut no persistent_load function was specified.")},
+		{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "loads() argument 1 must be string, not int")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("loads", cPickleLoads).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCPickleRoundTrip(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, o *Object, proto int) (*Object, *BaseException) {
+		s, raised := cPickleDumps(f, Args{o, NewInt(proto).ToObject()}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return cPickleLoads(f, Args{s}, nil)
+	})
+	values := []*Object{
+		None, False.ToObject(), NewInt(-1 << 31).ToObject(), NewInt(1 << 62).ToObject(),
+		NewLong(new(big.Int).Lsh(big.NewInt(-1), 100)).ToObject(), NewFloat(-2.5e-300).ToObject(),
+		NewStr("\x00\xff\n\\'\"").ToObject(), NewUnicode("a\\b\n€\U0001f600").ToObject(),
+		newTestTuple(1, newTestTuple(), newTestList("x", "x")).ToObject(),
+		newTestDict("a", 1, "b", newTestList(2)).ToObject(),
+	}
+	for _, v := range values {
+		for proto := 0; proto <= pickleHighestProtocol; proto++ {
+			cas := invokeTestCase{args: wrapArgs(v, proto), want: v}
+			if err := runInvokeTestCase(fun, &cas); err != "" {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+func TestCPickleRecursive(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, proto int) (*Object, *BaseException) {
+		l := NewList()
+		l.Append(l.ToObject())
+		s, raised := cPickleDumps(f, Args{l.ToObject(), NewInt(proto).ToObject()}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		o, raised := cPickleLoads(f, Args{s}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		elems := toListUnsafe(o).elems
+		return GetBool(len(elems) == 1 && elems[0] == o).ToObject(), nil
+	})
+	for proto := 0; proto <= pickleHighestProtocol; proto++ {
+		cas := invokeTestCase{args: wrapArgs(proto), want: True.ToObject()}
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCPicklePickler(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		p, raised := cPicklePickler(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		o := NewStr("ab").ToObject()
+		for _, o := range []*Object{o, o, NewStr("cd").ToObject()} {
+			dump, raised := GetAttr(f, p, NewStr("dump"), nil)
+			if raised != nil {
+				return nil, raised
+			}
+			if _, raised := dump.Call(f, Args{o}, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		getValue, raised := GetAttr(f, p, NewStr("getvalue"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return getValue.Call(f, nil, nil)
+	})
+	cases := []invokeTestCase{
+		{want: NewStr("S'ab'\np1\n.g1\n.S'cd'\np2\n.").ToObject()},
+		{args: wrapArgs(2), want: NewStr("\x80\x02U\x02abq\x01.\x80\x02h\x01.\x80\x02U\x02cd.").ToObject()},
+		{args: wrapArgs(None), wantExc: mustCreateException(picklingErrorType, "Attempt to getvalue() a non-list-based pickler")},
+		{args: wrapArgs(NewList()), wantExc: mustCreateException(TypeErrorType, "argument must have 'write' attribute")},
+		{args: wrapArgs(None, 3), wantExc: mustCreateException(ValueErrorType, "pickle protocol 3 asked for; the highest available protocol is 2")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
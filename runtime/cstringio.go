@@ -0,0 +1,469 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var (
+	// stringIType corresponds to the Python type 'cStringIO.StringI', the
+	// read-only type returned by StringIO(s).
+	stringIType = newBasisType("StringI", reflect.TypeOf(stringI{}), toStringIUnsafe, ObjectType)
+	// stringOType corresponds to the Python type 'cStringIO.StringO', the
+	// writable type returned by StringIO().
+	stringOType = newBasisType("StringO", reflect.TypeOf(stringO{}), toStringOUnsafe, ObjectType)
+)
+
+// stringI represents Python 'cStringIO.StringI' objects.
+type stringI struct {
+	Object
+	memBuffer
+}
+
+func toStringIUnsafe(o *Object) *stringI {
+	return (*stringI)(o.toPointer())
+}
+
+// ToObject upcasts s to an Object.
+func (s *stringI) ToObject() *Object {
+	return &s.Object
+}
+
+// stringO represents Python 'cStringIO.StringO' objects.
+type stringO struct {
+	Object
+	memBuffer
+	Softspace int `attr:"softspace" attr_mode:"rw"`
+}
+
+func toStringOUnsafe(o *Object) *stringO {
+	return (*stringO)(o.toPointer())
+}
+
+// ToObject upcasts s to an Object.
+func (s *stringO) ToObject() *Object {
+	return &s.Object
+}
+
+// cStringIOMethod is the implementation of a method shared by StringI and
+// StringO. args[0] is the receiver, whose buffer is passed as b.
+type cStringIOMethod func(f *Frame, b *memBuffer, args Args) (*Object, *BaseException)
+
+// newCStringIOMethod returns a builtin function named name that checks its
+// receiver is an instance of typ before calling fn.
+func newCStringIOMethod(typ *Type, name string, fn cStringIOMethod) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodVarArgs(f, name, args, typ); raised != nil {
+			return nil, raised
+		}
+		if len(kwargs) > 0 {
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("%s() takes no keyword arguments", name))
+		}
+		return fn(f, cStringIOBuffer(args[0]), args)
+	}).ToObject()
+}
+
+// cStringIOLock acquires b's mutex and raises ValueError if b is closed. On
+// success the caller must release the mutex.
+func cStringIOLock(f *Frame, b *memBuffer) *BaseException {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return f.RaiseType(ValueErrorType, errIOClosed)
+	}
+	return nil
+}
+
+// cStringIOParseSizeArgs validates the optional integer argument accepted by
+// read, readline and readlines, returning -1 when it is absent. On success
+// b's mutex is held and must be released by the caller.
+func cStringIOParseSizeArgs(f *Frame, b *memBuffer, method string, args Args) (int, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, method, args, expectedTypes...); raised != nil {
+		return 0, raised
+	}
+	size := -1
+	if len(args) > 1 {
+		var raised *BaseException
+		if size, raised = cStringIOToInt(f, args[1]); raised != nil {
+			return 0, raised
+		}
+	}
+	return size, cStringIOLock(f, b)
+}
+
+func cStringIOToInt(f *Frame, o *Object) (int, *BaseException) {
+	if o.typ.slots.Index == nil {
+		return 0, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	return IndexInt(f, o)
+}
+
+func cStringIOClose(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Lock()
+	b.closed = true
+	b.buf = nil
+	b.pos = 0
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func cStringIOFlush(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func cStringIOGetClosed(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	b.mutex.Lock()
+	closed := b.closed
+	b.mutex.Unlock()
+	return GetBool(closed).ToObject(), nil
+}
+
+func cStringIOGetValue(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "getvalue", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	usePos := false
+	if len(args) > 1 {
+		var raised *BaseException
+		if usePos, raised = IsTrue(f, args[1]); raised != nil {
+			return nil, raised
+		}
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	value := b.buf
+	if usePos && b.pos < len(value) {
+		value = value[:b.pos]
+	}
+	s := string(value)
+	b.mutex.Unlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func cStringIOIsatty(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isatty", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Unlock()
+	return False.ToObject(), nil
+}
+
+func cStringIORead(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	n, raised := cStringIOParseSizeArgs(f, b, "read", args)
+	if raised != nil {
+		return nil, raised
+	}
+	s := string(b.read(n))
+	b.mutex.Unlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func cStringIOReadLine(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	limit, raised := cStringIOParseSizeArgs(f, b, "readline", args)
+	if raised != nil {
+		return nil, raised
+	}
+	s := string(b.readLine(limit))
+	b.mutex.Unlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func cStringIOReadLines(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	hint, raised := cStringIOParseSizeArgs(f, b, "readlines", args)
+	if raised != nil {
+		return nil, raised
+	}
+	lines := b.readLines(hint)
+	b.mutex.Unlock()
+	return NewList(lines...).ToObject(), nil
+}
+
+func cStringIOReset(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "reset", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	b.pos = 0
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func cStringIOSeek(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType, ObjectType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	pos, raised := cStringIOToInt(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	whence := 0
+	if argc > 2 {
+		if whence, raised = cStringIOToInt(f, args[2]); raised != nil {
+			return nil, raised
+		}
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	// Like CPython, unrecognized whence values are treated as absolute
+	// and negative positions are clamped to the start of the buffer.
+	switch whence {
+	case 1:
+		pos += b.pos
+	case 2:
+		pos += len(b.buf)
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	b.pos = pos
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func cStringIOTell(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	pos := b.pos
+	b.mutex.Unlock()
+	return NewInt(pos).ToObject(), nil
+}
+
+func cStringIOTruncate(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "truncate", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	size := -1
+	if len(args) > 1 {
+		var raised *BaseException
+		if size, raised = cStringIOToInt(f, args[1]); raised != nil {
+			return nil, raised
+		}
+		if size < 0 {
+			return nil, f.RaiseType(IOErrorType, "[Errno 22] Invalid argument")
+		}
+	}
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	if size < 0 {
+		size = b.pos
+	}
+	b.truncate(size)
+	if b.pos > len(b.buf) {
+		b.pos = len(b.buf)
+	}
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func cStringIOWrite(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "write", args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	data := args[1]
+	if data.isInstance(UnicodeType) {
+		s, raised := toUnicodeUnsafe(data).Encode(f, EncodeDefault, EncodeStrict)
+		if raised != nil {
+			return nil, raised
+		}
+		data = s.ToObject()
+	} else if data.typ.slots.Buffer == nil {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("write() argument 1 must be string or buffer, not %s", data.typ.Name()))
+	}
+	raised := bufferApply(f, data, func(p []byte) *BaseException {
+		if raised := cStringIOLock(f, b); raised != nil {
+			return raised
+		}
+		b.write(p)
+		b.mutex.Unlock()
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func cStringIOWriteLines(f *Frame, b *memBuffer, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "writelines", args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	raised := seqForEach(f, args[1], func(line *Object) *BaseException {
+		if !line.isInstance(BaseStringType) {
+			return f.RaiseType(TypeErrorType, fmt.Sprintf("expected string or Unicode object, %s found", line.typ.Name()))
+		}
+		_, raised := cStringIOWrite(f, b, Args{args[0], line})
+		return raised
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func cStringIOIter(f *Frame, o *Object) (*Object, *BaseException) {
+	b := cStringIOBuffer(o)
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Unlock()
+	return o, nil
+}
+
+func cStringIONext(f *Frame, o *Object) (*Object, *BaseException) {
+	b := cStringIOBuffer(o)
+	if raised := cStringIOLock(f, b); raised != nil {
+		return nil, raised
+	}
+	line := string(b.readLine(-1))
+	b.mutex.Unlock()
+	if line == "" {
+		return nil, f.Raise(StopIterationType.ToObject(), nil, nil)
+	}
+	return NewStr(line).ToObject(), nil
+}
+
+// cStringIOBuffer returns the buffer underlying o which must be a StringI or
+// StringO object.
+func cStringIOBuffer(o *Object) *memBuffer {
+	if o.typ == stringOType {
+		return &toStringOUnsafe(o).memBuffer
+	}
+	return &toStringIUnsafe(o).memBuffer
+}
+
+func initCStringIOType(typ *Type, dict map[string]*Object) {
+	methods := map[string]cStringIOMethod{
+		"close":     cStringIOClose,
+		"flush":     cStringIOFlush,
+		"getvalue":  cStringIOGetValue,
+		"isatty":    cStringIOIsatty,
+		"read":      cStringIORead,
+		"readline":  cStringIOReadLine,
+		"readlines": cStringIOReadLines,
+		"reset":     cStringIOReset,
+		"seek":      cStringIOSeek,
+		"tell":      cStringIOTell,
+		"truncate":  cStringIOTruncate,
+	}
+	if typ == stringOType {
+		methods["write"] = cStringIOWrite
+		methods["writelines"] = cStringIOWriteLines
+	}
+	for name, fn := range methods {
+		dict[name] = newCStringIOMethod(typ, name, fn)
+	}
+	dict["__module__"] = NewStr("cStringIO").ToObject()
+	dict["closed"] = newProperty(newCStringIOMethod(typ, "_get_closed", cStringIOGetClosed), nil, nil).ToObject()
+	typ.flags &^= typeFlagBasetype | typeFlagInstantiable
+	typ.slots.Iter = &unaryOpSlot{cStringIOIter}
+	typ.slots.Next = &unaryOpSlot{cStringIONext}
+}
+
+func initStringIType(dict map[string]*Object) {
+	initCStringIOType(stringIType, dict)
+}
+
+func initStringOType(dict map[string]*Object) {
+	initCStringIOType(stringOType, dict)
+}
+
+func cStringIOStringIO(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if len(kwargs) > 0 {
+		return nil, f.RaiseType(TypeErrorType, "StringIO() takes no keyword arguments")
+	}
+	switch len(args) {
+	case 0:
+		s := &stringO{Object: Object{typ: stringOType}}
+		return s.ToObject(), nil
+	case 1:
+	default:
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("StringIO expected at most 1 arguments, got %d", len(args)))
+	}
+	data := args[0]
+	if data.isInstance(UnicodeType) {
+		encoded, raised := toUnicodeUnsafe(data).Encode(f, EncodeDefault, EncodeStrict)
+		if raised != nil {
+			return nil, raised
+		}
+		data = encoded.ToObject()
+	} else if data.typ.slots.Buffer == nil {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("StringIO() argument 1 must be string or buffer, not %s", data.typ.Name()))
+	}
+	s := &stringI{Object: Object{typ: stringIType}}
+	raised := bufferApply(f, data, func(p []byte) *BaseException {
+		s.buf = append([]byte(nil), p...)
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return s.ToObject(), nil
+}
+
+func init() {
+	RegisterModule("cStringIO", NewCode("<module>", "cStringIO", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		for name, value := range map[string]*Object{
+			"InputType":  stringIType.ToObject(),
+			"OutputType": stringOType.ToObject(),
+			"StringIO":   newBuiltinFunction("StringIO", cStringIOStringIO).ToObject(),
+		} {
+			if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestCStringIOStringIO(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		o, raised := cStringIOStringIO(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		value, raised := cStringIOGetValue(f, cStringIOBuffer(o), Args{o})
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(o.typ.ToObject(), value).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: nil, want: newTestTuple(stringOType, "").ToObject()},
+		{args: wrapArgs("abc"), want: newTestTuple(stringIType, "abc").ToObject()},
+		{args: wrapArgs(NewUnicode("abc")), want: newTestTuple(stringIType, "abc").ToObject()},
+		{args: wrapArgs(newTestByteArray("xy")), want: newTestTuple(stringIType, "xy").ToObject()},
+		{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "StringIO() argument 1 must be string or buffer, not int")},
+		{args: wrapArgs("a", "b"), wantExc: mustCreateException(TypeErrorType, "StringIO expected at most 1 arguments, got 2")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCStringIOMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, init *Object, method string, args ...*Object) (*Object, *BaseException) {
+		var o *Object
+		var raised *BaseException
+		if init == None {
+			o, raised = cStringIOStringIO(f, nil, nil)
+		} else {
+			o, raised = cStringIOStringIO(f, Args{init}, nil)
+		}
+		if raised != nil {
+			return nil, raised
+		}
+		m, raised := GetAttr(f, o, NewStr(method), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := m.Call(f, args, nil); raised != nil {
+			return nil, raised
+		}
+		b := cStringIOBuffer(o)
+		return newTestTuple(string(b.buf), b.pos).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("ab\ncd", "readline"), want: newTestTuple("ab\ncd", 3).ToObject()},
+		{args: wrapArgs("ab\ncd", "read", 2), want: newTestTuple("ab\ncd", 2).ToObject()},
+		{args: wrapArgs("ab\ncd", "seek", -100), want: newTestTuple("ab\ncd", 0).ToObject()},
+		{args: wrapArgs("ab\ncd", "seek", -1, 2), want: newTestTuple("ab\ncd", 4).ToObject()},
+		{args: wrapArgs("ab\ncd", "seek", 1, 3), want: newTestTuple("ab\ncd", 1).ToObject()},
+		{args: wrapArgs("ab\ncd", "truncate", 1), want: newTestTuple("a", 0).ToObject()},
+		{args: wrapArgs(None, "write", "abc"), want: newTestTuple("abc", 3).ToObject()},
+		{args: wrapArgs(None, "write", NewUnicode("ab")), want: newTestTuple("ab", 2).ToObject()},
+		{args: wrapArgs(None, "writelines", newTestList("a", "b")), want: newTestTuple("ab", 2).ToObject()},
+		{args: wrapArgs("ab", "write", "c"), wantExc: mustCreateException(AttributeErrorType, "'StringI' object has no attribute 'write'")},
+		{args: wrapArgs(None, "write", 1), wantExc: mustCreateException(TypeErrorType, "write() argument 1 must be string or buffer, not int")},
+		{args: wrapArgs(None, "writelines", newTestList(1)), wantExc: mustCreateException(TypeErrorType, "expected string or Unicode object, int found")},
+		{args: wrapArgs(None, "truncate", -1), wantExc: mustCreateException(IOErrorType, "[Errno 22] Invalid argument")},
+		{args: wrapArgs(None, "seek", "a"), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCStringIOClosed(t *testing.T) {
+	f := NewRootFrame()
+	o := mustNotRaise(cStringIOStringIO(f, nil, nil))
+	b := cStringIOBuffer(o)
+	if _, raised := cStringIOClose(f, b, Args{o}); raised != nil {
+		t.Fatalf("close() raised %v", raised)
+	}
+	for _, fn := range []cStringIOMethod{cStringIOGetValue, cStringIORead, cStringIOTell} {
+		cas := invokeTestCase{args: wrapArgs(o), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file")}
+		if err := runInvokeTestCase(wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+			return fn(f, b, Args{o})
+		}), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,2118 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	datetimeMinYear = 1
+	datetimeMaxYear = 9999
+	// datetimeMaxOrdinal is the proleptic Gregorian ordinal of 9999-12-31.
+	datetimeMaxOrdinal = 3652059
+	timedeltaMaxDays   = 999999999
+	secondsPerDay      = 86400
+	usPerSecond        = 1000000
+)
+
+var (
+	// dateType corresponds to the Python type 'datetime.date'.
+	dateType = newBasisType("date", reflect.TypeOf(date{}), toDateUnsafe, ObjectType)
+	// datetimeType corresponds to the Python type 'datetime.datetime'.
+	datetimeType = newBasisType("datetime", reflect.TypeOf(datetime{}), toDatetimeUnsafe, dateType)
+	// timeOfDayType corresponds to the Python type 'datetime.time'.
+	timeOfDayType = newBasisType("time", reflect.TypeOf(timeOfDay{}), toTimeOfDayUnsafe, ObjectType)
+	// timedeltaType corresponds to the Python type 'datetime.timedelta'.
+	timedeltaType = newBasisType("timedelta", reflect.TypeOf(timedelta{}), toTimedeltaUnsafe, ObjectType)
+	// tzinfoType corresponds to the Python type 'datetime.tzinfo'.
+	tzinfoType           = newSimpleType("tzinfo", ObjectType)
+	datetimeDaysInMonth  = [...]int{0, 31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+	datetimeDaysBeforeMo = [...]int{0, 0, 31, 59, 90, 120, 151, 181, 212, 243, 273, 304, 334}
+	datetimeDayAbbrs     = [...]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+	datetimeMonthAbbrs   = [...]string{"", "Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	zeroObject           = NewInt(0).ToObject()
+	// timedeltaParams are the timedelta constructor's parameters. The
+	// components are accumulated in the order given by timedeltaUnits.
+	timedeltaParams = NewParamSpec("timedelta", []Param{
+		{"days", zeroObject}, {"seconds", zeroObject},
+		{"microseconds", zeroObject}, {"milliseconds", zeroObject},
+		{"minutes", zeroObject}, {"hours", zeroObject}, {"weeks", zeroObject},
+	}, false, false)
+	timedeltaUnits = []struct {
+		index  int
+		factor int64
+	}{{2, 1}, {3, 1000}, {1, usPerSecond}, {4, 60 * usPerSecond}, {5, 3600 * usPerSecond}, {0, secondsPerDay * usPerSecond}, {6, 7 * secondsPerDay * usPerSecond}}
+	dateParams = NewParamSpec("date", []Param{
+		{"year", nil}, {"month", None}, {"day", None},
+	}, false, false)
+	dateReplaceParams = NewParamSpec("replace", []Param{
+		{"year", None}, {"month", None}, {"day", None},
+	}, false, false)
+	datetimeParams = NewParamSpec("datetime", []Param{
+		{"year", nil}, {"month", None}, {"day", None}, {"hour", zeroObject},
+		{"minute", zeroObject}, {"second", zeroObject},
+		{"microsecond", zeroObject}, {"tzinfo", None},
+	}, false, false)
+	datetimeReplaceParams = NewParamSpec("replace", []Param{
+		{"year", None}, {"month", None}, {"day", None}, {"hour", None},
+		{"minute", None}, {"second", None}, {"microsecond", None},
+		{"tzinfo", True.ToObject()},
+	}, false, false)
+	timeOfDayParams = NewParamSpec("time", []Param{
+		{"hour", zeroObject}, {"minute", zeroObject},
+		{"second", zeroObject}, {"microsecond", zeroObject},
+		{"tzinfo", None},
+	}, false, false)
+	datetimeAstimezoneParams    = NewParamSpec("astimezone", []Param{{"tz", nil}}, false, false)
+	datetimeCombineParams       = NewParamSpec("combine", []Param{{"date", nil}, {"time", nil}}, false, false)
+	datetimeFromtimestampParams = NewParamSpec("fromtimestamp", []Param{
+		{"timestamp", nil}, {"tz", None},
+	}, false, false)
+	datetimeIsoformatParams        = NewParamSpec("isoformat", []Param{{"sep", NewStr("T").ToObject()}}, false, false)
+	datetimeNowParams              = NewParamSpec("now", []Param{{"tz", None}}, false, false)
+	datetimeUtcfromtimestampParams = NewParamSpec("utcfromtimestamp", []Param{{"timestamp", nil}}, false, false)
+	datetimeUtcnowParams           = NewParamSpec("utcnow", nil, false, false)
+	timeOfDayIsoformatParams       = NewParamSpec("isoformat", nil, false, false)
+	timeOfDayReplaceParams         = NewParamSpec("replace", []Param{
+		{"hour", None}, {"minute", None}, {"second", None},
+		{"microsecond", None}, {"tzinfo", True.ToObject()},
+	}, false, false)
+)
+
+// date represents Python 'datetime.date' objects.
+type date struct {
+	Object
+	year, month, day int
+}
+
+func newDate(t *Type, year, month, day int) *date {
+	if t == dateType {
+		return &date{Object{typ: t}, year, month, day}
+	}
+	d := toDateUnsafe(newObject(t))
+	d.year, d.month, d.day = year, month, day
+	return d
+}
+
+func toDateUnsafe(o *Object) *date {
+	return (*date)(o.toPointer())
+}
+
+// ToObject upcasts d to an Object.
+func (d *date) ToObject() *Object {
+	return &d.Object
+}
+
+func (d *date) ordinal() int {
+	return datetimeYMDToOrd(d.year, d.month, d.day)
+}
+
+func (d *date) state() string {
+	return string([]byte{byte(d.year >> 8), byte(d.year), byte(d.month), byte(d.day)})
+}
+
+func (d *date) timeTM(hour, minute, second, isdst int) timeTM {
+	yday := datetimeDaysBefore(d.year, d.month) + d.day
+	return timeTM{d.year, d.month, d.day, hour, minute, second, (d.ordinal() + 6) % 7, yday, isdst}
+}
+
+// datetime represents Python 'datetime.datetime' objects.
+type datetime struct {
+	date
+	hour, minute, second, microsecond int
+	tzinfo                            *Object
+}
+
+func newDatetime(t *Type, year, month, day, hour, minute, second, microsecond int, tzinfo *Object) *datetime {
+	if t == datetimeType {
+		return &datetime{date{Object{typ: t}, year, month, day}, hour, minute, second, microsecond, tzinfo}
+	}
+	d := toDatetimeUnsafe(newObject(t))
+	d.year, d.month, d.day = year, month, day
+	d.hour, d.minute, d.second, d.microsecond, d.tzinfo = hour, minute, second, microsecond, tzinfo
+	return d
+}
+
+func toDatetimeUnsafe(o *Object) *datetime {
+	return (*datetime)(o.toPointer())
+}
+
+// ToObject upcasts d to an Object.
+func (d *datetime) ToObject() *Object {
+	return &d.Object
+}
+
+// daySeconds returns the number of seconds since midnight.
+func (d *datetime) daySeconds() int {
+	return d.hour*3600 + d.minute*60 + d.second
+}
+
+func (d *datetime) state() string {
+	us := d.microsecond
+	return d.date.state() + string([]byte{byte(d.hour), byte(d.minute), byte(d.second), byte(us >> 16), byte(us >> 8), byte(us)})
+}
+
+// timeOfDay represents Python 'datetime.time' objects.
+type timeOfDay struct {
+	Object
+	hour, minute, second, microsecond int
+	tzinfo                            *Object
+}
+
+func newTimeOfDay(t *Type, hour, minute, second, microsecond int, tzinfo *Object) *timeOfDay {
+	if t == timeOfDayType {
+		return &timeOfDay{Object{typ: t}, hour, minute, second, microsecond, tzinfo}
+	}
+	tod := toTimeOfDayUnsafe(newObject(t))
+	tod.hour, tod.minute, tod.second, tod.microsecond, tod.tzinfo = hour, minute, second, microsecond, tzinfo
+	return tod
+}
+
+func toTimeOfDayUnsafe(o *Object) *timeOfDay {
+	return (*timeOfDay)(o.toPointer())
+}
+
+// ToObject upcasts t to an Object.
+func (t *timeOfDay) ToObject() *Object {
+	return &t.Object
+}
+
+func (t *timeOfDay) state() string {
+	us := t.microsecond
+	return string([]byte{byte(t.hour), byte(t.minute), byte(t.second), byte(us >> 16), byte(us >> 8), byte(us)})
+}
+
+// timedelta represents Python 'datetime.timedelta' objects. The fields are
+// normalized such that 0 <= seconds < 86400 and 0 <= microseconds < 1000000.
+type timedelta struct {
+	Object
+	days, seconds, microseconds int
+}
+
+func toTimedeltaUnsafe(o *Object) *timedelta {
+	return (*timedelta)(o.toPointer())
+}
+
+// ToObject upcasts d to an Object.
+func (d *timedelta) ToObject() *Object {
+	return &d.Object
+}
+
+func (d *timedelta) microsecondsBig() *big.Int {
+	us := big.NewInt(int64(d.days))
+	us.Mul(us, big.NewInt(secondsPerDay))
+	us.Add(us, big.NewInt(int64(d.seconds)))
+	us.Mul(us, big.NewInt(usPerSecond))
+	return us.Add(us, big.NewInt(int64(d.microseconds)))
+}
+
+func (d *timedelta) compare(other *timedelta) int {
+	return datetimeCompareInts([]int{d.days, d.seconds, d.microseconds}, []int{other.days, other.seconds, other.microseconds})
+}
+
+// newTimedelta creates a timedelta of type t after normalizing the given
+// components.
+func newTimedelta(f *Frame, t *Type, days, seconds, microseconds int) (*Object, *BaseException) {
+	q, microseconds := datetimeDivMod(microseconds, usPerSecond)
+	q, seconds = datetimeDivMod(seconds+q, secondsPerDay)
+	days += q
+	if days < -timedeltaMaxDays || days > timedeltaMaxDays {
+		return nil, f.RaiseType(OverflowErrorType, fmt.Sprintf("days=%d; must have magnitude <= %d", days, timedeltaMaxDays))
+	}
+	if t == timedeltaType {
+		return (&timedelta{Object{typ: t}, days, seconds, microseconds}).ToObject(), nil
+	}
+	d := toTimedeltaUnsafe(newObject(t))
+	d.days, d.seconds, d.microseconds = days, seconds, microseconds
+	return d.ToObject(), nil
+}
+
+func newTimedeltaFromMicroseconds(f *Frame, t *Type, us *big.Int) (*Object, *BaseException) {
+	days, rem := new(big.Int), new(big.Int)
+	longDivAndMod(days, rem, us, big.NewInt(secondsPerDay*usPerSecond))
+	if days.CmpAbs(big.NewInt(timedeltaMaxDays)) > 0 {
+		return nil, f.RaiseType(OverflowErrorType, fmt.Sprintf("days=%s; must have magnitude <= %d", days, timedeltaMaxDays))
+	}
+	r := int(rem.Int64())
+	return newTimedelta(f, t, int(days.Int64()), r/usPerSecond, r%usPerSecond)
+}
+
+func init() {
+	RegisterModule("_datetime", NewCode("<module>", "_datetime", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		globals := map[string]*Object{
+			"MAXYEAR":   NewInt(datetimeMaxYear).ToObject(),
+			"MINYEAR":   NewInt(datetimeMinYear).ToObject(),
+			"date":      dateType.ToObject(),
+			"datetime":  datetimeType.ToObject(),
+			"time":      timeOfDayType.ToObject(),
+			"timedelta": timedeltaType.ToObject(),
+			"tzinfo":    tzinfoType.ToObject(),
+		}
+		for name, value := range globals {
+			if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
+
+// Calendar helpers. Like the datetime module, these use the proleptic
+// Gregorian calendar where January 1 of year 1 has ordinal 1.
+
+func datetimeDivMod(x, y int) (int, int) {
+	q, r := x/y, x%y
+	if r != 0 && (r < 0) != (y < 0) {
+		q--
+		r += y
+	}
+	return q, r
+}
+
+func datetimeIsLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func datetimeDaysIn(year, month int) int {
+	if month == 2 && datetimeIsLeap(year) {
+		return 29
+	}
+	return datetimeDaysInMonth[month]
+}
+
+func datetimeDaysBefore(year, month int) int {
+	n := datetimeDaysBeforeMo[month]
+	if month > 2 && datetimeIsLeap(year) {
+		n++
+	}
+	return n
+}
+
+func datetimeYMDToOrd(year, month, day int) int {
+	y := year - 1
+	return y*365 + y/4 - y/100 + y/400 + datetimeDaysBefore(year, month) + day
+}
+
+func datetimeOrdToYMD(n int) (int, int, int) {
+	n400, n := datetimeDivMod(n-1, 146097)
+	year := n400*400 + 1
+	n100, n := datetimeDivMod(n, 36524)
+	n4, n := datetimeDivMod(n, 1461)
+	n1, n := datetimeDivMod(n, 365)
+	year += n100*100 + n4*4 + n1
+	if n1 == 4 || n100 == 4 {
+		return year - 1, 12, 31
+	}
+	leap := n1 == 3 && (n4 != 24 || n100 == 3)
+	month := (n + 50) >> 5
+	preceding := datetimeDaysBeforeMo[month]
+	if month > 2 && leap {
+		preceding++
+	}
+	if preceding > n {
+		month--
+		preceding -= datetimeDaysInMonth[month]
+		if month == 2 && leap {
+			preceding--
+		}
+	}
+	return year, month, n - preceding + 1
+}
+
+// datetimeISOWeek1Monday returns the ordinal of the Monday starting ISO week
+// 1 of year.
+func datetimeISOWeek1Monday(year int) int {
+	firstDay := datetimeYMDToOrd(year, 1, 1)
+	firstWeekday := (firstDay + 6) % 7
+	week1Monday := firstDay - firstWeekday
+	if firstWeekday > 3 {
+		week1Monday += 7
+	}
+	return week1Monday
+}
+
+func datetimeCompareInts(x, y []int) int {
+	for i, v := range x {
+		if v < y[i] {
+			return -1
+		}
+		if v > y[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Argument checking helpers.
+
+// datetimeIntField converts o to an int the way the constructors do. Floats
+// are rejected and other types must implement __int__.
+func datetimeIntField(f *Frame, o *Object) (int, *BaseException) {
+	if o.isInstance(IntType) {
+		return toIntUnsafe(o).Value(), nil
+	}
+	if o.isInstance(FloatType) {
+		return 0, f.RaiseType(TypeErrorType, "integer argument expected, got float")
+	}
+	intSlot := o.typ.slots.Int
+	if intSlot == nil {
+		return 0, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	i, raised := intSlot.Fn(f, o)
+	if raised != nil {
+		return 0, raised
+	}
+	if i.isInstance(IntType) {
+		return toIntUnsafe(i).Value(), nil
+	}
+	if i.isInstance(LongType) {
+		// Values too large for an int are out of range for every field
+		// so clamp them.
+		v := toLongUnsafe(i).Value()
+		if v.IsInt64() {
+			return int(v.Int64()), nil
+		}
+		if v.Sign() < 0 {
+			return MinInt, nil
+		}
+		return MaxInt, nil
+	}
+	return 0, f.RaiseType(TypeErrorType, "__int__ method should return an integer")
+}
+
+func datetimeIntFields(f *Frame, values []*Object, fields ...*int) *BaseException {
+	for i, field := range fields {
+		v, raised := datetimeIntField(f, values[i])
+		if raised != nil {
+			return raised
+		}
+		*field = v
+	}
+	return nil
+}
+
+// datetimeRangeError raises ValueError(msg, value) like the datetime module
+// does for fields that are out of range.
+func datetimeRangeError(f *Frame, msg string, value int) *BaseException {
+	args := NewTuple2(NewStr(msg).ToObject(), NewInt(value).ToObject())
+	return f.Raise(ValueErrorType.ToObject(), args.ToObject(), nil)
+}
+
+func datetimeCheckDate(f *Frame, year, month, day int) *BaseException {
+	if year < datetimeMinYear || year > datetimeMaxYear {
+		return datetimeRangeError(f, fmt.Sprintf("year must be in %d..%d", datetimeMinYear, datetimeMaxYear), year)
+	}
+	if month < 1 || month > 12 {
+		return datetimeRangeError(f, "month must be in 1..12", month)
+	}
+	if dim := datetimeDaysIn(year, month); day < 1 || day > dim {
+		return datetimeRangeError(f, fmt.Sprintf("day must be in 1..%d", dim), day)
+	}
+	return nil
+}
+
+func datetimeCheckTime(f *Frame, hour, minute, second, microsecond int) *BaseException {
+	if hour < 0 || hour > 23 {
+		return datetimeRangeError(f, "hour must be in 0..23", hour)
+	}
+	if minute < 0 || minute > 59 {
+		return datetimeRangeError(f, "minute must be in 0..59", minute)
+	}
+	if second < 0 || second > 59 {
+		return datetimeRangeError(f, "second must be in 0..59", second)
+	}
+	if microsecond < 0 || microsecond > 999999 {
+		return datetimeRangeError(f, "microsecond must be in 0..999999", microsecond)
+	}
+	return nil
+}
+
+func datetimeCheckTzinfo(f *Frame, tzinfo *Object) *BaseException {
+	if tzinfo != None && !tzinfo.isInstance(tzinfoType) {
+		return f.RaiseType(TypeErrorType, "tzinfo argument must be None or of a tzinfo subclass")
+	}
+	return nil
+}
+
+func datetimeCheckStateTzinfo(f *Frame, tzinfo *Object) *BaseException {
+	if tzinfo != None && !tzinfo.isInstance(tzinfoType) {
+		return f.RaiseType(TypeErrorType, "bad tzinfo state arg")
+	}
+	return nil
+}
+
+// datetimeCmpError raises the TypeError used for ordering comparisons
+// between datetime objects and objects they don't know how to compare with.
+func datetimeCmpError(f *Frame, v, w *Object) *BaseException {
+	return f.RaiseType(TypeErrorType, fmt.Sprintf("can't compare '%s' to '%s'", v.typ.Name(), w.typ.Name()))
+}
+
+// datetimeMismatchResult returns the result of comparison op between v and w
+// where w is not of a type that v knows how to compare itself with.
+// Equality comparisons succeed and ordering comparisons raise TypeError.
+func datetimeMismatchResult(f *Frame, op compareOp, v, w *Object) (*Object, *BaseException) {
+	switch op {
+	case compareOpEq:
+		return False.ToObject(), nil
+	case compareOpNE:
+		return True.ToObject(), nil
+	}
+	return nil, datetimeCmpError(f, v, w)
+}
+
+// datetimeHasTimetuple reports whether o has a timetuple attribute, which
+// allows other date-like types to intercept comparisons with dates.
+func datetimeHasTimetuple(f *Frame, o *Object) (bool, *BaseException) {
+	attr, raised := GetAttr(f, o, NewStr("timetuple"), None)
+	if raised != nil {
+		return false, raised
+	}
+	return attr != None, nil
+}
+
+// datetimeCompareSlots sets the rich comparison slots of t to functions
+// implemented in terms of compare, which returns NotImplemented or the
+// result of the comparison.
+func datetimeCompareSlots(t *Type, compare func(*Frame, compareOp, *Object, *Object) (*Object, *BaseException)) {
+	slot := func(op compareOp) *binaryOpSlot {
+		return &binaryOpSlot{func(f *Frame, v, w *Object) (*Object, *BaseException) {
+			return compare(f, op, v, w)
+		}}
+	}
+	t.slots.Eq = slot(compareOpEq)
+	t.slots.GE = slot(compareOpGE)
+	t.slots.GT = slot(compareOpGT)
+	t.slots.LE = slot(compareOpLE)
+	t.slots.LT = slot(compareOpLT)
+	t.slots.NE = slot(compareOpNE)
+}
+
+// newDatetimeField returns a read-only property for instances of t whose
+// value is computed by get.
+func newDatetimeField(t *Type, name string, get func(*Object) *Object) *Object {
+	getter := newBuiltinFunction("_get_"+name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_get_"+name, args, t); raised != nil {
+			return nil, raised
+		}
+		return get(args[0]), nil
+	}).ToObject()
+	return newProperty(getter, nil, nil).ToObject()
+}
+
+// datetimeClassName returns the name used for o's class in its repr.
+func datetimeClassName(o *Object, t *Type) string {
+	if o.typ == t {
+		return "datetime." + t.Name()
+	}
+	return o.typ.Name()
+}
+
+// Time zone helpers.
+
+// datetimeUTCOffset calls the utcoffset or dst method, given by name, of
+// tzinfo with arg and returns the offset in minutes. ok is false when tzinfo
+// is None or the method returns None.
+func datetimeUTCOffset(f *Frame, tzinfo *Object, name string, arg *Object) (minutes int, ok bool, raised *BaseException) {
+	if tzinfo == None {
+		return 0, false, nil
+	}
+	method, raised := GetAttr(f, tzinfo, NewStr(name), nil)
+	if raised != nil {
+		return 0, false, raised
+	}
+	offset, raised := method.Call(f, Args{arg}, nil)
+	if raised != nil {
+		return 0, false, raised
+	}
+	if offset == None {
+		return 0, false, nil
+	}
+	if !offset.isInstance(timedeltaType) {
+		s, raised := Repr(f, offset.typ.ToObject())
+		if raised != nil {
+			return 0, false, raised
+		}
+		format := "tzinfo.%s() must return None or timedelta, not '%s'"
+		return 0, false, f.RaiseType(TypeErrorType, fmt.Sprintf(format, name, s.Value()))
+	}
+	d := toTimedeltaUnsafe(offset)
+	if d.days < -1 || d.days > 0 {
+		minutes = 1440
+	} else {
+		seconds := d.days*secondsPerDay + d.seconds
+		if seconds%60 != 0 || d.microseconds != 0 {
+			format := "tzinfo.%s() must return a whole number of minutes"
+			return 0, false, f.RaiseType(ValueErrorType, fmt.Sprintf(format, name))
+		}
+		minutes = seconds / 60
+	}
+	if minutes <= -1440 || minutes >= 1440 {
+		format := "%s()=%d, must be in -1439..1439"
+		return 0, false, f.RaiseType(ValueErrorType, fmt.Sprintf(format, name, minutes))
+	}
+	return minutes, true, nil
+}
+
+// datetimeUTCOffsetObject is like datetimeUTCOffset but returns the offset
+// as a timedelta or None.
+func datetimeUTCOffsetObject(f *Frame, tzinfo *Object, name string, arg *Object) (*Object, *BaseException) {
+	minutes, ok, raised := datetimeUTCOffset(f, tzinfo, name, arg)
+	if raised != nil || !ok {
+		return None, raised
+	}
+	return newTimedelta(f, timedeltaType, 0, minutes*60, 0)
+}
+
+// datetimeTZName calls the tzname method of tzinfo with arg. The result is
+// None or a str.
+func datetimeTZName(f *Frame, tzinfo *Object, arg *Object) (*Object, *BaseException) {
+	if tzinfo == None {
+		return None, nil
+	}
+	method, raised := GetAttr(f, tzinfo, NewStr("tzname"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	name, raised := method.Call(f, Args{arg}, nil)
+	if raised != nil {
+		return nil, raised
+	}
+	if name != None && !name.isInstance(StrType) {
+		s, raised := Repr(f, name.typ.ToObject())
+		if raised != nil {
+			return nil, raised
+		}
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("tzinfo.tzname() must return None or string, not '%s'", s.Value()))
+	}
+	return name, nil
+}
+
+// datetimeFormatOffset formats an offset in minutes as [+-]HH<sep>MM.
+func datetimeFormatOffset(minutes int, sep string) string {
+	sign := "+"
+	if minutes < 0 {
+		sign, minutes = "-", -minutes
+	}
+	return fmt.Sprintf("%s%02d%s%02d", sign, minutes/60, sep, minutes%60)
+}
+
+func datetimeFormatTime(hour, minute, second, microsecond int) string {
+	if microsecond != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d.%06d", hour, minute, second, microsecond)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
+}
+
+// datetimeFormatTM formats tm according to format like time.strftime after
+// substituting %f, %z and %Z with the microseconds and the offset and name
+// of tzinfo.
+func datetimeFormatTM(f *Frame, format string, tm timeTM, microsecond int, tzinfo, tzarg *Object) (*Object, *BaseException) {
+	if tm.year < 1900 {
+		format := "year=%d is before 1900; the datetime strftime() methods require year >= 1900"
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf(format, tm.year))
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i == len(format)-1 {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = format[i]; c {
+		case 'f':
+			fmt.Fprintf(&buf, "%06d", microsecond)
+		case 'z':
+			minutes, ok, raised := datetimeUTCOffset(f, tzinfo, "utcoffset", tzarg)
+			if raised != nil {
+				return nil, raised
+			}
+			if ok {
+				buf.WriteString(datetimeFormatOffset(minutes, ""))
+			}
+		case 'Z':
+			name, raised := datetimeTZName(f, tzinfo, tzarg)
+			if raised != nil {
+				return nil, raised
+			}
+			if name != None {
+				buf.WriteString(strings.Replace(toStrUnsafe(name).Value(), "%", "%%", -1))
+			}
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(c)
+		}
+	}
+	return NewStr(timeFormat(buf.String(), tm, timeCurrentZone())).ToObject(), nil
+}
+
+// datetimeFormat implements __format__ for date, datetime and time objects.
+func datetimeFormat(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__format__", args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	o, spec := args[0], args[1]
+	if !spec.isInstance(BaseStringType) {
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("__format__ expects str or unicode, not %s", spec.typ.Name()))
+	}
+	n, raised := Len(f, spec)
+	if raised != nil {
+		return nil, raised
+	}
+	if n.Value() == 0 {
+		s, raised := ToStr(f, o)
+		if raised != nil {
+			return nil, raised
+		}
+		return s.ToObject(), nil
+	}
+	strftime, raised := GetAttr(f, o, NewStr("strftime"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return strftime.Call(f, Args{spec}, nil)
+}
+
+// timedelta
+
+func timedeltaAbs(f *Frame, o *Object) (*Object, *BaseException) {
+	if toTimedeltaUnsafe(o).days < 0 {
+		return timedeltaNeg(f, o)
+	}
+	return o, nil
+}
+
+func timedeltaAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(timedeltaType) {
+		return NotImplemented, nil
+	}
+	x, y := toTimedeltaUnsafe(v), toTimedeltaUnsafe(w)
+	return newTimedelta(f, timedeltaType, x.days+y.days, x.seconds+y.seconds, x.microseconds+y.microseconds)
+}
+
+func timedeltaCompare(f *Frame, op compareOp, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(timedeltaType) {
+		return datetimeMismatchResult(f, op, v, w)
+	}
+	return convert3wayToObject(op, toTimedeltaUnsafe(v).compare(toTimedeltaUnsafe(w))), nil
+}
+
+// timedeltaDiv implements / and // which both floor the result.
+func timedeltaDiv(f *Frame, v, w *Object) (*Object, *BaseException) {
+	var divisor *big.Int
+	if w.isInstance(IntType) {
+		divisor = big.NewInt(int64(toIntUnsafe(w).Value()))
+	} else if w.isInstance(LongType) {
+		divisor = toLongUnsafe(w).Value()
+	} else {
+		return NotImplemented, nil
+	}
+	if divisor.Sign() == 0 {
+		return nil, f.RaiseType(ZeroDivisionErrorType, "integer division or modulo by zero")
+	}
+	us := toTimedeltaUnsafe(v).microsecondsBig()
+	longDiv(us, us, divisor)
+	return newTimedeltaFromMicroseconds(f, timedeltaType, us)
+}
+
+func timedeltaHash(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toTimedeltaUnsafe(o)
+	h, raised := Hash(f, NewTuple3(NewInt(d.days).ToObject(), NewInt(d.seconds).ToObject(), NewInt(d.microseconds).ToObject()).ToObject())
+	if raised != nil {
+		return nil, raised
+	}
+	return h.ToObject(), nil
+}
+
+func timedeltaMul(f *Frame, v, w *Object) (*Object, *BaseException) {
+	var factor *big.Int
+	if w.isInstance(IntType) {
+		factor = big.NewInt(int64(toIntUnsafe(w).Value()))
+	} else if w.isInstance(LongType) {
+		factor = toLongUnsafe(w).Value()
+	} else {
+		return NotImplemented, nil
+	}
+	us := toTimedeltaUnsafe(v).microsecondsBig()
+	return newTimedeltaFromMicroseconds(f, timedeltaType, us.Mul(us, factor))
+}
+
+func timedeltaNeg(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toTimedeltaUnsafe(o)
+	return newTimedelta(f, timedeltaType, -d.days, -d.seconds, -d.microseconds)
+}
+
+// timedeltaNew implements timedelta(days=0, seconds=0, microseconds=0,
+// milliseconds=0, minutes=0, hours=0, weeks=0). The components may be ints,
+// longs or floats. They're summed in microseconds with the fractional parts
+// accumulated separately and rounded at the end.
+func timedeltaNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [7]*Object
+	if raised := timedeltaParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	var sum, term big.Int
+	leftover := 0.0
+	for _, unit := range timedeltaUnits {
+		o := validated[unit.index]
+		switch {
+		case o.isInstance(IntType):
+			term.SetInt64(int64(toIntUnsafe(o).Value()))
+		case o.isInstance(LongType):
+			term.Set(toLongUnsafe(o).Value())
+		case o.isInstance(FloatType):
+			x := toFloatUnsafe(o).Value()
+			if math.IsInf(x, 0) {
+				return nil, f.RaiseType(OverflowErrorType, "cannot convert float infinity to integer")
+			}
+			if math.IsNaN(x) {
+				return nil, f.RaiseType(ValueErrorType, "cannot convert float NaN to integer")
+			}
+			whole, frac := math.Modf(x)
+			new(big.Float).SetFloat64(whole).Int(&term)
+			if frac != 0 {
+				whole, frac = math.Modf(float64(unit.factor) * frac)
+				sum.Add(&sum, big.NewInt(int64(whole)))
+				leftover += frac
+			}
+		default:
+			s, raised := Repr(f, o.typ.ToObject())
+			if raised != nil {
+				return nil, raised
+			}
+			format := "unsupported type for timedelta %s component: %s"
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, timedeltaParams.params[unit.index].Name, s.Value()))
+		}
+		sum.Add(&sum, term.Mul(&term, big.NewInt(unit.factor)))
+	}
+	if leftover != 0 {
+		sum.Add(&sum, big.NewInt(int64(math.Round(leftover))))
+	}
+	return newTimedeltaFromMicroseconds(f, t, &sum)
+}
+
+func timedeltaNonZero(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toTimedeltaUnsafe(o)
+	return GetBool(d.days != 0 || d.seconds != 0 || d.microseconds != 0).ToObject(), nil
+}
+
+func timedeltaPos(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toTimedeltaUnsafe(o)
+	return newTimedelta(f, timedeltaType, d.days, d.seconds, d.microseconds)
+}
+
+func timedeltaReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, timedeltaType); raised != nil {
+		return nil, raised
+	}
+	d := toTimedeltaUnsafe(args[0])
+	state := NewTuple3(NewInt(d.days).ToObject(), NewInt(d.seconds).ToObject(), NewInt(d.microseconds).ToObject())
+	return NewTuple2(args[0].typ.ToObject(), state.ToObject()).ToObject(), nil
+}
+
+func timedeltaRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toTimedeltaUnsafe(o)
+	name := datetimeClassName(o, timedeltaType)
+	var s string
+	switch {
+	case d.microseconds != 0:
+		s = fmt.Sprintf("%s(%d, %d, %d)", name, d.days, d.seconds, d.microseconds)
+	case d.seconds != 0:
+		s = fmt.Sprintf("%s(%d, %d)", name, d.days, d.seconds)
+	default:
+		s = fmt.Sprintf("%s(%d)", name, d.days)
+	}
+	return NewStr(s).ToObject(), nil
+}
+
+func timedeltaStr(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toTimedeltaUnsafe(o)
+	s := fmt.Sprintf("%d:%02d:%02d", d.seconds/3600, d.seconds/60%60, d.seconds%60)
+	if d.days != 0 {
+		plural := "s"
+		if d.days == 1 || d.days == -1 {
+			plural = ""
+		}
+		s = fmt.Sprintf("%d day%s, ", d.days, plural) + s
+	}
+	if d.microseconds != 0 {
+		s += fmt.Sprintf(".%06d", d.microseconds)
+	}
+	return NewStr(s).ToObject(), nil
+}
+
+func timedeltaSub(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(timedeltaType) {
+		return NotImplemented, nil
+	}
+	x, y := toTimedeltaUnsafe(v), toTimedeltaUnsafe(w)
+	return newTimedelta(f, timedeltaType, x.days-y.days, x.seconds-y.seconds, x.microseconds-y.microseconds)
+}
+
+func timedeltaTotalSeconds(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "total_seconds", args, timedeltaType); raised != nil {
+		return nil, raised
+	}
+	us, _ := new(big.Float).SetInt(toTimedeltaUnsafe(args[0]).microsecondsBig()).Float64()
+	return NewFloat(us / usPerSecond).ToObject(), nil
+}
+
+func initTimedeltaType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("datetime").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", timedeltaReduce).ToObject()
+	dict["days"] = newDatetimeField(timedeltaType, "days", func(o *Object) *Object {
+		return NewInt(toTimedeltaUnsafe(o).days).ToObject()
+	})
+	dict["microseconds"] = newDatetimeField(timedeltaType, "microseconds", func(o *Object) *Object {
+		return NewInt(toTimedeltaUnsafe(o).microseconds).ToObject()
+	})
+	dict["seconds"] = newDatetimeField(timedeltaType, "seconds", func(o *Object) *Object {
+		return NewInt(toTimedeltaUnsafe(o).seconds).ToObject()
+	})
+	dict["total_seconds"] = newBuiltinFunction("total_seconds", timedeltaTotalSeconds).ToObject()
+	dict["max"] = (&timedelta{Object{typ: timedeltaType}, timedeltaMaxDays, secondsPerDay - 1, usPerSecond - 1}).ToObject()
+	dict["min"] = (&timedelta{Object{typ: timedeltaType}, -timedeltaMaxDays, 0, 0}).ToObject()
+	dict["resolution"] = (&timedelta{Object{typ: timedeltaType}, 0, 0, 1}).ToObject()
+	timedeltaType.slots.Abs = &unaryOpSlot{timedeltaAbs}
+	timedeltaType.slots.Add = &binaryOpSlot{timedeltaAdd}
+	timedeltaType.slots.Div = &binaryOpSlot{timedeltaDiv}
+	timedeltaType.slots.FloorDiv = &binaryOpSlot{timedeltaDiv}
+	timedeltaType.slots.Hash = &unaryOpSlot{timedeltaHash}
+	timedeltaType.slots.Mul = &binaryOpSlot{timedeltaMul}
+	timedeltaType.slots.Neg = &unaryOpSlot{timedeltaNeg}
+	timedeltaType.slots.New = &newSlot{timedeltaNew}
+	timedeltaType.slots.NonZero = &unaryOpSlot{timedeltaNonZero}
+	timedeltaType.slots.Pos = &unaryOpSlot{timedeltaPos}
+	timedeltaType.slots.RMul = &binaryOpSlot{timedeltaMul}
+	timedeltaType.slots.Repr = &unaryOpSlot{timedeltaRepr}
+	timedeltaType.slots.Str = &unaryOpSlot{timedeltaStr}
+	timedeltaType.slots.Sub = &binaryOpSlot{timedeltaSub}
+	datetimeCompareSlots(timedeltaType, timedeltaCompare)
+}
+
+// date
+
+// dateMake returns t(year, month, day), creating instances of date itself
+// directly.
+func dateMake(f *Frame, t *Type, year, month, day int) (*Object, *BaseException) {
+	if t != dateType {
+		return t.Call(f, Args{NewInt(year).ToObject(), NewInt(month).ToObject(), NewInt(day).ToObject()}, nil)
+	}
+	if raised := datetimeCheckDate(f, year, month, day); raised != nil {
+		return nil, raised
+	}
+	return newDate(t, year, month, day).ToObject(), nil
+}
+
+// dateAddDays returns the date days after d.
+func dateAddDays(f *Frame, d *date, days int) (*Object, *BaseException) {
+	n := d.ordinal() + days
+	if n < 1 || n > datetimeMaxOrdinal {
+		return nil, f.RaiseType(OverflowErrorType, "date value out of range")
+	}
+	year, month, day := datetimeOrdToYMD(n)
+	return newDate(dateType, year, month, day).ToObject(), nil
+}
+
+func dateAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(timedeltaType) {
+		return NotImplemented, nil
+	}
+	return dateAddDays(f, toDateUnsafe(v), toTimedeltaUnsafe(w).days)
+}
+
+func dateCompare(f *Frame, op compareOp, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(dateType) {
+		if ok, raised := datetimeHasTimetuple(f, w); raised != nil || ok {
+			return NotImplemented, raised
+		}
+		return datetimeMismatchResult(f, op, v, w)
+	}
+	x, y := toDateUnsafe(v), toDateUnsafe(w)
+	return convert3wayToObject(op, datetimeCompareInts([]int{x.year, x.month, x.day}, []int{y.year, y.month, y.day})), nil
+}
+
+func dateCtime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "ctime", args, dateType); raised != nil {
+		return nil, raised
+	}
+	d := toDateUnsafe(args[0])
+	s := fmt.Sprintf("%s %s %2d 00:00:00 %04d", datetimeDayAbbrs[(d.ordinal()+6)%7], datetimeMonthAbbrs[d.month], d.day, d.year)
+	return NewStr(s).ToObject(), nil
+}
+
+func dateFromordinal(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "fromordinal", args, TypeType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	n, raised := datetimeIntField(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if n < 1 || n > datetimeMaxOrdinal {
+		// The conversion would overflow for extreme values so reject
+		// them the same way the constructor does.
+		return nil, f.RaiseType(ValueErrorType, "ordinal must be >= 1")
+	}
+	year, month, day := datetimeOrdToYMD(n)
+	return dateMake(f, toTypeUnsafe(args[0]), year, month, day)
+}
+
+func dateFromtimestamp(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "fromtimestamp", args, TypeType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	secs, raised := timeSecondsFromObject(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	tm := newTimeTM(time.Unix(secs, 0).In(timeCurrentZone().loc))
+	return dateMake(f, toTypeUnsafe(args[0]), tm.year, tm.mon, tm.mday)
+}
+
+func dateHash(f *Frame, o *Object) (*Object, *BaseException) {
+	return NewInt(hashString(toDateUnsafe(o).state())).ToObject(), nil
+}
+
+func dateIsocalendar(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isocalendar", args, dateType); raised != nil {
+		return nil, raised
+	}
+	d := toDateUnsafe(args[0])
+	year, today := d.year, d.ordinal()
+	week1Monday := datetimeISOWeek1Monday(year)
+	week, day := datetimeDivMod(today-week1Monday, 7)
+	if week < 0 {
+		year--
+		week, day = datetimeDivMod(today-datetimeISOWeek1Monday(year), 7)
+	} else if week >= 52 && today >= datetimeISOWeek1Monday(year+1) {
+		year++
+		week = 0
+	}
+	return NewTuple3(NewInt(year).ToObject(), NewInt(week+1).ToObject(), NewInt(day+1).ToObject()).ToObject(), nil
+}
+
+func dateIsoformat(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isoformat", args, dateType); raised != nil {
+		return nil, raised
+	}
+	return dateStr(f, args[0])
+}
+
+func dateIsoweekday(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isoweekday", args, dateType); raised != nil {
+		return nil, raised
+	}
+	return NewInt((toDateUnsafe(args[0]).ordinal()+6)%7 + 1).ToObject(), nil
+}
+
+// dateNew implements date(year, month, day). For pickle support, year may
+// instead be the 4 byte state string produced by __reduce__.
+func dateNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [3]*Object
+	if raised := dateParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	if validated[1] == None && validated[0].isInstance(StrType) {
+		if s := toStrUnsafe(validated[0]).Value(); len(s) == 4 && s[2] >= 1 && s[2] <= 12 {
+			return newDate(t, int(s[0])<<8|int(s[1]), int(s[2]), int(s[3])).ToObject(), nil
+		}
+	}
+	var year, month, day int
+	if raised := datetimeIntFields(f, validated[:], &year, &month, &day); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckDate(f, year, month, day); raised != nil {
+		return nil, raised
+	}
+	return newDate(t, year, month, day).ToObject(), nil
+}
+
+func dateReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, dateType); raised != nil {
+		return nil, raised
+	}
+	state := NewTuple1(NewStr(toDateUnsafe(args[0]).state()).ToObject())
+	return NewTuple2(args[0].typ.ToObject(), state.ToObject()).ToObject(), nil
+}
+
+func dateReplace(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "replace", args, dateType); raised != nil {
+		return nil, raised
+	}
+	d := toDateUnsafe(args[0])
+	var validated [3]*Object
+	if raised := dateReplaceParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	fields := []int{d.year, d.month, d.day}
+	if raised := datetimeReplaceFields(f, validated[:], fields); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckDate(f, fields[0], fields[1], fields[2]); raised != nil {
+		return nil, raised
+	}
+	return newDate(args[0].typ, fields[0], fields[1], fields[2]).ToObject(), nil
+}
+
+// datetimeReplaceFields overwrites fields with the corresponding values that
+// are not None.
+func datetimeReplaceFields(f *Frame, values []*Object, fields []int) *BaseException {
+	for i := range fields {
+		if values[i] != None {
+			v, raised := datetimeIntField(f, values[i])
+			if raised != nil {
+				return raised
+			}
+			fields[i] = v
+		}
+	}
+	return nil
+}
+
+func dateRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toDateUnsafe(o)
+	return NewStr(fmt.Sprintf("%s(%d, %d, %d)", datetimeClassName(o, dateType), d.year, d.month, d.day)).ToObject(), nil
+}
+
+func dateStr(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toDateUnsafe(o)
+	return NewStr(fmt.Sprintf("%04d-%02d-%02d", d.year, d.month, d.day)).ToObject(), nil
+}
+
+func dateStrftime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "strftime", args, dateType, StrType); raised != nil {
+		return nil, raised
+	}
+	tm := toDateUnsafe(args[0]).timeTM(0, 0, 0, -1)
+	return datetimeFormatTM(f, toStrUnsafe(args[1]).Value(), tm, 0, None, None)
+}
+
+func dateSub(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if w.isInstance(dateType) {
+		return newTimedelta(f, timedeltaType, toDateUnsafe(v).ordinal()-toDateUnsafe(w).ordinal(), 0, 0)
+	}
+	if w.isInstance(timedeltaType) {
+		return dateAddDays(f, toDateUnsafe(v), -toTimedeltaUnsafe(w).days)
+	}
+	return NotImplemented, nil
+}
+
+func dateTimetuple(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "timetuple", args, dateType); raised != nil {
+		return nil, raised
+	}
+	return newStructTime(f, toDateUnsafe(args[0]).timeTM(0, 0, 0, -1))
+}
+
+func dateToday(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "today", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	fromtimestamp, raised := GetAttr(f, args[0], NewStr("fromtimestamp"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	return fromtimestamp.Call(f, Args{NewFloat(now).ToObject()}, nil)
+}
+
+func dateToordinal(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "toordinal", args, dateType); raised != nil {
+		return nil, raised
+	}
+	return NewInt(toDateUnsafe(args[0]).ordinal()).ToObject(), nil
+}
+
+func dateWeekday(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "weekday", args, dateType); raised != nil {
+		return nil, raised
+	}
+	return NewInt((toDateUnsafe(args[0]).ordinal() + 6) % 7).ToObject(), nil
+}
+
+func initDateType(dict map[string]*Object) {
+	dict["__format__"] = newBuiltinFunction("__format__", datetimeFormat).ToObject()
+	dict["__module__"] = NewStr("datetime").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", dateReduce).ToObject()
+	dict["ctime"] = newBuiltinFunction("ctime", dateCtime).ToObject()
+	dict["day"] = newDatetimeField(dateType, "day", func(o *Object) *Object {
+		return NewInt(toDateUnsafe(o).day).ToObject()
+	})
+	dict["fromordinal"] = newClassMethod(newBuiltinFunction("fromordinal", dateFromordinal).ToObject()).ToObject()
+	dict["fromtimestamp"] = newClassMethod(newBuiltinFunction("fromtimestamp", dateFromtimestamp).ToObject()).ToObject()
+	dict["isocalendar"] = newBuiltinFunction("isocalendar", dateIsocalendar).ToObject()
+	dict["isoformat"] = newBuiltinFunction("isoformat", dateIsoformat).ToObject()
+	dict["isoweekday"] = newBuiltinFunction("isoweekday", dateIsoweekday).ToObject()
+	dict["month"] = newDatetimeField(dateType, "month", func(o *Object) *Object {
+		return NewInt(toDateUnsafe(o).month).ToObject()
+	})
+	dict["replace"] = newBuiltinFunction("replace", dateReplace).ToObject()
+	dict["strftime"] = newBuiltinFunction("strftime", dateStrftime).ToObject()
+	dict["timetuple"] = newBuiltinFunction("timetuple", dateTimetuple).ToObject()
+	dict["today"] = newClassMethod(newBuiltinFunction("today", dateToday).ToObject()).ToObject()
+	dict["toordinal"] = newBuiltinFunction("toordinal", dateToordinal).ToObject()
+	dict["weekday"] = newBuiltinFunction("weekday", dateWeekday).ToObject()
+	dict["year"] = newDatetimeField(dateType, "year", func(o *Object) *Object {
+		return NewInt(toDateUnsafe(o).year).ToObject()
+	})
+	dict["max"] = newDate(dateType, datetimeMaxYear, 12, 31).ToObject()
+	dict["min"] = newDate(dateType, datetimeMinYear, 1, 1).ToObject()
+	dict["resolution"] = (&timedelta{Object{typ: timedeltaType}, 1, 0, 0}).ToObject()
+	dateType.slots.Add = &binaryOpSlot{dateAdd}
+	dateType.slots.Hash = &unaryOpSlot{dateHash}
+	dateType.slots.New = &newSlot{dateNew}
+	dateType.slots.RAdd = &binaryOpSlot{dateAdd}
+	dateType.slots.Repr = &unaryOpSlot{dateRepr}
+	dateType.slots.Str = &unaryOpSlot{dateStr}
+	dateType.slots.Sub = &binaryOpSlot{dateSub}
+	datetimeCompareSlots(dateType, dateCompare)
+}
+
+// tzinfo
+
+func tzinfoNotImplemented(name string) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, name, args, tzinfoType, ObjectType); raised != nil {
+			return nil, raised
+		}
+		return nil, f.RaiseType(NotImplementedErrorType, fmt.Sprintf("tzinfo subclass must override %s()", name))
+	}
+}
+
+// tzinfoFromutc implements the default tzinfo.fromutc(dt), which converts
+// the UTC time dt to local time for any tzinfo whose standard offset is
+// fixed. See the notes at the end of CPython's datetime.py for the details.
+func tzinfoFromutc(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fromutc", args, tzinfoType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	self, o := args[0], args[1]
+	if !o.isInstance(datetimeType) {
+		return nil, f.RaiseType(TypeErrorType, "fromutc() requires a datetime argument")
+	}
+	dt := toDatetimeUnsafe(o)
+	if dt.tzinfo != self {
+		return nil, f.RaiseType(ValueErrorType, "dt.tzinfo is not self")
+	}
+	dtoff, ok, raised := datetimeUTCOffset(f, self, "utcoffset", o)
+	if raised != nil {
+		return nil, raised
+	}
+	if !ok {
+		return nil, f.RaiseType(ValueErrorType, "fromutc() requires a non-None utcoffset() result")
+	}
+	dtdst, ok, raised := datetimeUTCOffset(f, self, "dst", o)
+	if raised != nil {
+		return nil, raised
+	}
+	if !ok {
+		return nil, f.RaiseType(ValueErrorType, "fromutc() requires a non-None dst() result")
+	}
+	if delta := dtoff - dtdst; delta != 0 {
+		if o, raised = datetimeAddOffset(f, dt, 0, delta*60, 0); raised != nil {
+			return nil, raised
+		}
+		dt = toDatetimeUnsafe(o)
+		if dtdst, ok, raised = datetimeUTCOffset(f, self, "dst", o); raised != nil {
+			return nil, raised
+		}
+		if !ok {
+			return nil, f.RaiseType(ValueErrorType, "fromutc(): dt.dst gave inconsistent results; cannot convert")
+		}
+	}
+	if dtdst != 0 {
+		return datetimeAddOffset(f, dt, 0, dtdst*60, 0)
+	}
+	return o, nil
+}
+
+func tzinfoReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, tzinfoType); raised != nil {
+		return nil, raised
+	}
+	self := args[0]
+	initArgs := NewTuple0().ToObject()
+	getinitargs, raised := GetAttr(f, self, NewStr("__getinitargs__"), None)
+	if raised != nil {
+		return nil, raised
+	}
+	if getinitargs != None {
+		if initArgs, raised = getinitargs.Call(f, nil, nil); raised != nil {
+			return nil, raised
+		}
+	}
+	state := None
+	getstate, raised := GetAttr(f, self, NewStr("__getstate__"), None)
+	if raised != nil {
+		return nil, raised
+	}
+	if getstate != None {
+		if state, raised = getstate.Call(f, nil, nil); raised != nil {
+			return nil, raised
+		}
+	} else if d := self.Dict(); d != nil && d.Len() > 0 {
+		state = d.ToObject()
+	}
+	if state == None {
+		return NewTuple2(self.typ.ToObject(), initArgs).ToObject(), nil
+	}
+	return NewTuple3(self.typ.ToObject(), initArgs, state).ToObject(), nil
+}
+
+func initTzinfoType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("datetime").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", tzinfoReduce).ToObject()
+	dict["dst"] = newBuiltinFunction("dst", tzinfoNotImplemented("dst")).ToObject()
+	dict["fromutc"] = newBuiltinFunction("fromutc", tzinfoFromutc).ToObject()
+	dict["tzname"] = newBuiltinFunction("tzname", tzinfoNotImplemented("tzname")).ToObject()
+	dict["utcoffset"] = newBuiltinFunction("utcoffset", tzinfoNotImplemented("utcoffset")).ToObject()
+}
+
+// time
+
+func timeOfDayCompare(f *Frame, op compareOp, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(timeOfDayType) {
+		return datetimeMismatchResult(f, op, v, w)
+	}
+	x, y := toTimeOfDayUnsafe(v), toTimeOfDayUnsafe(w)
+	xFields := []int{x.hour*60 + x.minute, x.second, x.microsecond}
+	yFields := []int{y.hour*60 + y.minute, y.second, y.microsecond}
+	if x.tzinfo != y.tzinfo {
+		xoff, xok, raised := datetimeUTCOffset(f, x.tzinfo, "utcoffset", None)
+		if raised != nil {
+			return nil, raised
+		}
+		yoff, yok, raised := datetimeUTCOffset(f, y.tzinfo, "utcoffset", None)
+		if raised != nil {
+			return nil, raised
+		}
+		if xok != yok {
+			return nil, f.RaiseType(TypeErrorType, "can't compare offset-naive and offset-aware times")
+		}
+		if xoff != yoff {
+			xFields[0] -= xoff
+			yFields[0] -= yoff
+		}
+	}
+	return convert3wayToObject(op, datetimeCompareInts(xFields, yFields)), nil
+}
+
+func timeOfDayDst(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "dst", args, timeOfDayType); raised != nil {
+		return nil, raised
+	}
+	return datetimeUTCOffsetObject(f, toTimeOfDayUnsafe(args[0]).tzinfo, "dst", None)
+}
+
+func timeOfDayHash(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTimeOfDayUnsafe(o)
+	offset, _, raised := datetimeUTCOffset(f, t.tzinfo, "utcoffset", None)
+	if raised != nil {
+		return nil, raised
+	}
+	if offset == 0 {
+		return NewInt(hashString(t.state())).ToObject(), nil
+	}
+	h, m := datetimeDivMod(t.hour*60+t.minute-offset, 60)
+	if h >= 0 && h < 24 {
+		return NewInt(hashString((&timeOfDay{hour: h, minute: m, second: t.second, microsecond: t.microsecond}).state())).ToObject(), nil
+	}
+	elems := []*Object{NewInt(h).ToObject(), NewInt(m).ToObject(), NewInt(t.second).ToObject(), NewInt(t.microsecond).ToObject()}
+	hash, raised := Hash(f, NewTuple(elems...).ToObject())
+	if raised != nil {
+		return nil, raised
+	}
+	return hash.ToObject(), nil
+}
+
+func timeOfDayIsoformat(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "isoformat", args, timeOfDayType); raised != nil {
+		return nil, raised
+	}
+	if raised := timeOfDayIsoformatParams.Validate(f, nil, args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	return timeOfDayStr(f, args[0])
+}
+
+// timeOfDayNew implements time(hour=0, minute=0, second=0, microsecond=0,
+// tzinfo=None). For pickle support, hour may instead be the 6 byte state
+// string produced by __reduce__ in which case minute is the tzinfo.
+func timeOfDayNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [5]*Object
+	if raised := timeOfDayParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	if validated[0].isInstance(StrType) {
+		if s := toStrUnsafe(validated[0]).Value(); len(s) == 6 && s[0] < 24 {
+			tzinfo := validated[1]
+			if tzinfo == zeroObject {
+				tzinfo = None
+			}
+			if raised := datetimeCheckStateTzinfo(f, tzinfo); raised != nil {
+				return nil, raised
+			}
+			us := int(s[3])<<16 | int(s[4])<<8 | int(s[5])
+			return newTimeOfDay(t, int(s[0]), int(s[1]), int(s[2]), us, tzinfo).ToObject(), nil
+		}
+	}
+	var hour, minute, second, microsecond int
+	if raised := datetimeIntFields(f, validated[:], &hour, &minute, &second, &microsecond); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTime(f, hour, minute, second, microsecond); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTzinfo(f, validated[4]); raised != nil {
+		return nil, raised
+	}
+	return newTimeOfDay(t, hour, minute, second, microsecond, validated[4]).ToObject(), nil
+}
+
+func timeOfDayNonZero(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTimeOfDayUnsafe(o)
+	if t.second != 0 || t.microsecond != 0 {
+		return True.ToObject(), nil
+	}
+	offset, _, raised := datetimeUTCOffset(f, t.tzinfo, "utcoffset", None)
+	if raised != nil {
+		return nil, raised
+	}
+	return GetBool(t.hour*60+t.minute != offset).ToObject(), nil
+}
+
+func timeOfDayReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, timeOfDayType); raised != nil {
+		return nil, raised
+	}
+	t := toTimeOfDayUnsafe(args[0])
+	state := NewTuple1(NewStr(t.state()).ToObject())
+	if t.tzinfo != None {
+		state = NewTuple2(NewStr(t.state()).ToObject(), t.tzinfo)
+	}
+	return NewTuple2(timeOfDayType.ToObject(), state.ToObject()).ToObject(), nil
+}
+
+func timeOfDayReplace(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "replace", args, timeOfDayType); raised != nil {
+		return nil, raised
+	}
+	t := toTimeOfDayUnsafe(args[0])
+	var validated [5]*Object
+	if raised := timeOfDayReplaceParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	fields := []int{t.hour, t.minute, t.second, t.microsecond}
+	if raised := datetimeReplaceFields(f, validated[:], fields); raised != nil {
+		return nil, raised
+	}
+	tzinfo := validated[4]
+	if tzinfo == True.ToObject() {
+		tzinfo = t.tzinfo
+	}
+	if raised := datetimeCheckTime(f, fields[0], fields[1], fields[2], fields[3]); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTzinfo(f, tzinfo); raised != nil {
+		return nil, raised
+	}
+	return newTimeOfDay(args[0].typ, fields[0], fields[1], fields[2], fields[3], tzinfo).ToObject(), nil
+}
+
+func timeOfDayRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTimeOfDayUnsafe(o)
+	s := fmt.Sprintf("%s(%d, %d", datetimeClassName(o, timeOfDayType), t.hour, t.minute)
+	if t.microsecond != 0 {
+		s += fmt.Sprintf(", %d, %d", t.second, t.microsecond)
+	} else if t.second != 0 {
+		s += fmt.Sprintf(", %d", t.second)
+	}
+	return datetimeReprTzinfo(f, s, t.tzinfo)
+}
+
+// datetimeReprTzinfo completes the repr s of a time or datetime object by
+// appending its tzinfo if it has one.
+func datetimeReprTzinfo(f *Frame, s string, tzinfo *Object) (*Object, *BaseException) {
+	if tzinfo != None {
+		r, raised := Repr(f, tzinfo)
+		if raised != nil {
+			return nil, raised
+		}
+		s += ", tzinfo=" + r.Value()
+	}
+	return NewStr(s + ")").ToObject(), nil
+}
+
+func timeOfDayStr(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTimeOfDayUnsafe(o)
+	s := datetimeFormatTime(t.hour, t.minute, t.second, t.microsecond)
+	offset, ok, raised := datetimeUTCOffset(f, t.tzinfo, "utcoffset", None)
+	if raised != nil {
+		return nil, raised
+	}
+	if ok {
+		s += datetimeFormatOffset(offset, ":")
+	}
+	return NewStr(s).ToObject(), nil
+}
+
+func timeOfDayStrftime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "strftime", args, timeOfDayType, StrType); raised != nil {
+		return nil, raised
+	}
+	t := toTimeOfDayUnsafe(args[0])
+	tm := timeTM{1900, 1, 1, t.hour, t.minute, t.second, 0, 1, -1}
+	return datetimeFormatTM(f, toStrUnsafe(args[1]).Value(), tm, t.microsecond, t.tzinfo, None)
+}
+
+func timeOfDayTzname(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tzname", args, timeOfDayType); raised != nil {
+		return nil, raised
+	}
+	return datetimeTZName(f, toTimeOfDayUnsafe(args[0]).tzinfo, None)
+}
+
+func timeOfDayUtcoffset(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "utcoffset", args, timeOfDayType); raised != nil {
+		return nil, raised
+	}
+	return datetimeUTCOffsetObject(f, toTimeOfDayUnsafe(args[0]).tzinfo, "utcoffset", None)
+}
+
+func initTimeOfDayType(dict map[string]*Object) {
+	dict["__format__"] = newBuiltinFunction("__format__", datetimeFormat).ToObject()
+	dict["__module__"] = NewStr("datetime").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", timeOfDayReduce).ToObject()
+	dict["dst"] = newBuiltinFunction("dst", timeOfDayDst).ToObject()
+	dict["hour"] = newDatetimeField(timeOfDayType, "hour", func(o *Object) *Object {
+		return NewInt(toTimeOfDayUnsafe(o).hour).ToObject()
+	})
+	dict["isoformat"] = newBuiltinFunction("isoformat", timeOfDayIsoformat).ToObject()
+	dict["microsecond"] = newDatetimeField(timeOfDayType, "microsecond", func(o *Object) *Object {
+		return NewInt(toTimeOfDayUnsafe(o).microsecond).ToObject()
+	})
+	dict["minute"] = newDatetimeField(timeOfDayType, "minute", func(o *Object) *Object {
+		return NewInt(toTimeOfDayUnsafe(o).minute).ToObject()
+	})
+	dict["replace"] = newBuiltinFunction("replace", timeOfDayReplace).ToObject()
+	dict["second"] = newDatetimeField(timeOfDayType, "second", func(o *Object) *Object {
+		return NewInt(toTimeOfDayUnsafe(o).second).ToObject()
+	})
+	dict["strftime"] = newBuiltinFunction("strftime", timeOfDayStrftime).ToObject()
+	dict["tzinfo"] = newDatetimeField(timeOfDayType, "tzinfo", func(o *Object) *Object {
+		return toTimeOfDayUnsafe(o).tzinfo
+	})
+	dict["tzname"] = newBuiltinFunction("tzname", timeOfDayTzname).ToObject()
+	dict["utcoffset"] = newBuiltinFunction("utcoffset", timeOfDayUtcoffset).ToObject()
+	dict["max"] = newTimeOfDay(timeOfDayType, 23, 59, 59, 999999, None).ToObject()
+	dict["min"] = newTimeOfDay(timeOfDayType, 0, 0, 0, 0, None).ToObject()
+	dict["resolution"] = (&timedelta{Object{typ: timedeltaType}, 0, 0, 1}).ToObject()
+	timeOfDayType.slots.Hash = &unaryOpSlot{timeOfDayHash}
+	timeOfDayType.slots.New = &newSlot{timeOfDayNew}
+	timeOfDayType.slots.NonZero = &unaryOpSlot{timeOfDayNonZero}
+	timeOfDayType.slots.Repr = &unaryOpSlot{timeOfDayRepr}
+	timeOfDayType.slots.Str = &unaryOpSlot{timeOfDayStr}
+	datetimeCompareSlots(timeOfDayType, timeOfDayCompare)
+}
+
+// datetime
+
+// datetimeMake returns t(year, month, day, hour, minute, second,
+// microsecond, tzinfo), creating instances of datetime itself directly.
+func datetimeMake(f *Frame, t *Type, year, month, day, hour, minute, second, microsecond int, tzinfo *Object) (*Object, *BaseException) {
+	if t != datetimeType {
+		args := Args{
+			NewInt(year).ToObject(), NewInt(month).ToObject(), NewInt(day).ToObject(),
+			NewInt(hour).ToObject(), NewInt(minute).ToObject(), NewInt(second).ToObject(),
+			NewInt(microsecond).ToObject(), tzinfo,
+		}
+		return t.Call(f, args, nil)
+	}
+	if raised := datetimeCheckDate(f, year, month, day); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTime(f, hour, minute, second, microsecond); raised != nil {
+		return nil, raised
+	}
+	return newDatetime(t, year, month, day, hour, minute, second, microsecond, tzinfo).ToObject(), nil
+}
+
+// datetimeFromTime returns a datetime of type t holding the wall time of tm
+// and tzinfo. When tzinfo is not None tm must be in UTC and the result is
+// converted to tzinfo's local time with its fromutc method.
+func datetimeFromTime(f *Frame, t *Type, tm time.Time, tzinfo *Object) (*Object, *BaseException) {
+	// Clamp leap seconds.
+	second := tm.Second()
+	if second > 59 {
+		second = 59
+	}
+	o, raised := datetimeMake(f, t, tm.Year(), int(tm.Month()), tm.Day(), tm.Hour(), tm.Minute(), second, tm.Nanosecond()/1000, tzinfo)
+	if raised != nil || tzinfo == None {
+		return o, raised
+	}
+	fromutc, raised := GetAttr(f, tzinfo, NewStr("fromutc"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return fromutc.Call(f, Args{o}, nil)
+}
+
+// datetimeTimestamp converts the POSIX timestamp o to a time in loc. The
+// fractional part is rounded to the nearest microsecond.
+func datetimeTimestamp(f *Frame, o *Object, loc *time.Location) (time.Time, *BaseException) {
+	ts, ok := floatCoerce(o)
+	if !ok {
+		return time.Time{}, f.RaiseType(TypeErrorType, "a float is required")
+	}
+	secs := math.Floor(ts)
+	us := math.Floor((ts-secs)*1e6 + 0.5)
+	if us == 1e6 {
+		secs++
+		us = 0
+	}
+	// Limit the seconds to roughly the range of years Go can represent.
+	if math.IsNaN(secs) || math.Abs(secs) > 1<<60 {
+		return time.Time{}, f.RaiseType(ValueErrorType, "timestamp out of range for platform time_t")
+	}
+	return time.Unix(int64(secs), int64(us)*1000).In(loc), nil
+}
+
+// datetimeAddOffset returns d advanced by the given amounts, which need not
+// be normalized. The result is always a datetime rather than a subclass.
+func datetimeAddOffset(f *Frame, d *datetime, days, seconds, microseconds int) (*Object, *BaseException) {
+	q, us := datetimeDivMod(d.microsecond+microseconds, usPerSecond)
+	q, secs := datetimeDivMod(d.daySeconds()+seconds+q, secondsPerDay)
+	n := d.ordinal() + days + q
+	if n < 1 || n > datetimeMaxOrdinal {
+		return nil, f.RaiseType(OverflowErrorType, "date value out of range")
+	}
+	year, month, day := datetimeOrdToYMD(n)
+	return newDatetime(datetimeType, year, month, day, secs/3600, secs/60%60, secs%60, us, d.tzinfo).ToObject(), nil
+}
+
+func datetimeAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(timedeltaType) {
+		return NotImplemented, nil
+	}
+	d := toTimedeltaUnsafe(w)
+	return datetimeAddOffset(f, toDatetimeUnsafe(v), d.days, d.seconds, d.microseconds)
+}
+
+func datetimeAstimezone(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "astimezone", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	var validated [1]*Object
+	if raised := datetimeAstimezoneParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	self, tz := args[0], validated[0]
+	if !tz.isInstance(tzinfoType) {
+		return nil, f.RaiseType(TypeErrorType, "tz argument must be an instance of tzinfo")
+	}
+	d := toDatetimeUnsafe(self)
+	if d.tzinfo == None {
+		return nil, f.RaiseType(ValueErrorType, "astimezone() requires an aware datetime")
+	}
+	if tz == d.tzinfo {
+		return self, nil
+	}
+	offset, ok, raised := datetimeUTCOffset(f, d.tzinfo, "utcoffset", self)
+	if raised != nil {
+		return nil, raised
+	}
+	if !ok {
+		return nil, f.RaiseType(ValueErrorType, "astimezone() requires an aware datetime")
+	}
+	utc, raised := datetimeAddOffset(f, d, 0, -offset*60, 0)
+	if raised != nil {
+		return nil, raised
+	}
+	toDatetimeUnsafe(utc).tzinfo = tz
+	fromutc, raised := GetAttr(f, tz, NewStr("fromutc"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return fromutc.Call(f, Args{utc}, nil)
+}
+
+func datetimeCombine(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionVarArgs(f, "combine", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	var validated [2]*Object
+	if raised := datetimeCombineParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	if !validated[0].isInstance(dateType) {
+		return nil, f.RaiseType(TypeErrorType, "date argument must be a date instance")
+	}
+	if !validated[1].isInstance(timeOfDayType) {
+		return nil, f.RaiseType(TypeErrorType, "time argument must be a time instance")
+	}
+	d, t := toDateUnsafe(validated[0]), toTimeOfDayUnsafe(validated[1])
+	return datetimeMake(f, toTypeUnsafe(args[0]), d.year, d.month, d.day, t.hour, t.minute, t.second, t.microsecond, t.tzinfo)
+}
+
+// datetimeCompare compares datetimes, taking their UTC offsets into account
+// when their tzinfos differ.
+func datetimeCompare(f *Frame, op compareOp, v, w *Object) (*Object, *BaseException) {
+	if !w.isInstance(datetimeType) {
+		if !w.isInstance(dateType) {
+			if ok, raised := datetimeHasTimetuple(f, w); raised != nil || ok {
+				return NotImplemented, raised
+			}
+		}
+		return datetimeMismatchResult(f, op, v, w)
+	}
+	x, y := toDatetimeUnsafe(v), toDatetimeUnsafe(w)
+	xFields := []int{x.ordinal()*secondsPerDay + x.daySeconds(), x.microsecond}
+	yFields := []int{y.ordinal()*secondsPerDay + y.daySeconds(), y.microsecond}
+	if x.tzinfo != y.tzinfo {
+		xoff, xok, raised := datetimeUTCOffset(f, x.tzinfo, "utcoffset", v)
+		if raised != nil {
+			return nil, raised
+		}
+		yoff, yok, raised := datetimeUTCOffset(f, y.tzinfo, "utcoffset", w)
+		if raised != nil {
+			return nil, raised
+		}
+		if xok != yok {
+			return nil, f.RaiseType(TypeErrorType, "can't compare offset-naive and offset-aware datetimes")
+		}
+		xFields[0] -= xoff * 60
+		yFields[0] -= yoff * 60
+	}
+	return convert3wayToObject(op, datetimeCompareInts(xFields, yFields)), nil
+}
+
+func datetimeCtime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "ctime", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	s := fmt.Sprintf("%s %s %2d %02d:%02d:%02d %04d", datetimeDayAbbrs[(d.ordinal()+6)%7], datetimeMonthAbbrs[d.month], d.day, d.hour, d.minute, d.second, d.year)
+	return NewStr(s).ToObject(), nil
+}
+
+func datetimeDate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "date", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	return newDate(dateType, d.year, d.month, d.day).ToObject(), nil
+}
+
+func datetimeDst(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "dst", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	return datetimeUTCOffsetObject(f, toDatetimeUnsafe(args[0]).tzinfo, "dst", args[0])
+}
+
+func datetimeFromtimestamp(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionVarArgs(f, "fromtimestamp", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	var validated [2]*Object
+	if raised := datetimeFromtimestampParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	tz := validated[1]
+	if raised := datetimeCheckTzinfo(f, tz); raised != nil {
+		return nil, raised
+	}
+	loc := time.UTC
+	if tz == None {
+		loc = timeCurrentZone().loc
+	}
+	tm, raised := datetimeTimestamp(f, validated[0], loc)
+	if raised != nil {
+		return nil, raised
+	}
+	return datetimeFromTime(f, toTypeUnsafe(args[0]), tm, tz)
+}
+
+func datetimeHash(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toDatetimeUnsafe(o)
+	offset, ok, raised := datetimeUTCOffset(f, d.tzinfo, "utcoffset", o)
+	if raised != nil {
+		return nil, raised
+	}
+	if !ok {
+		return NewInt(hashString(d.state())).ToObject(), nil
+	}
+	delta, raised := newTimedelta(f, timedeltaType, d.ordinal(), d.daySeconds()-offset*60, d.microsecond)
+	if raised != nil {
+		return nil, raised
+	}
+	return timedeltaHash(f, delta)
+}
+
+func datetimeIsoformat(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "isoformat", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	var validated [1]*Object
+	if raised := datetimeIsoformatParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	if !validated[0].isInstance(StrType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("isoformat() argument 1 must be char, not %s", validated[0].typ.Name()))
+	}
+	sep := toStrUnsafe(validated[0]).Value()
+	if len(sep) != 1 {
+		return nil, f.RaiseType(TypeErrorType, "isoformat() argument 1 must be char, not str")
+	}
+	return datetimeToString(f, args[0], sep)
+}
+
+// datetimeToString formats o in ISO 8601 format with sep between the date
+// and the time.
+func datetimeToString(f *Frame, o *Object, sep string) (*Object, *BaseException) {
+	d := toDatetimeUnsafe(o)
+	s := fmt.Sprintf("%04d-%02d-%02d%s", d.year, d.month, d.day, sep) + datetimeFormatTime(d.hour, d.minute, d.second, d.microsecond)
+	offset, ok, raised := datetimeUTCOffset(f, d.tzinfo, "utcoffset", o)
+	if raised != nil {
+		return nil, raised
+	}
+	if ok {
+		s += datetimeFormatOffset(offset, ":")
+	}
+	return NewStr(s).ToObject(), nil
+}
+
+// datetimeNew implements datetime(year, month, day, hour=0, minute=0,
+// second=0, microsecond=0, tzinfo=None). For pickle support, year may
+// instead be the 10 byte state string produced by __reduce__ in which case
+// month is the tzinfo.
+func datetimeNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [8]*Object
+	if raised := datetimeParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	if validated[0].isInstance(StrType) {
+		if s := toStrUnsafe(validated[0]).Value(); len(s) == 10 && s[2] >= 1 && s[2] <= 12 {
+			tzinfo := validated[1]
+			if raised := datetimeCheckStateTzinfo(f, tzinfo); raised != nil {
+				return nil, raised
+			}
+			us := int(s[7])<<16 | int(s[8])<<8 | int(s[9])
+			return newDatetime(t, int(s[0])<<8|int(s[1]), int(s[2]), int(s[3]), int(s[4]), int(s[5]), int(s[6]), us, tzinfo).ToObject(), nil
+		}
+	}
+	var year, month, day, hour, minute, second, microsecond int
+	if raised := datetimeIntFields(f, validated[:], &year, &month, &day, &hour, &minute, &second, &microsecond); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckDate(f, year, month, day); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTime(f, hour, minute, second, microsecond); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTzinfo(f, validated[7]); raised != nil {
+		return nil, raised
+	}
+	return newDatetime(t, year, month, day, hour, minute, second, microsecond, validated[7]).ToObject(), nil
+}
+
+func datetimeNow(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionVarArgs(f, "now", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	var validated [1]*Object
+	if raised := datetimeNowParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	tz := validated[0]
+	if raised := datetimeCheckTzinfo(f, tz); raised != nil {
+		return nil, raised
+	}
+	loc := time.UTC
+	if tz == None {
+		loc = timeCurrentZone().loc
+	}
+	return datetimeFromTime(f, toTypeUnsafe(args[0]), time.Now().In(loc), tz)
+}
+
+func datetimeReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	state := NewTuple1(NewStr(d.state()).ToObject())
+	if d.tzinfo != None {
+		state = NewTuple2(NewStr(d.state()).ToObject(), d.tzinfo)
+	}
+	return NewTuple2(args[0].typ.ToObject(), state.ToObject()).ToObject(), nil
+}
+
+func datetimeReplace(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "replace", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	var validated [8]*Object
+	if raised := datetimeReplaceParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	fields := []int{d.year, d.month, d.day, d.hour, d.minute, d.second, d.microsecond}
+	if raised := datetimeReplaceFields(f, validated[:], fields); raised != nil {
+		return nil, raised
+	}
+	tzinfo := validated[7]
+	if tzinfo == True.ToObject() {
+		tzinfo = d.tzinfo
+	}
+	if raised := datetimeCheckDate(f, fields[0], fields[1], fields[2]); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTime(f, fields[3], fields[4], fields[5], fields[6]); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeCheckTzinfo(f, tzinfo); raised != nil {
+		return nil, raised
+	}
+	return newDatetime(args[0].typ, fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6], tzinfo).ToObject(), nil
+}
+
+func datetimeRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toDatetimeUnsafe(o)
+	fields := []int{d.year, d.month, d.day, d.hour, d.minute, d.second, d.microsecond}
+	n := len(fields)
+	for i := 0; i < 2 && fields[n-1] == 0; i++ {
+		n--
+	}
+	parts := make([]string, n)
+	for i, v := range fields[:n] {
+		parts[i] = fmt.Sprint(v)
+	}
+	return datetimeReprTzinfo(f, datetimeClassName(o, datetimeType)+"("+strings.Join(parts, ", "), d.tzinfo)
+}
+
+func datetimeStr(f *Frame, o *Object) (*Object, *BaseException) {
+	return datetimeToString(f, o, " ")
+}
+
+func datetimeStrftime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "strftime", args, datetimeType, StrType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	tm, raised := datetimeLocalTM(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	return datetimeFormatTM(f, toStrUnsafe(args[1]).Value(), tm, d.microsecond, d.tzinfo, args[0])
+}
+
+// datetimeLocalTM returns the fields of o's timetuple().
+func datetimeLocalTM(f *Frame, o *Object) (timeTM, *BaseException) {
+	d := toDatetimeUnsafe(o)
+	dst, ok, raised := datetimeUTCOffset(f, d.tzinfo, "dst", o)
+	if raised != nil {
+		return timeTM{}, raised
+	}
+	isdst := -1
+	if ok {
+		isdst = 0
+		if dst != 0 {
+			isdst = 1
+		}
+	}
+	return d.timeTM(d.hour, d.minute, d.second, isdst), nil
+}
+
+func datetimeStrptime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "strptime", args, TypeType, StrType, StrType); raised != nil {
+		return nil, raised
+	}
+	tm, microsecond, raised := timeParse(f, toStrUnsafe(args[1]).Value(), toStrUnsafe(args[2]).Value())
+	if raised != nil {
+		return nil, raised
+	}
+	return datetimeMake(f, toTypeUnsafe(args[0]), tm.year, tm.mon, tm.mday, tm.hour, tm.min, tm.sec, microsecond, None)
+}
+
+func datetimeSub(f *Frame, v, w *Object) (*Object, *BaseException) {
+	x := toDatetimeUnsafe(v)
+	if !w.isInstance(datetimeType) {
+		if w.isInstance(timedeltaType) {
+			d := toTimedeltaUnsafe(w)
+			return datetimeAddOffset(f, x, -d.days, -d.seconds, -d.microseconds)
+		}
+		return NotImplemented, nil
+	}
+	y := toDatetimeUnsafe(w)
+	days := x.ordinal() - y.ordinal()
+	seconds := x.daySeconds() - y.daySeconds()
+	if x.tzinfo != y.tzinfo {
+		xoff, xok, raised := datetimeUTCOffset(f, x.tzinfo, "utcoffset", v)
+		if raised != nil {
+			return nil, raised
+		}
+		yoff, yok, raised := datetimeUTCOffset(f, y.tzinfo, "utcoffset", w)
+		if raised != nil {
+			return nil, raised
+		}
+		if xok != yok {
+			return nil, f.RaiseType(TypeErrorType, "can't subtract offset-naive and offset-aware datetimes")
+		}
+		seconds += (yoff - xoff) * 60
+	}
+	return newTimedelta(f, timedeltaType, days, seconds, x.microsecond-y.microsecond)
+}
+
+func datetimeTime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "time", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	return newTimeOfDay(timeOfDayType, d.hour, d.minute, d.second, d.microsecond, None).ToObject(), nil
+}
+
+func datetimeTimetuple(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "timetuple", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	tm, raised := datetimeLocalTM(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	return newStructTime(f, tm)
+}
+
+func datetimeTimetz(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "timetz", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	return newTimeOfDay(timeOfDayType, d.hour, d.minute, d.second, d.microsecond, d.tzinfo).ToObject(), nil
+}
+
+func datetimeTzname(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tzname", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	return datetimeTZName(f, toDatetimeUnsafe(args[0]).tzinfo, args[0])
+}
+
+func datetimeUtcfromtimestamp(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionVarArgs(f, "utcfromtimestamp", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	var validated [1]*Object
+	if raised := datetimeUtcfromtimestampParams.Validate(f, validated[:], args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	tm, raised := datetimeTimestamp(f, validated[0], time.UTC)
+	if raised != nil {
+		return nil, raised
+	}
+	return datetimeFromTime(f, toTypeUnsafe(args[0]), tm, None)
+}
+
+func datetimeUtcnow(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionVarArgs(f, "utcnow", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	if raised := datetimeUtcnowParams.Validate(f, nil, args[1:], kwargs); raised != nil {
+		return nil, raised
+	}
+	return datetimeFromTime(f, toTypeUnsafe(args[0]), time.Now().UTC(), None)
+}
+
+func datetimeUtcoffset(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "utcoffset", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	return datetimeUTCOffsetObject(f, toDatetimeUnsafe(args[0]).tzinfo, "utcoffset", args[0])
+}
+
+func datetimeUtctimetuple(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "utctimetuple", args, datetimeType); raised != nil {
+		return nil, raised
+	}
+	d := toDatetimeUnsafe(args[0])
+	offset, _, raised := datetimeUTCOffset(f, d.tzinfo, "utcoffset", args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	// The result may fall just outside the range of datetime so work with
+	// the raw ordinal rather than datetimeAddOffset.
+	q, secs := datetimeDivMod(d.daySeconds()-offset*60, secondsPerDay)
+	year, month, day := datetimeOrdToYMD(d.ordinal() + q)
+	u := date{year: year, month: month, day: day}
+	return newStructTime(f, u.timeTM(secs/3600, secs/60%60, secs%60, 0))
+}
+
+func initDatetimeType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("datetime").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", datetimeReduce).ToObject()
+	dict["astimezone"] = newBuiltinFunction("astimezone", datetimeAstimezone).ToObject()
+	dict["combine"] = newClassMethod(newBuiltinFunction("combine", datetimeCombine).ToObject()).ToObject()
+	dict["ctime"] = newBuiltinFunction("ctime", datetimeCtime).ToObject()
+	dict["date"] = newBuiltinFunction("date", datetimeDate).ToObject()
+	dict["dst"] = newBuiltinFunction("dst", datetimeDst).ToObject()
+	dict["fromtimestamp"] = newClassMethod(newBuiltinFunction("fromtimestamp", datetimeFromtimestamp).ToObject()).ToObject()
+	dict["hour"] = newDatetimeField(datetimeType, "hour", func(o *Object) *Object {
+		return NewInt(toDatetimeUnsafe(o).hour).ToObject()
+	})
+	dict["isoformat"] = newBuiltinFunction("isoformat", datetimeIsoformat).ToObject()
+	dict["microsecond"] = newDatetimeField(datetimeType, "microsecond", func(o *Object) *Object {
+		return NewInt(toDatetimeUnsafe(o).microsecond).ToObject()
+	})
+	dict["minute"] = newDatetimeField(datetimeType, "minute", func(o *Object) *Object {
+		return NewInt(toDatetimeUnsafe(o).minute).ToObject()
+	})
+	dict["now"] = newClassMethod(newBuiltinFunction("now", datetimeNow).ToObject()).ToObject()
+	dict["replace"] = newBuiltinFunction("replace", datetimeReplace).ToObject()
+	dict["second"] = newDatetimeField(datetimeType, "second", func(o *Object) *Object {
+		return NewInt(toDatetimeUnsafe(o).second).ToObject()
+	})
+	dict["strftime"] = newBuiltinFunction("strftime", datetimeStrftime).ToObject()
+	dict["strptime"] = newClassMethod(newBuiltinFunction("strptime", datetimeStrptime).ToObject()).ToObject()
+	dict["time"] = newBuiltinFunction("time", datetimeTime).ToObject()
+	dict["timetuple"] = newBuiltinFunction("timetuple", datetimeTimetuple).ToObject()
+	dict["timetz"] = newBuiltinFunction("timetz", datetimeTimetz).ToObject()
+	dict["tzinfo"] = newDatetimeField(datetimeType, "tzinfo", func(o *Object) *Object {
+		return toDatetimeUnsafe(o).tzinfo
+	})
+	dict["tzname"] = newBuiltinFunction("tzname", datetimeTzname).ToObject()
+	dict["utcfromtimestamp"] = newClassMethod(newBuiltinFunction("utcfromtimestamp", datetimeUtcfromtimestamp).ToObject()).ToObject()
+	dict["utcnow"] = newClassMethod(newBuiltinFunction("utcnow", datetimeUtcnow).ToObject()).ToObject()
+	dict["utcoffset"] = newBuiltinFunction("utcoffset", datetimeUtcoffset).ToObject()
+	dict["utctimetuple"] = newBuiltinFunction("utctimetuple", datetimeUtctimetuple).ToObject()
+	dict["max"] = newDatetime(datetimeType, datetimeMaxYear, 12, 31, 23, 59, 59, 999999, None).ToObject()
+	dict["min"] = newDatetime(datetimeType, datetimeMinYear, 1, 1, 0, 0, 0, 0, None).ToObject()
+	dict["resolution"] = (&timedelta{Object{typ: timedeltaType}, 0, 0, 1}).ToObject()
+	datetimeType.slots.Add = &binaryOpSlot{datetimeAdd}
+	datetimeType.slots.Hash = &unaryOpSlot{datetimeHash}
+	datetimeType.slots.New = &newSlot{datetimeNew}
+	datetimeType.slots.RAdd = &binaryOpSlot{datetimeAdd}
+	datetimeType.slots.Repr = &unaryOpSlot{datetimeRepr}
+	datetimeType.slots.Str = &unaryOpSlot{datetimeStr}
+	datetimeType.slots.Sub = &binaryOpSlot{datetimeSub}
+	datetimeCompareSlots(datetimeType, datetimeCompare)
+}
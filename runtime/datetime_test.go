@@ -0,0 +1,179 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestDatetimeOrdinals(t *testing.T) {
+	for _, n := range []int{1, 59, 60, 365, 366, 730120, 730179, 146097, 146098, datetimeMaxOrdinal} {
+		year, month, day := datetimeOrdToYMD(n)
+		if got := datetimeYMDToOrd(year, month, day); got != n {
+			t.Errorf("datetimeYMDToOrd(datetimeOrdToYMD(%d)) = %d", n, got)
+		}
+	}
+	if year, month, day := datetimeOrdToYMD(730179); year != 2000 || month != 2 || day != 29 {
+		t.Errorf("datetimeOrdToYMD(730179) = %d-%d-%d, want 2000-2-29", year, month, day)
+	}
+}
+
+func TestTimedeltaNew(t *testing.T) {
+	cases := []invokeTestCase{
+		{want: newTestTimedelta(0, 0, 0)},
+		{args: wrapArgs(1, 2, 3), want: newTestTimedelta(1, 2, 3)},
+		{args: wrapArgs(0, -1), want: newTestTimedelta(-1, 86399, 0)},
+		{args: wrapArgs(1.5), want: newTestTimedelta(1, 43200, 0)},
+		{args: wrapArgs(0, 0, 0.5), want: newTestTimedelta(0, 0, 1)},
+		{args: wrapArgs(0, 0, 1.5), want: newTestTimedelta(0, 0, 2)},
+		{args: wrapArgs(0, 0, -1.5), want: newTestTimedelta(-1, 86399, 999998)},
+		{args: wrapArgs(), kwargs: wrapKWArgs("weeks", 1, "hours", 1, "minutes", 1, "milliseconds", 1), want: newTestTimedelta(7, 3660, 1000)},
+		{args: wrapArgs(1000000000), wantExc: mustCreateException(OverflowErrorType, "days=1000000000; must have magnitude <= 999999999")},
+		{args: wrapArgs("1"), wantExc: mustCreateException(TypeErrorType, "unsupported type for timedelta days component: <type 'str'>")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(timedeltaType.ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTimedeltaRepr(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestTimedelta(1, 0, 0)), want: NewStr("datetime.timedelta(1) 1 day, 0:00:00").ToObject()},
+		{args: wrapArgs(newTestTimedelta(-2, 3661, 0)), want: NewStr("datetime.timedelta(-2, 3661) -2 days, 1:01:01").ToObject()},
+		{args: wrapArgs(newTestTimedelta(0, 5, 10)), want: NewStr("datetime.timedelta(0, 5, 10) 0:00:05.000010").ToObject()},
+	}
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+		r, raised := Repr(f, o)
+		if raised != nil {
+			return nil, raised
+		}
+		s, raised := ToStr(f, o)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewStr(r.Value() + " " + s.Value()).ToObject(), nil
+	})
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDateNew(t *testing.T) {
+	f := NewRootFrame()
+	cases := []invokeTestCase{
+		{args: wrapArgs(2000, 2, 29), want: newDate(dateType, 2000, 2, 29).ToObject()},
+		{args: wrapArgs("\x07\xd0\x02\x1d"), want: newDate(dateType, 2000, 2, 29).ToObject()},
+		{args: wrapArgs(1999, 2, 29), wantExc: toBaseExceptionUnsafe(mustNotRaise(ValueErrorType.Call(f, wrapArgs("day must be in 1..28", 29), nil)))},
+		{args: wrapArgs(0, 1, 1), wantExc: toBaseExceptionUnsafe(mustNotRaise(ValueErrorType.Call(f, wrapArgs("year must be in 1..9999", 0), nil)))},
+		{args: wrapArgs(2000, 1.0, 1), wantExc: mustCreateException(TypeErrorType, "integer argument expected, got float")},
+		{args: wrapArgs(2000, "1", 1), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(dateType.ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDatetimeArithmetic(t *testing.T) {
+	dt := newDatetime(datetimeType, 2000, 12, 31, 23, 59, 59, 999999, None).ToObject()
+	cases := []invokeTestCase{
+		{args: wrapArgs(dt, newTestTimedelta(0, 0, 1)), want: newDatetime(datetimeType, 2001, 1, 1, 0, 0, 0, 0, None).ToObject()},
+		{args: wrapArgs(newTestTimedelta(-366, 0, 0), dt), want: newDatetime(datetimeType, 1999, 12, 31, 23, 59, 59, 999999, None).ToObject()},
+		{args: wrapArgs(newDate(dateType, 2000, 2, 28), newTestTimedelta(1, 86399, 0)), want: newDate(dateType, 2000, 2, 29).ToObject()},
+		{args: wrapArgs(newDate(dateType, 9999, 12, 31), newTestTimedelta(1, 0, 0)), wantExc: mustCreateException(OverflowErrorType, "date value out of range")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(Add), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	cases = []invokeTestCase{
+		{args: wrapArgs(dt, newDatetime(datetimeType, 2000, 1, 1, 0, 0, 0, 0, None)), want: newTestTimedelta(365, 86399, 999999)},
+		{args: wrapArgs(newDate(dateType, 2000, 1, 1), newDate(dateType, 2000, 3, 1)), want: newTestTimedelta(-60, 0, 0)},
+		{args: wrapArgs(dt, newDate(dateType, 2000, 1, 1)), wantExc: mustCreateException(TypeErrorType, "unsupported operand type(s) for -: 'datetime' and 'date'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(Sub), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDatetimeCompare(t *testing.T) {
+	d := newDate(dateType, 2000, 1, 1).ToObject()
+	dt := newDatetime(datetimeType, 2000, 1, 1, 0, 0, 0, 0, None).ToObject()
+	cases := []invokeTestCase{
+		{args: wrapArgs(d, newDate(dateType, 2000, 1, 2)), want: True.ToObject()},
+		{args: wrapArgs(dt, newDatetime(datetimeType, 2000, 1, 1, 0, 0, 0, 1, None)), want: True.ToObject()},
+		{args: wrapArgs(newTestTimedelta(-1, 0, 0), newTestTimedelta(0, 0, 0)), want: True.ToObject()},
+		{args: wrapArgs(d, 1), wantExc: mustCreateException(TypeErrorType, "can't compare 'date' to 'int'")},
+		{args: wrapArgs(dt, d), wantExc: mustCreateException(TypeErrorType, "can't compare 'datetime' to 'date'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(LT), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	if eq := mustNotRaise(Eq(NewRootFrame(), dt, d)); eq != False.ToObject() {
+		t.Errorf("datetime == date returned %v, want False", eq)
+	}
+}
+
+func TestDatetimeStrftime(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(newDatetime(datetimeType, 2009, 2, 3, 16, 5, 6, 7, None), "%Y-%m-%d %H:%M:%S.%f%z%Z %%f"), want: NewStr("2009-02-03 16:05:06.000007 %f").ToObject()},
+		{args: wrapArgs(newDate(dateType, 2009, 2, 3), "%a %j %f"), want: NewStr("Tue 034 000000").ToObject()},
+		{args: wrapArgs(newTimeOfDay(timeOfDayType, 16, 5, 6, 0, None), "%H:%M %Y"), want: NewStr("16:05 1900").ToObject()},
+		{args: wrapArgs(newDate(dateType, 1899, 12, 31), "%Y"), wantExc: mustCreateException(ValueErrorType, "year=1899 is before 1900; the datetime strftime() methods require year >= 1900")},
+	}
+	fun := wrapFuncForTest(func(f *Frame, o *Object, format *Str) (*Object, *BaseException) {
+		return invokeMethod(f, o, "strftime", format.ToObject())
+	})
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDatetimeStrptime(t *testing.T) {
+	strptime := mustNotRaise(GetAttr(NewRootFrame(), datetimeType.ToObject(), NewStr("strptime"), nil))
+	cases := []invokeTestCase{
+		{args: wrapArgs("2009-02-03 16:05:06.25", "%Y-%m-%d %H:%M:%S.%f"), want: newDatetime(datetimeType, 2009, 2, 3, 16, 5, 6, 250000, None).ToObject()},
+		{args: wrapArgs("2009", "%Y %m"), wantExc: mustCreateException(ValueErrorType, "time data '2009' does not match format '%Y %m'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(strptime, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func newTestTimedelta(days, seconds, microseconds int) *Object {
+	return mustNotRaise(newTimedelta(NewRootFrame(), timedeltaType, days, seconds, microseconds))
+}
+
+func invokeMethod(f *Frame, o *Object, name string, args ...*Object) (*Object, *BaseException) {
+	method, raised := GetAttr(f, o, NewStr(name), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return method.Call(f, args, nil)
+}
@@ -17,6 +17,8 @@ package grumpy
 import (
 	"fmt"
 	"reflect"
+	"sync/atomic"
+	"unsafe"
 )
 
 type fieldDescriptorType int
@@ -45,6 +47,99 @@ func (p *Property) ToObject() *Object {
 	return &p.Object
 }
 
+// memberDescriptor represents the descriptors created for the names declared
+// in a class's __slots__. The values themselves are stored inline in each
+// instance at the given offset from the start of the object.
+type memberDescriptor struct {
+	Object
+	owner  *Type
+	name   string
+	offset uintptr
+}
+
+func newMemberDescriptor(owner *Type, name string, offset uintptr) *memberDescriptor {
+	return &memberDescriptor{Object{typ: memberDescriptorType}, owner, name, offset}
+}
+
+func toMemberDescriptorUnsafe(o *Object) *memberDescriptor {
+	return (*memberDescriptor)(o.toPointer())
+}
+
+// ToObject upcasts d to an Object.
+func (d *memberDescriptor) ToObject() *Object {
+	return &d.Object
+}
+
+// load returns the value stored in o's slot for d or nil if unset. The caller
+// must ensure that o is an instance of d.owner.
+func (d *memberDescriptor) load(o *Object) *Object {
+	return (*Object)(atomic.LoadPointer(d.slot(o)))
+}
+
+func (d *memberDescriptor) slot(o *Object) *unsafe.Pointer {
+	return (*unsafe.Pointer)(unsafe.Add(o.toPointer(), d.offset))
+}
+
+func (d *memberDescriptor) checkInstance(f *Frame, o *Object) *BaseException {
+	if !o.isInstance(d.owner) {
+		format := "descriptor '%s' for '%s' objects doesn't apply to '%s' object"
+		return f.RaiseType(TypeErrorType, fmt.Sprintf(format, d.name, d.owner.Name(), o.typ.Name()))
+	}
+	return nil
+}
+
+// memberDescriptorType is the object representing the Python
+// 'member_descriptor' type.
+var memberDescriptorType = newBasisType("member_descriptor", reflect.TypeOf(memberDescriptor{}), toMemberDescriptorUnsafe, ObjectType)
+
+func memberDescriptorDelete(f *Frame, desc, inst *Object) *BaseException {
+	d := toMemberDescriptorUnsafe(desc)
+	if raised := d.checkInstance(f, inst); raised != nil {
+		return raised
+	}
+	if atomic.SwapPointer(d.slot(inst), nil) == nil {
+		return f.RaiseType(AttributeErrorType, d.name)
+	}
+	return nil
+}
+
+func memberDescriptorGet(f *Frame, desc, instance *Object, _ *Type) (*Object, *BaseException) {
+	if instance == None {
+		return desc, nil
+	}
+	d := toMemberDescriptorUnsafe(desc)
+	if raised := d.checkInstance(f, instance); raised != nil {
+		return nil, raised
+	}
+	value := d.load(instance)
+	if value == nil {
+		return nil, f.RaiseType(AttributeErrorType, d.name)
+	}
+	return value, nil
+}
+
+func memberDescriptorRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toMemberDescriptorUnsafe(o)
+	return NewStr(fmt.Sprintf("<member '%s' of '%s' objects>", d.name, d.owner.Name())).ToObject(), nil
+}
+
+func memberDescriptorSet(f *Frame, desc, inst, value *Object) *BaseException {
+	d := toMemberDescriptorUnsafe(desc)
+	if raised := d.checkInstance(f, inst); raised != nil {
+		return raised
+	}
+	atomic.StorePointer(d.slot(inst), value.toPointer())
+	return nil
+}
+
+func initMemberDescriptorType(map[string]*Object) {
+	memberDescriptorType.flags &= ^(typeFlagInstantiable | typeFlagBasetype)
+	memberDescriptorType.slots.Delete = &deleteSlot{memberDescriptorDelete}
+	memberDescriptorType.slots.Get = &getSlot{memberDescriptorGet}
+	memberDescriptorType.slots.Repr = &unaryOpSlot{memberDescriptorRepr}
+	memberDescriptorType.slots.Set = &setSlot{memberDescriptorSet}
+}
+
 // PropertyType is the object representing the Python 'property' type.
 var PropertyType = newBasisType("property", reflect.TypeOf(Property{}), toPropertyUnsafe, ObjectType)
 
@@ -18,6 +18,32 @@ import (
 	"testing"
 )
 
+func TestMemberDescriptor(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newTestDict("__slots__", newTestTuple("bar")))
+	desc := mustNotRaise(GetAttr(NewRootFrame(), fooType.ToObject(), NewStr("bar"), nil))
+	foo := newObject(fooType)
+	fun := wrapFuncForTest(func(f *Frame, method string, args ...*Object) (*Object, *BaseException) {
+		return mustNotRaise(GetAttr(f, desc, NewStr(method), nil)).Call(f, args, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("__get__", foo, fooType), wantExc: mustCreateException(AttributeErrorType, "bar")},
+		{args: wrapArgs("__delete__", foo), wantExc: mustCreateException(AttributeErrorType, "bar")},
+		{args: wrapArgs("__set__", foo, 123), want: None},
+		{args: wrapArgs("__get__", foo, fooType), want: NewInt(123).ToObject()},
+		{args: wrapArgs("__get__", None, fooType), want: desc},
+		{args: wrapArgs("__delete__", foo), want: None},
+		{args: wrapArgs("__get__", foo, fooType), wantExc: mustCreateException(AttributeErrorType, "bar")},
+		{args: wrapArgs("__get__", 42, IntType), wantExc: mustCreateException(TypeErrorType, "descriptor 'bar' for 'Foo' objects doesn't apply to 'int' object")},
+		{args: wrapArgs("__set__", 42, 123), wantExc: mustCreateException(TypeErrorType, "descriptor 'bar' for 'Foo' objects doesn't apply to 'int' object")},
+		{args: wrapArgs("__repr__"), want: NewStr("<member 'bar' of 'Foo' objects>").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestPropertyDelete(t *testing.T) {
 	dummy := newObject(ObjectType)
 	cases := []invokeTestCase{
@@ -27,6 +27,7 @@ var (
 	DictType              = newBasisType("dict", reflect.TypeOf(Dict{}), toDictUnsafe, ObjectType)
 	dictItemIteratorType  = newBasisType("dictionary-itemiterator", reflect.TypeOf(dictItemIterator{}), toDictItemIteratorUnsafe, ObjectType)
 	dictKeyIteratorType   = newBasisType("dictionary-keyiterator", reflect.TypeOf(dictKeyIterator{}), toDictKeyIteratorUnsafe, ObjectType)
+	dictProxyType         = newBasisType("dictproxy", reflect.TypeOf(dictProxy{}), toDictProxyUnsafe, ObjectType)
 	dictValueIteratorType = newBasisType("dictionary-valueiterator", reflect.TypeOf(dictValueIterator{}), toDictValueIteratorUnsafe, ObjectType)
 	deletedEntry          = &dictEntry{}
 )
@@ -61,6 +62,10 @@ type dictTable struct {
 	// When the table is no longer large enough to hold a dict's contents,
 	// a new dictTable will be created.
 	entries []*dictEntry
+	// nonStrKeys is set to 1 once a key that is not exactly a str has been
+	// inserted into the table. Until then, lookups of str keys compare
+	// keys directly rather than via Eq. It must be accessed atomically.
+	nonStrKeys int32
 }
 
 // newDictTable allocates a table where at least minCapacity entries can be
@@ -118,6 +123,48 @@ func (t *dictTable) insertAbsentEntry(entry *dictEntry) {
 	t.fill++
 }
 
+// lookup returns the index and entry in t with the given hash and key, using
+// lookupStrEntry when both key and all the keys in t are str objects.
+func (t *dictTable) lookup(f *Frame, hash int, key *Object) (int, *dictEntry, *BaseException) {
+	if key.typ == StrType && atomic.LoadInt32(&t.nonStrKeys) == 0 {
+		index, entry := t.lookupStrEntry(hash, toStrUnsafe(key))
+		return index, entry, nil
+	}
+	return t.lookupEntry(f, hash, key)
+}
+
+// lookupStrEntry is like lookupEntry but it requires that key and all the
+// keys in t are exactly str. This allows keys to be compared by identity and
+// then by value without calling Eq, similar to CPython's lookdict_string.
+func (t *dictTable) lookupStrEntry(hash int, key *Str) (int, *dictEntry) {
+	mask := uint(len(t.entries) - 1)
+	i, perturb := uint(hash)&mask, uint(hash)
+	free := -1
+	var freeEntry *dictEntry
+	index := int(i & mask)
+	entry := t.loadEntry(index)
+	for {
+		if entry == nil {
+			if free != -1 {
+				index = free
+				entry = freeEntry
+			}
+			break
+		}
+		if entry == deletedEntry {
+			if free == -1 {
+				free = index
+			}
+		} else if entry.key == key.ToObject() || (entry.hash == hash && toStrUnsafe(entry.key).value == key.value) {
+			break
+		}
+		i, perturb = dictNextIndex(i, perturb)
+		index = int(i & mask)
+		entry = t.loadEntry(index)
+	}
+	return index, entry
+}
+
 // lookupEntry returns the index and entry in t with the given hash and key.
 // Elements in the table are updated with immutable entries atomically and
 // lookupEntry loads them atomically. So it is not necessary to lock the dict
@@ -198,6 +245,7 @@ func (t *dictTable) writeEntry(f *Frame, index int, entry *dictEntry) (*dictTabl
 		return nil, false
 	}
 	newTable := newDictTable(n)
+	newTable.nonStrKeys = t.nonStrKeys
 	for _, oldEntry := range t.entries {
 		if oldEntry != nil && oldEntry != deletedEntry {
 			newTable.insertAbsentEntry(oldEntry)
@@ -243,6 +291,19 @@ func (iter *dictEntryIterator) next() *dictEntry {
 	return entry
 }
 
+// dictKeyHash returns the hash of key, using the hash cached on str keys
+// rather than dispatching to __hash__.
+func dictKeyHash(f *Frame, key *Object) (int, *BaseException) {
+	if key.typ == StrType {
+		return toStrUnsafe(key).loadHash().Value(), nil
+	}
+	hash, raised := Hash(f, key)
+	if raised != nil {
+		return 0, raised
+	}
+	return hash.Value(), nil
+}
+
 // dictVersionGuard is used to detect when a dict has been modified.
 type dictVersionGuard struct {
 	dict    *Dict
@@ -342,11 +403,11 @@ func (d *Dict) DelItemString(f *Frame, key string) (bool, *BaseException) {
 // GetItem looks up key in d, returning the associated value or nil if key is
 // not present in d.
 func (d *Dict) GetItem(f *Frame, key *Object) (*Object, *BaseException) {
-	hash, raised := Hash(f, key)
+	hash, raised := dictKeyHash(f, key)
 	if raised != nil {
 		return nil, raised
 	}
-	_, entry, raised := d.loadTable().lookupEntry(f, hash.Value(), key)
+	_, entry, raised := d.loadTable().lookup(f, hash, key)
 	if raised != nil {
 		return nil, raised
 	}
@@ -391,14 +452,14 @@ func (d *Dict) Len() int {
 // putItem associates value with key in d, returning the old associated value if
 // the key was added, or nil if it was not already present in d.
 func (d *Dict) putItem(f *Frame, key, value *Object, overwrite bool) (*Object, *BaseException) {
-	hash, raised := Hash(f, key)
+	hash, raised := dictKeyHash(f, key)
 	if raised != nil {
 		return nil, raised
 	}
 	d.mutex.Lock(f)
 	t := d.table
 	v := d.version
-	index, entry, raised := t.lookupEntry(f, hash.Value(), key)
+	index, entry, raised := t.lookup(f, hash, key)
 	var originValue *Object
 	if raised == nil {
 		if v != d.version {
@@ -414,7 +475,12 @@ func (d *Dict) putItem(f *Frame, key, value *Object, overwrite bool) (*Object, *
 					d.incVersion()
 				}
 			} else if overwrite || entry == nil {
-				newEntry := &dictEntry{hash.Value(), key, value}
+				if key.typ != StrType {
+					// Switch to generic lookups before the
+					// key becomes visible to readers.
+					atomic.StoreInt32(&t.nonStrKeys, 1)
+				}
+				newEntry := &dictEntry{hash, key, value}
 				if newTable, ok := t.writeEntry(f, index, newEntry); ok {
 					if newTable != nil {
 						d.storeTable(newTable)
@@ -1008,6 +1074,87 @@ func initDictValueIteratorType(map[string]*Object) {
 	dictValueIteratorType.slots.Next = &unaryOpSlot{dictValueIteratorNext}
 }
 
+// dictProxy is a read-only view of a Dict. It is used to expose type dicts via
+// the __dict__ attribute so that they can't be modified without going
+// through the type's __setattr__.
+type dictProxy struct {
+	Object
+	dict *Dict
+}
+
+func newDictProxy(d *Dict) *dictProxy {
+	return &dictProxy{Object: Object{typ: dictProxyType}, dict: d}
+}
+
+func toDictProxyUnsafe(o *Object) *dictProxy {
+	return (*dictProxy)(o.toPointer())
+}
+
+func (p *dictProxy) ToObject() *Object {
+	return &p.Object
+}
+
+func dictProxyContains(f *Frame, seq, value *Object) (*Object, *BaseException) {
+	return dictContains(f, toDictProxyUnsafe(seq).dict.ToObject(), value)
+}
+
+func dictProxyGetItem(f *Frame, o, key *Object) (*Object, *BaseException) {
+	return dictGetItem(f, toDictProxyUnsafe(o).dict.ToObject(), key)
+}
+
+func dictProxyIter(f *Frame, o *Object) (*Object, *BaseException) {
+	return dictIter(f, toDictProxyUnsafe(o).dict.ToObject())
+}
+
+func dictProxyLen(f *Frame, o *Object) (*Object, *BaseException) {
+	return dictLen(f, toDictProxyUnsafe(o).dict.ToObject())
+}
+
+func dictProxyRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	s, raised := dictRepr(f, toDictProxyUnsafe(o).dict.ToObject())
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(fmt.Sprintf("dict_proxy(%s)", toStrUnsafe(s).Value())).ToObject(), nil
+}
+
+func dictProxyStr(f *Frame, o *Object) (*Object, *BaseException) {
+	return dictRepr(f, toDictProxyUnsafe(o).dict.ToObject())
+}
+
+// makeDictProxyMethod returns a dictproxy method that invokes the given dict
+// method on the proxied dict.
+func makeDictProxyMethod(name string, fn Func) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodVarArgs(f, name, args, dictProxyType); raised != nil {
+			return nil, raised
+		}
+		dictArgs := make(Args, len(args))
+		dictArgs[0] = toDictProxyUnsafe(args[0]).dict.ToObject()
+		copy(dictArgs[1:], args[1:])
+		return fn(f, dictArgs, kwargs)
+	}).ToObject()
+}
+
+func initDictProxyType(dict map[string]*Object) {
+	dictProxyType.flags &^= typeFlagBasetype | typeFlagInstantiable
+	dict["copy"] = makeDictProxyMethod("copy", dictCopy)
+	dict["get"] = makeDictProxyMethod("get", dictGet)
+	dict["has_key"] = makeDictProxyMethod("has_key", dictHasKey)
+	dict["items"] = makeDictProxyMethod("items", dictItems)
+	dict["iteritems"] = makeDictProxyMethod("iteritems", dictIterItems)
+	dict["iterkeys"] = makeDictProxyMethod("iterkeys", dictIterKeys)
+	dict["itervalues"] = makeDictProxyMethod("itervalues", dictIterValues)
+	dict["keys"] = makeDictProxyMethod("keys", dictKeys)
+	dict["values"] = makeDictProxyMethod("values", dictValues)
+	dictProxyType.slots.Contains = &binaryOpSlot{dictProxyContains}
+	dictProxyType.slots.GetItem = &binaryOpSlot{dictProxyGetItem}
+	dictProxyType.slots.Iter = &unaryOpSlot{dictProxyIter}
+	dictProxyType.slots.Len = &unaryOpSlot{dictProxyLen}
+	dictProxyType.slots.Repr = &unaryOpSlot{dictProxyRepr}
+	dictProxyType.slots.Str = &unaryOpSlot{dictProxyStr}
+}
+
 func raiseKeyError(f *Frame, key *Object) *BaseException {
 	s, raised := ToStr(f, key)
 	if raised == nil {
@@ -233,6 +233,40 @@ func TestDictGetItem(t *testing.T) {
 	}
 }
 
+func TestDictStrKeys(t *testing.T) {
+	fooStrType := newTestClass("FooStr", []*Type{StrType}, NewDict())
+	fun := wrapFuncForTest(func(f *Frame, d *Dict, keys *Tuple, lookup *Object) (*Tuple, *BaseException) {
+		for i, k := range keys.elems {
+			if raised := d.SetItem(f, k, NewInt(i).ToObject()); raised != nil {
+				return nil, raised
+			}
+		}
+		v, raised := d.GetItem(f, lookup)
+		if raised != nil {
+			return nil, raised
+		}
+		if v == nil {
+			v = None
+		}
+		return NewTuple2(v, GetBool(d.table.nonStrKeys == 0).ToObject()), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewDict(), newTestTuple("foo", "bar"), "bar"), want: newTestTuple(1, true).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple("foo", "bar"), "baz"), want: newTestTuple(None, true).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple("foo", NewUnicode("bar")), "bar"), want: newTestTuple(1, false).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple(NewUnicode("foo"), "bar"), NewUnicode("bar")), want: newTestTuple(1, false).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple("foo", "bar"), NewUnicode("bar")), want: newTestTuple(1, true).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple("foo", 42), 42), want: newTestTuple(1, false).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple("foo", mustNotRaise(fooStrType.Call(NewRootFrame(), wrapArgs("bar"), nil))), "bar"), want: newTestTuple(1, false).ToObject()},
+		{args: wrapArgs(NewDict(), newTestTuple("foo", "bar"), mustNotRaise(fooStrType.Call(NewRootFrame(), wrapArgs("foo"), nil))), want: newTestTuple(0, true).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 // BenchmarkDictGetItem is to keep an eye on the speed of contended dict access
 // in a fast read loop.
 func BenchmarkDictGetItem(b *testing.B) {
@@ -826,6 +860,63 @@ func TestDictValues(t *testing.T) {
 	}
 }
 
+func TestDictProxy(t *testing.T) {
+	d := newTestDict("foo", 1, "bar", 2)
+	proxy := newDictProxy(d).ToObject()
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Tuple, *BaseException) {
+		item, raised := GetItem(f, o, NewStr("foo").ToObject())
+		if raised != nil {
+			return nil, raised
+		}
+		contains, raised := Contains(f, o, NewStr("bar").ToObject())
+		if raised != nil {
+			return nil, raised
+		}
+		length, raised := Len(f, o)
+		if raised != nil {
+			return nil, raised
+		}
+		s, raised := ToStr(f, o)
+		if raised != nil {
+			return nil, raised
+		}
+		r, raised := Repr(f, o)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple(item, GetBool(contains).ToObject(), length.ToObject(), s.ToObject(), r.ToObject()), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(proxy), want: newTestTuple(1, true, 2, "{'foo': 1, 'bar': 2}", "dict_proxy({'foo': 1, 'bar': 2})").ToObject()},
+		{args: wrapArgs(newDictProxy(NewDict())), wantExc: mustCreateException(KeyErrorType, "foo")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	methodCases := []struct {
+		method string
+		invokeTestCase
+	}{
+		{"copy", invokeTestCase{args: wrapArgs(proxy), want: d.ToObject()}},
+		{"get", invokeTestCase{args: wrapArgs(proxy, "bar"), want: NewInt(2).ToObject()}},
+		{"get", invokeTestCase{args: wrapArgs(proxy, "baz", 3), want: NewInt(3).ToObject()}},
+		{"has_key", invokeTestCase{args: wrapArgs(proxy, "baz"), want: False.ToObject()}},
+		{"keys", invokeTestCase{args: wrapArgs(newDictProxy(newTestDict("foo", 1))), want: newTestList("foo").ToObject()}},
+		{"values", invokeTestCase{args: wrapArgs(newDictProxy(newTestDict("foo", 1))), want: newTestList(1).ToObject()}},
+		{"keys", invokeTestCase{args: wrapArgs(d), wantExc: mustCreateException(TypeErrorType, "unbound method keys() must be called with dictproxy instance as first argument (got dict instance instead)")}},
+	}
+	for _, cas := range methodCases {
+		if err := runInvokeMethodTestCase(dictProxyType, cas.method, &cas.invokeTestCase); err != "" {
+			t.Error(err)
+		}
+	}
+	if raised := SetItem(NewRootFrame(), proxy, NewStr("baz").ToObject(), None); raised == nil || !raised.isInstance(TypeErrorType) {
+		t.Errorf("proxy['baz'] = None raised %v, want TypeError", raised)
+	}
+}
+
 func TestParallelDictUpdates(t *testing.T) {
 	keys := []*Object{
 		NewStr("abc").ToObject(),
@@ -14,6 +14,15 @@
 
 package grumpy
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+)
+
 var (
 	// ArithmeticErrorType corresponds to the Python type 'ArithmeticError'.
 	ArithmeticErrorType = newSimpleType("ArithmeticError", StandardErrorType)
@@ -118,3 +127,93 @@ func systemExitInit(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *Ba
 func initSystemExitType(map[string]*Object) {
 	SystemExitType.slots.Init = &initSlot{systemExitInit}
 }
+
+// environmentErrorInit implements EnvironmentError.__init__. Like CPython, when
+// called with two or three args the errno, strerror and filename attributes
+// are populated from them and args is truncated to (errno, strerror).
+func environmentErrorInit(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	baseExceptionInit(f, o, args, kwargs)
+	errno, strerror, filename := None, None, None
+	if argc := len(args); argc == 2 || argc == 3 {
+		errno, strerror = args[0], args[1]
+		if argc == 3 {
+			filename = args[2]
+			toBaseExceptionUnsafe(o).args = NewTuple2(errno, strerror)
+		}
+	}
+	attrs := []struct {
+		name  string
+		value *Object
+	}{{"errno", errno}, {"strerror", strerror}, {"filename", filename}}
+	for _, attr := range attrs {
+		if raised := SetAttr(f, o, NewStr(attr.name), attr.value); raised != nil {
+			return nil, raised
+		}
+	}
+	return None, nil
+}
+
+func environmentErrorStr(f *Frame, o *Object) (*Object, *BaseException) {
+	var values [3]*Object
+	for i, name := range []string{"errno", "strerror", "filename"} {
+		value, raised := GetAttr(f, o, NewStr(name), None)
+		if raised != nil {
+			return nil, raised
+		}
+		values[i] = value
+	}
+	errno, strerror, filename := values[0], values[1], values[2]
+	if errno == None && strerror == None {
+		return baseExceptionStr(f, o)
+	}
+	errnoStr, raised := ToStr(f, errno)
+	if raised != nil {
+		return nil, raised
+	}
+	strerrorStr, raised := ToStr(f, strerror)
+	if raised != nil {
+		return nil, raised
+	}
+	s := fmt.Sprintf("[Errno %s] %s", errnoStr.Value(), strerrorStr.Value())
+	if filename != None {
+		filenameRepr, raised := Repr(f, filename)
+		if raised != nil {
+			return nil, raised
+		}
+		s += ": " + filenameRepr.Value()
+	}
+	return NewStr(s).ToObject(), nil
+}
+
+func initEnvironmentErrorType(map[string]*Object) {
+	EnvironmentErrorType.slots.Init = &initSlot{environmentErrorInit}
+	EnvironmentErrorType.slots.Str = &unaryOpSlot{environmentErrorStr}
+}
+
+// raiseEnvironmentError raises an instance of t (an EnvironmentError subclass)
+// describing the Go error err. When err carries a syscall.Errno, the errno and
+// strerror attributes are populated from it along with the filename of any
+// path recorded by err. Other errors are raised with their message only.
+func raiseEnvironmentError(f *Frame, t *Type, err error) *BaseException {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return f.RaiseType(t, err.Error())
+	}
+	args := []*Object{NewInt(int(errno)).ToObject(), NewStr(errnoMessage(errno)).ToObject()}
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	if errors.As(err, &pathErr) {
+		args = append(args, NewStr(pathErr.Path).ToObject())
+	} else if errors.As(err, &linkErr) {
+		args = append(args, NewStr(linkErr.Old).ToObject())
+	}
+	return f.Raise(t.ToObject(), NewTuple(args...).ToObject(), nil)
+}
+
+// errnoMessage returns the strerror(3) style message for errno. Go's messages
+// are lower case so the first letter is capitalized to match libc.
+func errnoMessage(errno syscall.Errno) string {
+	msg := errno.Error()
+	r, size := utf8.DecodeRuneInString(msg)
+	return string(unicode.ToUpper(r)) + msg[size:]
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestEnvironmentErrorAttrs(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Tuple, *BaseException) {
+		e, raised := OSErrorType.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		var elems []*Object
+		for _, name := range []string{"errno", "strerror", "filename"} {
+			attr, raised := GetAttr(f, e, NewStr(name), nil)
+			if raised != nil {
+				return nil, raised
+			}
+			elems = append(elems, attr)
+		}
+		return NewTuple(append(elems, toBaseExceptionUnsafe(e).args.ToObject())...), nil
+	})
+	cases := []invokeTestCase{
+		{want: newTestTuple(None, None, None, NewTuple()).ToObject()},
+		{args: wrapArgs("foo"), want: newTestTuple(None, None, None, newTestTuple("foo")).ToObject()},
+		{args: wrapArgs(2, "bar"), want: newTestTuple(2, "bar", None, newTestTuple(2, "bar")).ToObject()},
+		{args: wrapArgs(2, "bar", "/baz"), want: newTestTuple(2, "bar", "/baz", newTestTuple(2, "bar")).ToObject()},
+		{args: wrapArgs(1, 2, 3, 4), want: newTestTuple(None, None, None, newTestTuple(1, 2, 3, 4)).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestEnvironmentErrorStr(t *testing.T) {
+	f := NewRootFrame()
+	cases := []invokeTestCase{
+		{args: wrapArgs(newObject(IOErrorType)), want: NewStr("").ToObject()},
+		{args: wrapArgs(mustNotRaise(IOErrorType.Call(f, wrapArgs("foo"), nil))), want: NewStr("foo").ToObject()},
+		{args: wrapArgs(mustNotRaise(OSErrorType.Call(f, wrapArgs(2, "bar"), nil))), want: NewStr("[Errno 2] bar").ToObject()},
+		{args: wrapArgs(mustNotRaise(OSErrorType.Call(f, wrapArgs(2, "bar", "/baz"), nil))), want: NewStr("[Errno 2] bar: '/baz'").ToObject()},
+		{args: wrapArgs(mustNotRaise(OSErrorType.Call(f, wrapArgs(1, 2, 3), nil))), want: NewStr("[Errno 1] 2: 3").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(ToStr), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRaiseEnvironmentError(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, t *Type, err error) *BaseException {
+		return raiseEnvironmentError(f, t, err)
+	})
+	f := NewRootFrame()
+	newError := func(t *Type, args ...interface{}) *BaseException {
+		return toBaseExceptionUnsafe(mustNotRaise(t.Call(f, wrapArgs(args...), nil)))
+	}
+	cases := []invokeTestCase{
+		{args: wrapArgs(OSErrorType, syscall.ENOENT), wantExc: newError(OSErrorType, 2, "No such file or directory")},
+		{args: wrapArgs(IOErrorType, &os.PathError{Op: "open", Path: "/foo", Err: syscall.EACCES}), wantExc: newError(IOErrorType, 13, "Permission denied", "/foo")},
+		{args: wrapArgs(OSErrorType, &os.LinkError{Op: "rename", Old: "/foo", New: "/bar", Err: syscall.ENOENT}), wantExc: newError(OSErrorType, 2, "No such file or directory", "/foo")},
+		{args: wrapArgs(OSErrorType, errors.New("foo")), wantExc: mustCreateException(OSErrorType, "foo")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -46,19 +46,25 @@ type File struct {
 // NewFileFromFD creates a file object from the given file descriptor fd.
 func NewFileFromFD(fd uintptr, close *Object) *File {
 	// TODO: Use fcntl or something to get the mode of the descriptor.
-	file := &File{
-		Object: Object{typ: FileType},
-		mode:   "?",
-		open:   true,
-		file:   os.NewFile(fd, "<fdopen>"),
-	}
+	file := newFileFromOSFile(os.NewFile(fd, "<fdopen>"), "?")
 	if close != None {
 		file.close = close
 	}
-	file.reader = bufio.NewReader(file.file)
 	return file
 }
 
+// newFileFromOSFile creates a file object with the given mode that takes
+// ownership of osFile.
+func newFileFromOSFile(osFile *os.File, mode string) *File {
+	return &File{
+		Object: Object{typ: FileType},
+		mode:   mode,
+		open:   true,
+		file:   osFile,
+		reader: bufio.NewReader(osFile),
+	}
+}
+
 func toFileUnsafe(o *Object) *File {
 	return (*File)(o.toPointer())
 }
@@ -120,6 +126,18 @@ func (f *File) writeString(s string) error {
 	return nil
 }
 
+// closeFile closes the underlying os.File if it is still open, bypassing any
+// close function associated with f.
+func (f *File) closeFile() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.open {
+		return nil
+	}
+	f.open = false
+	return f.file.Close()
+}
+
 // FileType is the object representing the Python 'file' type.
 var FileType = newBasisType("file", reflect.TypeOf(File{}), toFileUnsafe, ObjectType)
 
@@ -136,7 +154,8 @@ func fileInit(f *Frame, o *Object, args Args, _ KWArgs) (*Object, *BaseException
 	if argc > 1 {
 		mode = toStrUnsafe(args[1]).Value()
 	}
-	// TODO: Do something with the binary mode flag.
+	// The binary mode flag is accepted but has no effect since POSIX makes
+	// no distinction between text and binary files.
 	var flag int
 	switch mode {
 	case "a", "ab":
@@ -217,8 +236,8 @@ func fileClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	return ret, nil
 }
 
-func fileClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
-	if raised := checkMethodArgs(f, "closed", args, FileType); raised != nil {
+func fileGetClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_closed", args, FileType); raised != nil {
 		return nil, raised
 	}
 	file := toFileUnsafe(args[0])
@@ -243,6 +262,30 @@ func fileFileno(f *Frame, args Args, _ KWArgs) (ret *Object, raised *BaseExcepti
 	return ret, raised
 }
 
+func fileFlush(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, FileType); raised != nil {
+		return nil, raised
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	return None, nil
+}
+
+func fileGetMode(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_mode", args, FileType); raised != nil {
+		return nil, raised
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	mode := file.mode
+	file.mutex.Unlock()
+	return NewStr(mode).ToObject(), nil
+}
+
 func fileGetName(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "_get_name", args, FileType); raised != nil {
 		return nil, raised
@@ -254,6 +297,19 @@ func fileGetName(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	return NewStr(name).ToObject(), nil
 }
 
+func fileIsatty(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isatty", args, FileType); raised != nil {
+		return nil, raised
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	return GetBool(isatty(int(file.file.Fd()))).ToObject(), nil
+}
+
 func fileIter(f *Frame, o *Object) (*Object, *BaseException) {
 	return o, nil
 }
@@ -288,7 +344,7 @@ func fileRead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	var data []byte
 	var err error
 	if size < 0 {
-		data, err = ioutil.ReadAll(file.file)
+		data, err = ioutil.ReadAll(file.reader)
 	} else {
 		data = make([]byte, size)
 		var n int
@@ -301,6 +357,58 @@ func fileRead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	return NewStr(string(data)).ToObject(), nil
 }
 
+// fileReadable implements readable(), seekable() and writable() so that
+// files can stand in for io streams.
+func fileReadable(name string) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, name, args, FileType); raised != nil {
+			return nil, raised
+		}
+		file := toFileUnsafe(args[0])
+		file.mutex.Lock()
+		defer file.mutex.Unlock()
+		if !file.open {
+			return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+		}
+		// Descriptors of unknown mode ("?") are assumed to be readable
+		// and writable.
+		var result bool
+		switch name {
+		case "readable":
+			result = strings.ContainsAny(file.mode, "r+?U")
+		case "writable":
+			result = strings.ContainsAny(file.mode, "wa+?")
+		default:
+			_, err := file.file.Seek(0, os.SEEK_CUR)
+			result = err == nil
+		}
+		return GetBool(result).ToObject(), nil
+	}).ToObject()
+}
+
+func fileReadInto(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readinto", args, FileType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(ByteArrayType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("must be read-write buffer, not %s", args[1].typ.Name()))
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	dst := toByteArrayUnsafe(args[1])
+	dst.mutex.Lock()
+	n, err := io.ReadFull(file.reader, dst.value)
+	dst.mutex.Unlock()
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, f.RaiseType(IOErrorType, err.Error())
+	}
+	return NewInt(n).ToObject(), nil
+}
+
 func fileReadLine(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	file, size, raised := fileParseReadArgs(f, "readline", args)
 	if raised != nil {
@@ -368,18 +476,122 @@ func fileRepr(f *Frame, o *Object) (*Object, *BaseException) {
 	return NewStr(fmt.Sprintf("<%s file %q, mode %q at %p>", openState, file.name(), mode, file)).ToObject(), nil
 }
 
+func fileSeek(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{FileType, ObjectType, IntType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	if args[1].typ.slots.Index == nil && !args[1].isInstance(FloatType) {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	o, raised := IntType.Call(f, args[1:2], nil)
+	if raised != nil {
+		return nil, raised
+	}
+	offset := int64(toIntUnsafe(o).Value())
+	whence := os.SEEK_SET
+	if argc > 2 {
+		whence = toIntUnsafe(args[2]).Value()
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	if whence == os.SEEK_CUR {
+		// Account for data read ahead into the buffer.
+		offset -= int64(file.reader.Buffered())
+	}
+	if _, err := file.file.Seek(offset, whence); err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	file.reader.Reset(file.file)
+	file.skipNextLF = false
+	return None, nil
+}
+
+func fileTell(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, FileType); raised != nil {
+		return nil, raised
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	pos, err := file.file.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return NewInt(int(pos) - file.reader.Buffered()).ToObject(), nil
+}
+
+func fileTruncate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{FileType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "truncate", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	pos, err := file.file.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	size := pos - int64(file.reader.Buffered())
+	if len(args) > 1 && args[1] != None {
+		n, raised := IndexInt(f, args[1])
+		if raised != nil {
+			return nil, raised
+		}
+		size = int64(n)
+	}
+	if err := file.file.Truncate(size); err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return None, nil
+}
+
 func fileWrite(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
-	if raised := checkMethodArgs(f, "write", args, FileType, StrType); raised != nil {
+	if raised := checkMethodArgs(f, "write", args, FileType, ObjectType); raised != nil {
 		return nil, raised
 	}
+	if args[1].typ.slots.Buffer == nil {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("must be string or buffer, not %s", args[1].typ.Name()))
+	}
 	file := toFileUnsafe(args[0])
 	file.mutex.Lock()
 	defer file.mutex.Unlock()
 	if !file.open {
 		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
 	}
-	if _, err := file.file.Write([]byte(toStrUnsafe(args[1]).Value())); err != nil {
-		return nil, f.RaiseType(IOErrorType, err.Error())
+	if n := file.reader.Buffered(); n > 0 {
+		// Discard read ahead data so the write lands at the logical
+		// position.
+		if _, err := file.file.Seek(int64(-n), os.SEEK_CUR); err == nil {
+			file.reader.Reset(file.file)
+		}
+	}
+	raised := bufferApply(f, args[1], func(b []byte) *BaseException {
+		if _, err := file.file.Write(b); err != nil {
+			return f.RaiseType(IOErrorType, err.Error())
+		}
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
 	}
 	return None, nil
 }
@@ -389,12 +601,22 @@ func initFileType(dict map[string]*Object) {
 	dict["__enter__"] = newBuiltinFunction("__enter__", fileEnter).ToObject()
 	dict["__exit__"] = newBuiltinFunction("__exit__", fileExit).ToObject()
 	dict["close"] = newBuiltinFunction("close", fileClose).ToObject()
-	dict["closed"] = newBuiltinFunction("closed", fileClosed).ToObject()
+	dict["closed"] = newProperty(newBuiltinFunction("_get_closed", fileGetClosed).ToObject(), nil, nil).ToObject()
 	dict["fileno"] = newBuiltinFunction("fileno", fileFileno).ToObject()
+	dict["flush"] = newBuiltinFunction("flush", fileFlush).ToObject()
+	dict["isatty"] = newBuiltinFunction("isatty", fileIsatty).ToObject()
+	dict["mode"] = newProperty(newBuiltinFunction("_get_mode", fileGetMode).ToObject(), nil, nil).ToObject()
 	dict["name"] = newProperty(newBuiltinFunction("_get_name", fileGetName).ToObject(), nil, nil).ToObject()
 	dict["read"] = newBuiltinFunction("read", fileRead).ToObject()
+	dict["readable"] = fileReadable("readable")
+	dict["readinto"] = newBuiltinFunction("readinto", fileReadInto).ToObject()
 	dict["readline"] = newBuiltinFunction("readline", fileReadLine).ToObject()
 	dict["readlines"] = newBuiltinFunction("readlines", fileReadLines).ToObject()
+	dict["seek"] = newBuiltinFunction("seek", fileSeek).ToObject()
+	dict["seekable"] = fileReadable("seekable")
+	dict["tell"] = newBuiltinFunction("tell", fileTell).ToObject()
+	dict["truncate"] = newBuiltinFunction("truncate", fileTruncate).ToObject()
+	dict["writable"] = fileReadable("writable")
 	dict["write"] = newBuiltinFunction("write", fileWrite).ToObject()
 	FileType.slots.Init = &initSlot{fileInit}
 	FileType.slots.Iter = &unaryOpSlot{fileIter}
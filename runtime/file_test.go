@@ -50,13 +50,16 @@ func TestFileClosed(t *testing.T) {
 	// This puts the file into an invalid state since Grumpy thinks
 	// it's open even though the underlying file was closed.
 	closedFile.file.Close()
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+		return GetAttr(f, o, NewStr("closed"), nil)
+	})
 	cases := []invokeTestCase{
 		{args: wrapArgs(newObject(FileType)), want: True.ToObject()},
 		{args: wrapArgs(f.open("r")), want: False.ToObject()},
 		{args: wrapArgs(closedFile), want: False.ToObject()},
 	}
 	for _, cas := range cases {
-		if err := runInvokeMethodTestCase(FileType, "closed", &cas); err != "" {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
 			t.Error(err)
 		}
 	}
@@ -264,6 +267,67 @@ func TestFileReadLines(t *testing.T) {
 	}
 }
 
+func TestFileSeekTell(t *testing.T) {
+	f := newTestFile("foo\nbar")
+	defer f.cleanup()
+	fun := wrapFuncForTest(func(fr *Frame, mode string, pos, whence int) (*Object, *BaseException) {
+		file := f.open(mode).ToObject()
+		if _, raised := ioCall(fr, file, "readline"); raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(fr, file, "seek", NewInt(pos).ToObject(), NewInt(whence).ToObject()); raised != nil {
+			return nil, raised
+		}
+		tell, raised := ioCall(fr, file, "tell")
+		if raised != nil {
+			return nil, raised
+		}
+		rest, raised := ioCall(fr, file, "read")
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(tell, rest).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("r", 0, 1), want: newTestTuple(4, "bar").ToObject()},
+		{args: wrapArgs("r", 1, 0), want: newTestTuple(1, "oo\nbar").ToObject()},
+		{args: wrapArgs("r", -1, 1), want: newTestTuple(3, "\nbar").ToObject()},
+		{args: wrapArgs("r", -2, 2), want: newTestTuple(5, "ar").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFileReadableWritable(t *testing.T) {
+	f := newTestFile("foo")
+	defer f.cleanup()
+	fun := wrapFuncForTest(func(fr *Frame, mode string) (*Object, *BaseException) {
+		file := f.open(mode).ToObject()
+		readable, raised := ioCall(fr, file, "readable")
+		if raised != nil {
+			return nil, raised
+		}
+		writable, raised := ioCall(fr, file, "writable")
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(readable, writable).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("r"), want: newTestTuple(true, false).ToObject()},
+		{args: wrapArgs("a"), want: newTestTuple(false, true).ToObject()},
+		{args: wrapArgs("r+"), want: newTestTuple(true, true).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestFileStrRepr(t *testing.T) {
 	fun := newBuiltinFunction("TestFileStrRepr", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 		if raised := checkFunctionArgs(f, "TestFileStrRepr", args, ObjectType, StrType); raised != nil {
@@ -304,7 +368,7 @@ func TestFileStrRepr(t *testing.T) {
 
 func TestFileWrite(t *testing.T) {
 	fun := newBuiltinFunction("TestFileWrite", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
-		if raised := checkMethodArgs(f, "TestFileWrite", args, StrType, StrType, StrType); raised != nil {
+		if raised := checkMethodArgs(f, "TestFileWrite", args, StrType, StrType, ObjectType); raised != nil {
 			return nil, raised
 		}
 		writeFile, raised := FileType.Call(f, args[:2], nil)
@@ -357,6 +421,9 @@ func TestFileWrite(t *testing.T) {
 		{args: wrapArgs("wplus.txt", "w+", "destructo"), want: NewStr("destructo").ToObject()},
 		{args: wrapArgs("noexistplus2.txt", "w+", "wapper"), want: NewStr("wapper").ToObject()},
 
+		{args: wrapArgs("bytearray.txt", "w", newTestByteArray("abc")), want: NewStr("abc").ToObject()},
+		{args: wrapArgs("int.txt", "w", 123), wantExc: mustCreateException(TypeErrorType, "must be string or buffer, not int")},
+
 		{args: wrapArgs("readonly.txt", "r", "foo"), wantExc: mustCreateException(IOErrorType, "write readonly.txt: bad file descriptor")},
 	}
 	for _, cas := range cases {
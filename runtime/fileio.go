@@ -0,0 +1,541 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+var (
+	// fileIOType corresponds to the Python type '_io.FileIO'.
+	fileIOType     = newBasisType("FileIO", reflect.TypeOf(fileIO{}), toFileIOUnsafe, rawIOBaseType)
+	fileIOInitSpec = NewParamSpec("FileIO", []Param{{"file", nil}, {"mode", NewStr("r").ToObject()}, {"closefd", True.ToObject()}}, false, false)
+)
+
+// fileIO represents Python '_io.FileIO' objects. It performs unbuffered I/O
+// directly on a file descriptor. The descriptor is not wrapped in an os.File
+// so that it is never closed behind the Python object's back.
+type fileIO struct {
+	ioBase
+	mutex     sync.Mutex
+	fd        int
+	readable  bool
+	writable  bool
+	appending bool
+	closefd   bool
+}
+
+func toFileIOUnsafe(o *Object) *fileIO {
+	return (*fileIO)(o.toPointer())
+}
+
+// ToObject upcasts r to an Object.
+func (r *fileIO) ToObject() *Object {
+	return &r.Object
+}
+
+// lock acquires r's mutex and raises ValueError if r is closed. On success
+// the caller must release the mutex.
+func (r *fileIO) lock(f *Frame) *BaseException {
+	r.mutex.Lock()
+	if r.fd < 0 {
+		r.mutex.Unlock()
+		return f.RaiseType(ValueErrorType, errIOClosed)
+	}
+	return nil
+}
+
+func (r *fileIO) mode() string {
+	switch {
+	case r.appending && r.readable:
+		return "ab+"
+	case r.appending:
+		return "ab"
+	case r.readable && r.writable:
+		return "rb+"
+	case r.readable:
+		return "rb"
+	default:
+		return "wb"
+	}
+}
+
+// readAll reads from r until EOF. The caller must hold r.mutex.
+func (r *fileIO) readAll() ([]byte, error) {
+	var buf []byte
+	chunk := make([]byte, ioDefaultBufferSize)
+	for {
+		var n int
+		err := posixRetry(func() (err error) {
+			n, err = syscall.Read(r.fd, chunk)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return buf, nil
+		}
+		buf = append(buf, chunk[:n]...)
+	}
+}
+
+func fileIOClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	r := toFileIOUnsafe(args[0])
+	r.mutex.Lock()
+	fd := r.fd
+	r.fd = -1
+	r.mutex.Unlock()
+	if fd < 0 || !r.closefd {
+		return None, nil
+	}
+	if err := syscall.Close(fd); err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return None, nil
+}
+
+func fileIOFileno(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fileno", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	fd := r.fd
+	r.mutex.Unlock()
+	return NewInt(fd).ToObject(), nil
+}
+
+func fileIOGetClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_closed", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	r := toFileIOUnsafe(args[0])
+	r.mutex.Lock()
+	closed := r.fd < 0
+	r.mutex.Unlock()
+	return GetBool(closed).ToObject(), nil
+}
+
+func fileIOGetCloseFD(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_closefd", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	return GetBool(toFileIOUnsafe(args[0]).closefd).ToObject(), nil
+}
+
+func fileIOGetMode(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_mode", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	return NewUnicode(toFileIOUnsafe(args[0]).mode()).ToObject(), nil
+}
+
+func fileIOInit(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [3]*Object
+	if raised := fileIOInitSpec.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	name, modeArg := validated[0], validated[1]
+	if name.isInstance(FloatType) {
+		return nil, f.RaiseType(TypeErrorType, "integer argument expected, got float")
+	}
+	if !modeArg.isInstance(StrType) && !modeArg.isInstance(UnicodeType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("FileIO() argument 2 must be string, not %s", modeArg.typ.Name()))
+	}
+	closefd, raised := IsTrue(f, validated[2])
+	if raised != nil {
+		return nil, raised
+	}
+	modeStr, raised := ToStr(f, modeArg)
+	if raised != nil {
+		return nil, raised
+	}
+	mode := modeStr.Value()
+	r := toFileIOUnsafe(o)
+	r.readable, r.writable, r.appending = false, false, false
+	var rwa, plus bool
+	flag := 0
+	for _, c := range mode {
+		switch c {
+		case 'r', 'w', 'a':
+			if rwa {
+				return nil, f.RaiseType(ValueErrorType, "Must have exactly one of read/write/append mode and at most one plus")
+			}
+			rwa = true
+			switch c {
+			case 'r':
+				r.readable = true
+			case 'w':
+				r.writable = true
+				flag |= os.O_CREATE | os.O_TRUNC
+			case 'a':
+				r.writable, r.appending = true, true
+				flag |= os.O_CREATE | os.O_APPEND
+			}
+		case 'b':
+		case '+':
+			if plus {
+				return nil, f.RaiseType(ValueErrorType, "Must have exactly one of read/write/append mode and at most one plus")
+			}
+			r.readable, r.writable, plus = true, true, true
+		default:
+			return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("invalid mode: %s", mode))
+		}
+	}
+	if !rwa {
+		return nil, f.RaiseType(ValueErrorType, "Must have exactly one of read/write/append mode and at most one plus")
+	}
+	switch {
+	case r.readable && r.writable:
+		flag |= os.O_RDWR
+	case r.readable:
+		flag |= os.O_RDONLY
+	default:
+		flag |= os.O_WRONLY
+	}
+	fd := -1
+	if name.typ.slots.Index != nil {
+		if fd, raised = IndexInt(f, name); raised != nil {
+			return nil, raised
+		}
+		if fd < 0 {
+			return nil, f.RaiseType(ValueErrorType, "negative file descriptor")
+		}
+	} else {
+		if !closefd {
+			return nil, f.RaiseType(ValueErrorType, "Cannot use closefd=False with file name")
+		}
+		path, raised := ToStr(f, name)
+		if raised != nil {
+			return nil, raised
+		}
+		err := posixRetry(func() (err error) {
+			fd, err = syscall.Open(path.Value(), flag|syscall.O_CLOEXEC, 0666)
+			return err
+		})
+		if err != nil {
+			return nil, raiseEnvironmentError(f, IOErrorType, &os.PathError{Op: "open", Path: path.Value(), Err: err})
+		}
+		var st syscall.Stat_t
+		if err := syscall.Fstat(fd, &st); err == nil && st.Mode&syscall.S_IFMT == syscall.S_IFDIR {
+			syscall.Close(fd)
+			return nil, raiseEnvironmentError(f, IOErrorType, &os.PathError{Op: "open", Path: path.Value(), Err: syscall.EISDIR})
+		}
+	}
+	if r.appending {
+		// For consistent behavior, explicitly seek to the end of the file
+		// like CPython does.
+		syscall.Seek(fd, 0, os.SEEK_END)
+	}
+	r.mutex.Lock()
+	r.fd = fd
+	r.closefd = closefd
+	r.mutex.Unlock()
+	if raised := o.Dict().SetItemString(f, "name", name); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func fileIOIsatty(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isatty", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	tty := isatty(r.fd)
+	r.mutex.Unlock()
+	return GetBool(tty).ToObject(), nil
+}
+
+func fileIONew(f *Frame, t *Type, _ Args, _ KWArgs) (*Object, *BaseException) {
+	o := newObject(t)
+	toFileIOUnsafe(o).fd = -1
+	return o, nil
+}
+
+func fileIORead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{fileIOType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "read", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	n, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	defer r.mutex.Unlock()
+	if !r.readable {
+		return nil, f.RaiseType(ValueErrorType, "File not open for reading")
+	}
+	var data []byte
+	var err error
+	if n < 0 {
+		data, err = r.readAll()
+	} else {
+		data = make([]byte, n)
+		err = posixRetry(func() (err error) {
+			n, err = syscall.Read(r.fd, data)
+			return err
+		})
+		if err == nil {
+			data = data[:n]
+		}
+	}
+	if err == syscall.EAGAIN {
+		return None, nil
+	}
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return NewStr(string(data)).ToObject(), nil
+}
+
+func fileIOReadAll(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readall", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	return fileIORead(f, args, nil)
+}
+
+func fileIOReadInto(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readinto", args, fileIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(ByteArrayType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("must be read-write buffer, not %s", args[1].typ.Name()))
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	defer r.mutex.Unlock()
+	if !r.readable {
+		return nil, f.RaiseType(ValueErrorType, "File not open for reading")
+	}
+	dst := toByteArrayUnsafe(args[1])
+	dst.mutex.Lock()
+	var n int
+	err := posixRetry(func() (err error) {
+		n, err = syscall.Read(r.fd, dst.value)
+		return err
+	})
+	dst.mutex.Unlock()
+	if err == syscall.EAGAIN {
+		return None, nil
+	}
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+func fileIORepr(f *Frame, o *Object) (*Object, *BaseException) {
+	r := toFileIOUnsafe(o)
+	r.mutex.Lock()
+	closed := r.fd < 0
+	r.mutex.Unlock()
+	if closed {
+		return NewStr("<_io.FileIO [closed]>").ToObject(), nil
+	}
+	name, raised := o.Dict().GetItemString(f, "name")
+	if raised != nil {
+		return nil, raised
+	}
+	if name == nil {
+		return NewStr(fmt.Sprintf("<_io.FileIO fd=%d mode='%s'>", r.fd, r.mode())).ToObject(), nil
+	}
+	s, raised := Repr(f, name)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(fmt.Sprintf("<_io.FileIO name=%s mode='%s'>", s.Value(), r.mode())).ToObject(), nil
+}
+
+// fileIOReturnMode implements readable(), writable() and seekable().
+func fileIOReturnMode(name string) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, name, args, fileIOType); raised != nil {
+			return nil, raised
+		}
+		r := toFileIOUnsafe(args[0])
+		if raised := r.lock(f); raised != nil {
+			return nil, raised
+		}
+		defer r.mutex.Unlock()
+		switch name {
+		case "readable":
+			return GetBool(r.readable).ToObject(), nil
+		case "writable":
+			return GetBool(r.writable).ToObject(), nil
+		}
+		_, err := syscall.Seek(r.fd, 0, os.SEEK_CUR)
+		return GetBool(err == nil).ToObject(), nil
+	}).ToObject()
+}
+
+func fileIOSeek(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{fileIOType, ObjectType, IntType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	if args[1].isInstance(FloatType) {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	offset, raised := IndexInt(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	whence := 0
+	if argc > 2 {
+		whence = toIntUnsafe(args[2]).Value()
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	pos, err := syscall.Seek(r.fd, int64(offset), whence)
+	r.mutex.Unlock()
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return NewInt(int(pos)).ToObject(), nil
+}
+
+func fileIOTell(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, fileIOType); raised != nil {
+		return nil, raised
+	}
+	return fileIOSeek(f, Args{args[0], NewInt(0).ToObject(), NewInt(os.SEEK_CUR).ToObject()}, nil)
+}
+
+func fileIOTruncate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{fileIOType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "truncate", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	defer r.mutex.Unlock()
+	if !r.writable {
+		return nil, f.RaiseType(ValueErrorType, "File not open for writing")
+	}
+	var size int64
+	if len(args) > 1 && args[1] != None {
+		n, raised := IndexInt(f, args[1])
+		if raised != nil {
+			return nil, raised
+		}
+		size = int64(n)
+	} else {
+		var err error
+		if size, err = syscall.Seek(r.fd, 0, os.SEEK_CUR); err != nil {
+			return nil, raiseEnvironmentError(f, IOErrorType, err)
+		}
+	}
+	if err := syscall.Ftruncate(r.fd, size); err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return NewInt(int(size)).ToObject(), nil
+}
+
+func fileIOWrite(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "write", args, fileIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	data := args[1]
+	if data.isInstance(UnicodeType) {
+		s, raised := toUnicodeUnsafe(data).Encode(f, EncodeDefault, EncodeStrict)
+		if raised != nil {
+			return nil, raised
+		}
+		data = s.ToObject()
+	} else if data.typ.slots.Buffer == nil {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("must be string or buffer, not %s", data.typ.Name()))
+	}
+	r := toFileIOUnsafe(args[0])
+	if raised := r.lock(f); raised != nil {
+		return nil, raised
+	}
+	defer r.mutex.Unlock()
+	if !r.writable {
+		return nil, f.RaiseType(ValueErrorType, "File not open for writing")
+	}
+	var n int
+	raised := bufferApply(f, data, func(b []byte) *BaseException {
+		err := posixRetry(func() (err error) {
+			n, err = syscall.Write(r.fd, b)
+			return err
+		})
+		if err != nil {
+			return raiseEnvironmentError(f, IOErrorType, err)
+		}
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+func initFileIOType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["close"] = newBuiltinFunction("close", fileIOClose).ToObject()
+	dict["closed"] = newProperty(newBuiltinFunction("_get_closed", fileIOGetClosed).ToObject(), nil, nil).ToObject()
+	dict["closefd"] = newProperty(newBuiltinFunction("_get_closefd", fileIOGetCloseFD).ToObject(), nil, nil).ToObject()
+	dict["fileno"] = newBuiltinFunction("fileno", fileIOFileno).ToObject()
+	dict["isatty"] = newBuiltinFunction("isatty", fileIOIsatty).ToObject()
+	dict["mode"] = newProperty(newBuiltinFunction("_get_mode", fileIOGetMode).ToObject(), nil, nil).ToObject()
+	dict["read"] = newBuiltinFunction("read", fileIORead).ToObject()
+	dict["readable"] = fileIOReturnMode("readable")
+	dict["readall"] = newBuiltinFunction("readall", fileIOReadAll).ToObject()
+	dict["readinto"] = newBuiltinFunction("readinto", fileIOReadInto).ToObject()
+	dict["seek"] = newBuiltinFunction("seek", fileIOSeek).ToObject()
+	dict["seekable"] = fileIOReturnMode("seekable")
+	dict["tell"] = newBuiltinFunction("tell", fileIOTell).ToObject()
+	dict["truncate"] = newBuiltinFunction("truncate", fileIOTruncate).ToObject()
+	dict["writable"] = fileIOReturnMode("writable")
+	dict["write"] = newBuiltinFunction("write", fileIOWrite).ToObject()
+	fileIOType.slots.Init = &initSlot{fileIOInit}
+	fileIOType.slots.New = &newSlot{fileIONew}
+	fileIOType.slots.Repr = &unaryOpSlot{fileIORepr}
+}
@@ -0,0 +1,155 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileIOInit(t *testing.T) {
+	f := newTestFile("foo")
+	defer f.cleanup()
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		r, raised := fileIOType.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		defer ioCall(f, r, "close")
+		return GetAttr(f, r, NewStr("mode"), nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(f.path), want: NewUnicode("rb").ToObject()},
+		{args: wrapArgs(f.path, "r+"), want: NewUnicode("rb+").ToObject()},
+		{args: wrapArgs(f.path, "ab"), want: NewUnicode("ab").ToObject()},
+		{args: wrapArgs(f.path, "a+"), want: NewUnicode("ab+").ToObject()},
+		{args: wrapArgs(f.path, "x"), wantExc: mustCreateException(ValueErrorType, "invalid mode: x")},
+		{args: wrapArgs(f.path, "rw"), wantExc: mustCreateException(ValueErrorType, "Must have exactly one of read/write/append mode and at most one plus")},
+		{args: wrapArgs(f.path, "b"), wantExc: mustCreateException(ValueErrorType, "Must have exactly one of read/write/append mode and at most one plus")},
+		{args: wrapArgs(-1), wantExc: mustCreateException(ValueErrorType, "negative file descriptor")},
+		{args: wrapArgs(1.5), wantExc: mustCreateException(TypeErrorType, "integer argument expected, got float")},
+		{args: wrapArgs(f.path, "r", false), wantExc: mustCreateException(ValueErrorType, "Cannot use closefd=False with file name")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFileIOMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, mode, method string, args ...*Object) (*Object, *BaseException) {
+		file := newTestFile("ab\ncd")
+		defer file.cleanup()
+		r, raised := fileIOType.Call(f, wrapArgs(file.path, mode), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		defer ioCall(f, r, "close")
+		return ioCall(f, r, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("r", "read"), want: NewStr("ab\ncd").ToObject()},
+		{args: wrapArgs("r", "read", 2), want: NewStr("ab").ToObject()},
+		{args: wrapArgs("r", "readall"), want: NewStr("ab\ncd").ToObject()},
+		{args: wrapArgs("r", "readline"), want: NewStr("ab\n").ToObject()},
+		{args: wrapArgs("r", "readlines"), want: newTestList("ab\n", "cd").ToObject()},
+		{args: wrapArgs("r", "seek", 0, 2), want: NewInt(5).ToObject()},
+		{args: wrapArgs("r", "tell"), want: NewInt(0).ToObject()},
+		{args: wrapArgs("r", "readable"), want: True.ToObject()},
+		{args: wrapArgs("r", "writable"), want: False.ToObject()},
+		{args: wrapArgs("r", "seekable"), want: True.ToObject()},
+		{args: wrapArgs("r", "isatty"), want: False.ToObject()},
+		{args: wrapArgs("a", "tell"), want: NewInt(5).ToObject()},
+		{args: wrapArgs("w", "write", "xyz"), want: NewInt(3).ToObject()},
+		{args: wrapArgs("w", "write", NewUnicode("xyz")), want: NewInt(3).ToObject()},
+		{args: wrapArgs("r+", "truncate", 1), want: NewInt(1).ToObject()},
+		{args: wrapArgs("r", "write", "x"), wantExc: mustCreateException(ValueErrorType, "File not open for writing")},
+		{args: wrapArgs("w", "read"), wantExc: mustCreateException(ValueErrorType, "File not open for reading")},
+		{args: wrapArgs("w", "write", 1), wantExc: mustCreateException(TypeErrorType, "must be string or buffer, not int")},
+		{args: wrapArgs("r", "seek", 1.5), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFileIOReadInto(t *testing.T) {
+	f := newTestFile("abc")
+	defer f.cleanup()
+	fr := NewRootFrame()
+	r := mustNotRaise(fileIOType.Call(fr, wrapArgs(f.path), nil))
+	b := newTestByteArray("xx")
+	if got := mustNotRaise(ioCall(fr, r, "readinto", b.ToObject())); !got.isInstance(IntType) || toIntUnsafe(got).Value() != 2 {
+		t.Errorf("readinto() = %v, want 2", got)
+	}
+	if got := string(b.Value()); got != "ab" {
+		t.Errorf("readinto() filled %q, want %q", got, "ab")
+	}
+	mustNotRaise(ioCall(fr, r, "close"))
+}
+
+func TestFileIOClosed(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, method string, args ...*Object) (*Object, *BaseException) {
+		file := newTestFile("abc")
+		defer file.cleanup()
+		r, raised := fileIOType.Call(f, wrapArgs(file.path), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(f, r, "close"); raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, r, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("close"), want: None},
+		{args: wrapArgs("__repr__"), want: NewStr("<_io.FileIO [closed]>").ToObject()},
+		{args: wrapArgs("read"), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file")},
+		{args: wrapArgs("fileno"), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file")},
+		{args: wrapArgs("seek", 0), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFileIOCloseFD(t *testing.T) {
+	osFile, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(osFile.Name())
+	defer osFile.Close()
+	fr := NewRootFrame()
+	r := mustNotRaise(fileIOType.Call(fr, wrapArgs(int(osFile.Fd()), "w", false), nil))
+	repr, raised := Repr(fr, r)
+	if raised != nil {
+		t.Fatal(raised)
+	}
+	if want := "<_io.FileIO name="; !strings.HasPrefix(repr.Value(), want) {
+		t.Errorf("repr() = %q, want prefix %q", repr.Value(), want)
+	}
+	mustNotRaise(ioCall(fr, r, "close"))
+	if _, err := osFile.WriteString("x"); err != nil {
+		t.Errorf("write after FileIO.close() with closefd=False failed: %v", err)
+	}
+}
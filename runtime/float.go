@@ -108,7 +108,11 @@ func floatGetNewArgs(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "__getnewargs__", args, FloatType); raised != nil {
 		return nil, raised
 	}
-	return NewTuple1(args[0]).ToObject(), nil
+	o := args[0]
+	if o.typ != FloatType {
+		o = NewFloat(toFloatUnsafe(args[0]).Value()).ToObject()
+	}
+	return NewTuple1(o).ToObject(), nil
 }
 
 func floatGT(f *Frame, v, w *Object) (*Object, *BaseException) {
@@ -0,0 +1,351 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"reflect"
+)
+
+var (
+	functoolsCmpToKeyParams = NewParamSpec("cmp_to_key", []Param{{"mycmp", nil}}, false, false)
+	// keyWrapperType corresponds to the Python type
+	// 'functools.KeyWrapper', which is returned by cmp_to_key().
+	keyWrapperType = newBasisType("KeyWrapper", reflect.TypeOf(keyWrapper{}), toKeyWrapperUnsafe, ObjectType)
+	// partialType corresponds to the Python type 'functools.partial'.
+	partialType = newBasisType("partial", reflect.TypeOf(partial{}), toPartialUnsafe, ObjectType)
+)
+
+// keyWrapper represents Python 'functools.KeyWrapper' objects. The key
+// function returned by cmp_to_key() is a keyWrapper with a nil obj and
+// calling it wraps its argument in a new keyWrapper with the same cmp.
+type keyWrapper struct {
+	Object
+	cmp *Object
+	obj *Object
+}
+
+func toKeyWrapperUnsafe(o *Object) *keyWrapper {
+	return (*keyWrapper)(o.toPointer())
+}
+
+// ToObject upcasts k to an Object.
+func (k *keyWrapper) ToObject() *Object {
+	return &k.Object
+}
+
+func newKeyWrapper(cmp, obj *Object) *keyWrapper {
+	k := toKeyWrapperUnsafe(newObject(keyWrapperType))
+	k.cmp = cmp
+	k.obj = obj
+	return k
+}
+
+func keyWrapperCall(f *Frame, callable *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if len(kwargs) > 0 {
+		return nil, f.RaiseType(TypeErrorType, "K() does not take keyword arguments")
+	}
+	if raised := checkFunctionArgs(f, "K", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return newKeyWrapper(toKeyWrapperUnsafe(callable).cmp, args[0]).ToObject(), nil
+}
+
+func keyWrapperGetObj(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_obj", args, keyWrapperType); raised != nil {
+		return nil, raised
+	}
+	if obj := toKeyWrapperUnsafe(args[0]).obj; obj != nil {
+		return obj, nil
+	}
+	return None, nil
+}
+
+func keyWrapperCompare(f *Frame, v, w *Object, op binaryOpFunc) (*Object, *BaseException) {
+	if !w.isInstance(keyWrapperType) {
+		return nil, f.RaiseType(TypeErrorType, "other argument must be K instance")
+	}
+	k, other := toKeyWrapperUnsafe(v), toKeyWrapperUnsafe(w)
+	if k.obj == nil || other.obj == nil {
+		return nil, f.RaiseType(AttributeErrorType, "object")
+	}
+	r, raised := k.cmp.Call(f, Args{k.obj, other.obj}, nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return op(f, r, NewInt(0).ToObject())
+}
+
+func keyWrapperEq(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return keyWrapperCompare(f, v, w, Eq)
+}
+
+func keyWrapperGE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return keyWrapperCompare(f, v, w, GE)
+}
+
+func keyWrapperGT(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return keyWrapperCompare(f, v, w, GT)
+}
+
+func keyWrapperLE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return keyWrapperCompare(f, v, w, LE)
+}
+
+func keyWrapperLT(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return keyWrapperCompare(f, v, w, LT)
+}
+
+func keyWrapperNE(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return keyWrapperCompare(f, v, w, NE)
+}
+
+func initKeyWrapperType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("functools").ToObject()
+	dict["obj"] = newProperty(newBuiltinFunction("_get_obj", keyWrapperGetObj).ToObject(), nil, nil).ToObject()
+	keyWrapperType.flags &^= typeFlagBasetype | typeFlagInstantiable
+	keyWrapperType.flags |= typeFlagNoDict
+	keyWrapperType.slots.Call = &callSlot{keyWrapperCall}
+	keyWrapperType.slots.Eq = &binaryOpSlot{keyWrapperEq}
+	keyWrapperType.slots.GE = &binaryOpSlot{keyWrapperGE}
+	keyWrapperType.slots.GT = &binaryOpSlot{keyWrapperGT}
+	keyWrapperType.slots.Hash = &unaryOpSlot{hashNotImplemented}
+	keyWrapperType.slots.LE = &binaryOpSlot{keyWrapperLE}
+	keyWrapperType.slots.LT = &binaryOpSlot{keyWrapperLT}
+	keyWrapperType.slots.NE = &binaryOpSlot{keyWrapperNE}
+}
+
+// partial represents Python 'functools.partial' objects.
+type partial struct {
+	Object
+	fn   *Object `attr:"func"`
+	args *Tuple  `attr:"args"`
+	kw   *Dict   `attr:"keywords"`
+}
+
+func toPartialUnsafe(o *Object) *partial {
+	return (*partial)(o.toPointer())
+}
+
+// ToObject upcasts p to an Object.
+func (p *partial) ToObject() *Object {
+	return &p.Object
+}
+
+// kwargs returns the keywords stored in p overridden by the keyword arguments
+// in kwargs.
+func (p *partial) kwargs(f *Frame, kwargs KWArgs) (KWArgs, *BaseException) {
+	if p.kw.Len() == 0 {
+		return kwargs, nil
+	}
+	merged := make(KWArgs, 0, p.kw.Len()+len(kwargs))
+	p.kw.mutex.Lock(f)
+	iter := newDictEntryIterator(p.kw)
+	p.kw.mutex.Unlock(f)
+	for entry := iter.next(); entry != nil; entry = iter.next() {
+		if !entry.key.isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, "keywords must be strings")
+		}
+		if name := toStrUnsafe(entry.key).Value(); kwargs.get(name, nil) == nil {
+			merged = append(merged, KWArg{Name: name, Value: entry.value})
+		}
+	}
+	return append(merged, kwargs...), nil
+}
+
+func partialCall(f *Frame, callable *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	p := toPartialUnsafe(callable)
+	if len(p.args.elems) > 0 {
+		args = append(p.args.elems[:len(p.args.elems):len(p.args.elems)], args...)
+	}
+	kwargs, raised := p.kwargs(f, kwargs)
+	if raised != nil {
+		return nil, raised
+	}
+	return p.fn.Call(f, args, kwargs)
+}
+
+func partialDelDict(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	return nil, f.RaiseType(TypeErrorType, "a partial object's dictionary may not be deleted")
+}
+
+func partialGetDict(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_dict", args, partialType); raised != nil {
+		return nil, raised
+	}
+	return args[0].Dict().ToObject(), nil
+}
+
+func partialNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if len(args) == 0 {
+		return nil, f.RaiseType(TypeErrorType, "type 'partial' takes at least one argument")
+	}
+	fn, fnArgs, kw := args[0], args[1:], kwargs.makeDict()
+	if fn.typ.slots.Call == nil {
+		return nil, f.RaiseType(TypeErrorType, "the first argument must be callable")
+	}
+	// Flatten partial(partial(fn, *a1, **k1), *a2, **k2) into
+	// partial(fn, *(a1 + a2), **dict(k1, **k2)) so that calls go straight
+	// to fn. Subclasses and partials with attributes are left alone since
+	// they may behave differently.
+	if t == partialType && fn.typ == partialType && fn.Dict().Len() == 0 {
+		inner := toPartialUnsafe(fn)
+		fn = inner.fn
+		fnArgs = append(inner.args.elems[:len(inner.args.elems):len(inner.args.elems)], fnArgs...)
+		merged := NewDict()
+		if raised := merged.Update(f, inner.kw.ToObject()); raised != nil {
+			return nil, raised
+		}
+		if raised := merged.Update(f, kw.ToObject()); raised != nil {
+			return nil, raised
+		}
+		kw = merged
+	}
+	p := toPartialUnsafe(newObject(t))
+	p.fn = fn
+	p.args = NewTuple(fnArgs.makeCopy()...)
+	p.kw = kw
+	return p.ToObject(), nil
+}
+
+func partialReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, partialType); raised != nil {
+		return nil, raised
+	}
+	p := toPartialUnsafe(args[0])
+	dict := None
+	if d := p.Dict(); d.Len() > 0 {
+		dict = d.ToObject()
+	}
+	state := NewTuple4(p.fn, p.args.ToObject(), p.kw.ToObject(), dict).ToObject()
+	return NewTuple3(p.typ.ToObject(), NewTuple1(p.fn).ToObject(), state).ToObject(), nil
+}
+
+func partialSetDict(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_set_dict", args, partialType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(DictType) {
+		return nil, f.RaiseType(TypeErrorType, "setting partial object's dictionary to a non-dict")
+	}
+	args[0].setDict(toDictUnsafe(args[1]))
+	return None, nil
+}
+
+func partialSetState(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__setstate__", args, partialType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	state := args[1]
+	if !state.isInstance(TupleType) || len(toTupleUnsafe(state).elems) != 4 {
+		return nil, f.RaiseType(TypeErrorType, "invalid partial state")
+	}
+	elems := toTupleUnsafe(state).elems
+	fn, fnArgs, kw, dict := elems[0], elems[1], elems[2], elems[3]
+	if fn.typ.slots.Call == nil || !fnArgs.isInstance(TupleType) ||
+		(kw != None && !kw.isInstance(DictType)) || (dict != None && !dict.isInstance(DictType)) {
+		return nil, f.RaiseType(TypeErrorType, "invalid partial state")
+	}
+	p := toPartialUnsafe(args[0])
+	p.fn = fn
+	p.args = NewTuple(toTupleUnsafe(fnArgs).elems...)
+	if kw == None {
+		p.kw = NewDict()
+	} else {
+		p.kw = toDictUnsafe(kw)
+	}
+	if dict == None {
+		p.setDict(NewDict())
+	} else {
+		p.setDict(toDictUnsafe(dict))
+	}
+	return None, nil
+}
+
+func initPartialType(dict map[string]*Object) {
+	dict["__dict__"] = newProperty(newBuiltinFunction("_get_dict", partialGetDict).ToObject(), newBuiltinFunction("_set_dict", partialSetDict).ToObject(), newBuiltinFunction("_del_dict", partialDelDict).ToObject()).ToObject()
+	dict["__module__"] = NewStr("functools").ToObject()
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", partialReduce).ToObject()
+	dict["__setstate__"] = newBuiltinFunction("__setstate__", partialSetState).ToObject()
+	partialType.slots.Call = &callSlot{partialCall}
+	partialType.slots.New = &newSlot{partialNew}
+}
+
+func functoolsCmpToKey(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [1]*Object
+	if raised := functoolsCmpToKeyParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	return newKeyWrapper(validated[0], nil).ToObject(), nil
+}
+
+func functoolsReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType, ObjectType}
+	if len(args) == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkFunctionArgs(f, "reduce", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	fn := args[0]
+	iter, raised := Iter(f, args[1])
+	if raised != nil {
+		if raised.isInstance(TypeErrorType) {
+			f.RestoreExc(nil, nil)
+			return nil, f.RaiseType(TypeErrorType, "reduce() arg 2 must support iteration")
+		}
+		return nil, raised
+	}
+	var result *Object
+	if len(args) > 2 {
+		result = args[2]
+	}
+	for {
+		item, raised := Next(f, iter)
+		if raised != nil {
+			if !raised.isInstance(StopIterationType) {
+				return nil, raised
+			}
+			f.RestoreExc(nil, nil)
+			break
+		}
+		if result == nil {
+			result = item
+			continue
+		}
+		if result, raised = fn.Call(f, Args{result, item}, nil); raised != nil {
+			return nil, raised
+		}
+	}
+	if result == nil {
+		return nil, f.RaiseType(TypeErrorType, "reduce() of empty sequence with no initial value")
+	}
+	return result, nil
+}
+
+func init() {
+	RegisterModule("_functools", NewCode("<module>", "_functools", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		attrs := map[string]*Object{
+			"cmp_to_key": newBuiltinFunction("cmp_to_key", functoolsCmpToKey).ToObject(),
+			"partial":    partialType.ToObject(),
+			"reduce":     newBuiltinFunction("reduce", functoolsReduce).ToObject(),
+		}
+		for name, o := range attrs {
+			if raised := f.Globals().SetItemString(f, name, o); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+var functoolsCaptureFunc = newBuiltinFunction("capture", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	return NewTuple2(NewTuple(args.makeCopy()...).ToObject(), kwargs.makeDict().ToObject()).ToObject(), nil
+}).ToObject()
+
+func TestPartialCall(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, newArgs Args, newKWArgs KWArgs, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		p, raised := partialType.Call(f, newArgs, newKWArgs)
+		if raised != nil {
+			return nil, raised
+		}
+		return p.Call(f, args, kwargs)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(wrapArgs(functoolsCaptureFunc), KWArgs(nil), Args{}, KWArgs(nil)), want: newTestTuple(NewTuple(), NewDict()).ToObject()},
+		{args: wrapArgs(wrapArgs(functoolsCaptureFunc, 1), KWArgs{{"a", NewInt(2).ToObject()}}, wrapArgs(3), KWArgs{{"b", NewInt(4).ToObject()}}), want: newTestTuple(newTestTuple(1, 3), newTestDict("a", 2, "b", 4)).ToObject()},
+		{args: wrapArgs(wrapArgs(functoolsCaptureFunc), KWArgs{{"a", NewInt(2).ToObject()}}, Args{}, KWArgs{{"a", NewInt(5).ToObject()}}), want: newTestTuple(NewTuple(), newTestDict("a", 5)).ToObject()},
+		{args: wrapArgs(Args{}, KWArgs(nil), Args{}, KWArgs(nil)), wantExc: mustCreateException(TypeErrorType, "type 'partial' takes at least one argument")},
+		{args: wrapArgs(wrapArgs(1), KWArgs(nil), Args{}, KWArgs(nil)), wantExc: mustCreateException(TypeErrorType, "the first argument must be callable")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPartialFlatten(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+		inner, raised := partialType.Call(f, wrapArgs(o, 1), KWArgs{{"a", NewInt(2).ToObject()}})
+		if raised != nil {
+			return nil, raised
+		}
+		p, raised := partialType.Call(f, wrapArgs(inner, 3), KWArgs{{"a", NewInt(4).ToObject()}})
+		if raised != nil {
+			return nil, raised
+		}
+		outer := toPartialUnsafe(p)
+		return NewTuple3(GetBool(outer.fn == o).ToObject(), outer.args.ToObject(), outer.kw.ToObject()).ToObject(), nil
+	})
+	cas := invokeTestCase{args: wrapArgs(functoolsCaptureFunc), want: newTestTuple(true, newTestTuple(1, 3), newTestDict("a", 4)).ToObject()}
+	if err := runInvokeTestCase(fun, &cas); err != "" {
+		t.Error(err)
+	}
+}
+
+func TestPartialSetState(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, state *Object) (*Object, *BaseException) {
+		p, raised := partialType.Call(f, wrapArgs(functoolsCaptureFunc), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := partialSetState(f, Args{p, state}, nil); raised != nil {
+			return nil, raised
+		}
+		return p.Call(f, wrapArgs(2), nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestTuple(functoolsCaptureFunc, newTestTuple(1), None, None)), want: newTestTuple(newTestTuple(1, 2), NewDict()).ToObject()},
+		{args: wrapArgs(newTestTuple(functoolsCaptureFunc, NewTuple(), newTestDict("a", 3), newTestDict("x", 4))), want: newTestTuple(newTestTuple(2), newTestDict("a", 3)).ToObject()},
+		{args: wrapArgs(newTestTuple(1, NewTuple(), None, None)), wantExc: mustCreateException(TypeErrorType, "invalid partial state")},
+		{args: wrapArgs(newTestTuple(functoolsCaptureFunc, NewList(), None, None)), wantExc: mustCreateException(TypeErrorType, "invalid partial state")},
+		{args: wrapArgs(newTestTuple(functoolsCaptureFunc)), wantExc: mustCreateException(TypeErrorType, "invalid partial state")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFunctoolsReduce(t *testing.T) {
+	add := newBuiltinFunction("add", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return Add(f, args[0], args[1])
+	}).ToObject()
+	cases := []invokeTestCase{
+		{args: wrapArgs(add, newTestList(1, 2, 3)), want: NewInt(6).ToObject()},
+		{args: wrapArgs(add, newTestList(1, 2, 3), 10), want: NewInt(16).ToObject()},
+		{args: wrapArgs(add, NewList(), "x"), want: NewStr("x").ToObject()},
+		{args: wrapArgs(add, "ab"), want: NewStr("ab").ToObject()},
+		{args: wrapArgs(add, NewList()), wantExc: mustCreateException(TypeErrorType, "reduce() of empty sequence with no initial value")},
+		{args: wrapArgs(add, 1), wantExc: mustCreateException(TypeErrorType, "reduce() arg 2 must support iteration")},
+		{args: wrapArgs(add), wantExc: mustCreateException(TypeErrorType, "'reduce' requires 3 arguments")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("reduce", functoolsReduce).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCmpToKey(t *testing.T) {
+	reversedCmp := newBuiltinFunction("cmp", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return Sub(f, args[1], args[0])
+	}).ToObject()
+	fun := wrapFuncForTest(func(f *Frame, op binaryOpFunc, a, b *Object) (*Object, *BaseException) {
+		key, raised := functoolsCmpToKey(f, Args{reversedCmp}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if a, raised = key.Call(f, Args{a}, nil); raised != nil {
+			return nil, raised
+		}
+		if b.isInstance(IntType) {
+			if b, raised = key.Call(f, Args{b}, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		return op(f, a, b)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(LT, 2, 1), want: True.ToObject()},
+		{args: wrapArgs(LE, 1, 1), want: True.ToObject()},
+		{args: wrapArgs(GT, 2, 1), want: False.ToObject()},
+		{args: wrapArgs(GE, 1, 2), want: True.ToObject()},
+		{args: wrapArgs(Eq, 1, 1), want: True.ToObject()},
+		{args: wrapArgs(NE, 1, 1), want: False.ToObject()},
+		{args: wrapArgs(LT, 1, "a"), wantExc: mustCreateException(TypeErrorType, "other argument must be K instance")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// builtinsCacheSize is the number of entries in builtinsCache. It must be a
+// power of two.
+const builtinsCacheSize = 512
+
+// builtinsCacheEntry records the result of looking up name in the builtins
+// dict when its version was version. A nil value means name was absent.
+// Entries are immutable once published.
+type builtinsCacheEntry struct {
+	builtins *Dict
+	version  int64
+	name     *Str
+	value    *Object
+}
+
+// builtinsCache is a global, direct mapped cache of Builtins lookups keyed by
+// (Builtins version, name). Like typeAttrCache, only interned names are
+// cached and they are matched by identity.
+var builtinsCache [builtinsCacheSize]atomic.Pointer[builtinsCacheEntry]
+
+// GlobalCache is an inline cache for the global or builtin name referenced at
+// a single site in compiled code. It remembers the object that name resolved
+// to along with the versions of the globals and Builtins dicts at the time so
+// that subsequent lookups can be skipped until one of those dicts changes.
+// The zero value is an empty cache.
+type GlobalCache struct {
+	entry atomic.Pointer[globalCacheEntry]
+}
+
+// globalCacheEntry is an immutable snapshot of a resolved name.
+type globalCacheEntry struct {
+	globals         *Dict
+	globalsVersion  int64
+	builtinsVersion int64
+	value           *Object
+}
+
+// ResolveGlobal behaves like the ResolveGlobal function but returns the cached
+// result when neither the frame's globals nor Builtins have been modified since
+// name was last resolved through c.
+func (c *GlobalCache) ResolveGlobal(f *Frame, name *Str) (*Object, *BaseException) {
+	globals := f.Globals()
+	// Load the versions before doing any lookups so that concurrent
+	// modifications result in a stale entry that never matches.
+	globalsVersion := globals.loadVersion()
+	builtinsVersion := Builtins.loadVersion()
+	if e := c.entry.Load(); e != nil && e.globals == globals && e.globalsVersion == globalsVersion && e.builtinsVersion == builtinsVersion {
+		return e.value, nil
+	}
+	value, raised := ResolveGlobal(f, name)
+	if raised != nil {
+		return nil, raised
+	}
+	c.entry.Store(&globalCacheEntry{globals, globalsVersion, builtinsVersion, value})
+	return value, nil
+}
+
+// lookupBuiltin returns the value of name in Builtins or nil if it's absent,
+// consulting builtinsCache first.
+func lookupBuiltin(f *Frame, name *Str) (*Object, *BaseException) {
+	builtins := Builtins
+	if !name.interned {
+		return builtins.GetItem(f, name.ToObject())
+	}
+	// Load the version before the lookup so that a concurrent
+	// modification results in a stale entry that never matches.
+	version := builtins.loadVersion()
+	entry := &builtinsCache[uintptr(unsafe.Pointer(name))>>4&(builtinsCacheSize-1)]
+	if e := entry.Load(); e != nil && e.builtins == builtins && e.version == version && e.name == name {
+		return e.value, nil
+	}
+	value, raised := builtins.GetItem(f, name.ToObject())
+	if raised != nil {
+		return nil, raised
+	}
+	entry.Store(&builtinsCacheEntry{builtins, version, name, value})
+	return value, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestGlobalCacheResolveGlobal(t *testing.T) {
+	name := InternStr("str")
+	fun := wrapFuncForTest(func(f *Frame, modify func(*Frame, *Dict) *BaseException) (*Tuple, *BaseException) {
+		var c GlobalCache
+		f.globals = NewDict()
+		before, raised := c.ResolveGlobal(f, name)
+		if raised != nil {
+			return nil, raised
+		}
+		if raised := modify(f, f.globals); raised != nil {
+			return nil, raised
+		}
+		after, raised := c.ResolveGlobal(f, name)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple(before, after), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(func(*Frame, *Dict) *BaseException { return nil }), want: newTestTuple(StrType, StrType).ToObject()},
+		{args: wrapArgs(func(f *Frame, globals *Dict) *BaseException {
+			return globals.SetItem(f, name.ToObject(), NewInt(1).ToObject())
+		}), want: newTestTuple(StrType, 1).ToObject()},
+		{args: wrapArgs(func(f *Frame, globals *Dict) *BaseException {
+			f.globals = newStringDict(map[string]*Object{"str": NewInt(2).ToObject()})
+			return nil
+		}), want: newTestTuple(StrType, 2).ToObject()},
+		{args: wrapArgs(func(f *Frame, globals *Dict) *BaseException {
+			return Builtins.SetItem(f, name.ToObject(), NewInt(3).ToObject())
+		}), want: newTestTuple(StrType, 3).ToObject()},
+		{args: wrapArgs(func(f *Frame, globals *Dict) *BaseException {
+			_, raised := Builtins.DelItem(f, name.ToObject())
+			return raised
+		}), wantExc: mustCreateException(NameErrorType, "name 'str' is not defined")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+		// Restore the builtin that some cases replace or remove.
+		mustNotRaise(nil, Builtins.SetItem(NewRootFrame(), name.ToObject(), StrType.ToObject()))
+	}
+}
+
+func TestLookupBuiltin(t *testing.T) {
+	name := InternStr("len")
+	lenFunc := mustNotRaise(Builtins.GetItem(NewRootFrame(), name.ToObject()))
+	fun := wrapFuncForTest(func(f *Frame, modify func(*Frame) *BaseException) (*Tuple, *BaseException) {
+		before, raised := lookupBuiltin(f, name)
+		if raised != nil {
+			return nil, raised
+		}
+		if raised := modify(f); raised != nil {
+			return nil, raised
+		}
+		after, raised := lookupBuiltin(f, name)
+		if raised != nil {
+			return nil, raised
+		}
+		if after == nil {
+			after = None
+		}
+		return NewTuple(before, after), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(func(*Frame) *BaseException { return nil }), want: NewTuple(lenFunc, lenFunc).ToObject()},
+		{args: wrapArgs(func(f *Frame) *BaseException {
+			return Builtins.SetItem(f, name.ToObject(), NewInt(1).ToObject())
+		}), want: NewTuple(lenFunc, NewInt(1).ToObject()).ToObject()},
+		{args: wrapArgs(func(f *Frame) *BaseException {
+			_, raised := Builtins.DelItem(f, name.ToObject())
+			return raised
+		}), want: NewTuple(lenFunc, None).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+		mustNotRaise(nil, Builtins.SetItem(NewRootFrame(), name.ToObject(), lenFunc))
+	}
+}
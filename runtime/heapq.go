@@ -0,0 +1,287 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+// heapqLess reports whether v should sit above w in the heap. For a max heap
+// the comparison is reversed.
+func heapqLess(f *Frame, v, w *Object, maxHeap bool) (bool, *BaseException) {
+	if maxHeap {
+		return LTBool(f, w, v)
+	}
+	return LTBool(f, v, w)
+}
+
+// heapqSiftDown moves the item at pos towards startPos until its parent is no
+// greater than it. This is the _siftdown() of heapq.py. If a comparison
+// raises, the item is stored at its current position so that no element is
+// lost. NOTE: The list owning elems must be at least read locked.
+func heapqSiftDown(f *Frame, elems []*Object, startPos, pos int, maxHeap bool) *BaseException {
+	newItem := elems[pos]
+	for pos > startPos {
+		parentPos := (pos - 1) >> 1
+		lt, raised := heapqLess(f, newItem, elems[parentPos], maxHeap)
+		if raised != nil {
+			elems[pos] = newItem
+			return raised
+		}
+		if !lt {
+			break
+		}
+		elems[pos] = elems[parentPos]
+		pos = parentPos
+	}
+	elems[pos] = newItem
+	return nil
+}
+
+// heapqSiftUp bubbles the smaller child of pos up until hitting a leaf and
+// then sifts the item originally at pos down into place. This is the
+// _siftup() of heapq.py. NOTE: The list owning elems must be at least read
+// locked.
+func heapqSiftUp(f *Frame, elems []*Object, pos int, maxHeap bool) *BaseException {
+	endPos := len(elems)
+	startPos := pos
+	newItem := elems[pos]
+	for childPos := 2*pos + 1; childPos < endPos; childPos = 2*pos + 1 {
+		if rightPos := childPos + 1; rightPos < endPos {
+			lt, raised := heapqLess(f, elems[childPos], elems[rightPos], maxHeap)
+			if raised != nil {
+				elems[pos] = newItem
+				return raised
+			}
+			if !lt {
+				childPos = rightPos
+			}
+		}
+		elems[pos] = elems[childPos]
+		pos = childPos
+	}
+	elems[pos] = newItem
+	return heapqSiftDown(f, elems, startPos, pos, maxHeap)
+}
+
+// heapqBuild transforms elems into a heap in O(len(elems)) time. NOTE: The
+// list owning elems must be at least read locked.
+func heapqBuild(f *Frame, elems []*Object, maxHeap bool) *BaseException {
+	for i := len(elems)/2 - 1; i >= 0; i-- {
+		if raised := heapqSiftUp(f, elems, i, maxHeap); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+// The heap functions below only hold the list's write lock while changing
+// its length. Like List.Sort, comparisons and the element moves they drive
+// happen under the read lock so that __lt__ methods may inspect the heap.
+
+func heapqHeapPush(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "heappush", args, ListType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	l := toListUnsafe(args[0])
+	l.mutex.Lock()
+	numElems := len(l.elems)
+	l.resize(numElems + 1)
+	l.elems[numElems] = args[1]
+	l.mutex.Unlock()
+	l.mutex.RLock()
+	var raised *BaseException
+	// The list may have been changed by another thread in the meantime
+	// but sifting its last item into place keeps the heap invariant.
+	if n := len(l.elems); n > 0 {
+		raised = heapqSiftDown(f, l.elems, 0, n-1, false)
+	}
+	l.mutex.RUnlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func heapqHeapPop(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "heappop", args, ListType); raised != nil {
+		return nil, raised
+	}
+	l := toListUnsafe(args[0])
+	l.mutex.Lock()
+	numElems := len(l.elems)
+	if numElems == 0 {
+		l.mutex.Unlock()
+		return nil, f.RaiseType(IndexErrorType, "index out of range")
+	}
+	item := l.elems[numElems-1]
+	l.elems[numElems-1] = nil
+	l.elems = l.elems[:numElems-1]
+	if numElems > 1 {
+		item, l.elems[0] = l.elems[0], item
+	}
+	l.mutex.Unlock()
+	l.mutex.RLock()
+	var raised *BaseException
+	if len(l.elems) > 0 {
+		raised = heapqSiftUp(f, l.elems, 0, false)
+	}
+	l.mutex.RUnlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return item, nil
+}
+
+func heapqHeapReplace(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "heapreplace", args, ListType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	l := toListUnsafe(args[0])
+	l.mutex.RLock()
+	if len(l.elems) == 0 {
+		l.mutex.RUnlock()
+		return nil, f.RaiseType(IndexErrorType, "index out of range")
+	}
+	item := l.elems[0]
+	l.elems[0] = args[1]
+	raised := heapqSiftUp(f, l.elems, 0, false)
+	l.mutex.RUnlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return item, nil
+}
+
+func heapqHeapPushPop(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "heappushpop", args, ListType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	l := toListUnsafe(args[0])
+	item := args[1]
+	l.mutex.RLock()
+	if len(l.elems) == 0 {
+		l.mutex.RUnlock()
+		return item, nil
+	}
+	lt, raised := LTBool(f, l.elems[0], item)
+	if raised == nil && lt {
+		item, l.elems[0] = l.elems[0], item
+		raised = heapqSiftUp(f, l.elems, 0, false)
+	}
+	l.mutex.RUnlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return item, nil
+}
+
+func heapqHeapify(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "heapify", args, ListType); raised != nil {
+		return nil, raised
+	}
+	l := toListUnsafe(args[0])
+	l.mutex.RLock()
+	raised := heapqBuild(f, l.elems, false)
+	l.mutex.RUnlock()
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+// heapqSelect returns a list of the n largest (or, when smallest is true,
+// the n smallest) items of iterable in sorted order. The items are kept in
+// a heap of size n whose root is the weakest candidate so far.
+func heapqSelect(f *Frame, name string, args Args, smallest bool) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, name, args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if args[0].typ.slots.Index == nil {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	n, raised := IndexInt(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	iter, raised := Iter(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	var elems []*Object
+	if n > 0 {
+		for len(elems) < n {
+			item, raised := Next(f, iter)
+			if raised != nil {
+				if !raised.isInstance(StopIterationType) {
+					return nil, raised
+				}
+				f.RestoreExc(nil, nil)
+				break
+			}
+			elems = append(elems, item)
+		}
+		if len(elems) == n {
+			if raised := heapqBuild(f, elems, smallest); raised != nil {
+				return nil, raised
+			}
+			raised = seqForEach(f, iter, func(item *Object) *BaseException {
+				lt, raised := heapqLess(f, elems[0], item, smallest)
+				if raised != nil || !lt {
+					return raised
+				}
+				elems[0] = item
+				return heapqSiftUp(f, elems, 0, smallest)
+			})
+			if raised != nil {
+				return nil, raised
+			}
+		}
+	}
+	result := NewList(elems...)
+	if raised := result.Sort(f); raised != nil {
+		return nil, raised
+	}
+	if !smallest {
+		if _, raised := listReverse(f, Args{result.ToObject()}, nil); raised != nil {
+			return nil, raised
+		}
+	}
+	return result.ToObject(), nil
+}
+
+func heapqNLargest(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	return heapqSelect(f, "nlargest", args, false)
+}
+
+func heapqNSmallest(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	return heapqSelect(f, "nsmallest", args, true)
+}
+
+func init() {
+	RegisterModule("_heapq", NewCode("<module>", "_heapq", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		attrs := map[string]*Object{
+			"heapify":     newBuiltinFunction("heapify", heapqHeapify).ToObject(),
+			"heappop":     newBuiltinFunction("heappop", heapqHeapPop).ToObject(),
+			"heappush":    newBuiltinFunction("heappush", heapqHeapPush).ToObject(),
+			"heappushpop": newBuiltinFunction("heappushpop", heapqHeapPushPop).ToObject(),
+			"heapreplace": newBuiltinFunction("heapreplace", heapqHeapReplace).ToObject(),
+			"nlargest":    newBuiltinFunction("nlargest", heapqNLargest).ToObject(),
+			"nsmallest":   newBuiltinFunction("nsmallest", heapqNSmallest).ToObject(),
+		}
+		for name, o := range attrs {
+			if raised := f.Globals().SetItemString(f, name, o); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
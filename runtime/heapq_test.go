@@ -0,0 +1,214 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestHeapqHeapPushPop(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, items *List) (*Object, *BaseException) {
+		heap := NewList()
+		for _, item := range items.elems {
+			if _, raised := heapqHeapPush(f, Args{heap.ToObject(), item}, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		var result []*Object
+		for len(heap.elems) > 0 {
+			item, raised := heapqHeapPop(f, Args{heap.ToObject()}, nil)
+			if raised != nil {
+				return nil, raised
+			}
+			result = append(result, item)
+		}
+		return NewList(result...).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewList()), want: NewList().ToObject()},
+		{args: wrapArgs(newTestList(5, 1, 4, 2, 3, 1)), want: newTestList(1, 1, 2, 3, 4, 5).ToObject()},
+		{args: wrapArgs(newTestList("b", "c", "a")), want: newTestList("a", "b", "c").ToObject()},
+		{args: wrapArgs(newTestList(1, "a", NewList())), want: newTestList(1, NewList(), "a").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapqHeapify(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, l *List) (*Object, *BaseException) {
+		if _, raised := heapqHeapify(f, Args{l.ToObject()}, nil); raised != nil {
+			return nil, raised
+		}
+		return l.ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewList()), want: NewList().ToObject()},
+		{args: wrapArgs(newTestList(3, 2, 1)), want: newTestList(1, 2, 3).ToObject()},
+		{args: wrapArgs(newTestList(9, 8, 7, 6, 5, 4, 3)), want: newTestList(3, 5, 4, 6, 8, 9, 7).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 3, 4, 5)), want: newTestList(1, 2, 3, 4, 5).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapqHeapPop(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestList(1)), want: NewInt(1).ToObject()},
+		{args: wrapArgs(NewList()), wantExc: mustCreateException(IndexErrorType, "index out of range")},
+		{args: wrapArgs(newTestTuple(1)), wantExc: mustCreateException(TypeErrorType, "'heappop' requires a 'list' object but received a \"tuple\"")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("heappop", heapqHeapPop).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapqHeapReplace(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, l *List, item *Object) (*Object, *BaseException) {
+		ret, raised := heapqHeapReplace(f, Args{l.ToObject(), item}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(ret, l.ToObject()).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestList(1, 2, 3), 4), want: newTestTuple(1, newTestList(2, 4, 3)).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 3), 0), want: newTestTuple(1, newTestList(0, 2, 3)).ToObject()},
+		{args: wrapArgs(NewList(), 0), wantExc: mustCreateException(IndexErrorType, "index out of range")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapqHeapPushPopFunc(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, l *List, item *Object) (*Object, *BaseException) {
+		ret, raised := heapqHeapPushPop(f, Args{l.ToObject(), item}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(ret, l.ToObject()).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewList(), 1), want: newTestTuple(1, NewList()).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 3), 0), want: newTestTuple(0, newTestList(1, 2, 3)).ToObject()},
+		{args: wrapArgs(newTestList(1, 2, 3), 4), want: newTestTuple(1, newTestList(2, 4, 3)).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapqHeapPushRaises(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, l *List, item *Object) (*Object, *BaseException) {
+		if _, raised := heapqHeapPush(f, Args{l.ToObject(), item}, nil); raised != nil {
+			return nil, raised
+		}
+		return l.ToObject(), nil
+	})
+	cmpErr := newTestClass("CmpErr", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"__lt__": newBuiltinFunction("__lt__", func(f *Frame, _ Args, _ KWArgs) (*Object, *BaseException) {
+			return nil, f.RaiseType(ZeroDivisionErrorType, "uh oh")
+		}).ToObject(),
+	}))
+	cas := invokeTestCase{args: wrapArgs(NewList(newObject(cmpErr)), newObject(cmpErr)), wantExc: mustCreateException(ZeroDivisionErrorType, "uh oh")}
+	if err := runInvokeTestCase(fun, &cas); err != "" {
+		t.Error(err)
+	}
+}
+
+func TestHeapqNLargestNSmallest(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, n, iterable *Object) (*Object, *BaseException) {
+		largest, raised := heapqNLargest(f, Args{n, iterable}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		smallest, raised := heapqNSmallest(f, Args{n, iterable}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(largest, smallest).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(3, newTestList(5, 1, 9, 3, 7, 2)), want: newTestTuple(newTestList(9, 7, 5), newTestList(1, 2, 3)).ToObject()},
+		{args: wrapArgs(10, "cab"), want: newTestTuple(newTestList("c", "b", "a"), newTestList("a", "b", "c")).ToObject()},
+		{args: wrapArgs(0, newTestList(1, 2)), want: newTestTuple(NewList(), NewList()).ToObject()},
+		{args: wrapArgs(-1, newTestList(1, 2)), want: newTestTuple(NewList(), NewList()).ToObject()},
+		{args: wrapArgs(1.5, newTestList(1, 2)), wantExc: mustCreateException(TypeErrorType, "an integer is required")},
+		{args: wrapArgs(1, 2), wantExc: mustCreateException(TypeErrorType, "'int' object is not iterable")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHeapqComparatorReadsHeap(t *testing.T) {
+	heap := NewList()
+	// Item is an int whose __lt__ inspects the heap being modified, which
+	// must not deadlock.
+	itemType := newTestClass("Item", []*Type{IntType}, newStringDict(map[string]*Object{
+		"__lt__": newBuiltinFunction("__lt__", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+			if _, raised := Len(f, heap.ToObject()); raised != nil {
+				return nil, raised
+			}
+			return intLT(f, args[0], args[1])
+		}).ToObject(),
+	}))
+	newItem := func(f *Frame, i int) *Object {
+		return mustNotRaise(itemType.Call(f, Args{NewInt(i).ToObject()}, nil))
+	}
+	fun := wrapFuncForTest(func(f *Frame) (*Object, *BaseException) {
+		for _, i := range []int{5, 3, 8, 1} {
+			if _, raised := heapqHeapPush(f, Args{heap.ToObject(), newItem(f, i)}, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		if _, raised := heapqHeapify(f, Args{heap.ToObject()}, nil); raised != nil {
+			return nil, raised
+		}
+		if _, raised := heapqHeapReplace(f, Args{heap.ToObject(), newItem(f, 9)}, nil); raised != nil {
+			return nil, raised
+		}
+		if _, raised := heapqHeapPushPop(f, Args{heap.ToObject(), newItem(f, 4)}, nil); raised != nil {
+			return nil, raised
+		}
+		var result []*Object
+		for len(heap.elems) > 0 {
+			item, raised := heapqHeapPop(f, Args{heap.ToObject()}, nil)
+			if raised != nil {
+				return nil, raised
+			}
+			result = append(result, item)
+		}
+		return NewList(result...).ToObject(), nil
+	})
+	cas := invokeTestCase{want: newTestList(4, 5, 8, 9).ToObject()}
+	if err := runInvokeTestCase(fun, &cas); err != "" {
+		t.Error(err)
+	}
+}
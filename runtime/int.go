@@ -106,7 +106,11 @@ func intGetNewArgs(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "__getnewargs__", args, IntType); raised != nil {
 		return nil, raised
 	}
-	return NewTuple1(args[0]).ToObject(), nil
+	o := args[0]
+	if o.typ != IntType {
+		o = NewInt(toIntUnsafe(args[0]).Value()).ToObject()
+	}
+	return NewTuple1(o).ToObject(), nil
 }
 
 func intGT(f *Frame, v, w *Object) (*Object, *BaseException) {
@@ -0,0 +1,1686 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	// ioDefaultBufferSize is the value of io.DEFAULT_BUFFER_SIZE.
+	ioDefaultBufferSize = 8192
+	errIOClosed         = "I/O operation on closed file"
+)
+
+var (
+	// ioBaseType corresponds to the Python type '_io._IOBase', the root of
+	// the io class hierarchy.
+	ioBaseType = newBasisType("_IOBase", reflect.TypeOf(ioBase{}), toIOBaseUnsafe, ObjectType)
+	// rawIOBaseType corresponds to the Python type '_io._RawIOBase'.
+	rawIOBaseType = newSimpleType("_RawIOBase", ioBaseType)
+	// bufferedIOBaseType corresponds to the Python type
+	// '_io._BufferedIOBase'.
+	bufferedIOBaseType = newSimpleType("_BufferedIOBase", ioBaseType)
+	// textIOBaseType corresponds to the Python type '_io._TextIOBase'.
+	textIOBaseType = newSimpleType("_TextIOBase", ioBaseType)
+	// bytesIOType corresponds to the Python type '_io.BytesIO'.
+	bytesIOType = newBasisType("BytesIO", reflect.TypeOf(bytesIO{}), toBytesIOUnsafe, bufferedIOBaseType)
+	// stringIOType corresponds to the Python type '_io.StringIO'.
+	stringIOType = newBasisType("StringIO", reflect.TypeOf(stringIO{}), toStringIOUnsafe, textIOBaseType)
+	// blockingIOErrorType corresponds to the Python type 'BlockingIOError'.
+	blockingIOErrorType = newType(TypeType, "BlockingIOError", IOErrorType.basis, []*Type{IOErrorType}, nil)
+	// unsupportedOperationType corresponds to the Python type
+	// 'io.UnsupportedOperation'.
+	unsupportedOperationType = newType(TypeType, "UnsupportedOperation", ValueErrorType.basis, []*Type{ValueErrorType, IOErrorType}, nil)
+	bytesIOInitSpec          = NewParamSpec("BytesIO", []Param{{"initial_bytes", None}}, false, false)
+	ioOpenSpec               = NewParamSpec("open", []Param{{"file", nil}, {"mode", NewStr("r").ToObject()}, {"buffering", NewInt(-1).ToObject()}, {"encoding", None}, {"errors", None}, {"newline", None}, {"closefd", True.ToObject()}}, false, false)
+	stringIOInitSpec         = NewParamSpec("StringIO", []Param{{"initial_value", None}, {"newline", NewStr("\n").ToObject()}}, false, false)
+)
+
+// ioBase represents Python '_io._IOBase' objects. It is the basis of all the
+// io types. Like CPython, the closed state of streams that don't override
+// close is kept in the instance dict under ioBaseClosedAttr.
+type ioBase struct {
+	Object
+}
+
+const ioBaseClosedAttr = "__IOBase_closed"
+
+func toIOBaseUnsafe(o *Object) *ioBase {
+	return (*ioBase)(o.toPointer())
+}
+
+// ToObject upcasts b to an Object.
+func (b *ioBase) ToObject() *Object {
+	return &b.Object
+}
+
+func (b *ioBase) isClosed(f *Frame) (bool, *BaseException) {
+	d := b.Dict()
+	if d == nil {
+		return false, nil
+	}
+	closed, raised := d.GetItemString(f, ioBaseClosedAttr)
+	if raised != nil || closed == nil {
+		return false, raised
+	}
+	return IsTrue(f, closed)
+}
+
+// ioCall calls the method name on o with the given args.
+func ioCall(f *Frame, o *Object, name string, args ...*Object) (*Object, *BaseException) {
+	method, raised := GetAttr(f, o, NewStr(name), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return method.Call(f, args, nil)
+}
+
+// ioCallBool calls the method name on o and returns the truthiness of the
+// result.
+func ioCallBool(f *Frame, o *Object, name string) (bool, *BaseException) {
+	result, raised := ioCall(f, o, name)
+	if raised != nil {
+		return false, raised
+	}
+	return IsTrue(f, result)
+}
+
+// ioCheckClosed raises ValueError if o.closed is true.
+func ioCheckClosed(f *Frame, o *Object) *BaseException {
+	closed, raised := GetAttr(f, o, NewStr("closed"), nil)
+	if raised != nil {
+		return raised
+	}
+	isClosed, raised := IsTrue(f, closed)
+	if raised != nil {
+		return raised
+	}
+	if isClosed {
+		return f.RaiseType(ValueErrorType, errIOClosed+".")
+	}
+	return nil
+}
+
+// ioCheckMode raises IOError with the given message unless o.method()
+// returns true. It implements _checkReadable and friends.
+func ioCheckMode(f *Frame, o *Object, method, msg string) *BaseException {
+	ok, raised := ioCallBool(f, o, method)
+	if raised != nil {
+		return raised
+	}
+	if !ok {
+		return f.RaiseType(IOErrorType, msg)
+	}
+	return nil
+}
+
+// ioUnsupported returns a method that unconditionally raises
+// UnsupportedOperation.
+func ioUnsupported(name string) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodVarArgs(f, name, args, ioBaseType); raised != nil {
+			return nil, raised
+		}
+		return nil, f.RaiseType(unsupportedOperationType, name)
+	}).ToObject()
+}
+
+// ioParseSize converts the optional size argument accepted by the read
+// family of methods to an int. None or a missing argument produce -1.
+func ioParseSize(f *Frame, args Args, i int) (int, *BaseException) {
+	if len(args) <= i || args[i] == None {
+		return -1, nil
+	}
+	if args[i].typ.slots.Index == nil {
+		return 0, f.RaiseType(TypeErrorType, fmt.Sprintf("integer argument expected, got '%s'", args[i].typ.Name()))
+	}
+	return IndexInt(f, args[i])
+}
+
+func ioBaseCheckClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "_checkClosed", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckClosed(f, args[0]); raised != nil {
+		return nil, raised
+	}
+	return True.ToObject(), nil
+}
+
+func ioBaseCheckReadable(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "_checkReadable", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckMode(f, args[0], "readable", "File or stream is not readable."); raised != nil {
+		return nil, raised
+	}
+	return True.ToObject(), nil
+}
+
+func ioBaseCheckSeekable(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "_checkSeekable", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckMode(f, args[0], "seekable", "File or stream is not seekable."); raised != nil {
+		return nil, raised
+	}
+	return True.ToObject(), nil
+}
+
+func ioBaseCheckWritable(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "_checkWritable", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckMode(f, args[0], "writable", "File or stream is not writable."); raised != nil {
+		return nil, raised
+	}
+	return True.ToObject(), nil
+}
+
+func ioBaseClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	b := toIOBaseUnsafe(args[0])
+	closed, raised := b.isClosed(f)
+	if raised != nil {
+		return nil, raised
+	}
+	if closed {
+		return None, nil
+	}
+	// Like CPython, the stream is marked closed even if flush fails.
+	_, raised = ioCall(f, args[0], "flush")
+	if d := b.Dict(); d != nil {
+		if raised := d.SetItemString(f, ioBaseClosedAttr, True.ToObject()); raised != nil {
+			return nil, raised
+		}
+	}
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func ioBaseEnter(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__enter__", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckClosed(f, args[0]); raised != nil {
+		return nil, raised
+	}
+	return args[0], nil
+}
+
+func ioBaseExit(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "__exit__", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if _, raised := ioCall(f, args[0], "close"); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func ioBaseFlush(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	closed, raised := toIOBaseUnsafe(args[0]).isClosed(f)
+	if raised != nil {
+		return nil, raised
+	}
+	if closed {
+		return nil, f.RaiseType(ValueErrorType, errIOClosed+".")
+	}
+	return None, nil
+}
+
+func ioBaseGetClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_closed", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	closed, raised := toIOBaseUnsafe(args[0]).isClosed(f)
+	if raised != nil {
+		return nil, raised
+	}
+	return GetBool(closed).ToObject(), nil
+}
+
+func ioBaseIsatty(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "isatty", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckClosed(f, args[0]); raised != nil {
+		return nil, raised
+	}
+	return False.ToObject(), nil
+}
+
+func ioBaseIter(f *Frame, o *Object) (*Object, *BaseException) {
+	if raised := ioCheckClosed(f, o); raised != nil {
+		return nil, raised
+	}
+	return o, nil
+}
+
+func ioBaseNext(f *Frame, o *Object) (*Object, *BaseException) {
+	line, raised := ioCall(f, o, "readline")
+	if raised != nil {
+		return nil, raised
+	}
+	n, raised := Len(f, line)
+	if raised != nil {
+		return nil, raised
+	}
+	if n.Value() == 0 {
+		return nil, f.Raise(StopIterationType.ToObject(), nil, nil)
+	}
+	return line, nil
+}
+
+// ioBaseReadLine implements readline for streams that only provide read and
+// optionally peek, consuming no more than necessary.
+func ioBaseReadLine(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{ioBaseType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "readline", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	limit, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	o := args[0]
+	peek, raised := GetAttr(f, o, NewStr("peek"), None)
+	if raised != nil {
+		return nil, raised
+	}
+	var buf []byte
+	for limit < 0 || len(buf) < limit {
+		n := 1
+		if peek != None {
+			ahead, raised := peek.Call(f, Args{NewInt(1).ToObject()}, nil)
+			if raised != nil {
+				return nil, raised
+			}
+			if !ahead.isInstance(StrType) {
+				return nil, f.RaiseType(IOErrorType, fmt.Sprintf("peek() should have returned a bytes object, not '%s'", ahead.typ.Name()))
+			}
+			if s := toStrUnsafe(ahead).Value(); s != "" {
+				if i := strings.IndexByte(s, '\n'); i >= 0 {
+					n = i + 1
+				} else {
+					n = len(s)
+				}
+				if limit >= 0 && n > limit-len(buf) {
+					n = limit - len(buf)
+				}
+			}
+		}
+		data, raised := ioCall(f, o, "read", NewInt(n).ToObject())
+		if raised != nil {
+			return nil, raised
+		}
+		if !data.isInstance(StrType) {
+			return nil, f.RaiseType(IOErrorType, fmt.Sprintf("read() should have returned a bytes object, not '%s'", data.typ.Name()))
+		}
+		s := toStrUnsafe(data).Value()
+		if s == "" {
+			break
+		}
+		buf = append(buf, s...)
+		if buf[len(buf)-1] == '\n' {
+			break
+		}
+	}
+	return NewStr(string(buf)).ToObject(), nil
+}
+
+func ioBaseReadLines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{ioBaseType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "readlines", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	hint, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	var lines []*Object
+	total := 0
+	raised = seqForEach(f, args[0], func(line *Object) *BaseException {
+		if hint > 0 && total >= hint {
+			return f.Raise(StopIterationType.ToObject(), nil, nil)
+		}
+		n, raised := Len(f, line)
+		if raised != nil {
+			return raised
+		}
+		lines = append(lines, line)
+		total += n.Value()
+		return nil
+	})
+	if raised != nil && !raised.isInstance(StopIterationType) {
+		return nil, raised
+	}
+	f.RestoreExc(nil, nil)
+	return NewList(lines...).ToObject(), nil
+}
+
+// ioBaseReturnFalse implements readable(), seekable() and writable() which
+// return False unless overridden.
+func ioBaseReturnFalse(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readable", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	return False.ToObject(), nil
+}
+
+func ioBaseTell(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, ioBaseType); raised != nil {
+		return nil, raised
+	}
+	return ioCall(f, args[0], "seek", NewInt(0).ToObject(), NewInt(1).ToObject())
+}
+
+func ioBaseWriteLines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "writelines", args, ioBaseType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckClosed(f, args[0]); raised != nil {
+		return nil, raised
+	}
+	raised := seqForEach(f, args[1], func(line *Object) *BaseException {
+		_, raised := ioCall(f, args[0], "write", line)
+		return raised
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func initIOBaseType(dict map[string]*Object) {
+	dict["__enter__"] = newBuiltinFunction("__enter__", ioBaseEnter).ToObject()
+	dict["__exit__"] = newBuiltinFunction("__exit__", ioBaseExit).ToObject()
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["_checkClosed"] = newBuiltinFunction("_checkClosed", ioBaseCheckClosed).ToObject()
+	dict["_checkReadable"] = newBuiltinFunction("_checkReadable", ioBaseCheckReadable).ToObject()
+	dict["_checkSeekable"] = newBuiltinFunction("_checkSeekable", ioBaseCheckSeekable).ToObject()
+	dict["_checkWritable"] = newBuiltinFunction("_checkWritable", ioBaseCheckWritable).ToObject()
+	dict["close"] = newBuiltinFunction("close", ioBaseClose).ToObject()
+	dict["closed"] = newProperty(newBuiltinFunction("_get_closed", ioBaseGetClosed).ToObject(), nil, nil).ToObject()
+	dict["fileno"] = ioUnsupported("fileno")
+	dict["flush"] = newBuiltinFunction("flush", ioBaseFlush).ToObject()
+	dict["isatty"] = newBuiltinFunction("isatty", ioBaseIsatty).ToObject()
+	dict["readable"] = newBuiltinFunction("readable", ioBaseReturnFalse).ToObject()
+	dict["readline"] = newBuiltinFunction("readline", ioBaseReadLine).ToObject()
+	dict["readlines"] = newBuiltinFunction("readlines", ioBaseReadLines).ToObject()
+	dict["seek"] = ioUnsupported("seek")
+	dict["seekable"] = newBuiltinFunction("seekable", ioBaseReturnFalse).ToObject()
+	dict["tell"] = newBuiltinFunction("tell", ioBaseTell).ToObject()
+	dict["truncate"] = ioUnsupported("truncate")
+	dict["writable"] = newBuiltinFunction("writable", ioBaseReturnFalse).ToObject()
+	dict["writelines"] = newBuiltinFunction("writelines", ioBaseWriteLines).ToObject()
+	ioBaseType.slots.Iter = &unaryOpSlot{ioBaseIter}
+	ioBaseType.slots.Next = &unaryOpSlot{ioBaseNext}
+}
+
+func rawIOBaseRead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{rawIOBaseType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "read", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	n, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	if n < 0 {
+		return ioCall(f, args[0], "readall")
+	}
+	b := &ByteArray{Object: Object{typ: ByteArrayType}, value: make([]byte, n)}
+	result, raised := ioCall(f, args[0], "readinto", b.ToObject())
+	if raised != nil || result == None {
+		return result, raised
+	}
+	if n, raised = IndexInt(f, result); raised != nil {
+		return nil, raised
+	}
+	if n < 0 || n > len(b.value) {
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("readinto returned %d outside buffer size %d", n, len(b.value)))
+	}
+	return NewStr(string(b.value[:n])).ToObject(), nil
+}
+
+func rawIOBaseReadAll(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readall", args, rawIOBaseType); raised != nil {
+		return nil, raised
+	}
+	var buf []byte
+	for {
+		data, raised := ioCall(f, args[0], "read", NewInt(ioDefaultBufferSize).ToObject())
+		if raised != nil {
+			return nil, raised
+		}
+		if data == None {
+			if buf == nil {
+				return None, nil
+			}
+			break
+		}
+		if !data.isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, "read() should return bytes")
+		}
+		s := toStrUnsafe(data).Value()
+		if s == "" {
+			break
+		}
+		buf = append(buf, s...)
+	}
+	return NewStr(string(buf)).ToObject(), nil
+}
+
+func initRawIOBaseType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["read"] = newBuiltinFunction("read", rawIOBaseRead).ToObject()
+	dict["readall"] = newBuiltinFunction("readall", rawIOBaseReadAll).ToObject()
+}
+
+func bufferedIOBaseReadInto(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readinto", args, bufferedIOBaseType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(ByteArrayType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("must be read-write buffer, not %s", args[1].typ.Name()))
+	}
+	dst := toByteArrayUnsafe(args[1])
+	dst.mutex.RLock()
+	n := len(dst.value)
+	dst.mutex.RUnlock()
+	data, raised := ioCall(f, args[0], "read", NewInt(n).ToObject())
+	if raised != nil {
+		return nil, raised
+	}
+	if !data.isInstance(StrType) {
+		return nil, f.RaiseType(TypeErrorType, "read() should return bytes")
+	}
+	dst.mutex.Lock()
+	n = copy(dst.value, toStrUnsafe(data).Value())
+	dst.mutex.Unlock()
+	return NewInt(n).ToObject(), nil
+}
+
+func initBufferedIOBaseType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["detach"] = ioUnsupported("detach")
+	dict["read"] = ioUnsupported("read")
+	dict["read1"] = ioUnsupported("read1")
+	dict["readinto"] = newBuiltinFunction("readinto", bufferedIOBaseReadInto).ToObject()
+	dict["write"] = ioUnsupported("write")
+}
+
+func textIOBaseGetNone(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_none", args, textIOBaseType); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func initTextIOBaseType(dict map[string]*Object) {
+	getNone := newProperty(newBuiltinFunction("_get_none", textIOBaseGetNone).ToObject(), nil, nil).ToObject()
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["detach"] = ioUnsupported("detach")
+	dict["encoding"] = getNone
+	dict["errors"] = getNone
+	dict["newlines"] = getNone
+	dict["read"] = ioUnsupported("read")
+	dict["readline"] = ioUnsupported("readline")
+	dict["write"] = ioUnsupported("write")
+}
+
+// memBuffer is the seekable in-memory byte storage that backs cStringIO and
+// io.BytesIO objects. Its methods must be called with mutex held.
+type memBuffer struct {
+	mutex  sync.Mutex
+	buf    []byte
+	pos    int
+	closed bool
+}
+
+// read consumes and returns at most n bytes from the current position, or
+// everything up to the end of the buffer if n is negative. The result
+// aliases the buffer.
+func (b *memBuffer) read(n int) []byte {
+	if b.pos >= len(b.buf) {
+		return nil
+	}
+	rest := b.buf[b.pos:]
+	if n < 0 || n > len(rest) {
+		n = len(rest)
+	}
+	b.pos += n
+	return rest[:n]
+}
+
+// readLine consumes and returns the bytes up to and including the next
+// newline, stopping early after limit bytes when limit is non-negative.
+func (b *memBuffer) readLine(limit int) []byte {
+	if b.pos >= len(b.buf) {
+		return nil
+	}
+	rest := b.buf[b.pos:]
+	n := len(rest)
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		n = i + 1
+	}
+	if limit >= 0 && limit < n {
+		n = limit
+	}
+	b.pos += n
+	return rest[:n]
+}
+
+// readLines returns the remaining lines, stopping once at least hint bytes
+// have been read when hint is positive.
+func (b *memBuffer) readLines(hint int) []*Object {
+	var lines []*Object
+	total := 0
+	for {
+		line := b.readLine(-1)
+		if len(line) == 0 {
+			break
+		}
+		lines = append(lines, NewStr(string(line)).ToObject())
+		total += len(line)
+		if hint > 0 && total >= hint {
+			break
+		}
+	}
+	return lines
+}
+
+// write stores p at the current position, zero filling any gap left by a
+// seek past the end of the buffer.
+func (b *memBuffer) write(p []byte) {
+	end := b.pos + len(p)
+	if end > len(b.buf) {
+		if end > cap(b.buf) {
+			buf := make([]byte, len(b.buf), 2*end)
+			copy(buf, b.buf)
+			b.buf = buf
+		}
+		n := len(b.buf)
+		b.buf = b.buf[:end]
+		for i := n; i < b.pos; i++ {
+			b.buf[i] = 0
+		}
+	}
+	copy(b.buf[b.pos:], p)
+	b.pos = end
+}
+
+// truncate discards the contents of the buffer beyond size.
+func (b *memBuffer) truncate(size int) {
+	if size < len(b.buf) {
+		b.buf = b.buf[:size]
+	}
+}
+
+// bytesIO represents Python '_io.BytesIO' objects.
+type bytesIO struct {
+	ioBase
+	memBuffer
+}
+
+func toBytesIOUnsafe(o *Object) *bytesIO {
+	return (*bytesIO)(o.toPointer())
+}
+
+// ToObject upcasts b to an Object.
+func (b *bytesIO) ToObject() *Object {
+	return &b.Object
+}
+
+// lock acquires b's mutex and raises ValueError if b is closed. On success
+// the caller must release the mutex.
+func (b *bytesIO) lock(f *Frame) *BaseException {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return f.RaiseType(ValueErrorType, errIOClosed+".")
+	}
+	return nil
+}
+
+func bytesIOClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, bytesIOType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	b.mutex.Lock()
+	b.closed = true
+	b.buf = nil
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func bytesIOFlush(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, bytesIOType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Unlock()
+	return None, nil
+}
+
+func bytesIOGetClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_closed", args, bytesIOType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	b.mutex.Lock()
+	closed := b.closed
+	b.mutex.Unlock()
+	return GetBool(closed).ToObject(), nil
+}
+
+func bytesIOGetValue(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getvalue", args, bytesIOType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	s := string(b.buf)
+	b.mutex.Unlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func bytesIOInit(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [1]*Object
+	if raised := bytesIOInitSpec.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(o)
+	b.mutex.Lock()
+	b.buf, b.pos, b.closed = nil, 0, false
+	b.mutex.Unlock()
+	if validated[0] == None {
+		return None, nil
+	}
+	return None, bufferApply(f, validated[0], func(data []byte) *BaseException {
+		b.mutex.Lock()
+		b.write(data)
+		b.pos = 0
+		b.mutex.Unlock()
+		return nil
+	})
+}
+
+func bytesIOIter(f *Frame, o *Object) (*Object, *BaseException) {
+	b := toBytesIOUnsafe(o)
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Unlock()
+	return o, nil
+}
+
+func bytesIONext(f *Frame, o *Object) (*Object, *BaseException) {
+	b := toBytesIOUnsafe(o)
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	line := string(b.readLine(-1))
+	b.mutex.Unlock()
+	if line == "" {
+		return nil, f.Raise(StopIterationType.ToObject(), nil, nil)
+	}
+	return NewStr(line).ToObject(), nil
+}
+
+func bytesIORead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	b, n, raised := bytesIOParseSizeArgs(f, "read", args)
+	if raised != nil {
+		return nil, raised
+	}
+	s := string(b.read(n))
+	b.mutex.Unlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func bytesIOReadInto(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readinto", args, bytesIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(ByteArrayType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("must be read-write buffer, not %s", args[1].typ.Name()))
+	}
+	b, dst := toBytesIOUnsafe(args[0]), toByteArrayUnsafe(args[1])
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	dst.mutex.Lock()
+	n := copy(dst.value, b.read(len(dst.value)))
+	dst.mutex.Unlock()
+	b.mutex.Unlock()
+	return NewInt(n).ToObject(), nil
+}
+
+func bytesIOReadLine(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	b, limit, raised := bytesIOParseSizeArgs(f, "readline", args)
+	if raised != nil {
+		return nil, raised
+	}
+	s := string(b.readLine(limit))
+	b.mutex.Unlock()
+	return NewStr(s).ToObject(), nil
+}
+
+func bytesIOReadLines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	b, hint, raised := bytesIOParseSizeArgs(f, "readlines", args)
+	if raised != nil {
+		return nil, raised
+	}
+	lines := b.readLines(hint)
+	b.mutex.Unlock()
+	return NewList(lines...).ToObject(), nil
+}
+
+// bytesIOReturnTrue implements readable(), seekable() and writable() which
+// always return True for open BytesIO objects.
+func bytesIOReturnTrue(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readable", args, bytesIOType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	b.mutex.Unlock()
+	return True.ToObject(), nil
+}
+
+func bytesIOSeek(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{bytesIOType, ObjectType, ObjectType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	pos, raised := IndexInt(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	whence := 0
+	if argc > 2 {
+		if whence, raised = IndexInt(f, args[2]); raised != nil {
+			return nil, raised
+		}
+	}
+	if whence == 0 && pos < 0 {
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("negative seek value %d", pos))
+	}
+	if whence < 0 || whence > 2 {
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("invalid whence (%d, should be 0, 1 or 2)", whence))
+	}
+	b := toBytesIOUnsafe(args[0])
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	switch whence {
+	case 1:
+		pos += b.pos
+	case 2:
+		pos += len(b.buf)
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	b.pos = pos
+	b.mutex.Unlock()
+	return NewInt(pos).ToObject(), nil
+}
+
+func bytesIOTell(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, bytesIOType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	if raised := b.lock(f); raised != nil {
+		return nil, raised
+	}
+	pos := b.pos
+	b.mutex.Unlock()
+	return NewInt(pos).ToObject(), nil
+}
+
+func bytesIOTruncate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	b, size, raised := bytesIOParseSizeArgs(f, "truncate", args)
+	if raised != nil {
+		return nil, raised
+	}
+	if len(args) < 2 || args[1] == None {
+		size = b.pos
+	} else if size < 0 {
+		b.mutex.Unlock()
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("negative size value %d", size))
+	}
+	b.truncate(size)
+	b.mutex.Unlock()
+	return NewInt(size).ToObject(), nil
+}
+
+func bytesIOWrite(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "write", args, bytesIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	b := toBytesIOUnsafe(args[0])
+	n := 0
+	raised := bufferApply(f, args[1], func(data []byte) *BaseException {
+		if raised := b.lock(f); raised != nil {
+			return raised
+		}
+		b.write(data)
+		b.mutex.Unlock()
+		n = len(data)
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+func bytesIOWriteLines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "writelines", args, bytesIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	raised := seqForEach(f, args[1], func(line *Object) *BaseException {
+		_, raised := bytesIOWrite(f, Args{args[0], line}, nil)
+		return raised
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func initBytesIOType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["close"] = newBuiltinFunction("close", bytesIOClose).ToObject()
+	dict["closed"] = newProperty(newBuiltinFunction("_get_closed", bytesIOGetClosed).ToObject(), nil, nil).ToObject()
+	dict["flush"] = newBuiltinFunction("flush", bytesIOFlush).ToObject()
+	dict["getvalue"] = newBuiltinFunction("getvalue", bytesIOGetValue).ToObject()
+	dict["read"] = newBuiltinFunction("read", bytesIORead).ToObject()
+	dict["read1"] = newBuiltinFunction("read1", bytesIORead).ToObject()
+	dict["readable"] = newBuiltinFunction("readable", bytesIOReturnTrue).ToObject()
+	dict["readinto"] = newBuiltinFunction("readinto", bytesIOReadInto).ToObject()
+	dict["readline"] = newBuiltinFunction("readline", bytesIOReadLine).ToObject()
+	dict["readlines"] = newBuiltinFunction("readlines", bytesIOReadLines).ToObject()
+	dict["seek"] = newBuiltinFunction("seek", bytesIOSeek).ToObject()
+	dict["seekable"] = newBuiltinFunction("seekable", bytesIOReturnTrue).ToObject()
+	dict["tell"] = newBuiltinFunction("tell", bytesIOTell).ToObject()
+	dict["truncate"] = newBuiltinFunction("truncate", bytesIOTruncate).ToObject()
+	dict["writable"] = newBuiltinFunction("writable", bytesIOReturnTrue).ToObject()
+	dict["write"] = newBuiltinFunction("write", bytesIOWrite).ToObject()
+	dict["writelines"] = newBuiltinFunction("writelines", bytesIOWriteLines).ToObject()
+	bytesIOType.slots.Init = &initSlot{bytesIOInit}
+	bytesIOType.slots.Iter = &unaryOpSlot{bytesIOIter}
+	bytesIOType.slots.Next = &unaryOpSlot{bytesIONext}
+}
+
+// bytesIOParseSizeArgs validates the optional size argument accepted by
+// several BytesIO methods. None or a missing argument produce -1. On success
+// the BytesIO's mutex is held and must be released by the caller.
+func bytesIOParseSizeArgs(f *Frame, method string, args Args) (*bytesIO, int, *BaseException) {
+	expectedTypes := []*Type{bytesIOType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, method, args, expectedTypes...); raised != nil {
+		return nil, 0, raised
+	}
+	size := -1
+	if len(args) > 1 && args[1] != None {
+		if args[1].typ.slots.Index == nil {
+			return nil, 0, f.RaiseType(TypeErrorType, fmt.Sprintf("integer argument expected, got '%s'", args[1].typ.Name()))
+		}
+		var raised *BaseException
+		if size, raised = IndexInt(f, args[1]); raised != nil {
+			return nil, 0, raised
+		}
+	}
+	b := toBytesIOUnsafe(args[0])
+	if raised := b.lock(f); raised != nil {
+		return nil, 0, raised
+	}
+	return b, size, nil
+}
+
+// stringIO represents Python '_io.StringIO' objects. It holds text as runes
+// and applies the newline translation selected by its newline argument.
+type stringIO struct {
+	ioBase
+	mutex  sync.Mutex
+	buf    []rune
+	pos    int
+	closed bool
+	// newline is the newline argument passed to the constructor and
+	// universal is true when it was None.
+	newline   string
+	universal bool
+	// seenNewlines records the kinds of newline written in universal
+	// mode as a combination of the ioSeen* flags.
+	seenNewlines int
+}
+
+const (
+	ioSeenCR = 1 << iota
+	ioSeenLF
+	ioSeenCRLF
+)
+
+func toStringIOUnsafe(o *Object) *stringIO {
+	return (*stringIO)(o.toPointer())
+}
+
+// ToObject upcasts s to an Object.
+func (s *stringIO) ToObject() *Object {
+	return &s.Object
+}
+
+// lock acquires s's mutex and raises ValueError if s is closed. On success
+// the caller must release the mutex.
+func (s *stringIO) lock(f *Frame) *BaseException {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return f.RaiseType(ValueErrorType, errIOClosed)
+	}
+	return nil
+}
+
+// readLine consumes and returns the runes up to and including the next line
+// terminator, stopping early after limit runes when limit is non-negative.
+// The caller must hold s.mutex.
+func (s *stringIO) readLine(limit int) []rune {
+	if s.pos >= len(s.buf) {
+		return nil
+	}
+	rest := s.buf[s.pos:]
+	n := len(rest)
+	switch s.newline {
+	case "":
+		for i, r := range rest {
+			if r == '\n' {
+				n = i + 1
+				break
+			}
+			if r == '\r' {
+				n = i + 1
+				if n < len(rest) && rest[n] == '\n' {
+					n++
+				}
+				break
+			}
+		}
+	case "\r\n":
+		for i := 0; i+1 < len(rest); i++ {
+			if rest[i] == '\r' && rest[i+1] == '\n' {
+				n = i + 2
+				break
+			}
+		}
+	default:
+		term := '\n'
+		if s.newline == "\r" {
+			term = '\r'
+		}
+		for i, r := range rest {
+			if r == term {
+				n = i + 1
+				break
+			}
+		}
+	}
+	if limit >= 0 && limit < n {
+		n = limit
+	}
+	s.pos += n
+	return rest[:n]
+}
+
+// translate applies the write side newline translation to runes. The caller
+// must hold s.mutex.
+func (s *stringIO) translate(runes []rune) []rune {
+	if s.universal || s.newline == "" {
+		// Record the newlines seen and, in universal mode, convert
+		// them all to "\n".
+		var result []rune
+		if s.universal {
+			result = make([]rune, 0, len(runes))
+		}
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			switch {
+			case r == '\r' && i+1 < len(runes) && runes[i+1] == '\n':
+				s.seenNewlines |= ioSeenCRLF
+				i++
+				r = '\n'
+			case r == '\r':
+				s.seenNewlines |= ioSeenCR
+				r = '\n'
+			case r == '\n':
+				s.seenNewlines |= ioSeenLF
+			}
+			if s.universal {
+				result = append(result, r)
+			}
+		}
+		if s.universal {
+			return result
+		}
+		return runes
+	}
+	if s.newline == "\n" {
+		return runes
+	}
+	var result []rune
+	for _, r := range runes {
+		if r == '\n' {
+			result = append(result, []rune(s.newline)...)
+		} else {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// write stores runes at the current position after translating newlines,
+// zero filling any gap left by a seek past the end. The caller must hold
+// s.mutex.
+func (s *stringIO) write(runes []rune) {
+	runes = s.translate(runes)
+	end := s.pos + len(runes)
+	if end > len(s.buf) {
+		if end > cap(s.buf) {
+			buf := make([]rune, len(s.buf), 2*end)
+			copy(buf, s.buf)
+			s.buf = buf
+		}
+		n := len(s.buf)
+		s.buf = s.buf[:end]
+		for i := n; i < s.pos; i++ {
+			s.buf[i] = 0
+		}
+	}
+	copy(s.buf[s.pos:], runes)
+	s.pos = end
+}
+
+func stringIOClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	s.mutex.Lock()
+	s.closed = true
+	s.buf = nil
+	s.mutex.Unlock()
+	return None, nil
+}
+
+func stringIOFlush(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	s.mutex.Unlock()
+	return None, nil
+}
+
+func stringIOGetClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_closed", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+	return GetBool(closed).ToObject(), nil
+}
+
+func stringIOGetNewlines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_get_newlines", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	seen, track := s.seenNewlines, s.universal || s.newline == ""
+	s.mutex.Unlock()
+	if !track {
+		return None, nil
+	}
+	return ioNewlines(seen), nil
+}
+
+// ioNewlines returns the value of the newlines attribute of a text stream
+// that has seen the kinds of newline recorded by the ioSeen* flags in seen.
+func ioNewlines(seen int) *Object {
+	// The order of the reported newlines follows CPython's
+	// IncrementalNewlineDecoder.
+	var names []*Object
+	for _, kind := range []struct {
+		flag int
+		name string
+	}{{ioSeenCR, "\r"}, {ioSeenLF, "\n"}, {ioSeenCRLF, "\r\n"}} {
+		if seen&kind.flag != 0 {
+			names = append(names, NewStr(kind.name).ToObject())
+		}
+	}
+	switch len(names) {
+	case 0:
+		return None
+	case 1:
+		return names[0]
+	}
+	return NewTuple(names...).ToObject()
+}
+
+func stringIOGetValue(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getvalue", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	value := append([]rune(nil), s.buf...)
+	s.mutex.Unlock()
+	return NewUnicodeFromRunes(value).ToObject(), nil
+}
+
+func stringIOInit(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := stringIOInitSpec.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	initial, newlineArg := validated[0], validated[1]
+	if initial != None && !initial.isInstance(UnicodeType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("initial_value must be unicode or None, not %s", initial.typ.Name()))
+	}
+	newline, universal := "", newlineArg == None
+	if !universal {
+		switch {
+		case newlineArg.isInstance(StrType):
+			newline = toStrUnsafe(newlineArg).Value()
+		case newlineArg.isInstance(UnicodeType):
+			newline = string(toUnicodeUnsafe(newlineArg).Value())
+		default:
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("newline must be str or None, not %s", newlineArg.typ.Name()))
+		}
+		if newline != "" && newline != "\n" && newline != "\r" && newline != "\r\n" {
+			return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("illegal newline value: %s", newline))
+		}
+	}
+	s := toStringIOUnsafe(o)
+	s.mutex.Lock()
+	s.buf, s.pos, s.closed = nil, 0, false
+	s.newline, s.universal, s.seenNewlines = newline, universal, 0
+	if initial != None {
+		s.write(toUnicodeUnsafe(initial).Value())
+		s.pos = 0
+	}
+	s.mutex.Unlock()
+	return None, nil
+}
+
+func stringIOIter(f *Frame, o *Object) (*Object, *BaseException) {
+	s := toStringIOUnsafe(o)
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	s.mutex.Unlock()
+	return o, nil
+}
+
+func stringIONext(f *Frame, o *Object) (*Object, *BaseException) {
+	s := toStringIOUnsafe(o)
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	line := append([]rune(nil), s.readLine(-1)...)
+	s.mutex.Unlock()
+	if len(line) == 0 {
+		return nil, f.Raise(StopIterationType.ToObject(), nil, nil)
+	}
+	return NewUnicodeFromRunes(line).ToObject(), nil
+}
+
+func stringIORead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, n, raised := stringIOParseSizeArgs(f, "read", args)
+	if raised != nil {
+		return nil, raised
+	}
+	var result []rune
+	if s.pos < len(s.buf) {
+		rest := s.buf[s.pos:]
+		if n < 0 || n > len(rest) {
+			n = len(rest)
+		}
+		result = append(result, rest[:n]...)
+		s.pos += n
+	}
+	s.mutex.Unlock()
+	return NewUnicodeFromRunes(result).ToObject(), nil
+}
+
+func stringIOReadLine(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, limit, raised := stringIOParseSizeArgs(f, "readline", args)
+	if raised != nil {
+		return nil, raised
+	}
+	line := append([]rune(nil), s.readLine(limit)...)
+	s.mutex.Unlock()
+	return NewUnicodeFromRunes(line).ToObject(), nil
+}
+
+func stringIOReadLines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, hint, raised := stringIOParseSizeArgs(f, "readlines", args)
+	if raised != nil {
+		return nil, raised
+	}
+	var lines []*Object
+	total := 0
+	for {
+		line := s.readLine(-1)
+		if len(line) == 0 {
+			break
+		}
+		lines = append(lines, NewUnicodeFromRunes(append([]rune(nil), line...)).ToObject())
+		total += len(line)
+		if hint > 0 && total >= hint {
+			break
+		}
+	}
+	s.mutex.Unlock()
+	return NewList(lines...).ToObject(), nil
+}
+
+// stringIOReturnTrue implements readable(), seekable() and writable() which
+// always return True for open StringIO objects.
+func stringIOReturnTrue(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "readable", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	s.mutex.Unlock()
+	return True.ToObject(), nil
+}
+
+func stringIOSeek(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{stringIOType, ObjectType, ObjectType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	pos, raised := IndexInt(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	whence := 0
+	if argc > 2 {
+		if whence, raised = IndexInt(f, args[2]); raised != nil {
+			return nil, raised
+		}
+	}
+	switch {
+	case whence < 0 || whence > 2:
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("Invalid whence (%d, should be 0, 1 or 2)", whence))
+	case whence == 0 && pos < 0:
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("Negative seek position %d", pos))
+	case whence == 1 && pos != 0:
+		return nil, f.RaiseType(IOErrorType, "Can't do nonzero cur-relative seeks")
+	case whence == 2 && pos != 0:
+		return nil, f.RaiseType(IOErrorType, "Can't do nonzero end-relative seeks")
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	switch whence {
+	case 1:
+		pos = s.pos
+	case 2:
+		pos = len(s.buf)
+	}
+	s.pos = pos
+	s.mutex.Unlock()
+	return NewInt(pos).ToObject(), nil
+}
+
+func stringIOTell(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, stringIOType); raised != nil {
+		return nil, raised
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	pos := s.pos
+	s.mutex.Unlock()
+	return NewInt(pos).ToObject(), nil
+}
+
+func stringIOTruncate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, size, raised := stringIOParseSizeArgs(f, "truncate", args)
+	if raised != nil {
+		return nil, raised
+	}
+	if len(args) < 2 || args[1] == None {
+		size = s.pos
+	} else if size < 0 {
+		s.mutex.Unlock()
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("Negative size value %d", size))
+	}
+	if size < len(s.buf) {
+		s.buf = s.buf[:size]
+	}
+	s.mutex.Unlock()
+	return NewInt(size).ToObject(), nil
+}
+
+func stringIOWrite(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "write", args, stringIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(UnicodeType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("unicode argument expected, got '%s'", args[1].typ.Name()))
+	}
+	s, runes := toStringIOUnsafe(args[0]), toUnicodeUnsafe(args[1]).Value()
+	if raised := s.lock(f); raised != nil {
+		return nil, raised
+	}
+	s.write(runes)
+	s.mutex.Unlock()
+	return NewInt(len(runes)).ToObject(), nil
+}
+
+func stringIOWriteLines(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "writelines", args, stringIOType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	raised := seqForEach(f, args[1], func(line *Object) *BaseException {
+		_, raised := stringIOWrite(f, Args{args[0], line}, nil)
+		return raised
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func initStringIOType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_io").ToObject()
+	dict["close"] = newBuiltinFunction("close", stringIOClose).ToObject()
+	dict["closed"] = newProperty(newBuiltinFunction("_get_closed", stringIOGetClosed).ToObject(), nil, nil).ToObject()
+	dict["encoding"] = None
+	dict["errors"] = None
+	dict["flush"] = newBuiltinFunction("flush", stringIOFlush).ToObject()
+	dict["getvalue"] = newBuiltinFunction("getvalue", stringIOGetValue).ToObject()
+	dict["line_buffering"] = False.ToObject()
+	dict["newlines"] = newProperty(newBuiltinFunction("_get_newlines", stringIOGetNewlines).ToObject(), nil, nil).ToObject()
+	dict["read"] = newBuiltinFunction("read", stringIORead).ToObject()
+	dict["readable"] = newBuiltinFunction("readable", stringIOReturnTrue).ToObject()
+	dict["readline"] = newBuiltinFunction("readline", stringIOReadLine).ToObject()
+	dict["readlines"] = newBuiltinFunction("readlines", stringIOReadLines).ToObject()
+	dict["seek"] = newBuiltinFunction("seek", stringIOSeek).ToObject()
+	dict["seekable"] = newBuiltinFunction("seekable", stringIOReturnTrue).ToObject()
+	dict["tell"] = newBuiltinFunction("tell", stringIOTell).ToObject()
+	dict["truncate"] = newBuiltinFunction("truncate", stringIOTruncate).ToObject()
+	dict["writable"] = newBuiltinFunction("writable", stringIOReturnTrue).ToObject()
+	dict["write"] = newBuiltinFunction("write", stringIOWrite).ToObject()
+	dict["writelines"] = newBuiltinFunction("writelines", stringIOWriteLines).ToObject()
+	stringIOType.slots.Init = &initSlot{stringIOInit}
+	stringIOType.slots.Iter = &unaryOpSlot{stringIOIter}
+	stringIOType.slots.Next = &unaryOpSlot{stringIONext}
+}
+
+// stringIOParseSizeArgs is the StringIO analog of bytesIOParseSizeArgs.
+func stringIOParseSizeArgs(f *Frame, method string, args Args) (*stringIO, int, *BaseException) {
+	expectedTypes := []*Type{stringIOType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, method, args, expectedTypes...); raised != nil {
+		return nil, 0, raised
+	}
+	size := -1
+	if len(args) > 1 && args[1] != None {
+		if args[1].typ.slots.Index == nil {
+			return nil, 0, f.RaiseType(TypeErrorType, fmt.Sprintf("integer argument expected, got '%s'", args[1].typ.Name()))
+		}
+		var raised *BaseException
+		if size, raised = IndexInt(f, args[1]); raised != nil {
+			return nil, 0, raised
+		}
+	}
+	s := toStringIOUnsafe(args[0])
+	if raised := s.lock(f); raised != nil {
+		return nil, 0, raised
+	}
+	return s, size, nil
+}
+
+// ioOpen implements io.open, layering a FileIO, a buffered stream and
+// optionally a TextIOWrapper according to mode and buffering.
+func ioOpen(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [7]*Object
+	if raised := ioOpenSpec.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	file, modeArg, bufferingArg := validated[0], validated[1], validated[2]
+	encoding, errors, newline := validated[3], validated[4], validated[5]
+	if !file.isInstance(BaseStringType) && file.typ.slots.Int == nil && file.typ.slots.Float == nil {
+		s, raised := Repr(f, file)
+		if raised != nil {
+			return nil, raised
+		}
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("invalid file: %s", s.Value()))
+	}
+	if !modeArg.isInstance(BaseStringType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("open() argument 2 must be string, not %s", modeArg.typ.Name()))
+	}
+	if !bufferingArg.isInstance(IntType) {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	for i, arg := range []*Object{encoding, errors, newline} {
+		if arg != None && !arg.isInstance(BaseStringType) {
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("open() argument %d must be string or None, not %s", i+4, arg.typ.Name()))
+		}
+	}
+	modeStr, raised := ToStr(f, modeArg)
+	if raised != nil {
+		return nil, raised
+	}
+	mode := modeStr.Value()
+	seen := map[rune]bool{}
+	for _, c := range mode {
+		if seen[c] || !strings.ContainsRune("rwaUb+t", c) {
+			return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("invalid mode: '%s'", mode))
+		}
+		seen[c] = true
+	}
+	reading, writing, appending, updating := seen['r'], seen['w'], seen['a'], seen['+']
+	text, binary := seen['t'], seen['b']
+	if seen['U'] {
+		if writing || appending {
+			return nil, f.RaiseType(ValueErrorType, "can't use U and writing mode at once")
+		}
+		reading = true
+	}
+	if text && binary {
+		return nil, f.RaiseType(ValueErrorType, "can't have text and binary mode at once")
+	}
+	n := 0
+	for _, b := range []bool{reading, writing, appending} {
+		if b {
+			n++
+		}
+	}
+	if n > 1 {
+		return nil, f.RaiseType(ValueErrorType, "must have exactly one of read/write/append mode")
+	}
+	if binary {
+		for _, arg := range []struct {
+			value *Object
+			name  string
+		}{{encoding, "an encoding"}, {errors, "an errors"}, {newline, "a newline"}} {
+			if arg.value != None {
+				return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("binary mode doesn't take %s argument", arg.name))
+			}
+		}
+	}
+	rawMode := ""
+	if reading {
+		rawMode = "r"
+	} else if writing {
+		rawMode = "w"
+	} else if appending {
+		rawMode = "a"
+	}
+	if updating {
+		rawMode += "+"
+	}
+	raw, raised := fileIOType.Call(f, Args{file, NewStr(rawMode).ToObject(), validated[6]}, nil)
+	if raised != nil {
+		return nil, raised
+	}
+	result, raised := ioOpenWrap(f, raw, mode, toIntUnsafe(bufferingArg).Value(), binary, updating, writing || appending, encoding, errors, newline)
+	if raised != nil {
+		// Close raw so the descriptor doesn't leak, preserving the
+		// original exception.
+		e, tb := f.ExcInfo()
+		ioCall(f, raw, "close")
+		f.RestoreExc(e, tb)
+		return nil, raised
+	}
+	return result, nil
+}
+
+// ioOpenWrap layers the buffered and text streams selected by io.open on top
+// of raw.
+func ioOpenWrap(f *Frame, raw *Object, mode string, buffering int, binary, updating, writing bool, encoding, errors, newline *Object) (*Object, *BaseException) {
+	lineBuffering := false
+	if buffering == 1 || buffering < 0 {
+		tty, raised := ioCallBool(f, raw, "isatty")
+		if raised != nil {
+			return nil, raised
+		}
+		lineBuffering = buffering == 1 || tty
+	}
+	if buffering == 1 || buffering < 0 {
+		buffering = ioDefaultBufferSize
+	}
+	if buffering == 0 {
+		if binary {
+			return raw, nil
+		}
+		return nil, f.RaiseType(ValueErrorType, "can't have unbuffered text I/O")
+	}
+	bufferType := bufferedReaderType
+	if updating {
+		bufferType = bufferedRandomType
+	} else if writing {
+		bufferType = bufferedWriterType
+	}
+	buffer, raised := bufferType.Call(f, Args{raw, NewInt(buffering).ToObject()}, nil)
+	if raised != nil || binary {
+		return buffer, raised
+	}
+	text, raised := textIOWrapperType.Call(f, Args{buffer, encoding, errors, newline, GetBool(lineBuffering).ToObject()}, nil)
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := SetAttr(f, text, NewStr("mode"), NewUnicode(mode).ToObject()); raised != nil {
+		return nil, raised
+	}
+	return text, nil
+}
+
+func initUnsupportedOperationType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("io").ToObject()
+}
+
+func init() {
+	RegisterModule("_io", NewCode("<module>", "_io", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		for name, value := range map[string]*Object{
+			"BlockingIOError":      blockingIOErrorType.ToObject(),
+			"BufferedRandom":       bufferedRandomType.ToObject(),
+			"BufferedReader":       bufferedReaderType.ToObject(),
+			"BufferedWriter":       bufferedWriterType.ToObject(),
+			"BytesIO":              bytesIOType.ToObject(),
+			"DEFAULT_BUFFER_SIZE":  NewInt(ioDefaultBufferSize).ToObject(),
+			"FileIO":               fileIOType.ToObject(),
+			"SEEK_CUR":             NewInt(1).ToObject(),
+			"SEEK_END":             NewInt(2).ToObject(),
+			"SEEK_SET":             NewInt(0).ToObject(),
+			"StringIO":             stringIOType.ToObject(),
+			"TextIOWrapper":        textIOWrapperType.ToObject(),
+			"UnsupportedOperation": unsupportedOperationType.ToObject(),
+			"_BufferedIOBase":      bufferedIOBaseType.ToObject(),
+			"_IOBase":              ioBaseType.ToObject(),
+			"_RawIOBase":           rawIOBaseType.ToObject(),
+			"_TextIOBase":          textIOBaseType.ToObject(),
+			"open":                 newBuiltinFunction("open", ioOpen).ToObject(),
+		} {
+			if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
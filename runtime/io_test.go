@@ -0,0 +1,241 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemBuffer(t *testing.T) {
+	b := &memBuffer{}
+	b.write([]byte("ab\ncd"))
+	if b.pos != 5 {
+		t.Errorf("pos after write = %d, want 5", b.pos)
+	}
+	b.pos = 7
+	b.write([]byte("x"))
+	if got, want := string(b.buf), "ab\ncd\x00\x00x"; got != want {
+		t.Errorf("buf after gap write = %q, want %q", got, want)
+	}
+	b.pos = 0
+	if got := string(b.readLine(-1)); got != "ab\n" {
+		t.Errorf("readLine(-1) = %q, want %q", got, "ab\n")
+	}
+	if got := string(b.readLine(1)); got != "c" {
+		t.Errorf("readLine(1) = %q, want %q", got, "c")
+	}
+	if got := string(b.read(-1)); got != "d\x00\x00x" {
+		t.Errorf("read(-1) = %q, want %q", got, "d\x00\x00x")
+	}
+	if got := b.read(1); got != nil {
+		t.Errorf("read(1) at EOF = %q, want nil", got)
+	}
+	b.truncate(2)
+	if got := string(b.buf); got != "ab" {
+		t.Errorf("buf after truncate = %q, want %q", got, "ab")
+	}
+}
+
+func TestBytesIOMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, init *Object, method string, args ...*Object) (*Object, *BaseException) {
+		b, raised := bytesIOType.Call(f, Args{init}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		m, raised := GetAttr(f, b, NewStr(method), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return m.Call(f, args, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("ab\ncd", "read"), want: NewStr("ab\ncd").ToObject()},
+		{args: wrapArgs("ab\ncd", "read", 1), want: NewStr("a").ToObject()},
+		{args: wrapArgs("ab\ncd", "read", None), want: NewStr("ab\ncd").ToObject()},
+		{args: wrapArgs("ab\ncd", "readline"), want: NewStr("ab\n").ToObject()},
+		{args: wrapArgs("ab\ncd", "readlines"), want: newTestList("ab\n", "cd").ToObject()},
+		{args: wrapArgs("ab", "seek", 0, 2), want: NewInt(2).ToObject()},
+		{args: wrapArgs("ab", "write", "xyz"), want: NewInt(3).ToObject()},
+		{args: wrapArgs("ab", "truncate", 1), want: NewInt(1).ToObject()},
+		{args: wrapArgs("ab", "getvalue"), want: NewStr("ab").ToObject()},
+		{args: wrapArgs(None, "readable"), want: True.ToObject()},
+		{args: wrapArgs("ab", "read", "a"), wantExc: mustCreateException(TypeErrorType, "integer argument expected, got 'str'")},
+		{args: wrapArgs("ab", "seek", -1), wantExc: mustCreateException(ValueErrorType, "negative seek value -1")},
+		{args: wrapArgs("ab", "seek", 0, 3), wantExc: mustCreateException(ValueErrorType, "invalid whence (3, should be 0, 1 or 2)")},
+		{args: wrapArgs("ab", "truncate", -1), wantExc: mustCreateException(ValueErrorType, "negative size value -1")},
+		{args: wrapArgs("ab", "write", NewUnicode("a")), wantExc: mustCreateException(TypeErrorType, "'unicode' does not have the buffer interface")},
+		{args: wrapArgs("ab", "fileno"), wantExc: mustCreateException(unsupportedOperationType, "fileno")},
+		{args: wrapArgs(1, "read"), wantExc: mustCreateException(TypeErrorType, "'int' does not have the buffer interface")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBytesIOClosed(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, method string) (*Object, *BaseException) {
+		b, raised := bytesIOType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := bytesIOClose(f, Args{b}, nil); raised != nil {
+			return nil, raised
+		}
+		m, raised := GetAttr(f, b, NewStr(method), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return m.Call(f, nil, nil)
+	})
+	for _, method := range []string{"getvalue", "read", "tell"} {
+		cas := invokeTestCase{args: wrapArgs(method), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file.")}
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestStringIOMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, init, newline *Object, method string, args ...*Object) (*Object, *BaseException) {
+		s, raised := stringIOType.Call(f, Args{init, newline}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		m, raised := GetAttr(f, s, NewStr(method), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return m.Call(f, args, nil)
+	})
+	text := NewUnicode("a\r\nb\rc\n")
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewUnicode("ab\ncd"), "\n", "readline"), want: NewUnicode("ab\n").ToObject()},
+		{args: wrapArgs(NewUnicode("ab\ncd"), "\n", "read", 4), want: NewUnicode("ab\nc").ToObject()},
+		{args: wrapArgs(NewUnicode("ab"), "\n", "write", NewUnicode("xyz")), want: NewInt(3).ToObject()},
+		{args: wrapArgs(NewUnicode("ab"), "\n", "seek", 0, 2), want: NewInt(2).ToObject()},
+		{args: wrapArgs(text, None, "getvalue"), want: NewUnicode("a\nb\nc\n").ToObject()},
+		{args: wrapArgs(text, "", "readlines"), want: newTestList(NewUnicode("a\r\n"), NewUnicode("b\r"), NewUnicode("c\n")).ToObject()},
+		{args: wrapArgs(NewUnicode("a\nb"), "\r\n", "getvalue"), want: NewUnicode("a\r\nb").ToObject()},
+		{args: wrapArgs(NewUnicode("a\nb"), "\r", "readline"), want: NewUnicode("a\r").ToObject()},
+		{args: wrapArgs("ab", None, "read"), wantExc: mustCreateException(TypeErrorType, "initial_value must be unicode or None, not str")},
+		{args: wrapArgs(None, "x", "read"), wantExc: mustCreateException(ValueErrorType, "illegal newline value: x")},
+		{args: wrapArgs(None, "\n", "write", "a"), wantExc: mustCreateException(TypeErrorType, "unicode argument expected, got 'str'")},
+		{args: wrapArgs(None, "\n", "seek", 1, 1), wantExc: mustCreateException(IOErrorType, "Can't do nonzero cur-relative seeks")},
+		{args: wrapArgs(None, "\n", "seek", -1), wantExc: mustCreateException(ValueErrorType, "Negative seek position -1")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestIOBaseMethods(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, method string, args ...*Object) (*Object, *BaseException) {
+		o, raised := ioBaseType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, o, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("readable"), want: False.ToObject()},
+		{args: wrapArgs("writable"), want: False.ToObject()},
+		{args: wrapArgs("seekable"), want: False.ToObject()},
+		{args: wrapArgs("isatty"), want: False.ToObject()},
+		{args: wrapArgs("flush"), want: None},
+		{args: wrapArgs("close"), want: None},
+		{args: wrapArgs("seek", 0), wantExc: mustCreateException(unsupportedOperationType, "seek")},
+		{args: wrapArgs("fileno"), wantExc: mustCreateException(unsupportedOperationType, "fileno")},
+		{args: wrapArgs("_checkReadable"), wantExc: mustCreateException(IOErrorType, "File or stream is not readable.")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestIOOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestIOOpen")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "foo.txt")
+	if err := ioutil.WriteFile(path, []byte("foo\r\nbar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		o, raised := ioOpen(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		defer ioCall(f, o, "close")
+		return NewTuple2(o.typ.ToObject(), mustNotRaise(ioCall(f, o, "read"))).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(path), want: NewTuple2(textIOWrapperType.ToObject(), NewUnicode("foo\nbar").ToObject()).ToObject()},
+		{args: wrapArgs(path, "rU"), want: NewTuple2(textIOWrapperType.ToObject(), NewUnicode("foo\nbar").ToObject()).ToObject()},
+		{args: wrapArgs(path, "rb"), want: newTestTuple(bufferedReaderType, "foo\r\nbar").ToObject()},
+		{args: wrapArgs(path, "r+b"), want: newTestTuple(bufferedRandomType, "foo\r\nbar").ToObject()},
+		{args: wrapArgs(path, "rb", 0), want: newTestTuple(fileIOType, "foo\r\nbar").ToObject()},
+		{args: wrapArgs(path, "r", 0), wantExc: mustCreateException(ValueErrorType, "can't have unbuffered text I/O")},
+		{args: wrapArgs(path, "rw"), wantExc: mustCreateException(ValueErrorType, "must have exactly one of read/write/append mode")},
+		{args: wrapArgs(path, "rz"), wantExc: mustCreateException(ValueErrorType, "invalid mode: 'rz'")},
+		{args: wrapArgs(path, "wU"), wantExc: mustCreateException(ValueErrorType, "can't use U and writing mode at once")},
+		{args: wrapArgs(path, "rbt"), wantExc: mustCreateException(ValueErrorType, "can't have text and binary mode at once")},
+		{args: wrapArgs(path, "rb", -1, "utf8"), wantExc: mustCreateException(ValueErrorType, "binary mode doesn't take an encoding argument")},
+		{args: wrapArgs(None), wantExc: mustCreateException(TypeErrorType, "invalid file: None")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPrintToMemBuffer(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, dest *Object, args *Tuple, nl bool) (*Object, *BaseException) {
+		if raised := PrintTo(f, dest, args.elems, nl); raised != nil {
+			return nil, raised
+		}
+		getvalue, raised := GetAttr(f, dest, NewStr("getvalue"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return getvalue.Call(f, nil, nil)
+	})
+	newStringO := func() *Object {
+		return mustNotRaise(cStringIOStringIO(NewRootFrame(), nil, nil))
+	}
+	cases := []invokeTestCase{
+		{args: wrapArgs(newStringO(), newTestTuple("abc", 123), true), want: NewStr("abc 123\n").ToObject()},
+		{args: wrapArgs(newStringO(), newTestTuple("abc"), false), want: NewStr("abc ").ToObject()},
+		{args: wrapArgs(mustNotRaise(bytesIOType.Call(NewRootFrame(), nil, nil)), newTestTuple(1, 2), true), want: NewStr("1 2\n").ToObject()},
+		{args: wrapArgs(mustNotRaise(stringIOType.Call(NewRootFrame(), nil, nil)), newTestTuple("a"), true), wantExc: mustCreateException(TypeErrorType, "unicode argument expected, got 'str'")},
+		{args: wrapArgs(NewInt(1), newTestTuple("a"), true), wantExc: mustCreateException(AttributeErrorType, "'int' object has no attribute 'write'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,1814 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	// teeDataSize is the number of items buffered by each link of the
+	// list shared between tee iterators.
+	teeDataSize = 57
+)
+
+var (
+	// chainType is the object representing the Python 'itertools.chain'
+	// type.
+	chainType = newBasisType("chain", reflect.TypeOf(chain{}), toChainUnsafe, ObjectType)
+	// combinationsType is the object representing the Python
+	// 'itertools.combinations' type.
+	combinationsType = newBasisType("combinations", reflect.TypeOf(combinations{}), toCombinationsUnsafe, ObjectType)
+	// cwrType is the object representing the Python
+	// 'itertools.combinations_with_replacement' type.
+	cwrType = newBasisType("combinations_with_replacement", reflect.TypeOf(cwr{}), toCWRUnsafe, ObjectType)
+	// compressType is the object representing the Python
+	// 'itertools.compress' type.
+	compressType = newBasisType("compress", reflect.TypeOf(compress{}), toCompressUnsafe, ObjectType)
+	// countType is the object representing the Python 'itertools.count'
+	// type.
+	countType = newBasisType("count", reflect.TypeOf(count{}), toCountUnsafe, ObjectType)
+	// cycleType is the object representing the Python 'itertools.cycle'
+	// type.
+	cycleType = newBasisType("cycle", reflect.TypeOf(cycle{}), toCycleUnsafe, ObjectType)
+	// dropWhileType is the object representing the Python
+	// 'itertools.dropwhile' type.
+	dropWhileType = newBasisType("dropwhile", reflect.TypeOf(dropWhile{}), toDropWhileUnsafe, ObjectType)
+	// groupByType is the object representing the Python
+	// 'itertools.groupby' type.
+	groupByType = newBasisType("groupby", reflect.TypeOf(groupBy{}), toGroupByUnsafe, ObjectType)
+	// grouperType is the object representing the Python
+	// 'itertools._grouper' type, which iterates over a single group
+	// produced by groupby.
+	grouperType = newBasisType("_grouper", reflect.TypeOf(grouper{}), toGrouperUnsafe, ObjectType)
+	// iFilterType is the object representing the Python
+	// 'itertools.ifilter' type.
+	iFilterType = newBasisType("ifilter", reflect.TypeOf(iFilter{}), toIFilterUnsafe, ObjectType)
+	// iFilterFalseType is the object representing the Python
+	// 'itertools.ifilterfalse' type.
+	iFilterFalseType = newBasisType("ifilterfalse", reflect.TypeOf(iFilterFalse{}), toIFilterFalseUnsafe, ObjectType)
+	// iMapType is the object representing the Python 'itertools.imap'
+	// type.
+	iMapType = newBasisType("imap", reflect.TypeOf(iMap{}), toIMapUnsafe, ObjectType)
+	// iSliceType is the object representing the Python
+	// 'itertools.islice' type.
+	iSliceType = newBasisType("islice", reflect.TypeOf(iSlice{}), toISliceUnsafe, ObjectType)
+	// iZipType is the object representing the Python 'itertools.izip'
+	// type.
+	iZipType = newBasisType("izip", reflect.TypeOf(iZip{}), toIZipUnsafe, ObjectType)
+	// iZipLongestType is the object representing the Python
+	// 'itertools.izip_longest' type.
+	iZipLongestType = newBasisType("izip_longest", reflect.TypeOf(iZipLongest{}), toIZipLongestUnsafe, ObjectType)
+	// permutationsType is the object representing the Python
+	// 'itertools.permutations' type.
+	permutationsType = newBasisType("permutations", reflect.TypeOf(permutations{}), toPermutationsUnsafe, ObjectType)
+	// productType is the object representing the Python
+	// 'itertools.product' type.
+	productType = newBasisType("product", reflect.TypeOf(product{}), toProductUnsafe, ObjectType)
+	// repeatType is the object representing the Python
+	// 'itertools.repeat' type.
+	repeatType = newBasisType("repeat", reflect.TypeOf(repeat{}), toRepeatUnsafe, ObjectType)
+	// starMapType is the object representing the Python
+	// 'itertools.starmap' type.
+	starMapType = newBasisType("starmap", reflect.TypeOf(starMap{}), toStarMapUnsafe, ObjectType)
+	// takeWhileType is the object representing the Python
+	// 'itertools.takewhile' type.
+	takeWhileType = newBasisType("takewhile", reflect.TypeOf(takeWhile{}), toTakeWhileUnsafe, ObjectType)
+	// teeType is the object representing the Python 'itertools.tee'
+	// type. Note that the itertools.tee module attribute is the tee()
+	// function and not this type.
+	teeType = newBasisType("tee", reflect.TypeOf(tee{}), toTeeUnsafe, ObjectType)
+
+	combinationsParams = NewParamSpec("combinations", []Param{{"iterable", nil}, {"r", nil}}, false, false)
+	cwrParams          = NewParamSpec("combinations_with_replacement", []Param{{"iterable", nil}, {"r", nil}}, false, false)
+	compressParams     = NewParamSpec("compress", []Param{{"data", nil}, {"selectors", nil}}, false, false)
+	countParams        = NewParamSpec("count", []Param{{"start", NewInt(0).ToObject()}, {"step", NewInt(1).ToObject()}}, false, false)
+	groupByParams      = NewParamSpec("groupby", []Param{{"iterable", nil}, {"key", None}}, false, false)
+	permutationsParams = NewParamSpec("permutations", []Param{{"iterable", nil}, {"r", None}}, false, false)
+	repeatParams       = NewParamSpec("repeat", []Param{{"object", nil}, {"times", None}}, false, false)
+)
+
+func itertoolsIter(f *Frame, o *Object) (*Object, *BaseException) {
+	return o, nil
+}
+
+// itertoolsInitType installs the slots common to all itertools iterator
+// types on t.
+func itertoolsInitType(dict map[string]*Object, t *Type, newFunc func(*Frame, *Type, Args, KWArgs) (*Object, *BaseException), next func(*Frame, *Object) (*Object, *BaseException)) {
+	dict["__module__"] = NewStr("itertools").ToObject()
+	t.flags |= typeFlagNoDict
+	t.slots.Iter = &unaryOpSlot{itertoolsIter}
+	t.slots.Next = &unaryOpSlot{next}
+	if newFunc != nil {
+		t.slots.New = &newSlot{newFunc}
+	}
+}
+
+func itertoolsNoKeywords(f *Frame, name string, kwargs KWArgs) *BaseException {
+	if len(kwargs) > 0 {
+		return f.RaiseType(TypeErrorType, fmt.Sprintf("%s() does not take keyword arguments", name))
+	}
+	return nil
+}
+
+// itertoolsCheckArgs is like checkFunctionArgs but produces the error
+// messages of CPython's itertools constructors.
+func itertoolsCheckArgs(f *Frame, name string, args Args, kwargs KWArgs, argc int) *BaseException {
+	if raised := itertoolsNoKeywords(f, name, kwargs); raised != nil {
+		return raised
+	}
+	if len(args) != argc {
+		format := "%s expected %d arguments, got %d"
+		return f.RaiseType(TypeErrorType, fmt.Sprintf(format, name, argc, len(args)))
+	}
+	return nil
+}
+
+// itertoolsR validates the r argument of the combinatoric iterators.
+func itertoolsR(f *Frame, o *Object) (int, *BaseException) {
+	if o.typ.slots.Index == nil {
+		return 0, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	r, raised := IndexInt(f, o)
+	if raised != nil {
+		return 0, raised
+	}
+	if r < 0 {
+		return 0, f.RaiseType(ValueErrorType, "r must be non-negative")
+	}
+	return r, nil
+}
+
+func itertoolsIsNumber(o *Object) bool {
+	return o.typ.slots.Int != nil || o.typ.slots.Float != nil || o.typ.slots.Complex != nil
+}
+
+func itertoolsStop(f *Frame) *BaseException {
+	return f.Raise(StopIterationType.ToObject(), nil, nil)
+}
+
+// chain represents Python 'itertools.chain' objects.
+type chain struct {
+	Object
+	mutex  sync.Mutex
+	source *Object
+	active *Object
+}
+
+func toChainUnsafe(o *Object) *chain {
+	return (*chain)(o.toPointer())
+}
+
+// ToObject upcasts c to an Object.
+func (c *chain) ToObject() *Object {
+	return &c.Object
+}
+
+func newChain(t *Type, source *Object) *Object {
+	c := toChainUnsafe(newObject(t))
+	c.source = source
+	return c.ToObject()
+}
+
+func chainNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsNoKeywords(f, "chain", kwargs); raised != nil {
+		return nil, raised
+	}
+	source, raised := Iter(f, NewTuple(args.makeCopy()...).ToObject())
+	if raised != nil {
+		return nil, raised
+	}
+	return newChain(t, source), nil
+}
+
+func chainFromIterable(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "from_iterable", args, TypeType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	t := toTypeUnsafe(args[0])
+	if !t.isSubclass(chainType) {
+		format := "chain.from_iterable(%s): %s is not a subtype of chain"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, t.Name(), t.Name()))
+	}
+	source, raised := Iter(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	return newChain(t, source), nil
+}
+
+func chainNext(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toChainUnsafe(o)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for {
+		if c.active == nil {
+			if c.source == nil {
+				return nil, itertoolsStop(f)
+			}
+			iterable, raised := Next(f, c.source)
+			if raised != nil {
+				if raised.isInstance(StopIterationType) {
+					c.source = nil
+				}
+				return nil, raised
+			}
+			if c.active, raised = Iter(f, iterable); raised != nil {
+				return nil, raised
+			}
+		}
+		item, raised := Next(f, c.active)
+		if raised == nil {
+			return item, nil
+		}
+		if !raised.isInstance(StopIterationType) {
+			return nil, raised
+		}
+		f.RestoreExc(nil, nil)
+		c.active = nil
+	}
+}
+
+func initChainType(dict map[string]*Object) {
+	dict["from_iterable"] = newClassMethod(newBuiltinFunction("from_iterable", chainFromIterable).ToObject()).ToObject()
+	itertoolsInitType(dict, chainType, chainNew, chainNext)
+}
+
+// combinations represents Python 'itertools.combinations' objects.
+type combinations struct {
+	Object
+	mutex   sync.Mutex
+	pool    []*Object
+	indices []int
+	first   bool
+	stopped bool
+}
+
+func toCombinationsUnsafe(o *Object) *combinations {
+	return (*combinations)(o.toPointer())
+}
+
+// ToObject upcasts c to an Object.
+func (c *combinations) ToObject() *Object {
+	return &c.Object
+}
+
+func combinationsNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := combinationsParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	pool, raised := seqNew(f, Args{validated[0]})
+	if raised != nil {
+		return nil, raised
+	}
+	r, raised := itertoolsR(f, validated[1])
+	if raised != nil {
+		return nil, raised
+	}
+	c := toCombinationsUnsafe(newObject(t))
+	c.pool = pool
+	c.indices = make([]int, r)
+	for i := range c.indices {
+		c.indices[i] = i
+	}
+	c.first = true
+	c.stopped = r > len(pool)
+	return c.ToObject(), nil
+}
+
+func combinationsNext(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toCombinationsUnsafe(o)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.stopped {
+		return nil, itertoolsStop(f)
+	}
+	n, r := len(c.pool), len(c.indices)
+	if c.first {
+		c.first = false
+	} else {
+		// Find the rightmost index that can be incremented and reset
+		// the indices to its right to consecutive values.
+		i := r - 1
+		for i >= 0 && c.indices[i] == i+n-r {
+			i--
+		}
+		if i < 0 {
+			c.stopped = true
+			return nil, itertoolsStop(f)
+		}
+		c.indices[i]++
+		for j := i + 1; j < r; j++ {
+			c.indices[j] = c.indices[j-1] + 1
+		}
+	}
+	result := make([]*Object, r)
+	for i, index := range c.indices {
+		result[i] = c.pool[index]
+	}
+	return NewTuple(result...).ToObject(), nil
+}
+
+func initCombinationsType(dict map[string]*Object) {
+	itertoolsInitType(dict, combinationsType, combinationsNew, combinationsNext)
+}
+
+// cwr represents Python 'itertools.combinations_with_replacement'
+// objects.
+type cwr struct {
+	Object
+	mutex   sync.Mutex
+	pool    []*Object
+	indices []int
+	first   bool
+	stopped bool
+}
+
+func toCWRUnsafe(o *Object) *cwr {
+	return (*cwr)(o.toPointer())
+}
+
+// ToObject upcasts c to an Object.
+func (c *cwr) ToObject() *Object {
+	return &c.Object
+}
+
+func cwrNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := cwrParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	pool, raised := seqNew(f, Args{validated[0]})
+	if raised != nil {
+		return nil, raised
+	}
+	r, raised := itertoolsR(f, validated[1])
+	if raised != nil {
+		return nil, raised
+	}
+	c := toCWRUnsafe(newObject(t))
+	c.pool = pool
+	c.indices = make([]int, r)
+	c.first = true
+	c.stopped = len(pool) == 0 && r > 0
+	return c.ToObject(), nil
+}
+
+func cwrNext(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toCWRUnsafe(o)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.stopped {
+		return nil, itertoolsStop(f)
+	}
+	n, r := len(c.pool), len(c.indices)
+	if c.first {
+		c.first = false
+	} else {
+		i := r - 1
+		for i >= 0 && c.indices[i] == n-1 {
+			i--
+		}
+		if i < 0 {
+			c.stopped = true
+			return nil, itertoolsStop(f)
+		}
+		index := c.indices[i] + 1
+		for j := i; j < r; j++ {
+			c.indices[j] = index
+		}
+	}
+	result := make([]*Object, r)
+	for i, index := range c.indices {
+		result[i] = c.pool[index]
+	}
+	return NewTuple(result...).ToObject(), nil
+}
+
+func initCWRType(dict map[string]*Object) {
+	itertoolsInitType(dict, cwrType, cwrNew, cwrNext)
+}
+
+// compress represents Python 'itertools.compress' objects.
+type compress struct {
+	Object
+	mutex     sync.Mutex
+	data      *Object
+	selectors *Object
+}
+
+func toCompressUnsafe(o *Object) *compress {
+	return (*compress)(o.toPointer())
+}
+
+// ToObject upcasts c to an Object.
+func (c *compress) ToObject() *Object {
+	return &c.Object
+}
+
+func compressNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := compressParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	iters, raised := initIters(f, validated[:])
+	if raised != nil {
+		return nil, raised
+	}
+	c := toCompressUnsafe(newObject(t))
+	c.data = iters[0]
+	c.selectors = iters[1]
+	return c.ToObject(), nil
+}
+
+func compressNext(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toCompressUnsafe(o)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for {
+		item, raised := Next(f, c.data)
+		if raised != nil {
+			return nil, raised
+		}
+		selector, raised := Next(f, c.selectors)
+		if raised != nil {
+			return nil, raised
+		}
+		ok, raised := IsTrue(f, selector)
+		if raised != nil {
+			return nil, raised
+		}
+		if ok {
+			return item, nil
+		}
+	}
+}
+
+func initCompressType(dict map[string]*Object) {
+	itertoolsInitType(dict, compressType, compressNew, compressNext)
+}
+
+// count represents Python 'itertools.count' objects.
+type count struct {
+	Object
+	mutex sync.Mutex
+	cnt   *Object
+	step  *Object
+}
+
+func toCountUnsafe(o *Object) *count {
+	return (*count)(o.toPointer())
+}
+
+// ToObject upcasts c to an Object.
+func (c *count) ToObject() *Object {
+	return &c.Object
+}
+
+func countNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := countParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	for _, o := range validated {
+		if !itertoolsIsNumber(o) {
+			return nil, f.RaiseType(TypeErrorType, "a number is required")
+		}
+	}
+	c := toCountUnsafe(newObject(t))
+	c.cnt = validated[0]
+	c.step = validated[1]
+	return c.ToObject(), nil
+}
+
+func countNext(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toCountUnsafe(o)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	next, raised := Add(f, c.cnt, c.step)
+	if raised != nil {
+		return nil, raised
+	}
+	item := c.cnt
+	c.cnt = next
+	return item, nil
+}
+
+func countRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toCountUnsafe(o)
+	c.mutex.Lock()
+	cnt, step := c.cnt, c.step
+	c.mutex.Unlock()
+	s, raised := Repr(f, cnt)
+	if raised != nil {
+		return nil, raised
+	}
+	if step.typ == IntType && toIntUnsafe(step).Value() == 1 {
+		return NewStr(fmt.Sprintf("count(%s)", s.Value())).ToObject(), nil
+	}
+	stepRepr, raised := Repr(f, step)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(fmt.Sprintf("count(%s, %s)", s.Value(), stepRepr.Value())).ToObject(), nil
+}
+
+func initCountType(dict map[string]*Object) {
+	itertoolsInitType(dict, countType, countNew, countNext)
+	countType.slots.Repr = &unaryOpSlot{countRepr}
+}
+
+// cycle represents Python 'itertools.cycle' objects.
+type cycle struct {
+	Object
+	mutex     sync.Mutex
+	it        *Object
+	saved     []*Object
+	index     int
+	firstPass bool
+}
+
+func toCycleUnsafe(o *Object) *cycle {
+	return (*cycle)(o.toPointer())
+}
+
+// ToObject upcasts c to an Object.
+func (c *cycle) ToObject() *Object {
+	return &c.Object
+}
+
+func cycleNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsCheckArgs(f, "cycle", args, kwargs, 1); raised != nil {
+		return nil, raised
+	}
+	it, raised := Iter(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	c := toCycleUnsafe(newObject(t))
+	c.it = it
+	c.firstPass = true
+	return c.ToObject(), nil
+}
+
+func cycleNext(f *Frame, o *Object) (*Object, *BaseException) {
+	c := toCycleUnsafe(o)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.firstPass {
+		item, raised := Next(f, c.it)
+		if raised == nil {
+			c.saved = append(c.saved, item)
+			return item, nil
+		}
+		if !raised.isInstance(StopIterationType) || len(c.saved) == 0 {
+			return nil, raised
+		}
+		f.RestoreExc(nil, nil)
+		c.firstPass = false
+	}
+	item := c.saved[c.index]
+	c.index = (c.index + 1) % len(c.saved)
+	return item, nil
+}
+
+func initCycleType(dict map[string]*Object) {
+	itertoolsInitType(dict, cycleType, cycleNew, cycleNext)
+}
+
+// dropWhile represents Python 'itertools.dropwhile' objects.
+type dropWhile struct {
+	Object
+	mutex    sync.Mutex
+	pred     *Object
+	it       *Object
+	dropping bool
+}
+
+func toDropWhileUnsafe(o *Object) *dropWhile {
+	return (*dropWhile)(o.toPointer())
+}
+
+// ToObject upcasts d to an Object.
+func (d *dropWhile) ToObject() *Object {
+	return &d.Object
+}
+
+func dropWhileNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsCheckArgs(f, "dropwhile", args, kwargs, 2); raised != nil {
+		return nil, raised
+	}
+	it, raised := Iter(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	d := toDropWhileUnsafe(newObject(t))
+	d.pred = args[0]
+	d.it = it
+	d.dropping = true
+	return d.ToObject(), nil
+}
+
+func dropWhileNext(f *Frame, o *Object) (*Object, *BaseException) {
+	d := toDropWhileUnsafe(o)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for {
+		item, raised := Next(f, d.it)
+		if raised != nil {
+			return nil, raised
+		}
+		if !d.dropping {
+			return item, nil
+		}
+		result, raised := d.pred.Call(f, Args{item}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		ok, raised := IsTrue(f, result)
+		if raised != nil {
+			return nil, raised
+		}
+		if !ok {
+			d.dropping = false
+			return item, nil
+		}
+	}
+}
+
+func initDropWhileType(dict map[string]*Object) {
+	itertoolsInitType(dict, dropWhileType, dropWhileNew, dropWhileNext)
+}
+
+// groupBy represents Python 'itertools.groupby' objects. The current key
+// and value are shared with the _grouper for the current group and are
+// protected by mutex.
+type groupBy struct {
+	Object
+	mutex     sync.Mutex
+	it        *Object
+	keyFunc   *Object
+	tgtKey    *Object
+	currKey   *Object
+	currValue *Object
+}
+
+func toGroupByUnsafe(o *Object) *groupBy {
+	return (*groupBy)(o.toPointer())
+}
+
+// ToObject upcasts g to an Object.
+func (g *groupBy) ToObject() *Object {
+	return &g.Object
+}
+
+// advance fetches the next value from the underlying iterator and
+// computes its key. g.mutex must be held.
+func (g *groupBy) advance(f *Frame) *BaseException {
+	value, raised := Next(f, g.it)
+	if raised != nil {
+		return raised
+	}
+	key := value
+	if g.keyFunc != None {
+		if key, raised = g.keyFunc.Call(f, Args{value}, nil); raised != nil {
+			return raised
+		}
+	}
+	g.currKey = key
+	g.currValue = value
+	return nil
+}
+
+func groupByNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := groupByParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	it, raised := Iter(f, validated[0])
+	if raised != nil {
+		return nil, raised
+	}
+	g := toGroupByUnsafe(newObject(t))
+	g.it = it
+	g.keyFunc = validated[1]
+	return g.ToObject(), nil
+}
+
+func groupByNext(f *Frame, o *Object) (*Object, *BaseException) {
+	g := toGroupByUnsafe(o)
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	// Skip over the remainder of the current group.
+	for {
+		if g.currKey != nil {
+			if g.tgtKey == nil {
+				break
+			}
+			eq, raised := Eq(f, g.tgtKey, g.currKey)
+			if raised != nil {
+				return nil, raised
+			}
+			same, raised := IsTrue(f, eq)
+			if raised != nil {
+				return nil, raised
+			}
+			if !same {
+				break
+			}
+		}
+		if raised := g.advance(f); raised != nil {
+			return nil, raised
+		}
+	}
+	g.tgtKey = g.currKey
+	gr := toGrouperUnsafe(newObject(grouperType))
+	gr.parent = g
+	gr.tgtKey = g.tgtKey
+	return NewTuple2(g.currKey, gr.ToObject()).ToObject(), nil
+}
+
+func initGroupByType(dict map[string]*Object) {
+	itertoolsInitType(dict, groupByType, groupByNew, groupByNext)
+}
+
+// grouper represents Python 'itertools._grouper' objects.
+type grouper struct {
+	Object
+	parent *groupBy
+	tgtKey *Object
+}
+
+func toGrouperUnsafe(o *Object) *grouper {
+	return (*grouper)(o.toPointer())
+}
+
+// ToObject upcasts g to an Object.
+func (g *grouper) ToObject() *Object {
+	return &g.Object
+}
+
+func grouperNext(f *Frame, o *Object) (*Object, *BaseException) {
+	gr := toGrouperUnsafe(o)
+	g := gr.parent
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.currValue == nil {
+		if raised := g.advance(f); raised != nil {
+			return nil, raised
+		}
+	}
+	eq, raised := Eq(f, gr.tgtKey, g.currKey)
+	if raised != nil {
+		return nil, raised
+	}
+	same, raised := IsTrue(f, eq)
+	if raised != nil {
+		return nil, raised
+	}
+	if !same {
+		return nil, itertoolsStop(f)
+	}
+	item := g.currValue
+	g.currKey = nil
+	g.currValue = nil
+	return item, nil
+}
+
+func initGrouperType(dict map[string]*Object) {
+	itertoolsInitType(dict, grouperType, nil, grouperNext)
+	grouperType.flags &^= typeFlagBasetype | typeFlagInstantiable
+}
+
+// iFilterState holds the state shared by ifilter and ifilterfalse.
+type iFilterState struct {
+	mutex sync.Mutex
+	pred  *Object
+	it    *Object
+}
+
+func (s *iFilterState) init(f *Frame, name string, args Args, kwargs KWArgs) *BaseException {
+	if raised := itertoolsCheckArgs(f, name, args, kwargs, 2); raised != nil {
+		return raised
+	}
+	it, raised := Iter(f, args[1])
+	if raised != nil {
+		return raised
+	}
+	s.pred = args[0]
+	s.it = it
+	return nil
+}
+
+// next returns the next item whose truth value (or the truth value of
+// pred applied to it) is want.
+func (s *iFilterState) next(f *Frame, want bool) (*Object, *BaseException) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for {
+		item, raised := Next(f, s.it)
+		if raised != nil {
+			return nil, raised
+		}
+		result := item
+		if s.pred != None {
+			if result, raised = s.pred.Call(f, Args{item}, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		ok, raised := IsTrue(f, result)
+		if raised != nil {
+			return nil, raised
+		}
+		if ok == want {
+			return item, nil
+		}
+	}
+}
+
+// iFilter represents Python 'itertools.ifilter' objects.
+type iFilter struct {
+	Object
+	iFilterState
+}
+
+func toIFilterUnsafe(o *Object) *iFilter {
+	return (*iFilter)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *iFilter) ToObject() *Object {
+	return &i.Object
+}
+
+func iFilterNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	i := toIFilterUnsafe(newObject(t))
+	if raised := i.init(f, "ifilter", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	return i.ToObject(), nil
+}
+
+func iFilterNext(f *Frame, o *Object) (*Object, *BaseException) {
+	return toIFilterUnsafe(o).next(f, true)
+}
+
+func initIFilterType(dict map[string]*Object) {
+	itertoolsInitType(dict, iFilterType, iFilterNew, iFilterNext)
+}
+
+// iFilterFalse represents Python 'itertools.ifilterfalse' objects.
+type iFilterFalse struct {
+	Object
+	iFilterState
+}
+
+func toIFilterFalseUnsafe(o *Object) *iFilterFalse {
+	return (*iFilterFalse)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *iFilterFalse) ToObject() *Object {
+	return &i.Object
+}
+
+func iFilterFalseNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	i := toIFilterFalseUnsafe(newObject(t))
+	if raised := i.init(f, "ifilterfalse", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	return i.ToObject(), nil
+}
+
+func iFilterFalseNext(f *Frame, o *Object) (*Object, *BaseException) {
+	return toIFilterFalseUnsafe(o).next(f, false)
+}
+
+func initIFilterFalseType(dict map[string]*Object) {
+	itertoolsInitType(dict, iFilterFalseType, iFilterFalseNew, iFilterFalseNext)
+}
+
+// iMap represents Python 'itertools.imap' objects.
+type iMap struct {
+	Object
+	mutex sync.Mutex
+	fn    *Object
+	iters []*Object
+}
+
+func toIMapUnsafe(o *Object) *iMap {
+	return (*iMap)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *iMap) ToObject() *Object {
+	return &i.Object
+}
+
+func iMapNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsNoKeywords(f, "imap", kwargs); raised != nil {
+		return nil, raised
+	}
+	if len(args) < 2 {
+		return nil, f.RaiseType(TypeErrorType, "imap() must have at least two arguments.")
+	}
+	iters, raised := initIters(f, args[1:])
+	if raised != nil {
+		return nil, raised
+	}
+	i := toIMapUnsafe(newObject(t))
+	i.fn = args[0]
+	i.iters = iters
+	return i.ToObject(), nil
+}
+
+func iMapNext(f *Frame, o *Object) (*Object, *BaseException) {
+	i := toIMapUnsafe(o)
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	elems := make([]*Object, len(i.iters))
+	for j, iter := range i.iters {
+		item, raised := Next(f, iter)
+		if raised != nil {
+			return nil, raised
+		}
+		elems[j] = item
+	}
+	if i.fn == None {
+		return NewTuple(elems...).ToObject(), nil
+	}
+	return i.fn.Call(f, elems, nil)
+}
+
+func initIMapType(dict map[string]*Object) {
+	itertoolsInitType(dict, iMapType, iMapNew, iMapNext)
+}
+
+// iSlice represents Python 'itertools.islice' objects. A stop of -1
+// means the slice is unbounded.
+type iSlice struct {
+	Object
+	mutex sync.Mutex
+	it    *Object
+	next  int
+	stop  int
+	step  int
+	cnt   int
+}
+
+func toISliceUnsafe(o *Object) *iSlice {
+	return (*iSlice)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *iSlice) ToObject() *Object {
+	return &i.Object
+}
+
+// iSliceIndex converts an islice() argument to a non-negative int,
+// returning def if o is None.
+func iSliceIndex(f *Frame, o *Object, def int, msg string) (int, *BaseException) {
+	if o == None {
+		return def, nil
+	}
+	if o.typ.slots.Index != nil {
+		i, raised := IndexInt(f, o)
+		if raised == nil && i >= 0 {
+			return i, nil
+		}
+		if raised != nil {
+			f.RestoreExc(nil, nil)
+		}
+	}
+	return 0, f.RaiseType(ValueErrorType, msg)
+}
+
+func iSliceNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsNoKeywords(f, "islice", kwargs); raised != nil {
+		return nil, raised
+	}
+	argc := len(args)
+	if argc < 2 {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("islice expected at least 2 arguments, got %d", argc))
+	}
+	if argc > 4 {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("islice expected at most 4 arguments, got %d", argc))
+	}
+	const stopMsg = "Stop argument for islice() must be None or an integer: 0 <= x <= maxint."
+	start, stop, step := 0, -1, 1
+	var raised *BaseException
+	if argc == 2 {
+		if stop, raised = iSliceIndex(f, args[1], -1, stopMsg); raised != nil {
+			return nil, raised
+		}
+	} else {
+		const indicesMsg = "Indices for islice() must be None or an integer: 0 <= x <= maxint."
+		if start, raised = iSliceIndex(f, args[1], 0, indicesMsg); raised != nil {
+			return nil, raised
+		}
+		if stop, raised = iSliceIndex(f, args[2], -1, stopMsg); raised != nil {
+			return nil, raised
+		}
+		if argc == 4 {
+			const stepMsg = "Step for islice() must be a positive integer or None."
+			if step, raised = iSliceIndex(f, args[3], 1, stepMsg); raised != nil {
+				return nil, raised
+			}
+			if step < 1 {
+				return nil, f.RaiseType(ValueErrorType, stepMsg)
+			}
+		}
+	}
+	it, raised := Iter(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	i := toISliceUnsafe(newObject(t))
+	i.it = it
+	i.next = start
+	i.stop = stop
+	i.step = step
+	return i.ToObject(), nil
+}
+
+func iSliceNext(f *Frame, o *Object) (*Object, *BaseException) {
+	i := toISliceUnsafe(o)
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for i.cnt < i.next {
+		if _, raised := Next(f, i.it); raised != nil {
+			return nil, raised
+		}
+		i.cnt++
+	}
+	if i.stop != -1 && i.cnt >= i.stop {
+		return nil, itertoolsStop(f)
+	}
+	item, raised := Next(f, i.it)
+	if raised != nil {
+		return nil, raised
+	}
+	i.cnt++
+	oldNext := i.next
+	i.next += i.step
+	if i.next < oldNext || (i.stop != -1 && i.next > i.stop) {
+		i.next = i.stop
+	}
+	return item, nil
+}
+
+func initISliceType(dict map[string]*Object) {
+	itertoolsInitType(dict, iSliceType, iSliceNew, iSliceNext)
+}
+
+// itertoolsInitIters is like initIters but reports arguments that don't
+// support iteration the way CPython's izip and izip_longest do.
+func itertoolsInitIters(f *Frame, name string, items []*Object) ([]*Object, *BaseException) {
+	iters := make([]*Object, len(items))
+	for i, item := range items {
+		iter, raised := Iter(f, item)
+		if raised != nil {
+			if raised.isInstance(TypeErrorType) {
+				f.RestoreExc(nil, nil)
+				raised = f.RaiseType(TypeErrorType, fmt.Sprintf("%s argument #%d must support iteration", name, i+1))
+			}
+			return nil, raised
+		}
+		iters[i] = iter
+	}
+	return iters, nil
+}
+
+// iZip represents Python 'itertools.izip' objects.
+type iZip struct {
+	Object
+	mutex sync.Mutex
+	iters []*Object
+}
+
+func toIZipUnsafe(o *Object) *iZip {
+	return (*iZip)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *iZip) ToObject() *Object {
+	return &i.Object
+}
+
+func iZipNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsNoKeywords(f, "izip", kwargs); raised != nil {
+		return nil, raised
+	}
+	iters, raised := itertoolsInitIters(f, "izip", args)
+	if raised != nil {
+		return nil, raised
+	}
+	i := toIZipUnsafe(newObject(t))
+	i.iters = iters
+	return i.ToObject(), nil
+}
+
+func iZipNext(f *Frame, o *Object) (*Object, *BaseException) {
+	i := toIZipUnsafe(o)
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if len(i.iters) == 0 {
+		return nil, itertoolsStop(f)
+	}
+	elems := make([]*Object, len(i.iters))
+	for j, iter := range i.iters {
+		item, raised := Next(f, iter)
+		if raised != nil {
+			return nil, raised
+		}
+		elems[j] = item
+	}
+	return NewTuple(elems...).ToObject(), nil
+}
+
+func initIZipType(dict map[string]*Object) {
+	itertoolsInitType(dict, iZipType, iZipNew, iZipNext)
+}
+
+// iZipLongest represents Python 'itertools.izip_longest' objects.
+// Exhausted iterators are replaced with nil in iters.
+type iZipLongest struct {
+	Object
+	mutex     sync.Mutex
+	iters     []*Object
+	numActive int
+	fillValue *Object
+}
+
+func toIZipLongestUnsafe(o *Object) *iZipLongest {
+	return (*iZipLongest)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *iZipLongest) ToObject() *Object {
+	return &i.Object
+}
+
+func iZipLongestNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	fillValue := None
+	for _, kwarg := range kwargs {
+		if kwarg.Name != "fillvalue" {
+			return nil, f.RaiseType(TypeErrorType, "izip_longest() got an unexpected keyword argument")
+		}
+		fillValue = kwarg.Value
+	}
+	iters, raised := itertoolsInitIters(f, "izip_longest", args)
+	if raised != nil {
+		return nil, raised
+	}
+	i := toIZipLongestUnsafe(newObject(t))
+	i.iters = iters
+	i.numActive = len(iters)
+	i.fillValue = fillValue
+	return i.ToObject(), nil
+}
+
+func iZipLongestNext(f *Frame, o *Object) (*Object, *BaseException) {
+	i := toIZipLongestUnsafe(o)
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if i.numActive == 0 {
+		return nil, itertoolsStop(f)
+	}
+	elems := make([]*Object, len(i.iters))
+	for j, iter := range i.iters {
+		if iter == nil {
+			elems[j] = i.fillValue
+			continue
+		}
+		item, raised := Next(f, iter)
+		if raised != nil {
+			if !raised.isInstance(StopIterationType) {
+				return nil, raised
+			}
+			i.iters[j] = nil
+			i.numActive--
+			if i.numActive == 0 {
+				return nil, raised
+			}
+			f.RestoreExc(nil, nil)
+			item = i.fillValue
+		}
+		elems[j] = item
+	}
+	return NewTuple(elems...).ToObject(), nil
+}
+
+func initIZipLongestType(dict map[string]*Object) {
+	itertoolsInitType(dict, iZipLongestType, iZipLongestNew, iZipLongestNext)
+}
+
+// permutations represents Python 'itertools.permutations' objects.
+type permutations struct {
+	Object
+	mutex   sync.Mutex
+	pool    []*Object
+	r       int
+	indices []int
+	cycles  []int
+	first   bool
+	stopped bool
+}
+
+func toPermutationsUnsafe(o *Object) *permutations {
+	return (*permutations)(o.toPointer())
+}
+
+// ToObject upcasts p to an Object.
+func (p *permutations) ToObject() *Object {
+	return &p.Object
+}
+
+func permutationsNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := permutationsParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	pool, raised := seqNew(f, Args{validated[0]})
+	if raised != nil {
+		return nil, raised
+	}
+	n := len(pool)
+	r := n
+	if validated[1] != None {
+		if r, raised = itertoolsR(f, validated[1]); raised != nil {
+			return nil, raised
+		}
+	}
+	p := toPermutationsUnsafe(newObject(t))
+	p.pool = pool
+	p.r = r
+	p.indices = make([]int, n)
+	for i := range p.indices {
+		p.indices[i] = i
+	}
+	if r <= n {
+		p.cycles = make([]int, r)
+		for i := range p.cycles {
+			p.cycles[i] = n - i
+		}
+	}
+	p.first = true
+	p.stopped = r > n
+	return p.ToObject(), nil
+}
+
+func permutationsNext(f *Frame, o *Object) (*Object, *BaseException) {
+	p := toPermutationsUnsafe(o)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stopped {
+		return nil, itertoolsStop(f)
+	}
+	n, r := len(p.pool), p.r
+	if p.first {
+		p.first = false
+	} else {
+		if n == 0 {
+			p.stopped = true
+			return nil, itertoolsStop(f)
+		}
+		i := r - 1
+		for ; i >= 0; i-- {
+			p.cycles[i]--
+			if p.cycles[i] == 0 {
+				// Rotate indices[i:] left by one.
+				index := p.indices[i]
+				copy(p.indices[i:], p.indices[i+1:])
+				p.indices[n-1] = index
+				p.cycles[i] = n - i
+			} else {
+				j := p.cycles[i]
+				p.indices[i], p.indices[n-j] = p.indices[n-j], p.indices[i]
+				break
+			}
+		}
+		if i < 0 {
+			p.stopped = true
+			return nil, itertoolsStop(f)
+		}
+	}
+	result := make([]*Object, r)
+	for i, index := range p.indices[:r] {
+		result[i] = p.pool[index]
+	}
+	return NewTuple(result...).ToObject(), nil
+}
+
+func initPermutationsType(dict map[string]*Object) {
+	itertoolsInitType(dict, permutationsType, permutationsNew, permutationsNext)
+}
+
+// product represents Python 'itertools.product' objects. indices acts as
+// an odometer over pools and result holds the most recent tuple's
+// elements.
+type product struct {
+	Object
+	mutex   sync.Mutex
+	pools   [][]*Object
+	indices []int
+	result  []*Object
+	stopped bool
+}
+
+func toProductUnsafe(o *Object) *product {
+	return (*product)(o.toPointer())
+}
+
+// ToObject upcasts p to an Object.
+func (p *product) ToObject() *Object {
+	return &p.Object
+}
+
+func productNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	repeat := 1
+	for _, kwarg := range kwargs {
+		if kwarg.Name != "repeat" {
+			format := "'%s' is an invalid keyword argument for this function"
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, kwarg.Name))
+		}
+		if kwarg.Value.typ.slots.Index == nil {
+			return nil, f.RaiseType(TypeErrorType, "an integer is required")
+		}
+		i, raised := IndexInt(f, kwarg.Value)
+		if raised != nil {
+			return nil, raised
+		}
+		if i < 0 {
+			return nil, f.RaiseType(ValueErrorType, "repeat argument cannot be negative")
+		}
+		repeat = i
+	}
+	pools := make([][]*Object, 0, len(args)*repeat)
+	for _, arg := range args {
+		pool, raised := seqNew(f, Args{arg})
+		if raised != nil {
+			return nil, raised
+		}
+		pools = append(pools, pool)
+	}
+	n := len(pools)
+	for i := 1; i < repeat; i++ {
+		pools = append(pools, pools[:n]...)
+	}
+	if repeat == 0 {
+		pools = pools[:0]
+	}
+	p := toProductUnsafe(newObject(t))
+	p.pools = pools
+	return p.ToObject(), nil
+}
+
+func productNext(f *Frame, o *Object) (*Object, *BaseException) {
+	p := toProductUnsafe(o)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stopped {
+		return nil, itertoolsStop(f)
+	}
+	if p.result == nil {
+		p.indices = make([]int, len(p.pools))
+		p.result = make([]*Object, len(p.pools))
+		for i, pool := range p.pools {
+			if len(pool) == 0 {
+				p.stopped = true
+				return nil, itertoolsStop(f)
+			}
+			p.result[i] = pool[0]
+		}
+	} else {
+		// Advance the odometer, carrying into the next pool to the left
+		// whenever an index wraps around.
+		i := len(p.pools) - 1
+		for ; i >= 0; i-- {
+			pool := p.pools[i]
+			p.indices[i]++
+			if p.indices[i] < len(pool) {
+				p.result[i] = pool[p.indices[i]]
+				break
+			}
+			p.indices[i] = 0
+			p.result[i] = pool[0]
+		}
+		if i < 0 {
+			p.stopped = true
+			return nil, itertoolsStop(f)
+		}
+	}
+	return NewTuple(Args(p.result).makeCopy()...).ToObject(), nil
+}
+
+func initProductType(dict map[string]*Object) {
+	itertoolsInitType(dict, productType, productNew, productNext)
+}
+
+// repeat represents Python 'itertools.repeat' objects. A remaining count
+// of -1 means the object is repeated forever.
+type repeat struct {
+	Object
+	mutex     sync.Mutex
+	elem      *Object
+	remaining int
+}
+
+func toRepeatUnsafe(o *Object) *repeat {
+	return (*repeat)(o.toPointer())
+}
+
+// ToObject upcasts r to an Object.
+func (r *repeat) ToObject() *Object {
+	return &r.Object
+}
+
+func repeatNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [2]*Object
+	if raised := repeatParams.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	remaining := -1
+	if times := validated[1]; times != None {
+		if times.typ.slots.Index == nil {
+			return nil, f.RaiseType(TypeErrorType, "an integer is required")
+		}
+		i, raised := IndexInt(f, times)
+		if raised != nil {
+			return nil, raised
+		}
+		remaining = 0
+		if i > 0 {
+			remaining = i
+		}
+	}
+	r := toRepeatUnsafe(newObject(t))
+	r.elem = validated[0]
+	r.remaining = remaining
+	return r.ToObject(), nil
+}
+
+func repeatNext(f *Frame, o *Object) (*Object, *BaseException) {
+	r := toRepeatUnsafe(o)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.remaining == 0 {
+		return nil, itertoolsStop(f)
+	}
+	if r.remaining > 0 {
+		r.remaining--
+	}
+	return r.elem, nil
+}
+
+func repeatLengthHint(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__length_hint__", args, repeatType); raised != nil {
+		return nil, raised
+	}
+	r := toRepeatUnsafe(args[0])
+	r.mutex.Lock()
+	remaining := r.remaining
+	r.mutex.Unlock()
+	if remaining == -1 {
+		return nil, f.RaiseType(TypeErrorType, "len() of unsized object")
+	}
+	return NewInt(remaining).ToObject(), nil
+}
+
+func repeatRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	r := toRepeatUnsafe(o)
+	r.mutex.Lock()
+	remaining := r.remaining
+	r.mutex.Unlock()
+	s, raised := Repr(f, r.elem)
+	if raised != nil {
+		return nil, raised
+	}
+	if remaining == -1 {
+		return NewStr(fmt.Sprintf("repeat(%s)", s.Value())).ToObject(), nil
+	}
+	return NewStr(fmt.Sprintf("repeat(%s, %d)", s.Value(), remaining)).ToObject(), nil
+}
+
+func initRepeatType(dict map[string]*Object) {
+	dict["__length_hint__"] = newBuiltinFunction("__length_hint__", repeatLengthHint).ToObject()
+	itertoolsInitType(dict, repeatType, repeatNew, repeatNext)
+	repeatType.slots.Repr = &unaryOpSlot{repeatRepr}
+}
+
+// starMap represents Python 'itertools.starmap' objects.
+type starMap struct {
+	Object
+	mutex sync.Mutex
+	fn    *Object
+	it    *Object
+}
+
+func toStarMapUnsafe(o *Object) *starMap {
+	return (*starMap)(o.toPointer())
+}
+
+// ToObject upcasts s to an Object.
+func (s *starMap) ToObject() *Object {
+	return &s.Object
+}
+
+func starMapNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsCheckArgs(f, "starmap", args, kwargs, 2); raised != nil {
+		return nil, raised
+	}
+	it, raised := Iter(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	s := toStarMapUnsafe(newObject(t))
+	s.fn = args[0]
+	s.it = it
+	return s.ToObject(), nil
+}
+
+func starMapNext(f *Frame, o *Object) (*Object, *BaseException) {
+	s := toStarMapUnsafe(o)
+	s.mutex.Lock()
+	item, raised := Next(f, s.it)
+	s.mutex.Unlock()
+	if raised != nil {
+		return nil, raised
+	}
+	var callArgs Args
+	if item.isInstance(TupleType) {
+		callArgs = toTupleUnsafe(item).elems
+	} else if callArgs, raised = seqNew(f, Args{item}); raised != nil {
+		return nil, raised
+	}
+	return s.fn.Call(f, callArgs, nil)
+}
+
+func initStarMapType(dict map[string]*Object) {
+	itertoolsInitType(dict, starMapType, starMapNew, starMapNext)
+}
+
+// takeWhile represents Python 'itertools.takewhile' objects.
+type takeWhile struct {
+	Object
+	mutex   sync.Mutex
+	pred    *Object
+	it      *Object
+	stopped bool
+}
+
+func toTakeWhileUnsafe(o *Object) *takeWhile {
+	return (*takeWhile)(o.toPointer())
+}
+
+// ToObject upcasts t to an Object.
+func (t *takeWhile) ToObject() *Object {
+	return &t.Object
+}
+
+func takeWhileNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsCheckArgs(f, "takewhile", args, kwargs, 2); raised != nil {
+		return nil, raised
+	}
+	it, raised := Iter(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	tw := toTakeWhileUnsafe(newObject(t))
+	tw.pred = args[0]
+	tw.it = it
+	return tw.ToObject(), nil
+}
+
+func takeWhileNext(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTakeWhileUnsafe(o)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.stopped {
+		return nil, itertoolsStop(f)
+	}
+	item, raised := Next(f, t.it)
+	if raised != nil {
+		return nil, raised
+	}
+	result, raised := t.pred.Call(f, Args{item}, nil)
+	if raised != nil {
+		return nil, raised
+	}
+	ok, raised := IsTrue(f, result)
+	if raised != nil {
+		return nil, raised
+	}
+	if !ok {
+		t.stopped = true
+		return nil, itertoolsStop(f)
+	}
+	return item, nil
+}
+
+func initTakeWhileType(dict map[string]*Object) {
+	itertoolsInitType(dict, takeWhileType, takeWhileNew, takeWhileNext)
+}
+
+// teeSource is the underlying iterator shared by a family of tee objects.
+// Its mutex protects the iterator, the buffered teeData links and the
+// positions of all tee objects reading from them.
+type teeSource struct {
+	mutex sync.Mutex
+	it    *Object
+}
+
+// teeData is a link in the list of values read from a teeSource. Links
+// are dropped by the garbage collector once every tee has moved past
+// them.
+type teeData struct {
+	values  [teeDataSize]*Object
+	numRead int
+	next    *teeData
+}
+
+// tee represents Python 'itertools.tee' objects.
+type tee struct {
+	Object
+	source *teeSource
+	data   *teeData
+	index  int
+}
+
+func toTeeUnsafe(o *Object) *tee {
+	return (*tee)(o.toPointer())
+}
+
+// ToObject upcasts t to an Object.
+func (t *tee) ToObject() *Object {
+	return &t.Object
+}
+
+func newTee(source *teeSource, data *teeData, index int) *tee {
+	t := toTeeUnsafe(newObject(teeType))
+	t.source = source
+	t.data = data
+	t.index = index
+	return t
+}
+
+// teeFromIterable returns a tee reading from iterable. If iterable is
+// itself a tee then a copy of it is returned.
+func teeFromIterable(f *Frame, iterable *Object) (*Object, *BaseException) {
+	it, raised := Iter(f, iterable)
+	if raised != nil {
+		return nil, raised
+	}
+	if it.typ == teeType {
+		return teeCopy(toTeeUnsafe(it)).ToObject(), nil
+	}
+	return newTee(&teeSource{it: it}, &teeData{}, 0).ToObject(), nil
+}
+
+func teeCopy(t *tee) *tee {
+	t.source.mutex.Lock()
+	data, index := t.data, t.index
+	t.source.mutex.Unlock()
+	return newTee(t.source, data, index)
+}
+
+func teeNew(f *Frame, _ *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := itertoolsCheckArgs(f, "tee", args, kwargs, 1); raised != nil {
+		return nil, raised
+	}
+	return teeFromIterable(f, args[0])
+}
+
+func teeNext(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTeeUnsafe(o)
+	s := t.source
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if t.index == teeDataSize {
+		if t.data.next == nil {
+			t.data.next = &teeData{}
+		}
+		t.data = t.data.next
+		t.index = 0
+	}
+	d := t.data
+	if t.index < d.numRead {
+		item := d.values[t.index]
+		t.index++
+		return item, nil
+	}
+	item, raised := Next(f, s.it)
+	if raised != nil {
+		return nil, raised
+	}
+	d.values[d.numRead] = item
+	d.numRead++
+	t.index++
+	return item, nil
+}
+
+func teeCopyMethod(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__copy__", args, teeType); raised != nil {
+		return nil, raised
+	}
+	return teeCopy(toTeeUnsafe(args[0])).ToObject(), nil
+}
+
+func initTeeType(dict map[string]*Object) {
+	dict["__copy__"] = newBuiltinFunction("__copy__", teeCopyMethod).ToObject()
+	itertoolsInitType(dict, teeType, teeNew, teeNext)
+	teeType.flags &^= typeFlagBasetype
+}
+
+func itertoolsTee(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, IntType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkFunctionArgs(f, "tee", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	n := 2
+	if len(args) > 1 {
+		n = toIntUnsafe(args[1]).Value()
+	}
+	if n < 0 {
+		return nil, f.RaiseType(ValueErrorType, "n must be >= 0")
+	}
+	if n == 0 {
+		return NewTuple().ToObject(), nil
+	}
+	it, raised := Iter(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	copyFunc, raised := GetAttr(f, it, NewStr("__copy__"), None)
+	if raised != nil {
+		return nil, raised
+	}
+	if it.typ != teeType && copyFunc == None {
+		if it, raised = teeFromIterable(f, it); raised != nil {
+			return nil, raised
+		}
+	}
+	result := make([]*Object, n)
+	result[0] = it
+	for i := 1; i < n; i++ {
+		if it.typ == teeType {
+			result[i] = teeCopy(toTeeUnsafe(it)).ToObject()
+		} else if result[i], raised = copyFunc.Call(f, nil, nil); raised != nil {
+			return nil, raised
+		}
+	}
+	return NewTuple(result...).ToObject(), nil
+}
+
+func init() {
+	RegisterModule("itertools", NewCode("<module>", "itertools", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		attrs := map[string]*Object{
+			"chain":                         chainType.ToObject(),
+			"combinations":                  combinationsType.ToObject(),
+			"combinations_with_replacement": cwrType.ToObject(),
+			"compress":                      compressType.ToObject(),
+			"count":                         countType.ToObject(),
+			"cycle":                         cycleType.ToObject(),
+			"dropwhile":                     dropWhileType.ToObject(),
+			"groupby":                       groupByType.ToObject(),
+			"ifilter":                       iFilterType.ToObject(),
+			"ifilterfalse":                  iFilterFalseType.ToObject(),
+			"imap":                          iMapType.ToObject(),
+			"islice":                        iSliceType.ToObject(),
+			"izip":                          iZipType.ToObject(),
+			"izip_longest":                  iZipLongestType.ToObject(),
+			"permutations":                  permutationsType.ToObject(),
+			"product":                       productType.ToObject(),
+			"repeat":                        repeatType.ToObject(),
+			"starmap":                       starMapType.ToObject(),
+			"takewhile":                     takeWhileType.ToObject(),
+			"tee":                           newBuiltinFunction("tee", itertoolsTee).ToObject(),
+		}
+		for name, o := range attrs {
+			if raised := f.Globals().SetItemString(f, name, o); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
@@ -0,0 +1,205 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"math/big"
+	"testing"
+)
+
+// itertoolsListFunc returns a function that instantiates t with its
+// arguments and collects the resulting iterator into a list.
+func itertoolsListFunc(t *Type) *Object {
+	return wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		o, raised := t.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return ListType.Call(f, Args{o}, nil)
+	})
+}
+
+func TestItertoolsIterators(t *testing.T) {
+	isOdd := newBuiltinFunction("isOdd", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return GetBool(toIntUnsafe(args[0]).Value()%2 == 1).ToObject(), nil
+	}).ToObject()
+	lessThan3 := newBuiltinFunction("lessThan3", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return LT(f, args[0], NewInt(3).ToObject())
+	}).ToObject()
+	add := newBuiltinFunction("add", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		return Add(f, args[0], args[1])
+	}).ToObject()
+	cases := []struct {
+		t *Type
+		invokeTestCase
+	}{
+		{chainType, invokeTestCase{args: wrapArgs("ab", NewTuple(), newTestList(1)), want: newTestList("a", "b", 1).ToObject()}},
+		{chainType, invokeTestCase{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "'int' object is not iterable")}},
+		{combinationsType, invokeTestCase{args: wrapArgs("abcd", 3), want: newTestList(newTestTuple("a", "b", "c"), newTestTuple("a", "b", "d"), newTestTuple("a", "c", "d"), newTestTuple("b", "c", "d")).ToObject()}},
+		{combinationsType, invokeTestCase{args: wrapArgs("ab", 3), want: NewList().ToObject()}},
+		{combinationsType, invokeTestCase{args: wrapArgs("ab", -1), wantExc: mustCreateException(ValueErrorType, "r must be non-negative")}},
+		{compressType, invokeTestCase{args: wrapArgs("abcd", newTestList(1, 0, 1)), want: newTestList("a", "c").ToObject()}},
+		{cwrType, invokeTestCase{args: wrapArgs("ab", 2), want: newTestList(newTestTuple("a", "a"), newTestTuple("a", "b"), newTestTuple("b", "b")).ToObject()}},
+		{cwrType, invokeTestCase{args: wrapArgs("", 0), want: newTestList(NewTuple()).ToObject()}},
+		{cycleType, invokeTestCase{args: wrapArgs(NewTuple()), want: NewList().ToObject()}},
+		{cycleType, invokeTestCase{args: wrapArgs(), wantExc: mustCreateException(TypeErrorType, "cycle expected 1 arguments, got 0")}},
+		{dropWhileType, invokeTestCase{args: wrapArgs(lessThan3, newTestList(1, 2, 5, 1)), want: newTestList(5, 1).ToObject()}},
+		{dropWhileType, invokeTestCase{args: wrapArgs(lessThan3), wantExc: mustCreateException(TypeErrorType, "dropwhile expected 2 arguments, got 1")}},
+		{iFilterType, invokeTestCase{args: wrapArgs(isOdd, newTestList(1, 2, 3)), want: newTestList(1, 3).ToObject()}},
+		{iFilterType, invokeTestCase{args: wrapArgs(None, newTestList(0, 1, "", "a")), want: newTestList(1, "a").ToObject()}},
+		{iFilterFalseType, invokeTestCase{args: wrapArgs(isOdd, newTestList(1, 2, 3)), want: newTestList(2).ToObject()}},
+		{iMapType, invokeTestCase{args: wrapArgs(add, newTestList(1, 2), newTestList(10, 20, 30)), want: newTestList(11, 22).ToObject()}},
+		{iMapType, invokeTestCase{args: wrapArgs(None, "ab", "xy"), want: newTestList(newTestTuple("a", "x"), newTestTuple("b", "y")).ToObject()}},
+		{iMapType, invokeTestCase{args: wrapArgs(add), wantExc: mustCreateException(TypeErrorType, "imap() must have at least two arguments.")}},
+		{iSliceType, invokeTestCase{args: wrapArgs("abcdefgh", 1, None, 3), want: newTestList("b", "e", "h").ToObject()}},
+		{iSliceType, invokeTestCase{args: wrapArgs("abcdefgh", 2), want: newTestList("a", "b").ToObject()}},
+		{iSliceType, invokeTestCase{args: wrapArgs("abc", -1), wantExc: mustCreateException(ValueErrorType, "Stop argument for islice() must be None or an integer: 0 <= x <= maxint.")}},
+		{iSliceType, invokeTestCase{args: wrapArgs("abc", "a", None), wantExc: mustCreateException(ValueErrorType, "Indices for islice() must be None or an integer: 0 <= x <= maxint.")}},
+		{iSliceType, invokeTestCase{args: wrapArgs("abc", 0, None, 0), wantExc: mustCreateException(ValueErrorType, "Step for islice() must be a positive integer or None.")}},
+		{iZipType, invokeTestCase{args: wrapArgs("ab", "xyz"), want: newTestList(newTestTuple("a", "x"), newTestTuple("b", "y")).ToObject()}},
+		{iZipType, invokeTestCase{args: wrapArgs(), want: NewList().ToObject()}},
+		{iZipType, invokeTestCase{args: wrapArgs("ab", 1), wantExc: mustCreateException(TypeErrorType, "izip argument #2 must support iteration")}},
+		{iZipLongestType, invokeTestCase{args: wrapArgs("ab", newTestList(1)), want: newTestList(newTestTuple("a", 1), newTestTuple("b", None)).ToObject()}},
+		{permutationsType, invokeTestCase{args: wrapArgs("abc", 2), want: newTestList(newTestTuple("a", "b"), newTestTuple("a", "c"), newTestTuple("b", "a"), newTestTuple("b", "c"), newTestTuple("c", "a"), newTestTuple("c", "b")).ToObject()}},
+		{permutationsType, invokeTestCase{args: wrapArgs("ab"), want: newTestList(newTestTuple("a", "b"), newTestTuple("b", "a")).ToObject()}},
+		{permutationsType, invokeTestCase{args: wrapArgs("ab", 3), want: NewList().ToObject()}},
+		{productType, invokeTestCase{args: wrapArgs("ab", newTestList(1, 2)), want: newTestList(newTestTuple("a", 1), newTestTuple("a", 2), newTestTuple("b", 1), newTestTuple("b", 2)).ToObject()}},
+		{productType, invokeTestCase{args: wrapArgs(), want: newTestList(NewTuple()).ToObject()}},
+		{productType, invokeTestCase{args: wrapArgs("ab", NewList()), want: NewList().ToObject()}},
+		{repeatType, invokeTestCase{args: wrapArgs("a", 3), want: newTestList("a", "a", "a").ToObject()}},
+		{repeatType, invokeTestCase{args: wrapArgs("a", -1), want: NewList().ToObject()}},
+		{repeatType, invokeTestCase{args: wrapArgs("a", "b"), wantExc: mustCreateException(TypeErrorType, "an integer is required")}},
+		{starMapType, invokeTestCase{args: wrapArgs(add, newTestList(newTestTuple(1, 2), newTestList(3, 4))), want: newTestList(3, 7).ToObject()}},
+		{starMapType, invokeTestCase{args: wrapArgs(add, newTestList(1)), wantExc: mustCreateException(TypeErrorType, "'int' object is not iterable")}},
+		{takeWhileType, invokeTestCase{args: wrapArgs(lessThan3, newTestList(1, 2, 5, 1)), want: newTestList(1, 2).ToObject()}},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(itertoolsListFunc(cas.t), &cas.invokeTestCase); err != "" {
+			t.Errorf("%s: %s", cas.t.Name(), err)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		c, raised := countType.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		items := make([]*Object, 3)
+		for i := range items {
+			if items[i], raised = Next(f, c); raised != nil {
+				return nil, raised
+			}
+		}
+		s, raised := Repr(f, c)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(NewList(items...).ToObject(), s.ToObject()).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(), want: newTestTuple(newTestList(0, 1, 2), "count(3)").ToObject()},
+		{args: wrapArgs(10, -2), want: newTestTuple(newTestList(10, 8, 6), "count(4, -2)").ToObject()},
+		{args: wrapArgs(1, 0.5), want: newTestTuple(newTestList(1, 1.5, 2.0), "count(2.5, 0.5)").ToObject()},
+		{args: wrapArgs(MaxInt - 1), want: newTestTuple(newTestList(MaxInt-1, MaxInt, NewLong(new(big.Int).Add(maxIntBig, big.NewInt(1)))), "count(9223372036854775809L)").ToObject()},
+		{args: wrapArgs("a"), wantExc: mustCreateException(TypeErrorType, "a number is required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		g, raised := groupByType.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		var groups []*Object
+		for {
+			item, raised := Next(f, g)
+			if raised != nil {
+				if !raised.isInstance(StopIterationType) {
+					return nil, raised
+				}
+				f.RestoreExc(nil, nil)
+				return NewList(groups...).ToObject(), nil
+			}
+			elems := toTupleUnsafe(item).elems
+			values, raised := ListType.Call(f, Args{elems[1]}, nil)
+			if raised != nil {
+				return nil, raised
+			}
+			groups = append(groups, NewTuple2(elems[0], values).ToObject())
+		}
+	})
+	length := newBuiltinFunction("len", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		n, raised := Len(f, args[0])
+		if raised != nil {
+			return nil, raised
+		}
+		return n.ToObject(), nil
+	}).ToObject()
+	cases := []invokeTestCase{
+		{args: wrapArgs("aabccca"), want: newTestList(newTestTuple("a", newTestList("a", "a")), newTestTuple("b", newTestList("b")), newTestTuple("c", newTestList("c", "c", "c")), newTestTuple("a", newTestList("a"))).ToObject()},
+		{args: wrapArgs(newTestList("ab", "cd", "e"), length), want: newTestList(newTestTuple(2, newTestList("ab", "cd")), newTestTuple(1, newTestList("e"))).ToObject()},
+		{args: wrapArgs(NewTuple()), want: NewList().ToObject()},
+		{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "'int' object is not iterable")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTee(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		result, raised := itertoolsTee(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		elems := toTupleUnsafe(result).elems
+		lists := make([]*Object, len(elems))
+		// Drain the iterators in reverse so that later tees read ahead
+		// of earlier ones.
+		for i := len(elems) - 1; i >= 0; i-- {
+			if lists[i], raised = ListType.Call(f, Args{elems[i]}, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		return NewTuple(lists...).ToObject(), nil
+	})
+	elems := make([]*Object, 200)
+	for i := range elems {
+		elems[i] = NewInt(i).ToObject()
+	}
+	r := NewList(elems...)
+	cases := []invokeTestCase{
+		{args: wrapArgs("abc"), want: newTestTuple(newTestList("a", "b", "c"), newTestList("a", "b", "c")).ToObject()},
+		{args: wrapArgs(r, 3), want: newTestTuple(r, r, r).ToObject()},
+		{args: wrapArgs("abc", 0), want: NewTuple().ToObject()},
+		{args: wrapArgs("abc", -1), wantExc: mustCreateException(ValueErrorType, "n must be >= 0")},
+		{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "'int' object is not iterable")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
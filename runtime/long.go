@@ -128,7 +128,11 @@ func longGetNewArgs(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "__getnewargs__", args, LongType); raised != nil {
 		return nil, raised
 	}
-	return NewTuple1(args[0]).ToObject(), nil
+	o := args[0]
+	if o.typ != LongType {
+		o = NewLong(toLongUnsafe(args[0]).Value()).ToObject()
+	}
+	return NewTuple1(o).ToObject(), nil
 }
 
 func longGT(x, y *big.Int) bool {
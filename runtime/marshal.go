@@ -0,0 +1,593 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+)
+
+const (
+	// marshalVersion is the highest marshal format version supported. It
+	// is exposed as marshal.version.
+	marshalVersion = 2
+	// marshalMaxDepth bounds the nesting of containers like CPython's
+	// MAX_MARSHAL_STACK_DEPTH.
+	marshalMaxDepth = 2000
+	// Longs are marshaled as base 2**15 digits.
+	marshalLongShift = 15
+
+	marshalTypeNull          = '0'
+	marshalTypeNone          = 'N'
+	marshalTypeFalse         = 'F'
+	marshalTypeTrue          = 'T'
+	marshalTypeStopIter      = 'S'
+	marshalTypeEllipsis      = '.'
+	marshalTypeInt           = 'i'
+	marshalTypeInt64         = 'I'
+	marshalTypeFloat         = 'f'
+	marshalTypeBinaryFloat   = 'g'
+	marshalTypeComplex       = 'x'
+	marshalTypeBinaryComplex = 'y'
+	marshalTypeLong          = 'l'
+	marshalTypeString        = 's'
+	marshalTypeInterned      = 't'
+	marshalTypeStringRef     = 'R'
+	marshalTypeTuple         = '('
+	marshalTypeList          = '['
+	marshalTypeDict          = '{'
+	marshalTypeUnicode       = 'u'
+	marshalTypeSet           = '<'
+	marshalTypeFrozenSet     = '>'
+)
+
+// marshalWriter accumulates the marshal serialization of objects.
+type marshalWriter struct {
+	buf     bytes.Buffer
+	version int
+	depth   int
+	// strings maps the interned strings written so far to their index for
+	// use in string references. It is nil for version 0.
+	strings map[string]int
+}
+
+func newMarshalWriter(version int) *marshalWriter {
+	w := &marshalWriter{version: version}
+	if version > 0 {
+		w.strings = map[string]int{}
+	}
+	return w
+}
+
+func (w *marshalWriter) writeLong(x int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(x))
+	w.buf.Write(b[:])
+}
+
+func (w *marshalWriter) writeString(s string) {
+	w.writeLong(int32(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *marshalWriter) writeFloat(x float64) {
+	if w.version > 1 {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(x))
+		w.buf.Write(b[:])
+	} else {
+		s := pickleFloatString(x)
+		w.buf.WriteByte(byte(len(s)))
+		w.buf.WriteString(s)
+	}
+}
+
+func (w *marshalWriter) writeBigInt(x *big.Int) {
+	var digits []uint16
+	abs := new(big.Int).Abs(x)
+	mask := big.NewInt(1<<marshalLongShift - 1)
+	for abs.Sign() != 0 {
+		digits = append(digits, uint16(new(big.Int).And(abs, mask).Int64()))
+		abs.Rsh(abs, marshalLongShift)
+	}
+	n := int32(len(digits))
+	if x.Sign() < 0 {
+		n = -n
+	}
+	w.buf.WriteByte(marshalTypeLong)
+	w.writeLong(n)
+	for _, d := range digits {
+		w.buf.WriteByte(byte(d))
+		w.buf.WriteByte(byte(d >> 8))
+	}
+}
+
+func (w *marshalWriter) writeSeq(f *Frame, code byte, elems []*Object) *BaseException {
+	w.buf.WriteByte(code)
+	w.writeLong(int32(len(elems)))
+	for _, elem := range elems {
+		if raised := w.writeObject(f, elem); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+// writeObject appends the serialization of o. Like CPython, only exact
+// instances of the core types are supported, with the exception of buffer
+// objects which are written as strings.
+func (w *marshalWriter) writeObject(f *Frame, o *Object) *BaseException {
+	if w.depth >= marshalMaxDepth {
+		return f.RaiseType(ValueErrorType, "object too deeply nested to marshal")
+	}
+	w.depth++
+	defer func() { w.depth-- }()
+	switch {
+	case o == None:
+		w.buf.WriteByte(marshalTypeNone)
+	case o == StopIterationType.ToObject():
+		w.buf.WriteByte(marshalTypeStopIter)
+	case o == Ellipsis:
+		w.buf.WriteByte(marshalTypeEllipsis)
+	case o == False.ToObject():
+		w.buf.WriteByte(marshalTypeFalse)
+	case o == True.ToObject():
+		w.buf.WriteByte(marshalTypeTrue)
+	case o.typ == IntType:
+		x := toIntUnsafe(o).Value()
+		if y := int64(x) >> 31; y != 0 && y != -1 {
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], uint64(x))
+			w.buf.WriteByte(marshalTypeInt64)
+			w.buf.Write(b[:])
+		} else {
+			w.buf.WriteByte(marshalTypeInt)
+			w.writeLong(int32(x))
+		}
+	case o.typ == LongType:
+		w.writeBigInt(toLongUnsafe(o).Value())
+	case o.typ == FloatType:
+		if w.version > 1 {
+			w.buf.WriteByte(marshalTypeBinaryFloat)
+		} else {
+			w.buf.WriteByte(marshalTypeFloat)
+		}
+		w.writeFloat(toFloatUnsafe(o).Value())
+	case o.typ == ComplexType:
+		if w.version > 1 {
+			w.buf.WriteByte(marshalTypeBinaryComplex)
+		} else {
+			w.buf.WriteByte(marshalTypeComplex)
+		}
+		c := toComplexUnsafe(o).Value()
+		w.writeFloat(real(c))
+		w.writeFloat(imag(c))
+	case o.typ == StrType:
+		str := toStrUnsafe(o)
+		s := str.Value()
+		if w.strings != nil && str.interned {
+			if i, ok := w.strings[s]; ok {
+				w.buf.WriteByte(marshalTypeStringRef)
+				w.writeLong(int32(i))
+				break
+			}
+			w.strings[s] = len(w.strings)
+			w.buf.WriteByte(marshalTypeInterned)
+		} else {
+			w.buf.WriteByte(marshalTypeString)
+		}
+		w.writeString(s)
+	case o.typ == UnicodeType:
+		s, raised := toUnicodeUnsafe(o).Encode(f, "utf8", EncodeStrict)
+		if raised != nil {
+			return raised
+		}
+		w.buf.WriteByte(marshalTypeUnicode)
+		w.writeString(s.Value())
+	case o.typ == TupleType:
+		return w.writeSeq(f, marshalTypeTuple, toTupleUnsafe(o).elems)
+	case o.typ == ListType:
+		l := toListUnsafe(o)
+		l.mutex.RLock()
+		elems := make([]*Object, len(l.elems))
+		copy(elems, l.elems)
+		l.mutex.RUnlock()
+		return w.writeSeq(f, marshalTypeList, elems)
+	case o.typ == DictType:
+		w.buf.WriteByte(marshalTypeDict)
+		d := toDictUnsafe(o)
+		d.mutex.Lock(f)
+		iter := newDictEntryIterator(d)
+		d.mutex.Unlock(f)
+		for entry := iter.next(); entry != nil; entry = iter.next() {
+			if raised := w.writeObject(f, entry.key); raised != nil {
+				return raised
+			}
+			if raised := w.writeObject(f, entry.value); raised != nil {
+				return raised
+			}
+		}
+		w.buf.WriteByte(marshalTypeNull)
+	case o.typ == SetType:
+		return w.writeSeq(f, marshalTypeSet, toSetUnsafe(o).dict.Keys(f).elems)
+	case o.typ == FrozenSetType:
+		return w.writeSeq(f, marshalTypeFrozenSet, toFrozenSetUnsafe(o).dict.Keys(f).elems)
+	case o.typ.slots.Buffer != nil:
+		w.buf.WriteByte(marshalTypeString)
+		return bufferApply(f, o, func(b []byte) *BaseException {
+			w.writeString(string(b))
+			return nil
+		})
+	default:
+		return f.RaiseType(ValueErrorType, "unmarshallable object")
+	}
+	return nil
+}
+
+// marshalSource is the stream that marshal data is read from.
+type marshalSource interface {
+	io.Reader
+	io.ByteReader
+}
+
+// marshalReader decodes objects from a marshalSource.
+type marshalReader struct {
+	src     marshalSource
+	depth   int
+	strings []*Object
+}
+
+// readByte returns the next byte or -1 at EOF. Like CPython, multi-byte
+// integers are assembled from readByte results so truncated data yields
+// garbage rather than an error.
+func (r *marshalReader) readByte() int {
+	b, err := r.src.ReadByte()
+	if err != nil {
+		return -1
+	}
+	return int(b)
+}
+
+func (r *marshalReader) readShort() int {
+	x := r.readByte()
+	x |= r.readByte() << 8
+	return int(int16(x))
+}
+
+func (r *marshalReader) readLong() int {
+	x := r.readByte()
+	x |= r.readByte() << 8
+	x |= r.readByte() << 16
+	x |= r.readByte() << 24
+	return int(int32(x))
+}
+
+func (r *marshalReader) readBytes(f *Frame, n int) ([]byte, *BaseException) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.src, b); err != nil {
+		return nil, f.RaiseType(EOFErrorType, "EOF read where object expected")
+	}
+	return b, nil
+}
+
+func (r *marshalReader) readSize(f *Frame, what string) (int, *BaseException) {
+	n := r.readLong()
+	if n < 0 {
+		return 0, f.RaiseType(ValueErrorType, fmt.Sprintf("bad marshal data (%s size out of range)", what))
+	}
+	return n, nil
+}
+
+func (r *marshalReader) readFloat(f *Frame, binaryFloat bool) (float64, *BaseException) {
+	if binaryFloat {
+		b, raised := r.readBytes(f, 8)
+		if raised != nil {
+			return 0, raised
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	}
+	n := r.readByte()
+	if n < 0 {
+		return 0, f.RaiseType(EOFErrorType, "EOF read where object expected")
+	}
+	b, raised := r.readBytes(f, n)
+	if raised != nil {
+		return 0, raised
+	}
+	o, raised := FloatType.Call(f, Args{NewStr(string(b)).ToObject()}, nil)
+	if raised != nil {
+		return 0, raised
+	}
+	return toFloatUnsafe(o).Value(), nil
+}
+
+func (r *marshalReader) readBigInt(f *Frame) (*Object, *BaseException) {
+	n := r.readLong()
+	numDigits := n
+	if n < 0 {
+		numDigits = -n
+	}
+	x := new(big.Int)
+	digit := new(big.Int)
+	for i := 0; i < numDigits; i++ {
+		d := r.readShort()
+		if d < 0 {
+			return nil, f.RaiseType(ValueErrorType, "bad marshal data (digit out of range in long)")
+		}
+		if d == 0 && i == numDigits-1 {
+			return nil, f.RaiseType(ValueErrorType, "bad marshal data (unnormalized long data)")
+		}
+		x.Or(x, digit.Lsh(big.NewInt(int64(d)), uint(i*marshalLongShift)))
+	}
+	if n < 0 {
+		x.Neg(x)
+	}
+	return NewLong(x).ToObject(), nil
+}
+
+func (r *marshalReader) readElems(f *Frame, what string) ([]*Object, *BaseException) {
+	n, raised := r.readSize(f, what)
+	if raised != nil {
+		return nil, raised
+	}
+	var elems []*Object
+	for i := 0; i < n; i++ {
+		elem, raised := r.readObject(f)
+		if raised != nil {
+			return nil, raised
+		}
+		if elem == nil {
+			return nil, f.RaiseType(TypeErrorType, "NULL object in marshal data for "+what)
+		}
+		elems = append(elems, elem)
+	}
+	return elems, nil
+}
+
+// readObject decodes the next object. It returns nil without raising when the
+// null marker terminating a dict is read.
+func (r *marshalReader) readObject(f *Frame) (*Object, *BaseException) {
+	if r.depth >= marshalMaxDepth {
+		return nil, f.RaiseType(ValueErrorType, "recursion limit exceeded")
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+	code := r.readByte()
+	switch code {
+	case -1:
+		return nil, f.RaiseType(EOFErrorType, "EOF read where object expected")
+	case marshalTypeNull:
+		return nil, nil
+	case marshalTypeNone:
+		return None, nil
+	case marshalTypeStopIter:
+		return StopIterationType.ToObject(), nil
+	case marshalTypeEllipsis:
+		return Ellipsis, nil
+	case marshalTypeFalse:
+		return False.ToObject(), nil
+	case marshalTypeTrue:
+		return True.ToObject(), nil
+	case marshalTypeInt:
+		return NewInt(r.readLong()).ToObject(), nil
+	case marshalTypeInt64:
+		lo := r.readLong()
+		hi := r.readLong()
+		return NewInt(int(int64(hi)<<32 | int64(lo)&0xffffffff)).ToObject(), nil
+	case marshalTypeLong:
+		return r.readBigInt(f)
+	case marshalTypeFloat, marshalTypeBinaryFloat:
+		x, raised := r.readFloat(f, code == marshalTypeBinaryFloat)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewFloat(x).ToObject(), nil
+	case marshalTypeComplex, marshalTypeBinaryComplex:
+		re, raised := r.readFloat(f, code == marshalTypeBinaryComplex)
+		if raised != nil {
+			return nil, raised
+		}
+		im, raised := r.readFloat(f, code == marshalTypeBinaryComplex)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewComplex(complex(re, im)).ToObject(), nil
+	case marshalTypeString, marshalTypeInterned:
+		n, raised := r.readSize(f, "string")
+		if raised != nil {
+			return nil, raised
+		}
+		b, raised := r.readBytes(f, n)
+		if raised != nil {
+			return nil, raised
+		}
+		s := NewStr(string(b)).ToObject()
+		if code == marshalTypeInterned {
+			r.strings = append(r.strings, s)
+		}
+		return s, nil
+	case marshalTypeStringRef:
+		i := r.readLong()
+		if i < 0 || i >= len(r.strings) {
+			return nil, f.RaiseType(ValueErrorType, "bad marshal data (string ref out of range)")
+		}
+		return r.strings[i], nil
+	case marshalTypeUnicode:
+		n, raised := r.readSize(f, "unicode")
+		if raised != nil {
+			return nil, raised
+		}
+		b, raised := r.readBytes(f, n)
+		if raised != nil {
+			return nil, raised
+		}
+		s, raised := NewStr(string(b)).Decode(f, "utf8", EncodeStrict)
+		if raised != nil {
+			return nil, raised
+		}
+		return s.ToObject(), nil
+	case marshalTypeTuple:
+		elems, raised := r.readElems(f, "tuple")
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple(elems...).ToObject(), nil
+	case marshalTypeList:
+		elems, raised := r.readElems(f, "list")
+		if raised != nil {
+			return nil, raised
+		}
+		return NewList(elems...).ToObject(), nil
+	case marshalTypeDict:
+		d := NewDict()
+		for {
+			key, raised := r.readObject(f)
+			if raised != nil {
+				return nil, raised
+			}
+			if key == nil {
+				break
+			}
+			value, raised := r.readObject(f)
+			if raised != nil {
+				return nil, raised
+			}
+			if value != nil {
+				if raised := d.SetItem(f, key, value); raised != nil {
+					return nil, raised
+				}
+			}
+		}
+		return d.ToObject(), nil
+	case marshalTypeSet, marshalTypeFrozenSet:
+		elems, raised := r.readElems(f, "set")
+		if raised != nil {
+			return nil, raised
+		}
+		t := SetType
+		if code == marshalTypeFrozenSet {
+			t = FrozenSetType
+		}
+		return t.Call(f, Args{NewTuple(elems...).ToObject()}, nil)
+	}
+	return nil, f.RaiseType(ValueErrorType, "bad marshal data (unknown type code)")
+}
+
+// marshalLoad decodes the first object in src.
+func marshalLoad(f *Frame, src marshalSource) (*Object, *BaseException) {
+	r := &marshalReader{src: src}
+	o, raised := r.readObject(f)
+	if raised == nil && o == nil {
+		raised = f.RaiseType(TypeErrorType, "NULL object in marshal data for object")
+	}
+	return o, raised
+}
+
+// marshalDumpArgs validates the arguments of dump() and dumps(), which take
+// numArgs leading objects followed by an optional version, and returns the
+// serialization of args[0].
+func marshalDumpArgs(f *Frame, name string, args Args, numArgs int) (string, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}[:numArgs]
+	if len(args) > numArgs {
+		expectedTypes = append(expectedTypes, IntType)
+	}
+	if raised := checkFunctionArgs(f, name, args, expectedTypes...); raised != nil {
+		return "", raised
+	}
+	version := marshalVersion
+	if len(args) > numArgs {
+		version = toIntUnsafe(args[numArgs]).Value()
+	}
+	w := newMarshalWriter(version)
+	if raised := w.writeObject(f, args[0]); raised != nil {
+		return "", raised
+	}
+	return w.buf.String(), nil
+}
+
+func marshalDump(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if len(args) >= 2 && !args[1].isInstance(FileType) {
+		return nil, f.RaiseType(TypeErrorType, "marshal.dump() 2nd arg must be file")
+	}
+	s, raised := marshalDumpArgs(f, "dump", args, 2)
+	if raised != nil {
+		return nil, raised
+	}
+	return fileWrite(f, Args{args[1], NewStr(s).ToObject()}, nil)
+}
+
+func marshalDumps(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, raised := marshalDumpArgs(f, "dumps", args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(s).ToObject(), nil
+}
+
+func marshalLoadFile(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "load", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[0].isInstance(FileType) {
+		return nil, f.RaiseType(TypeErrorType, "marshal.load() arg must be file")
+	}
+	file := toFileUnsafe(args[0])
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if !file.open {
+		return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+	}
+	return marshalLoad(f, file.reader)
+}
+
+func marshalLoads(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "loads", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if args[0].typ.slots.Buffer == nil {
+		format := "loads() argument 1 must be string or read-only buffer, not %s"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, args[0].typ.Name()))
+	}
+	var data []byte
+	if raised := bufferApply(f, args[0], func(b []byte) *BaseException {
+		data = make([]byte, len(b))
+		copy(data, b)
+		return nil
+	}); raised != nil {
+		return nil, raised
+	}
+	return marshalLoad(f, bytes.NewReader(data))
+}
+
+func init() {
+	RegisterModule("marshal", NewCode("<module>", "marshal", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		for name, value := range map[string]*Object{
+			"dump":    newBuiltinFunction("dump", marshalDump).ToObject(),
+			"dumps":   newBuiltinFunction("dumps", marshalDumps).ToObject(),
+			"load":    newBuiltinFunction("load", marshalLoadFile).ToObject(),
+			"loads":   newBuiltinFunction("loads", marshalLoads).ToObject(),
+			"version": NewInt(marshalVersion).ToObject(),
+		} {
+			if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
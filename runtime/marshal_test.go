@@ -0,0 +1,102 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestMarshalDumps(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(None), want: NewStr("N").ToObject()},
+		{args: wrapArgs(True), want: NewStr("T").ToObject()},
+		{args: wrapArgs(1), want: NewStr("i\x01\x00\x00\x00").ToObject()},
+		{args: wrapArgs(1 << 40), want: NewStr("I\x00\x00\x00\x00\x00\x01\x00\x00").ToObject()},
+		{args: wrapArgs(big.NewInt(-65535)), want: NewStr("l\xfe\xff\xff\xff\xff\x7f\x01\x00").ToObject()},
+		{args: wrapArgs(1.5), want: NewStr("g\x00\x00\x00\x00\x00\x00\xf8?").ToObject()},
+		{args: wrapArgs(1.5, 1), want: NewStr("f\x031.5").ToObject()},
+		{args: wrapArgs(complex(0, 1), 1), want: NewStr("x\x010\x011").ToObject()},
+		{args: wrapArgs(InternStr("abc")), want: NewStr("t\x03\x00\x00\x00abc").ToObject()},
+		{args: wrapArgs(NewStr("abcdef")), want: NewStr("s\x06\x00\x00\x00abcdef").ToObject()},
+		{args: wrapArgs("a b"), want: NewStr("s\x03\x00\x00\x00a b").ToObject()},
+		{args: wrapArgs(NewUnicode("é")), want: NewStr("u\x02\x00\x00\x00\xc3\xa9").ToObject()},
+		{args: wrapArgs(newTestTuple(1)), want: NewStr("(\x01\x00\x00\x00i\x01\x00\x00\x00").ToObject()},
+		{args: wrapArgs(newTestList(1, "qx")), want: NewStr("[\x02\x00\x00\x00i\x01\x00\x00\x00s\x02\x00\x00\x00qx").ToObject()},
+		{args: wrapArgs(newTestDict("qa", None)), want: NewStr("{s\x02\x00\x00\x00qaN0").ToObject()},
+		{args: wrapArgs(newTestFrozenSet(1)), want: NewStr(">\x01\x00\x00\x00i\x01\x00\x00\x00").ToObject()},
+		{args: wrapArgs(newTestList(InternStr("ab"), InternStr("ab"))), want: NewStr("[\x02\x00\x00\x00t\x02\x00\x00\x00abR\x00\x00\x00\x00").ToObject()},
+		{args: wrapArgs(newTestList("qab", "qab")), want: NewStr("[\x02\x00\x00\x00s\x03\x00\x00\x00qabs\x03\x00\x00\x00qab").ToObject()},
+		{args: wrapArgs(newTestList(InternStr("ab"), InternStr("ab")), 0), want: NewStr("[\x02\x00\x00\x00s\x02\x00\x00\x00abs\x02\x00\x00\x00ab").ToObject()},
+		{args: wrapArgs(newObject(ObjectType)), wantExc: mustCreateException(ValueErrorType, "unmarshallable object")},
+		{args: wrapArgs(newTestList(newObject(ObjectType))), wantExc: mustCreateException(ValueErrorType, "unmarshallable object")},
+		{args: wrapArgs(1, "foo"), wantExc: mustCreateException(TypeErrorType, "'dumps' requires a 'int' object but received a \"str\"")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("dumps", marshalDumps).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMarshalLoads(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs("N"), want: None},
+		{args: wrapArgs("Nextra"), want: None},
+		{args: wrapArgs("i\x01\x00\x00\x00"), want: NewInt(1).ToObject()},
+		{args: wrapArgs("I\x01\x00\x00\x00\x00\x00\x00\x80"), want: NewInt(-9223372036854775807).ToObject()},
+		{args: wrapArgs("l\xff\xff\xff\xff\x05\x00"), want: NewLong(big.NewInt(-5)).ToObject()},
+		{args: wrapArgs("f\x031.5"), want: NewFloat(1.5).ToObject()},
+		{args: wrapArgs("u\x02\x00\x00\x00\xc3\xa9"), want: NewUnicode("é").ToObject()},
+		{args: wrapArgs("[\x02\x00\x00\x00t\x02\x00\x00\x00abR\x00\x00\x00\x00"), want: newTestList("ab", "ab").ToObject()},
+		{args: wrapArgs("{t\x01\x00\x00\x00aN0"), want: newTestDict("a", None).ToObject()},
+		{args: wrapArgs(newTestByteArray("T")), want: True.ToObject()},
+		{args: wrapArgs(""), wantExc: mustCreateException(EOFErrorType, "EOF read where object expected")},
+		{args: wrapArgs("z"), wantExc: mustCreateException(ValueErrorType, "bad marshal data (unknown type code)")},
+		{args: wrapArgs("s\x05\x00\x00\x00ab"), wantExc: mustCreateException(EOFErrorType, "EOF read where object expected")},
+		{args: wrapArgs("R\x00\x00\x00\x00"), wantExc: mustCreateException(ValueErrorType, "bad marshal data (string ref out of range)")},
+		{args: wrapArgs("l\x01\x00\x00\x00\x00\x80"), wantExc: mustCreateException(ValueErrorType, "bad marshal data (digit out of range in long)")},
+		{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "loads() argument 1 must be string or read-only buffer, not int")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("loads", marshalLoads).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, o *Object, version int) (*Object, *BaseException) {
+		s, raised := marshalDumps(f, Args{o, NewInt(version).ToObject()}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return marshalLoads(f, Args{s}, nil)
+	})
+	values := []*Object{
+		None, NewInt(-1 << 31).ToObject(), NewLong(new(big.Int).Lsh(big.NewInt(1), 100)).ToObject(),
+		NewFloat(-2.5e-300).ToObject(), NewComplex(complex(1.5, 2.5)).ToObject(), NewStr("a b").ToObject(),
+		NewUnicode("€\U0001f600").ToObject(), newTestTuple(1, newTestList(2, "x")).ToObject(),
+		newTestDict("a", 1, "b", newTestList(2)).ToObject(), newTestSet(1, 2).ToObject(), newTestFrozenSet().ToObject(),
+	}
+	for _, v := range values {
+		for version := 0; version <= marshalVersion; version++ {
+			cas := invokeTestCase{args: wrapArgs(v, version), want: v}
+			if err := runInvokeTestCase(fun, &cas); err != "" {
+				t.Error(err)
+			}
+		}
+	}
+}
@@ -121,7 +121,11 @@ func importOne(f *Frame, name string) (*Object, *BaseException) {
 	// sys.modules consistency gotchas.
 	importMutex.Lock()
 	o, raised := SysModules.GetItemString(f, name)
-	if raised == nil && o == nil {
+	if raised == nil && o == None {
+		// A None entry blocks the import, which lets callers fall
+		// back to a pure Python implementation of an accelerator.
+		raised = f.RaiseType(ImportErrorType, fmt.Sprintf("import of %s halted; None in sys.modules", name))
+	} else if raised == nil && o == nil {
 		if c = moduleRegistry[name]; c == nil {
 			raised = f.RaiseType(ImportErrorType, name)
 		} else {
@@ -185,6 +185,25 @@ func TestImportModule(t *testing.T) {
 	}
 }
 
+func TestImportModuleBlocked(t *testing.T) {
+	f := NewRootFrame()
+	oldSysModules := SysModules
+	oldModuleRegistry := moduleRegistry
+	defer func() {
+		SysModules = oldSysModules
+		moduleRegistry = oldModuleRegistry
+	}()
+	SysModules = newStringDict(map[string]*Object{"blocked": None})
+	moduleRegistry = map[string]*Code{
+		"blocked": NewCode("<module>", "blocked.py", nil, 0, func(*Frame, []*Object) (*Object, *BaseException) { return None, nil }),
+	}
+	_, raised := ImportModule(f, "blocked")
+	wantExc := mustCreateException(ImportErrorType, "import of blocked halted; None in sys.modules")
+	if !exceptionsAreEquivalent(raised, wantExc) {
+		t.Errorf(`ImportModule("blocked") raised %v, want %v`, raised, wantExc)
+	}
+}
+
 func TestModuleGetNameAndFilename(t *testing.T) {
 	fun := wrapFuncForTest(func(f *Frame, m *Module) (*Tuple, *BaseException) {
 		name, raised := m.GetName(f)
@@ -23,6 +23,7 @@ import (
 
 var (
 	objectBasis             = reflect.TypeOf(Object{})
+	objectNewObjFunc        = newBuiltinFunction("__newobj__", objectNewObj).ToObject()
 	objectReconstructorFunc = newBuiltinFunction("_reconstructor", objectReconstructor).ToObject()
 	objectReduceFunc        = newBuiltinFunction("__reduce__", objectReduce).ToObject()
 	// ObjectType is the object representing the Python 'object' type.
@@ -46,7 +47,7 @@ type Object struct {
 
 func newObject(t *Type) *Object {
 	var dict *Dict
-	if t != ObjectType {
+	if t != ObjectType && t.flags&typeFlagNoDict == 0 {
 		dict = NewDict()
 	}
 	o := (*Object)(unsafe.Pointer(reflect.New(t.basis).Pointer()))
@@ -105,7 +106,19 @@ func objectBasisFunc(o *Object) reflect.Value {
 	return reflect.ValueOf(o).Elem()
 }
 
+// objectDelAttr implements object.__delattr__. Like CPython, it can't be
+// applied to types since that would bypass typeDelAttr, leaving the type's
+// cached attribute lookups and slots stale.
 func objectDelAttr(f *Frame, o *Object, name *Str) *BaseException {
+	if o.isInstance(TypeType) {
+		return f.RaiseType(TypeErrorType, "can't apply this __delattr__ to type object")
+	}
+	return delAttrCommon(f, o, name)
+}
+
+// delAttrCommon deletes name from o's dict or via a data descriptor on its
+// type.
+func delAttrCommon(f *Frame, o *Object, name *Str) *BaseException {
 	desc, raised := o.typ.mroLookup(f, name)
 	if raised != nil {
 		return raised
@@ -202,13 +215,24 @@ func objectReduceEx(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 		return nil, raised
 	}
 	if reduce != nil && reduce != objectReduceFunc {
-		// __reduce__ is overridden so prefer using it.
-		return reduce.Call(f, args, nil)
+		// __reduce__ is overridden so prefer using it. Like CPython,
+		// the protocol is not passed along.
+		return reduce.Call(f, args[:1], nil)
 	}
 	return objectReduceCommon(f, args)
 }
 
+// objectSetAttr implements object.__setattr__. As with objectDelAttr, types
+// are rejected so that typeSetAttr is the only way to modify them.
 func objectSetAttr(f *Frame, o *Object, name *Str, value *Object) *BaseException {
+	if o.isInstance(TypeType) {
+		return f.RaiseType(TypeErrorType, "can't apply this __setattr__ to type object")
+	}
+	return setAttrCommon(f, o, name, value)
+}
+
+// setAttrCommon sets name in o's dict or via a data descriptor on its type.
+func setAttrCommon(f *Frame, o *Object, name *Str, value *Object) *BaseException {
 	if typeAttr, raised := o.typ.mroLookup(f, name); raised != nil {
 		return raised
 	} else if typeAttr != nil {
@@ -224,11 +248,29 @@ func objectSetAttr(f *Frame, o *Object, name *Str, value *Object) *BaseException
 	return f.RaiseType(AttributeErrorType, fmt.Sprintf("'%s' has no attribute '%s'", o.typ.Name(), name.Value()))
 }
 
+// objectInitSubclass is the default __init_subclass__ hook, which is called
+// with each newly created class. It does nothing.
+func objectInitSubclass(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "__init_subclass__", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	if len(kwargs) > 0 {
+		return nil, f.RaiseType(TypeErrorType, "__init_subclass__() takes no keyword arguments")
+	}
+	return None, nil
+}
+
+func objectSubclassHook(f *Frame, _ Args, _ KWArgs) (*Object, *BaseException) {
+	return NotImplemented, nil
+}
+
 func initObjectType(dict map[string]*Object) {
 	ObjectType.typ = TypeType
 	dict["__reduce__"] = objectReduceFunc
 	dict["__reduce_ex__"] = newBuiltinFunction("__reduce_ex__", objectReduceEx).ToObject()
 	dict["__dict__"] = newProperty(newBuiltinFunction("_get_dict", objectGetDict).ToObject(), newBuiltinFunction("_set_dict", objectSetDict).ToObject(), nil).ToObject()
+	dict["__init_subclass__"] = newClassMethod(newBuiltinFunction("__init_subclass__", objectInitSubclass).ToObject()).ToObject()
+	dict["__subclasshook__"] = newClassMethod(newBuiltinFunction("__subclasshook__", objectSubclassHook).ToObject()).ToObject()
 	ObjectType.slots.DelAttr = &delAttrSlot{objectDelAttr}
 	ObjectType.slots.GetAttribute = &getAttributeSlot{objectGetAttribute}
 	ObjectType.slots.Hash = &unaryOpSlot{objectHash}
@@ -266,17 +308,42 @@ func objectReconstructor(f *Frame, args Args, _ KWArgs) (*Object, *BaseException
 	return o, nil
 }
 
+func objectNewObj(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if len(args) == 0 {
+		return nil, f.RaiseType(TypeErrorType, "__newobj__() takes at least 1 argument (0 given)")
+	}
+	if args[0].isInstance(TypeType) {
+		// Use the new slot directly since __new__ methods defined in
+		// Python classes are not static methods in Grumpy.
+		if t := toTypeUnsafe(args[0]); t.slots.New != nil {
+			return t.slots.New.Fn(f, t, args[1:], nil)
+		}
+	}
+	newMethod, raised := GetAttr(f, args[0], NewStr("__new__"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return newMethod.Call(f, args, nil)
+}
+
 func objectReduceCommon(f *Frame, args Args) (*Object, *BaseException) {
-	// TODO: Support __getstate__ and __getnewargs__.
 	o := args[0]
 	t := o.Type()
 	proto := 0
 	if len(args) > 1 {
 		proto = toIntUnsafe(args[1]).Value()
 	}
-	var raised *BaseException
+	getState, raised := GetAttr(f, o, NewStr("__getstate__"), None)
+	if raised != nil {
+		return nil, raised
+	}
 	if proto < 2 {
-		basisType := basisTypes[t.basis]
+		basis := t.basis
+		for basisTypes[basis] == nil {
+			// Skip over the storage added for __slots__.
+			basis = basisParent(basis)
+		}
+		basisType := basisTypes[basis]
 		if basisType == t {
 			// Basis types are handled elsewhere by the pickle and
 			// copy frameworks. This matches behavior in
@@ -292,9 +359,24 @@ func objectReduceCommon(f *Frame, args Args) (*Object, *BaseException) {
 				return nil, raised
 			}
 		}
+		dict := None
+		if getState != None {
+			if dict, raised = getState.Call(f, nil, nil); raised != nil {
+				return nil, raised
+			}
+		} else if t.hasSlotMembers() {
+			return nil, f.RaiseType(TypeErrorType, "a class that defines __slots__ without defining __getstate__ cannot be pickled")
+		} else if d := o.Dict(); d != nil {
+			dict = d.ToObject()
+		}
 		newArgs := NewTuple3(t.ToObject(), basisType.ToObject(), state).ToObject()
-		if d := o.Dict(); d != nil {
-			return NewTuple3(objectReconstructorFunc, newArgs, d.ToObject()).ToObject(), nil
+		// Like copy_reg._reduce_ex, empty state is omitted.
+		isTrue, raised := IsTrue(f, dict)
+		if raised != nil {
+			return nil, raised
+		}
+		if isTrue {
+			return NewTuple3(objectReconstructorFunc, newArgs, dict).ToObject(), nil
 		}
 		return NewTuple2(objectReconstructorFunc, newArgs).ToObject(), nil
 	}
@@ -315,8 +397,30 @@ func objectReduceCommon(f *Frame, args Args) (*Object, *BaseException) {
 		newArgs = append(newArgs, toTupleUnsafe(extraNewArgs).elems...)
 	}
 	dict := None
-	if d := o.Dict(); d != nil {
-		dict = d.ToObject()
+	if getState != None {
+		if dict, raised = getState.Call(f, nil, nil); raised != nil {
+			return nil, raised
+		}
+	} else {
+		if d := o.Dict(); d != nil {
+			dict = d.ToObject()
+		}
+		// Values stored in __slots__ are included in the state like
+		// CPython's copy_reg.__reduce_ex__ so that copy and pickle can
+		// restore them.
+		slotState := NewDict()
+		for _, typ := range t.mro {
+			for _, m := range typ.members {
+				if value := m.load(o); value != nil {
+					if raised := slotState.SetItemString(f, m.name, value); raised != nil {
+						return nil, raised
+					}
+				}
+			}
+		}
+		if slotState.Len() > 0 {
+			dict = NewTuple2(dict, slotState.ToObject()).ToObject()
+		}
 	}
 	// For proto >= 2 include list and dict items.
 	listItems := None
@@ -337,11 +441,7 @@ func objectReduceCommon(f *Frame, args Args) (*Object, *BaseException) {
 			}
 		}
 	}
-	newFunc, raised := GetAttr(f, t.ToObject(), NewStr("__new__"), nil)
-	if raised != nil {
-		return nil, raised
-	}
-	return NewTuple5(newFunc, NewTuple(newArgs...).ToObject(), dict, listItems, dictItems).ToObject(), nil
+	return NewTuple5(objectNewObjFunc, NewTuple(newArgs...).ToObject(), dict, listItems, dictItems).ToObject(), nil
 }
 
 func objectGetDict(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
@@ -362,7 +462,7 @@ func objectSetDict(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 		return nil, raised
 	}
 	o := args[0]
-	if o.Type() == ObjectType {
+	if o.Type() == ObjectType || o.typ.flags&typeFlagNoDict != 0 {
 		format := "'%s' object has no attribute '__dict__'"
 		return nil, f.RaiseType(AttributeErrorType, fmt.Sprintf(format, o.typ.Name()))
 	}
@@ -345,15 +345,21 @@ func TestObjectReduce(t *testing.T) {
 	// subclasses can be reduced.
 	intSubclass := newTestClass("IntSubclass", []*Type{IntType}, NewDict())
 	intSubclassInst := &Int{Object{typ: intSubclass}, 123}
+	slotsType := newTestClass("Slots", []*Type{ObjectType}, newTestDict("__slots__", newTestTuple("foo")))
+	getStateType := newTestClass("GetState", []*Type{StrType}, newStringDict(map[string]*Object{
+		"__getstate__": newBuiltinFunction("__getstate__", func(f *Frame, _ Args, _ KWArgs) (*Object, *BaseException) {
+			return NewStr("state").ToObject(), nil
+		}).ToObject(),
+	}))
 	cases := []invokeTestCase{
 		{args: wrapArgs("__reduce__", 42, Args{}), wantExc: mustCreateException(TypeErrorType, "can't pickle int objects")},
 		{args: wrapArgs("__reduce__", 42, wrapArgs(2)), want: newTestTuple(42, None, None, None).ToObject()},
 		{args: wrapArgs("__reduce_ex__", 42, Args{}), wantExc: mustCreateException(TypeErrorType, "can't pickle int objects")},
 		{args: wrapArgs("__reduce__", 3.14, wrapArgs("bad proto")), wantExc: mustCreateException(TypeErrorType, "'__reduce__' requires a 'int' object but received a 'str'")},
 		{args: wrapArgs("__reduce_ex__", 3.14, wrapArgs("bad proto")), wantExc: mustCreateException(TypeErrorType, "'__reduce_ex__' requires a 'int' object but received a 'str'")},
-		{args: wrapArgs("__reduce__", newObject(fooType), Args{}), want: newTestTuple("", NewDict(), None, None).ToObject()},
+		{args: wrapArgs("__reduce__", newObject(fooType), Args{}), want: newTestTuple("", None, None, None).ToObject()},
 		{args: wrapArgs("__reduce__", newObject(fooType), wrapArgs(2)), want: newTestTuple("", NewDict(), None, None).ToObject()},
-		{args: wrapArgs("__reduce_ex__", newObject(fooType), Args{}), want: newTestTuple("", NewDict(), None, None).ToObject()},
+		{args: wrapArgs("__reduce_ex__", newObject(fooType), Args{}), want: newTestTuple("", None, None, None).ToObject()},
 		{args: wrapArgs("__reduce_ex__", newObject(reduceOverrideType), Args{}), want: newTestTuple("ReduceOverride", None, None, None).ToObject()},
 		{args: wrapArgs("__reduce__", fooNoDict, Args{}), want: newTestTuple("fooNoDict", None, None, None).ToObject()},
 		{args: wrapArgs("__reduce__", newTestList(1, 2, 3), wrapArgs(2)), want: newTestTuple(NewList(), None, newTestList(1, 2, 3), None).ToObject()},
@@ -364,6 +370,11 @@ func TestObjectReduce(t *testing.T) {
 		{args: wrapArgs("__reduce__", 3.14, wrapArgs(2)), want: newTestTuple(3.14, None, None, None).ToObject()},
 		{args: wrapArgs("__reduce__", NewUnicode("abc"), wrapArgs(2)), want: newTestTuple(NewUnicode("abc"), None, None, None).ToObject()},
 		{args: wrapArgs("__reduce__", intSubclassInst, Args{}), want: newTestTuple(intSubclassInst, None, None, None).ToObject()},
+		{args: wrapArgs("__reduce__", intSubclassInst, wrapArgs(2)), want: newTestTuple(intSubclassInst, None, None, None).ToObject()},
+		{args: wrapArgs("__reduce_ex__", newObject(reduceOverrideType), wrapArgs(2)), want: newTestTuple("ReduceOverride", None, None, None).ToObject()},
+		{args: wrapArgs("__reduce__", newObject(getStateType), Args{}), want: newTestTuple("", "state", None, None).ToObject()},
+		{args: wrapArgs("__reduce__", newObject(getStateType), wrapArgs(2)), want: newTestTuple("", "state", None, None).ToObject()},
+		{args: wrapArgs("__reduce__", newObject(slotsType), Args{}), wantExc: mustCreateException(TypeErrorType, "a class that defines __slots__ without defining __getstate__ cannot be pickled")},
 	}
 	for _, cas := range cases {
 		if err := runInvokeTestCase(fun, &cas); err != "" {
@@ -412,6 +423,19 @@ func TestObjectSetAttr(t *testing.T) {
 	}
 }
 
+func TestObjectSubclassHook(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
+	cases := []invokeTestCase{
+		{args: wrapArgs(IntType), want: NotImplemented},
+		{args: wrapArgs(fooType), want: NotImplemented},
+	}
+	for _, cas := range cases {
+		if err := runInvokeMethodTestCase(ObjectType, "__subclasshook__", &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestObjectStrRepr(t *testing.T) {
 	fun := wrapFuncForTest(func(f *Frame, o *Object, wantPattern string) *BaseException {
 		re := regexp.MustCompile(wantPattern)
@@ -0,0 +1,518 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// attrGetterType corresponds to the Python type 'operator.attrgetter'.
+	attrGetterType = newBasisType("attrgetter", reflect.TypeOf(attrGetter{}), toAttrGetterUnsafe, ObjectType)
+	// itemGetterType corresponds to the Python type 'operator.itemgetter'.
+	itemGetterType = newBasisType("itemgetter", reflect.TypeOf(itemGetter{}), toItemGetterUnsafe, ObjectType)
+	// methodCallerType corresponds to the Python type
+	// 'operator.methodcaller'.
+	methodCallerType = newBasisType("methodcaller", reflect.TypeOf(methodCaller{}), toMethodCallerUnsafe, ObjectType)
+	// operatorBinaryOps are the operator functions that apply a core
+	// binary operation to their two arguments.
+	operatorBinaryOps = map[string]binaryOpFunc{
+		"add":       Add,
+		"and_":      And,
+		"div":       Div,
+		"eq":        Eq,
+		"floordiv":  FloorDiv,
+		"ge":        GE,
+		"getitem":   GetItem,
+		"gt":        GT,
+		"iadd":      IAdd,
+		"iand":      IAnd,
+		"iconcat":   operatorIConcat,
+		"idiv":      IDiv,
+		"ifloordiv": IFloorDiv,
+		"ilshift":   ILShift,
+		"imod":      IMod,
+		"imul":      IMul,
+		"ior":       IOr,
+		"ipow":      IPow,
+		"irshift":   IRShift,
+		"isub":      ISub,
+		"itruediv":  operatorITrueDiv,
+		"ixor":      IXor,
+		"le":        LE,
+		"lshift":    LShift,
+		"lt":        LT,
+		"mod":       Mod,
+		"mul":       Mul,
+		"ne":        NE,
+		"or_":       Or,
+		"pow":       Pow,
+		"rshift":    RShift,
+		"sub":       Sub,
+		"truediv":   operatorTrueDiv,
+		"xor":       Xor,
+		"concat":    operatorConcat,
+		"contains":  operatorContains,
+		"countOf":   operatorCountOf,
+		"indexOf":   operatorIndexOf,
+		"is_":       operatorIs,
+		"is_not":    operatorIsNot,
+	}
+	// operatorUnaryOps are the operator functions that apply a core
+	// unary operation to their argument.
+	operatorUnaryOps = map[string]func(*Frame, *Object) (*Object, *BaseException){
+		"abs":   Abs,
+		"index": operatorIndex,
+		"inv":   Invert,
+		"neg":   Neg,
+		"not_":  operatorNot,
+		"pos":   Pos,
+		"truth": operatorTruth,
+	}
+)
+
+// attrGetter represents Python 'operator.attrgetter' objects.
+type attrGetter struct {
+	Object
+	// names holds the components of each dotted attribute name.
+	names [][]*Str
+}
+
+func toAttrGetterUnsafe(o *Object) *attrGetter {
+	return (*attrGetter)(o.toPointer())
+}
+
+// ToObject upcasts a to an Object.
+func (a *attrGetter) ToObject() *Object {
+	return &a.Object
+}
+
+func attrGetterCall(f *Frame, callable *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := operatorCheckCallArgs(f, "attrgetter", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	names := toAttrGetterUnsafe(callable).names
+	results := make([]*Object, len(names))
+	for i, path := range names {
+		o := args[0]
+		for _, name := range path {
+			var raised *BaseException
+			if o, raised = GetAttr(f, o, name, nil); raised != nil {
+				return nil, raised
+			}
+		}
+		results[i] = o
+	}
+	if len(results) == 1 {
+		return results[0], nil
+	}
+	return NewTuple(results...).ToObject(), nil
+}
+
+func attrGetterNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := operatorCheckNewArgs(f, "attrgetter", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	names := make([][]*Str, len(args))
+	for i, arg := range args {
+		if !arg.isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, "attribute name must be a string")
+		}
+		for _, name := range strings.Split(toStrUnsafe(arg).Value(), ".") {
+			names[i] = append(names[i], NewStr(name))
+		}
+	}
+	a := toAttrGetterUnsafe(newObject(t))
+	a.names = names
+	return a.ToObject(), nil
+}
+
+func initAttrGetterType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("operator").ToObject()
+	attrGetterType.flags &^= typeFlagBasetype
+	attrGetterType.slots.Call = &callSlot{attrGetterCall}
+	attrGetterType.slots.New = &newSlot{attrGetterNew}
+}
+
+// itemGetter represents Python 'operator.itemgetter' objects.
+type itemGetter struct {
+	Object
+	items []*Object
+}
+
+func toItemGetterUnsafe(o *Object) *itemGetter {
+	return (*itemGetter)(o.toPointer())
+}
+
+// ToObject upcasts i to an Object.
+func (i *itemGetter) ToObject() *Object {
+	return &i.Object
+}
+
+func itemGetterCall(f *Frame, callable *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := operatorCheckCallArgs(f, "itemgetter", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	items := toItemGetterUnsafe(callable).items
+	if len(items) == 1 {
+		return GetItem(f, args[0], items[0])
+	}
+	results := make([]*Object, len(items))
+	for i, item := range items {
+		var raised *BaseException
+		if results[i], raised = GetItem(f, args[0], item); raised != nil {
+			return nil, raised
+		}
+	}
+	return NewTuple(results...).ToObject(), nil
+}
+
+func itemGetterNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := operatorCheckNewArgs(f, "itemgetter", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	i := toItemGetterUnsafe(newObject(t))
+	i.items = args.makeCopy()
+	return i.ToObject(), nil
+}
+
+func initItemGetterType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("operator").ToObject()
+	itemGetterType.flags &^= typeFlagBasetype
+	itemGetterType.slots.Call = &callSlot{itemGetterCall}
+	itemGetterType.slots.New = &newSlot{itemGetterNew}
+}
+
+// methodCaller represents Python 'operator.methodcaller' objects.
+type methodCaller struct {
+	Object
+	name   *Str
+	args   Args
+	kwargs KWArgs
+}
+
+func toMethodCallerUnsafe(o *Object) *methodCaller {
+	return (*methodCaller)(o.toPointer())
+}
+
+// ToObject upcasts m to an Object.
+func (m *methodCaller) ToObject() *Object {
+	return &m.Object
+}
+
+func methodCallerCall(f *Frame, callable *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if raised := operatorCheckCallArgs(f, "methodcaller", args, kwargs); raised != nil {
+		return nil, raised
+	}
+	m := toMethodCallerUnsafe(callable)
+	method, raised := GetAttr(f, args[0], m.name, nil)
+	if raised != nil {
+		return nil, raised
+	}
+	return method.Call(f, m.args, m.kwargs)
+}
+
+func methodCallerNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if len(args) == 0 {
+		return nil, f.RaiseType(TypeErrorType, "methodcaller needs at least one argument, the method name")
+	}
+	if !args[0].isInstance(StrType) {
+		return nil, f.RaiseType(TypeErrorType, "method name must be a string")
+	}
+	m := toMethodCallerUnsafe(newObject(t))
+	m.name = toStrUnsafe(args[0])
+	m.args = args[1:].makeCopy()
+	m.kwargs = append(KWArgs(nil), kwargs...)
+	return m.ToObject(), nil
+}
+
+func initMethodCallerType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("operator").ToObject()
+	methodCallerType.flags &^= typeFlagBasetype
+	methodCallerType.slots.Call = &callSlot{methodCallerCall}
+	methodCallerType.slots.New = &newSlot{methodCallerNew}
+}
+
+// operatorCheckCallArgs validates the arguments passed when calling an
+// attrgetter, itemgetter or methodcaller, which take exactly one positional
+// argument.
+func operatorCheckCallArgs(f *Frame, name string, args Args, kwargs KWArgs) *BaseException {
+	if len(kwargs) > 0 {
+		return f.RaiseType(TypeErrorType, name+" does not take keyword arguments")
+	}
+	if len(args) != 1 {
+		return f.RaiseType(TypeErrorType, fmt.Sprintf("%s expected 1 arguments, got %d", name, len(args)))
+	}
+	return nil
+}
+
+// operatorCheckNewArgs validates the arguments passed to the attrgetter and
+// itemgetter constructors, which take one or more positional arguments.
+func operatorCheckNewArgs(f *Frame, name string, args Args, kwargs KWArgs) *BaseException {
+	if len(kwargs) > 0 {
+		return f.RaiseType(TypeErrorType, name+"() does not take keyword arguments")
+	}
+	if len(args) == 0 {
+		return f.RaiseType(TypeErrorType, name+" expected 1 arguments, got 0")
+	}
+	return nil
+}
+
+func operatorCheckSequence(f *Frame, o *Object) *BaseException {
+	if o.typ.slots.GetItem == nil {
+		return f.RaiseType(TypeErrorType, fmt.Sprintf("'%s' object can't be concatenated", o.typ.Name()))
+	}
+	return nil
+}
+
+func operatorConcat(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if raised := operatorCheckSequence(f, v); raised != nil {
+		return nil, raised
+	}
+	return Add(f, v, w)
+}
+
+func operatorContains(f *Frame, seq, value *Object) (*Object, *BaseException) {
+	found, raised := Contains(f, seq, value)
+	if raised != nil {
+		return nil, raised
+	}
+	return GetBool(found).ToObject(), nil
+}
+
+func operatorCountOf(f *Frame, seq, value *Object) (*Object, *BaseException) {
+	count := 0
+	raised := seqForEach(f, seq, func(o *Object) *BaseException {
+		eq, raised := EqBool(f, o, value)
+		if raised == nil && eq {
+			count++
+		}
+		return raised
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(count).ToObject(), nil
+}
+
+func operatorDelItem(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "delitem", args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := DelItem(f, args[0], args[1]); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func operatorIConcat(f *Frame, v, w *Object) (*Object, *BaseException) {
+	if raised := operatorCheckSequence(f, v); raised != nil {
+		return nil, raised
+	}
+	return IAdd(f, v, w)
+}
+
+func operatorIndex(f *Frame, o *Object) (*Object, *BaseException) {
+	i, raised := Index(f, o)
+	if raised != nil {
+		return nil, raised
+	}
+	if i == nil {
+		format := "'%s' object cannot be interpreted as an index"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, o.typ.Name()))
+	}
+	return i, nil
+}
+
+func operatorIndexOf(f *Frame, seq, value *Object) (*Object, *BaseException) {
+	i, index := 0, -1
+	raised := seqForEach(f, seq, func(o *Object) *BaseException {
+		if index != -1 {
+			return nil
+		}
+		eq, raised := EqBool(f, o, value)
+		if raised != nil {
+			return raised
+		}
+		if eq {
+			index = i
+		}
+		i++
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	if index == -1 {
+		return nil, f.RaiseType(ValueErrorType, "sequence.index(x): x not in sequence")
+	}
+	return NewInt(index).ToObject(), nil
+}
+
+func operatorIs(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return GetBool(v == w).ToObject(), nil
+}
+
+func operatorIsNot(f *Frame, v, w *Object) (*Object, *BaseException) {
+	return GetBool(v != w).ToObject(), nil
+}
+
+func operatorITrueDiv(f *Frame, v, w *Object) (*Object, *BaseException) {
+	v, raised := operatorToFloat(f, v)
+	if raised != nil {
+		return nil, raised
+	}
+	return IDiv(f, v, w)
+}
+
+func operatorLengthHint(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkFunctionArgs(f, "length_hint", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	def := NewInt(0).ToObject()
+	if len(args) > 1 {
+		def = args[1]
+	}
+	if !def.isInstance(IntType) {
+		format := "'%s' object cannot be interpreted as an integer"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, def.typ.Name()))
+	}
+	if args[0].typ.slots.Len != nil {
+		n, raised := Len(f, args[0])
+		if raised == nil {
+			return n.ToObject(), nil
+		}
+		if !raised.isInstance(TypeErrorType) {
+			return nil, raised
+		}
+		f.RestoreExc(nil, nil)
+	}
+	hint, raised := args[0].typ.mroLookup(f, NewStr("__length_hint__"))
+	if raised != nil || hint == nil {
+		return def, raised
+	}
+	val, raised := hint.Call(f, Args{args[0]}, nil)
+	if raised != nil {
+		if !raised.isInstance(TypeErrorType) {
+			return nil, raised
+		}
+		f.RestoreExc(nil, nil)
+		return def, nil
+	}
+	if val == NotImplemented {
+		return def, nil
+	}
+	if !val.isInstance(IntType) {
+		format := "__length_hint__ must be integer, not %s"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, val.typ.Name()))
+	}
+	if toIntUnsafe(val).Value() < 0 {
+		return nil, f.RaiseType(ValueErrorType, "__length_hint__() should return >= 0")
+	}
+	return val, nil
+}
+
+func operatorNot(f *Frame, o *Object) (*Object, *BaseException) {
+	ret, raised := IsTrue(f, o)
+	if raised != nil {
+		return nil, raised
+	}
+	return GetBool(!ret).ToObject(), nil
+}
+
+func operatorSetItem(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "setitem", args, ObjectType, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := SetItem(f, args[0], args[1], args[2]); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+// operatorToFloat converts ints and longs to float so that division by the
+// result is true division.
+func operatorToFloat(f *Frame, o *Object) (*Object, *BaseException) {
+	if o.typ != IntType && o.typ != LongType {
+		return o, nil
+	}
+	return FloatType.Call(f, Args{o}, nil)
+}
+
+func operatorTrueDiv(f *Frame, v, w *Object) (*Object, *BaseException) {
+	v, raised := operatorToFloat(f, v)
+	if raised != nil {
+		return nil, raised
+	}
+	return Div(f, v, w)
+}
+
+func operatorTruth(f *Frame, o *Object) (*Object, *BaseException) {
+	ret, raised := IsTrue(f, o)
+	if raised != nil {
+		return nil, raised
+	}
+	return GetBool(ret).ToObject(), nil
+}
+
+func newOperatorBinaryFunc(name string, op binaryOpFunc) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkFunctionArgs(f, name, args, ObjectType, ObjectType); raised != nil {
+			return nil, raised
+		}
+		return op(f, args[0], args[1])
+	}).ToObject()
+}
+
+func newOperatorUnaryFunc(name string, op func(*Frame, *Object) (*Object, *BaseException)) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkFunctionArgs(f, name, args, ObjectType); raised != nil {
+			return nil, raised
+		}
+		return op(f, args[0])
+	}).ToObject()
+}
+
+func init() {
+	RegisterModule("_operator", NewCode("<module>", "_operator", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		funcs := map[string]*Object{
+			"attrgetter":   attrGetterType.ToObject(),
+			"delitem":      newBuiltinFunction("delitem", operatorDelItem).ToObject(),
+			"itemgetter":   itemGetterType.ToObject(),
+			"length_hint":  newBuiltinFunction("length_hint", operatorLengthHint).ToObject(),
+			"methodcaller": methodCallerType.ToObject(),
+			"setitem":      newBuiltinFunction("setitem", operatorSetItem).ToObject(),
+		}
+		for name, op := range operatorBinaryOps {
+			funcs[name] = newOperatorBinaryFunc(name, op)
+		}
+		for name, op := range operatorUnaryOps {
+			funcs[name] = newOperatorUnaryFunc(name, op)
+		}
+		funcs["invert"] = funcs["inv"]
+		for name, fn := range funcs {
+			if raised := f.Globals().SetItemString(f, name, fn); raised != nil {
+				return nil, raised
+			}
+		}
+		return nil, nil
+	}))
+}
@@ -0,0 +1,160 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestOperatorBinaryOps(t *testing.T) {
+	cases := []struct {
+		name string
+		invokeTestCase
+	}{
+		{"add", invokeTestCase{args: wrapArgs(1, 2), want: NewInt(3).ToObject()}},
+		{"contains", invokeTestCase{args: wrapArgs(newTestList(1, 2), 2), want: True.ToObject()}},
+		{"concat", invokeTestCase{args: wrapArgs("a", "b"), want: NewStr("ab").ToObject()}},
+		{"concat", invokeTestCase{args: wrapArgs(1, 2), wantExc: mustCreateException(TypeErrorType, "'int' object can't be concatenated")}},
+		{"countOf", invokeTestCase{args: wrapArgs(newTestList(1, 2, 1), 1), want: NewInt(2).ToObject()}},
+		{"div", invokeTestCase{args: wrapArgs(7, 2), want: NewInt(3).ToObject()}},
+		{"getitem", invokeTestCase{args: wrapArgs(newTestTuple("a", "b"), 1), want: NewStr("b").ToObject()}},
+		{"iadd", invokeTestCase{args: wrapArgs(newTestList(1), newTestList(2)), want: newTestList(1, 2).ToObject()}},
+		{"indexOf", invokeTestCase{args: wrapArgs(newTestList(1, 2, 2), 2), want: NewInt(1).ToObject()}},
+		{"indexOf", invokeTestCase{args: wrapArgs(newTestList(1), 2), wantExc: mustCreateException(ValueErrorType, "sequence.index(x): x not in sequence")}},
+		{"is_", invokeTestCase{args: wrapArgs(None, None), want: True.ToObject()}},
+		{"is_not", invokeTestCase{args: wrapArgs(None, None), want: False.ToObject()}},
+		{"itruediv", invokeTestCase{args: wrapArgs(1, 4), want: NewFloat(0.25).ToObject()}},
+		{"lt", invokeTestCase{args: wrapArgs(1, 2), want: True.ToObject()}},
+		{"sub", invokeTestCase{args: wrapArgs(1), wantExc: mustCreateException(TypeErrorType, "'sub' requires 2 arguments")}},
+		{"truediv", invokeTestCase{args: wrapArgs(1, 2), want: NewFloat(0.5).ToObject()}},
+	}
+	for _, cas := range cases {
+		fun := newOperatorBinaryFunc(cas.name, operatorBinaryOps[cas.name])
+		if err := runInvokeTestCase(fun, &cas.invokeTestCase); err != "" {
+			t.Errorf("%s: %s", cas.name, err)
+		}
+	}
+}
+
+func TestOperatorUnaryOps(t *testing.T) {
+	cases := []struct {
+		name string
+		invokeTestCase
+	}{
+		{"abs", invokeTestCase{args: wrapArgs(-3), want: NewInt(3).ToObject()}},
+		{"index", invokeTestCase{args: wrapArgs(5), want: NewInt(5).ToObject()}},
+		{"index", invokeTestCase{args: wrapArgs(1.5), wantExc: mustCreateException(TypeErrorType, "'float' object cannot be interpreted as an index")}},
+		{"inv", invokeTestCase{args: wrapArgs(3), want: NewInt(-4).ToObject()}},
+		{"not_", invokeTestCase{args: wrapArgs(0), want: True.ToObject()}},
+		{"truth", invokeTestCase{args: wrapArgs(NewList()), want: False.ToObject()}},
+	}
+	for _, cas := range cases {
+		fun := newOperatorUnaryFunc(cas.name, operatorUnaryOps[cas.name])
+		if err := runInvokeTestCase(fun, &cas.invokeTestCase); err != "" {
+			t.Errorf("%s: %s", cas.name, err)
+		}
+	}
+}
+
+func TestOperatorLengthHint(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestList(1, 2)), want: NewInt(2).ToObject()},
+		{args: wrapArgs(newObject(ObjectType)), want: NewInt(0).ToObject()},
+		{args: wrapArgs(newObject(ObjectType), 5), want: NewInt(5).ToObject()},
+		{args: wrapArgs(NewList(), "a"), wantExc: mustCreateException(TypeErrorType, "'str' object cannot be interpreted as an integer")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(newBuiltinFunction("length_hint", operatorLengthHint).ToObject(), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestAttrGetter(t *testing.T) {
+	inner := newTestClass("Inner", []*Type{ObjectType}, newStringDict(map[string]*Object{"baz": NewInt(2).ToObject()}))
+	outer := newTestClass("Outer", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"bar":   NewInt(1).ToObject(),
+		"inner": inner.ToObject(),
+	}))
+	fun := wrapFuncForTest(func(f *Frame, names Args, o *Object) (*Object, *BaseException) {
+		getter, raised := attrGetterType.Call(f, names, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return getter.Call(f, Args{o}, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(wrapArgs("bar"), outer), want: NewInt(1).ToObject()},
+		{args: wrapArgs(wrapArgs("inner.baz"), outer), want: NewInt(2).ToObject()},
+		{args: wrapArgs(wrapArgs("bar", "inner.baz"), outer), want: newTestTuple(1, 2).ToObject()},
+		{args: wrapArgs(wrapArgs("qux"), outer), wantExc: mustCreateException(AttributeErrorType, "type object 'Outer' has no attribute 'qux'")},
+		{args: wrapArgs(wrapArgs(2), outer), wantExc: mustCreateException(TypeErrorType, "attribute name must be a string")},
+		{args: wrapArgs(Args{}, outer), wantExc: mustCreateException(TypeErrorType, "attrgetter expected 1 arguments, got 0")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestItemGetter(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, items Args, args ...*Object) (*Object, *BaseException) {
+		getter, raised := itemGetterType.Call(f, items, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return getter.Call(f, args, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(wrapArgs(1), newTestTuple("a", "b")), want: NewStr("b").ToObject()},
+		{args: wrapArgs(wrapArgs("x", "y"), newTestDict("x", 1, "y", 2)), want: newTestTuple(1, 2).ToObject()},
+		{args: wrapArgs(wrapArgs(5), NewList()), wantExc: mustCreateException(IndexErrorType, "index out of range")},
+		{args: wrapArgs(wrapArgs(1)), wantExc: mustCreateException(TypeErrorType, "itemgetter expected 1 arguments, got 0")},
+		{args: wrapArgs(Args{}, NewList()), wantExc: mustCreateException(TypeErrorType, "itemgetter expected 1 arguments, got 0")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMethodCaller(t *testing.T) {
+	kwargsFunc := newBuiltinFunction("kw", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		return NewTuple(NewTuple(args...).ToObject(), kwargs.makeDict().ToObject()).ToObject(), nil
+	})
+	mod := &Module{Object: Object{typ: ModuleType, dict: newTestDict("kw", kwargsFunc)}}
+	fun := wrapFuncForTest(func(f *Frame, args Args, kwargs KWArgs, o *Object) (*Object, *BaseException) {
+		caller, raised := methodCallerType.Call(f, args, kwargs)
+		if raised != nil {
+			return nil, raised
+		}
+		return caller.Call(f, Args{o}, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(wrapArgs("upper"), KWArgs(nil), "ab"), want: NewStr("AB").ToObject()},
+		{args: wrapArgs(wrapArgs("split", ","), KWArgs(nil), "a,b"), want: newTestList("a", "b").ToObject()},
+		{args: wrapArgs(wrapArgs("kw", 1), KWArgs{{"x", NewInt(2).ToObject()}}, mod), want: newTestTuple(newTestTuple(1), newTestDict("x", 2)).ToObject()},
+		{args: wrapArgs(wrapArgs("foo"), KWArgs(nil), "ab"), wantExc: mustCreateException(AttributeErrorType, "'str' object has no attribute 'foo'")},
+		{args: wrapArgs(Args{}, KWArgs(nil), "ab"), wantExc: mustCreateException(TypeErrorType, "methodcaller needs at least one argument, the method name")},
+		{args: wrapArgs(wrapArgs(1), KWArgs(nil), "ab"), wantExc: mustCreateException(TypeErrorType, "method name must be a string")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,702 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// statResultType corresponds to the Python type 'posix.stat_result'.
+	statResultType = newSimpleType("stat_result", TupleType)
+	// statResultFields are the names of the stat_result tuple elements
+	// followed by the names of the fields only available as attributes.
+	statResultFields = []string{
+		"st_mode", "st_ino", "st_dev", "st_nlink", "st_uid", "st_gid",
+		"st_size", "st_atime", "st_mtime", "st_ctime",
+		"st_blksize", "st_blocks", "st_rdev",
+	}
+)
+
+// numStatResultElems is the number of elements in a stat_result tuple.
+const numStatResultElems = 10
+
+func init() {
+	RegisterModule("posix", NewCode("<module>", "posix", nil, 0, posixInit))
+}
+
+// posixInit populates the globals of the posix module, the low level
+// operating system interface that the os module is built upon.
+func posixInit(f *Frame, _ []*Object) (*Object, *BaseException) {
+	environ := NewDict()
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if raised := environ.SetItemString(f, k, NewStr(v).ToObject()); raised != nil {
+			return nil, raised
+		}
+	}
+	globals := map[string]*Object{
+		"environ":     environ.ToObject(),
+		"error":       OSErrorType.ToObject(),
+		"stat_result": statResultType.ToObject(),
+	}
+	for name, fn := range posixFuncs {
+		globals[name] = newBuiltinFunction(name, fn).ToObject()
+	}
+	for name, value := range posixConstants {
+		globals[name] = NewInt(value).ToObject()
+	}
+	for name, value := range globals {
+		if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+			return nil, raised
+		}
+	}
+	return nil, nil
+}
+
+var posixConstants = map[string]int{
+	"F_OK":       0,
+	"R_OK":       4,
+	"W_OK":       2,
+	"X_OK":       1,
+	"O_APPEND":   syscall.O_APPEND,
+	"O_CREAT":    syscall.O_CREAT,
+	"O_EXCL":     syscall.O_EXCL,
+	"O_NOCTTY":   syscall.O_NOCTTY,
+	"O_NONBLOCK": syscall.O_NONBLOCK,
+	"O_RDONLY":   syscall.O_RDONLY,
+	"O_RDWR":     syscall.O_RDWR,
+	"O_SYNC":     syscall.O_SYNC,
+	"O_TRUNC":    syscall.O_TRUNC,
+	"O_WRONLY":   syscall.O_WRONLY,
+	"WNOHANG":    syscall.WNOHANG,
+	"WUNTRACED":  syscall.WUNTRACED,
+}
+
+var posixFuncs = map[string]Func{
+	"_exit":       posixExit,
+	"WEXITSTATUS": posixWaitStatusFunc("WEXITSTATUS", func(ws syscall.WaitStatus) *Object { return NewInt(ws.ExitStatus()).ToObject() }),
+	"WIFEXITED":   posixWaitStatusFunc("WIFEXITED", func(ws syscall.WaitStatus) *Object { return GetBool(ws.Exited()).ToObject() }),
+	"WIFSIGNALED": posixWaitStatusFunc("WIFSIGNALED", func(ws syscall.WaitStatus) *Object { return GetBool(ws.Signaled()).ToObject() }),
+	"WIFSTOPPED":  posixWaitStatusFunc("WIFSTOPPED", func(ws syscall.WaitStatus) *Object { return GetBool(ws.Stopped()).ToObject() }),
+	"WSTOPSIG":    posixWaitStatusFunc("WSTOPSIG", func(ws syscall.WaitStatus) *Object { return NewInt(int(ws.StopSignal())).ToObject() }),
+	"WTERMSIG":    posixWaitStatusFunc("WTERMSIG", func(ws syscall.WaitStatus) *Object { return NewInt(int(ws.Signal())).ToObject() }),
+	"access":      posixAccess,
+	"chdir":       posixChdir,
+	"chmod":       posixChmod,
+	"chown":       posixChown,
+	"close":       posixClose,
+	"dup":         posixDup,
+	"dup2":        posixDup2,
+	"fstat":       posixFstat,
+	"getcwd":      posixGetcwd,
+	"getegid":     posixIDFunc("getegid", os.Getegid),
+	"geteuid":     posixIDFunc("geteuid", os.Geteuid),
+	"getgid":      posixIDFunc("getgid", os.Getgid),
+	"getpid":      posixIDFunc("getpid", os.Getpid),
+	"getppid":     posixIDFunc("getppid", os.Getppid),
+	"getuid":      posixIDFunc("getuid", os.Getuid),
+	"kill":        posixKill,
+	"link":        posixLink,
+	"listdir":     posixListdir,
+	"lseek":       posixLseek,
+	"lstat":       posixLstat,
+	"mkdir":       posixMkdir,
+	"open":        posixOpen,
+	"pipe":        posixPipe,
+	"putenv":      posixPutenv,
+	"read":        posixRead,
+	"readlink":    posixReadlink,
+	"remove":      posixUnlink,
+	"rename":      posixRename,
+	"rmdir":       posixRmdir,
+	"stat":        posixStat,
+	"strerror":    posixStrerror,
+	"symlink":     posixSymlink,
+	"umask":       posixUmask,
+	"unlink":      posixUnlink,
+	"unsetenv":    posixUnsetenv,
+	"urandom":     posixUrandom,
+	"utime":       posixUtime,
+	"waitpid":     posixWaitpid,
+	"write":       posixWrite,
+}
+
+// posixError raises an OSError for err, which is typically a syscall.Errno
+// or an *os.PathError wrapping one.
+func posixError(f *Frame, err error) *BaseException {
+	return raiseEnvironmentError(f, OSErrorType, err)
+}
+
+// posixPathError raises an OSError for an error returned by a syscall that
+// operated on path.
+func posixPathError(f *Frame, op, path string, err error) *BaseException {
+	return posixError(f, &os.PathError{Op: op, Path: path, Err: err})
+}
+
+// posixRetry calls fn until it returns an error other than EINTR.
+func posixRetry(fn func() error) error {
+	for {
+		if err := fn(); err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func posixAccess(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "access", args, StrType, IntType); raised != nil {
+		return nil, raised
+	}
+	err := syscall.Access(toStrUnsafe(args[0]).Value(), uint32(toIntUnsafe(args[1]).Value()))
+	return GetBool(err == nil).ToObject(), nil
+}
+
+func posixChdir(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "chdir", args, StrType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	if err := syscall.Chdir(path); err != nil {
+		return nil, posixPathError(f, "chdir", path, err)
+	}
+	return None, nil
+}
+
+func posixChmod(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "chmod", args, StrType, IntType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	if err := syscall.Chmod(path, uint32(toIntUnsafe(args[1]).Value())); err != nil {
+		return nil, posixPathError(f, "chmod", path, err)
+	}
+	return None, nil
+}
+
+func posixChown(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "chown", args, StrType, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	if err := syscall.Chown(path, toIntUnsafe(args[1]).Value(), toIntUnsafe(args[2]).Value()); err != nil {
+		return nil, posixPathError(f, "chown", path, err)
+	}
+	return None, nil
+}
+
+func posixClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "close", args, IntType); raised != nil {
+		return nil, raised
+	}
+	if err := syscall.Close(toIntUnsafe(args[0]).Value()); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixDup(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "dup", args, IntType); raised != nil {
+		return nil, raised
+	}
+	syscall.ForkLock.RLock()
+	fd, err := syscall.Dup(toIntUnsafe(args[0]).Value())
+	if err == nil {
+		syscall.CloseOnExec(fd)
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewInt(fd).ToObject(), nil
+}
+
+func posixDup2(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "dup2", args, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	oldfd, newfd := toIntUnsafe(args[0]).Value(), toIntUnsafe(args[1]).Value()
+	var err error
+	if oldfd == newfd {
+		// dup2 is a no-op in this case but must still fail when oldfd
+		// is not a valid descriptor.
+		var st syscall.Stat_t
+		err = syscall.Fstat(oldfd, &st)
+	} else {
+		err = posixRetry(func() error { return dup2(oldfd, newfd) })
+	}
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixExit(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "_exit", args, IntType); raised != nil {
+		return nil, raised
+	}
+	os.Exit(toIntUnsafe(args[0]).Value())
+	return None, nil
+}
+
+func posixFstat(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "fstat", args, IntType); raised != nil {
+		return nil, raised
+	}
+	var st syscall.Stat_t
+	if err := syscall.Fstat(toIntUnsafe(args[0]).Value(), &st); err != nil {
+		return nil, posixError(f, err)
+	}
+	return newStatResult(f, &st)
+}
+
+func posixGetcwd(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "getcwd", args); raised != nil {
+		return nil, raised
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewStr(dir).ToObject(), nil
+}
+
+// posixIDFunc returns a Func that takes no arguments and returns the result
+// of calling fn, e.g. os.Getpid.
+func posixIDFunc(name string, fn func() int) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkFunctionArgs(f, name, args); raised != nil {
+			return nil, raised
+		}
+		return NewInt(fn()).ToObject(), nil
+	}
+}
+
+func posixKill(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "kill", args, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	if err := syscall.Kill(toIntUnsafe(args[0]).Value(), syscall.Signal(toIntUnsafe(args[1]).Value())); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixLink(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "link", args, StrType, StrType); raised != nil {
+		return nil, raised
+	}
+	if err := os.Link(toStrUnsafe(args[0]).Value(), toStrUnsafe(args[1]).Value()); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixListdir(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "listdir", args, StrType); raised != nil {
+		return nil, raised
+	}
+	dir, err := os.Open(toStrUnsafe(args[0]).Value())
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	elems := make([]*Object, len(names))
+	for i, name := range names {
+		elems[i] = NewStr(name).ToObject()
+	}
+	return NewList(elems...).ToObject(), nil
+}
+
+func posixLseek(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "lseek", args, IntType, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	off, err := syscall.Seek(toIntUnsafe(args[0]).Value(), int64(toIntUnsafe(args[1]).Value()), toIntUnsafe(args[2]).Value())
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewInt(int(off)).ToObject(), nil
+}
+
+func posixLstat(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "lstat", args, StrType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return nil, posixPathError(f, "lstat", path, err)
+	}
+	return newStatResult(f, &st)
+}
+
+func posixMkdir(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{StrType, IntType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkFunctionArgs(f, "mkdir", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	mode := uint32(0777)
+	if len(args) > 1 {
+		mode = uint32(toIntUnsafe(args[1]).Value())
+	}
+	if err := syscall.Mkdir(path, mode); err != nil {
+		return nil, posixPathError(f, "mkdir", path, err)
+	}
+	return None, nil
+}
+
+// NOTE(compatibility): Child processes are started via os/exec, which can't
+// close arbitrary descriptors in the child. So like Python 3 (PEP 446) the
+// descriptors created by open, dup and pipe are not inherited by children.
+// dup2 still creates an inheritable descriptor so it can be used to redirect
+// the standard streams.
+func posixOpen(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{StrType, IntType, IntType}
+	if len(args) == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkFunctionArgs(f, "open", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	flags := toIntUnsafe(args[1]).Value()
+	mode := uint32(0777)
+	if len(args) > 2 {
+		mode = uint32(toIntUnsafe(args[2]).Value())
+	}
+	var fd int
+	err := posixRetry(func() (err error) {
+		fd, err = syscall.Open(path, flags|syscall.O_CLOEXEC, mode)
+		return err
+	})
+	if err != nil {
+		return nil, posixPathError(f, "open", path, err)
+	}
+	return NewInt(fd).ToObject(), nil
+}
+
+func posixPipe(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "pipe", args); raised != nil {
+		return nil, raised
+	}
+	var fds [2]int
+	syscall.ForkLock.RLock()
+	err := syscall.Pipe(fds[:])
+	if err == nil {
+		syscall.CloseOnExec(fds[0])
+		syscall.CloseOnExec(fds[1])
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewTuple2(NewInt(fds[0]).ToObject(), NewInt(fds[1]).ToObject()).ToObject(), nil
+}
+
+func posixPutenv(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "putenv", args, StrType, StrType); raised != nil {
+		return nil, raised
+	}
+	if err := os.Setenv(toStrUnsafe(args[0]).Value(), toStrUnsafe(args[1]).Value()); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixRead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "read", args, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	size := toIntUnsafe(args[1]).Value()
+	if size < 0 {
+		return nil, posixError(f, syscall.EINVAL)
+	}
+	buf := make([]byte, size)
+	var n int
+	err := posixRetry(func() (err error) {
+		n, err = syscall.Read(toIntUnsafe(args[0]).Value(), buf)
+		return err
+	})
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewStr(string(buf[:n])).ToObject(), nil
+}
+
+func posixReadlink(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "readlink", args, StrType); raised != nil {
+		return nil, raised
+	}
+	dest, err := os.Readlink(toStrUnsafe(args[0]).Value())
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewStr(dest).ToObject(), nil
+}
+
+func posixRename(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "rename", args, StrType, StrType); raised != nil {
+		return nil, raised
+	}
+	oldpath, newpath := toStrUnsafe(args[0]).Value(), toStrUnsafe(args[1]).Value()
+	if err := syscall.Rename(oldpath, newpath); err != nil {
+		return nil, posixError(f, &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err})
+	}
+	return None, nil
+}
+
+func posixRmdir(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "rmdir", args, StrType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	if err := syscall.Rmdir(path); err != nil {
+		return nil, posixPathError(f, "rmdir", path, err)
+	}
+	return None, nil
+}
+
+func posixStat(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "stat", args, StrType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return nil, posixPathError(f, "stat", path, err)
+	}
+	return newStatResult(f, &st)
+}
+
+func posixStrerror(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "strerror", args, IntType); raised != nil {
+		return nil, raised
+	}
+	return NewStr(errnoMessage(syscall.Errno(toIntUnsafe(args[0]).Value()))).ToObject(), nil
+}
+
+func posixSymlink(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "symlink", args, StrType, StrType); raised != nil {
+		return nil, raised
+	}
+	if err := os.Symlink(toStrUnsafe(args[0]).Value(), toStrUnsafe(args[1]).Value()); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixUmask(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "umask", args, IntType); raised != nil {
+		return nil, raised
+	}
+	return NewInt(syscall.Umask(toIntUnsafe(args[0]).Value())).ToObject(), nil
+}
+
+func posixUnlink(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "unlink", args, StrType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	if err := syscall.Unlink(path); err != nil {
+		return nil, posixPathError(f, "unlink", path, err)
+	}
+	return None, nil
+}
+
+func posixUnsetenv(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "unsetenv", args, StrType); raised != nil {
+		return nil, raised
+	}
+	if err := os.Unsetenv(toStrUnsafe(args[0]).Value()); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixUrandom(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "urandom", args, IntType); raised != nil {
+		return nil, raised
+	}
+	n := toIntUnsafe(args[0]).Value()
+	if n < 0 {
+		return nil, f.RaiseType(ValueErrorType, "negative argument not allowed")
+	}
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewStr(string(buf)).ToObject(), nil
+}
+
+func posixUtime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "utime", args, StrType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	path := toStrUnsafe(args[0]).Value()
+	atime := time.Now()
+	mtime := atime
+	if times := args[1]; times != None {
+		if !times.isInstance(TupleType) || len(toTupleUnsafe(times).elems) != 2 {
+			return nil, f.RaiseType(TypeErrorType, "utime() arg 2 must be a tuple (atime, mtime)")
+		}
+		var ts [2]time.Time
+		for i, elem := range toTupleUnsafe(times).elems {
+			secs, ok := floatCoerce(elem)
+			if !ok {
+				return nil, f.RaiseType(TypeErrorType, "an integer is required")
+			}
+			ts[i] = time.Unix(0, int64(secs*1e9))
+		}
+		atime, mtime = ts[0], ts[1]
+	}
+	if err := os.Chtimes(path, atime, mtime); err != nil {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func posixWaitpid(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "waitpid", args, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	var ws syscall.WaitStatus
+	var pid int
+	err := posixRetry(func() (err error) {
+		pid, err = syscall.Wait4(toIntUnsafe(args[0]).Value(), &ws, toIntUnsafe(args[1]).Value(), nil)
+		return err
+	})
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewTuple2(NewInt(pid).ToObject(), NewInt(int(ws)).ToObject()).ToObject(), nil
+}
+
+// posixWaitStatusFunc returns a Func that decodes a status returned by
+// waitpid using fn.
+func posixWaitStatusFunc(name string, fn func(syscall.WaitStatus) *Object) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkFunctionArgs(f, name, args, IntType); raised != nil {
+			return nil, raised
+		}
+		return fn(syscall.WaitStatus(toIntUnsafe(args[0]).Value())), nil
+	}
+}
+
+func posixWrite(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "write", args, IntType, StrType); raised != nil {
+		return nil, raised
+	}
+	var n int
+	err := posixRetry(func() (err error) {
+		n, err = syscall.Write(toIntUnsafe(args[0]).Value(), []byte(toStrUnsafe(args[1]).Value()))
+		return err
+	})
+	if err != nil {
+		return nil, posixError(f, err)
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+// newStatResult creates a stat_result from st. Like CPython, the times in the
+// tuple are whole seconds while the corresponding attributes are floats.
+func newStatResult(f *Frame, st *syscall.Stat_t) (*Object, *BaseException) {
+	atime, mtime, ctime := statTimes(st)
+	ints := []int{
+		int(st.Mode), int(st.Ino), int(st.Dev), int(st.Nlink), int(st.Uid),
+		int(st.Gid), int(st.Size), int(atime.Sec), int(mtime.Sec), int(ctime.Sec),
+		int(st.Blksize), int(st.Blocks), int(st.Rdev),
+	}
+	elems := make([]*Object, len(ints))
+	for i, v := range ints {
+		elems[i] = NewInt(v).ToObject()
+	}
+	o := newObject(statResultType)
+	toTupleUnsafe(o).elems = elems[:numStatResultElems]
+	attrs := make([]*Object, len(elems))
+	copy(attrs, elems)
+	for i, ts := range []syscall.Timespec{atime, mtime, ctime} {
+		secs := float64(ts.Sec) + float64(ts.Nsec)/1e9
+		attrs[7+i] = NewFloat(secs).ToObject()
+	}
+	if raised := statResultSetAttrs(f, o, attrs); raised != nil {
+		return nil, raised
+	}
+	return o, nil
+}
+
+func statResultNew(f *Frame, t *Type, args Args, _ KWArgs) (*Object, *BaseException) {
+	elems, raised := seqNew(f, args)
+	if raised != nil {
+		return nil, raised
+	}
+	if n := len(elems); n < numStatResultElems || n > len(statResultFields) {
+		format := "posix.stat_result() takes an at least %d-sequence (%d-sequence given)"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, numStatResultElems, n))
+	}
+	o := newObject(t)
+	toTupleUnsafe(o).elems = elems[:numStatResultElems]
+	if raised := statResultSetAttrs(f, o, elems); raised != nil {
+		return nil, raised
+	}
+	return o, nil
+}
+
+// statResultSetAttrs binds the named stat_result fields of o to values.
+// Fields beyond the end of values are set to None.
+func statResultSetAttrs(f *Frame, o *Object, values []*Object) *BaseException {
+	d := o.Dict()
+	for i, name := range statResultFields {
+		value := None
+		if i < len(values) {
+			value = values[i]
+		}
+		if raised := d.SetItemString(f, name, value); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+func statResultRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	elems := toTupleUnsafe(o).elems
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		s, raised := Repr(f, elem)
+		if raised != nil {
+			return nil, raised
+		}
+		parts[i] = statResultFields[i] + "=" + s.Value()
+	}
+	return NewStr("posix.stat_result(" + strings.Join(parts, ", ") + ")").ToObject(), nil
+}
+
+func initStatResultType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("posix").ToObject()
+	statResultType.slots.New = &newSlot{statResultNew}
+	statResultType.slots.Repr = &unaryOpSlot{statResultRepr}
+}
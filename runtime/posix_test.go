@@ -0,0 +1,155 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// mustGetModuleAttr imports the named module and returns its attribute
+// attrName.
+func mustGetModuleAttr(modName, attrName string) *Object {
+	f := NewRootFrame()
+	mods, raised := ImportModule(f, modName)
+	if raised != nil {
+		panic(raised)
+	}
+	return mustNotRaise(GetAttr(f, mods[len(mods)-1], NewStr(attrName), nil))
+}
+
+func mustGetPosixFunc(name string) *Object {
+	return mustGetModuleAttr("posix", name)
+}
+
+func TestPosixStat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo")
+	if err := os.WriteFile(path, []byte("bar"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+	fun := wrapFuncForTest(func(f *Frame, name, path string) (*Tuple, *BaseException) {
+		st, raised := mustGetPosixFunc(name).Call(f, wrapArgs(path), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		var elems []*Object
+		for _, attr := range []string{"st_mode", "st_size", "st_nlink"} {
+			elem, raised := GetAttr(f, st, NewStr(attr), nil)
+			if raised != nil {
+				return nil, raised
+			}
+			elems = append(elems, elem)
+		}
+		mtime, raised := GetAttr(f, st, NewStr("st_mtime"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		elems = append(elems, GetBool(mtime.isInstance(FloatType)).ToObject(), NewInt(len(toTupleUnsafe(st).elems)).ToObject())
+		return NewTuple(elems...), nil
+	})
+	f := NewRootFrame()
+	enoent := toBaseExceptionUnsafe(mustNotRaise(OSErrorType.Call(f, wrapArgs(2, "No such file or directory", missing), nil)))
+	cases := []invokeTestCase{
+		{args: wrapArgs("stat", path), want: newTestTuple(syscall.S_IFREG|0640, 3, 1, true, 10).ToObject()},
+		{args: wrapArgs("lstat", path), want: newTestTuple(syscall.S_IFREG|0640, 3, 1, true, 10).ToObject()},
+		{args: wrapArgs("stat", missing), wantExc: enoent},
+		{args: wrapArgs("lstat", missing), wantExc: enoent},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPosixFileDescriptors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo")
+	f := NewRootFrame()
+	call := func(name string, args ...interface{}) *Object {
+		return mustNotRaise(mustGetPosixFunc(name).Call(f, wrapArgs(args...), nil))
+	}
+	fd := call("open", path, syscall.O_CREAT|syscall.O_RDWR, 0600)
+	if n := call("write", fd, "foobar"); toIntUnsafe(n).Value() != 6 {
+		t.Errorf("write returned %v, want 6", n)
+	}
+	if off := call("lseek", fd, 3, 0); toIntUnsafe(off).Value() != 3 {
+		t.Errorf("lseek returned %v, want 3", off)
+	}
+	dupFd := call("dup", fd)
+	if s := call("read", dupFd, 10); toStrUnsafe(s).Value() != "bar" {
+		t.Errorf("read returned %v, want 'bar'", s)
+	}
+	call("close", dupFd)
+	call("close", fd)
+	ebadf := toBaseExceptionUnsafe(mustNotRaise(OSErrorType.Call(f, wrapArgs(int(syscall.EBADF), "Bad file descriptor"), nil)))
+	cas := invokeTestCase{args: wrapArgs(fd), wantExc: ebadf}
+	if err := runInvokeTestCase(mustGetPosixFunc("close"), &cas); err != "" {
+		t.Error(err)
+	}
+}
+
+func TestPosixEnv(t *testing.T) {
+	const name = "GRUMPY_TEST_POSIX_ENV"
+	f := NewRootFrame()
+	mustNotRaise(mustGetPosixFunc("putenv").Call(f, wrapArgs(name, "foo"), nil))
+	if got := os.Getenv(name); got != "foo" {
+		t.Errorf("putenv(%q, 'foo') set %q, want 'foo'", name, got)
+	}
+	mustNotRaise(mustGetPosixFunc("unsetenv").Call(f, wrapArgs(name), nil))
+	if _, ok := os.LookupEnv(name); ok {
+		t.Errorf("unsetenv(%q) left the variable set", name)
+	}
+}
+
+func TestStatResultNew(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Tuple, *BaseException) {
+		st, raised := statResultType.Call(f, args, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		mode, raised := GetAttr(f, st, NewStr("st_mode"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		rdev, raised := GetAttr(f, st, NewStr("st_rdev"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple(mode, rdev, NewInt(len(toTupleUnsafe(st).elems)).ToObject()), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestRange(10)), want: newTestTuple(0, None, 10).ToObject()},
+		{args: wrapArgs(newTestRange(13)), want: newTestTuple(0, 12, 10).ToObject()},
+		{args: wrapArgs(newTestRange(3)), wantExc: mustCreateException(TypeErrorType, "posix.stat_result() takes an at least 10-sequence (3-sequence given)")},
+		{args: wrapArgs(newTestRange(14)), wantExc: mustCreateException(TypeErrorType, "posix.stat_result() takes an at least 10-sequence (14-sequence given)")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestStatResultRepr(t *testing.T) {
+	st := mustNotRaise(statResultType.Call(NewRootFrame(), wrapArgs(newTestRange(10)), nil))
+	cas := invokeTestCase{args: wrapArgs(st), want: NewStr("posix.stat_result(st_mode=0, st_ino=1, st_dev=2, st_nlink=3, st_uid=4, st_gid=5, st_size=6, st_atime=7, st_mtime=8, st_ctime=9)").ToObject()}
+	if err := runInvokeTestCase(wrapFuncForTest(Repr), &cas); err != "" {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// The MT19937 parameters. See http://www.math.sci.hiroshima-u.ac.jp/~m-mat/MT/emt.html
+const (
+	mtN         = 624
+	mtM         = 397
+	mtMatrixA   = 0x9908b0df
+	mtUpperMask = 0x80000000
+	mtLowerMask = 0x7fffffff
+)
+
+var (
+	// randomType corresponds to the Python type '_random.Random'.
+	randomType = newBasisType("Random", reflect.TypeOf(random{}), toRandomUnsafe, ObjectType)
+)
+
+// random represents Python '_random.Random' objects. It is a Mersenne
+// Twister generator that produces the same streams as CPython's for the same
+// seeds.
+type random struct {
+	Object
+	mutex sync.Mutex
+	state [mtN]uint32
+	index int
+}
+
+func toRandomUnsafe(o *Object) *random {
+	return (*random)(o.toPointer())
+}
+
+// ToObject upcasts r to an Object.
+func (r *random) ToObject() *Object {
+	return &r.Object
+}
+
+// genrand returns the next 32 bit value from the generator. The caller must
+// hold r.mutex.
+func (r *random) genrand() uint32 {
+	mt := &r.state
+	if r.index >= mtN {
+		var kk int
+		for ; kk < mtN-mtM; kk++ {
+			y := mt[kk]&mtUpperMask | mt[kk+1]&mtLowerMask
+			mt[kk] = mt[kk+mtM] ^ y>>1 ^ (y&1)*mtMatrixA
+		}
+		for ; kk < mtN-1; kk++ {
+			y := mt[kk]&mtUpperMask | mt[kk+1]&mtLowerMask
+			mt[kk] = mt[kk+(mtM-mtN)] ^ y>>1 ^ (y&1)*mtMatrixA
+		}
+		y := mt[mtN-1]&mtUpperMask | mt[0]&mtLowerMask
+		mt[mtN-1] = mt[mtM-1] ^ y>>1 ^ (y&1)*mtMatrixA
+		r.index = 0
+	}
+	y := mt[r.index]
+	r.index++
+	y ^= y >> 11
+	y ^= y << 7 & 0x9d2c5680
+	y ^= y << 15 & 0xefc60000
+	return y ^ y>>18
+}
+
+func (r *random) initGenrand(s uint32) {
+	mt := &r.state
+	mt[0] = s
+	for i := 1; i < mtN; i++ {
+		mt[i] = 1812433253*(mt[i-1]^mt[i-1]>>30) + uint32(i)
+	}
+	r.index = mtN
+}
+
+func (r *random) initByArray(key []uint32) {
+	mt := &r.state
+	r.initGenrand(19650218)
+	i, j := 1, 0
+	k := len(key)
+	if mtN > k {
+		k = mtN
+	}
+	for ; k > 0; k-- {
+		mt[i] = (mt[i] ^ (mt[i-1]^mt[i-1]>>30)*1664525) + key[j] + uint32(j)
+		i++
+		j++
+		if i >= mtN {
+			mt[0] = mt[mtN-1]
+			i = 1
+		}
+		if j >= len(key) {
+			j = 0
+		}
+	}
+	for k = mtN - 1; k > 0; k-- {
+		mt[i] = (mt[i] ^ (mt[i-1]^mt[i-1]>>30)*1566083941) - uint32(i)
+		i++
+		if i >= mtN {
+			mt[0] = mt[mtN-1]
+			i = 1
+		}
+	}
+	// MSB is 1, assuring a non-zero initial array.
+	mt[0] = 0x80000000
+}
+
+// seed initializes the generator from the absolute value of n, which is
+// split into 32 bit words, least significant first.
+func (r *random) seed(n *big.Int) {
+	words := n.Bits()
+	var key []uint32
+	for _, w := range words {
+		for b := 0; b < bits.UintSize; b += 32 {
+			key = append(key, uint32(uint64(w)>>uint(b)))
+		}
+	}
+	for len(key) > 1 && key[len(key)-1] == 0 {
+		key = key[:len(key)-1]
+	}
+	if len(key) == 0 {
+		key = []uint32{0}
+	}
+	r.mutex.Lock()
+	r.initByArray(key)
+	r.mutex.Unlock()
+}
+
+// randomSeedObject seeds r from o the way random.seed does. Ints and longs
+// are used directly, None uses the current time and anything else is hashed.
+func randomSeedObject(f *Frame, r *random, o *Object) *BaseException {
+	n := new(big.Int)
+	switch {
+	case o == None:
+		n.SetInt64(time.Now().UnixNano())
+	case o.isInstance(IntType):
+		n.SetInt64(int64(toIntUnsafe(o).Value()))
+	case o.isInstance(LongType):
+		n.Set(toLongUnsafe(o).Value())
+	default:
+		h, raised := Hash(f, o)
+		if raised != nil {
+			return raised
+		}
+		// Treat the hash as unsigned like CPython does.
+		n.SetUint64(uint64(h.Value()))
+	}
+	r.seed(n.Abs(n))
+	return nil
+}
+
+func randomGetrandbits(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getrandbits", args, randomType, IntType); raised != nil {
+		return nil, raised
+	}
+	r := toRandomUnsafe(args[0])
+	k := toIntUnsafe(args[1]).Value()
+	if k <= 0 {
+		return nil, f.RaiseType(ValueErrorType, "number of bits must be greater than zero")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if k <= 32 {
+		return NewInt(int(r.genrand() >> uint(32-k))).ToObject(), nil
+	}
+	// Fill the result 32 bits at a time, least significant word first,
+	// dropping the excess bits from the final word.
+	words := make([]big.Word, 0, (k+31)/32)
+	var acc uint64
+	shift := uint(0)
+	for ; k > 0; k -= 32 {
+		w := r.genrand()
+		if k < 32 {
+			w >>= uint(32 - k)
+		}
+		acc |= uint64(w) << shift
+		shift += 32
+		if shift == bits.UintSize {
+			words = append(words, big.Word(acc))
+			acc, shift = 0, 0
+		}
+	}
+	if shift != 0 {
+		words = append(words, big.Word(acc))
+	}
+	return NewLong(new(big.Int).SetBits(words)).ToObject(), nil
+}
+
+func randomGetstate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getstate", args, randomType); raised != nil {
+		return nil, raised
+	}
+	r := toRandomUnsafe(args[0])
+	elems := make([]*Object, mtN+1)
+	r.mutex.Lock()
+	for i, v := range r.state {
+		elems[i] = NewLong(big.NewInt(int64(v))).ToObject()
+	}
+	elems[mtN] = NewLong(big.NewInt(int64(r.index))).ToObject()
+	r.mutex.Unlock()
+	return NewTuple(elems...).ToObject(), nil
+}
+
+// randomJumpahead implements Random.jumpahead(n) which permutes the state
+// based on n so that generators seeded alike can produce distinct streams.
+func randomJumpahead(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "jumpahead", args, randomType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	r, o := toRandomUnsafe(args[0]), args[1]
+	var n *big.Int
+	if o.isInstance(IntType) {
+		n = big.NewInt(int64(toIntUnsafe(o).Value()))
+	} else if o.isInstance(LongType) {
+		n = toLongUnsafe(o).Value()
+	} else {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("jumpahead requires an integer, not '%s'", o.typ.Name()))
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	mt := &r.state
+	rem := new(big.Int)
+	for i := mtN - 1; i > 1; i-- {
+		j := int(rem.Mod(n, big.NewInt(int64(i))).Int64())
+		mt[i], mt[j] = mt[j], mt[i]
+	}
+	nonzero := uint32(0)
+	for i := 1; i < mtN; i++ {
+		mt[i] += uint32(i + 1)
+		nonzero |= mt[i]
+	}
+	// Ensure the state is nonzero.
+	if nonzero != 0 {
+		mt[0]++
+	} else {
+		mt[0] = 0x80000000
+	}
+	r.index = mtN
+	return None, nil
+}
+
+func randomNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	if t == randomType && len(kwargs) > 0 {
+		return nil, f.RaiseType(TypeErrorType, "Random() does not take keyword arguments")
+	}
+	r := toRandomUnsafe(newObject(t))
+	if _, raised := randomSeed(f, append(Args{r.ToObject()}, args...), nil); raised != nil {
+		return nil, raised
+	}
+	return r.ToObject(), nil
+}
+
+// randomRandom implements Random.random() which returns a float in the
+// interval [0.0, 1.0) with 53 bits of randomness.
+func randomRandom(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "random", args, randomType); raised != nil {
+		return nil, raised
+	}
+	r := toRandomUnsafe(args[0])
+	r.mutex.Lock()
+	a, b := r.genrand()>>5, r.genrand()>>6
+	r.mutex.Unlock()
+	return NewFloat((float64(a)*67108864.0 + float64(b)) * (1.0 / 9007199254740992.0)).ToObject(), nil
+}
+
+func randomSeed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodVarArgs(f, "seed", args, randomType); raised != nil {
+		return nil, raised
+	}
+	if argc := len(args) - 1; argc > 1 {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("seed expected at most 1 arguments, got %d", argc))
+	}
+	o := None
+	if len(args) > 1 {
+		o = args[1]
+	}
+	if raised := randomSeedObject(f, toRandomUnsafe(args[0]), o); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func randomSetstate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "setstate", args, randomType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	r, o := toRandomUnsafe(args[0]), args[1]
+	if !o.isInstance(TupleType) {
+		return nil, f.RaiseType(TypeErrorType, "state vector must be a tuple")
+	}
+	elems := toTupleUnsafe(o).elems
+	if len(elems) != mtN+1 {
+		return nil, f.RaiseType(ValueErrorType, "state vector is the wrong size")
+	}
+	var state [mtN]uint32
+	for i, elem := range elems[:mtN] {
+		v, raised := randomStateElem(f, elem)
+		if raised != nil {
+			return nil, raised
+		}
+		state[i] = uint32(v)
+	}
+	index, raised := ToIntValue(f, elems[mtN])
+	if raised != nil {
+		return nil, raised
+	}
+	if index < 0 || index > mtN {
+		return nil, f.RaiseType(ValueErrorType, "invalid state")
+	}
+	r.mutex.Lock()
+	r.state, r.index = state, index
+	r.mutex.Unlock()
+	return None, nil
+}
+
+// randomStateElem converts o to an unsigned long like CPython does for the
+// elements of the state passed to setstate.
+func randomStateElem(f *Frame, o *Object) (uint64, *BaseException) {
+	var n *big.Int
+	if o.isInstance(IntType) {
+		n = big.NewInt(int64(toIntUnsafe(o).Value()))
+	} else if o.isInstance(LongType) {
+		n = toLongUnsafe(o).Value()
+	} else {
+		return 0, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	if n.Sign() < 0 {
+		return 0, f.RaiseType(OverflowErrorType, "can't convert negative value to unsigned long")
+	}
+	if n.BitLen() > 64 {
+		return 0, f.RaiseType(OverflowErrorType, "long int too large to convert")
+	}
+	return n.Uint64(), nil
+}
+
+func initRandomType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_random").ToObject()
+	dict["getrandbits"] = newBuiltinFunction("getrandbits", randomGetrandbits).ToObject()
+	dict["getstate"] = newBuiltinFunction("getstate", randomGetstate).ToObject()
+	dict["jumpahead"] = newBuiltinFunction("jumpahead", randomJumpahead).ToObject()
+	dict["random"] = newBuiltinFunction("random", randomRandom).ToObject()
+	dict["seed"] = newBuiltinFunction("seed", randomSeed).ToObject()
+	dict["setstate"] = newBuiltinFunction("setstate", randomSetstate).ToObject()
+	randomType.slots.New = &newSlot{randomNew}
+}
+
+func init() {
+	RegisterModule("_random", NewCode("<module>", "_random", nil, 0, func(f *Frame, _ []*Object) (*Object, *BaseException) {
+		return nil, f.Globals().SetItemString(f, "Random", randomType.ToObject())
+	}))
+}
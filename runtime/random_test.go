@@ -0,0 +1,107 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRandomGenrand(t *testing.T) {
+	// Expected values are from mt19937ar.out, the reference output for
+	// init_by_array({0x123, 0x234, 0x345, 0x456}).
+	r := &random{}
+	r.initByArray([]uint32{0x123, 0x234, 0x345, 0x456})
+	for i, want := range []uint32{1067595299, 955945823, 477289528, 4107218783, 4228976476} {
+		if got := r.genrand(); got != want {
+			t.Errorf("genrand() #%d = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestRandomRandom(t *testing.T) {
+	bigSeed := new(big.Int).Lsh(big.NewInt(1), 64)
+	bigSeed.Add(bigSeed, big.NewInt(1))
+	cases := []invokeTestCase{
+		{args: wrapArgs(42), want: NewFloat(0.6394267984578837).ToObject()},
+		{args: wrapArgs(-42), want: NewFloat(0.6394267984578837).ToObject()},
+		{args: wrapArgs(bigSeed), want: NewFloat(0.10175875467846374).ToObject()},
+		{args: wrapArgs("hello"), want: NewFloat(0.8180391270568783).ToObject()},
+	}
+	fun := wrapFuncForTest(func(f *Frame, seed *Object) (*Object, *BaseException) {
+		r, raised := randomType.Call(f, Args{seed}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return randomRandom(f, Args{r}, nil)
+	})
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRandomGetrandbits(t *testing.T) {
+	want, _ := new(big.Int).SetString("14668732198480837564", 10)
+	fun := wrapFuncForTest(func(f *Frame, ks ...int) (*Object, *BaseException) {
+		r, raised := randomType.Call(f, wrapArgs(5), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		var results []*Object
+		for _, k := range ks {
+			o, raised := randomGetrandbits(f, wrapArgs(r, k), nil)
+			if raised != nil {
+				return nil, raised
+			}
+			results = append(results, o)
+		}
+		return NewTuple(results...).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(8, 33, 64), want: newTestTuple(159, big.NewInt(5392095289), want).ToObject()},
+		{args: wrapArgs(0), wantExc: mustCreateException(ValueErrorType, "number of bits must be greater than zero")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRandomSetstate(t *testing.T) {
+	f := NewRootFrame()
+	r := mustNotRaise(randomType.Call(f, wrapArgs(0), nil))
+	state := mustNotRaise(randomGetstate(f, Args{r}, nil))
+	elems := toTupleUnsafe(state).elems
+	badIndex := NewTuple(append(append([]*Object{}, elems[:mtN]...), NewInt(mtN+1).ToObject())...)
+	negative := NewTuple(append([]*Object{NewInt(-1).ToObject()}, elems[1:]...)...)
+	cases := []invokeTestCase{
+		{args: wrapArgs(r, state), want: None},
+		{args: wrapArgs(r, NewList()), wantExc: mustCreateException(TypeErrorType, "state vector must be a tuple")},
+		{args: wrapArgs(r, newTestTuple(1, 2, 3)), wantExc: mustCreateException(ValueErrorType, "state vector is the wrong size")},
+		{args: wrapArgs(r, badIndex), wantExc: mustCreateException(ValueErrorType, "invalid state")},
+		{args: wrapArgs(r, negative), wantExc: mustCreateException(OverflowErrorType, "can't convert negative value to unsigned long")},
+	}
+	fun := wrapFuncForTest(func(f *Frame, r, state *Object) (*Object, *BaseException) {
+		return randomSetstate(f, Args{r, state}, nil)
+	})
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,623 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Operations for epollCtl. These are the Linux values.
+const (
+	epollCtlAdd = 1
+	epollCtlDel = 2
+	epollCtlMod = 3
+)
+
+var (
+	// epollType corresponds to the Python type 'select.epoll'.
+	epollType = newBasisType("epoll", reflect.TypeOf(epoll{}), toEpollUnsafe, ObjectType)
+	// pollType corresponds to the Python type of the objects returned by
+	// select.poll().
+	pollType = newBasisType("poll", reflect.TypeOf(poll{}), toPollUnsafe, ObjectType)
+	// selectErrorType corresponds to the Python type 'select.error'.
+	selectErrorType = newSimpleType("error", ExceptionType)
+)
+
+// selectPollFd mirrors struct pollfd from poll.h.
+type selectPollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// epollEvent is a file descriptor and the events reported for it by
+// epollWait.
+type epollEvent struct {
+	fd     int
+	events uint32
+}
+
+// poll represents the objects returned by select.poll().
+type poll struct {
+	Object
+	mutex sync.Mutex
+	// fds maps each registered file descriptor to its event mask.
+	fds map[int]int16
+}
+
+func toPollUnsafe(o *Object) *poll {
+	return (*poll)(o.toPointer())
+}
+
+// ToObject upcasts p to an Object.
+func (p *poll) ToObject() *Object {
+	return &p.Object
+}
+
+// epoll represents Python 'select.epoll' objects.
+type epoll struct {
+	Object
+	mutex sync.Mutex
+	// epfd is the epoll file descriptor or -1 once closed.
+	epfd int
+}
+
+func toEpollUnsafe(o *Object) *epoll {
+	return (*epoll)(o.toPointer())
+}
+
+// ToObject upcasts e to an Object.
+func (e *epoll) ToObject() *Object {
+	return &e.Object
+}
+
+func init() {
+	RegisterModule("_select", NewCode("<module>", "_select", nil, 0, selectInit))
+}
+
+func selectInit(f *Frame, _ []*Object) (*Object, *BaseException) {
+	globals := map[string]*Object{
+		"error":         selectErrorType.ToObject(),
+		"poll":          newBuiltinFunction("poll", selectPoll).ToObject(),
+		"wait_readable": newBuiltinFunction("wait_readable", selectWaitFunc("wait_readable", false)).ToObject(),
+		"wait_writable": newBuiltinFunction("wait_writable", selectWaitFunc("wait_writable", true)).ToObject(),
+	}
+	if selectHasEpoll {
+		globals["epoll"] = epollType.ToObject()
+	}
+	for name, value := range globals {
+		if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+			return nil, raised
+		}
+	}
+	for name, value := range selectConstants {
+		if raised := f.Globals().SetItemString(f, name, NewInt(value).ToObject()); raised != nil {
+			return nil, raised
+		}
+	}
+	return nil, nil
+}
+
+// selectFileno returns the file descriptor for o, which is either an int or
+// an object with a fileno method that returns an int.
+func selectFileno(f *Frame, o *Object) (int, *BaseException) {
+	if !o.isInstance(IntType) {
+		fileno, raised := GetAttr(f, o, NewStr("fileno"), None)
+		if raised != nil {
+			return 0, raised
+		}
+		if fileno == None {
+			return 0, f.RaiseType(TypeErrorType, "argument must be an int, or have a fileno() method.")
+		}
+		if o, raised = fileno.Call(f, nil, nil); raised != nil {
+			return 0, raised
+		}
+		if !o.isInstance(IntType) {
+			return 0, f.RaiseType(TypeErrorType, "fileno() returned a non-integer")
+		}
+	}
+	fd := toIntUnsafe(o).Value()
+	if fd < 0 {
+		return 0, f.RaiseType(ValueErrorType, fmt.Sprintf("file descriptor cannot be a negative integer (%d)", fd))
+	}
+	return fd, nil
+}
+
+// selectTimeout converts a Python timeout to a time.Duration given the unit
+// of o. None and negative values yield -1, meaning no timeout.
+func selectTimeout(f *Frame, o *Object, unit time.Duration) (time.Duration, *BaseException) {
+	if o == None {
+		return -1, nil
+	}
+	timeout, ok := floatCoerce(o)
+	if !ok {
+		return 0, f.RaiseType(TypeErrorType, fmt.Sprintf("timeout must be a number or None, not %s", o.typ.Name()))
+	}
+	if timeout < 0 {
+		return -1, nil
+	}
+	return time.Duration(math.Min(timeout*float64(unit), math.MaxInt64)), nil
+}
+
+// selectRetry calls fn until it returns an error other than EINTR, passing
+// it the time remaining until timeout elapses. A negative timeout is passed
+// through unchanged. The Go runtime interrupts system calls with signals
+// regularly so blocking calls must always be retried.
+func selectRetry(timeout time.Duration, fn func(timeout time.Duration) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := fn(timeout); err != syscall.EINTR {
+			return err
+		}
+		if timeout >= 0 {
+			if timeout = time.Until(deadline); timeout < 0 {
+				timeout = 0
+			}
+		}
+	}
+}
+
+// selectPoll implements select.poll().
+func selectPoll(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "poll", args); raised != nil {
+		return nil, raised
+	}
+	return (&poll{Object: Object{typ: pollType}, fds: map[int]int16{}}).ToObject(), nil
+}
+
+func pollRegister(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{pollType, ObjectType, IntType}
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "register", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	fd, raised := selectFileno(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	events := selectPollIn | selectPollPri | selectPollOut
+	if argc > 2 {
+		events = toIntUnsafe(args[2]).Value()
+	}
+	p := toPollUnsafe(args[0])
+	p.mutex.Lock()
+	p.fds[fd] = int16(events)
+	p.mutex.Unlock()
+	return None, nil
+}
+
+func pollModify(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "modify", args, pollType, ObjectType, IntType); raised != nil {
+		return nil, raised
+	}
+	fd, raised := selectFileno(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	p := toPollUnsafe(args[0])
+	p.mutex.Lock()
+	_, ok := p.fds[fd]
+	if ok {
+		p.fds[fd] = int16(toIntUnsafe(args[2]).Value())
+	}
+	p.mutex.Unlock()
+	if !ok {
+		return nil, raiseEnvironmentError(f, IOErrorType, syscall.ENOENT)
+	}
+	return None, nil
+}
+
+func pollUnregister(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "unregister", args, pollType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	fd, raised := selectFileno(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	p := toPollUnsafe(args[0])
+	p.mutex.Lock()
+	_, ok := p.fds[fd]
+	delete(p.fds, fd)
+	p.mutex.Unlock()
+	if !ok {
+		return nil, raiseKeyError(f, NewInt(fd).ToObject())
+	}
+	return None, nil
+}
+
+// pollPoll implements poll.poll([timeout]) where timeout is in milliseconds.
+// The calling goroutine blocks in the poll system call, which does not
+// prevent other goroutines from running.
+func pollPoll(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{pollType, ObjectType}
+	if argc == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "poll", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	timeout := time.Duration(-1)
+	if argc > 1 {
+		var raised *BaseException
+		if timeout, raised = selectTimeout(f, args[1], time.Millisecond); raised != nil {
+			return nil, raised
+		}
+	}
+	p := toPollUnsafe(args[0])
+	p.mutex.Lock()
+	fds := make([]selectPollFd, 0, len(p.fds))
+	for fd, events := range p.fds {
+		fds = append(fds, selectPollFd{fd: int32(fd), events: events})
+	}
+	p.mutex.Unlock()
+	sort.Slice(fds, func(i, j int) bool { return fds[i].fd < fds[j].fd })
+	err := selectRetry(timeout, func(timeout time.Duration) error {
+		_, err := selectPollFds(fds, timeout)
+		return err
+	})
+	if err != nil {
+		return nil, raiseEnvironmentError(f, selectErrorType, err)
+	}
+	var result []*Object
+	for _, pfd := range fds {
+		if pfd.revents != 0 {
+			result = append(result, NewTuple2(NewInt(int(pfd.fd)).ToObject(), NewInt(int(pfd.revents)).ToObject()).ToObject())
+		}
+	}
+	return NewList(result...).ToObject(), nil
+}
+
+func initPollType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("select").ToObject()
+	dict["modify"] = newBuiltinFunction("modify", pollModify).ToObject()
+	dict["poll"] = newBuiltinFunction("poll", pollPoll).ToObject()
+	dict["register"] = newBuiltinFunction("register", pollRegister).ToObject()
+	dict["unregister"] = newBuiltinFunction("unregister", pollUnregister).ToObject()
+	pollType.flags &^= typeFlagBasetype | typeFlagInstantiable
+}
+
+// epollNew implements epoll([sizehint[, flags]]). Both arguments are
+// accepted for compatibility and ignored.
+func epollNew(f *Frame, t *Type, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{IntType, IntType}
+	if argc := len(args); argc < 2 {
+		expectedTypes = expectedTypes[:argc]
+	}
+	if raised := checkFunctionArgs(f, "__new__", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	epfd, err := epollCreate()
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	return newEpoll(t, epfd).ToObject(), nil
+}
+
+func newEpoll(t *Type, epfd int) *epoll {
+	e := toEpollUnsafe(newObject(t))
+	e.epfd = epfd
+	return e
+}
+
+// fd returns e's descriptor, raising ValueError if e is closed.
+func (e *epoll) fd(f *Frame) (int, *BaseException) {
+	e.mutex.Lock()
+	epfd := e.epfd
+	e.mutex.Unlock()
+	if epfd < 0 {
+		return 0, f.RaiseType(ValueErrorType, "I/O operation on closed epoll fd")
+	}
+	return epfd, nil
+}
+
+func epollClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, epollType); raised != nil {
+		return nil, raised
+	}
+	e := toEpollUnsafe(args[0])
+	e.mutex.Lock()
+	epfd := e.epfd
+	e.epfd = -1
+	e.mutex.Unlock()
+	if epfd >= 0 {
+		if err := syscall.Close(epfd); err != nil {
+			return nil, raiseEnvironmentError(f, IOErrorType, err)
+		}
+	}
+	return None, nil
+}
+
+func epollGetClosed(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_closed", args, epollType); raised != nil {
+		return nil, raised
+	}
+	e := toEpollUnsafe(args[0])
+	e.mutex.Lock()
+	closed := e.epfd < 0
+	e.mutex.Unlock()
+	return GetBool(closed).ToObject(), nil
+}
+
+func epollFileno(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fileno", args, epollType); raised != nil {
+		return nil, raised
+	}
+	epfd, raised := toEpollUnsafe(args[0]).fd(f)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(epfd).ToObject(), nil
+}
+
+// epollFromfd implements the classmethod epoll.fromfd(fd). The new object
+// takes ownership of fd.
+func epollFromfd(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "fromfd", args, TypeType, IntType); raised != nil {
+		return nil, raised
+	}
+	t := toTypeUnsafe(args[0])
+	if !t.isSubclass(epollType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("fromfd() requires a subtype of epoll, not %s", t.Name()))
+	}
+	return newEpoll(t, toIntUnsafe(args[1]).Value()).ToObject(), nil
+}
+
+func epollCtlFunc(name string, op int) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		argc := len(args)
+		expectedTypes := []*Type{epollType, ObjectType, IntType}
+		if op == epollCtlDel || (op == epollCtlAdd && argc == 2) {
+			expectedTypes = expectedTypes[:2]
+		}
+		if raised := checkMethodArgs(f, name, args, expectedTypes...); raised != nil {
+			return nil, raised
+		}
+		epfd, raised := toEpollUnsafe(args[0]).fd(f)
+		if raised != nil {
+			return nil, raised
+		}
+		fd, raised := selectFileno(f, args[1])
+		if raised != nil {
+			return nil, raised
+		}
+		events := uint32(selectConstants["EPOLLIN"] | selectConstants["EPOLLPRI"] | selectConstants["EPOLLOUT"])
+		if argc > 2 {
+			events = uint32(toIntUnsafe(args[2]).Value())
+		}
+		if err := epollCtl(epfd, op, fd, events); err != nil {
+			return nil, raiseEnvironmentError(f, IOErrorType, err)
+		}
+		return None, nil
+	}
+}
+
+// epollPoll implements epoll.poll([timeout[, maxevents]]) where timeout is in
+// seconds.
+func epollPoll(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{epollType, ObjectType, IntType}
+	if argc < 3 {
+		expectedTypes = expectedTypes[:argc]
+	}
+	if raised := checkMethodArgs(f, "poll", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	epfd, raised := toEpollUnsafe(args[0]).fd(f)
+	if raised != nil {
+		return nil, raised
+	}
+	timeout := time.Duration(-1)
+	if argc > 1 {
+		if timeout, raised = selectTimeout(f, args[1], time.Second); raised != nil {
+			return nil, raised
+		}
+	}
+	maxEvents := syscall.FD_SETSIZE - 1
+	if argc > 2 {
+		if n := toIntUnsafe(args[2]).Value(); n != -1 {
+			if n <= 0 {
+				return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("maxevents must be greater than 0, got %d", n))
+			}
+			maxEvents = n
+		}
+	}
+	var events []epollEvent
+	err := selectRetry(timeout, func(timeout time.Duration) (err error) {
+		events, err = epollWait(epfd, maxEvents, timeout)
+		return err
+	})
+	if err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, err)
+	}
+	result := make([]*Object, len(events))
+	for i, event := range events {
+		result[i] = NewTuple2(NewInt(event.fd).ToObject(), NewInt(int(event.events)).ToObject()).ToObject()
+	}
+	return NewList(result...).ToObject(), nil
+}
+
+func initEpollType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("select").ToObject()
+	dict["close"] = newBuiltinFunction("close", epollClose).ToObject()
+	dict["closed"] = newProperty(newBuiltinFunction("_get_closed", epollGetClosed).ToObject(), nil, nil).ToObject()
+	dict["fileno"] = newBuiltinFunction("fileno", epollFileno).ToObject()
+	dict["fromfd"] = newClassMethod(newBuiltinFunction("fromfd", epollFromfd).ToObject()).ToObject()
+	dict["modify"] = newBuiltinFunction("modify", epollCtlFunc("modify", epollCtlMod)).ToObject()
+	dict["poll"] = newBuiltinFunction("poll", epollPoll).ToObject()
+	dict["register"] = newBuiltinFunction("register", epollCtlFunc("register", epollCtlAdd)).ToObject()
+	dict["unregister"] = newBuiltinFunction("unregister", epollCtlFunc("unregister", epollCtlDel)).ToObject()
+	epollType.slots.New = &newSlot{epollNew}
+}
+
+func initSelectErrorType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("select").ToObject()
+}
+
+// selectWaitFunc returns the implementation of wait_readable(obj[, timeout])
+// or wait_writable(obj[, timeout]). They wait until obj, which is a socket,
+// a file or anything with a fileno method, is ready for reading or writing
+// and return True, or return False if timeout seconds elapse first. Sockets
+// and files backed by pipes wait in Go's network poller and so do not tie
+// up an OS thread. For sockets this replaces any pending read or write
+// deadline.
+func selectWaitFunc(name string, write bool) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		argc := len(args)
+		expectedTypes := []*Type{ObjectType, ObjectType}
+		if argc == 1 {
+			expectedTypes = expectedTypes[:1]
+		}
+		if raised := checkFunctionArgs(f, name, args, expectedTypes...); raised != nil {
+			return nil, raised
+		}
+		timeout := time.Duration(-1)
+		if argc > 1 {
+			var raised *BaseException
+			if timeout, raised = selectTimeout(f, args[1], time.Second); raised != nil {
+				return nil, raised
+			}
+		}
+		var ready bool
+		var err error
+		switch o := args[0]; {
+		case o.isInstance(socketType):
+			s := toSocketUnsafe(o)
+			file, _ := s.state()
+			if file == nil {
+				return nil, socketRaise(f, syscall.EBADF)
+			}
+			if ready, err = selectWaitFile(file, write, timeout); err != nil {
+				if file, _ := s.state(); file == nil {
+					err = syscall.EBADF
+				}
+				return nil, socketRaise(f, err)
+			}
+		case o.isInstance(FileType):
+			file := toFileUnsafe(o)
+			file.mutex.Lock()
+			osFile, open := file.file, file.open
+			buffered := open && file.reader != nil && file.reader.Buffered() > 0
+			file.mutex.Unlock()
+			if !open {
+				return nil, f.RaiseType(ValueErrorType, "I/O operation on closed file")
+			}
+			// Data already read into the file's buffer is available
+			// regardless of the state of the descriptor.
+			if ready = !write && buffered; !ready {
+				if ready, err = selectWaitFile(osFile, write, timeout); err != nil {
+					return nil, raiseEnvironmentError(f, IOErrorType, err)
+				}
+			}
+		default:
+			fd, raised := selectFileno(f, o)
+			if raised != nil {
+				return nil, raised
+			}
+			if ready, err = selectWaitFd(fd, write, timeout); err != nil {
+				return nil, raiseEnvironmentError(f, selectErrorType, err)
+			}
+		}
+		return GetBool(ready).ToObject(), nil
+	}
+}
+
+// selectWaitFile waits for file to become readable or writable. Files that
+// are not pollable, such as regular files, fall back to poll(2).
+func selectWaitFile(file *os.File, write bool, timeout time.Duration) (bool, error) {
+	rc, err := file.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+	ready := false
+	check := func(fd uintptr) bool {
+		var err error
+		ready, err = selectWaitFd(int(fd), write, 0)
+		// Errors are left to be reported by the I/O that follows.
+		return ready || err != nil
+	}
+	if timeout == 0 {
+		// A deadline in the past would fail before checking the
+		// descriptor.
+		err = rc.Control(func(fd uintptr) { check(fd) })
+		return ready, err
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if write {
+		err = file.SetWriteDeadline(deadline)
+	} else {
+		err = file.SetReadDeadline(deadline)
+	}
+	if errors.Is(err, os.ErrNoDeadline) {
+		var waitErr error
+		err = rc.Control(func(fd uintptr) { ready, waitErr = selectWaitFd(int(fd), write, timeout) })
+		if err == nil {
+			err = waitErr
+		}
+		return ready, err
+	}
+	if err != nil {
+		return false, err
+	}
+	// The descriptor is checked before each wait in the poller so that
+	// readiness that was signaled before the call is not missed.
+	if write {
+		err = rc.Write(check)
+	} else {
+		err = rc.Read(check)
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// selectWaitFd waits for fd to become readable or writable using poll(2).
+// Errors and hangups count as ready since the I/O that follows will not
+// block.
+func selectWaitFd(fd int, write bool, timeout time.Duration) (bool, error) {
+	events := int16(selectPollIn)
+	if write {
+		events = selectPollOut
+	}
+	fds := []selectPollFd{{fd: int32(fd), events: events}}
+	var n int
+	err := selectRetry(timeout, func(timeout time.Duration) (err error) {
+		n, err = selectPollFds(fds, timeout)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if n > 0 && fds[0].revents&selectPollNval != 0 {
+		return false, syscall.EBADF
+	}
+	return n > 0, nil
+}
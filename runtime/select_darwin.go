@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Event flags for poll(2) from Darwin's poll.h.
+const (
+	selectPollIn     = 0x1
+	selectPollPri    = 0x2
+	selectPollOut    = 0x4
+	selectPollErr    = 0x8
+	selectPollHup    = 0x10
+	selectPollNval   = 0x20
+	selectPollRdNorm = 0x40
+	selectPollRdBand = 0x80
+	selectPollWrNorm = selectPollOut
+	selectPollWrBand = 0x100
+)
+
+// selectHasEpoll is false because epoll is Linux specific.
+const selectHasEpoll = false
+
+var selectConstants = map[string]int{
+	"POLLERR":    selectPollErr,
+	"POLLHUP":    selectPollHup,
+	"POLLIN":     selectPollIn,
+	"POLLNVAL":   selectPollNval,
+	"POLLOUT":    selectPollOut,
+	"POLLPRI":    selectPollPri,
+	"POLLRDBAND": selectPollRdBand,
+	"POLLRDNORM": selectPollRdNorm,
+	"POLLWRBAND": selectPollWrBand,
+	"POLLWRNORM": selectPollWrNorm,
+}
+
+// selectPollFds calls poll(2) on fds. A negative timeout blocks
+// indefinitely.
+func selectPollFds(fds []selectPollFd, timeout time.Duration) (int, error) {
+	msec := -1
+	if timeout >= 0 {
+		msec = int((timeout + time.Millisecond - 1) / time.Millisecond)
+	}
+	var p unsafe.Pointer
+	if len(fds) > 0 {
+		p = unsafe.Pointer(&fds[0])
+	}
+	n, _, errno := syscall.Syscall(syscall.SYS_POLL, uintptr(p), uintptr(len(fds)), uintptr(msec))
+	runtime.KeepAlive(fds)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func epollCreate() (int, error) {
+	return -1, syscall.ENOSYS
+}
+
+func epollCtl(epfd, op, fd int, events uint32) error {
+	return syscall.ENOSYS
+}
+
+func epollWait(epfd, maxEvents int, timeout time.Duration) ([]epollEvent, error) {
+	return nil, syscall.ENOSYS
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Event flags for poll(2) from Linux's poll.h.
+const (
+	selectPollIn     = 0x1
+	selectPollPri    = 0x2
+	selectPollOut    = 0x4
+	selectPollErr    = 0x8
+	selectPollHup    = 0x10
+	selectPollNval   = 0x20
+	selectPollRdNorm = 0x40
+	selectPollRdBand = 0x80
+	selectPollWrNorm = 0x100
+	selectPollWrBand = 0x200
+	selectPollMsg    = 0x400
+)
+
+const selectHasEpoll = true
+
+var selectConstants = map[string]int{
+	"EPOLLERR":     syscall.EPOLLERR,
+	"EPOLLET":      1 << 31, // syscall.EPOLLET is a negative int32.
+	"EPOLLHUP":     syscall.EPOLLHUP,
+	"EPOLLIN":      syscall.EPOLLIN,
+	"EPOLLMSG":     syscall.EPOLLMSG,
+	"EPOLLONESHOT": syscall.EPOLLONESHOT,
+	"EPOLLOUT":     syscall.EPOLLOUT,
+	"EPOLLPRI":     syscall.EPOLLPRI,
+	"EPOLLRDBAND":  syscall.EPOLLRDBAND,
+	"EPOLLRDHUP":   syscall.EPOLLRDHUP,
+	"EPOLLRDNORM":  syscall.EPOLLRDNORM,
+	"EPOLLWRBAND":  syscall.EPOLLWRBAND,
+	"EPOLLWRNORM":  syscall.EPOLLWRNORM,
+	"POLLERR":      selectPollErr,
+	"POLLHUP":      selectPollHup,
+	"POLLIN":       selectPollIn,
+	"POLLMSG":      selectPollMsg,
+	"POLLNVAL":     selectPollNval,
+	"POLLOUT":      selectPollOut,
+	"POLLPRI":      selectPollPri,
+	"POLLRDBAND":   selectPollRdBand,
+	"POLLRDNORM":   selectPollRdNorm,
+	"POLLWRBAND":   selectPollWrBand,
+	"POLLWRNORM":   selectPollWrNorm,
+}
+
+// selectPollFds calls poll(2) on fds. A negative timeout blocks
+// indefinitely. Not all Linux architectures provide the poll syscall so
+// ppoll is used instead.
+func selectPollFds(fds []selectPollFd, timeout time.Duration) (int, error) {
+	var ts *syscall.Timespec
+	if timeout >= 0 {
+		t := syscall.NsecToTimespec(int64(timeout))
+		ts = &t
+	}
+	var p unsafe.Pointer
+	if len(fds) > 0 {
+		p = unsafe.Pointer(&fds[0])
+	}
+	n, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(p), uintptr(len(fds)), uintptr(unsafe.Pointer(ts)), 0, 0, 0)
+	runtime.KeepAlive(fds)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func epollCreate() (int, error) {
+	return syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+}
+
+func epollCtl(epfd, op, fd int, events uint32) error {
+	return syscall.EpollCtl(epfd, op, fd, &syscall.EpollEvent{Events: events, Fd: int32(fd)})
+}
+
+// epollWait waits for up to maxEvents events on epfd. A negative timeout
+// blocks indefinitely.
+func epollWait(epfd, maxEvents int, timeout time.Duration) ([]epollEvent, error) {
+	msec := -1
+	if timeout >= 0 {
+		// Round up so that short timeouts do not become busy loops.
+		msec = int((timeout + time.Millisecond - 1) / time.Millisecond)
+	}
+	events := make([]syscall.EpollEvent, maxEvents)
+	n, err := syscall.EpollWait(epfd, events, msec)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]epollEvent, n)
+	for i, event := range events[:n] {
+		result[i] = epollEvent{int(event.Fd), event.Events}
+	}
+	return result, nil
+}
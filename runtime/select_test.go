@@ -0,0 +1,100 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSelectFileno(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"fileno": newBuiltinFunction("fileno", func(f *Frame, _ Args, _ KWArgs) (*Object, *BaseException) {
+			return NewInt(3).ToObject(), nil
+		}).ToObject(),
+	}))
+	barType := newTestClass("Bar", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"fileno": newBuiltinFunction("fileno", func(f *Frame, _ Args, _ KWArgs) (*Object, *BaseException) {
+			return NewStr("foo").ToObject(), nil
+		}).ToObject(),
+	}))
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (int, *BaseException) {
+		return selectFileno(f, o)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(5), want: NewInt(5).ToObject()},
+		{args: wrapArgs(newObject(fooType)), want: NewInt(3).ToObject()},
+		{args: wrapArgs(-1), wantExc: mustCreateException(ValueErrorType, "file descriptor cannot be a negative integer (-1)")},
+		{args: wrapArgs("foo"), wantExc: mustCreateException(TypeErrorType, "argument must be an int, or have a fileno() method.")},
+		{args: wrapArgs(newObject(barType)), wantExc: mustCreateException(TypeErrorType, "fileno() returned a non-integer")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSelectWaitFd(t *testing.T) {
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fds[0])
+	defer syscall.Close(fds[1])
+	start := time.Now()
+	if ready, err := selectWaitFd(fds[0], false, 10*time.Millisecond); ready || err != nil {
+		t.Errorf("selectWaitFd(%d, false, 10ms) = %v, %v, want false, nil", fds[0], ready, err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("selectWaitFd returned after %v, want at least 10ms", elapsed)
+	}
+	if ready, err := selectWaitFd(fds[1], true, -1); !ready || err != nil {
+		t.Errorf("selectWaitFd(%d, true, -1) = %v, %v, want true, nil", fds[1], ready, err)
+	}
+	syscall.Write(fds[1], []byte("foo"))
+	if ready, err := selectWaitFd(fds[0], false, -1); !ready || err != nil {
+		t.Errorf("selectWaitFd(%d, false, -1) = %v, %v, want true, nil", fds[0], ready, err)
+	}
+}
+
+func TestPollPoll(t *testing.T) {
+	f := NewRootFrame()
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(fds[0])
+	defer syscall.Close(fds[1])
+	p := mustNotRaise(selectPoll(f, nil, nil))
+	mustNotRaise(pollRegister(f, wrapArgs(p, fds[0], selectPollIn), nil))
+	mustNotRaise(pollRegister(f, wrapArgs(p, fds[1], selectPollOut), nil))
+	poll := mustNotRaise(GetAttr(f, pollType.ToObject(), NewStr("poll"), nil))
+	cases := []invokeTestCase{
+		{args: wrapArgs(p, 0), want: newTestList(newTestTuple(fds[1], selectPollOut)).ToObject()},
+		{args: wrapArgs(p, "foo"), wantExc: mustCreateException(TypeErrorType, "timeout must be a number or None, not str")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(poll, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	syscall.Write(fds[1], []byte("foo"))
+	cas := invokeTestCase{args: wrapArgs(p, None), want: newTestList(newTestTuple(fds[0], selectPollIn), newTestTuple(fds[1], selectPollOut)).ToObject()}
+	if err := runInvokeTestCase(poll, &cas); err != "" {
+		t.Error(err)
+	}
+}
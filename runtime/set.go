@@ -55,6 +55,17 @@ func (s *setBase) isSuperset(f *Frame, o *Object) (*Object, *BaseException) {
 	return setCompare(f, compareOpGE, s, &s2.Object)
 }
 
+func (s *setBase) reduce(f *Frame) (*Object, *BaseException) {
+	// Like CPython's set_reduce, only a non-empty instance dict is
+	// included as the state.
+	state := None
+	if d := s.Dict(); d != nil && d.Len() > 0 {
+		state = d.ToObject()
+	}
+	args := NewTuple1(s.dict.Keys(f).ToObject()).ToObject()
+	return NewTuple3(s.typ.ToObject(), args, state).ToObject(), nil
+}
+
 func (s *setBase) repr(f *Frame) (*Object, *BaseException) {
 	if f.reprEnter(&s.Object) {
 		return NewStr(fmt.Sprintf("%s(...)", s.typ.Name())).ToObject(), nil
@@ -209,6 +220,13 @@ func setNew(f *Frame, t *Type, _ Args, _ KWArgs) (*Object, *BaseException) {
 	return s.ToObject(), nil
 }
 
+func setReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, SetType); raised != nil {
+		return nil, raised
+	}
+	return (*setBase)(toSetUnsafe(args[0])).reduce(f)
+}
+
 func setRemove(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "remove", args, SetType, ObjectType); raised != nil {
 		return nil, raised
@@ -237,6 +255,7 @@ func setUpdate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 }
 
 func initSetType(dict map[string]*Object) {
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", setReduce).ToObject()
 	dict["add"] = newBuiltinFunction("add", setAdd).ToObject()
 	dict["discard"] = newBuiltinFunction("discard", setDiscard).ToObject()
 	dict["issubset"] = newBuiltinFunction("issubset", setIsSubset).ToObject()
@@ -352,11 +371,19 @@ func frozenSetNew(f *Frame, t *Type, args Args, _ KWArgs) (*Object, *BaseExcepti
 	return s.ToObject(), nil
 }
 
+func frozenSetReduce(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__reduce__", args, FrozenSetType); raised != nil {
+		return nil, raised
+	}
+	return (*setBase)(toFrozenSetUnsafe(args[0])).reduce(f)
+}
+
 func frozenSetRepr(f *Frame, o *Object) (*Object, *BaseException) {
 	return (*setBase)(toFrozenSetUnsafe(o)).repr(f)
 }
 
 func initFrozenSetType(dict map[string]*Object) {
+	dict["__reduce__"] = newBuiltinFunction("__reduce__", frozenSetReduce).ToObject()
 	dict["issubset"] = newBuiltinFunction("issubset", frozenSetIsSubset).ToObject()
 	dict["issuperset"] = newBuiltinFunction("issuperset", frozenSetIsSuperset).ToObject()
 	FrozenSetType.slots.Contains = &binaryOpSlot{frozenSetContains}
@@ -229,6 +229,30 @@ func TestSetNewInit(t *testing.T) {
 	}
 }
 
+func TestSetReduce(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{SetType}, NewDict())
+	withAttr := mustNotRaise(fooType.Call(NewRootFrame(), nil, nil))
+	mustNotRaise(nil, SetAttr(NewRootFrame(), withAttr, NewStr("bar"), NewInt(1).ToObject()))
+	fun := wrapFuncForTest(func(f *Frame, o *Object) (*Object, *BaseException) {
+		reduce, raised := GetAttr(f, o, NewStr("__reduce__"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return reduce.Call(f, nil, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestSet("foo")), want: newTestTuple(SetType, newTestTuple(newTestList("foo")), None).ToObject()},
+		{args: wrapArgs(newTestFrozenSet()), want: newTestTuple(FrozenSetType, newTestTuple(NewList()), None).ToObject()},
+		{args: wrapArgs(mustNotRaise(fooType.Call(NewRootFrame(), nil, nil))), want: newTestTuple(fooType, newTestTuple(NewList()), None).ToObject()},
+		{args: wrapArgs(withAttr), want: newTestTuple(fooType, newTestTuple(NewList()), newTestDict("bar", 1)).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestSetRemove(t *testing.T) {
 	fun := wrapFuncForTest(func(f *Frame, s *Set, args ...*Object) (*Object, *BaseException) {
 		remove, raised := GetAttr(f, s.ToObject(), NewStr("remove"), nil)
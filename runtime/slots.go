@@ -24,6 +24,12 @@ var (
 	slotsType = reflect.TypeOf(typeSlots{})
 	numSlots  = slotsType.NumField()
 	slotNames = calcSlotNames()
+	// slotIndexes maps special method names like __eq__ to the index of
+	// the corresponding field in typeSlots.
+	slotIndexes = calcSlotIndexes()
+	// slotOverridable records for each field in typeSlots whether its
+	// slot type implements overridableSlot.
+	slotOverridable = calcSlotOverridable()
 )
 
 type slot interface {
@@ -31,10 +37,17 @@ type slot interface {
 	// the receiving slot with the given slotName. It is used to populate
 	// t's type dictionary so that slots are accessible from Python.
 	makeCallable(t *Type, slotName string) *Object
+}
+
+// overridableSlot is a slot that a user defined type can override by
+// defining the corresponding special method in Python. Slots like __basis__
+// and __native__ don't implement it.
+type overridableSlot interface {
+	slot
 	// wrapCallable updates the receiver slot to forward its calls to the
 	// given callable. This method is called when a user defined type
 	// defines a slot method in Python to override the slot.
-	wrapCallable(callable *Object) bool
+	wrapCallable(callable *Object)
 }
 
 type basisSlot struct {
@@ -45,10 +58,6 @@ func (s *basisSlot) makeCallable(t *Type, slotName string) *Object {
 	return nil
 }
 
-func (s *basisSlot) wrapCallable(callable *Object) bool {
-	return false
-}
-
 type binaryOpFunc func(*Frame, *Object, *Object) (*Object, *BaseException)
 
 type binaryOpSlot struct {
@@ -64,11 +73,21 @@ func (s *binaryOpSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *binaryOpSlot) wrapCallable(callable *Object) bool {
+func (s *binaryOpSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, v, w *Object) (*Object, *BaseException) {
 		return callable.Call(f, Args{v, w}, nil)
 	}
-	return true
+}
+
+// bufferSlot exports the raw bytes of an object. Fn calls its callback with
+// a slice that is only valid for the duration of the call and that must not
+// be modified.
+type bufferSlot struct {
+	Fn func(*Frame, *Object, func([]byte) *BaseException) *BaseException
+}
+
+func (s *bufferSlot) makeCallable(t *Type, slotName string) *Object {
+	return nil
 }
 
 type callSlot struct {
@@ -84,14 +103,13 @@ func (s *callSlot) makeCallable(t *Type, _ string) *Object {
 	}).ToObject()
 }
 
-func (s *callSlot) wrapCallable(callable *Object) bool {
+func (s *callSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
 		callArgs := make(Args, len(args)+1)
 		callArgs[0] = o
 		copy(callArgs[1:], args)
 		return callable.Call(f, callArgs, kwargs)
 	}
-	return true
 }
 
 type delAttrSlot struct {
@@ -110,12 +128,11 @@ func (s *delAttrSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *delAttrSlot) wrapCallable(callable *Object) bool {
+func (s *delAttrSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, name *Str) *BaseException {
 		_, raised := callable.Call(f, Args{o, name.ToObject()}, nil)
 		return raised
 	}
-	return true
 }
 
 type deleteSlot struct {
@@ -134,12 +151,11 @@ func (s *deleteSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *deleteSlot) wrapCallable(callable *Object) bool {
+func (s *deleteSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, desc *Object, inst *Object) *BaseException {
 		_, raised := callable.Call(f, Args{desc, inst}, nil)
 		return raised
 	}
-	return true
 }
 
 type delItemSlot struct {
@@ -158,12 +174,11 @@ func (s *delItemSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *delItemSlot) wrapCallable(callable *Object) bool {
+func (s *delItemSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, key *Object) *BaseException {
 		_, raised := callable.Call(f, Args{o, key}, nil)
 		return raised
 	}
-	return true
 }
 
 type getAttributeSlot struct {
@@ -179,11 +194,10 @@ func (s *getAttributeSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *getAttributeSlot) wrapCallable(callable *Object) bool {
+func (s *getAttributeSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, name *Str) (*Object, *BaseException) {
 		return callable.Call(f, Args{o, name.ToObject()}, nil)
 	}
-	return true
 }
 
 type getSlot struct {
@@ -199,11 +213,10 @@ func (s *getSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *getSlot) wrapCallable(callable *Object) bool {
+func (s *getSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, desc, inst *Object, owner *Type) (*Object, *BaseException) {
 		return callable.Call(f, Args{desc, inst, owner.ToObject()}, nil)
 	}
-	return true
 }
 
 type initSlot struct {
@@ -219,14 +232,13 @@ func (s *initSlot) makeCallable(t *Type, _ string) *Object {
 	}).ToObject()
 }
 
-func (s *initSlot) wrapCallable(callable *Object) bool {
+func (s *initSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
 		callArgs := make(Args, len(args)+1)
 		callArgs[0] = o
 		copy(callArgs[1:], args)
 		return callable.Call(f, callArgs, kwargs)
 	}
-	return true
 }
 
 type nativeSlot struct {
@@ -237,10 +249,6 @@ func (s *nativeSlot) makeCallable(t *Type, slotName string) *Object {
 	return nil
 }
 
-func (s *nativeSlot) wrapCallable(callable *Object) bool {
-	return false
-}
-
 type newSlot struct {
 	Fn func(*Frame, *Type, Args, KWArgs) (*Object, *BaseException)
 }
@@ -259,14 +267,13 @@ func (s *newSlot) makeCallable(t *Type, _ string) *Object {
 	}).ToObject()).ToObject()
 }
 
-func (s *newSlot) wrapCallable(callable *Object) bool {
+func (s *newSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseException) {
 		callArgs := make(Args, len(args)+1)
 		callArgs[0] = t.ToObject()
 		copy(callArgs[1:], args)
 		return callable.Call(f, callArgs, kwargs)
 	}
-	return true
 }
 
 type setAttrSlot struct {
@@ -285,12 +292,11 @@ func (s *setAttrSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *setAttrSlot) wrapCallable(callable *Object) bool {
+func (s *setAttrSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, name *Str, value *Object) *BaseException {
 		_, raised := callable.Call(f, Args{o, name.ToObject(), value}, nil)
 		return raised
 	}
-	return true
 }
 
 type setItemSlot struct {
@@ -309,12 +315,11 @@ func (s *setItemSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *setItemSlot) wrapCallable(callable *Object) bool {
+func (s *setItemSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object, key *Object, value *Object) *BaseException {
 		_, raised := callable.Call(f, Args{o, key, value}, nil)
 		return raised
 	}
-	return true
 }
 
 type setSlot struct {
@@ -333,12 +338,11 @@ func (s *setSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *setSlot) wrapCallable(callable *Object) bool {
+func (s *setSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, desc, inst, value *Object) *BaseException {
 		_, raised := callable.Call(f, Args{desc, inst, value}, nil)
 		return raised
 	}
-	return true
 }
 
 type unaryOpSlot struct {
@@ -354,11 +358,10 @@ func (s *unaryOpSlot) makeCallable(t *Type, slotName string) *Object {
 	}).ToObject()
 }
 
-func (s *unaryOpSlot) wrapCallable(callable *Object) bool {
+func (s *unaryOpSlot) wrapCallable(callable *Object) {
 	s.Fn = func(f *Frame, o *Object) (*Object, *BaseException) {
 		return callable.Call(f, Args{o}, nil)
 	}
-	return true
 }
 
 // typeSlots hold a type's special methods such as __eq__. During type
@@ -373,6 +376,7 @@ type typeSlots struct {
 	Add          *binaryOpSlot
 	And          *binaryOpSlot
 	Basis        *basisSlot
+	Buffer       *bufferSlot
 	Call         *callSlot
 	Cmp          *binaryOpSlot
 	Complex      *unaryOpSlot
@@ -465,3 +469,20 @@ func calcSlotNames() []string {
 	}
 	return names
 }
+
+func calcSlotIndexes() map[string]int {
+	indexes := make(map[string]int, numSlots)
+	for i, name := range slotNames {
+		indexes[name] = i
+	}
+	return indexes
+}
+
+func calcSlotOverridable() []bool {
+	overridableType := reflect.TypeOf((*overridableSlot)(nil)).Elem()
+	overridable := make([]bool, numSlots, numSlots)
+	for i := 0; i < numSlots; i++ {
+		overridable[i] = slotsType.Field(i).Type.Implements(overridableType)
+	}
+	return overridable
+}
@@ -42,6 +42,7 @@ func TestSlotMakeCallable(t *testing.T) {
 	})
 	cases := []invokeTestCase{
 		{args: wrapArgs(&basisSlot{}, None), want: None},
+		{args: wrapArgs(&bufferSlot{}, None), want: None},
 		{args: wrapArgs(&binaryOpSlot{}, "foo", foo, 123), want: newTestTuple("foo", newTestTuple(foo, 123)).ToObject()},
 		{args: wrapArgs(&binaryOpSlot{}, None, "abc", 123), wantExc: mustCreateException(TypeErrorType, "'__slot__' requires a 'Foo' object but received a 'str'")},
 		{args: wrapArgs(&delAttrSlot{}, None, foo, "bar"), want: newTestTuple(None, newTestTuple(foo, "bar")).ToObject()},
@@ -93,7 +94,9 @@ func TestSlotWrapCallable(t *testing.T) {
 			gotKWArgs = kwargs.makeDict().ToObject()
 			return ret, nil
 		}).ToObject()
-		s.wrapCallable(wrapped)
+		if o, ok := s.(overridableSlot); ok {
+			o.wrapCallable(wrapped)
+		}
 		fnField := reflect.ValueOf(s).Elem().Field(0)
 		if fnField.IsNil() {
 			// Return None to denote the slot was empty.
@@ -114,6 +117,7 @@ func TestSlotWrapCallable(t *testing.T) {
 	o := newObject(ObjectType)
 	cases := []invokeTestCase{
 		{args: wrapArgs(&basisSlot{}, "no"), want: None},
+		{args: wrapArgs(&bufferSlot{}, "no"), want: None},
 		{args: wrapArgs(&binaryOpSlot{}, "ret", "foo", "bar"), want: newTestTuple("ret", newTestTuple("foo", "bar"), NewDict()).ToObject()},
 		{args: wrapArgs(&binaryOpSlot{}, RuntimeErrorType, "foo", "bar"), wantExc: mustCreateException(RuntimeErrorType, "")},
 		{args: wrapArgs(&callSlot{}, "ret", true, wrapArgs(1, 2), None), want: newTestTuple("ret", newTestTuple(true, 1, 2), NewDict()).ToObject()},
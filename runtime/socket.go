@@ -0,0 +1,1145 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Flags for getaddrinfo. These have the same values on all supported
+// platforms.
+const (
+	socketAIPassive     = 0x1
+	socketAICanonName   = 0x2
+	socketAINumericHost = 0x4
+)
+
+var (
+	// socketType corresponds to the Python type '_socket.socket'.
+	socketType = newBasisType("socket", reflect.TypeOf(socket{}), toSocketUnsafe, ObjectType)
+	// socketErrorType corresponds to the Python type 'socket.error'.
+	socketErrorType = newSimpleType("error", IOErrorType)
+	// socketGaierrorType corresponds to the Python type 'socket.gaierror'.
+	socketGaierrorType = newSimpleType("gaierror", socketErrorType)
+	// socketHerrorType corresponds to the Python type 'socket.herror'.
+	socketHerrorType = newSimpleType("herror", socketErrorType)
+	// socketTimeoutType corresponds to the Python type 'socket.timeout'.
+	socketTimeoutType = newSimpleType("timeout", socketErrorType)
+	// socketDefaultTimeout is the timeout in nanoseconds given to newly
+	// created sockets. Negative values mean blocking. It must be accessed
+	// atomically.
+	socketDefaultTimeout int64 = -1
+)
+
+// socket represents a Python socket object. The descriptor is non-blocking
+// and registered with Go's network poller so that blocking operations park
+// the calling goroutine rather than the OS thread it runs on.
+type socket struct {
+	Object
+	family   int `attr:"family"`
+	sockType int `attr:"type"`
+	proto    int `attr:"proto"`
+	mutex    sync.Mutex
+	// file owns the descriptor. It is nil before __init__ and after close.
+	file *os.File
+	// timeout is negative for blocking sockets and zero for non-blocking
+	// ones.
+	timeout time.Duration
+}
+
+func toSocketUnsafe(o *Object) *socket {
+	return (*socket)(o.toPointer())
+}
+
+// ToObject upcasts s to an Object.
+func (s *socket) ToObject() *Object {
+	return &s.Object
+}
+
+func init() {
+	RegisterModule("_socket", NewCode("<module>", "_socket", nil, 0, socketModuleInit))
+}
+
+func socketModuleInit(f *Frame, _ []*Object) (*Object, *BaseException) {
+	globals := map[string]*Object{
+		"error":             socketErrorType.ToObject(),
+		"gaierror":          socketGaierrorType.ToObject(),
+		"getaddrinfo":       newBuiltinFunction("getaddrinfo", socketGetaddrinfo).ToObject(),
+		"getdefaulttimeout": newBuiltinFunction("getdefaulttimeout", socketGetdefaulttimeout).ToObject(),
+		"gethostbyname":     newBuiltinFunction("gethostbyname", socketGethostbyname).ToObject(),
+		"gethostname":       newBuiltinFunction("gethostname", socketGethostname).ToObject(),
+		"herror":            socketHerrorType.ToObject(),
+		"setdefaulttimeout": newBuiltinFunction("setdefaulttimeout", socketSetdefaulttimeout).ToObject(),
+		"socket":            socketType.ToObject(),
+		"timeout":           socketTimeoutType.ToObject(),
+	}
+	for name, value := range globals {
+		if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+			return nil, raised
+		}
+	}
+	constants := map[string]int{
+		"AF_INET":        syscall.AF_INET,
+		"AF_INET6":       syscall.AF_INET6,
+		"AF_UNIX":        syscall.AF_UNIX,
+		"AF_UNSPEC":      syscall.AF_UNSPEC,
+		"AI_CANONNAME":   socketAICanonName,
+		"AI_NUMERICHOST": socketAINumericHost,
+		"AI_PASSIVE":     socketAIPassive,
+		"EAI_AGAIN":      socketEAIAgain,
+		"EAI_FAMILY":     socketEAIFamily,
+		"EAI_NONAME":     socketEAINoName,
+		"EAI_SERVICE":    socketEAIService,
+		"IPPROTO_IP":     syscall.IPPROTO_IP,
+		"IPPROTO_IPV6":   syscall.IPPROTO_IPV6,
+		"IPPROTO_TCP":    syscall.IPPROTO_TCP,
+		"IPPROTO_UDP":    syscall.IPPROTO_UDP,
+		"IPV6_V6ONLY":    syscall.IPV6_V6ONLY,
+		"MSG_DONTWAIT":   syscall.MSG_DONTWAIT,
+		"MSG_OOB":        syscall.MSG_OOB,
+		"MSG_PEEK":       syscall.MSG_PEEK,
+		"MSG_WAITALL":    syscall.MSG_WAITALL,
+		"SHUT_RD":        syscall.SHUT_RD,
+		"SHUT_RDWR":      syscall.SHUT_RDWR,
+		"SHUT_WR":        syscall.SHUT_WR,
+		"SOCK_DGRAM":     syscall.SOCK_DGRAM,
+		"SOCK_RAW":       syscall.SOCK_RAW,
+		"SOCK_STREAM":    syscall.SOCK_STREAM,
+		"SOL_SOCKET":     syscall.SOL_SOCKET,
+		"SOMAXCONN":      syscall.SOMAXCONN,
+		"SO_BROADCAST":   syscall.SO_BROADCAST,
+		"SO_ERROR":       syscall.SO_ERROR,
+		"SO_KEEPALIVE":   syscall.SO_KEEPALIVE,
+		"SO_LINGER":      syscall.SO_LINGER,
+		"SO_RCVBUF":      syscall.SO_RCVBUF,
+		"SO_REUSEADDR":   syscall.SO_REUSEADDR,
+		"SO_SNDBUF":      syscall.SO_SNDBUF,
+		"SO_TYPE":        syscall.SO_TYPE,
+		"TCP_NODELAY":    syscall.TCP_NODELAY,
+	}
+	for name, value := range constants {
+		if raised := f.Globals().SetItemString(f, name, NewInt(value).ToObject()); raised != nil {
+			return nil, raised
+		}
+	}
+	return nil, nil
+}
+
+// socketRaise raises the Python exception corresponding to err, which was
+// returned by a socket operation.
+func socketRaise(f *Frame, err error) *BaseException {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return f.RaiseType(socketTimeoutType, "timed out")
+	}
+	if errors.Is(err, os.ErrClosed) {
+		err = syscall.EBADF
+	}
+	return raiseEnvironmentError(f, socketErrorType, err)
+}
+
+// socketRaiseGaierror raises a socket.gaierror for a name resolution
+// failure.
+func socketRaiseGaierror(f *Frame, code int) *BaseException {
+	args := NewTuple2(NewInt(code).ToObject(), NewStr(socketEAIMessages[code]).ToObject())
+	return f.Raise(socketGaierrorType.ToObject(), args.ToObject(), nil)
+}
+
+// newSocket wraps the descriptor fd, taking ownership of it.
+func newSocket(f *Frame, family, sockType, proto, fd int) (*socket, *BaseException) {
+	s := &socket{Object: Object{typ: socketType}}
+	if raised := s.setFD(f, family, sockType, proto, fd); raised != nil {
+		return nil, raised
+	}
+	return s, nil
+}
+
+func (s *socket) setFD(f *Frame, family, sockType, proto, fd int) *BaseException {
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return socketRaise(f, err)
+	}
+	s.mutex.Lock()
+	old := s.file
+	s.family, s.sockType, s.proto = family, sockType, proto
+	s.file = os.NewFile(uintptr(fd), "<socket>")
+	s.timeout = time.Duration(atomic.LoadInt64(&socketDefaultTimeout))
+	s.mutex.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+func (s *socket) state() (*os.File, time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file, s.timeout
+}
+
+// control calls op with s's descriptor.
+func (s *socket) control(op func(fd int) error) error {
+	file, _ := s.state()
+	if file == nil {
+		return syscall.EBADF
+	}
+	rc, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var opErr error
+	if err := rc.Control(func(fd uintptr) { opErr = op(int(fd)) }); err != nil {
+		return err
+	}
+	return opErr
+}
+
+// io calls op with s's descriptor until it returns something other than
+// EAGAIN, waiting in between for the descriptor to become readable (or
+// writable when write is true). Waits are bounded by s's timeout and a
+// non-blocking socket calls op exactly once.
+func (s *socket) io(write bool, op func(fd int) error) error {
+	file, timeout := s.state()
+	if file == nil {
+		return syscall.EBADF
+	}
+	if timeout == 0 {
+		// A deadline in the past would fail before op is attempted.
+		return s.control(op)
+	}
+	rc, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	var opErr error
+	fn := func(fd uintptr) bool {
+		opErr = op(int(fd))
+		return opErr != syscall.EAGAIN
+	}
+	if write {
+		if err = file.SetWriteDeadline(deadline); err == nil {
+			err = rc.Write(fn)
+		}
+	} else if err = file.SetReadDeadline(deadline); err == nil {
+		err = rc.Read(fn)
+	}
+	if err != nil {
+		if file, _ := s.state(); file == nil {
+			// The socket was closed while waiting.
+			return syscall.EBADF
+		}
+		return err
+	}
+	return opErr
+}
+
+// sockaddr converts the Python address addr to a syscall.Sockaddr for s's
+// address family.
+func (s *socket) sockaddr(f *Frame, addr *Object) (syscall.Sockaddr, *BaseException) {
+	switch s.family {
+	case syscall.AF_UNIX:
+		if !addr.isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("getsockaddrarg: AF_UNIX address must be str, not %s", addr.typ.Name()))
+		}
+		return &syscall.SockaddrUnix{Name: toStrUnsafe(addr).Value()}, nil
+	case syscall.AF_INET, syscall.AF_INET6:
+		msg := "getsockaddrarg: AF_INET address must be a (host, port) tuple"
+		maxElems := 2
+		if s.family == syscall.AF_INET6 {
+			msg = "getsockaddrarg: AF_INET6 address must be a (host, port[, flowinfo[, scopeid]]) tuple"
+			maxElems = 4
+		}
+		if !addr.isInstance(TupleType) {
+			return nil, f.RaiseType(TypeErrorType, msg)
+		}
+		elems := toTupleUnsafe(addr).elems
+		if len(elems) < 2 || len(elems) > maxElems || !elems[0].isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, msg)
+		}
+		for _, o := range elems[1:] {
+			if !o.isInstance(IntType) {
+				return nil, f.RaiseType(TypeErrorType, msg)
+			}
+		}
+		port := toIntUnsafe(elems[1]).Value()
+		if port < 0 || port > 0xffff {
+			return nil, f.RaiseType(OverflowErrorType, "getsockaddrarg: port must be 0-65535.")
+		}
+		ip, raised := socketResolveHost(f, toStrUnsafe(elems[0]).Value(), s.family)
+		if raised != nil {
+			return nil, raised
+		}
+		if s.family == syscall.AF_INET {
+			sa := &syscall.SockaddrInet4{Port: port}
+			copy(sa.Addr[:], ip.To4())
+			return sa, nil
+		}
+		// NOTE(compatibility): syscall.SockaddrInet6 has no field for
+		// flowinfo so it is ignored.
+		sa := &syscall.SockaddrInet6{Port: port}
+		if len(elems) == 4 {
+			sa.ZoneId = uint32(toIntUnsafe(elems[3]).Value())
+		}
+		copy(sa.Addr[:], ip.To16())
+		return sa, nil
+	}
+	return nil, raiseEnvironmentError(f, socketErrorType, syscall.EAFNOSUPPORT)
+}
+
+// socketAddrToObject converts sa to the Python representation of an address
+// in its family. It returns None for unknown families.
+func socketAddrToObject(sa syscall.Sockaddr) *Object {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		return NewTuple2(NewStr(net.IP(sa.Addr[:]).String()).ToObject(), NewInt(sa.Port).ToObject()).ToObject()
+	case *syscall.SockaddrInet6:
+		return NewTuple(NewStr(net.IP(sa.Addr[:]).String()).ToObject(), NewInt(sa.Port).ToObject(), NewInt(0).ToObject(), NewInt(int(sa.ZoneId)).ToObject()).ToObject()
+	case *syscall.SockaddrUnix:
+		return NewStr(sa.Name).ToObject()
+	}
+	return None
+}
+
+// socketResolveHost returns an address of the given family for host. As in
+// CPython, the empty string means the wildcard address and "<broadcast>"
+// the IPv4 broadcast address.
+func socketResolveHost(f *Frame, host string, family int) (net.IP, *BaseException) {
+	switch {
+	case host == "" && family == syscall.AF_INET6:
+		return net.IPv6unspecified, nil
+	case host == "":
+		return net.IPv4zero, nil
+	case host == "<broadcast>" && family == syscall.AF_INET:
+		return net.IPv4bcast, nil
+	}
+	ips, raised := socketLookup(f, host, family, 0)
+	if raised != nil {
+		return nil, raised
+	}
+	return ips[0], nil
+}
+
+// socketLookup returns the addresses of the given family (or either family
+// for AF_UNSPEC) for host, which may be a name or a numeric address. It
+// never returns an empty slice.
+func socketLookup(f *Frame, host string, family, flags int) ([]net.IP, *BaseException) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else if flags&socketAINumericHost != 0 {
+		return nil, socketRaiseGaierror(f, socketEAINoName)
+	} else {
+		network := "ip"
+		switch family {
+		case syscall.AF_INET:
+			network = "ip4"
+		case syscall.AF_INET6:
+			network = "ip6"
+		}
+		var err error
+		ips, err = net.DefaultResolver.LookupIP(context.Background(), network, host)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsTemporary {
+				return nil, socketRaiseGaierror(f, socketEAIAgain)
+			}
+			return nil, socketRaiseGaierror(f, socketEAINoName)
+		}
+	}
+	var result []net.IP
+	for _, ip := range ips {
+		if family == syscall.AF_UNSPEC || socketIPFamily(ip) == family {
+			result = append(result, ip)
+		}
+	}
+	if len(result) == 0 {
+		return nil, socketRaiseGaierror(f, socketEAINoName)
+	}
+	return result, nil
+}
+
+func socketIPFamily(ip net.IP) int {
+	if ip.To4() != nil {
+		return syscall.AF_INET
+	}
+	return syscall.AF_INET6
+}
+
+func socketInit(f *Frame, o *Object, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{IntType, IntType, IntType}
+	if argc < 3 {
+		expectedTypes = expectedTypes[:argc]
+	}
+	if raised := checkFunctionArgs(f, "__init__", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	family, sockType, proto := syscall.AF_INET, syscall.SOCK_STREAM, 0
+	if argc > 0 {
+		family = toIntUnsafe(args[0]).Value()
+	}
+	if argc > 1 {
+		sockType = toIntUnsafe(args[1]).Value()
+	}
+	if argc > 2 {
+		proto = toIntUnsafe(args[2]).Value()
+	}
+	syscall.ForkLock.RLock()
+	fd, err := syscall.Socket(family, sockType, proto)
+	if err == nil {
+		syscall.CloseOnExec(fd)
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	if raised := toSocketUnsafe(o).setFD(f, family, sockType, proto, fd); raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func socketAccept(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "accept", args, socketType); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	var nfd int
+	var sa syscall.Sockaddr
+	err := s.io(false, func(fd int) (err error) {
+		syscall.ForkLock.RLock()
+		nfd, sa, err = syscall.Accept(fd)
+		if err == nil {
+			syscall.CloseOnExec(nfd)
+		}
+		syscall.ForkLock.RUnlock()
+		return err
+	})
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	conn, raised := newSocket(f, s.family, s.sockType, s.proto, nfd)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewTuple2(conn.ToObject(), socketAddrToObject(sa)).ToObject(), nil
+}
+
+func socketBind(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "bind", args, socketType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	sa, raised := s.sockaddr(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if err := s.control(func(fd int) error { return syscall.Bind(fd, sa) }); err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return None, nil
+}
+
+func socketClose(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, socketType); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	s.mutex.Lock()
+	file := s.file
+	s.file = nil
+	s.mutex.Unlock()
+	if file != nil {
+		// Closing the file wakes any goroutines blocked on the socket.
+		if err := file.Close(); err != nil {
+			return nil, socketRaise(f, err)
+		}
+	}
+	return None, nil
+}
+
+// connect connects s to sa, waiting for the connection to be established
+// unless s is non-blocking.
+func (s *socket) connect(sa syscall.Sockaddr) error {
+	started := false
+	err := s.io(true, func(fd int) error {
+		if !started {
+			started = true
+			err := syscall.Connect(fd, sa)
+			if err == syscall.EINPROGRESS {
+				return syscall.EAGAIN
+			}
+			return err
+		}
+		// The descriptor became writable, which may mean that the
+		// connection was established or that it failed.
+		soErr, err := syscall.GetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_ERROR)
+		if err != nil {
+			return err
+		}
+		switch errno := syscall.Errno(soErr); errno {
+		case syscall.EINPROGRESS, syscall.EALREADY, syscall.EINTR:
+			return syscall.EAGAIN
+		case 0:
+			if _, err := syscall.Getpeername(fd); err == syscall.ENOTCONN {
+				return syscall.EAGAIN
+			}
+			return nil
+		default:
+			return errno
+		}
+	})
+	if err == syscall.EAGAIN {
+		// Only non-blocking sockets give up on the first attempt.
+		err = syscall.EINPROGRESS
+	}
+	return err
+}
+
+func socketConnect(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "connect", args, socketType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	sa, raised := s.sockaddr(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	if err := s.connect(sa); err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return None, nil
+}
+
+func socketConnectEx(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "connect_ex", args, socketType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	sa, raised := s.sockaddr(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	err := s.connect(sa)
+	if err == nil {
+		return NewInt(0).ToObject(), nil
+	}
+	var errno syscall.Errno
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		errno = syscall.EAGAIN
+	} else if errors.Is(err, os.ErrClosed) {
+		errno = syscall.EBADF
+	} else if !errors.As(err, &errno) {
+		return nil, socketRaise(f, err)
+	}
+	return NewInt(int(errno)).ToObject(), nil
+}
+
+func socketFileno(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "fileno", args, socketType); raised != nil {
+		return nil, raised
+	}
+	var result int
+	// File.Fd would put the descriptor into blocking mode.
+	err := toSocketUnsafe(args[0]).control(func(fd int) error {
+		result = fd
+		return nil
+	})
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewInt(result).ToObject(), nil
+}
+
+func socketGetpeername(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getpeername", args, socketType); raised != nil {
+		return nil, raised
+	}
+	var sa syscall.Sockaddr
+	err := toSocketUnsafe(args[0]).control(func(fd int) (err error) {
+		sa, err = syscall.Getpeername(fd)
+		return err
+	})
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return socketAddrToObject(sa), nil
+}
+
+func socketGetsockname(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getsockname", args, socketType); raised != nil {
+		return nil, raised
+	}
+	var sa syscall.Sockaddr
+	err := toSocketUnsafe(args[0]).control(func(fd int) (err error) {
+		sa, err = syscall.Getsockname(fd)
+		return err
+	})
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return socketAddrToObject(sa), nil
+}
+
+func socketGetsockopt(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "getsockopt", args, socketType, IntType, IntType); raised != nil {
+		return nil, raised
+	}
+	level, name := toIntUnsafe(args[1]).Value(), toIntUnsafe(args[2]).Value()
+	var value int
+	err := toSocketUnsafe(args[0]).control(func(fd int) (err error) {
+		value, err = syscall.GetsockoptInt(fd, level, name)
+		return err
+	})
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewInt(value).ToObject(), nil
+}
+
+func socketGettimeout(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "gettimeout", args, socketType); raised != nil {
+		return nil, raised
+	}
+	_, timeout := toSocketUnsafe(args[0]).state()
+	return socketTimeoutToObject(timeout), nil
+}
+
+func socketListen(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, IntType}
+	if argc == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "listen", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	backlog := syscall.SOMAXCONN
+	if argc > 1 {
+		if backlog = toIntUnsafe(args[1]).Value(); backlog < 0 {
+			backlog = 0
+		}
+	}
+	if err := toSocketUnsafe(args[0]).control(func(fd int) error { return syscall.Listen(fd, backlog) }); err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return None, nil
+}
+
+// socketMakefile implements socket.makefile([mode[, bufsize]]). The returned
+// file has its own descriptor so it may be closed independently of the
+// socket. It does not honor the socket's timeout.
+func socketMakefile(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, StrType, IntType}
+	if argc < 3 {
+		expectedTypes = expectedTypes[:argc]
+	}
+	if raised := checkMethodArgs(f, "makefile", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	mode := "r"
+	if argc > 1 {
+		mode = toStrUnsafe(args[1]).Value()
+	}
+	var dup int
+	err := toSocketUnsafe(args[0]).control(func(fd int) (err error) {
+		syscall.ForkLock.RLock()
+		dup, err = syscall.Dup(fd)
+		if err == nil {
+			syscall.CloseOnExec(dup)
+		}
+		syscall.ForkLock.RUnlock()
+		return err
+	})
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return newFileFromOSFile(os.NewFile(uintptr(dup), "<socket>"), mode).ToObject(), nil
+}
+
+func socketRecv(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, bufsize, flags, raised := socketParseRecvArgs(f, "recv", args)
+	if raised != nil {
+		return nil, raised
+	}
+	buf := make([]byte, bufsize)
+	n, _, err := s.recvfrom(buf, flags)
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewStr(string(buf[:n])).ToObject(), nil
+}
+
+func socketRecvfrom(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	s, bufsize, flags, raised := socketParseRecvArgs(f, "recvfrom", args)
+	if raised != nil {
+		return nil, raised
+	}
+	buf := make([]byte, bufsize)
+	n, sa, err := s.recvfrom(buf, flags)
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewTuple2(NewStr(string(buf[:n])).ToObject(), socketAddrToObject(sa)).ToObject(), nil
+}
+
+// socketRecvInto implements socket.recv_into(buffer[, nbytes[, flags]]) for
+// bytearray buffers.
+func socketRecvInto(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, ByteArrayType, IntType, IntType}
+	if argc < 4 {
+		expectedTypes = expectedTypes[:argc]
+	}
+	if raised := checkMethodArgs(f, "recv_into", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	s, buffer := toSocketUnsafe(args[0]), toByteArrayUnsafe(args[1])
+	buffer.mutex.RLock()
+	size := len(buffer.value)
+	buffer.mutex.RUnlock()
+	nbytes, flags := 0, 0
+	if argc > 2 {
+		nbytes = toIntUnsafe(args[2]).Value()
+	}
+	if argc > 3 {
+		flags = toIntUnsafe(args[3]).Value()
+	}
+	if nbytes < 0 {
+		return nil, f.RaiseType(ValueErrorType, "negative buffersize in recv_into")
+	}
+	if nbytes > size {
+		return nil, f.RaiseType(ValueErrorType, "buffer too small for requested bytes")
+	}
+	if nbytes == 0 {
+		nbytes = size
+	}
+	// Receive into a temporary buffer so that the bytearray is not locked
+	// while blocked.
+	buf := make([]byte, nbytes)
+	n, _, err := s.recvfrom(buf, flags)
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	buffer.mutex.Lock()
+	n = copy(buffer.value, buf[:n])
+	buffer.mutex.Unlock()
+	return NewInt(n).ToObject(), nil
+}
+
+func (s *socket) recvfrom(buf []byte, flags int) (n int, sa syscall.Sockaddr, err error) {
+	err = s.io(false, func(fd int) (err error) {
+		n, sa, err = syscall.Recvfrom(fd, buf, flags)
+		return err
+	})
+	return n, sa, err
+}
+
+func socketParseRecvArgs(f *Frame, method string, args Args) (*socket, int, int, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, IntType, IntType}
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, method, args, expectedTypes...); raised != nil {
+		return nil, 0, 0, raised
+	}
+	bufsize, flags := toIntUnsafe(args[1]).Value(), 0
+	if argc > 2 {
+		flags = toIntUnsafe(args[2]).Value()
+	}
+	if bufsize < 0 {
+		return nil, 0, 0, f.RaiseType(ValueErrorType, "negative buffersize in "+method)
+	}
+	return toSocketUnsafe(args[0]), bufsize, flags, nil
+}
+
+func socketSend(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, StrType, IntType}
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "send", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	flags := 0
+	if argc > 2 {
+		flags = toIntUnsafe(args[2]).Value()
+	}
+	n, err := toSocketUnsafe(args[0]).sendto([]byte(toStrUnsafe(args[1]).Value()), flags, nil)
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+func socketSendall(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, StrType, IntType}
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "sendall", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	flags := 0
+	if argc > 2 {
+		flags = toIntUnsafe(args[2]).Value()
+	}
+	for buf := []byte(toStrUnsafe(args[1]).Value()); len(buf) > 0; {
+		n, err := s.sendto(buf, flags, nil)
+		if err != nil {
+			return nil, socketRaise(f, err)
+		}
+		buf = buf[n:]
+	}
+	return None, nil
+}
+
+// socketSendto implements socket.sendto(string[, flags], address).
+func socketSendto(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{socketType, StrType, IntType, ObjectType}
+	if argc == 3 {
+		expectedTypes = []*Type{socketType, StrType, ObjectType}
+	}
+	if raised := checkMethodArgs(f, "sendto", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	flags := 0
+	if argc > 3 {
+		flags = toIntUnsafe(args[2]).Value()
+	}
+	sa, raised := s.sockaddr(f, args[argc-1])
+	if raised != nil {
+		return nil, raised
+	}
+	n, err := s.sendto([]byte(toStrUnsafe(args[1]).Value()), flags, sa)
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewInt(n).ToObject(), nil
+}
+
+func (s *socket) sendto(buf []byte, flags int, sa syscall.Sockaddr) (n int, err error) {
+	err = s.io(true, func(fd int) (err error) {
+		n, err = syscall.SendmsgN(fd, buf, nil, sa, flags)
+		return err
+	})
+	return n, err
+}
+
+func socketSetblocking(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "setblocking", args, socketType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	blocking, raised := IsTrue(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	s.mutex.Lock()
+	s.timeout = 0
+	if blocking {
+		s.timeout = -1
+	}
+	s.mutex.Unlock()
+	return None, nil
+}
+
+// socketSetsockopt implements socket.setsockopt(level, optname, value) where
+// value is an int or a str holding the raw option value.
+func socketSetsockopt(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "setsockopt", args, socketType, IntType, IntType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	level, name, value := toIntUnsafe(args[1]).Value(), toIntUnsafe(args[2]).Value(), args[3]
+	var op func(fd int) error
+	switch {
+	case value.isInstance(IntType):
+		op = func(fd int) error { return syscall.SetsockoptInt(fd, level, name, toIntUnsafe(value).Value()) }
+	case value.isInstance(StrType):
+		op = func(fd int) error { return syscall.SetsockoptString(fd, level, name, toStrUnsafe(value).Value()) }
+	default:
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("setsockopt() value must be int or str, not %s", value.typ.Name()))
+	}
+	if err := toSocketUnsafe(args[0]).control(op); err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return None, nil
+}
+
+func socketSettimeout(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "settimeout", args, socketType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	timeout, raised := socketTimeoutFromObject(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	s := toSocketUnsafe(args[0])
+	s.mutex.Lock()
+	s.timeout = timeout
+	s.mutex.Unlock()
+	return None, nil
+}
+
+func socketShutdown(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "shutdown", args, socketType, IntType); raised != nil {
+		return nil, raised
+	}
+	how := toIntUnsafe(args[1]).Value()
+	if err := toSocketUnsafe(args[0]).control(func(fd int) error { return syscall.Shutdown(fd, how) }); err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return None, nil
+}
+
+func socketRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	s := toSocketUnsafe(o)
+	fd := -1
+	s.control(func(sysfd int) error {
+		fd = sysfd
+		return nil
+	})
+	return NewStr(fmt.Sprintf("<socket object, fd=%d, family=%d, type=%d, protocol=%d>", fd, s.family, s.sockType, s.proto)).ToObject(), nil
+}
+
+// socketTimeoutFromObject converts a Python timeout value, which is None or a
+// non-negative number of seconds, to a time.Duration. None yields -1.
+func socketTimeoutFromObject(f *Frame, o *Object) (time.Duration, *BaseException) {
+	if o == None {
+		return -1, nil
+	}
+	secs, ok := floatCoerce(o)
+	if !ok {
+		return 0, f.RaiseType(TypeErrorType, fmt.Sprintf("timeout must be a number or None, not %s", o.typ.Name()))
+	}
+	if secs < 0 {
+		return 0, f.RaiseType(ValueErrorType, "Timeout value out of range")
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+func socketTimeoutToObject(timeout time.Duration) *Object {
+	if timeout < 0 {
+		return None
+	}
+	return NewFloat(timeout.Seconds()).ToObject()
+}
+
+// socketGetaddrinfo implements getaddrinfo(host, port[, family[, socktype[,
+// proto[, flags]]]]). host is resolved with the Go resolver and port may be
+// None, an int, a numeric string or a service name.
+func socketGetaddrinfo(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{ObjectType, ObjectType, IntType, IntType, IntType, IntType}
+	if argc < 6 {
+		expectedTypes = expectedTypes[:argc]
+	}
+	if raised := checkFunctionArgs(f, "getaddrinfo", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	var options [4]int
+	for i := 2; i < argc; i++ {
+		options[i-2] = toIntUnsafe(args[i]).Value()
+	}
+	family, sockType, proto, flags := options[0], options[1], options[2], options[3]
+	if family != syscall.AF_UNSPEC && family != syscall.AF_INET && family != syscall.AF_INET6 {
+		return nil, socketRaiseGaierror(f, socketEAIFamily)
+	}
+	var ips []net.IP
+	canonName := ""
+	switch host := args[0]; {
+	case host == None:
+		// Without a host the result is the wildcard address for
+		// servers or loopback for clients.
+		ip4, ip6 := net.IPv4(127, 0, 0, 1), net.IPv6loopback
+		if flags&socketAIPassive != 0 {
+			ip4, ip6 = net.IPv4zero, net.IPv6unspecified
+		}
+		switch family {
+		case syscall.AF_INET:
+			ips = []net.IP{ip4}
+		case syscall.AF_INET6:
+			ips = []net.IP{ip6}
+		default:
+			ips = []net.IP{ip4, ip6}
+		}
+	case host.isInstance(StrType):
+		name := toStrUnsafe(host).Value()
+		var raised *BaseException
+		if ips, raised = socketLookup(f, name, family, flags); raised != nil {
+			return nil, raised
+		}
+		if flags&socketAICanonName != 0 {
+			canonName = name
+			if cname, err := net.DefaultResolver.LookupCNAME(context.Background(), name); err == nil && net.ParseIP(name) == nil {
+				canonName = strings.TrimSuffix(cname, ".")
+			}
+		}
+	default:
+		return nil, f.RaiseType(TypeErrorType, "getaddrinfo() argument 1 must be string or None")
+	}
+	var entries []*Object
+	for _, candidate := range []struct{ sockType, proto int }{{syscall.SOCK_STREAM, syscall.IPPROTO_TCP}, {syscall.SOCK_DGRAM, syscall.IPPROTO_UDP}} {
+		if (sockType != 0 && sockType != candidate.sockType) || (proto != 0 && proto != candidate.proto) {
+			continue
+		}
+		port, raised := socketLookupPort(f, args[1], candidate.sockType)
+		if raised != nil {
+			return nil, raised
+		}
+		for _, ip := range ips {
+			ipFamily := socketIPFamily(ip)
+			var sa syscall.Sockaddr
+			if ipFamily == syscall.AF_INET {
+				sa4 := &syscall.SockaddrInet4{Port: port}
+				copy(sa4.Addr[:], ip.To4())
+				sa = sa4
+			} else {
+				sa6 := &syscall.SockaddrInet6{Port: port}
+				copy(sa6.Addr[:], ip.To16())
+				sa = sa6
+			}
+			entry := NewTuple(NewInt(ipFamily).ToObject(), NewInt(candidate.sockType).ToObject(), NewInt(candidate.proto).ToObject(), NewStr(canonName).ToObject(), socketAddrToObject(sa))
+			entries = append(entries, entry.ToObject())
+			// As with getaddrinfo(3), only the first entry carries
+			// the canonical name.
+			canonName = ""
+		}
+	}
+	if len(entries) == 0 {
+		return nil, socketRaiseGaierror(f, socketEAIService)
+	}
+	return NewList(entries...).ToObject(), nil
+}
+
+// socketLookupPort converts the port argument of getaddrinfo to a port
+// number for the given socket type.
+func socketLookupPort(f *Frame, o *Object, sockType int) (int, *BaseException) {
+	switch {
+	case o == None:
+		return 0, nil
+	case o.isInstance(IntType):
+		return toIntUnsafe(o).Value(), nil
+	case o.isInstance(StrType):
+		service := toStrUnsafe(o).Value()
+		if port, err := strconv.Atoi(service); err == nil {
+			return port, nil
+		}
+		network := "tcp"
+		if sockType == syscall.SOCK_DGRAM {
+			network = "udp"
+		}
+		port, err := net.DefaultResolver.LookupPort(context.Background(), network, service)
+		if err != nil {
+			return 0, socketRaiseGaierror(f, socketEAIService)
+		}
+		return port, nil
+	}
+	return 0, f.RaiseType(TypeErrorType, "getaddrinfo() argument 2 must be int, string or None")
+}
+
+func socketGetdefaulttimeout(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "getdefaulttimeout", args); raised != nil {
+		return nil, raised
+	}
+	return socketTimeoutToObject(time.Duration(atomic.LoadInt64(&socketDefaultTimeout))), nil
+}
+
+func socketGethostbyname(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "gethostbyname", args, StrType); raised != nil {
+		return nil, raised
+	}
+	ip, raised := socketResolveHost(f, toStrUnsafe(args[0]).Value(), syscall.AF_INET)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(ip.String()).ToObject(), nil
+}
+
+func socketGethostname(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "gethostname", args); raised != nil {
+		return nil, raised
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		return nil, socketRaise(f, err)
+	}
+	return NewStr(name).ToObject(), nil
+}
+
+func socketSetdefaulttimeout(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "setdefaulttimeout", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	timeout, raised := socketTimeoutFromObject(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	atomic.StoreInt64(&socketDefaultTimeout, int64(timeout))
+	return None, nil
+}
+
+func initSocketType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_socket").ToObject()
+	dict["accept"] = newBuiltinFunction("accept", socketAccept).ToObject()
+	dict["bind"] = newBuiltinFunction("bind", socketBind).ToObject()
+	dict["close"] = newBuiltinFunction("close", socketClose).ToObject()
+	dict["connect"] = newBuiltinFunction("connect", socketConnect).ToObject()
+	dict["connect_ex"] = newBuiltinFunction("connect_ex", socketConnectEx).ToObject()
+	dict["fileno"] = newBuiltinFunction("fileno", socketFileno).ToObject()
+	dict["getpeername"] = newBuiltinFunction("getpeername", socketGetpeername).ToObject()
+	dict["getsockname"] = newBuiltinFunction("getsockname", socketGetsockname).ToObject()
+	dict["getsockopt"] = newBuiltinFunction("getsockopt", socketGetsockopt).ToObject()
+	dict["gettimeout"] = newBuiltinFunction("gettimeout", socketGettimeout).ToObject()
+	dict["listen"] = newBuiltinFunction("listen", socketListen).ToObject()
+	dict["makefile"] = newBuiltinFunction("makefile", socketMakefile).ToObject()
+	dict["recv"] = newBuiltinFunction("recv", socketRecv).ToObject()
+	dict["recv_into"] = newBuiltinFunction("recv_into", socketRecvInto).ToObject()
+	dict["recvfrom"] = newBuiltinFunction("recvfrom", socketRecvfrom).ToObject()
+	dict["send"] = newBuiltinFunction("send", socketSend).ToObject()
+	dict["sendall"] = newBuiltinFunction("sendall", socketSendall).ToObject()
+	dict["sendto"] = newBuiltinFunction("sendto", socketSendto).ToObject()
+	dict["setblocking"] = newBuiltinFunction("setblocking", socketSetblocking).ToObject()
+	dict["setsockopt"] = newBuiltinFunction("setsockopt", socketSetsockopt).ToObject()
+	dict["settimeout"] = newBuiltinFunction("settimeout", socketSettimeout).ToObject()
+	dict["shutdown"] = newBuiltinFunction("shutdown", socketShutdown).ToObject()
+	socketType.slots.Init = &initSlot{socketInit}
+	socketType.slots.Repr = &unaryOpSlot{socketRepr}
+}
+
+func initSocketErrorType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("socket").ToObject()
+}
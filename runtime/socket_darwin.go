@@ -0,0 +1,32 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+// getaddrinfo error codes from Darwin's netdb.h.
+const (
+	socketEAIAgain   = 2
+	socketEAIFamily  = 5
+	socketEAINoName  = 8
+	socketEAIService = 9
+)
+
+// socketEAIMessages holds the gai_strerror(3) messages for the error codes
+// above.
+var socketEAIMessages = map[int]string{
+	socketEAIAgain:   "temporary failure in name resolution",
+	socketEAIFamily:  "ai_family not supported",
+	socketEAINoName:  "nodename nor servname provided, or not known",
+	socketEAIService: "servname not supported for ai_socktype",
+}
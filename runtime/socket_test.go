@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"syscall"
+	"testing"
+)
+
+func newTestSocket(t *testing.T, family, sockType int) *Object {
+	t.Helper()
+	o := mustNotRaise(socketType.Call(NewRootFrame(), wrapArgs(family, sockType), nil))
+	t.Cleanup(func() { socketClose(NewRootFrame(), Args{o}, nil) })
+	return o
+}
+
+func TestSocketSockaddr(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, s *socket, addr *Object) (*Object, *BaseException) {
+		sa, raised := s.sockaddr(f, addr)
+		if raised != nil {
+			return nil, raised
+		}
+		return socketAddrToObject(sa), nil
+	})
+	inet := newTestSocket(t, syscall.AF_INET, syscall.SOCK_STREAM)
+	inet6 := newTestSocket(t, syscall.AF_INET6, syscall.SOCK_STREAM)
+	unix := newTestSocket(t, syscall.AF_UNIX, syscall.SOCK_STREAM)
+	cases := []invokeTestCase{
+		{args: wrapArgs(inet, newTestTuple("127.0.0.1", 80)), want: newTestTuple("127.0.0.1", 80).ToObject()},
+		{args: wrapArgs(inet, newTestTuple("", 0)), want: newTestTuple("0.0.0.0", 0).ToObject()},
+		{args: wrapArgs(inet, newTestTuple("<broadcast>", 1)), want: newTestTuple("255.255.255.255", 1).ToObject()},
+		{args: wrapArgs(inet6, newTestTuple("::1", 80)), want: newTestTuple("::1", 80, 0, 0).ToObject()},
+		{args: wrapArgs(inet6, newTestTuple("::1", 80, 0, 3)), want: newTestTuple("::1", 80, 0, 3).ToObject()},
+		{args: wrapArgs(unix, "/tmp/foo"), want: NewStr("/tmp/foo").ToObject()},
+		{args: wrapArgs(inet, "foo"), wantExc: mustCreateException(TypeErrorType, "getsockaddrarg: AF_INET address must be a (host, port) tuple")},
+		{args: wrapArgs(inet, newTestTuple("127.0.0.1", 80, 0)), wantExc: mustCreateException(TypeErrorType, "getsockaddrarg: AF_INET address must be a (host, port) tuple")},
+		{args: wrapArgs(inet, newTestTuple("127.0.0.1", -1)), wantExc: mustCreateException(OverflowErrorType, "getsockaddrarg: port must be 0-65535.")},
+		{args: wrapArgs(unix, newTestTuple("foo", 1)), wantExc: mustCreateException(TypeErrorType, "getsockaddrarg: AF_UNIX address must be str, not tuple")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSocketRoundTrip(t *testing.T) {
+	f := NewRootFrame()
+	server := newTestSocket(t, syscall.AF_INET, syscall.SOCK_STREAM)
+	mustNotRaise(socketBind(f, wrapArgs(server, newTestTuple("127.0.0.1", 0)), nil))
+	mustNotRaise(socketListen(f, Args{server}, nil))
+	addr := mustNotRaise(socketGetsockname(f, Args{server}, nil))
+	client := newTestSocket(t, syscall.AF_INET, syscall.SOCK_STREAM)
+	mustNotRaise(socketConnect(f, Args{client, addr}, nil))
+	accepted := mustNotRaise(socketAccept(f, Args{server}, nil))
+	conn := toTupleUnsafe(accepted).elems[0]
+	defer socketClose(f, Args{conn}, nil)
+	mustNotRaise(socketSendall(f, wrapArgs(client, "foo"), nil))
+	cases := []invokeTestCase{
+		{args: wrapArgs(conn, 1024), want: NewStr("foo").ToObject()},
+		{args: wrapArgs(conn, -1), wantExc: mustCreateException(ValueErrorType, "negative buffersize in recv")},
+	}
+	recv := mustNotRaise(GetAttr(f, socketType.ToObject(), NewStr("recv"), nil))
+	for _, cas := range cases {
+		if err := runInvokeTestCase(recv, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	mustNotRaise(socketSettimeout(f, wrapArgs(conn, 0.01), nil))
+	cas := invokeTestCase{args: wrapArgs(conn, 1024), wantExc: mustCreateException(socketTimeoutType, "timed out")}
+	if err := runInvokeTestCase(recv, &cas); err != "" {
+		t.Error(err)
+	}
+}
+
+func TestSocketGetaddrinfo(t *testing.T) {
+	tcp := newTestTuple(syscall.AF_INET, syscall.SOCK_STREAM, syscall.IPPROTO_TCP, "", newTestTuple("127.0.0.1", 80))
+	udp := newTestTuple(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_UDP, "", newTestTuple("127.0.0.1", 80))
+	noName := mustCreateException(socketGaierrorType, "")
+	noName.args = newTestTuple(socketEAINoName, socketEAIMessages[socketEAINoName])
+	cases := []invokeTestCase{
+		{args: wrapArgs("127.0.0.1", 80), want: newTestList(tcp, udp).ToObject()},
+		{args: wrapArgs("127.0.0.1", "80", syscall.AF_INET, syscall.SOCK_STREAM), want: newTestList(tcp).ToObject()},
+		{args: wrapArgs("127.0.0.1", None, 0, 0, syscall.IPPROTO_UDP), want: newTestList(newTestTuple(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_UDP, "", newTestTuple("127.0.0.1", 0))).ToObject()},
+		{args: wrapArgs("127.0.0.1", 80, syscall.AF_INET6), wantExc: noName},
+		{args: wrapArgs(123, 80), wantExc: mustCreateException(TypeErrorType, "getaddrinfo() argument 1 must be string or None")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(mustGetModuleAttr("_socket", "getaddrinfo"), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
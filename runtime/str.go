@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"unicode"
 	"unicode/utf8"
@@ -36,7 +37,7 @@ var (
 	internedStrs           = map[string]*Str{}
 	caseOffset             = byte('a' - 'A')
 
-	internedName = NewStr("__name__")
+	internedName = InternStr("__name__")
 )
 
 type stripSide int
@@ -53,17 +54,64 @@ const (
 func InternStr(s string) *Str {
 	str, _ := internedStrs[s]
 	if str == nil {
-		str = &Str{Object: Object{typ: StrType}, value: s, hash: NewInt(hashString(s))}
+		str = &Str{Object: Object{typ: StrType}, value: s, hash: NewInt(hashString(s)), interned: true}
 		internedStrs[s] = str
 	}
 	return str
 }
 
+// strBufferMinLen is the length at which concatenation starts producing Strs
+// backed by a strBuffer.
+const strBufferMinLen = 256
+
 // Str represents Python 'str' objects.
 type Str struct {
 	Object
 	value string
 	hash  *Int
+	// buffer is non-nil when value is a view of the bytes in a strBuffer
+	// because s was produced by concatenation.
+	buffer *strBuffer
+	// interned is true for Strs created by InternStr. Only these are
+	// stored in the identity keyed lookup caches.
+	interned bool
+}
+
+// strBuffer is the growable backing store shared by Strs produced by repeated
+// concatenation, e.g. "s += piece" in a loop. Each such Str is a view of a
+// prefix of buf and the Str that views all of buf may extend it in place, so
+// building a string this way is amortized linear rather than quadratic. Bytes
+// that have been appended to buf are never modified so existing views remain
+// immutable.
+type strBuffer struct {
+	mutex sync.Mutex
+	buf   []byte
+}
+
+// strConcat returns the concatenation of v and w. When inplace is true the
+// result replaces v as in "v += w", so v's buffer is extended in place when
+// possible, otherwise a new buffer with room to grow is allocated. Plain
+// concatenation allocates exactly the space it needs.
+func strConcat(v, w *Str, inplace bool) *Str {
+	n := len(v.value) + len(w.value)
+	if !inplace || n < strBufferMinLen {
+		return NewStr(v.value + w.value)
+	}
+	if b := v.buffer; b != nil {
+		b.mutex.Lock()
+		// Since the contents of buf only ever grow, v views all of it
+		// when the lengths match.
+		if len(b.buf) == len(v.value) {
+			b.buf = append(b.buf, w.value...)
+			value := unsafe.String(&b.buf[0], len(b.buf))
+			b.mutex.Unlock()
+			return &Str{Object: Object{typ: StrType}, value: value, buffer: b}
+		}
+		b.mutex.Unlock()
+	}
+	b := &strBuffer{buf: make([]byte, 0, 2*n)}
+	b.buf = append(append(b.buf, v.value...), w.value...)
+	return &Str{Object: Object{typ: StrType}, value: unsafe.String(&b.buf[0], n), buffer: b}
 }
 
 // NewStr returns a new Str holding the given string value.
@@ -112,6 +160,17 @@ func (s *Str) Decode(f *Frame, encoding, errors string) (*Unicode, *BaseExceptio
 	return NewUnicodeFromRunes(runes), nil
 }
 
+// loadHash returns the hash of s, computing and caching it on first use.
+func (s *Str) loadHash() *Int {
+	p := (*unsafe.Pointer)(unsafe.Pointer(&s.hash))
+	if v := atomic.LoadPointer(p); v != unsafe.Pointer(nil) {
+		return (*Int)(v)
+	}
+	h := NewInt(hashString(s.Value()))
+	atomic.StorePointer(p, unsafe.Pointer(h))
+	return h
+}
+
 // ToObject upcasts s to an Object.
 func (s *Str) ToObject() *Object {
 	return &s.Object
@@ -152,12 +211,23 @@ func strAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
 	if !w.isInstance(StrType) {
 		return NotImplemented, nil
 	}
-	stringV, stringW := toStrUnsafe(v).Value(), toStrUnsafe(w).Value()
-	if len(stringV)+len(stringW) < 0 {
+	strV, strW := toStrUnsafe(v), toStrUnsafe(w)
+	if len(strV.Value())+len(strW.Value()) < 0 {
+		// This indicates an int overflow.
+		return nil, f.RaiseType(OverflowErrorType, errResultTooLarge)
+	}
+	return strConcat(strV, strW, false).ToObject(), nil
+}
+
+// strIAdd implements "v += w" where v and w are both exactly str. Python's
+// str has no __iadd__ so this is called directly by IAdd.
+func strIAdd(f *Frame, v, w *Object) (*Object, *BaseException) {
+	strV, strW := toStrUnsafe(v), toStrUnsafe(w)
+	if len(strV.Value())+len(strW.Value()) < 0 {
 		// This indicates an int overflow.
 		return nil, f.RaiseType(OverflowErrorType, errResultTooLarge)
 	}
-	return NewStr(stringV + stringW).ToObject(), nil
+	return strConcat(strV, strW, true).ToObject(), nil
 }
 
 func strCapitalize(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
@@ -260,6 +330,11 @@ func strGE(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return strCompare(v, w, False, True, True), nil
 }
 
+func strGetBuffer(f *Frame, o *Object, fun func([]byte) *BaseException) *BaseException {
+	s := toStrUnsafe(o).Value()
+	return fun(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
 // strGetItem returns a slice of string depending on whether index is an integer
 // or a slice. If index is neither of those types then a TypeError is returned.
 func strGetItem(f *Frame, o, key *Object) (*Object, *BaseException) {
@@ -297,7 +372,11 @@ func strGetNewArgs(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "__getnewargs__", args, StrType); raised != nil {
 		return nil, raised
 	}
-	return NewTuple1(args[0]).ToObject(), nil
+	o := args[0]
+	if o.typ != StrType {
+		o = NewStr(toStrUnsafe(args[0]).Value()).ToObject()
+	}
+	return NewTuple1(o).ToObject(), nil
 }
 
 func strGT(f *Frame, v, w *Object) (*Object, *BaseException) {
@@ -305,14 +384,7 @@ func strGT(f *Frame, v, w *Object) (*Object, *BaseException) {
 }
 
 func strHash(f *Frame, o *Object) (*Object, *BaseException) {
-	s := toStrUnsafe(o)
-	p := (*unsafe.Pointer)(unsafe.Pointer(&s.hash))
-	if v := atomic.LoadPointer(p); v != unsafe.Pointer(nil) {
-		return (*Int)(v).ToObject(), nil
-	}
-	h := NewInt(hashString(toStrUnsafe(o).Value()))
-	atomic.StorePointer(p, unsafe.Pointer(h))
-	return h.ToObject(), nil
+	return toStrUnsafe(o).loadHash().ToObject(), nil
 }
 
 func strIndex(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
@@ -966,6 +1038,7 @@ func initStrType(dict map[string]*Object) {
 	dict["upper"] = newBuiltinFunction("upper", strUpper).ToObject()
 	dict["zfill"] = newBuiltinFunction("zfill", strZFill).ToObject()
 	StrType.slots.Add = &binaryOpSlot{strAdd}
+	StrType.slots.Buffer = &bufferSlot{strGetBuffer}
 	StrType.slots.Contains = &binaryOpSlot{strContains}
 	StrType.slots.Eq = &binaryOpSlot{strEq}
 	StrType.slots.GE = &binaryOpSlot{strGE}
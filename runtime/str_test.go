@@ -19,6 +19,7 @@ import (
 	"math/big"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -122,6 +123,35 @@ func TestStrBinaryOps(t *testing.T) {
 	}
 }
 
+func TestStrConcatBuffer(t *testing.T) {
+	f := NewRootFrame()
+	piece := NewStr(strings.Repeat("x", 100)).ToObject()
+	s := NewStr("").ToObject()
+	var history []*Object
+	for i := 0; i < 100; i++ {
+		s = mustNotRaise(IAdd(f, s, piece))
+		history = append(history, s)
+	}
+	if got := toStrUnsafe(s).buffer; got == nil || got != toStrUnsafe(history[50]).buffer {
+		t.Errorf("repeated concatenation did not share a buffer")
+	}
+	// Concatenating onto a Str that no longer views the whole buffer must
+	// leave the Strs that do unaffected.
+	fork := mustNotRaise(IAdd(f, history[50], NewStr("y").ToObject()))
+	for i, o := range history {
+		if want := strings.Repeat("x", 100*(i+1)); toStrUnsafe(o).Value() != want {
+			t.Errorf("history[%d] has length %d, want %d", i, len(toStrUnsafe(o).Value()), len(want))
+		}
+	}
+	if want := strings.Repeat("x", 5100) + "y"; toStrUnsafe(fork).Value() != want {
+		t.Errorf("fork = %q, want %q", toStrUnsafe(fork).Value(), want)
+	}
+	// Plain concatenation never over-allocates.
+	if sum := mustNotRaise(Add(f, s, piece)); toStrUnsafe(sum).buffer != nil {
+		t.Errorf("Add produced a buffer-backed str")
+	}
+}
+
 func TestStrCompare(t *testing.T) {
 	cases := []invokeTestCase{
 		{args: wrapArgs("", ""), want: compareAllResultEq},
@@ -0,0 +1,442 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Special values for the stdin, stdout and stderr arguments of spawn. Any
+// other negative value is invalid and non-negative values are file
+// descriptors.
+const (
+	subprocessPipe    = -1
+	subprocessStdout  = -2
+	subprocessDevNull = -3
+)
+
+var (
+	// processType corresponds to the Python type '_subprocess.Process'.
+	processType = newBasisType("Process", reflect.TypeOf(process{}), toProcessUnsafe, ObjectType)
+)
+
+// process represents a child process started by _subprocess.spawn. It is the
+// Go half of subprocess.Popen.
+type process struct {
+	Object
+	cmd    *exec.Cmd
+	pid    int   `attr:"pid"`
+	stdin  *File `attr:"stdin"`
+	stdout *File `attr:"stdout"`
+	stderr *File `attr:"stderr"`
+	// done is closed once the child has been reaped, after which
+	// returncode holds its exit status.
+	done       chan struct{}
+	returncode int
+	mutex      sync.Mutex
+	comm       *processComm
+}
+
+// processComm tracks an in-progress communicate() call. It outlives any
+// individual call that times out so that a subsequent call picks up where the
+// previous one left off.
+type processComm struct {
+	wg     sync.WaitGroup
+	stdout []byte
+	stderr []byte
+	err    error
+}
+
+func toProcessUnsafe(o *Object) *process {
+	return (*process)(o.toPointer())
+}
+
+// ToObject upcasts p to an Object.
+func (p *process) ToObject() *Object {
+	return &p.Object
+}
+
+func init() {
+	RegisterModule("_subprocess", NewCode("<module>", "_subprocess", nil, 0, subprocessInit))
+}
+
+func subprocessInit(f *Frame, _ []*Object) (*Object, *BaseException) {
+	globals := map[string]*Object{
+		"DEVNULL": NewInt(subprocessDevNull).ToObject(),
+		"PIPE":    NewInt(subprocessPipe).ToObject(),
+		"Process": processType.ToObject(),
+		"STDOUT":  NewInt(subprocessStdout).ToObject(),
+		"spawn":   newBuiltinFunction("spawn", subprocessSpawn).ToObject(),
+	}
+	for name, value := range globals {
+		if raised := f.Globals().SetItemString(f, name, value); raised != nil {
+			return nil, raised
+		}
+	}
+	return nil, nil
+}
+
+// subprocessSpawn implements _subprocess.spawn(args, executable, cwd, env,
+// stdin, stdout, stderr). args is a sequence of strings. executable, cwd and
+// env (a dict) may be None. The remaining arguments are None to inherit the
+// parent's stream, a file descriptor or one of PIPE, STDOUT or DEVNULL.
+func subprocessSpawn(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "spawn", args, ObjectType, ObjectType, ObjectType, ObjectType, ObjectType, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	var argv []string
+	raised := seqForEach(f, args[0], func(o *Object) *BaseException {
+		if !o.isInstance(StrType) {
+			return f.RaiseType(TypeErrorType, "spawn() args must be a sequence of strings")
+		}
+		argv = append(argv, toStrUnsafe(o).Value())
+		return nil
+	})
+	if raised != nil {
+		return nil, raised
+	}
+	if len(argv) == 0 {
+		return nil, f.RaiseType(ValueErrorType, "spawn() args must not be empty")
+	}
+	cmd := &exec.Cmd{Args: argv}
+	executable := argv[0]
+	if args[1] != None {
+		if !args[1].isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, "spawn() executable must be a string")
+		}
+		executable = toStrUnsafe(args[1]).Value()
+	}
+	if cmd.Path, raised = subprocessLookPath(f, executable); raised != nil {
+		return nil, raised
+	}
+	if args[2] != None {
+		if !args[2].isInstance(StrType) {
+			return nil, f.RaiseType(TypeErrorType, "spawn() cwd must be a string")
+		}
+		cmd.Dir = toStrUnsafe(args[2]).Value()
+	}
+	if args[3] != None {
+		if !args[3].isInstance(DictType) {
+			return nil, f.RaiseType(TypeErrorType, "spawn() env must be a dict")
+		}
+		// A non-nil empty slice gives the child an empty environment.
+		cmd.Env = []string{}
+		env := toDictUnsafe(args[3])
+		for _, k := range env.Keys(f).elems {
+			v, raised := env.GetItem(f, k)
+			if raised != nil {
+				return nil, raised
+			}
+			if v == nil || !k.isInstance(StrType) || !v.isInstance(StrType) {
+				return nil, f.RaiseType(TypeErrorType, "spawn() env must map strings to strings")
+			}
+			cmd.Env = append(cmd.Env, toStrUnsafe(k).Value()+"="+toStrUnsafe(v).Value())
+		}
+	}
+	p := &process{Object: Object{typ: processType}, cmd: cmd, done: make(chan struct{})}
+	// childFiles are closed in the parent once the child has started (or
+	// failed to start).
+	var childFiles []*os.File
+	defer func() {
+		for _, file := range childFiles {
+			file.Close()
+		}
+	}()
+	closeParentFiles := func() {
+		for _, file := range []*File{p.stdin, p.stdout, p.stderr} {
+			if file != nil {
+				file.file.Close()
+			}
+		}
+	}
+	streams := []struct {
+		spec   *Object
+		std    *os.File
+		parent **File
+		isIn   bool
+	}{
+		{args[4], os.Stdin, &p.stdin, true},
+		{args[5], os.Stdout, &p.stdout, false},
+		{args[6], os.Stderr, &p.stderr, false},
+	}
+	var childStreams [3]*os.File
+	for i, stream := range streams {
+		child := stream.std
+		if spec := stream.spec; spec != None {
+			if !spec.isInstance(IntType) {
+				closeParentFiles()
+				return nil, f.RaiseType(TypeErrorType, "spawn() stream must be None or an int")
+			}
+			var err error
+			switch fd := toIntUnsafe(spec).Value(); {
+			case fd == subprocessPipe:
+				var r, w *os.File
+				if r, w, err = os.Pipe(); err == nil {
+					if stream.isIn {
+						child, *stream.parent = r, newFileFromOSFile(w, "wb")
+					} else {
+						child, *stream.parent = w, newFileFromOSFile(r, "rb")
+					}
+					childFiles = append(childFiles, child)
+				}
+			case fd == subprocessStdout && i == 2:
+				child = childStreams[1]
+			case fd == subprocessDevNull:
+				if child, err = os.OpenFile(os.DevNull, os.O_RDWR, 0); err == nil {
+					childFiles = append(childFiles, child)
+				}
+			case fd >= 0:
+				// Duplicate fd so the caller retains ownership of
+				// the original descriptor.
+				var dupFd int
+				if dupFd, err = syscall.Dup(fd); err == nil {
+					syscall.CloseOnExec(dupFd)
+					child = os.NewFile(uintptr(dupFd), fmt.Sprintf("<fd %d>", fd))
+					childFiles = append(childFiles, child)
+				}
+			default:
+				closeParentFiles()
+				return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("invalid stream value: %d", fd))
+			}
+			if err != nil {
+				closeParentFiles()
+				return nil, posixError(f, err)
+			}
+		}
+		childStreams[i] = child
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = childStreams[0], childStreams[1], childStreams[2]
+	if err := cmd.Start(); err != nil {
+		closeParentFiles()
+		return nil, posixError(f, err)
+	}
+	p.pid = cmd.Process.Pid
+	go func() {
+		// The error is reflected in ProcessState so it can be ignored.
+		cmd.Wait()
+		ws := cmd.ProcessState.Sys().(syscall.WaitStatus)
+		if ws.Signaled() {
+			p.returncode = -int(ws.Signal())
+		} else {
+			p.returncode = ws.ExitStatus()
+		}
+		close(p.done)
+	}()
+	return p.ToObject(), nil
+}
+
+// subprocessLookPath resolves name against PATH like the shell does, raising
+// OSError with errno ENOENT or EACCES when no suitable executable is found.
+func subprocessLookPath(f *Frame, name string) (string, *BaseException) {
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	errno := syscall.ENOENT
+	if os.IsPermission(err) {
+		errno = syscall.EACCES
+	}
+	return "", posixPathError(f, "exec", name, errno)
+}
+
+// processTimeout converts a Python timeout argument to a channel that is
+// signaled when the timeout elapses. A timeout of None yields a nil channel
+// which blocks forever.
+func processTimeout(f *Frame, o *Object) (<-chan time.Time, *BaseException) {
+	if o == None {
+		return nil, nil
+	}
+	secs, ok := floatCoerce(o)
+	if !ok {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("timeout must be a number, not %s", o.typ.Name()))
+	}
+	return time.After(time.Duration(secs * float64(time.Second))), nil
+}
+
+func processCommunicate(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "communicate", args, processType, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	p := toProcessUnsafe(args[0])
+	if args[1] != None && !args[1].isInstance(StrType) {
+		return nil, f.RaiseType(TypeErrorType, "communicate() input must be a string or None")
+	}
+	timeout, raised := processTimeout(f, args[2])
+	if raised != nil {
+		return nil, raised
+	}
+	p.mutex.Lock()
+	comm := p.comm
+	if comm == nil {
+		comm = p.startCommunicate(args[1])
+		p.comm = comm
+	}
+	p.mutex.Unlock()
+	finished := make(chan struct{})
+	go func() {
+		comm.wg.Wait()
+		<-p.done
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-timeout:
+		return None, nil
+	}
+	if comm.err != nil {
+		return nil, raiseEnvironmentError(f, IOErrorType, comm.err)
+	}
+	result := func(file *File, data []byte) *Object {
+		if file == nil {
+			return None
+		}
+		return NewStr(string(data)).ToObject()
+	}
+	return NewTuple2(result(p.stdout, comm.stdout), result(p.stderr, comm.stderr)).ToObject(), nil
+}
+
+// startCommunicate starts goroutines that write input to the child's stdin
+// and read its stdout and stderr until EOF. Each pipe is serviced
+// concurrently so that a child blocked writing to one of them can't deadlock
+// with the parent.
+func (p *process) startCommunicate(input *Object) *processComm {
+	comm := &processComm{}
+	var errMutex sync.Mutex
+	setErr := func(err error) {
+		errMutex.Lock()
+		if comm.err == nil {
+			comm.err = err
+		}
+		errMutex.Unlock()
+	}
+	if p.stdin != nil {
+		comm.wg.Add(1)
+		go func() {
+			defer comm.wg.Done()
+			if input != None {
+				err := p.stdin.writeString(toStrUnsafe(input).Value())
+				// The child exiting without reading its input is
+				// not an error.
+				if err != nil && !isBrokenPipe(err) {
+					setErr(err)
+				}
+			}
+			p.stdin.closeFile()
+		}()
+	}
+	readAll := func(file *File, dst *[]byte) {
+		comm.wg.Add(1)
+		go func() {
+			defer comm.wg.Done()
+			data, err := io.ReadAll(file.reader)
+			*dst = data
+			if err != nil {
+				setErr(err)
+			}
+			file.closeFile()
+		}()
+	}
+	if p.stdout != nil {
+		readAll(p.stdout, &comm.stdout)
+	}
+	if p.stderr != nil {
+		readAll(p.stderr, &comm.stderr)
+	}
+	return comm
+}
+
+func isBrokenPipe(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && errno == syscall.EPIPE
+}
+
+func processPoll(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "poll", args, processType); raised != nil {
+		return nil, raised
+	}
+	p := toProcessUnsafe(args[0])
+	select {
+	case <-p.done:
+		return NewInt(p.returncode).ToObject(), nil
+	default:
+		return None, nil
+	}
+}
+
+// processSignal returns a method that sends sig to the process. Signaling a
+// process that has already exited is a no-op.
+func processSignal(name string, sig syscall.Signal) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, name, args, processType); raised != nil {
+			return nil, raised
+		}
+		return toProcessUnsafe(args[0]).signal(f, sig)
+	}
+}
+
+func processSendSignal(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "send_signal", args, processType, IntType); raised != nil {
+		return nil, raised
+	}
+	return toProcessUnsafe(args[0]).signal(f, syscall.Signal(toIntUnsafe(args[1]).Value()))
+}
+
+func (p *process) signal(f *Frame, sig syscall.Signal) (*Object, *BaseException) {
+	select {
+	case <-p.done:
+		return None, nil
+	default:
+	}
+	if err := p.cmd.Process.Signal(sig); err != nil && err != os.ErrProcessDone {
+		return nil, posixError(f, err)
+	}
+	return None, nil
+}
+
+func processWait(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "wait", args, processType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	p := toProcessUnsafe(args[0])
+	timeout, raised := processTimeout(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	select {
+	case <-p.done:
+		return NewInt(p.returncode).ToObject(), nil
+	case <-timeout:
+		return None, nil
+	}
+}
+
+func initProcessType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("_subprocess").ToObject()
+	dict["communicate"] = newBuiltinFunction("communicate", processCommunicate).ToObject()
+	dict["kill"] = newBuiltinFunction("kill", processSignal("kill", syscall.SIGKILL)).ToObject()
+	dict["poll"] = newBuiltinFunction("poll", processPoll).ToObject()
+	dict["send_signal"] = newBuiltinFunction("send_signal", processSendSignal).ToObject()
+	dict["terminate"] = newBuiltinFunction("terminate", processSignal("terminate", syscall.SIGTERM)).ToObject()
+	dict["wait"] = newBuiltinFunction("wait", processWait).ToObject()
+	processType.flags &^= typeFlagBasetype | typeFlagInstantiable
+}
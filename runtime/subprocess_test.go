@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubprocessSpawn(t *testing.T) {
+	spawn := mustGetModuleAttr("_subprocess", "spawn")
+	fun := wrapFuncForTest(func(f *Frame, args *List, input *Object, stdout, stderr *Object) (*Tuple, *BaseException) {
+		stdin := None
+		if input != None {
+			stdin = NewInt(subprocessPipe).ToObject()
+		}
+		p, raised := spawn.Call(f, Args{args.ToObject(), None, None, None, stdin, stdout, stderr}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		out, raised := processCommunicate(f, Args{p, input, None}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		returncode, raised := processWait(f, Args{p, None}, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(out, returncode), nil
+	})
+	pipe, stdout, devnull := subprocessPipe, subprocessStdout, subprocessDevNull
+	bigInput := strings.Repeat("x", 1<<20)
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestList("echo", "foo"), None, pipe, None), want: newTestTuple(newTestTuple("foo\n", None), 0).ToObject()},
+		{args: wrapArgs(newTestList("sh", "-c", "echo foo >&2; exit 3"), None, pipe, stdout), want: newTestTuple(newTestTuple("foo\n", None), 3).ToObject()},
+		{args: wrapArgs(newTestList("sh", "-c", "echo foo >&2"), None, devnull, pipe), want: newTestTuple(newTestTuple(None, "foo\n"), 0).ToObject()},
+		{args: wrapArgs(newTestList("sh", "-c", "kill $$"), None, None, None), want: newTestTuple(newTestTuple(None, None), -15).ToObject()},
+		{args: wrapArgs(newTestList("cat"), bigInput, pipe, None), want: newTestTuple(newTestTuple(bigInput, None), 0).ToObject()},
+		{args: wrapArgs(newTestList("cat"), "foo", devnull, None), want: newTestTuple(newTestTuple(None, None), 0).ToObject()},
+		{args: wrapArgs(NewList(), None, None, None), wantExc: mustCreateException(ValueErrorType, "spawn() args must not be empty")},
+		{args: wrapArgs(newTestList("echo"), None, -4, None), wantExc: mustCreateException(ValueErrorType, "invalid stream value: -4")},
+		{args: wrapArgs(newTestList("echo"), None, None, "foo"), wantExc: mustCreateException(TypeErrorType, "spawn() stream must be None or an int")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestProcessWaitTimeout(t *testing.T) {
+	f := NewRootFrame()
+	spawn := mustGetModuleAttr("_subprocess", "spawn")
+	p := mustNotRaise(spawn.Call(f, wrapArgs(newTestList("sleep", "10"), None, None, None, None, None, None), nil))
+	if got := mustNotRaise(processPoll(f, Args{p}, nil)); got != None {
+		t.Errorf("poll() = %v, want None", got)
+	}
+	if got := mustNotRaise(processWait(f, wrapArgs(p, 0.01), nil)); got != None {
+		t.Errorf("wait(0.01) = %v, want None", got)
+	}
+	mustNotRaise(processSignal("kill", 9)(f, Args{p}, nil))
+	if got := mustNotRaise(processWait(f, wrapArgs(p, None), nil)); !got.isInstance(IntType) || toIntUnsafe(got).Value() != -9 {
+		t.Errorf("wait() = %v, want -9", got)
+	}
+}
@@ -0,0 +1,668 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unicode/utf8"
+)
+
+const textIOChunkSize = 8192
+
+var (
+	// textIOWrapperType corresponds to the Python type
+	// '_io.TextIOWrapper'.
+	textIOWrapperType     = newBasisType("TextIOWrapper", reflect.TypeOf(textIOWrapper{}), toTextIOWrapperUnsafe, textIOBaseType)
+	textIOWrapperInitSpec = NewParamSpec("TextIOWrapper", []Param{{"buffer", nil}, {"encoding", None}, {"errors", None}, {"newline", None}, {"line_buffering", False.ToObject()}}, false, false)
+)
+
+// textIOWrapper represents Python '_io.TextIOWrapper' objects. Decoded text
+// is held untranslated in decoded so that the byte position of the wrapper
+// can be derived from that of the underlying buffer. pending holds the
+// trailing bytes of an incomplete multibyte sequence.
+type textIOWrapper struct {
+	ioBase
+	mutex    sync.Mutex
+	buffer   *Object
+	encoding string
+	errors   string
+	// readUniversal is true when any of \r, \n or \r\n terminate lines
+	// and readTranslate is true when they're all translated to \n. When
+	// readUniversal is false, readNL is the only line terminator.
+	readUniversal bool
+	readTranslate bool
+	readNL        string
+	// writeNL replaces \n on output unless it is empty.
+	writeNL       string
+	lineBuffering bool
+	decoded       []rune
+	dpos          int
+	pending       []byte
+	eof           bool
+	seenNewlines  int
+	detached      bool
+}
+
+func toTextIOWrapperUnsafe(o *Object) *textIOWrapper {
+	return (*textIOWrapper)(o.toPointer())
+}
+
+// ToObject upcasts t to an Object.
+func (t *textIOWrapper) ToObject() *Object {
+	return &t.Object
+}
+
+// check raises ValueError if t is detached, uninitialized or closed.
+func (t *textIOWrapper) check(f *Frame) *BaseException {
+	if t.buffer == nil {
+		return f.RaiseType(ValueErrorType, "I/O operation on uninitialized object")
+	}
+	if t.detached {
+		return f.RaiseType(ValueErrorType, "underlying buffer has been detached")
+	}
+	return ioCheckClosed(f, t.buffer)
+}
+
+// readChunk reads the next chunk of bytes from the buffer and decodes it.
+// It returns false at EOF.
+func (t *textIOWrapper) readChunk(f *Frame) (bool, *BaseException) {
+	if t.eof {
+		return false, nil
+	}
+	method := "read1"
+	if read1, raised := GetAttr(f, t.buffer, NewStr("read1"), None); raised != nil {
+		return false, raised
+	} else if read1 == None {
+		method = "read"
+	}
+	data, raised := ioCall(f, t.buffer, method, NewInt(textIOChunkSize).ToObject())
+	if raised != nil {
+		return false, raised
+	}
+	if !data.isInstance(StrType) {
+		return false, f.RaiseType(TypeErrorType, fmt.Sprintf("underlying %s() should have returned a bytes object, not '%s'", method, data.typ.Name()))
+	}
+	s := toStrUnsafe(data).Value()
+	t.pending = append(t.pending, s...)
+	n := len(t.pending)
+	if s == "" {
+		t.eof = true
+	} else {
+		// Hold back a trailing incomplete multibyte sequence until more
+		// data arrives.
+		for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+			if utf8.RuneStart(t.pending[i]) {
+				if !utf8.FullRune(t.pending[i:]) {
+					n = i
+				}
+				break
+			}
+		}
+	}
+	if n > 0 {
+		// Only UTF-8 is supported so decode using the canonical codec
+		// name which appears in error messages.
+		u, raised := NewStr(string(t.pending[:n])).Decode(f, EncodeDefault, t.errors)
+		if raised != nil {
+			return false, raised
+		}
+		if t.dpos == len(t.decoded) {
+			t.decoded, t.dpos = nil, 0
+		}
+		t.decoded = append(t.decoded, u.Value()...)
+		t.pending = append([]byte(nil), t.pending[n:]...)
+	}
+	return !t.eof, nil
+}
+
+// readRunes consumes and returns at most limit translated runes (all of them
+// when limit is negative), stopping after the first line terminator when
+// line is true.
+func (t *textIOWrapper) readRunes(f *Frame, limit int, line bool) ([]rune, *BaseException) {
+	var out []rune
+	for {
+		avail := t.decoded[t.dpos:]
+		i, done := 0, false
+		for i < len(avail) && !done && (limit < 0 || len(out) < limit) {
+			r := avail[i]
+			if !t.readUniversal {
+				out = append(out, r)
+				i++
+				if line && r == rune(t.readNL[len(t.readNL)-1]) {
+					tail := out
+					if len(tail) > len(t.readNL) {
+						tail = tail[len(tail)-len(t.readNL):]
+					}
+					done = string(tail) == t.readNL
+				}
+				continue
+			}
+			if r == '\r' && i+1 == len(avail) && !t.eof {
+				// Need to see the next rune to detect \r\n.
+				break
+			}
+			switch {
+			case r == '\r' && i+1 < len(avail) && avail[i+1] == '\n':
+				t.seenNewlines |= ioSeenCRLF
+				if t.readTranslate {
+					out = append(out, '\n')
+				} else {
+					out = append(out, '\r', '\n')
+				}
+				i += 2
+				done = line
+			case r == '\r':
+				t.seenNewlines |= ioSeenCR
+				if t.readTranslate {
+					r = '\n'
+				}
+				out = append(out, r)
+				i++
+				done = line
+			case r == '\n':
+				t.seenNewlines |= ioSeenLF
+				out = append(out, r)
+				i++
+				done = line
+			default:
+				out = append(out, r)
+				i++
+			}
+		}
+		t.dpos += i
+		if done || (limit >= 0 && len(out) >= limit) {
+			return out, nil
+		}
+		if t.eof && t.dpos == len(t.decoded) {
+			return out, nil
+		}
+		if _, raised := t.readChunk(f); raised != nil {
+			return nil, raised
+		}
+	}
+}
+
+// rewind discards decoded data that has not been consumed, moving the
+// buffer's position back to the logical position of t.
+func (t *textIOWrapper) rewind(f *Frame) *BaseException {
+	if t.dpos == len(t.decoded) && len(t.pending) == 0 {
+		t.decoded, t.dpos, t.eof = nil, 0, false
+		return nil
+	}
+	pos, raised := t.tell(f)
+	if raised != nil {
+		return raised
+	}
+	t.decoded, t.dpos, t.pending, t.eof = nil, 0, nil, false
+	_, raised = ioCall(f, t.buffer, "seek", NewInt(pos).ToObject())
+	return raised
+}
+
+func (t *textIOWrapper) tell(f *Frame) (int, *BaseException) {
+	result, raised := ioCall(f, t.buffer, "tell")
+	if raised != nil {
+		return 0, raised
+	}
+	pos, raised := IndexInt(f, result)
+	if raised != nil {
+		return 0, raised
+	}
+	for _, r := range t.decoded[t.dpos:] {
+		pos -= utf8.RuneLen(r)
+	}
+	return pos - len(t.pending), nil
+}
+
+// textIOMethod is the signature of TextIOWrapper methods. They are called
+// with t's mutex held.
+type textIOMethod func(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException)
+
+// newTextIOMethod returns a builtin function named name that checks its
+// receiver is a TextIOWrapper before calling fn. Unless unchecked is true,
+// it also ensures the wrapper is usable.
+func newTextIOMethod(name string, unchecked bool, fn textIOMethod) *Object {
+	return newBuiltinFunction(name, func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+		if raised := checkMethodVarArgs(f, name, args, textIOWrapperType); raised != nil {
+			return nil, raised
+		}
+		if len(kwargs) > 0 {
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("%s() takes no keyword arguments", name))
+		}
+		t := toTextIOWrapperUnsafe(args[0])
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		if !unchecked {
+			if raised := t.check(f); raised != nil {
+				return nil, raised
+			}
+		}
+		return fn(f, t, args)
+	}).ToObject()
+}
+
+func textIOWrapperClose(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "close", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if t.buffer == nil || t.detached {
+		return nil, f.RaiseType(ValueErrorType, "underlying buffer has been detached")
+	}
+	closed, raised := GetAttr(f, t.buffer, NewStr("closed"), nil)
+	if raised != nil {
+		return nil, raised
+	}
+	isClosed, raised := IsTrue(f, closed)
+	if raised != nil {
+		return nil, raised
+	}
+	if isClosed {
+		return None, nil
+	}
+	_, flushRaised := ioCall(f, t.buffer, "flush")
+	_, raised = ioCall(f, t.buffer, "close")
+	if flushRaised != nil {
+		return nil, flushRaised
+	}
+	if raised != nil {
+		return nil, raised
+	}
+	return None, nil
+}
+
+func textIOWrapperDelegate(name string) textIOMethod {
+	return func(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, name, args, ObjectType); raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, t.buffer, name)
+	}
+}
+
+func textIOWrapperDetach(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "detach", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if t.buffer == nil || t.detached {
+		return nil, f.RaiseType(ValueErrorType, "underlying buffer has been detached")
+	}
+	if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+		return nil, raised
+	}
+	t.detached = true
+	return t.buffer, nil
+}
+
+func textIOWrapperFlush(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "flush", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return ioCall(f, t.buffer, "flush")
+}
+
+func textIOWrapperGetAttr(name string) textIOMethod {
+	return func(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+		if raised := checkMethodArgs(f, "_get_"+name, args, ObjectType); raised != nil {
+			return nil, raised
+		}
+		if t.buffer == nil || t.detached {
+			return nil, f.RaiseType(ValueErrorType, "underlying buffer has been detached")
+		}
+		return GetAttr(f, t.buffer, NewStr(name), nil)
+	}
+}
+
+func textIOWrapperGetBuffer(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_buffer", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if t.buffer == nil || t.detached {
+		return nil, f.RaiseType(ValueErrorType, "underlying buffer has been detached")
+	}
+	return t.buffer, nil
+}
+
+func textIOWrapperGetEncoding(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_encoding", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return NewStr(t.encoding).ToObject(), nil
+}
+
+func textIOWrapperGetErrors(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_errors", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return NewStr(t.errors).ToObject(), nil
+}
+
+func textIOWrapperGetLineBuffering(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_line_buffering", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	return GetBool(t.lineBuffering).ToObject(), nil
+}
+
+func textIOWrapperGetNewlines(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_newlines", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !t.readUniversal {
+		return None, nil
+	}
+	return ioNewlines(t.seenNewlines), nil
+}
+
+func textIOWrapperInit(f *Frame, o *Object, args Args, kwargs KWArgs) (*Object, *BaseException) {
+	var validated [5]*Object
+	if raised := textIOWrapperInitSpec.Validate(f, validated[:], args, kwargs); raised != nil {
+		return nil, raised
+	}
+	encoding, errors := "UTF-8", EncodeStrict
+	for i, s := range []*string{&encoding, &errors} {
+		arg := validated[i+1]
+		if arg == None {
+			continue
+		}
+		if !arg.isInstance(StrType) {
+			name := []string{"encoding", "errors"}[i]
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("TextIOWrapper() argument '%s' must be string, not %s", name, arg.typ.Name()))
+		}
+		*s = toStrUnsafe(arg).Value()
+	}
+	// Decoding an empty string validates the encoding.
+	if _, raised := NewStr("").Decode(f, encoding, errors); raised != nil {
+		return nil, raised
+	}
+	newline := validated[3]
+	if newline != None && !newline.isInstance(StrType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("TextIOWrapper() argument 'newline' must be string or None, not %s", newline.typ.Name()))
+	}
+	lineBuffering, raised := IsTrue(f, validated[4])
+	if raised != nil {
+		return nil, raised
+	}
+	t := toTextIOWrapperUnsafe(o)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.readUniversal, t.readTranslate, t.readNL, t.writeNL = false, false, "", ""
+	if newline == None {
+		t.readUniversal, t.readTranslate = true, true
+	} else {
+		switch nl := toStrUnsafe(newline).Value(); nl {
+		case "":
+			t.readUniversal = true
+		case "\n":
+			t.readNL = nl
+		case "\r", "\r\n":
+			t.readNL, t.writeNL = nl, nl
+		default:
+			return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("illegal newline value: %s", nl))
+		}
+	}
+	t.buffer = validated[0]
+	t.encoding, t.errors = encoding, errors
+	t.lineBuffering = lineBuffering
+	t.decoded, t.dpos, t.pending, t.eof = nil, 0, nil, false
+	t.seenNewlines, t.detached = 0, false
+	return None, nil
+}
+
+func textIOWrapperNext(f *Frame, o *Object) (*Object, *BaseException) {
+	line, raised := ioCall(f, o, "readline")
+	if raised != nil {
+		return nil, raised
+	}
+	if line.isInstance(UnicodeType) && len(toUnicodeUnsafe(line).Value()) == 0 {
+		return nil, f.Raise(StopIterationType.ToObject(), nil, nil)
+	}
+	return line, nil
+}
+
+func textIOWrapperRead(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "read", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	n, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckMode(f, t.buffer, "readable", "not readable"); raised != nil {
+		return nil, raised
+	}
+	if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+		return nil, raised
+	}
+	runes, raised := t.readRunes(f, n, false)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewUnicodeFromRunes(runes).ToObject(), nil
+}
+
+func textIOWrapperReadLine(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "readline", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	limit, raised := ioParseSize(f, args, 1)
+	if raised != nil {
+		return nil, raised
+	}
+	if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+		return nil, raised
+	}
+	runes, raised := t.readRunes(f, limit, true)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewUnicodeFromRunes(runes).ToObject(), nil
+}
+
+func textIOWrapperRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	t := toTextIOWrapperUnsafe(o)
+	encoding := fmt.Sprintf(" encoding='%s'", t.encoding)
+	name, raised := GetAttr(f, o, NewStr("name"), nil)
+	if raised != nil {
+		if !raised.isInstance(AttributeErrorType) && !raised.isInstance(ValueErrorType) {
+			return nil, raised
+		}
+		f.RestoreExc(nil, nil)
+		return NewStr(fmt.Sprintf("<_io.TextIOWrapper%s>", encoding)).ToObject(), nil
+	}
+	s, raised := Repr(f, name)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(fmt.Sprintf("<_io.TextIOWrapper name=%s%s>", s.Value(), encoding)).ToObject(), nil
+}
+
+func textIOWrapperSeek(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType, IntType}
+	argc := len(args)
+	if argc == 2 {
+		expectedTypes = expectedTypes[:2]
+	}
+	if raised := checkMethodArgs(f, "seek", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	if args[1].typ.slots.Index == nil {
+		return nil, f.RaiseType(TypeErrorType, "an integer is required")
+	}
+	pos, raised := IndexInt(f, args[1])
+	if raised != nil {
+		return nil, raised
+	}
+	whence := 0
+	if argc > 2 {
+		whence = toIntUnsafe(args[2]).Value()
+	}
+	if raised := ioCheckMode(f, t.buffer, "seekable", "underlying stream is not seekable"); raised != nil {
+		return nil, raised
+	}
+	switch whence {
+	case 0:
+		if pos < 0 {
+			return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("negative seek position %d", pos))
+		}
+	case 1:
+		if pos != 0 {
+			return nil, f.RaiseType(IOErrorType, "can't do nonzero cur-relative seeks")
+		}
+		pos, raised = t.tell(f)
+		if raised != nil {
+			return nil, raised
+		}
+	case 2:
+		if pos != 0 {
+			return nil, f.RaiseType(IOErrorType, "can't do nonzero end-relative seeks")
+		}
+	default:
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("invalid whence (%d, should be 0, 1 or 2)", whence))
+	}
+	if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+		return nil, raised
+	}
+	t.decoded, t.dpos, t.pending, t.eof = nil, 0, nil, false
+	if whence == 2 {
+		return ioCall(f, t.buffer, "seek", NewInt(0).ToObject(), NewInt(2).ToObject())
+	}
+	return ioCall(f, t.buffer, "seek", NewInt(pos).ToObject())
+}
+
+func textIOWrapperTell(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "tell", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if raised := ioCheckMode(f, t.buffer, "seekable", "underlying stream is not seekable"); raised != nil {
+		return nil, raised
+	}
+	if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+		return nil, raised
+	}
+	pos, raised := t.tell(f)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewInt(pos).ToObject(), nil
+}
+
+func textIOWrapperTruncate(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	expectedTypes := []*Type{ObjectType, ObjectType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkMethodArgs(f, "truncate", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+		return nil, raised
+	}
+	if raised := t.rewind(f); raised != nil {
+		return nil, raised
+	}
+	if len(args) > 1 && args[1] != None {
+		return ioCall(f, t.buffer, "truncate", args[1])
+	}
+	return ioCall(f, t.buffer, "truncate")
+}
+
+func textIOWrapperWrite(f *Frame, t *textIOWrapper, args Args) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "write", args, ObjectType, ObjectType); raised != nil {
+		return nil, raised
+	}
+	if !args[1].isInstance(UnicodeType) {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("write() argument 1 must be unicode, not %s", args[1].typ.Name()))
+	}
+	runes := toUnicodeUnsafe(args[1]).Value()
+	text := runes
+	var hasNL, hasCR bool
+	for _, r := range runes {
+		hasNL = hasNL || r == '\n'
+		hasCR = hasCR || r == '\r'
+	}
+	if t.writeNL != "" && hasNL {
+		text = nil
+		for _, r := range runes {
+			if r == '\n' {
+				text = append(text, []rune(t.writeNL)...)
+			} else {
+				text = append(text, r)
+			}
+		}
+	}
+	encoded, raised := NewUnicodeFromRunes(text).Encode(f, EncodeDefault, t.errors)
+	if raised != nil {
+		return nil, raised
+	}
+	if raised := t.rewind(f); raised != nil {
+		return nil, raised
+	}
+	if _, raised := ioCall(f, t.buffer, "write", encoded.ToObject()); raised != nil {
+		return nil, raised
+	}
+	if t.lineBuffering && (hasNL || hasCR) {
+		if _, raised := ioCall(f, t.buffer, "flush"); raised != nil {
+			return nil, raised
+		}
+	}
+	return NewInt(len(runes)).ToObject(), nil
+}
+
+func initTextIOWrapperType(dict map[string]*Object) {
+	methods := map[string]textIOMethod{
+		"fileno":   textIOWrapperDelegate("fileno"),
+		"flush":    textIOWrapperFlush,
+		"isatty":   textIOWrapperDelegate("isatty"),
+		"read":     textIOWrapperRead,
+		"readable": textIOWrapperDelegate("readable"),
+		"readline": textIOWrapperReadLine,
+		"seek":     textIOWrapperSeek,
+		"seekable": textIOWrapperDelegate("seekable"),
+		"tell":     textIOWrapperTell,
+		"truncate": textIOWrapperTruncate,
+		"writable": textIOWrapperDelegate("writable"),
+		"write":    textIOWrapperWrite,
+	}
+	for name, fn := range methods {
+		dict[name] = newTextIOMethod(name, false, fn)
+	}
+	dict["close"] = newTextIOMethod("close", true, textIOWrapperClose)
+	dict["detach"] = newTextIOMethod("detach", true, textIOWrapperDetach)
+	for name, fn := range map[string]textIOMethod{
+		"buffer":         textIOWrapperGetBuffer,
+		"encoding":       textIOWrapperGetEncoding,
+		"errors":         textIOWrapperGetErrors,
+		"line_buffering": textIOWrapperGetLineBuffering,
+		"newlines":       textIOWrapperGetNewlines,
+	} {
+		dict[name] = newProperty(newTextIOMethod("_get_"+name, true, fn), nil, nil).ToObject()
+	}
+	for _, name := range []string{"closed", "name"} {
+		dict[name] = newProperty(newTextIOMethod("_get_"+name, true, textIOWrapperGetAttr(name)), nil, nil).ToObject()
+	}
+	dict["__module__"] = NewStr("_io").ToObject()
+	textIOWrapperType.slots.Init = &initSlot{textIOWrapperInit}
+	textIOWrapperType.slots.Next = &unaryOpSlot{textIOWrapperNext}
+	textIOWrapperType.slots.Repr = &unaryOpSlot{textIOWrapperRepr}
+}
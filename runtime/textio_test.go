@@ -0,0 +1,160 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+)
+
+func TestTextIOWrapperInit(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, args ...*Object) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		w, raised := textIOWrapperType.Call(f, append(Args{raw}, args...), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return GetAttr(f, w, NewStr("encoding"), nil)
+	})
+	cases := []invokeTestCase{
+		{want: NewStr("UTF-8").ToObject()},
+		{args: wrapArgs("utf8"), want: NewStr("utf8").ToObject()},
+		{args: wrapArgs(None, None, "\r\n"), want: NewStr("UTF-8").ToObject()},
+		{args: wrapArgs(None, None, "x"), wantExc: mustCreateException(ValueErrorType, "illegal newline value: x")},
+		{args: wrapArgs(None, None, 3), wantExc: mustCreateException(TypeErrorType, "TextIOWrapper() argument 'newline' must be string or None, not int")},
+		{args: wrapArgs(3), wantExc: mustCreateException(TypeErrorType, "TextIOWrapper() argument 'encoding' must be string, not int")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTextIOWrapperRead(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, data string, newline *Object, method string, args ...*Object) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, wrapArgs(data), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		w, raised := textIOWrapperType.Call(f, wrapArgs(raw, None, None, newline), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, w, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("abc", None, "read"), want: NewUnicode("abc").ToObject()},
+		{args: wrapArgs("abc", None, "read", 2), want: NewUnicode("ab").ToObject()},
+		{args: wrapArgs("a\r\nb\rc\n", None, "read"), want: NewUnicode("a\nb\nc\n").ToObject()},
+		{args: wrapArgs("a\r\nb\rc\n", "", "readlines"), want: NewList(NewUnicode("a\r\n").ToObject(), NewUnicode("b\r").ToObject(), NewUnicode("c\n").ToObject()).ToObject()},
+		{args: wrapArgs("a\r\nb\rc\n", "\r", "readline"), want: NewUnicode("a\r").ToObject()},
+		{args: wrapArgs("a\r\nb\rc\n", "\n", "read"), want: NewUnicode("a\r\nb\rc\n").ToObject()},
+		{args: wrapArgs("abc\ndef", None, "readline", 2), want: NewUnicode("ab").ToObject()},
+		{args: wrapArgs("\xc3\xa9t\xc3\xa9", None, "read"), want: NewUnicode("été").ToObject()},
+		{args: wrapArgs("\xc3\xa9t\xc3\xa9", None, "read", 1), want: NewUnicode("é").ToObject()},
+		{args: wrapArgs("abc", None, "seek", 1), want: NewInt(1).ToObject()},
+		{args: wrapArgs("abc", None, "seek", 0, 2), want: NewInt(3).ToObject()},
+		{args: wrapArgs("abc", None, "seek", 1, 1), wantExc: mustCreateException(IOErrorType, "can't do nonzero cur-relative seeks")},
+		{args: wrapArgs("abc", None, "seek", 1, 2), wantExc: mustCreateException(IOErrorType, "can't do nonzero end-relative seeks")},
+		{args: wrapArgs("abc", None, "seek", -1), wantExc: mustCreateException(ValueErrorType, "negative seek position -1")},
+		{args: wrapArgs("abc", None, "write", "x"), wantExc: mustCreateException(TypeErrorType, "write() argument 1 must be unicode, not str")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTextIOWrapperWrite(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, newline *Object, s *Unicode) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		w, raised := textIOWrapperType.Call(f, wrapArgs(raw, None, None, newline), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		n, raised := ioCall(f, w, "write", s.ToObject())
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(f, w, "flush"); raised != nil {
+			return nil, raised
+		}
+		data, raised := ioCall(f, raw, "getvalue")
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(n, data).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(None, NewUnicode("a\nb")), want: newTestTuple(3, "a\nb").ToObject()},
+		{args: wrapArgs("\r\n", NewUnicode("a\nb")), want: newTestTuple(3, "a\r\nb").ToObject()},
+		{args: wrapArgs("\r", NewUnicode("a\nb\n")), want: newTestTuple(4, "a\rb\r").ToObject()},
+		{args: wrapArgs(None, NewUnicode("é")), want: newTestTuple(1, "\xc3\xa9").ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTextIOWrapperTell(t *testing.T) {
+	f := NewRootFrame()
+	raw := mustNotRaise(bytesIOType.Call(f, wrapArgs("\xc3\xa9a\nb"), nil))
+	w := mustNotRaise(textIOWrapperType.Call(f, wrapArgs(raw), nil))
+	mustNotRaise(ioCall(f, w, "read", NewInt(1).ToObject()))
+	if got := mustNotRaise(ioCall(f, w, "tell")); toIntUnsafe(got).Value() != 2 {
+		t.Errorf("tell() = %v, want 2", got)
+	}
+	mustNotRaise(ioCall(f, w, "seek", NewInt(2).ToObject()))
+	got := mustNotRaise(ioCall(f, w, "readline"))
+	if !got.isInstance(UnicodeType) || string(toUnicodeUnsafe(got).Value()) != "a\n" {
+		t.Errorf("readline() after seek(2) = %v, want u'a\\n'", got)
+	}
+}
+
+func TestTextIOWrapperClosed(t *testing.T) {
+	fun := wrapFuncForTest(func(f *Frame, method string, args ...*Object) (*Object, *BaseException) {
+		raw, raised := bytesIOType.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		w, raised := textIOWrapperType.Call(f, wrapArgs(raw), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := ioCall(f, w, "close"); raised != nil {
+			return nil, raised
+		}
+		return ioCall(f, w, method, args...)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("close"), want: None},
+		{args: wrapArgs("read"), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file.")},
+		{args: wrapArgs("write", NewUnicode("x")), wantExc: mustCreateException(ValueErrorType, "I/O operation on closed file.")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
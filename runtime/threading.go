@@ -40,6 +40,10 @@ type threadState struct {
 	// reuse. The cache is maintained through the Frame `back` pointer as a
 	// singly linked list.
 	frameCache *Frame
+
+	// classInfoDepth is the nesting level of isinstance() and issubclass()
+	// checks in progress on this thread.
+	classInfoDepth int
 }
 
 func newThreadState() *threadState {
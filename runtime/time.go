@@ -0,0 +1,953 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// structTimeType corresponds to the Python type 'time.struct_time'.
+	structTimeType = newSimpleType("struct_time", TupleType)
+	// structTimeFields are the names of the struct_time tuple elements.
+	structTimeFields = []string{
+		"tm_year", "tm_mon", "tm_mday", "tm_hour", "tm_min", "tm_sec",
+		"tm_wday", "tm_yday", "tm_isdst",
+	}
+	timeDayNames = []string{
+		"Monday", "Tuesday", "Wednesday", "Thursday", "Friday",
+		"Saturday", "Sunday",
+	}
+	timeMonthNames = []string{
+		"January", "February", "March", "April", "May", "June", "July",
+		"August", "September", "October", "November", "December",
+	}
+	// timeStrptimeDirectives are the regular expressions matching each
+	// numeric strptime directive. They are the same as those used by
+	// CPython's _strptime module.
+	timeStrptimeDirectives = map[byte]string{
+		'd': `(?P<d>3[01]|[12]\d|0[1-9]|[1-9]| [1-9])`,
+		'f': `(?P<f>[0-9]{1,6})`,
+		'H': `(?P<H>2[0-3]|[0-1]\d|\d)`,
+		'I': `(?P<I>1[0-2]|0[1-9]|[1-9])`,
+		'j': `(?P<j>36[0-6]|3[0-5]\d|[12]\d\d|0[1-9]\d|00[1-9]|[1-9]\d|0[1-9]|[1-9])`,
+		'm': `(?P<m>1[0-2]|0[1-9]|[1-9])`,
+		'M': `(?P<M>[0-5]\d|\d)`,
+		'S': `(?P<S>6[0-1]|[0-5]\d|\d)`,
+		'U': `(?P<U>5[0-3]|[0-4]\d|\d)`,
+		'W': `(?P<W>5[0-3]|[0-4]\d|\d)`,
+		'w': `(?P<w>[0-6])`,
+		'y': `(?P<y>\d\d)`,
+		'Y': `(?P<Y>\d\d\d\d)`,
+	}
+	// timeStrptimeCache holds compiled strptime patterns keyed by format.
+	// It is tied to the time zone that was in effect when the patterns
+	// were compiled since %Z matches the zone's names.
+	timeStrptimeCache = struct {
+		sync.Mutex
+		zone     *timeZoneInfo
+		patterns map[string]*regexp.Regexp
+	}{}
+	// timeZone holds the time zone configured by the last call to tzset.
+	timeZone = struct {
+		sync.Mutex
+		info *timeZoneInfo
+	}{}
+)
+
+// Composite directives in the C locale.
+const (
+	timeDateTimeFormat = "%a %b %e %H:%M:%S %Y"
+	timeDateFormat     = "%m/%d/%y"
+	timeTimeFormat     = "%H:%M:%S"
+)
+
+// timeTM holds the fields of a struct_time. Unlike C's struct tm, the
+// fields have the same ranges as their Python counterparts, e.g. mon is
+// 1-12 and wday is 0 for Monday.
+type timeTM struct {
+	year, mon, mday, hour, min, sec, wday, yday, isdst int
+}
+
+// timeZoneInfo describes a time zone the way the time module's timezone,
+// altzone, daylight and tzname attributes do.
+type timeZoneInfo struct {
+	loc *time.Location
+	// timezone and altzone are the standard and DST offsets in seconds
+	// west of UTC.
+	timezone int
+	altzone  int
+	daylight bool
+	tzname   [2]string
+}
+
+func newTimeTM(t time.Time) timeTM {
+	isdst := 0
+	if t.IsDST() {
+		isdst = 1
+	}
+	return timeTM{
+		t.Year(), int(t.Month()), t.Day(), t.Hour(), t.Minute(),
+		t.Second(), (int(t.Weekday()) + 6) % 7, t.YearDay(), isdst,
+	}
+}
+
+func init() {
+	RegisterModule("time", NewCode("<module>", "time", nil, 0, timeInit))
+}
+
+func timeInit(f *Frame, _ []*Object) (*Object, *BaseException) {
+	globals := f.Globals()
+	tzset := func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		if raised := checkFunctionArgs(f, "tzset", args); raised != nil {
+			return nil, raised
+		}
+		return None, timeSetZoneGlobals(f, globals, timeTzset())
+	}
+	funcs := map[string]Func{
+		"asctime":      timeAsctime,
+		"clock":        timeClock,
+		"ctime":        timeCtime,
+		"gmtime":       timeStructFunc("gmtime", false),
+		"localtime":    timeStructFunc("localtime", true),
+		"mktime":       timeMktime,
+		"monotonic":    timeMonotonic,
+		"perf_counter": timeMonotonic,
+		"sleep":        timeSleep,
+		"strftime":     timeStrftime,
+		"strptime":     timeStrptime,
+		"time":         timeTime,
+		"tzset":        tzset,
+	}
+	for name, fn := range funcs {
+		if raised := globals.SetItemString(f, name, newBuiltinFunction(name, fn).ToObject()); raised != nil {
+			return nil, raised
+		}
+	}
+	if raised := globals.SetItemString(f, "struct_time", structTimeType.ToObject()); raised != nil {
+		return nil, raised
+	}
+	if raised := timeSetZoneGlobals(f, globals, timeCurrentZone()); raised != nil {
+		return nil, raised
+	}
+	return nil, nil
+}
+
+// timeCurrentZone returns the time zone configured by the last call to
+// tzset, calling it first if necessary.
+func timeCurrentZone() *timeZoneInfo {
+	timeZone.Lock()
+	info := timeZone.info
+	timeZone.Unlock()
+	if info == nil {
+		info = timeTzset()
+	}
+	return info
+}
+
+// timeTzset configures the local time zone from the TZ environment
+// variable. TZ may name a zone in the system's zoneinfo database, the path
+// of a zoneinfo file or, failing that, a POSIX TZ string such as
+// "EST+05EDT,M3.2.0,M11.1.0". As in C, an unset TZ means the system's
+// default zone and an empty or invalid one means UTC.
+func timeTzset() *timeZoneInfo {
+	loc := time.UTC
+	tz, ok := os.LookupEnv("TZ")
+	if !ok {
+		if data, err := ioutil.ReadFile("/etc/localtime"); err == nil {
+			if l, err := time.LoadLocationFromTZData("Local", data); err == nil {
+				loc = l
+			}
+		}
+	} else if tz = strings.TrimPrefix(tz, ":"); tz != "" {
+		var err error
+		if strings.HasPrefix(tz, "/") {
+			var data []byte
+			if data, err = ioutil.ReadFile(tz); err == nil {
+				loc, err = time.LoadLocationFromTZData(tz, data)
+			}
+		} else {
+			loc, err = time.LoadLocation(tz)
+		}
+		if err != nil {
+			if loc, ok = timeLoadPOSIXZone(tz); !ok {
+				loc = time.UTC
+			}
+		}
+	}
+	year := time.Now().In(loc).Year()
+	janName, janOffset := time.Date(year, 1, 1, 0, 0, 0, 0, loc).Zone()
+	julName, julOffset := time.Date(year, 7, 1, 0, 0, 0, 0, loc).Zone()
+	info := &timeZoneInfo{loc: loc, daylight: janOffset != julOffset}
+	if janOffset > julOffset {
+		// DST is in effect in January in the southern hemisphere.
+		info.timezone, info.altzone = -julOffset, -janOffset
+		info.tzname = [2]string{julName, janName}
+	} else {
+		info.timezone, info.altzone = -janOffset, -julOffset
+		info.tzname = [2]string{janName, julName}
+	}
+	timeZone.Lock()
+	timeZone.info = info
+	timeZone.Unlock()
+	return info
+}
+
+// timeLoadPOSIXZone creates a Location for a POSIX TZ string. Go can only
+// interpret such strings when they appear in the footer of a TZif file so
+// one is synthesized with a single zone for the standard time described by
+// the start of tz.
+func timeLoadPOSIXZone(tz string) (*time.Location, bool) {
+	m := regexp.MustCompile(`^([A-Za-z]{3,}|<[A-Za-z0-9+-]+>)([+-]?)(\d{1,2})(?::(\d{1,2}))?(?::(\d{1,2}))?`).FindStringSubmatch(tz)
+	if m == nil {
+		return nil, false
+	}
+	name := strings.Trim(m[1], "<>")
+	offset := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if n, err := strconv.Atoi(m[3+i]); err == nil {
+			offset += n * unit
+		}
+	}
+	// POSIX offsets are positive west of UTC.
+	if m[2] != "-" {
+		offset = -offset
+	}
+	var data bytes.Buffer
+	writeBlock := func(version byte) {
+		data.WriteString("TZif")
+		data.WriteByte(version)
+		data.Write(make([]byte, 15))
+		// isutcnt, isstdcnt, leapcnt, timecnt, typecnt, charcnt.
+		for _, n := range []uint32{0, 0, 0, 0, 1, uint32(len(name) + 1)} {
+			binary.Write(&data, binary.BigEndian, n)
+		}
+		binary.Write(&data, binary.BigEndian, int32(offset))
+		data.Write([]byte{0, 0})
+		data.WriteString(name)
+		data.WriteByte(0)
+	}
+	writeBlock('2')
+	writeBlock('2')
+	data.WriteString("\n" + tz + "\n")
+	loc, err := time.LoadLocationFromTZData(tz, data.Bytes())
+	return loc, err == nil
+}
+
+// timeSetZoneGlobals updates the zone related attributes of the time module
+// whose globals are given.
+func timeSetZoneGlobals(f *Frame, globals *Dict, info *timeZoneInfo) *BaseException {
+	daylight := 0
+	if info.daylight {
+		daylight = 1
+	}
+	values := map[string]*Object{
+		"altzone":  NewInt(info.altzone).ToObject(),
+		"daylight": NewInt(daylight).ToObject(),
+		"timezone": NewInt(info.timezone).ToObject(),
+		"tzname":   NewTuple2(NewStr(info.tzname[0]).ToObject(), NewStr(info.tzname[1]).ToObject()).ToObject(),
+	}
+	for name, value := range values {
+		if raised := globals.SetItemString(f, name, value); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+func newStructTime(f *Frame, tm timeTM) (*Object, *BaseException) {
+	ints := []int{tm.year, tm.mon, tm.mday, tm.hour, tm.min, tm.sec, tm.wday, tm.yday, tm.isdst}
+	elems := make([]*Object, len(ints))
+	for i, v := range ints {
+		elems[i] = NewInt(v).ToObject()
+	}
+	o := newObject(structTimeType)
+	toTupleUnsafe(o).elems = elems
+	if raised := structTimeSetAttrs(f, o, elems); raised != nil {
+		return nil, raised
+	}
+	return o, nil
+}
+
+func structTimeNew(f *Frame, t *Type, args Args, _ KWArgs) (*Object, *BaseException) {
+	elems, raised := seqNew(f, args)
+	if raised != nil {
+		return nil, raised
+	}
+	if n := len(elems); n != len(structTimeFields) {
+		format := "time.struct_time() takes a %d-sequence (%d-sequence given)"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, len(structTimeFields), n))
+	}
+	o := newObject(t)
+	toTupleUnsafe(o).elems = elems
+	if raised := structTimeSetAttrs(f, o, elems); raised != nil {
+		return nil, raised
+	}
+	return o, nil
+}
+
+func structTimeSetAttrs(f *Frame, o *Object, values []*Object) *BaseException {
+	d := o.Dict()
+	for i, name := range structTimeFields {
+		if raised := d.SetItemString(f, name, values[i]); raised != nil {
+			return raised
+		}
+	}
+	return nil
+}
+
+func structTimeRepr(f *Frame, o *Object) (*Object, *BaseException) {
+	elems := toTupleUnsafe(o).elems
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		s, raised := Repr(f, elem)
+		if raised != nil {
+			return nil, raised
+		}
+		parts[i] = structTimeFields[i] + "=" + s.Value()
+	}
+	return NewStr("time.struct_time(" + strings.Join(parts, ", ") + ")").ToObject(), nil
+}
+
+func initStructTimeType(dict map[string]*Object) {
+	dict["__module__"] = NewStr("time").ToObject()
+	structTimeType.slots.New = &newSlot{structTimeNew}
+	structTimeType.slots.Repr = &unaryOpSlot{structTimeRepr}
+}
+
+// timeTMFromObject converts o, which must be a struct_time or a 9-tuple of
+// ints, to a timeTM.
+func timeTMFromObject(f *Frame, o *Object) (timeTM, *BaseException) {
+	var tm timeTM
+	if !o.isInstance(TupleType) {
+		return tm, f.RaiseType(TypeErrorType, "Tuple or struct_time argument required")
+	}
+	elems := toTupleUnsafe(o).elems
+	if len(elems) != len(structTimeFields) {
+		return tm, f.RaiseType(TypeErrorType, fmt.Sprintf("function takes exactly %d arguments (%d given)", len(structTimeFields), len(elems)))
+	}
+	fields := []*int{&tm.year, &tm.mon, &tm.mday, &tm.hour, &tm.min, &tm.sec, &tm.wday, &tm.yday, &tm.isdst}
+	for i, elem := range elems {
+		v, raised := ToIntValue(f, elem)
+		if raised != nil {
+			return tm, raised
+		}
+		*fields[i] = v
+	}
+	return tm, nil
+}
+
+// timeCheckTM validates the fields of tm for use by strftime and asctime.
+// As in CPython, zero months, days and days of the year are accepted and
+// treated as the first.
+func timeCheckTM(f *Frame, tm *timeTM) *BaseException {
+	if tm.mon == 0 {
+		tm.mon = 1
+	}
+	if tm.mday == 0 {
+		tm.mday = 1
+	}
+	if tm.yday == 0 {
+		tm.yday = 1
+	}
+	if tm.isdst < -1 {
+		tm.isdst = -1
+	} else if tm.isdst > 1 {
+		tm.isdst = 1
+	}
+	var msg string
+	switch {
+	case tm.year < 1900:
+		msg = "year >= 1900 required"
+	case tm.mon < 1 || tm.mon > 12:
+		msg = "month out of range"
+	case tm.mday < 1 || tm.mday > 31:
+		msg = "day of month out of range"
+	case tm.hour < 0 || tm.hour > 23:
+		msg = "hour out of range"
+	case tm.min < 0 || tm.min > 59:
+		msg = "minute out of range"
+	case tm.sec < 0 || tm.sec > 61:
+		msg = "seconds out of range"
+	case tm.wday < 0:
+		msg = "day of week out of range"
+	case tm.yday < 1 || tm.yday > 366:
+		msg = "day of year out of range"
+	default:
+		tm.wday %= 7
+		return nil
+	}
+	return f.RaiseType(ValueErrorType, msg)
+}
+
+// timeSecondsFromObject converts a Python timestamp to whole seconds since
+// the epoch, truncating any fraction. None means the current time.
+func timeSecondsFromObject(f *Frame, o *Object) (int64, *BaseException) {
+	if o == None {
+		return time.Now().Unix(), nil
+	}
+	secs, ok := floatCoerce(o)
+	if !ok {
+		return 0, f.RaiseType(TypeErrorType, "a float is required")
+	}
+	if math.IsNaN(secs) || secs < math.MinInt64 || secs >= math.MaxInt64 {
+		return 0, f.RaiseType(ValueErrorType, "timestamp out of range for platform time_t")
+	}
+	return int64(secs), nil
+}
+
+// timeStructFunc returns the implementation of gmtime([seconds]) or, when
+// local is true, localtime([seconds]).
+func timeStructFunc(name string, local bool) Func {
+	return func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+		argc := len(args)
+		expectedTypes := []*Type{ObjectType}
+		if argc == 0 {
+			expectedTypes = nil
+		}
+		if raised := checkFunctionArgs(f, name, args, expectedTypes...); raised != nil {
+			return nil, raised
+		}
+		arg := None
+		if argc > 0 {
+			arg = args[0]
+		}
+		secs, raised := timeSecondsFromObject(f, arg)
+		if raised != nil {
+			return nil, raised
+		}
+		loc := time.UTC
+		if local {
+			loc = timeCurrentZone().loc
+		}
+		return newStructTime(f, newTimeTM(time.Unix(secs, 0).In(loc)))
+	}
+}
+
+// timeOptionalTM parses the optional struct_time argument of functions like
+// strftime and asctime, defaulting to the current local time.
+func timeOptionalTM(f *Frame, name string, args Args, expectedTypes ...*Type) (timeTM, *BaseException) {
+	argc := len(args)
+	hasTM := argc > len(expectedTypes)
+	if hasTM {
+		expectedTypes = append(expectedTypes, ObjectType)
+	}
+	if raised := checkFunctionArgs(f, name, args, expectedTypes...); raised != nil {
+		return timeTM{}, raised
+	}
+	if !hasTM {
+		return newTimeTM(time.Now().In(timeCurrentZone().loc)), nil
+	}
+	tm, raised := timeTMFromObject(f, args[argc-1])
+	if raised != nil {
+		return tm, raised
+	}
+	return tm, timeCheckTM(f, &tm)
+}
+
+func timeAsctime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	tm, raised := timeOptionalTM(f, "asctime", args)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(timeFormat(timeDateTimeFormat, tm, timeCurrentZone())).ToObject(), nil
+}
+
+func timeClock(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "clock", args); raised != nil {
+		return nil, raised
+	}
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return nil, raiseEnvironmentError(f, OSErrorType, err)
+	}
+	nsecs := usage.Utime.Nano() + usage.Stime.Nano()
+	return NewFloat(float64(nsecs) / float64(time.Second)).ToObject(), nil
+}
+
+func timeCtime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	argc := len(args)
+	expectedTypes := []*Type{ObjectType}
+	if argc == 0 {
+		expectedTypes = nil
+	}
+	if raised := checkFunctionArgs(f, "ctime", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	arg := None
+	if argc > 0 {
+		arg = args[0]
+	}
+	secs, raised := timeSecondsFromObject(f, arg)
+	if raised != nil {
+		return nil, raised
+	}
+	zone := timeCurrentZone()
+	tm := newTimeTM(time.Unix(secs, 0).In(zone.loc))
+	return NewStr(timeFormat(timeDateTimeFormat, tm, zone)).ToObject(), nil
+}
+
+func timeMktime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "mktime", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	tm, raised := timeTMFromObject(f, args[0])
+	if raised != nil {
+		return nil, raised
+	}
+	if tm.year < -292277022657 || tm.year > 292277026596 {
+		return nil, f.RaiseType(OverflowErrorType, "mktime argument out of range")
+	}
+	zone := timeCurrentZone()
+	t := time.Date(tm.year, time.Month(tm.mon), tm.mday, tm.hour, tm.min, tm.sec, 0, zone.loc)
+	if tm.isdst >= 0 && t.IsDST() != (tm.isdst > 0) {
+		// The caller disagrees with the zone about whether DST is in
+		// effect so interpret the fields using the offset they imply.
+		_, offset := t.Zone()
+		want := -zone.timezone
+		if tm.isdst > 0 {
+			want = -zone.altzone
+		}
+		t = t.Add(time.Duration(offset-want) * time.Second)
+	}
+	return NewFloat(float64(t.Unix())).ToObject(), nil
+}
+
+func timeMonotonic(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "monotonic", args); raised != nil {
+		return nil, raised
+	}
+	return NewFloat(monotonicNow().Seconds()).ToObject(), nil
+}
+
+func timeSleep(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "sleep", args, ObjectType); raised != nil {
+		return nil, raised
+	}
+	secs, ok := floatCoerce(args[0])
+	if !ok {
+		return nil, f.RaiseType(TypeErrorType, "a float is required")
+	}
+	if secs < 0 {
+		return nil, f.RaiseType(ValueErrorType, "sleep length must be non-negative")
+	}
+	time.Sleep(time.Duration(math.Min(secs*float64(time.Second), math.MaxInt64)))
+	return None, nil
+}
+
+// timeStrftime implements strftime(format[, t]). Directives are those of C's
+// strftime in the C locale. Unknown directives are copied to the output.
+func timeStrftime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	tm, raised := timeOptionalTM(f, "strftime", args, StrType)
+	if raised != nil {
+		return nil, raised
+	}
+	return NewStr(timeFormat(toStrUnsafe(args[0]).Value(), tm, timeCurrentZone())).ToObject(), nil
+}
+
+// timeFormat formats tm according to format as C's strftime would in the C
+// locale. zone provides the names and offsets for %Z and %z.
+func timeFormat(format string, tm timeTM, zone *timeZoneInfo) string {
+	var buf bytes.Buffer
+	sundayWday := (tm.wday + 1) % 7
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i == len(format)-1 {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = format[i]; c {
+		case '%':
+			buf.WriteByte('%')
+		case 'a':
+			buf.WriteString(timeDayNames[tm.wday%7][:3])
+		case 'A':
+			buf.WriteString(timeDayNames[tm.wday%7])
+		case 'b', 'h':
+			buf.WriteString(timeMonthNames[tm.mon-1][:3])
+		case 'B':
+			buf.WriteString(timeMonthNames[tm.mon-1])
+		case 'c':
+			buf.WriteString(timeFormat(timeDateTimeFormat, tm, zone))
+		case 'C':
+			fmt.Fprintf(&buf, "%02d", tm.year/100)
+		case 'd':
+			fmt.Fprintf(&buf, "%02d", tm.mday)
+		case 'D', 'x':
+			buf.WriteString(timeFormat(timeDateFormat, tm, zone))
+		case 'e':
+			fmt.Fprintf(&buf, "%2d", tm.mday)
+		case 'F':
+			buf.WriteString(timeFormat("%Y-%m-%d", tm, zone))
+		case 'G', 'g', 'V':
+			year, week := time.Date(tm.year, time.Month(tm.mon), tm.mday, 0, 0, 0, 0, time.UTC).ISOWeek()
+			switch c {
+			case 'G':
+				fmt.Fprintf(&buf, "%d", year)
+			case 'g':
+				fmt.Fprintf(&buf, "%02d", year%100)
+			default:
+				fmt.Fprintf(&buf, "%02d", week)
+			}
+		case 'H':
+			fmt.Fprintf(&buf, "%02d", tm.hour)
+		case 'I':
+			hour := tm.hour % 12
+			if hour == 0 {
+				hour = 12
+			}
+			fmt.Fprintf(&buf, "%02d", hour)
+		case 'j':
+			fmt.Fprintf(&buf, "%03d", tm.yday)
+		case 'm':
+			fmt.Fprintf(&buf, "%02d", tm.mon)
+		case 'M':
+			fmt.Fprintf(&buf, "%02d", tm.min)
+		case 'n':
+			buf.WriteByte('\n')
+		case 'p':
+			if tm.hour < 12 {
+				buf.WriteString("AM")
+			} else {
+				buf.WriteString("PM")
+			}
+		case 'r':
+			buf.WriteString(timeFormat("%I:%M:%S %p", tm, zone))
+		case 'R':
+			buf.WriteString(timeFormat("%H:%M", tm, zone))
+		case 'S':
+			fmt.Fprintf(&buf, "%02d", tm.sec)
+		case 't':
+			buf.WriteByte('\t')
+		case 'T', 'X':
+			buf.WriteString(timeFormat(timeTimeFormat, tm, zone))
+		case 'u':
+			fmt.Fprintf(&buf, "%d", tm.wday%7+1)
+		case 'U':
+			fmt.Fprintf(&buf, "%02d", (tm.yday-1+7-sundayWday)/7)
+		case 'w':
+			fmt.Fprintf(&buf, "%d", sundayWday)
+		case 'W':
+			fmt.Fprintf(&buf, "%02d", (tm.yday-1+7-tm.wday%7)/7)
+		case 'y':
+			fmt.Fprintf(&buf, "%02d", tm.year%100)
+		case 'Y':
+			fmt.Fprintf(&buf, "%d", tm.year)
+		case 'z':
+			offset := -zone.timezone
+			if tm.isdst > 0 {
+				offset = -zone.altzone
+			}
+			sign := '+'
+			if offset < 0 {
+				sign, offset = '-', -offset
+			}
+			fmt.Fprintf(&buf, "%c%02d%02d", sign, offset/3600, offset/60%60)
+		case 'Z':
+			if tm.isdst > 0 {
+				buf.WriteString(zone.tzname[1])
+			} else {
+				buf.WriteString(zone.tzname[0])
+			}
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// timeStrptime implements strptime(string[, format]). It behaves like
+// CPython's _strptime module in the C locale.
+func timeStrptime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	expectedTypes := []*Type{StrType, StrType}
+	if len(args) == 1 {
+		expectedTypes = expectedTypes[:1]
+	}
+	if raised := checkFunctionArgs(f, "strptime", args, expectedTypes...); raised != nil {
+		return nil, raised
+	}
+	value, format := toStrUnsafe(args[0]).Value(), "%a %b %d %H:%M:%S %Y"
+	if len(args) > 1 {
+		format = toStrUnsafe(args[1]).Value()
+	}
+	tm, _, raised := timeParse(f, value, format)
+	if raised != nil {
+		return nil, raised
+	}
+	return newStructTime(f, tm)
+}
+
+// timeParse parses value according to the strptime format. It returns the
+// fields of the resulting struct_time along with the microseconds matched by
+// %f, which struct_time cannot represent.
+func timeParse(f *Frame, value, format string) (timeTM, int, *BaseException) {
+	zone := timeCurrentZone()
+	re, raised := timeStrptimeRegexp(f, format, zone)
+	if raised != nil {
+		return timeTM{}, 0, raised
+	}
+	match := re.FindStringSubmatchIndex(value)
+	if match == nil {
+		s, raised := Repr(f, NewStr(value).ToObject())
+		if raised != nil {
+			return timeTM{}, 0, raised
+		}
+		fs, raised := Repr(f, NewStr(format).ToObject())
+		if raised != nil {
+			return timeTM{}, 0, raised
+		}
+		return timeTM{}, 0, f.RaiseType(ValueErrorType, fmt.Sprintf("time data %s does not match format %s", s.Value(), fs.Value()))
+	}
+	if match[1] != len(value) {
+		return timeTM{}, 0, f.RaiseType(ValueErrorType, "unconverted data remains: "+value[match[1]:])
+	}
+	found := map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if name != "" && match[2*i] >= 0 {
+			found[name] = strings.ToLower(value[match[2*i]:match[2*i+1]])
+		}
+	}
+	return timeStrptimeTM(f, found, zone)
+}
+
+// timeStrptimeRegexp returns the compiled pattern for the strptime format,
+// which may come from the cache.
+func timeStrptimeRegexp(f *Frame, format string, zone *timeZoneInfo) (*regexp.Regexp, *BaseException) {
+	timeStrptimeCache.Lock()
+	defer timeStrptimeCache.Unlock()
+	if timeStrptimeCache.zone != zone || len(timeStrptimeCache.patterns) > 100 {
+		timeStrptimeCache.zone = zone
+		timeStrptimeCache.patterns = map[string]*regexp.Regexp{}
+	}
+	if re, ok := timeStrptimeCache.patterns[format]; ok {
+		return re, nil
+	}
+	var buf bytes.Buffer
+	if bad, ok := timeStrptimePattern(&buf, format, zone); !ok {
+		if bad == "" {
+			return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("stray %% in format '%s'", format))
+		}
+		return nil, f.RaiseType(ValueErrorType, fmt.Sprintf("'%s' is a bad directive in format '%s'", bad, format))
+	}
+	re, err := regexp.Compile("(?i)" + buf.String())
+	if err != nil {
+		return nil, f.RaiseType(ValueErrorType, err.Error())
+	}
+	timeStrptimeCache.patterns[format] = re
+	return re, nil
+}
+
+// timeStrptimePattern writes the regular expression for format to buf. On
+// failure it returns the offending directive, which is empty for a stray %
+// at the end of format.
+func timeStrptimePattern(buf *bytes.Buffer, format string, zone *timeZoneInfo) (string, bool) {
+	abbrs := func(names []string) []string {
+		result := make([]string, len(names))
+		for i, name := range names {
+			result[i] = name[:3]
+		}
+		return result
+	}
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v' {
+			for i+1 < len(format) && strings.IndexByte(" \t\n\r\f\v", format[i+1]) >= 0 {
+				i++
+			}
+			buf.WriteString(`\s+`)
+			continue
+		}
+		if c != '%' {
+			buf.WriteString(regexp.QuoteMeta(format[i : i+1]))
+			continue
+		}
+		if i++; i == len(format) {
+			return "", false
+		}
+		c = format[i]
+		if pattern, ok := timeStrptimeDirectives[c]; ok {
+			buf.WriteString(pattern)
+			continue
+		}
+		switch c {
+		case '%':
+			buf.WriteByte('%')
+		case 'a':
+			timeWriteNamesPattern(buf, "a", abbrs(timeDayNames))
+		case 'A':
+			timeWriteNamesPattern(buf, "A", timeDayNames)
+		case 'b':
+			timeWriteNamesPattern(buf, "b", abbrs(timeMonthNames))
+		case 'B':
+			timeWriteNamesPattern(buf, "B", timeMonthNames)
+		case 'c':
+			timeStrptimePattern(buf, "%a %b %d %H:%M:%S %Y", zone)
+		case 'p':
+			timeWriteNamesPattern(buf, "p", []string{"am", "pm"})
+		case 'x':
+			timeStrptimePattern(buf, timeDateFormat, zone)
+		case 'X':
+			timeStrptimePattern(buf, timeTimeFormat, zone)
+		case 'Z':
+			names := []string{"utc", "gmt", strings.ToLower(zone.tzname[0])}
+			if zone.daylight {
+				names = append(names, strings.ToLower(zone.tzname[1]))
+			}
+			timeWriteNamesPattern(buf, "Z", names)
+		default:
+			return string(c), false
+		}
+	}
+	return "", true
+}
+
+// timeWriteNamesPattern writes a named group matching any of names to buf.
+// Longer names are tried first so that a prefix does not match instead.
+func timeWriteNamesPattern(buf *bytes.Buffer, group string, names []string) {
+	sorted := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "" {
+			sorted = append(sorted, regexp.QuoteMeta(name))
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	fmt.Fprintf(buf, "(?P<%s>%s)", group, strings.Join(sorted, "|"))
+}
+
+// timeStrptimeTM computes the fields of a struct_time and the microseconds
+// from the lower cased values of the directives matched by strptime.
+func timeStrptimeTM(f *Frame, found map[string]string, zone *timeZoneInfo) (timeTM, int, *BaseException) {
+	tm := timeTM{year: 1900, mon: 1, mday: 1, wday: -1, yday: -1, isdst: -1}
+	microsecond := 0
+	weekOfYear, weekStartsMonday := -1, false
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(strings.TrimSpace(s))
+		return n
+	}
+	indexOf := func(s string, names []string) int {
+		for i, name := range names {
+			if name = strings.ToLower(name); s == name || s == name[:3] {
+				return i
+			}
+		}
+		return -1
+	}
+	for key, value := range found {
+		switch key {
+		case "y":
+			if tm.year = atoi(value); tm.year <= 68 {
+				tm.year += 2000
+			} else {
+				tm.year += 1900
+			}
+		case "Y":
+			tm.year = atoi(value)
+		case "m":
+			tm.mon = atoi(value)
+		case "B", "b":
+			tm.mon = indexOf(value, timeMonthNames) + 1
+		case "d":
+			tm.mday = atoi(value)
+		case "H":
+			tm.hour = atoi(value)
+		case "I":
+			tm.hour = atoi(value)
+			if found["p"] == "pm" {
+				if tm.hour != 12 {
+					tm.hour += 12
+				}
+			} else if tm.hour == 12 {
+				tm.hour = 0
+			}
+		case "M":
+			tm.min = atoi(value)
+		case "S":
+			tm.sec = atoi(value)
+		case "f":
+			microsecond = atoi((value + "00000")[:6])
+		case "A", "a":
+			tm.wday = indexOf(value, timeDayNames)
+		case "w":
+			tm.wday = (atoi(value) + 6) % 7
+		case "j":
+			tm.yday = atoi(value)
+		case "U", "W":
+			weekOfYear, weekStartsMonday = atoi(value), key == "W"
+		case "Z":
+			if value == "utc" || value == "gmt" {
+				tm.isdst = 0
+			} else if zone.daylight && value == strings.ToLower(zone.tzname[1]) {
+				// Names shared by standard and DST time are
+				// ambiguous.
+				if !strings.EqualFold(zone.tzname[0], zone.tzname[1]) {
+					tm.isdst = 1
+				}
+			} else if value == strings.ToLower(zone.tzname[0]) {
+				tm.isdst = 0
+			}
+		}
+	}
+	if tm.year < 1 || tm.year > 9999 {
+		return tm, 0, f.RaiseType(ValueErrorType, "year is out of range")
+	}
+	jan1 := time.Date(tm.year, 1, 1, 0, 0, 0, 0, time.UTC)
+	if tm.yday == -1 && weekOfYear != -1 && tm.wday != -1 {
+		firstWday, wday := (int(jan1.Weekday())+6)%7, tm.wday
+		if !weekStartsMonday {
+			firstWday, wday = (firstWday+1)%7, (wday+1)%7
+		}
+		if weekOfYear == 0 {
+			tm.yday = 1 + wday - firstWday
+		} else {
+			tm.yday = 1 + (7-firstWday)%7 + 7*(weekOfYear-1) + wday
+		}
+	}
+	var date time.Time
+	if tm.yday == -1 {
+		date = time.Date(tm.year, time.Month(tm.mon), tm.mday, 0, 0, 0, 0, time.UTC)
+		if date.Day() != tm.mday {
+			return tm, 0, f.RaiseType(ValueErrorType, "day is out of range for month")
+		}
+		tm.yday = date.YearDay()
+	} else {
+		date = jan1.AddDate(0, 0, tm.yday-1)
+		tm.year, tm.mon, tm.mday = date.Year(), int(date.Month()), date.Day()
+	}
+	if tm.wday == -1 {
+		tm.wday = (int(date.Weekday()) + 6) % 7
+	}
+	return tm, microsecond, nil
+}
+
+func timeTime(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkFunctionArgs(f, "time", args); raised != nil {
+		return nil, raised
+	}
+	return NewFloat(float64(time.Now().UnixNano()) / float64(time.Second)).ToObject(), nil
+}
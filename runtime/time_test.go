@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grumpy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormat(t *testing.T) {
+	zone := &timeZoneInfo{loc: time.UTC, timezone: 18000, altzone: 14400, daylight: true, tzname: [2]string{"EST", "EDT"}}
+	tm := newTimeTM(time.Date(2009, 2, 3, 16, 5, 6, 0, time.UTC))
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"%a %A %b %B %h", "Tue Tuesday Feb February Feb"},
+		{"%c", "Tue Feb  3 16:05:06 2009"},
+		{"%C %y %Y %G %g %V", "20 09 2009 2009 09 06"},
+		{"%d %e %j %m %D %F", "03  3 034 02 02/03/09 2009-02-03"},
+		{"%H %I %M %S %p %r %R %T", "16 04 05 06 PM 04:05:06 PM 16:05 16:05:06"},
+		{"%u %w %U %W", "2 2 05 05"},
+		{"%x %X", "02/03/09 16:05:06"},
+		{"%z %Z", "-0500 EST"},
+		{"%n%t%%%q", "\n\t%%q"},
+		{"foo%", "foo%"},
+	}
+	for _, cas := range cases {
+		if got := timeFormat(cas.format, tm, zone); got != cas.want {
+			t.Errorf("timeFormat(%q) = %q, want %q", cas.format, got, cas.want)
+		}
+	}
+}
+
+func TestTimeStrptime(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs("2009-02-03 16:05:06", "%Y-%m-%d %H:%M:%S"), want: newTestTuple(2009, 2, 3, 16, 5, 6, 1, 34, -1).ToObject()},
+		{args: wrapArgs("Tue Feb  3 16:05:06 2009"), want: newTestTuple(2009, 2, 3, 16, 5, 6, 1, 34, -1).ToObject()},
+		{args: wrapArgs("march 4, 99 4:05 am", "%B %d, %y %I:%M %p"), want: newTestTuple(1999, 3, 4, 4, 5, 0, 3, 63, -1).ToObject()},
+		{args: wrapArgs("12 pm", "%I %p"), want: newTestTuple(1900, 1, 1, 12, 0, 0, 0, 1, -1).ToObject()},
+		{args: wrapArgs("2009 5 2", "%Y %U %w"), want: newTestTuple(2009, 2, 3, 0, 0, 0, 1, 34, -1).ToObject()},
+		{args: wrapArgs("2009 034", "%Y %j"), want: newTestTuple(2009, 2, 3, 0, 0, 0, 1, 34, -1).ToObject()},
+		{args: wrapArgs("10 UTC", "%H %Z"), want: newTestTuple(1900, 1, 1, 10, 0, 0, 0, 1, 0).ToObject()},
+		{args: wrapArgs("foo", "%Y"), wantExc: mustCreateException(ValueErrorType, "time data 'foo' does not match format '%Y'")},
+		{args: wrapArgs("2009x", "%Y"), wantExc: mustCreateException(ValueErrorType, "unconverted data remains: x")},
+		{args: wrapArgs("2009", "%Y%"), wantExc: mustCreateException(ValueErrorType, "stray % in format '%Y%'")},
+		{args: wrapArgs("2009", "%Q"), wantExc: mustCreateException(ValueErrorType, "'Q' is a bad directive in format '%Q'")},
+		{args: wrapArgs("2009 2 30", "%Y %m %d"), wantExc: mustCreateException(ValueErrorType, "day is out of range for month")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(mustGetModuleAttr("time", "strptime"), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTimeStrftimeArgs(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs("%Y-%m-%d", newTestTuple(2009, 0, 0, 0, 0, 0, 0, 0, 0)), want: NewStr("2009-01-01").ToObject()},
+		{args: wrapArgs("%Y", newTestTuple(1899, 1, 1, 0, 0, 0, 0, 1, 0)), wantExc: mustCreateException(ValueErrorType, "year >= 1900 required")},
+		{args: wrapArgs("%Y", newTestTuple(2009, 13, 1, 0, 0, 0, 0, 1, 0)), wantExc: mustCreateException(ValueErrorType, "month out of range")},
+		{args: wrapArgs("%Y", newTestTuple(2009, 1, 1)), wantExc: mustCreateException(TypeErrorType, "function takes exactly 9 arguments (3 given)")},
+		{args: wrapArgs("%Y", 123), wantExc: mustCreateException(TypeErrorType, "Tuple or struct_time argument required")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(mustGetModuleAttr("time", "strftime"), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTimeLoadPOSIXZone(t *testing.T) {
+	loc, ok := timeLoadPOSIXZone("EST+05EDT,M3.2.0,M11.1.0")
+	if !ok {
+		t.Fatal("timeLoadPOSIXZone failed")
+	}
+	cases := []struct {
+		t          time.Time
+		wantName   string
+		wantOffset int
+	}{
+		{time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC), "EST", -18000},
+		{time.Date(2009, 7, 1, 0, 0, 0, 0, time.UTC), "EDT", -14400},
+	}
+	for _, cas := range cases {
+		if name, offset := cas.t.In(loc).Zone(); name != cas.wantName || offset != cas.wantOffset {
+			t.Errorf("%v.Zone() = %q, %d, want %q, %d", cas.t, name, offset, cas.wantName, cas.wantOffset)
+		}
+	}
+	if _, ok := timeLoadPOSIXZone("123"); ok {
+		t.Error(`timeLoadPOSIXZone("123") succeeded, want failure`)
+	}
+}
@@ -205,13 +205,36 @@ func tupleGetNewArgs(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
 	if raised := checkMethodArgs(f, "__getnewargs__", args, TupleType); raised != nil {
 		return nil, raised
 	}
-	return NewTuple1(args[0]).ToObject(), nil
+	o := args[0]
+	if o.typ != TupleType {
+		o = NewTuple(toTupleUnsafe(args[0]).elems...).ToObject()
+	}
+	return NewTuple1(o).ToObject(), nil
 }
 
 func tupleGT(f *Frame, v, w *Object) (*Object, *BaseException) {
 	return tupleCompare(f, toTupleUnsafe(v), w, GT)
 }
 
+func tupleHash(f *Frame, o *Object) (*Object, *BaseException) {
+	// This is the same algorithm used by CPython.
+	elems := toTupleUnsafe(o).elems
+	result, mult := 0x345678, 1000003
+	for i, elem := range elems {
+		h, raised := Hash(f, elem)
+		if raised != nil {
+			return nil, raised
+		}
+		result = (result ^ h.Value()) * mult
+		mult += 82520 + 2*(len(elems)-i-1)
+	}
+	result += 97531
+	if result == -1 {
+		result = -2
+	}
+	return NewInt(result).ToObject(), nil
+}
+
 func tupleIter(f *Frame, o *Object) (*Object, *BaseException) {
 	return newSliceIterator(reflect.ValueOf(toTupleUnsafe(o).elems)), nil
 }
@@ -295,6 +318,7 @@ func initTupleType(dict map[string]*Object) {
 	TupleType.slots.GE = &binaryOpSlot{tupleGE}
 	TupleType.slots.GetItem = &binaryOpSlot{tupleGetItem}
 	TupleType.slots.GT = &binaryOpSlot{tupleGT}
+	TupleType.slots.Hash = &unaryOpSlot{tupleHash}
 	TupleType.slots.Iter = &unaryOpSlot{tupleIter}
 	TupleType.slots.LE = &binaryOpSlot{tupleLE}
 	TupleType.slots.Len = &unaryOpSlot{tupleLen}
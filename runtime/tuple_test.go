@@ -185,6 +185,19 @@ func TestTupleGetItem(t *testing.T) {
 	}
 }
 
+func TestTupleHash(t *testing.T) {
+	cases := []invokeTestCase{
+		{args: wrapArgs(NewTuple()), want: NewInt(3527539).ToObject()},
+		{args: wrapArgs(newTestTuple(1, 2)), want: NewInt(3713081631934410656).ToObject()},
+		{args: wrapArgs(newTestTuple("foo", NewList())), wantExc: mustCreateException(TypeErrorType, "unhashable type: 'list'")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(wrapFuncForTest(tupleHash), &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
 func TestTupleLen(t *testing.T) {
 	tuple := newTestTuple("foo", 42, "bar")
 	if got := tuple.Len(); got != 3 {
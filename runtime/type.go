@@ -17,6 +17,10 @@ package grumpy
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
 )
 
 type typeFlag int
@@ -30,9 +34,46 @@ const (
 	// Set when the type can be used as a base class. This is the default.
 	// Corresponds to the Py_TPFLAGS_BASETYPE flag in CPython.
 	typeFlagBasetype typeFlag = 1 << iota
-	typeFlagDefault           = typeFlagInstantiable | typeFlagBasetype
+	// Set when instances have no __dict__ because the class and its bases
+	// declare __slots__ that don't include "__dict__".
+	typeFlagNoDict typeFlag = 1 << iota
+	// Set when instances can't be weakly referenced because the class and
+	// its bases declare __slots__ that don't include "__weakref__".
+	typeFlagNoWeakRef typeFlag = 1 << iota
+	typeFlagDefault            = typeFlagInstantiable | typeFlagBasetype
 )
 
+// typeAttrCacheSize is the number of entries in typeAttrCache. It must be a
+// power of two.
+const typeAttrCacheSize = 4096
+
+// typeAttrCacheEntry records the result of looking up name in the MRO of the
+// type whose version tag was version. A nil value means the lookup found
+// nothing. Entries are immutable once published.
+type typeAttrCacheEntry struct {
+	version uint64
+	name    *Str
+	value   *Object
+}
+
+var (
+	// typeAttrCache is a global, direct mapped cache of MRO lookups keyed
+	// by (version tag, name). It is similar to CPython's method cache.
+	typeAttrCache [typeAttrCacheSize]atomic.Pointer[typeAttrCacheEntry]
+	// typeVersionCount is the last version tag handed out to a type.
+	typeVersionCount uint64
+	// slotUpdateMutex serializes slot rewrites made by updateSlot.
+	slotUpdateMutex sync.Mutex
+	// internedSetName is interned so that typeInitSubclass's
+	// lookups of __set_name__ can be served by typeAttrCache.
+	internedSetName = InternStr("__set_name__")
+)
+
+// slotsLayoutCount is used to give each class that declares __slots__ a
+// distinct basis so that classes with incompatible layouts can't be combined
+// via multiple inheritance.
+var slotsLayoutCount int64
+
 // Type represents Python 'type' objects.
 type Type struct {
 	Object
@@ -42,6 +83,19 @@ type Type struct {
 	mro   []*Type
 	flags typeFlag
 	slots typeSlots
+	// subclasses holds references to the types that directly inherit
+	// from this one so that they can be enumerated by __subclasses__.
+	// Where supported they are weak so that subclasses are not kept
+	// alive by their bases.
+	subclasses      []typeRef
+	subclassesMutex sync.Mutex
+	// members are the descriptors for the names declared in this type's
+	// __slots__, if any.
+	members []*memberDescriptor
+	// version tags the current contents of the dicts in t's MRO for the
+	// purposes of typeAttrCache. Zero means no tag has been assigned yet.
+	// It must only be accessed atomically.
+	version uint64
 }
 
 var basisTypes = map[reflect.Type]*Type{
@@ -68,6 +122,19 @@ func newClass(f *Frame, meta *Type, name string, bases []*Type, dict *Dict) (*Ty
 		return nil, f.RaiseType(TypeErrorType, "class layout error")
 	}
 	t := newType(meta, name, basis, bases, dict)
+	if raised := prepareSlots(f, t); raised != nil {
+		return nil, raised
+	}
+	// Like CPython, __init_subclass__ is implicitly a classmethod.
+	initSubclass, raised := dict.GetItemString(f, "__init_subclass__")
+	if raised != nil {
+		return nil, raised
+	}
+	if initSubclass != nil && initSubclass.isInstance(FunctionType) {
+		if raised := dict.SetItemString(f, "__init_subclass__", newClassMethod(initSubclass).ToObject()); raised != nil {
+			return nil, raised
+		}
+	}
 	// Populate slots for any special methods overridden in dict.
 	slotsValue := reflect.ValueOf(&t.slots).Elem()
 	for i := 0; i < numSlots; i++ {
@@ -75,12 +142,11 @@ func newClass(f *Frame, meta *Type, name string, bases []*Type, dict *Dict) (*Ty
 		if raised != nil {
 			return nil, raised
 		}
-		if dictFunc != nil {
+		if dictFunc != nil && slotOverridable[i] {
 			slotField := slotsValue.Field(i)
 			slotValue := reflect.New(slotField.Type().Elem())
-			if slotValue.Interface().(slot).wrapCallable(dictFunc) {
-				slotField.Set(slotValue)
-			}
+			slotValue.Interface().(overridableSlot).wrapCallable(dictFunc)
+			slotField.Set(slotValue)
 		}
 	}
 	if err := prepareType(t); err != "" {
@@ -96,6 +162,10 @@ func newClass(f *Frame, meta *Type, name string, bases []*Type, dict *Dict) (*Ty
 			return nil, raised
 		}
 	}
+	// Like CPython, classes without a docstring get a __doc__ of None.
+	if _, raised := dict.putItem(f, NewStr("__doc__").ToObject(), None, false); raised != nil {
+		return nil, raised
+	}
 	return t, nil
 }
 
@@ -207,9 +277,137 @@ func prepareType(typ *Type) string {
 			}
 		}
 	}
+	for _, base := range typ.bases {
+		base.addSubclass(typ)
+	}
 	return ""
 }
 
+// prepareSlots handles the optional __slots__ declaration in the dict of the
+// new class t. Instances of t get inline storage for each declared name, which
+// is accessed via a member descriptor, and they have no __dict__ or weak
+// reference support unless requested or provided by a base class.
+func prepareSlots(f *Frame, t *Type) *BaseException {
+	slots, raised := t.Dict().GetItemString(f, "__slots__")
+	if raised != nil || slots == nil {
+		return raised
+	}
+	if slots.isInstance(BaseStringType) {
+		slots = NewTuple(slots).ToObject()
+	}
+	baseHasDict, baseHasWeakRef := false, false
+	for _, base := range t.bases {
+		// Basis types like object and int don't provide either of
+		// these for their instances but ordinary classes do.
+		if basisTypes[base.basis] != base {
+			baseHasDict = baseHasDict || base.flags&typeFlagNoDict == 0
+			baseHasWeakRef = baseHasWeakRef || base.flags&typeFlagNoWeakRef == 0
+		}
+	}
+	hasDict, hasWeakRef := baseHasDict, baseHasWeakRef
+	var names []string
+	raised = seqForEach(f, slots, func(o *Object) *BaseException {
+		if o.isInstance(UnicodeType) {
+			s, raised := toUnicodeUnsafe(o).Encode(f, EncodeDefault, EncodeStrict)
+			if raised != nil {
+				return raised
+			}
+			o = s.ToObject()
+		}
+		if !o.isInstance(StrType) {
+			format := "__slots__ items must be strings, not '%s'"
+			return f.RaiseType(TypeErrorType, fmt.Sprintf(format, o.typ.Name()))
+		}
+		name := toStrUnsafe(o).Value()
+		switch name {
+		case "__dict__":
+			if hasDict {
+				return f.RaiseType(TypeErrorType, "__dict__ slot disallowed: we already got one")
+			}
+			hasDict = true
+		case "__weakref__":
+			if hasWeakRef {
+				return f.RaiseType(TypeErrorType, "__weakref__ slot disallowed: we already got one")
+			}
+			hasWeakRef = true
+		default:
+			if !isIdentifier(name) {
+				return f.RaiseType(TypeErrorType, "__slots__ must be identifiers")
+			}
+			name = mangleName(t.Name(), name)
+			attr, raised := t.Dict().GetItemString(f, name)
+			if raised != nil {
+				return raised
+			}
+			if attr != nil {
+				format := "'%s' in __slots__ conflicts with class variable"
+				return f.RaiseType(ValueErrorType, fmt.Sprintf(format, name))
+			}
+			names = append(names, name)
+		}
+		return nil
+	})
+	if raised != nil {
+		return raised
+	}
+	if !hasDict {
+		t.flags |= typeFlagNoDict
+	}
+	if !hasWeakRef {
+		t.flags |= typeFlagNoWeakRef
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	// Extend the basis with an array holding the slot values. The basis
+	// of the base class remains the first field as required by
+	// basisParent.
+	objectPtrType := reflect.TypeOf((*Object)(nil))
+	tag := fmt.Sprintf(`slots:"%d"`, atomic.AddInt64(&slotsLayoutCount, 1))
+	t.basis = reflect.StructOf([]reflect.StructField{
+		{Name: "Base", Type: t.basis},
+		{Name: "Slots", Type: reflect.ArrayOf(len(names), objectPtrType), Tag: reflect.StructTag(tag)},
+	})
+	slotsField := t.basis.Field(1)
+	for i, name := range names {
+		d := newMemberDescriptor(t, name, slotsField.Offset+uintptr(i)*objectPtrType.Size())
+		if raised := t.Dict().SetItemString(f, name, d.ToObject()); raised != nil {
+			return raised
+		}
+		t.members = append(t.members, d)
+	}
+	return nil
+}
+
+// isIdentifier returns true if s is a valid Python 2 identifier.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// mangleName returns the private name mangled form of name within the class
+// className, e.g. __x in class Foo becomes _Foo__x. Names that don't start
+// with two underscores, dunder names and dotted names are returned unchanged,
+// as are all names when className consists only of underscores. This is the
+// same transformation as CPython's _Py_Mangle.
+func mangleName(className, name string) string {
+	if !strings.HasPrefix(name, "__") || strings.HasSuffix(name, "__") || strings.Contains(name, ".") {
+		return name
+	}
+	className = strings.TrimLeft(className, "_")
+	if className == "" {
+		return name
+	}
+	return "_" + className + name
+}
+
 // Precondition: At least one of seqs is non-empty.
 func mroMerge(seqs [][]*Type) []*Type {
 	var res []*Type
@@ -302,6 +500,51 @@ func (t *Type) FullName(f *Frame) (string, *BaseException) {
 	return t.Name(), nil
 }
 
+// addSubclass records sub as a direct subclass of t. Where supported only a
+// weak reference to sub is retained.
+func (t *Type) addSubclass(sub *Type) {
+	ref := makeTypeRef(sub)
+	t.subclassesMutex.Lock()
+	subclasses := t.subclasses[:0]
+	found := false
+	for _, r := range t.subclasses {
+		if r.Value() != nil {
+			subclasses = append(subclasses, r)
+			found = found || r == ref
+		}
+	}
+	if !found {
+		subclasses = append(subclasses, ref)
+	}
+	t.subclasses = subclasses
+	t.subclassesMutex.Unlock()
+}
+
+// liveSubclasses returns the direct subclasses of t that have not yet been
+// garbage collected, in the order they were created.
+func (t *Type) liveSubclasses() []*Type {
+	t.subclassesMutex.Lock()
+	var result []*Type
+	for _, r := range t.subclasses {
+		if sub := r.Value(); sub != nil {
+			result = append(result, sub)
+		}
+	}
+	t.subclassesMutex.Unlock()
+	return result
+}
+
+// hasSlotMembers returns true if t or any of its bases declares a non-empty
+// __slots__.
+func (t *Type) hasSlotMembers() bool {
+	for _, typ := range t.mro {
+		if len(typ.members) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Type) isSubclass(super *Type) bool {
 	for _, b := range t.mro {
 		if b == super {
@@ -311,7 +554,30 @@ func (t *Type) isSubclass(super *Type) bool {
 	return false
 }
 
+// mroLookup returns the first value for name found in the dicts of t's MRO,
+// or nil if there is none. Results for exact str names are cached in
+// typeAttrCache until t or one of its bases is modified.
 func (t *Type) mroLookup(f *Frame, name *Str) (*Object, *BaseException) {
+	if !name.interned {
+		// Like CPython, only interned names are cached so that
+		// lookups of dynamically created names don't evict the
+		// entries for the names used by compiled code.
+		return t.mroLookupUncached(f, name)
+	}
+	version := t.versionTag()
+	entry := &typeAttrCache[typeAttrCacheIndex(version, name)]
+	if e := entry.Load(); e != nil && e.version == version && e.name == name {
+		return e.value, nil
+	}
+	v, raised := t.mroLookupUncached(f, name)
+	if raised != nil {
+		return nil, raised
+	}
+	entry.Store(&typeAttrCacheEntry{version, name, v})
+	return v, nil
+}
+
+func (t *Type) mroLookupUncached(f *Frame, name *Str) (*Object, *BaseException) {
 	for _, t := range t.mro {
 		v, raised := t.Dict().GetItem(f, name.ToObject())
 		if v != nil || raised != nil {
@@ -321,6 +587,87 @@ func (t *Type) mroLookup(f *Frame, name *Str) (*Object, *BaseException) {
 	return nil, nil
 }
 
+// versionTag returns t's version tag, assigning a fresh one if t has been
+// modified since the last lookup.
+func (t *Type) versionTag() uint64 {
+	if v := atomic.LoadUint64(&t.version); v != 0 {
+		return v
+	}
+	v := atomic.AddUint64(&typeVersionCount, 1)
+	if !atomic.CompareAndSwapUint64(&t.version, 0, v) {
+		// Another goroutine assigned a tag first.
+		return atomic.LoadUint64(&t.version)
+	}
+	return v
+}
+
+// modified invalidates cached lookups for t and its subclasses. It must be
+// called whenever the dict of t is changed after t has been created.
+func (t *Type) modified() {
+	atomic.StoreUint64(&t.version, 0)
+	for _, sub := range t.liveSubclasses() {
+		sub.modified()
+	}
+}
+
+func typeAttrCacheIndex(version uint64, name *Str) uint64 {
+	h := uint64(uintptr(unsafe.Pointer(name))>>4) ^ version*0x9e3779b97f4a7c15
+	return (h ^ h>>32) & (typeAttrCacheSize - 1)
+}
+
+// updateSlot recomputes the slot at index i after the corresponding special
+// method has been assigned or deleted on t, and then propagates the change to
+// subclasses that inherit the method rather than defining it themselves.
+//
+// Slot rewrites are serialized by slotUpdateMutex. Readers access slots
+// without locking so each new slot is fully built before it is stored
+// atomically, and installed slots are never modified in place.
+func (t *Type) updateSlot(f *Frame, i int) *BaseException {
+	if !slotOverridable[i] {
+		// Slots like __basis__ cannot be overridden from Python.
+		return nil
+	}
+	slotUpdateMutex.Lock()
+	raised := t.updateSlotLocked(f, i)
+	slotUpdateMutex.Unlock()
+	return raised
+}
+
+// updateSlotLocked does the work of updateSlot. NOTE: slotUpdateMutex must be
+// held.
+func (t *Type) updateSlotLocked(f *Frame, i int) *BaseException {
+	slotField := reflect.ValueOf(&t.slots).Elem().Field(i)
+	newSlot := reflect.Zero(slotField.Type())
+	for _, base := range t.mro {
+		method, raised := base.Dict().GetItemString(f, slotNames[i])
+		if raised != nil {
+			return raised
+		}
+		if method != nil {
+			if base == t {
+				newSlot = reflect.New(slotField.Type().Elem())
+				newSlot.Interface().(overridableSlot).wrapCallable(method)
+			} else {
+				newSlot = reflect.ValueOf(&base.slots).Elem().Field(i)
+			}
+			break
+		}
+	}
+	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(slotField.UnsafeAddr())), newSlot.UnsafePointer())
+	for _, sub := range t.liveSubclasses() {
+		method, raised := sub.Dict().GetItemString(f, slotNames[i])
+		if raised != nil {
+			return raised
+		}
+		if method == nil {
+			if raised := sub.updateSlotLocked(f, i); raised != nil {
+				return raised
+			}
+		}
+	}
+	return nil
+}
+
 var typeBasis = reflect.TypeOf(Type{})
 
 func typeBasisFunc(o *Object) reflect.Value {
@@ -357,6 +704,17 @@ func typeCall(f *Frame, callable *Object, args Args, kwargs KWArgs) (*Object, *B
 	return o, nil
 }
 
+func typeDelAttr(f *Frame, o *Object, name *Str) *BaseException {
+	if raised := delAttrCommon(f, o, name); raised != nil {
+		return raised
+	}
+	toTypeUnsafe(o).modified()
+	if i, ok := slotIndexes[name.Value()]; ok {
+		return toTypeUnsafe(o).updateSlot(f, i)
+	}
+	return nil
+}
+
 // typeGetAttribute is very similar to objectGetAttribute except that it uses
 // MRO to resolve dict attributes rather than just the type's own dict and the
 // exception message is slightly different.
@@ -433,13 +791,71 @@ func typeNew(f *Frame, t *Type, args Args, kwargs KWArgs) (*Object, *BaseExcepti
 		}
 		baseTypes[i] = toTypeUnsafe(o)
 	}
+	if meta != t && meta.slots.New != TypeType.slots.New {
+		// The winning metaclass overrides __new__ (e.g. ABCMeta) so
+		// let it construct the class, as CPython does.
+		return meta.slots.New.Fn(f, meta, args, kwargs)
+	}
 	ret, raised := newClass(f, meta, name, baseTypes, dict)
 	if raised != nil {
 		return nil, raised
 	}
+	if raised := typeInitSubclass(f, ret); raised != nil {
+		return nil, raised
+	}
 	return ret.ToObject(), nil
 }
 
+// typeInitSubclass runs the class creation callbacks for the newly created
+// class t. First __set_name__ is called on each object in t's dict whose type
+// defines it, then the __init_subclass__ classmethod of t's nearest base is
+// called with t. This lets libraries observe class creation without needing
+// a metaclass.
+func typeInitSubclass(f *Frame, t *Type) *BaseException {
+	d := t.Dict()
+	d.mutex.Lock(f)
+	var entries []*dictEntry
+	iter := newDictEntryIterator(d)
+	for entry := iter.next(); entry != nil; entry = iter.next() {
+		entries = append(entries, entry)
+	}
+	d.mutex.Unlock(f)
+	for _, entry := range entries {
+		method, raised := entry.value.typ.mroLookup(f, internedSetName)
+		if raised != nil {
+			return raised
+		}
+		if method == nil {
+			continue
+		}
+		if get := method.typ.slots.Get; get != nil {
+			if method, raised = get.Fn(f, method, entry.value, entry.value.typ); raised != nil {
+				return raised
+			}
+		}
+		if _, raised := method.Call(f, Args{t.ToObject(), entry.key}, nil); raised != nil {
+			return raised
+		}
+	}
+	for _, base := range t.mro[1:] {
+		method, raised := base.Dict().GetItemString(f, "__init_subclass__")
+		if raised != nil {
+			return raised
+		}
+		if method == nil {
+			continue
+		}
+		if get := method.typ.slots.Get; get != nil {
+			if method, raised = get.Fn(f, method, None, t); raised != nil {
+				return raised
+			}
+		}
+		_, raised = method.Call(f, nil, nil)
+		return raised
+	}
+	return nil
+}
+
 func typeRepr(f *Frame, o *Object) (*Object, *BaseException) {
 	s, raised := toTypeUnsafe(o).FullName(f)
 	if raised != nil {
@@ -448,12 +864,97 @@ func typeRepr(f *Frame, o *Object) (*Object, *BaseException) {
 	return NewStr(fmt.Sprintf("<type '%s'>", s)).ToObject(), nil
 }
 
-func initTypeType(map[string]*Object) {
+// typeSetAttr sets the attribute like objectSetAttr but also invalidates cached
+// lookups and keeps t's slots in sync when a special method such as __eq__ is
+// assigned after the class has been created.
+func typeSetAttr(f *Frame, o *Object, name *Str, value *Object) *BaseException {
+	if raised := setAttrCommon(f, o, name, value); raised != nil {
+		return raised
+	}
+	toTypeUnsafe(o).modified()
+	if i, ok := slotIndexes[name.Value()]; ok {
+		return toTypeUnsafe(o).updateSlot(f, i)
+	}
+	return nil
+}
+
+func typeGetBases(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_bases", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	return NewTuple(typesToObjects(toTypeUnsafe(args[0]).bases)...).ToObject(), nil
+}
+
+func typeGetDict(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_dict", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	return newDictProxy(toTypeUnsafe(args[0]).Dict()).ToObject(), nil
+}
+
+func typeGetDoc(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_doc", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	doc, raised := toTypeUnsafe(args[0]).Dict().GetItemString(f, "__doc__")
+	if raised != nil {
+		return nil, raised
+	}
+	if doc == nil {
+		doc = None
+	}
+	return doc, nil
+}
+
+func typeGetMRO(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "_get_mro", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	return NewTuple(typesToObjects(toTypeUnsafe(args[0]).mro)...).ToObject(), nil
+}
+
+func typeMRO(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "mro", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	t := toTypeUnsafe(args[0])
+	mro := mroCalc(t)
+	if mro == nil {
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("mro error for: %s", t.Name()))
+	}
+	return NewList(typesToObjects(mro)...).ToObject(), nil
+}
+
+func typeSubclasses(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+	if raised := checkMethodArgs(f, "__subclasses__", args, TypeType); raised != nil {
+		return nil, raised
+	}
+	return NewList(typesToObjects(toTypeUnsafe(args[0]).liveSubclasses())...).ToObject(), nil
+}
+
+func initTypeType(dict map[string]*Object) {
 	TypeType.typ = TypeType
+	dict["__bases__"] = newProperty(newBuiltinFunction("_get_bases", typeGetBases).ToObject(), None, None).ToObject()
+	dict["__dict__"] = newProperty(newBuiltinFunction("_get_dict", typeGetDict).ToObject(), None, None).ToObject()
+	dict["__doc__"] = newProperty(newBuiltinFunction("_get_doc", typeGetDoc).ToObject(), None, None).ToObject()
+	dict["__mro__"] = newProperty(newBuiltinFunction("_get_mro", typeGetMRO).ToObject(), None, None).ToObject()
+	dict["__subclasses__"] = newBuiltinFunction("__subclasses__", typeSubclasses).ToObject()
+	dict["mro"] = newBuiltinFunction("mro", typeMRO).ToObject()
 	TypeType.slots.Call = &callSlot{typeCall}
+	TypeType.slots.DelAttr = &delAttrSlot{typeDelAttr}
 	TypeType.slots.GetAttribute = &getAttributeSlot{typeGetAttribute}
 	TypeType.slots.New = &newSlot{typeNew}
 	TypeType.slots.Repr = &unaryOpSlot{typeRepr}
+	TypeType.slots.SetAttr = &setAttrSlot{typeSetAttr}
+}
+
+// typesToObjects upcasts each of the given types to an Object.
+func typesToObjects(types []*Type) []*Object {
+	objs := make([]*Object, len(types))
+	for i, t := range types {
+		objs[i] = t.ToObject()
+	}
+	return objs
 }
 
 // basisParent returns the immediate ancestor of basis, which is its first
@@ -57,6 +57,112 @@ func TestNewClass(t *testing.T) {
 	}
 }
 
+func TestNewClassSlots(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newTestDict("__slots__", newTestTuple("a", "b")))
+	barType := newTestClass("Bar", []*Type{ObjectType}, NewDict())
+	bazType := newTestClass("Baz", []*Type{ObjectType}, newTestDict("__slots__", "c"))
+	fun := wrapFuncForTest(func(f *Frame, bases []*Type, slots *Object) (*Tuple, *BaseException) {
+		cls, raised := newClass(f, TypeType, "Qux", bases, newTestDict("__slots__", slots, "d", None))
+		if raised != nil {
+			return nil, raised
+		}
+		var members []*Object
+		for _, m := range cls.members {
+			members = append(members, NewStr(m.name).ToObject())
+		}
+		hasDict := cls.flags&typeFlagNoDict == 0
+		hasWeakRef := cls.flags&typeFlagNoWeakRef == 0
+		return newTestTuple(NewTuple(members...), hasDict, hasWeakRef), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs([]*Type{ObjectType}, NewTuple()), want: newTestTuple(NewTuple(), false, false).ToObject()},
+		{args: wrapArgs([]*Type{ObjectType}, "x"), want: newTestTuple(newTestTuple("x"), false, false).ToObject()},
+		{args: wrapArgs([]*Type{ObjectType}, NewUnicode("x")), want: newTestTuple(newTestTuple("x"), false, false).ToObject()},
+		{args: wrapArgs([]*Type{ObjectType}, newTestList("x", "__dict__", "__weakref__")), want: newTestTuple(newTestTuple("x"), true, true).ToObject()},
+		{args: wrapArgs([]*Type{ObjectType}, newTestTuple("__x", "__y__", "_z")), want: newTestTuple(newTestTuple("_Qux__x", "__y__", "_z"), false, false).ToObject()},
+		{args: wrapArgs([]*Type{fooType}, newTestTuple("x")), want: newTestTuple(newTestTuple("x"), false, false).ToObject()},
+		{args: wrapArgs([]*Type{barType}, newTestTuple("x")), want: newTestTuple(newTestTuple("x"), true, true).ToObject()},
+		{args: wrapArgs([]*Type{IntType}, newTestTuple("x")), want: newTestTuple(newTestTuple("x"), false, false).ToObject()},
+		{args: wrapArgs([]*Type{fooType, bazType}, NewTuple()), wantExc: mustCreateException(TypeErrorType, "class layout error")},
+		{args: wrapArgs([]*Type{barType}, "__dict__"), wantExc: mustCreateException(TypeErrorType, "__dict__ slot disallowed: we already got one")},
+		{args: wrapArgs([]*Type{barType}, "__weakref__"), wantExc: mustCreateException(TypeErrorType, "__weakref__ slot disallowed: we already got one")},
+		{args: wrapArgs([]*Type{ObjectType}, newTestTuple("1x")), wantExc: mustCreateException(TypeErrorType, "__slots__ must be identifiers")},
+		{args: wrapArgs([]*Type{ObjectType}, newTestTuple(123)), wantExc: mustCreateException(TypeErrorType, "__slots__ items must be strings, not 'int'")},
+		{args: wrapArgs([]*Type{ObjectType}, newTestTuple("d")), wantExc: mustCreateException(ValueErrorType, "'d' in __slots__ conflicts with class variable")},
+		{args: wrapArgs([]*Type{ObjectType}, 123), wantExc: mustCreateException(TypeErrorType, "'int' object is not iterable")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMangleName(t *testing.T) {
+	cases := []struct {
+		className string
+		name      string
+		want      string
+	}{
+		{"Foo", "__x", "_Foo__x"},
+		{"_Foo", "__x", "_Foo__x"},
+		{"__Foo", "__x_", "_Foo__x_"},
+		{"Foo", "__x__", "__x__"},
+		{"Foo", "_x", "_x"},
+		{"Foo", "x", "x"},
+		{"Foo", "__a.b", "__a.b"},
+		{"__", "__x", "__x"},
+	}
+	for _, cas := range cases {
+		if got := mangleName(cas.className, cas.name); got != cas.want {
+			t.Errorf("mangleName(%q, %q) = %q, want %q", cas.className, cas.name, got, cas.want)
+		}
+	}
+}
+
+func TestNewClassSlotsInstance(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newTestDict("__slots__", newTestTuple("a", "b")))
+	barType := newTestClass("Bar", []*Type{fooType}, newTestDict("__slots__", newTestTuple("c")))
+	bazType := newTestClass("Baz", []*Type{fooType}, NewDict())
+	fun := wrapFuncForTest(func(f *Frame, t *Type, name *Str) (*Object, *BaseException) {
+		o, raised := t.Call(f, nil, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if raised := SetAttr(f, o, name, NewInt(42).ToObject()); raised != nil {
+			return nil, raised
+		}
+		var values []*Object
+		for _, attr := range []string{"a", "b", "c"} {
+			value, raised := GetAttr(f, o, NewStr(attr), None)
+			if raised != nil {
+				return nil, raised
+			}
+			values = append(values, value)
+		}
+		return NewTuple(values...).ToObject(), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(fooType, "a"), want: newTestTuple(42, None, None).ToObject()},
+		{args: wrapArgs(fooType, "b"), want: newTestTuple(None, 42, None).ToObject()},
+		{args: wrapArgs(fooType, "c"), wantExc: mustCreateException(AttributeErrorType, "'Foo' has no attribute 'c'")},
+		{args: wrapArgs(barType, "a"), want: newTestTuple(42, None, None).ToObject()},
+		{args: wrapArgs(barType, "c"), want: newTestTuple(None, None, 42).ToObject()},
+		{args: wrapArgs(bazType, "c"), want: newTestTuple(None, None, 42).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	if d := newObject(fooType).Dict(); d != nil {
+		t.Errorf("Foo() had __dict__ %v, want nil", d)
+	}
+	if size, want := fooType.basis.Size(), objectBasis.Size()+2*reflect.TypeOf((*Object)(nil)).Size(); size != want {
+		t.Errorf("Foo basis has size %d, want %d", size, want)
+	}
+}
+
 func TestNewBasisType(t *testing.T) {
 	type basisStruct struct{ Object }
 	basisStructFunc := func(o *Object) *basisStruct { return (*basisStruct)(o.toPointer()) }
@@ -356,6 +462,331 @@ func TestTypeGetAttribute(t *testing.T) {
 	}
 }
 
+func TestTypeIntrospection(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newTestDict("__doc__", "Foo doc", "bar", 42))
+	barType := newTestClass("Bar", []*Type{StrType, fooType}, NewDict())
+	fun := wrapFuncForTest(func(f *Frame, o *Object, name *Str) (*Object, *BaseException) {
+		return GetAttr(f, o, name, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(ObjectType, "__bases__"), want: NewTuple().ToObject()},
+		{args: wrapArgs(barType, "__bases__"), want: newTestTuple(StrType, fooType).ToObject()},
+		{args: wrapArgs(fooType, "__mro__"), want: newTestTuple(fooType, ObjectType).ToObject()},
+		{args: wrapArgs(barType, "__mro__"), want: newTestTuple(barType, StrType, BaseStringType, fooType, ObjectType).ToObject()},
+		{args: wrapArgs(fooType, "__doc__"), want: NewStr("Foo doc").ToObject()},
+		{args: wrapArgs(barType, "__doc__"), want: None},
+		{args: wrapArgs(IntType, "__doc__"), want: None},
+		{args: wrapArgs(newObject(fooType), "__doc__"), want: NewStr("Foo doc").ToObject()},
+		{args: wrapArgs(newObject(barType), "__doc__"), want: None},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	f := NewRootFrame()
+	for _, name := range []string{"__bases__", "__dict__", "__doc__", "__mro__"} {
+		if raised := SetAttr(f, fooType.ToObject(), NewStr(name), None); raised == nil || !raised.isInstance(AttributeErrorType) {
+			t.Errorf("Foo.%s = None raised %v, want AttributeError", name, raised)
+		}
+	}
+}
+
+func TestTypeDict(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newTestDict("bar", 42))
+	fun := wrapFuncForTest(func(f *Frame, typ *Type, key *Str) (*Object, *BaseException) {
+		d, raised := GetAttr(f, typ.ToObject(), NewStr("__dict__"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if d.typ != dictProxyType {
+			return nil, f.RaiseType(TypeErrorType, fmt.Sprintf("__dict__ is a %s, want dictproxy", d.typ.Name()))
+		}
+		return GetItem(f, d, key.ToObject())
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(fooType, "bar"), want: NewInt(42).ToObject()},
+		{args: wrapArgs(fooType, "__module__"), want: NewStr("__builtin__").ToObject()},
+		{args: wrapArgs(fooType, "baz"), wantExc: mustCreateException(KeyErrorType, "baz")},
+		{args: wrapArgs(IntType, "__doc__"), wantExc: mustCreateException(KeyErrorType, "__doc__")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTypeMRO(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
+	barType := newTestClass("Bar", []*Type{StrType, fooType}, NewDict())
+	cases := []invokeTestCase{
+		{args: wrapArgs(ObjectType), want: newTestList(ObjectType).ToObject()},
+		{args: wrapArgs(barType), want: newTestList(barType, StrType, BaseStringType, fooType, ObjectType).ToObject()},
+		{args: wrapArgs(None), wantExc: mustCreateException(TypeErrorType, "unbound method mro() must be called with type instance as first argument (got NoneType instance instead)")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeMethodTestCase(TypeType, "mro", &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTypeSubclasses(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
+	barType := newTestClass("Bar", []*Type{fooType}, NewDict())
+	bazType := newTestClass("Baz", []*Type{fooType}, NewDict())
+	quxType := newTestClass("Qux", []*Type{barType, bazType}, NewDict())
+	cases := []invokeTestCase{
+		{args: wrapArgs(fooType), want: newTestList(barType, bazType).ToObject()},
+		{args: wrapArgs(barType), want: newTestList(quxType).ToObject()},
+		{args: wrapArgs(quxType), want: NewList().ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeMethodTestCase(TypeType, "__subclasses__", &cas); err != "" {
+			t.Error(err)
+		}
+	}
+	subclasses := IntType.liveSubclasses()
+	if len(subclasses) == 0 || subclasses[0] != BoolType {
+		t.Errorf("int.__subclasses__() = %v, want [bool]", subclasses)
+	}
+}
+
+func TestTypeSetAttrUpdatesSlots(t *testing.T) {
+	lenFunc := func(n int) *Object {
+		return newBuiltinFunction("__len__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return NewInt(n).ToObject(), nil
+		}).ToObject()
+	}
+	fun := wrapFuncForTest(func(f *Frame, setAttr bool) (*Tuple, *BaseException) {
+		fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
+		barType := newTestClass("Bar", []*Type{fooType}, NewDict())
+		bazType := newTestClass("Baz", []*Type{fooType}, newStringDict(map[string]*Object{"__len__": lenFunc(2)}))
+		if raised := SetAttr(f, fooType.ToObject(), NewStr("__len__"), lenFunc(1)); raised != nil {
+			return nil, raised
+		}
+		if !setAttr {
+			if raised := DelAttr(f, fooType.ToObject(), NewStr("__len__")); raised != nil {
+				return nil, raised
+			}
+		}
+		var lens []*Object
+		for _, typ := range []*Type{fooType, barType, bazType} {
+			n, raised := Len(f, newObject(typ))
+			if raised != nil {
+				return nil, raised
+			}
+			lens = append(lens, n.ToObject())
+		}
+		return NewTuple(lens...), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(true), want: newTestTuple(1, 1, 2).ToObject()},
+		{args: wrapArgs(false), wantExc: mustCreateException(TypeErrorType, "object of type 'Foo' has no len()")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTypeDelAttrRestoresInheritedSlot(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"__repr__": newBuiltinFunction("__repr__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return NewStr("foo").ToObject(), nil
+		}).ToObject(),
+	}))
+	barType := newTestClass("Bar", []*Type{fooType}, newStringDict(map[string]*Object{
+		"__repr__": newBuiltinFunction("__repr__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return NewStr("bar").ToObject(), nil
+		}).ToObject(),
+	}))
+	fun := wrapFuncForTest(func(f *Frame) (*Str, *BaseException) {
+		if raised := DelAttr(f, barType.ToObject(), NewStr("__repr__")); raised != nil {
+			return nil, raised
+		}
+		return Repr(f, newObject(barType))
+	})
+	if err := runInvokeTestCase(fun, &invokeTestCase{want: NewStr("foo").ToObject()}); err != "" {
+		t.Error(err)
+	}
+}
+
+func TestTypeAttrCacheInvalidation(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{"bar": NewInt(1).ToObject()}))
+	bazType := newTestClass("Baz", []*Type{fooType}, NewDict())
+	bar := InternStr("bar")
+	fun := wrapFuncForTest(func(f *Frame, modify func(*Frame) *BaseException) (*Object, *BaseException) {
+		// Populate the cache before modifying the base class.
+		if _, raised := GetAttr(f, newObject(bazType), bar, None); raised != nil {
+			return nil, raised
+		}
+		if raised := modify(f); raised != nil {
+			return nil, raised
+		}
+		return GetAttr(f, newObject(bazType), bar, None)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(func(f *Frame) *BaseException {
+			return SetAttr(f, fooType.ToObject(), bar, NewInt(2).ToObject())
+		}), want: NewInt(2).ToObject()},
+		{args: wrapArgs(func(f *Frame) *BaseException {
+			return SetAttr(f, bazType.ToObject(), bar, NewInt(3).ToObject())
+		}), want: NewInt(3).ToObject()},
+		{args: wrapArgs(func(f *Frame) *BaseException {
+			return DelAttr(f, bazType.ToObject(), bar)
+		}), want: NewInt(2).ToObject()},
+		{args: wrapArgs(func(f *Frame) *BaseException {
+			return DelAttr(f, fooType.ToObject(), bar)
+		}), want: None},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTypeAttrCacheInternedOnly(t *testing.T) {
+	f := NewRootFrame()
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{"bar": NewInt(1).ToObject()}))
+	bar := InternStr("bar")
+	if _, raised := fooType.mroLookup(f, bar); raised != nil {
+		t.Fatal(raised)
+	}
+	version := fooType.versionTag()
+	// Lookups of non-interned names, which are as numerous as the
+	// cache's entries, must not displace the interned entry.
+	for i := 0; i < 2*typeAttrCacheSize; i++ {
+		name := NewStr(fmt.Sprintf("dynamic%d", i))
+		if _, raised := fooType.mroLookup(f, name); raised != nil {
+			t.Fatal(raised)
+		}
+		if e := typeAttrCache[typeAttrCacheIndex(version, name)].Load(); e != nil && e.name == name {
+			t.Fatalf("non-interned name %q was cached", name.Value())
+		}
+	}
+	if e := typeAttrCache[typeAttrCacheIndex(version, bar)].Load(); e == nil || e.name != bar || e.version != version {
+		t.Errorf("interned entry for %q was evicted", bar.Value())
+	}
+}
+
+func TestTypeObjectSetAttrRejected(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{"bar": NewInt(1).ToObject()}))
+	bar := InternStr("bar")
+	fun := wrapFuncForTest(func(f *Frame, method *Str, args ...*Object) (*Object, *BaseException) {
+		// Populate the cache so that a stale entry would be observed.
+		if _, raised := GetAttr(f, fooType.ToObject(), bar, nil); raised != nil {
+			return nil, raised
+		}
+		m, raised := GetAttr(f, ObjectType.ToObject(), method, nil)
+		if raised != nil {
+			return nil, raised
+		}
+		if _, raised := m.Call(f, args, nil); raised != nil {
+			return nil, raised
+		}
+		return GetAttr(f, fooType.ToObject(), bar, nil)
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs("__setattr__", fooType, "bar", 2), wantExc: mustCreateException(TypeErrorType, "can't apply this __setattr__ to type object")},
+		{args: wrapArgs("__delattr__", fooType, "bar"), wantExc: mustCreateException(TypeErrorType, "can't apply this __delattr__ to type object")},
+		{args: wrapArgs("__setattr__", newObject(fooType), "bar", 2), want: NewInt(1).ToObject()},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTypeInitSubclass(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"__init_subclass__": newClassMethod(newBuiltinFunction("__init_subclass__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			return None, SetAttr(f, args[0], NewStr("registered"), args[0])
+		}).ToObject()).ToObject(),
+	}))
+	namedType := newTestClass("Named", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"__set_name__": newBuiltinFunction("__set_name__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			if raised := checkFunctionArgs(f, "__set_name__", args, ObjectType, TypeType, StrType); raised != nil {
+				return nil, raised
+			}
+			if toStrUnsafe(args[2]).Value() == "bad" {
+				return nil, f.RaiseType(ValueErrorType, "bad name")
+			}
+			return None, SetAttr(f, args[0], NewStr("name"), args[2])
+		}).ToObject(),
+	}))
+	fun := wrapFuncForTest(func(f *Frame, bases *Tuple, attr string) (*Tuple, *BaseException) {
+		named := newObject(namedType)
+		cls, raised := TypeType.Call(f, wrapArgs("Bar", bases, newStringDict(map[string]*Object{attr: named})), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		registered, raised := GetAttr(f, cls, NewStr("registered"), None)
+		if raised != nil {
+			return nil, raised
+		}
+		name, raised := GetAttr(f, named, NewStr("name"), nil)
+		if raised != nil {
+			return nil, raised
+		}
+		return NewTuple2(GetBool(registered == cls).ToObject(), name), nil
+	})
+	cases := []invokeTestCase{
+		{args: wrapArgs(newTestTuple(fooType), "foo"), want: newTestTuple(true, "foo").ToObject()},
+		{args: wrapArgs(newTestTuple(ObjectType), "foo"), want: newTestTuple(false, "foo").ToObject()},
+		{args: wrapArgs(newTestTuple(fooType), "bad"), wantExc: mustCreateException(ValueErrorType, "bad name")},
+	}
+	for _, cas := range cases {
+		if err := runInvokeTestCase(fun, &cas); err != "" {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTypeInitSubclassRegistry(t *testing.T) {
+	f := NewRootFrame()
+	subclasses := NewList()
+	// A plain function is implicitly converted to a classmethod.
+	registry := mustNotRaise(TypeType.Call(f, wrapArgs("Registry", newTestTuple(ObjectType), newStringDict(map[string]*Object{
+		"__init_subclass__": newBuiltinFunction("__init_subclass__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			name, raised := GetAttr(f, args[0], internedName, nil)
+			if raised != nil {
+				return nil, raised
+			}
+			subclasses.Append(name)
+			return None, nil
+		}).ToObject(),
+	})), nil))
+	if initSubclass := mustNotRaise(toTypeUnsafe(registry).Dict().GetItemString(f, "__init_subclass__")); !initSubclass.isInstance(ClassMethodType) {
+		t.Errorf("Registry.__dict__['__init_subclass__'] is %v, want a classmethod", initSubclass)
+	}
+	fieldType := newTestClass("Field", []*Type{ObjectType}, newStringDict(map[string]*Object{
+		"__set_name__": newBuiltinFunction("__set_name__", func(f *Frame, args Args, kwargs KWArgs) (*Object, *BaseException) {
+			if raised := SetAttr(f, args[0], NewStr("owner"), args[1]); raised != nil {
+				return nil, raised
+			}
+			return None, SetAttr(f, args[0], NewStr("name"), args[2])
+		}).ToObject(),
+	}))
+	plugin := mustNotRaise(TypeType.Call(f, wrapArgs("Plugin", NewTuple(registry), NewDict()), nil))
+	mustNotRaise(TypeType.Call(f, wrapArgs("SubPlugin", NewTuple(plugin), NewDict()), nil))
+	field := newObject(fieldType)
+	named := mustNotRaise(TypeType.Call(f, wrapArgs("NamedPlugin", NewTuple(registry), newStringDict(map[string]*Object{"field": field})), nil))
+	if want := newTestList("Plugin", "SubPlugin", "NamedPlugin").ToObject(); mustNotRaise(Eq(f, subclasses.ToObject(), want)) != True.ToObject() {
+		t.Errorf("Registry subclasses = %v, want %v", subclasses, want)
+	}
+	if owner := mustNotRaise(GetAttr(f, field, NewStr("owner"), nil)); owner != named {
+		t.Errorf("field.owner = %v, want %v", owner, named)
+	}
+	if name := mustNotRaise(GetAttr(f, field, NewStr("name"), nil)); toStrUnsafe(name).Value() != "field" {
+		t.Errorf("field.name = %v, want 'field'", name)
+	}
+}
+
 func TestTypeName(t *testing.T) {
 	fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
 	fun := wrapFuncForTest(func(f *Frame, t *Type) (*Object, *BaseException) {
@@ -392,6 +823,18 @@ func TestTypeNew(t *testing.T) {
 	if raised != nil {
 		panic(raised)
 	}
+	// class QuxMeta(type):
+	//   def __new__(meta, *args):
+	//     return 'QuxMeta.__new__'
+	quxMetaType := newTestClass("QuxMeta", []*Type{TypeType}, newStringDict(map[string]*Object{
+		"__new__": newBuiltinFunction("__new__", func(f *Frame, args Args, _ KWArgs) (*Object, *BaseException) {
+			return NewStr("QuxMeta.__new__").ToObject(), nil
+		}).ToObject(),
+	}))
+	quxType, raised := newClass(NewRootFrame(), quxMetaType, "Qux", []*Type{ObjectType}, NewDict())
+	if raised != nil {
+		panic(raised)
+	}
 	cases := []invokeTestCase{
 		{wantExc: mustCreateException(TypeErrorType, "'__new__' requires 1 arguments")},
 		{args: wrapArgs(TypeType), wantExc: mustCreateException(TypeErrorType, "type() takes 1 or 3 arguments")},
@@ -403,6 +846,9 @@ func TestTypeNew(t *testing.T) {
 		// bazMetaType so pass bazMetaType to be compared by the __eq__
 		// operator defined above.
 		{args: wrapArgs(barMetaType, "Qux", newTestTuple(barType, bazType), NewDict()), want: bazMetaType.ToObject()},
+		// The most derived metaclass's __new__ is used when it's
+		// overridden.
+		{args: wrapArgs(TypeType, "Quux", newTestTuple(quxType), NewDict()), want: NewStr("QuxMeta.__new__").ToObject()},
 	}
 	for _, cas := range cases {
 		if err := runInvokeMethodTestCase(TypeType, "__new__", &cas); err != "" {
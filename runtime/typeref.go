@@ -0,0 +1,29 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.24
+
+package grumpy
+
+import (
+	"weak"
+)
+
+// typeRef is a reference to a subclass held by its base. On toolchains that
+// provide the weak package it does not keep the subclass alive.
+type typeRef = weak.Pointer[Type]
+
+func makeTypeRef(t *Type) typeRef {
+	return weak.Make(t)
+}
@@ -0,0 +1,33 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.24
+
+package grumpy
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestTypeSubclassesWeak(t *testing.T) {
+	fooType := newTestClass("Foo", []*Type{ObjectType}, NewDict())
+	func() {
+		newTestClass("Bar", []*Type{fooType}, NewDict())
+	}()
+	runtime.GC()
+	if subclasses := fooType.liveSubclasses(); len(subclasses) != 0 {
+		t.Errorf("Foo.__subclasses__() = %v, want []", subclasses)
+	}
+}
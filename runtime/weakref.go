@@ -113,6 +113,10 @@ func weakRefNew(f *Frame, t *Type, args Args, _ KWArgs) (*Object, *BaseException
 		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, argc))
 	}
 	o := args[0]
+	if o.typ.flags&typeFlagNoWeakRef != 0 {
+		format := "cannot create weak reference to '%s' object"
+		return nil, f.RaiseType(TypeErrorType, fmt.Sprintf(format, o.typ.Name()))
+	}
 	nilPtr := unsafe.Pointer(nil)
 	addr := (*unsafe.Pointer)(unsafe.Pointer(&o.ref))
 	var r *WeakRef
@@ -65,12 +65,14 @@ func TestWeakRefHash(t *testing.T) {
 }
 
 func TestWeakRefNew(t *testing.T) {
+	slotsType := newTestClass("Slots", []*Type{ObjectType}, newTestDict("__slots__", NewTuple()))
 	alive := NewStr("foo").ToObject()
 	aliveRef := newTestWeakRef(alive, nil)
 	cases := []invokeTestCase{
 		{args: wrapArgs(alive), want: aliveRef.ToObject()},
 		{wantExc: mustCreateException(TypeErrorType, "'__new__' requires 1 arguments")},
 		{args: wrapArgs("foo", "bar", "baz"), wantExc: mustCreateException(TypeErrorType, "__new__ expected at most 2 arguments, got 3")},
+		{args: wrapArgs(newObject(slotsType)), wantExc: mustCreateException(TypeErrorType, "cannot create weak reference to 'Slots' object")},
 	}
 	for _, cas := range cases {
 		if err := runInvokeTestCase(WeakRefType.ToObject(), &cas); err != "" {
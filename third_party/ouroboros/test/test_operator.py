@@ -135,7 +135,6 @@ class OperatorTestCase(unittest.TestCase):
         self.assertTrue(operator.countOf([1, 2, 1, 3, 1, 4], 3) == 1)
         self.assertTrue(operator.countOf([1, 2, 1, 3, 1, 4], 5) == 0)
 
-    @unittest.expectedFailure
     def test_delitem(self):
         #operator = self.module
         a = [4, 3, 2, 1]
@@ -146,8 +146,7 @@ class Dialect(object):
                 if converter:
                     value = converter(value)
 
-            # setattr(self, '_' + name, value)
-            self.__dict__['_' + name] = value
+            setattr(self, '_' + name, value)
 
         if not self.delimiter:
             raise TypeError("delimiter must be set")
@@ -55,7 +55,7 @@ bytes = str
 
 
 def unpack_int(data, index, size, le):
-  _bytes = [b for b in data[index:index + size]]
+  _bytes = [ord(b) for b in data[index:index + size]]
   if le == 'little':
     _bytes.reverse()
   number = 0
@@ -85,8 +85,13 @@ def bisect_left(a, x, lo=0, hi=None):
         else: hi = mid
     return lo
 
-# Overwrite above definitions with a fast C implementation
-# try:
-#     from _bisect import *
-# except ImportError:
-#     pass
+# Overwrite above definitions with a fast Go implementation
+try:
+    import _bisect
+except ImportError:
+    pass
+else:
+    bisect = bisect_right = _bisect.bisect_right
+    bisect_left = _bisect.bisect_left
+    insort = insort_right = _bisect.insort_right
+    insort_left = _bisect.insort_left
@@ -21,8 +21,7 @@ for name in _csv.__all__:
 # from _csv import Dialect as _Dialect
 _Dialect = _csv.Dialect
 
-import StringIO as _StringIO
-StringIO = _StringIO.StringIO
+from cStringIO import StringIO
 # try:
 #     from cStringIO import StringIO
 # except ImportError:
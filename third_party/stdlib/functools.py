@@ -7,13 +7,19 @@
 #   Copyright (C) 2006 Python Software Foundation.
 # See C source code for _functools credits/copyright
 
-# from _functools import partial, reduce
+# from _functools import cmp_to_key, partial, reduce
 import _functools
+cmp_to_key = _functools.cmp_to_key
 partial = _functools.partial
 reduce = _functools.reduce
 
 def setattr(d, k, v):
-  d.__dict__[k] = v
+  # Class __dict__ is a read-only proxy so types must go through __setattr__,
+  # which also keeps the class's special method slots up to date.
+  if isinstance(d, type):
+    type.__setattr__(d, k, v)
+  else:
+    d.__dict__[k] = v
 
 # update_wrapper() and wraps() are tools to help write
 # wrapper functions that can handle naive introspection
@@ -82,25 +88,3 @@ def total_ordering(cls):
             opfunc.__doc__ = getattr(int, opname).__doc__
             setattr(cls, opname, opfunc)
     return cls
-
-def cmp_to_key(mycmp):
-    """Convert a cmp= function into a key= function"""
-    class K(object):
-        __slots__ = ['obj']
-        def __init__(self, obj, *args):
-            self.obj = obj
-        def __lt__(self, other):
-            return mycmp(self.obj, other.obj) < 0
-        def __gt__(self, other):
-            return mycmp(self.obj, other.obj) > 0
-        def __eq__(self, other):
-            return mycmp(self.obj, other.obj) == 0
-        def __le__(self, other):
-            return mycmp(self.obj, other.obj) <= 0
-        def __ge__(self, other):
-            return mycmp(self.obj, other.obj) >= 0
-        def __ne__(self, other):
-            return mycmp(self.obj, other.obj) != 0
-        def __hash__(self):
-            raise TypeError('hash not implemented')
-    return K
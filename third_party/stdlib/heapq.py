@@ -355,11 +355,19 @@ def _siftup_max(heap, pos):
     heap[pos] = newitem
     _siftdown_max(heap, startpos, pos)
 
-# If available, use C implementation
-#try:
-#    import _heapq
-#except ImportError:
-#    pass
+# If available, use Go implementation
+try:
+    import _heapq
+except ImportError:
+    pass
+else:
+    heapify = _heapq.heapify
+    heappop = _heapq.heappop
+    heappush = _heapq.heappush
+    heappushpop = _heapq.heappushpop
+    heapreplace = _heapq.heapreplace
+    nlargest = _heapq.nlargest
+    nsmallest = _heapq.nsmallest
 
 def merge(*iterables):
     '''Merge multiple sorted inputs into a single sorted output.
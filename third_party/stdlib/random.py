@@ -41,11 +41,13 @@ General notes on the underlying Mersenne Twister core generator:
 
 #from warnings import warn as _warn
 #from types import MethodType as _MethodType, BuiltinMethodType as _BuiltinMethodType
-#from math import log as _log, exp as _exp, pi as _pi, e as _e, ceil as _ceil
+#from math import exp as _exp, pi as _pi, e as _e, ceil as _ceil
+from math import log as _log
 #from math import sqrt as _sqrt, acos as _acos, cos as _cos, sin as _sin
 #from os import urandom as _urandom
 #from binascii import hexlify as _hexlify
 #import hashlib as _hashlib
+from _sha512 import sha512 as _sha512
 
 __all__ = ["Random","seed","random","uniform","randint","choice","sample",
            "randrange","shuffle","normalvariate","lognormvariate",
@@ -54,20 +56,17 @@ __all__ = ["Random","seed","random","uniform","randint","choice","sample",
            "getstate","setstate","jumpahead", "WichmannHill", "getrandbits",
            "SystemRandom"]
 
-# Import _random wrapper from grumpy std lib.
-# It is used as a replacement for the CPython random generator.
-
 import _random
 
 # NV_MAGICCONST = 4 * _exp(-0.5)/_sqrt(2.0)
 # TWOPI = 2.0*_pi
 # LOG4 = _log(4.0)
 # SG_MAGICCONST = 1.0 + _log(4.5)
-BPF = _random.BPF
-RECIP_BPF = _random.RECIP_BPF
+BPF = 53        # Number of bits in a float
+RECIP_BPF = 2**-BPF
 
 
-class Random(_random.GrumpyRandom):
+class Random(_random.Random):
     """Random number generator base class used by bound module functions.
 
     Used to instantiate instances of Random to get generators that don't
@@ -109,9 +108,58 @@ class Random(_random.GrumpyRandom):
         super(Random, self).seed(a)
         self.gauss_next = None
 
+    def getstate(self):
+        """Return internal state; can be passed to setstate() later."""
+        return self.VERSION, super(Random, self).getstate(), self.gauss_next
+
+    def setstate(self, state):
+        """Restore internal state from object returned by getstate()."""
+        version = state[0]
+        if version == 3:
+            version, internalstate, self.gauss_next = state
+            super(Random, self).setstate(internalstate)
+        elif version == 2:
+            version, internalstate, self.gauss_next = state
+            # In version 2, the state was saved as signed ints, which causes
+            #   inconsistencies between 32/64-bit systems. The state is
+            #   really unsigned 32-bit ints, so we convert negative ints from
+            #   version 2 to positive longs for version 3.
+            try:
+                internalstate = tuple( long(x) % (2**32) for x in internalstate )
+            except ValueError, e:
+                raise TypeError, e
+            super(Random, self).setstate(internalstate)
+        else:
+            raise ValueError("state with version %s passed to "
+                             "Random.setstate() of version %s" %
+                             (version, self.VERSION))
+
+    def jumpahead(self, n):
+        """Change the internal state to one that is likely far away
+        from the current state.  This method will not be in Py3.x,
+        so it is better to simply reseed.
+        """
+        # The super.jumpahead() method uses shuffling to change state,
+        # so it needs a large and "interesting" n to work with.  Here,
+        # we use hashing to create a large n for the shuffle.
+        s = repr(n) + repr(self.getstate())
+        n = int(_sha512(s).hexdigest(), 16)
+        super(Random, self).jumpahead(n)
+
 ## ---- Methods below this point do not need to be overridden when
 ## ---- subclassing for the purpose of using a different core generator.
 
+## -------------------- pickle support  -------------------
+
+    def __getstate__(self): # for pickle
+        return self.getstate()
+
+    def __setstate__(self, state):  # for pickle
+        self.setstate(state)
+
+    def __reduce__(self):
+        return self.__class__, (), self.getstate()
+
 ## -------------------- integer methods  -------------------
 
     def randrange(self, start, stop=None, step=1, _int=int, _maxwidth=1L<<BPF):
@@ -183,6 +231,21 @@ class Random(_random.GrumpyRandom):
 
         return self.randrange(a, b+1)
 
+    def _randbelow(self, n, _log=_log, _int=int):
+        """Return a random int in the range [0,n)
+
+        Handles the case where n has more bits than returned
+        by a single call to the underlying generator.
+        """
+
+        # _random.Random always supplies getrandbits() so unlike CPython,
+        # don't fall back to random() when it is missing.
+        k = _int(1.00001 + _log(n-1, 2.0))   # 2**k > n-1 > 2**(k-2)
+        r = self.getrandbits(k)
+        while r >= n:
+            r = self.getrandbits(k)
+        return r
+
 ## -------------------- sequence methods  -------------------
 
     def choice(self, seq):
@@ -622,6 +685,7 @@ randrange = _inst.randrange
 getrandbits = _inst.getrandbits
 getstate = _inst.getstate
 setstate = _inst.setstate
+jumpahead = _inst.jumpahead
 uniform = _inst.uniform
 
 
@@ -9,7 +9,7 @@ UserList = _UserList.UserList
 # and the Python implementation of the module.
 
 # Make it impossible to import the C implementation anymore.
-sys.modules['_bisect'] = 0
+sys.modules['_bisect'] = None
 # We must also handle the case that bisect was imported before.
 if 'bisect' in sys.modules:
     del sys.modules['bisect']
@@ -22,7 +22,7 @@ del sys.modules['_bisect']
 del sys.modules['bisect']
 
 # This is now the module with the C implementation.
-#import bisect as c_bisect
+import bisect as c_bisect
 
 
 class Range(object):
@@ -48,8 +48,7 @@ class Range(object):
 
 
 class TestBisect(unittest.TestCase):
-    # module = None
-    module = py_bisect
+    module = None
 
     def setUp(self):
         self.precomputedCases = [
@@ -227,17 +226,16 @@ class TestBisect(unittest.TestCase):
         self.module.insort(a=data, x=25, lo=1, hi=3)
         self.assertEqual(data, [10, 20, 25, 25, 25, 30, 40, 50])
 
-# class TestBisectPython(TestBisect):
-#     module = py_bisect
+class TestBisectPython(TestBisect):
+    module = py_bisect
 
-# class TestBisectC(TestBisect):
-#     module = c_bisect
+class TestBisectC(TestBisect):
+    module = c_bisect
 
 #==============================================================================
 
 class TestInsort(unittest.TestCase):
-    # module = None
-    module = py_bisect
+    module = None
 
     def test_vsBuiltinSort(self, n=500):
         #from random import choice
@@ -268,11 +266,11 @@ class TestInsort(unittest.TestCase):
         self.module.insort_right(lst, 5)
         self.assertEqual([5, 10], lst.data)
 
-# class TestInsortPython(TestInsort):
-#     module = py_bisect
+class TestInsortPython(TestInsort):
+    module = py_bisect
 
-# class TestInsortC(TestInsort):
-#     module = c_bisect
+class TestInsortC(TestInsort):
+    module = c_bisect
 
 #==============================================================================
 
@@ -302,8 +300,7 @@ class CmpErr(object):
         raise ZeroDivisionError
 
 class TestErrorHandling(unittest.TestCase):
-    # module = None
-    module = py_bisect
+    module = None
 
     def test_non_sequence(self):
         for f in (self.module.bisect_left, self.module.bisect_right,
@@ -331,11 +328,11 @@ class TestErrorHandling(unittest.TestCase):
                   self.module.insort_left, self.module.insort_right):
             self.assertRaises(TypeError, f, 10)
 
-# class TestErrorHandlingPython(TestErrorHandling):
-#     module = py_bisect
+class TestErrorHandlingPython(TestErrorHandling):
+    module = py_bisect
 
-# class TestErrorHandlingC(TestErrorHandling):
-#     module = c_bisect
+class TestErrorHandlingC(TestErrorHandling):
+    module = c_bisect
 
 #==============================================================================
 
@@ -364,10 +361,9 @@ __test__ = {'libreftest' : libreftest}
 def test_main(verbose=None):
     # from test import test_bisect
 
-    # test_classes = [TestBisectPython, TestBisectC,
-    #                 TestInsortPython, TestInsortC,
-    #                 TestErrorHandlingPython, TestErrorHandlingC]
-    test_classes = [TestBisect, TestInsort, TestErrorHandling]
+    test_classes = [TestBisectPython, TestBisectC,
+                    TestInsortPython, TestInsortC,
+                    TestErrorHandlingPython, TestErrorHandlingC]
 
     test_support.run_unittest(*test_classes)
     # test_support.run_doctest(test_bisect, verbose)